@@ -173,11 +173,13 @@ if [ "$opttemplate" = true ]; then
   descriptor_set="_proto.descriptor_set"
   handler_gen_go="_handler.gen.go"
   instance_proto="_instance.proto"
+  handler_service="_handler_service.proto"
   pb_go=".pb.go"
 
   templateDS=${template/.proto/$descriptor_set}
   templateHG=${template/.proto/$handler_gen_go}
   templateIP=${template/.proto/$instance_proto}
+  templateHS=${template/.proto/$handler_service}
   templatePG=${template/.proto/$pb_go}
 
   # generate the descriptor set for the intermediate artifacts
@@ -187,7 +189,7 @@ if [ "$opttemplate" = true ]; then
     die "template generation failure: $err"; 
   fi
   
-  go run $GOPATH/src/istio.io/istio/mixer/tools/codegen/cmd/mixgenproc/main.go $templateDS -o $templateHG -t $templateIP $TMPL_GEN_MAP  
+  go run $GOPATH/src/istio.io/istio/mixer/tools/codegen/cmd/mixgenproc/main.go $templateDS -o $templateHG -t $templateIP -s $templateHS $TMPL_GEN_MAP  
 
   err=`$protoc $IMPORTS $TMPL_PLUGIN $templateIP`
   if [ ! -z "$err" ]; then 
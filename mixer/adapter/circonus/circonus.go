@@ -193,6 +193,7 @@ func (h *handler) Close() error {
 func GetInfo() adapter.Info {
 	return adapter.Info{
 		Name:        "circonus",
+		Impl:        "istio.io/istio/mixer/adapter/circonus",
 		Description: "Emit metrics to Circonus.com monitoring endpoint",
 		SupportedTemplates: []string{
 			metric.TemplateName,
@@ -14,14 +14,18 @@
 package circonus
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	cgm "github.com/circonus-labs/circonus-gometrics"
 	"github.com/circonus-labs/circonus-gometrics/checkmgr"
+	"github.com/gogo/protobuf/proto"
 	"golang.org/x/net/context"
 
 	"istio.io/istio/mixer/adapter/circonus/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/metric"
 )
@@ -171,6 +175,41 @@ func TestCirconusHandleMetrics(t *testing.T) {
 	}
 }
 
+func TestConformance(t *testing.T) {
+	trap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// circonus-gometrics keeps its connections to the broker open, closing them lets the leak check see the
+		// goroutines of the handlers only.
+		w.Header().Set("Connection", "close")
+		_, _ = w.Write([]byte(`{"stats":1}`))
+	}))
+	defer trap.Close()
+
+	cfg := makeConfig(counterInfo, histogramInfo, gaugeInfo)
+	cfg.SubmissionUrl = trap.URL + "/module/httptrap/myuuid/mysecret"
+
+	invalidURL := makeConfig(counterInfo, histogramInfo, gaugeInfo)
+	invalidURL.SubmissionUrl = "this is not a url"
+	invalidInterval := makeConfig(counterInfo, histogramInfo, gaugeInfo)
+	invalidInterval.SubmissionInterval = 100 * time.Millisecond
+	missingMetric := makeConfig(counterInfo, histogramInfo)
+
+	test.RunConformance(t, test.Conformance{
+		Info:           GetInfo(),
+		Config:         cfg,
+		InvalidConfigs: []adapter.Config{invalidURL, invalidInterval, missingMetric},
+		Types: map[string]map[string]proto.Message{
+			metric.TemplateName: {
+				counterInfo.Name:   &metric.Type{},
+				histogramInfo.Name: &metric.Type{},
+				gaugeInfo.Name:     &metric.Type{},
+			},
+		},
+		Instances: map[string][]interface{}{
+			metric.TemplateName: {counterInstance, histogramInstance, gaugeInstance},
+		},
+	})
+}
+
 func makeConfig(metrics ...*config.Params_MetricInfo) *config.Params {
 	return &config.Params{
 		SubmissionUrl:      "https://trap.noit.circonus.net/module/httptrap/myuuid/mysecret",
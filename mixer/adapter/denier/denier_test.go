@@ -99,3 +99,14 @@ func contains(s []string, e string) bool {
 	}
 	return false
 }
+
+func TestConformance(t *testing.T) {
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Instances: map[string][]interface{}{
+			checknothing.TemplateName: {&checknothing.Instance{Name: "checknothing"}},
+			listentry.TemplateName:    {&listentry.Instance{Name: "listentry", Value: "foo"}},
+			quota.TemplateName:        {&quota.Instance{Name: "quota", Dimensions: map[string]interface{}{"source": "foo"}}},
+		},
+	})
+}
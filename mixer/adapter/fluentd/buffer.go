@@ -0,0 +1,229 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var errBufferFull = errors.New("buffer file is full")
+
+// fileBuffer is a FIFO queue of records backed by a file. Records are
+// appended at the end of the file and read from a read offset, and the file
+// is truncated once all of its records have been read. Records left in the
+// file when it is closed are picked up again when it is reopened.
+//
+// Each record is stored as the uvarint encoded length of its tag, the tag,
+// the uvarint encoded length of its data and the data.
+type fileBuffer struct {
+	lock    sync.Mutex
+	file    *os.File
+	maxSize int64
+	read    int64 // offset of the first record not yet committed
+	write   int64 // offset past the last record
+}
+
+func openFileBuffer(path string, maxSize int64) (*fileBuffer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	b := &fileBuffer{file: f, maxSize: maxSize}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	// find the end of the last complete record, a previous process may
+	// have stopped while writing one.
+	r := &countingReader{r: bufio.NewReader(io.NewSectionReader(f, 0, info.Size()))}
+	for {
+		if _, err = b.readRecord(r); err != nil {
+			break
+		}
+		b.write = r.n
+	}
+	if err = f.Truncate(b.write); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// push appends a record to the buffer.
+func (b *fileBuffer) push(rec record) error {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+len(rec.tag)+len(rec.data))
+	buf = appendUvarint(buf, uint64(len(rec.tag)))
+	buf = append(buf, rec.tag...)
+	buf = appendUvarint(buf, uint64(len(rec.data)))
+	buf = append(buf, rec.data...)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.write+int64(len(buf)) > b.maxSize {
+		return errBufferFull
+	}
+	if _, err := b.file.WriteAt(buf, b.write); err != nil {
+		return err
+	}
+	b.write += int64(len(buf))
+	return nil
+}
+
+// peek returns up to n records from the head of the buffer, along with the
+// offset to commit once they have been consumed.
+func (b *fileBuffer) peek(n int) ([]record, int64, error) {
+	b.lock.Lock()
+	read, write := b.read, b.write
+	b.lock.Unlock()
+
+	r := &countingReader{r: bufio.NewReader(io.NewSectionReader(b.file, read, write-read))}
+	var records []record
+	for len(records) < n && read+r.n < write {
+		rec, err := b.readRecord(r)
+		if err != nil {
+			return nil, read, err
+		}
+		records = append(records, rec)
+	}
+	return records, read + r.n, nil
+}
+
+// commit removes the records before the given offset from the buffer.
+func (b *fileBuffer) commit(offset int64) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if offset < b.write {
+		b.read = offset
+		return nil
+	}
+	b.read, b.write = 0, 0
+	return b.file.Truncate(0)
+}
+
+// reset removes all records from the buffer.
+func (b *fileBuffer) reset() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.read, b.write = 0, 0
+	return b.file.Truncate(0)
+}
+
+// empty returns whether all records of the buffer have been committed.
+func (b *fileBuffer) empty() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.read >= b.write
+}
+
+// close compacts the file to the records which have not been committed, and
+// closes it.
+func (b *fileBuffer) close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.read > 0 {
+		if err := b.compact(); err != nil {
+			_ = b.file.Close()
+			return err
+		}
+	}
+	return b.file.Close()
+}
+
+// compact moves the records which have not been committed to the start of the file.
+func (b *fileBuffer) compact() error {
+	buf := make([]byte, 32*1024)
+	var n int64
+	for src := b.read; src < b.write; src += int64(len(buf)) {
+		chunk := buf
+		if rest := b.write - src; rest < int64(len(chunk)) {
+			chunk = chunk[:rest]
+		}
+		if _, err := b.file.ReadAt(chunk, src); err != nil {
+			return err
+		}
+		if _, err := b.file.WriteAt(chunk, n); err != nil {
+			return err
+		}
+		n += int64(len(chunk))
+	}
+	b.read, b.write = 0, n
+	return b.file.Truncate(n)
+}
+
+func (b *fileBuffer) readRecord(r *countingReader) (record, error) {
+	tag, err := b.readField(r)
+	if err != nil {
+		return record{}, err
+	}
+	data, err := b.readField(r)
+	if err != nil {
+		return record{}, err
+	}
+	return record{tag: string(tag), data: data}, nil
+}
+
+func (b *fileBuffer) readField(r *countingReader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(b.maxSize) {
+		return nil, fmt.Errorf("invalid record length %d in buffer file %s", l, b.file.Name())
+	}
+	field := make([]byte, l)
+	if _, err = io.ReadFull(r, field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+// countingReader keeps track of the number of bytes read from a reader.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
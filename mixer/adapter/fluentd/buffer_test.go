@@ -0,0 +1,93 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fluentd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "buffer")
+
+	b, err := openFileBuffer(path, 20)
+	if err != nil {
+		t.Fatalf("Unable to open buffer file: %v", err)
+	}
+	if !b.empty() {
+		t.Error("Got a non-empty buffer, want it empty")
+	}
+
+	records := []record{
+		{tag: "a", data: []byte("123")},
+		{tag: "b", data: []byte("456")},
+		{tag: "c", data: []byte("789")},
+	}
+	for _, r := range records {
+		if err = b.push(r); err != nil {
+			t.Fatalf("Got error %v, expecting success", err)
+		}
+	}
+	if err = b.push(record{tag: "d", data: []byte("0")}); err != errBufferFull {
+		t.Errorf("Got %v, want %v", err, errBufferFull)
+	}
+
+	got, offset, err := b.peek(2)
+	if err != nil || !reflect.DeepEqual(got, records[:2]) {
+		t.Errorf("Got %v, %v, want %v", got, err, records[:2])
+	}
+	if err = b.commit(offset); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if err = b.close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+
+	// append a partially written record, which is dropped when reopening
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{1, 'e', 5, '1'})
+	_ = f.Close()
+
+	if b, err = openFileBuffer(path, 20); err != nil {
+		t.Fatalf("Unable to open buffer file: %v", err)
+	}
+	got, offset, err = b.peek(10)
+	if err != nil || !reflect.DeepEqual(got, records[2:]) {
+		t.Errorf("Got %v, %v, want %v", got, err, records[2:])
+	}
+	if err = b.commit(offset); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if !b.empty() {
+		t.Error("Got a non-empty buffer, want it empty")
+	}
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("Got a buffer file of %d bytes, want it truncated", info.Size())
+	}
+	if err = b.close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+}
@@ -19,9 +19,15 @@ import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
 import _ "github.com/gogo/protobuf/gogoproto"
+import _ "github.com/gogo/protobuf/types"
+
+import time "time"
+
+import github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
 
 import strings "strings"
 import reflect "reflect"
+import github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 
 import io "io"
 
@@ -29,6 +35,7 @@ import io "io"
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -42,19 +49,81 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 // those logentries to a listening fluentd daemon with minimal
 // transformation. Fluentd uses a "tag" for all logs. The "Name" of
 // the logentry is used as the "tag", unless the logentry already has
-// a variable "tag".
+// a variable "tag", or a tag template is configured for it.
+//
+// Log entries are buffered in memory and forwarded to fluentd in batches.
+// While fluentd is unavailable, entries that do not fit in memory are
+// spilled to an optional buffer file, and are dropped once that is full
+// as well.
+//
+// Example configuration:
+//
+// address: fluentd-server:24224
+// tagTemplates:
+//   accesslog: "istio.{{.destinationService}}"
+// batchSize: 100
+// flushInterval: 1s
+// bufferSize: 10000
+// bufferPath: /var/lib/istio/fluentd.buffer
+// tls:
+//   caCertificates: /etc/certs/root-cert.pem
 type Params struct {
 	// Address of listening fluentd daemon. Example: fluentd-server:24224
 	// Default value is localhost:24224
 	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Tag templates, keyed by the name of the logentry instance they apply to.
+	// Templates use the Go text/template syntax, and are executed against the
+	// variables of the instance as well as its "name" and "severity". For
+	// example: "istio.{{.destinationService}}.{{.severity}}".
+	TagTemplates map[string]string `protobuf:"bytes,2,rep,name=tag_templates,json=tagTemplates" json:"tag_templates,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Maximum number of log entries forwarded to fluentd in a single message.
+	// Default value is 100.
+	BatchSize int64 `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	// Maximum amount of time log entries wait in the buffer before they are
+	// forwarded to fluentd. Default value is 1 second.
+	FlushInterval time.Duration `protobuf:"bytes,4,opt,name=flush_interval,json=flushInterval,stdduration" json:"flush_interval"`
+	// Maximum number of log entries buffered in memory. Default value is 10000.
+	BufferSize int64 `protobuf:"varint,5,opt,name=buffer_size,json=bufferSize,proto3" json:"buffer_size,omitempty"`
+	// Path of a file that log entries are spilled to when the in-memory buffer
+	// is full. If empty, such log entries are dropped.
+	BufferPath string `protobuf:"bytes,6,opt,name=buffer_path,json=bufferPath,proto3" json:"buffer_path,omitempty"`
+	// Maximum size in bytes of the buffer file. Default value is 100MiB.
+	MaxBufferFileSize int64 `protobuf:"varint,7,opt,name=max_buffer_file_size,json=maxBufferFileSize,proto3" json:"max_buffer_file_size,omitempty"`
+	// Settings for connecting to fluentd over TLS. If not specified, a plain
+	// TCP connection is used.
+	Tls *Params_TLS `protobuf:"bytes,8,opt,name=tls" json:"tls,omitempty"`
+	// Timeout for connecting and writing to fluentd. Default value is 5 seconds.
+	Timeout time.Duration `protobuf:"bytes,9,opt,name=timeout,stdduration" json:"timeout"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
 func (*Params) ProtoMessage()               {}
 func (*Params) Descriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0} }
 
+// TLS settings for the connection to fluentd.
+type Params_TLS struct {
+	// Path of the file holding the CA certificates used to verify fluentd. If
+	// empty, the host's root CA set is used.
+	CaCertificates string `protobuf:"bytes,1,opt,name=ca_certificates,json=caCertificates,proto3" json:"ca_certificates,omitempty"`
+	// Path of the file holding the client certificate presented to fluentd.
+	ClientCertificate string `protobuf:"bytes,2,opt,name=client_certificate,json=clientCertificate,proto3" json:"client_certificate,omitempty"`
+	// Path of the file holding the private key of the client certificate.
+	PrivateKey string `protobuf:"bytes,3,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
+	// Server name used to verify the certificate of fluentd. Defaults to the
+	// host in the address.
+	ServerName string `protobuf:"bytes,4,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	// Skip the verification of the certificate of fluentd. Only meant for
+	// testing.
+	InsecureSkipVerify bool `protobuf:"varint,5,opt,name=insecure_skip_verify,json=insecureSkipVerify,proto3" json:"insecure_skip_verify,omitempty"`
+}
+
+func (m *Params_TLS) Reset()                    { *m = Params_TLS{} }
+func (*Params_TLS) ProtoMessage()               {}
+func (*Params_TLS) Descriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0, 1} }
+
 func init() {
 	proto.RegisterType((*Params)(nil), "adapter.fluentd.config.Params")
+	proto.RegisterType((*Params_TLS)(nil), "adapter.fluentd.config.Params.TLS")
 }
 func (m *Params) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -77,6 +146,122 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintConfig(dAtA, i, uint64(len(m.Address)))
 		i += copy(dAtA[i:], m.Address)
 	}
+	if len(m.TagTemplates) > 0 {
+		for k, _ := range m.TagTemplates {
+			dAtA[i] = 0x12
+			i++
+			v := m.TagTemplates[k]
+			mapSize := 1 + len(k) + sovConfig(uint64(len(k))) + 1 + len(v) + sovConfig(uint64(len(v)))
+			i = encodeVarintConfig(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintConfig(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintConfig(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
+	if m.BatchSize != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.BatchSize))
+	}
+	dAtA[i] = 0x22
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.FlushInterval)))
+	n1, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.FlushInterval, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n1
+	if m.BufferSize != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.BufferSize))
+	}
+	if len(m.BufferPath) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.BufferPath)))
+		i += copy(dAtA[i:], m.BufferPath)
+	}
+	if m.MaxBufferFileSize != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.MaxBufferFileSize))
+	}
+	if m.Tls != nil {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.Tls.Size()))
+		n2, err := m.Tls.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
+	dAtA[i] = 0x4a
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)))
+	n3, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n3
+	return i, nil
+}
+
+func (m *Params_TLS) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Params_TLS) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.CaCertificates) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.CaCertificates)))
+		i += copy(dAtA[i:], m.CaCertificates)
+	}
+	if len(m.ClientCertificate) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.ClientCertificate)))
+		i += copy(dAtA[i:], m.ClientCertificate)
+	}
+	if len(m.PrivateKey) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.PrivateKey)))
+		i += copy(dAtA[i:], m.PrivateKey)
+	}
+	if len(m.ServerName) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.ServerName)))
+		i += copy(dAtA[i:], m.ServerName)
+	}
+	if m.InsecureSkipVerify {
+		dAtA[i] = 0x28
+		i++
+		if m.InsecureSkipVerify {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -96,6 +281,60 @@ func (m *Params) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovConfig(uint64(l))
 	}
+	if len(m.TagTemplates) > 0 {
+		for k, v := range m.TagTemplates {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovConfig(uint64(len(k))) + 1 + len(v) + sovConfig(uint64(len(v)))
+			n += mapEntrySize + 1 + sovConfig(uint64(mapEntrySize))
+		}
+	}
+	if m.BatchSize != 0 {
+		n += 1 + sovConfig(uint64(m.BatchSize))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.FlushInterval)
+	n += 1 + l + sovConfig(uint64(l))
+	if m.BufferSize != 0 {
+		n += 1 + sovConfig(uint64(m.BufferSize))
+	}
+	l = len(m.BufferPath)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	if m.MaxBufferFileSize != 0 {
+		n += 1 + sovConfig(uint64(m.MaxBufferFileSize))
+	}
+	if m.Tls != nil {
+		l = m.Tls.Size()
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)
+	n += 1 + l + sovConfig(uint64(l))
+	return n
+}
+
+func (m *Params_TLS) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.CaCertificates)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = len(m.ClientCertificate)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = len(m.PrivateKey)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = len(m.ServerName)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	if m.InsecureSkipVerify {
+		n += 2
+	}
 	return n
 }
 
@@ -116,8 +355,40 @@ func (this *Params) String() string {
 	if this == nil {
 		return "nil"
 	}
+	keysForTagTemplates := make([]string, 0, len(this.TagTemplates))
+	for k, _ := range this.TagTemplates {
+		keysForTagTemplates = append(keysForTagTemplates, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForTagTemplates)
+	mapStringForTagTemplates := "map[string]string{"
+	for _, k := range keysForTagTemplates {
+		mapStringForTagTemplates += fmt.Sprintf("%v: %v,", k, this.TagTemplates[k])
+	}
+	mapStringForTagTemplates += "}"
 	s := strings.Join([]string{`&Params{`,
 		`Address:` + fmt.Sprintf("%v", this.Address) + `,`,
+		`TagTemplates:` + mapStringForTagTemplates + `,`,
+		`BatchSize:` + fmt.Sprintf("%v", this.BatchSize) + `,`,
+		`FlushInterval:` + strings.Replace(strings.Replace(this.FlushInterval.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`BufferSize:` + fmt.Sprintf("%v", this.BufferSize) + `,`,
+		`BufferPath:` + fmt.Sprintf("%v", this.BufferPath) + `,`,
+		`MaxBufferFileSize:` + fmt.Sprintf("%v", this.MaxBufferFileSize) + `,`,
+		`Tls:` + strings.Replace(fmt.Sprintf("%v", this.Tls), "Params_TLS", "Params_TLS", 1) + `,`,
+		`Timeout:` + strings.Replace(strings.Replace(this.Timeout.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Params_TLS) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Params_TLS{`,
+		`CaCertificates:` + fmt.Sprintf("%v", this.CaCertificates) + `,`,
+		`ClientCertificate:` + fmt.Sprintf("%v", this.ClientCertificate) + `,`,
+		`PrivateKey:` + fmt.Sprintf("%v", this.PrivateKey) + `,`,
+		`ServerName:` + fmt.Sprintf("%v", this.ServerName) + `,`,
+		`InsecureSkipVerify:` + fmt.Sprintf("%v", this.InsecureSkipVerify) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -188,6 +459,489 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 			}
 			m.Address = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TagTemplates", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.TagTemplates == nil {
+				m.TagTemplates = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowConfig
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowConfig
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthConfig
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowConfig
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthConfig
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipConfig(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthConfig
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.TagTemplates[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BatchSize", wireType)
+			}
+			m.BatchSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BatchSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlushInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.FlushInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BufferSize", wireType)
+			}
+			m.BufferSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BufferSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BufferPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BufferPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxBufferFileSize", wireType)
+			}
+			m.MaxBufferFileSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxBufferFileSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tls", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Tls == nil {
+				m.Tls = &Params_TLS{}
+			}
+			if err := m.Tls.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Timeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipConfig(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthConfig
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Params_TLS) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowConfig
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Params_TLS: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Params_TLS: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CaCertificates", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CaCertificates = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientCertificate", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ClientCertificate = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PrivateKey", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PrivateKey = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServerName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServerName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InsecureSkipVerify", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.InsecureSkipVerify = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -317,16 +1071,41 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/fluentd/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 176 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xd2, 0xcc, 0xcd, 0xac, 0x48,
-	0x2d, 0xd2, 0x4f, 0x4c, 0x49, 0x2c, 0x28, 0x49, 0x2d, 0xd2, 0x4f, 0xcb, 0x29, 0x4d, 0xcd, 0x2b,
-	0x49, 0xd1, 0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0x87, 0x52, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9,
-	0x42, 0x62, 0x50, 0x45, 0x7a, 0x50, 0x45, 0x7a, 0x10, 0x59, 0x29, 0x91, 0xf4, 0xfc, 0xf4, 0x7c,
-	0xb0, 0x12, 0x7d, 0x10, 0x0b, 0xa2, 0x5a, 0x49, 0x89, 0x8b, 0x2d, 0x20, 0xb1, 0x28, 0x31, 0xb7,
-	0x58, 0x48, 0x82, 0x8b, 0x3d, 0x31, 0x25, 0xa5, 0x28, 0xb5, 0xb8, 0x58, 0x82, 0x51, 0x81, 0x51,
-	0x83, 0x33, 0x08, 0xc6, 0x75, 0xb2, 0x38, 0xf1, 0x50, 0x8e, 0xe1, 0xc2, 0x43, 0x39, 0x86, 0x1b,
-	0x0f, 0xe5, 0x18, 0x3e, 0x3c, 0x94, 0x63, 0x68, 0x78, 0x24, 0xc7, 0xb8, 0xe2, 0x91, 0x1c, 0xc3,
-	0x89, 0x47, 0x72, 0x8c, 0x17, 0x1e, 0xc9, 0x31, 0x3e, 0x78, 0x24, 0xc7, 0xf8, 0xe2, 0x91, 0x1c,
-	0xc3, 0x87, 0x47, 0x72, 0x8c, 0x13, 0x1e, 0xcb, 0x31, 0x44, 0xb1, 0x41, 0xec, 0x4c, 0x62, 0x03,
-	0x5b, 0x62, 0x0c, 0x08, 0x00, 0x00, 0xff, 0xff, 0x44, 0xb4, 0xde, 0x2c, 0xbf, 0x00, 0x00, 0x00,
+	// 561 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x51, 0xcd, 0x6e, 0xd3, 0x4c,
+	0x14, 0xf5, 0xd4, 0x5f, 0xd3, 0x66, 0xfa, 0xb5, 0xd0, 0x51, 0x84, 0x4c, 0x24, 0x26, 0x51, 0x37,
+	0x84, 0x05, 0x76, 0x55, 0x58, 0x54, 0x48, 0x08, 0x29, 0xfc, 0x48, 0x40, 0x85, 0x2a, 0x27, 0xb0,
+	0x60, 0x63, 0x4d, 0x9c, 0x6b, 0x67, 0x14, 0xff, 0x69, 0x3c, 0x8e, 0x92, 0xae, 0x78, 0x04, 0x96,
+	0x3c, 0x02, 0x8f, 0x92, 0x65, 0xd9, 0xb1, 0x02, 0x62, 0x36, 0x2c, 0xfb, 0x08, 0xc8, 0x33, 0x8e,
+	0x88, 0x04, 0x12, 0xac, 0x66, 0xee, 0x39, 0xe7, 0xfe, 0x9d, 0x8b, 0xef, 0xc4, 0x7c, 0x0e, 0xc2,
+	0x61, 0x63, 0x96, 0x49, 0x10, 0x4e, 0x10, 0x15, 0x90, 0xc8, 0xb1, 0xe3, 0xa7, 0x49, 0xc0, 0xc3,
+	0xfa, 0xb1, 0x33, 0x91, 0xca, 0x94, 0xdc, 0xa8, 0x45, 0x76, 0x2d, 0xb2, 0x35, 0xdb, 0x6e, 0x85,
+	0x69, 0x98, 0x2a, 0x89, 0x53, 0xfd, 0xb4, 0xba, 0x4d, 0xc3, 0x34, 0x0d, 0x23, 0x70, 0x54, 0x34,
+	0x2a, 0x02, 0x67, 0x5c, 0x08, 0x26, 0x79, 0x9a, 0x68, 0xfe, 0x68, 0xb5, 0x8d, 0x1b, 0xe7, 0x4c,
+	0xb0, 0x38, 0x27, 0x16, 0xde, 0x61, 0xe3, 0xb1, 0x80, 0x3c, 0xb7, 0x50, 0x17, 0xf5, 0x9a, 0xee,
+	0x3a, 0x24, 0xaf, 0xf1, 0xbe, 0x64, 0xa1, 0x27, 0x21, 0xce, 0x22, 0x26, 0x21, 0xb7, 0xb6, 0xba,
+	0x66, 0x6f, 0xef, 0xe4, 0xd8, 0xfe, 0xf3, 0x28, 0xb6, 0x2e, 0x68, 0x0f, 0x59, 0x38, 0x5c, 0xa7,
+	0x3c, 0x4d, 0xa4, 0x58, 0xb8, 0xff, 0xcb, 0x0d, 0x88, 0xdc, 0xc2, 0x78, 0xc4, 0xa4, 0x3f, 0xf1,
+	0x72, 0x7e, 0x01, 0x96, 0xd9, 0x45, 0x3d, 0xd3, 0x6d, 0x2a, 0x64, 0xc0, 0x2f, 0x80, 0xbc, 0xc0,
+	0x07, 0x41, 0x54, 0xe4, 0x13, 0x8f, 0x27, 0x12, 0xc4, 0x8c, 0x45, 0xd6, 0x7f, 0x5d, 0xd4, 0xdb,
+	0x3b, 0xb9, 0x69, 0xeb, 0x9d, 0xec, 0xf5, 0x4e, 0xf6, 0x93, 0x7a, 0xa7, 0xfe, 0xee, 0xf2, 0x4b,
+	0xc7, 0xf8, 0xf0, 0xb5, 0x83, 0xdc, 0x7d, 0x95, 0xfa, 0xbc, 0xce, 0x24, 0x1d, 0xbc, 0x37, 0x2a,
+	0x82, 0x00, 0x84, 0xee, 0xb5, 0xad, 0x7a, 0x61, 0x0d, 0xa9, 0x66, 0xbf, 0x04, 0x19, 0x93, 0x13,
+	0xab, 0xa1, 0x0c, 0xa8, 0x05, 0xe7, 0x4c, 0x4e, 0x88, 0x83, 0x5b, 0x31, 0x9b, 0x7b, 0xb5, 0x28,
+	0xe0, 0x11, 0xe8, 0x52, 0x3b, 0xaa, 0xd4, 0x61, 0xcc, 0xe6, 0x7d, 0x45, 0x3d, 0xe3, 0x11, 0xa8,
+	0x8a, 0xf7, 0xb1, 0x29, 0xa3, 0xdc, 0xda, 0x55, 0x33, 0x1f, 0xfd, 0xcd, 0xaa, 0xb3, 0x81, 0x5b,
+	0xc9, 0xc9, 0x43, 0xbc, 0x23, 0x79, 0x0c, 0x69, 0x21, 0xad, 0xe6, 0xbf, 0x6f, 0xbb, 0xce, 0x69,
+	0x3f, 0xc2, 0x87, 0xbf, 0xb9, 0x4e, 0xae, 0x63, 0x73, 0x0a, 0x8b, 0xfa, 0xa8, 0xd5, 0x97, 0xb4,
+	0xf0, 0xf6, 0x8c, 0x45, 0x05, 0x58, 0x5b, 0x0a, 0xd3, 0xc1, 0x83, 0xad, 0x53, 0xd4, 0xfe, 0x84,
+	0xb0, 0x39, 0x3c, 0x1b, 0x90, 0xdb, 0xf8, 0x9a, 0xcf, 0x3c, 0x1f, 0x84, 0xe4, 0x01, 0xf7, 0xd5,
+	0xd1, 0x75, 0xfe, 0x81, 0xcf, 0x1e, 0x6f, 0xa0, 0xe4, 0x2e, 0x26, 0x7e, 0xc4, 0x21, 0x91, 0x9b,
+	0xe2, 0xba, 0xee, 0xa1, 0x66, 0x36, 0xf4, 0x95, 0xcf, 0x99, 0xe0, 0x33, 0x26, 0xc1, 0xab, 0x66,
+	0x32, 0xb5, 0xcf, 0x35, 0xf4, 0x12, 0x16, 0x95, 0x20, 0x07, 0x31, 0x03, 0xe1, 0x25, 0x2c, 0x06,
+	0x75, 0xf2, 0xa6, 0x8b, 0x35, 0xf4, 0x8a, 0xc5, 0x40, 0x8e, 0x71, 0x8b, 0x27, 0x39, 0xf8, 0x85,
+	0x00, 0x2f, 0x9f, 0xf2, 0xcc, 0x9b, 0x81, 0xe0, 0xc1, 0x42, 0xdd, 0x74, 0xd7, 0x25, 0x6b, 0x6e,
+	0x30, 0xe5, 0xd9, 0x1b, 0xc5, 0xf4, 0x4f, 0x97, 0x2b, 0x6a, 0x5c, 0xae, 0xa8, 0xf1, 0x79, 0x45,
+	0x8d, 0xab, 0x15, 0x35, 0xde, 0x95, 0x14, 0x7d, 0x2c, 0xa9, 0xb1, 0x2c, 0x29, 0xba, 0x2c, 0x29,
+	0xfa, 0x56, 0x52, 0xf4, 0xa3, 0xa4, 0xc6, 0x55, 0x49, 0xd1, 0xfb, 0xef, 0xd4, 0x78, 0xdb, 0xd0,
+	0xd7, 0x19, 0x35, 0x94, 0xe9, 0xf7, 0x7e, 0x0e, 0x00, 0xb8, 0x57, 0xcd, 0x55, 0x9f, 0x03, 0x00,
+	0x00,
 }
@@ -16,17 +16,17 @@
 
 // Package fluentd adapter for Mixer. Conforms to interfaces in
 // mixer/pkg/adapter. Accepts logentries and forwards to a listening
-// fluentd daemon
+// fluentd daemon. Logentries are buffered, in memory and optionally on
+// disk, and forwarded asynchronously in batches, optionally over TLS.
 package fluentd
 
 import (
+	"bytes"
 	"context"
 	"net"
-	"strconv"
+	"text/template"
 	"time"
 
-	"github.com/fluent/fluent-logger-golang/fluent"
-
 	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/fluentd/config"
 	"istio.io/istio/mixer/pkg/adapter"
@@ -37,6 +37,14 @@ var (
 	defaultAddress = "localhost:24224"
 )
 
+const (
+	defaultBatchSize         = 100
+	defaultFlushInterval     = time.Second
+	defaultBufferSize        = 10000
+	defaultMaxBufferFileSize = 100 * 1024 * 1024
+	defaultTimeout           = 5 * time.Second
+)
+
 type (
 	builder struct {
 		adpCfg *config.Params
@@ -46,6 +54,7 @@ type (
 		logger fluentdLogger
 		types  map[string]*logentry.Type
 		env    adapter.Env
+		tags   map[string]*template.Template
 	}
 )
 
@@ -62,34 +71,49 @@ var _ logentry.Handler = &handler{}
 
 // adapter.HandlerBuilder#Build
 func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler, error) {
-	h, pStr, err := net.SplitHostPort(b.adpCfg.Address)
+	tags, err := parseTagTemplates(b.adpCfg.TagTemplates)
 	if err != nil {
 		return nil, err
 	}
-	p, err := strconv.Atoi(pStr)
+	f, err := newForwarder(b.adpCfg, env)
 	if err != nil {
 		return nil, err
 	}
 	han := &handler{
-		types: b.types,
-		env:   env,
-	}
-	han.logger, err = fluent.New(fluent.Config{FluentPort: p, FluentHost: h})
-	if err != nil {
-		return nil, err
+		logger: f,
+		types:  b.types,
+		env:    env,
+		tags:   tags,
 	}
 	return han, nil
 }
 
 func (b *builder) injectBuild(ctx context.Context, env adapter.Env, l fluentdLogger) (adapter.Handler, error) {
+	tags, err := parseTagTemplates(b.adpCfg.TagTemplates)
+	if err != nil {
+		return nil, err
+	}
 	han := &handler{
 		logger: l,
 		types:  b.types,
 		env:    env,
+		tags:   tags,
 	}
 	return han, nil
 }
 
+func parseTagTemplates(templates map[string]string) (map[string]*template.Template, error) {
+	tags := make(map[string]*template.Template, len(templates))
+	for name, text := range templates {
+		t, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		tags[name] = t
+	}
+	return tags, nil
+}
+
 // adapter.HandlerBuilder#SetAdapterConfig
 func (b *builder) SetAdapterConfig(cfg adapter.Config) {
 	b.adpCfg = cfg.(*config.Params)
@@ -103,6 +127,29 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	if _, _, err := net.SplitHostPort(b.adpCfg.Address); err != nil {
 		ce = ce.Appendf("address", "Address is malformed: %v", err)
 	}
+	for name, text := range b.adpCfg.TagTemplates {
+		if _, err := template.New(name).Parse(text); err != nil {
+			ce = ce.Appendf("tagTemplates", "Template for %s is invalid: %v", name, err)
+		}
+	}
+	if b.adpCfg.BatchSize < 0 {
+		ce = ce.Appendf("batchSize", "Batch size must be >= 0, it is %d", b.adpCfg.BatchSize)
+	}
+	if b.adpCfg.FlushInterval < 0 {
+		ce = ce.Appendf("flushInterval", "Flush interval must be >= 0, it is %v", b.adpCfg.FlushInterval)
+	}
+	if b.adpCfg.BufferSize < 0 {
+		ce = ce.Appendf("bufferSize", "Buffer size must be >= 0, it is %d", b.adpCfg.BufferSize)
+	}
+	if b.adpCfg.MaxBufferFileSize < 0 {
+		ce = ce.Appendf("maxBufferFileSize", "Maximum buffer file size must be >= 0, it is %d", b.adpCfg.MaxBufferFileSize)
+	}
+	if b.adpCfg.Timeout < 0 {
+		ce = ce.Appendf("timeout", "Timeout must be >= 0, it is %v", b.adpCfg.Timeout)
+	}
+	if tls := b.adpCfg.Tls; tls != nil && (tls.ClientCertificate == "") != (tls.PrivateKey == "") {
+		ce = ce.Appendf("tls", "Client certificate and private key must be specified together")
+	}
 	return
 }
 
@@ -120,31 +167,51 @@ func (h *handler) HandleLogEntry(ctx context.Context, insts []*logentry.Instance
 			h.env.Logger().Infof("Got a new log for fluentd, name %v", i.Name)
 		}
 
-		// Durations are not supported by msgp
+		// The instance may be dispatched to other handlers concurrently, so its
+		// variables are copied rather than modified.
+		record := make(map[string]interface{}, len(i.Variables)+2)
 		for k, v := range i.Variables {
+			// Durations are not supported by msgp
 			if h.types[i.Name].Variables[k] == descriptor.DURATION {
-				d := v.(time.Duration)
-				i.Variables[k] = d.String()
+				v = v.(time.Duration).String()
 			}
+			record[k] = v
 		}
 
-		i.Variables["severity"] = i.Severity
+		record["severity"] = i.Severity
 
-		tag, ok := i.Variables["tag"]
-		if !ok {
-			tag = i.Name
-		} else {
-			i.Variables["name"] = i.Name
-			delete(i.Variables, "tag")
-		}
-
-		if err := h.logger.PostWithTime(tag.(string), i.Timestamp, i.Variables); err != nil {
+		if err := h.logger.PostWithTime(h.tag(i.Name, record), i.Timestamp, record); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// tag returns the fluentd tag of the record of a logentry instance. The tag
+// template configured for the instance takes precedence over its "tag"
+// variable, which is removed from the record in either case.
+func (h *handler) tag(name string, record map[string]interface{}) string {
+	if t, found := h.tags[name]; found {
+		record["name"] = name
+		var b bytes.Buffer
+		err := t.Execute(&b, record)
+		delete(record, "tag")
+		if err == nil {
+			return b.String()
+		}
+		_ = h.env.Logger().Errorf("Unable to execute tag template for %s: %v", name, err)
+		return name
+	}
+
+	tag, ok := record["tag"]
+	if !ok {
+		return name
+	}
+	record["name"] = name
+	delete(record, "tag")
+	return tag.(string)
+}
+
 // adapter.Handler#Close
 func (h *handler) Close() error {
 	return h.logger.Close()
@@ -157,12 +224,18 @@ func GetInfo() adapter.Info {
 	return adapter.Info{
 		Name:        "fluentd",
 		Description: "Sends logentrys to a fluentd instance",
+		Impl:        "istio.io/istio/mixer/adapter/fluentd",
 		SupportedTemplates: []string{
 			logentry.TemplateName,
 		},
 		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
 		DefaultConfig: &config.Params{
-			Address: defaultAddress,
+			Address:           defaultAddress,
+			BatchSize:         defaultBatchSize,
+			FlushInterval:     defaultFlushInterval,
+			BufferSize:        defaultBufferSize,
+			MaxBufferFileSize: defaultMaxBufferFileSize,
+			Timeout:           defaultTimeout,
 		},
 	}
 }
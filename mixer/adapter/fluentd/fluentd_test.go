@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/gogo/protobuf/proto"
 
 	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/fluentd/config"
@@ -297,3 +298,126 @@ func (l *mockFluentd) PostWithTime(tag string, ts time.Time, msg interface{}) er
 func (l *mockFluentd) Reset() {
 	l.Messages = make([]message, 0)
 }
+
+func TestTagTemplates(t *testing.T) {
+	b := &builder{
+		adpCfg: &config.Params{
+			TagTemplates: map[string]string{
+				"Foo": "istio.{{.destination}}.{{.severity}}",
+				"Bad": "{{.missing.field}}",
+			},
+		},
+		types: map[string]*logentry.Type{
+			"Foo": {Variables: map[string]descriptor.ValueType{"destination": descriptor.STRING}},
+			"Bar": {Variables: map[string]descriptor.ValueType{}},
+			"Bad": {Variables: map[string]descriptor.ValueType{}},
+		},
+	}
+
+	mf := &mockFluentd{}
+	h, err := b.injectBuild(context.Background(), test.NewEnv(t), mf)
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	insts := []*logentry.Instance{
+		{
+			Name:      "Foo",
+			Severity:  "INFO",
+			Variables: map[string]interface{}{"destination": "ratings", "tag": "ignored"},
+		},
+		{
+			Name:      "Bar",
+			Severity:  "INFO",
+			Variables: map[string]interface{}{"tag": "bar-tag"},
+		},
+		{
+			Name:      "Bad",
+			Severity:  "INFO",
+			Variables: map[string]interface{}{"missing": 1},
+		},
+	}
+	if err = h.(logentry.Handler).HandleLogEntry(context.Background(), insts); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	wantTags := []string{"istio.ratings.INFO", "bar-tag", "Bad"}
+	if len(mf.Messages) != len(wantTags) {
+		t.Fatalf("Got %d messages, expected %d", len(mf.Messages), len(wantTags))
+	}
+	for i, tag := range wantTags {
+		if mf.Messages[i].Tag != tag {
+			t.Errorf("Got %v for Tag, expected %v", mf.Messages[i].Tag, tag)
+		}
+		msg := mf.Messages[i].Msg.(map[string]interface{})
+		if _, found := msg["tag"]; found {
+			t.Errorf("Got a tag variable in %v, expected it removed", msg)
+		}
+		if msg["name"] != insts[i].Name {
+			t.Errorf("Got %v for name, expected %v", msg["name"], insts[i].Name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		config config.Params
+		field  string
+	}{
+		{"Bad Template", config.Params{TagTemplates: map[string]string{"Foo": "{{.a"}}, "tagTemplates"},
+		{"Negative Batch Size", config.Params{BatchSize: -1}, "batchSize"},
+		{"Negative Flush Interval", config.Params{FlushInterval: -time.Second}, "flushInterval"},
+		{"Negative Buffer Size", config.Params{BufferSize: -1}, "bufferSize"},
+		{"Negative Buffer File Size", config.Params{MaxBufferFileSize: -1}, "maxBufferFileSize"},
+		{"Negative Timeout", config.Params{Timeout: -time.Second}, "timeout"},
+		{"Key Without Certificate", config.Params{Tls: &config.Params_TLS{PrivateKey: "key.pem"}}, "tls"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.config.Address = defaultAddress
+			b := &builder{adpCfg: &c.config}
+
+			ce := b.Validate()
+			if ce == nil || len(ce.Multi.Errors) != 1 {
+				t.Fatalf("Got %v, expecting a single error", ce)
+			}
+			if field := ce.Multi.Errors[0].(adapter.ConfigError).Field; field != c.field {
+				t.Errorf("Got an error for %s, expecting %s", field, c.field)
+			}
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	fd := newFakeFluentd(t, nil)
+	defer fd.close()
+
+	cfg := *GetInfo().DefaultConfig.(*config.Params)
+	cfg.Address = fd.address()
+
+	test.RunConformance(t, test.Conformance{
+		Info:   GetInfo(),
+		Config: &cfg,
+		InvalidConfigs: []adapter.Config{
+			&config.Params{},
+			&config.Params{Address: defaultAddress, BatchSize: -1},
+		},
+		Types: map[string]map[string]proto.Message{
+			logentry.TemplateName: {
+				"access": &logentry.Type{Variables: map[string]descriptor.ValueType{"user": descriptor.STRING}},
+			},
+		},
+		Instances: map[string][]interface{}{
+			logentry.TemplateName: {
+				&logentry.Instance{
+					Name:      "access",
+					Severity:  "INFO",
+					Timestamp: time.Now(),
+					Variables: map[string]interface{}{"user": "alice"},
+				},
+			},
+		},
+	})
+}
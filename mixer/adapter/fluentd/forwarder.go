@@ -0,0 +1,389 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+
+	"istio.io/istio/mixer/adapter/fluentd/config"
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+// record is a log entry waiting to be forwarded to fluentd.
+type record struct {
+	tag  string
+	data []byte // msgpack encoded [time, record] entry
+}
+
+// forwarder is a fluentdLogger which buffers log entries and hands them over to
+// a background task, which sends them in batches to fluentd using the forward
+// mode of the fluentd forward protocol.
+//
+// Log entries are buffered in memory, and spill over to an optional buffer file
+// when the memory buffer is full. While the buffer file holds any entries, new
+// entries are appended to it as well, so that entries are sent in order. Entries
+// that fit in neither are dropped.
+type forwarder struct {
+	env           adapter.Env
+	address       string
+	dial          func() (net.Conn, error)
+	timeout       time.Duration
+	batchSize     int
+	bufferSize    int
+	flushInterval time.Duration
+	file          *fileBuffer
+	counters      counters
+
+	lock    sync.Mutex
+	records []record
+
+	// only accessed by the background task
+	conn    net.Conn
+	retryAt time.Time
+
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+
+	// number of entries dropped since the last report, accessed atomically
+	dropped int64
+}
+
+var _ fluentdLogger = &forwarder{}
+
+func newForwarder(cfg *config.Params, env adapter.Env) (*forwarder, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &forwarder{
+		env:           env,
+		address:       cfg.Address,
+		timeout:       withDefault(cfg.Timeout, defaultTimeout),
+		batchSize:     int(cfg.BatchSize),
+		bufferSize:    int(cfg.BufferSize),
+		flushInterval: withDefault(cfg.FlushInterval, defaultFlushInterval),
+		counters:      newCounters(cfg.Address),
+		flush:         make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	if f.batchSize == 0 {
+		f.batchSize = defaultBatchSize
+	}
+	if f.bufferSize == 0 {
+		f.bufferSize = defaultBufferSize
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.Tls != nil {
+		if tlsConfig, err = newTLSConfig(cfg.Tls, host); err != nil {
+			return nil, err
+		}
+	}
+	f.dial = newDialer(cfg.Address, f.timeout, tlsConfig)
+
+	if cfg.BufferPath != "" {
+		maxSize := cfg.MaxBufferFileSize
+		if maxSize == 0 {
+			maxSize = defaultMaxBufferFileSize
+		}
+		if f.file, err = openFileBuffer(cfg.BufferPath, maxSize); err != nil {
+			return nil, fmt.Errorf("unable to open buffer file: %v", err)
+		}
+	}
+
+	env.ScheduleDaemon(f.run)
+	return f, nil
+}
+
+func withDefault(d time.Duration, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
+
+func newTLSConfig(cfg *config.Params_TLS, host string) (*tls.Config, error) {
+	c := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if c.ServerName == "" {
+		c.ServerName = host
+	}
+
+	if cfg.CaCertificates != "" {
+		pem, err := ioutil.ReadFile(cfg.CaCertificates)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificates: %v", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no CA certificates found in %s", cfg.CaCertificates)
+		}
+	}
+
+	if cfg.ClientCertificate != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertificate, cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+func newDialer(address string, timeout time.Duration, tlsConfig *tls.Config) func() (net.Conn, error) {
+	d := &net.Dialer{Timeout: timeout}
+	if tlsConfig == nil {
+		return func() (net.Conn, error) {
+			return d.Dial("tcp", address)
+		}
+	}
+	return func() (net.Conn, error) {
+		return tls.DialWithDialer(d, "tcp", address, tlsConfig)
+	}
+}
+
+// PostWithTime queues a log entry for forwarding. It never blocks on fluentd.
+func (f *forwarder) PostWithTime(tag string, t time.Time, msg interface{}) error {
+	data := msgp.AppendArrayHeader(nil, 2)
+	data = msgp.AppendInt64(data, t.Unix())
+	data, err := msgp.AppendIntf(data, msg)
+	if err != nil {
+		return err
+	}
+	rec := record{tag: tag, data: data}
+
+	f.lock.Lock()
+	if len(f.records) < f.bufferSize && (f.file == nil || f.file.empty()) {
+		f.records = append(f.records, rec)
+		full := len(f.records) >= f.batchSize
+		f.lock.Unlock()
+
+		f.counters.buffered.Inc()
+		if full {
+			f.notify()
+		}
+		return nil
+	}
+	err = errBufferFull
+	if f.file != nil {
+		err = f.file.push(rec)
+	}
+	f.lock.Unlock()
+
+	if err != nil {
+		if err != errBufferFull {
+			_ = f.env.Logger().Errorf("Unable to write log entry to buffer file: %v", err)
+		}
+		f.counters.dropped.Inc()
+		atomic.AddInt64(&f.dropped, 1)
+		return nil
+	}
+	f.counters.buffered.Inc()
+	return nil
+}
+
+// notify wakes up the background task, without waiting for the flush interval.
+func (f *forwarder) notify() {
+	select {
+	case f.flush <- struct{}{}:
+	default:
+	}
+}
+
+// Close sends the buffered entries, and stops the background task. Entries
+// which cannot be sent are kept in the buffer file, if any. It is safe to
+// call Close more than once.
+func (f *forwarder) Close() error {
+	f.closeOnce.Do(func() {
+		close(f.done)
+		<-f.stopped
+
+		if f.file != nil {
+			f.closeErr = f.file.close()
+		}
+	})
+	return f.closeErr
+}
+
+func (f *forwarder) run() {
+	defer close(f.stopped)
+
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-f.flush:
+		case <-f.done:
+			f.retryAt = time.Time{}
+			f.send()
+			f.spill()
+			f.disconnect()
+			f.reportDropped()
+			return
+		}
+		f.send()
+		f.reportDropped()
+	}
+}
+
+// send forwards the buffered entries in batches, until either the buffers are
+// empty or fluentd is unavailable.
+func (f *forwarder) send() {
+	if time.Now().Before(f.retryAt) {
+		return
+	}
+
+	for {
+		batch, commit, err := f.next()
+		if err != nil {
+			_ = f.env.Logger().Errorf("Discarding unreadable buffer file: %v", err)
+			f.discardFile()
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		if err = f.write(batch); err != nil {
+			f.env.Logger().Warningf("Unable to send %d log entries to fluentd at %s: %v", len(batch), f.address, err)
+			f.retryAt = time.Now().Add(f.flushInterval)
+			return
+		}
+		if err = commit(); err != nil {
+			_ = f.env.Logger().Errorf("Unable to update buffer file: %v", err)
+		}
+		f.counters.sent.Add(float64(len(batch)))
+	}
+}
+
+// next returns the next batch of entries to send, and a function removing
+// them from the buffers once they have been sent.
+func (f *forwarder) next() ([]record, func() error, error) {
+	f.lock.Lock()
+	n := len(f.records)
+	if n > f.batchSize {
+		n = f.batchSize
+	}
+	batch := f.records[:n:n]
+	f.lock.Unlock()
+
+	if n > 0 {
+		return batch, func() error {
+			f.lock.Lock()
+			f.records = append(f.records[:0], f.records[n:]...)
+			f.lock.Unlock()
+			return nil
+		}, nil
+	}
+
+	if f.file == nil {
+		return nil, nil, nil
+	}
+	batch, offset, err := f.file.peek(f.batchSize)
+	return batch, func() error { return f.file.commit(offset) }, err
+}
+
+func (f *forwarder) discardFile() {
+	if err := f.file.reset(); err != nil {
+		_ = f.env.Logger().Errorf("Unable to truncate buffer file: %v", err)
+	}
+}
+
+func (f *forwarder) write(batch []record) error {
+	if f.conn == nil {
+		conn, err := f.dial()
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+
+	if err := f.conn.SetWriteDeadline(time.Now().Add(f.timeout)); err != nil {
+		f.disconnect()
+		return err
+	}
+	if _, err := f.conn.Write(appendMessages(nil, batch)); err != nil {
+		f.disconnect()
+		return err
+	}
+	return nil
+}
+
+func (f *forwarder) disconnect() {
+	if f.conn != nil {
+		_ = f.conn.Close()
+		f.conn = nil
+	}
+}
+
+// spill moves the entries left in memory to the buffer file, or drops them if
+// there is none.
+func (f *forwarder) spill() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, rec := range f.records {
+		if f.file == nil || f.file.push(rec) != nil {
+			f.counters.dropped.Inc()
+			atomic.AddInt64(&f.dropped, 1)
+		}
+	}
+	f.records = nil
+}
+
+func (f *forwarder) reportDropped() {
+	if n := atomic.SwapInt64(&f.dropped, 0); n > 0 {
+		f.env.Logger().Warningf("Dropped %d log entries for fluentd at %s, the buffer is full", n, f.address)
+	}
+}
+
+// appendMessages encodes a batch of entries as forward mode messages, one
+// for each run of entries sharing the same tag.
+func appendMessages(b []byte, batch []record) []byte {
+	for len(batch) > 0 {
+		n := 1
+		for n < len(batch) && batch[n].tag == batch[0].tag {
+			n++
+		}
+
+		b = msgp.AppendArrayHeader(b, 2)
+		b = msgp.AppendString(b, batch[0].tag)
+		b = msgp.AppendArrayHeader(b, uint32(n))
+		for _, rec := range batch[:n] {
+			b = append(b, rec.data...)
+		}
+		batch = batch[n:]
+	}
+	return b
+}
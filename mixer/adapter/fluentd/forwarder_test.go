@@ -0,0 +1,391 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tinylib/msgp/msgp"
+
+	"istio.io/istio/mixer/adapter/fluentd/config"
+	"istio.io/istio/mixer/pkg/adapter/test"
+)
+
+func TestForwarder(t *testing.T) {
+	fd := newFakeFluentd(t, nil)
+	defer fd.close()
+
+	f, err := newForwarder(&config.Params{
+		Address:       fd.address(),
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+	}, test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Unable to create forwarder: %v", err)
+	}
+
+	tm := time.Date(2018, time.January, 1, 10, 0, 0, 0, time.UTC)
+	post(t, f, "a", tm, map[string]interface{}{"n": int64(1)})
+	post(t, f, "a", tm, map[string]interface{}{"n": int64(2)})
+	post(t, f, "b", tm, map[string]interface{}{"n": int64(3)})
+
+	// the batch is full, so it is sent without waiting for the flush interval
+	want := []entry{
+		{"a", tm.Unix(), map[string]interface{}{"n": int64(1)}},
+		{"a", tm.Unix(), map[string]interface{}{"n": int64(2)}},
+		{"b", tm.Unix(), map[string]interface{}{"n": int64(3)}},
+	}
+	if got := fd.wait(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+
+	// the rest is sent when closing
+	post(t, f, "c", tm, map[string]interface{}{"n": int64(4)})
+	if err = f.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if got := fd.wait(4); got[3].tag != "c" {
+		t.Errorf("Got %v, want an entry tagged c", got[3])
+	}
+}
+
+func TestForwarderTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fluentd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	cert, certFile := newCertificate(t, dir)
+	fd := newFakeFluentd(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+	defer fd.close()
+
+	f, err := newForwarder(&config.Params{
+		Address: fd.address(),
+		Tls: &config.Params_TLS{
+			CaCertificates: certFile,
+			ServerName:     "fluentd.test",
+		},
+	}, test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Unable to create forwarder: %v", err)
+	}
+
+	post(t, f, "secure", time.Now(), map[string]interface{}{"a": "b"})
+	if err = f.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if got := fd.wait(1); got[0].tag != "secure" || got[0].record["a"] != "b" {
+		t.Errorf("Got %v, want an entry tagged secure", got[0])
+	}
+
+	if _, err = newForwarder(&config.Params{
+		Address: fd.address(),
+		Tls:     &config.Params_TLS{CaCertificates: filepath.Join(dir, "missing.pem")},
+	}, test.NewEnv(t)); err == nil {
+		t.Error("Got success, expecting failure for missing CA certificates")
+	}
+}
+
+func TestForwarderBuffering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fluentd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// reserve an address nothing listens on yet
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	_ = l.Close()
+
+	f, err := newForwarder(&config.Params{
+		Address:           address,
+		BatchSize:         2,
+		FlushInterval:     10 * time.Millisecond,
+		BufferSize:        2,
+		BufferPath:        filepath.Join(dir, "buffer"),
+		MaxBufferFileSize: 60, // 4 entries of 15 bytes
+	}, test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Unable to create forwarder: %v", err)
+	}
+	c := newCounters(address)
+	buffered, dropped, sent := counterValue(c.buffered), counterValue(c.dropped), counterValue(c.sent)
+
+	// 2 entries fit in memory, 4 in the buffer file, and the rest is dropped
+	tm := time.Date(2018, time.January, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 8; i++ {
+		post(t, f, "tag", tm, map[string]interface{}{"n": int64(i)})
+	}
+	if got := counterValue(c.buffered) - buffered; got != 6 {
+		t.Errorf("Got %v buffered entries, want 6", got)
+	}
+	if got := counterValue(c.dropped) - dropped; got != 2 {
+		t.Errorf("Got %v dropped entries, want 2", got)
+	}
+
+	fd := newFakeFluentdAt(t, address, nil)
+	defer fd.close()
+
+	got := fd.wait(6)
+	for i, e := range got {
+		if e.record["n"] != int64(i) {
+			t.Errorf("Got entry %v at %d, want entries in order", e, i)
+		}
+	}
+	if err = f.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if got := counterValue(c.sent) - sent; got != 6 {
+		t.Errorf("Got %v sent entries, want 6", got)
+	}
+}
+
+func TestForwarderCloseSpills(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fluentd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	_ = l.Close()
+
+	cfg := &config.Params{
+		Address:       address,
+		FlushInterval: time.Hour,
+		BufferPath:    filepath.Join(dir, "buffer"),
+		Timeout:       100 * time.Millisecond,
+	}
+	f, err := newForwarder(cfg, test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Unable to create forwarder: %v", err)
+	}
+	post(t, f, "tag", time.Now(), map[string]interface{}{"n": int64(1)})
+	if err = f.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if err = f.Close(); err != nil {
+		t.Errorf("Got error %v on the second Close, expecting success", err)
+	}
+
+	// the entry is sent by the next forwarder using the buffer file
+	fd := newFakeFluentdAt(t, address, nil)
+	defer fd.close()
+
+	cfg.FlushInterval = 10 * time.Millisecond
+	if f, err = newForwarder(cfg, test.NewEnv(t)); err != nil {
+		t.Fatalf("Unable to create forwarder: %v", err)
+	}
+	if got := fd.wait(1); got[0].record["n"] != int64(1) {
+		t.Errorf("Got %v, want the spilled entry", got[0])
+	}
+	if err = f.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+}
+
+func TestAppendMessages(t *testing.T) {
+	batch := []record{
+		{tag: "a", data: msgp.AppendInt64(nil, 1)},
+		{tag: "a", data: msgp.AppendInt64(nil, 2)},
+		{tag: "b", data: msgp.AppendInt64(nil, 3)},
+	}
+
+	var want []byte
+	want = msgp.AppendArrayHeader(want, 2)
+	want = msgp.AppendString(want, "a")
+	want = msgp.AppendArrayHeader(want, 2)
+	want = msgp.AppendInt64(want, 1)
+	want = msgp.AppendInt64(want, 2)
+	want = msgp.AppendArrayHeader(want, 2)
+	want = msgp.AppendString(want, "b")
+	want = msgp.AppendArrayHeader(want, 1)
+	want = msgp.AppendInt64(want, 3)
+
+	if got := appendMessages(nil, batch); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %x, want %x", got, want)
+	}
+}
+
+func post(t *testing.T, f *forwarder, tag string, tm time.Time, msg map[string]interface{}) {
+	t.Helper()
+	if err := f.PostWithTime(tag, tm, msg); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+}
+
+func counterValue(c prometheus.Counter) float64 {
+	m := new(dto.Metric)
+	_ = c.Write(m)
+	return m.GetCounter().GetValue()
+}
+
+type entry struct {
+	tag    string
+	time   int64
+	record map[string]interface{}
+}
+
+// fakeFluentd accepts connections using the forward mode of the fluentd
+// forward protocol, and records the entries it receives.
+type fakeFluentd struct {
+	t        *testing.T
+	listener net.Listener
+
+	lock    sync.Mutex
+	entries []entry
+}
+
+func newFakeFluentd(t *testing.T, tlsConfig *tls.Config) *fakeFluentd {
+	return newFakeFluentdAt(t, "127.0.0.1:0", tlsConfig)
+}
+
+func newFakeFluentdAt(t *testing.T, address string, tlsConfig *tls.Config) *fakeFluentd {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
+
+	fd := &fakeFluentd{t: t, listener: l}
+	go fd.serve()
+	return fd
+}
+
+func (fd *fakeFluentd) address() string {
+	return fd.listener.Addr().String()
+}
+
+func (fd *fakeFluentd) close() {
+	_ = fd.listener.Close()
+}
+
+func (fd *fakeFluentd) serve() {
+	for {
+		conn, err := fd.listener.Accept()
+		if err != nil {
+			return
+		}
+		go fd.read(conn)
+	}
+}
+
+func (fd *fakeFluentd) read(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	r := msgp.NewReader(conn)
+	for {
+		if _, err := r.ReadArrayHeader(); err != nil {
+			return
+		}
+		tag, err := r.ReadString()
+		if err != nil {
+			return
+		}
+		n, err := r.ReadArrayHeader()
+		if err != nil {
+			return
+		}
+		for i := uint32(0); i < n; i++ {
+			e := entry{tag: tag, record: map[string]interface{}{}}
+			if _, err = r.ReadArrayHeader(); err != nil {
+				return
+			}
+			if e.time, err = r.ReadInt64(); err != nil {
+				return
+			}
+			if err = r.ReadMapStrIntf(e.record); err != nil {
+				return
+			}
+
+			fd.lock.Lock()
+			fd.entries = append(fd.entries, e)
+			fd.lock.Unlock()
+		}
+	}
+}
+
+// wait returns the received entries once there are at least n of them.
+func (fd *fakeFluentd) wait(n int) []entry {
+	fd.t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		fd.lock.Lock()
+		entries := fd.entries
+		fd.lock.Unlock()
+		if len(entries) >= n {
+			return entries
+		}
+	}
+	fd.t.Fatalf("Timed out waiting for %d entries", n)
+	return nil
+}
+
+// newCertificate creates a self-signed certificate for fluentd.test, and
+// writes it to a file in dir.
+func newCertificate(t *testing.T, dir string) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fluentd.test"},
+		DNSNames:              []string{"fluentd.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certFile
+}
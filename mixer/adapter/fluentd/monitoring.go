@@ -0,0 +1,52 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentd
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	addressLabel = "address"
+	stateLabel   = "state"
+)
+
+var (
+	recordCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "fluentd",
+		Name:      "record_count",
+		Help:      "Total number of log entries buffered, dropped and sent to fluentd, by fluentd address.",
+	}, []string{addressLabel, stateLabel})
+)
+
+func init() {
+	prometheus.MustRegister(recordCount)
+}
+
+// counters tracks the log entries forwarded to a single fluentd daemon.
+type counters struct {
+	buffered prometheus.Counter
+	dropped  prometheus.Counter
+	sent     prometheus.Counter
+}
+
+func newCounters(address string) counters {
+	return counters{
+		buffered: recordCount.WithLabelValues(address, "buffered"),
+		dropped:  recordCount.WithLabelValues(address, "dropped"),
+		sent:     recordCount.WithLabelValues(address, "sent"),
+	}
+}
@@ -27,11 +27,13 @@ import (
 	opa "istio.io/istio/mixer/adapter/opa"
 	prometheus "istio.io/istio/mixer/adapter/prometheus"
 	rbac "istio.io/istio/mixer/adapter/rbac"
+	remote "istio.io/istio/mixer/adapter/remote"
 	servicecontrol "istio.io/istio/mixer/adapter/servicecontrol"
 	solarwinds "istio.io/istio/mixer/adapter/solarwinds"
 	stackdriver "istio.io/istio/mixer/adapter/stackdriver"
 	statsd "istio.io/istio/mixer/adapter/statsd"
 	stdio "istio.io/istio/mixer/adapter/stdio"
+	zipkin "istio.io/istio/mixer/adapter/zipkin"
 	adptr "istio.io/istio/mixer/pkg/adapter"
 )
 
@@ -48,10 +50,12 @@ func Inventory() []adptr.InfoFn {
 		opa.GetInfo,
 		prometheus.GetInfo,
 		rbac.GetInfo,
+		remote.GetInfo,
 		servicecontrol.GetInfo,
 		solarwinds.GetInfo,
 		stackdriver.GetInfo,
 		statsd.GetInfo,
 		stdio.GetInfo,
+		zipkin.GetInfo,
 	}
 }
@@ -29,6 +29,7 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/api/core/v1"
@@ -80,6 +81,10 @@ type (
 		pods   cacheController
 		env    adapter.Env
 		params *config.Params
+
+		// stops the cache controller
+		stopChan  chan struct{}
+		closeOnce sync.Once
 	}
 
 	// used strictly for testing purposes
@@ -148,14 +153,15 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 	// a sync has occurred
 	env.Logger().Infof("Waiting for kubernetes cache sync...")
 	if success := cache.WaitForCacheSync(stopChan, controller.HasSynced); !success {
-		stopChan <- struct{}{}
+		close(stopChan)
 		return nil, errors.New("cache sync failure")
 	}
 	env.Logger().Infof("Cache sync successful.")
 	return &handler{
-		env:    env,
-		pods:   controller,
-		params: paramsProto,
+		env:      env,
+		pods:     controller,
+		params:   paramsProto,
+		stopChan: stopChan,
 	}, nil
 }
 
@@ -204,7 +210,9 @@ func (h *handler) GenerateKubernetesAttributes(ctx context.Context, inst *ktmpl.
 	return out, nil
 }
 
+// Close stops the cache controller.
 func (h *handler) Close() error {
+	h.closeOnce.Do(func() { close(h.stopChan) })
 	return nil
 }
 
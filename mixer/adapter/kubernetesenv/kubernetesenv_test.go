@@ -401,3 +401,35 @@ func TestKubegen_Generate(t *testing.T) {
 		})
 	}
 }
+
+func TestConformance(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "testns",
+			Labels:    map[string]string{"app": "test"},
+		},
+		Status: v1.PodStatus{PodIP: "10.10.10.1"},
+	}
+
+	info := GetInfo()
+	info.NewBuilder = func() adapter.HandlerBuilder {
+		return newBuilder(func(string, adapter.Env) (kubernetes.Interface, error) {
+			return fake.NewSimpleClientset(pod), nil
+		})
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info:           info,
+		InvalidConfigs: []adapter.Config{&config.Params{}},
+		Instances: map[string][]interface{}{
+			kubernetes_apa_tmpl.TemplateName: {
+				&kubernetes_apa_tmpl.Instance{
+					SourceUid:     "kubernetes://test-pod.testns",
+					DestinationIp: net.ParseIP("10.10.10.1"),
+					OriginUid:     "kubernetes://unknown-pod.testns",
+				},
+			},
+		},
+	})
+}
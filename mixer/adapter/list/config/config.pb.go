@@ -9,6 +9,10 @@
 	it to a URL from where the list should be fetched. Lists can be simple strings,
 	IP addresses, or regex patterns.
 
+	Any list entry may be suffixed with `;expires=` and an RFC 3339 time, such as
+	`10.1.2.3;expires=2018-06-01T00:00:00Z`, after which the entry is no longer
+	part of the list.
+
 	It is generated from these files:
 		mixer/adapter/list/config/config.proto
 
@@ -76,6 +80,8 @@ func (Params_ListEntryType) EnumDescriptor() ([]byte, []int) { return fileDescri
 
 type Params struct {
 	// Where to find the list to check against. This may be ommited for a completely local list.
+	// Lists are fetched from http:// and https:// URLs, or read from the local file system
+	// for file:// URLs, such as file:///etc/lists/blocked.txt.
 	ProviderUrl string `protobuf:"bytes,1,opt,name=provider_url,json=providerUrl,proto3" json:"provider_url,omitempty"`
 	// Determines how often the provider is polled for
 	// an updated list
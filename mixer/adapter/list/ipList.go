@@ -18,13 +18,15 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 )
 
 type (
 	ipList struct {
-		entries []*net.IPNet
+		entries *ipTrie
+		count   int
 	}
 
 	// represents the format of the data in a list
@@ -40,7 +42,7 @@ func parseIPList(buf []byte, overrides []string) (list, error) {
 		return nil, fmt.Errorf("could not unmarshal data from list %s", err)
 	}
 
-	ls := &ipList{make([]*net.IPNet, 0, len(lp.WhiteList)+len(overrides))}
+	ls := &ipList{entries: &ipTrie{}}
 	var err error
 
 	// copy to the internal format
@@ -59,38 +61,47 @@ func parseIPList(buf []byte, overrides []string) (list, error) {
 	return ls, nil
 }
 
-func (ls *ipList) addEntry(ip string) error {
-	orig := ip
-	if !strings.Contains(ip, "/") {
-		ip += "/32"
+func (ls *ipList) addEntry(entry string) error {
+	ip, expiration, err := parseEntry(entry)
+	if err != nil {
+		return err
 	}
 
-	_, ipnet, err := net.ParseCIDR(ip)
+	ipnet, err := parseIPNet(ip)
 	if err != nil {
-		return fmt.Errorf("could not parse list entry %s: %v", orig, err)
+		return fmt.Errorf("could not parse list entry %s: %v", ip, err)
 	}
-	ls.entries = append(ls.entries, ipnet)
+	ls.entries.insert(ipnet, expiration)
+	ls.count++
 
 	return nil
 }
 
-func (ls *ipList) checkList(symbol string) (bool, error) {
+// parseIPNet parses an IP address or range, where an address is treated as a range of one.
+func parseIPNet(ip string) (*net.IPNet, error) {
+	if !strings.Contains(ip, "/") {
+		if strings.Contains(ip, ":") {
+			ip += "/128"
+		} else {
+			ip += "/32"
+		}
+	}
+
+	_, ipnet, err := net.ParseCIDR(ip)
+	return ipnet, err
+}
+
+func (ls *ipList) checkList(symbol string, now time.Time) (bool, time.Time, error) {
 	ipa := net.ParseIP(symbol)
 	if ipa == nil {
 		// invalid symbol format
-		return false, fmt.Errorf("%s is not a valid IP address", symbol)
-	}
-
-	for _, ipnet := range ls.entries {
-		if ipnet.Contains(ipa) {
-			return true, nil
-		}
+		return false, time.Time{}, fmt.Errorf("%s is not a valid IP address", symbol)
 	}
 
-	// not found in the list
-	return false, nil
+	found, expiration := ls.entries.lookup(ipa, now)
+	return found, expiration, nil
 }
 
 func (ls *ipList) numEntries() int {
-	return len(ls.entries)
+	return ls.count
 }
@@ -0,0 +1,158 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"math/bits"
+	"net"
+	"time"
+)
+
+type (
+	// ipTrie is a path-compressed binary radix tree of IP prefixes. Looking up an address
+	// takes time proportional to the address length, regardless of the number of prefixes.
+	ipTrie struct {
+		v4 *ipTrieNode
+		v6 *ipTrieNode
+	}
+
+	// ipTrieNode is a prefix in the trie. Nodes which only branch the trie are not entries
+	// of the list themselves.
+	ipTrieNode struct {
+		addr       []byte
+		ones       int
+		entry      bool
+		expiration time.Time
+		children   [2]*ipTrieNode
+	}
+)
+
+// insert adds the prefix ipnet to the trie. A zero expiration means the prefix does not expire.
+func (t *ipTrie) insert(ipnet *net.IPNet, expiration time.Time) {
+	ones, size := ipnet.Mask.Size()
+	addr := []byte(ipnet.IP)
+	root := &t.v6
+	if ip4 := ipnet.IP.To4(); ip4 != nil && ones >= size-32 {
+		// IPv4 and IPv4-mapped IPv6 prefixes both match IPv4 addresses
+		addr = []byte(ip4)
+		ones -= size - 32
+		root = &t.v4
+	}
+
+	leaf := &ipTrieNode{addr: addr, ones: ones, entry: true, expiration: expiration}
+	for n := root; ; {
+		cur := *n
+		if cur == nil {
+			*n = leaf
+			return
+		}
+
+		common := commonPrefixLen(cur.addr, addr, min(cur.ones, ones))
+		switch {
+		case common == cur.ones && common == ones:
+			// the prefix is already in the trie, possibly as a branching node
+			if cur.entry {
+				expiration = laterExpiration(cur.expiration, expiration)
+			}
+			cur.entry = true
+			cur.expiration = expiration
+			return
+
+		case common == cur.ones:
+			// the prefix is within cur
+			n = &cur.children[bitAt(addr, cur.ones)]
+
+		case common == ones:
+			// cur is within the prefix
+			leaf.children[bitAt(cur.addr, ones)] = cur
+			*n = leaf
+			return
+
+		default:
+			// the prefix and cur diverge after common bits
+			branch := &ipTrieNode{addr: maskAddr(addr, common), ones: common}
+			branch.children[bitAt(cur.addr, common)] = cur
+			branch.children[bitAt(addr, common)] = leaf
+			*n = branch
+			return
+		}
+	}
+}
+
+// lookup returns whether ip is within a prefix of the trie that has not expired at now, and
+// when the last of the matching prefixes expires.
+func (t *ipTrie) lookup(ip net.IP, now time.Time) (bool, time.Time) {
+	addr := []byte(ip)
+	n := t.v6
+	if ip4 := ip.To4(); ip4 != nil {
+		addr = []byte(ip4)
+		n = t.v4
+	}
+
+	found := false
+	var expiration time.Time
+	for n != nil && commonPrefixLen(n.addr, addr, n.ones) == n.ones {
+		if n.entry && (n.expiration.IsZero() || now.Before(n.expiration)) {
+			if n.expiration.IsZero() {
+				return true, time.Time{}
+			}
+			if !found || n.expiration.After(expiration) {
+				expiration = n.expiration
+			}
+			found = true
+		}
+
+		if n.ones == len(addr)*8 {
+			break
+		}
+		n = n.children[bitAt(addr, n.ones)]
+	}
+
+	return found, expiration
+}
+
+// bitAt returns the bit of addr at position i, counting from the most significant bit.
+func bitAt(addr []byte, i int) int {
+	return int(addr[i/8]>>(7-uint(i%8))) & 1
+}
+
+// commonPrefixLen returns the number of leading bits a and b have in common, up to max.
+func commonPrefixLen(a, b []byte, max int) int {
+	n := 0
+	for i := 0; i < len(a) && i < len(b) && n < max; i++ {
+		if x := a[i] ^ b[i]; x != 0 {
+			n += bits.LeadingZeros8(x)
+			break
+		}
+		n += 8
+	}
+
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// maskAddr returns a copy of addr with all but the first ones bits cleared.
+func maskAddr(addr []byte, ones int) []byte {
+	return []byte(net.IP(addr).Mask(net.CIDRMask(ones, len(addr)*8)))
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
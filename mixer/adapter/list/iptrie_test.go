@@ -0,0 +1,162 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+)
+
+// linearIPList is the straightforward reference the trie is checked against.
+type linearIPList struct {
+	entries     []*net.IPNet
+	expirations []time.Time
+}
+
+func (l *linearIPList) lookup(ip net.IP, now time.Time) bool {
+	for i, ipnet := range l.entries {
+		if ipnet.Contains(ip) && (l.expirations[i].IsZero() || now.Before(l.expirations[i])) {
+			return true
+		}
+	}
+	return false
+}
+
+func randomIP(r *rand.Rand, size int) net.IP {
+	ip := make(net.IP, size)
+	_, _ = r.Read(ip)
+
+	// concentrate addresses in a few ranges, so that prefixes overlap
+	ip[0] = byte(r.Intn(4))
+	return ip
+}
+
+func TestIPTrie(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	now := time.Now()
+
+	for _, size := range []int{net.IPv4len, net.IPv6len} {
+		trie := &ipTrie{}
+		ref := &linearIPList{}
+
+		for i := 0; i < 2000; i++ {
+			ip := randomIP(r, size)
+			ones := r.Intn(size*8 + 1)
+			ipnet := &net.IPNet{IP: ip.Mask(net.CIDRMask(ones, size*8)), Mask: net.CIDRMask(ones, size*8)}
+			if ones < 6 {
+				// avoid matching everything
+				continue
+			}
+
+			var expiration time.Time
+			switch r.Intn(3) {
+			case 1:
+				expiration = now.Add(-time.Minute)
+			case 2:
+				expiration = now.Add(time.Minute)
+			}
+
+			trie.insert(ipnet, expiration)
+			ref.entries = append(ref.entries, ipnet)
+			ref.expirations = append(ref.expirations, expiration)
+		}
+
+		for i := 0; i < 20000; i++ {
+			ip := randomIP(r, size)
+			if i%2 == 0 {
+				// look up addresses within the prefixes as well
+				entry := ref.entries[r.Intn(len(ref.entries))]
+				for j := range ip {
+					ip[j] = ip[j]&^entry.Mask[j] | entry.IP[j]
+				}
+			}
+
+			found, _ := trie.lookup(ip, now)
+			if expected := ref.lookup(ip, now); found != expected {
+				t.Fatalf("lookup(%v) = %v, expecting %v", ip, found, expected)
+			}
+		}
+	}
+}
+
+func TestIPTrie_Mixed(t *testing.T) {
+	now := time.Now()
+	expiration := now.Add(time.Minute)
+
+	trie := &ipTrie{}
+	for _, entry := range []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.1.1", "2001:db8::/32", "::ffff:172.16.0.0/108"} {
+		ipnet, err := parseIPNet(entry)
+		if err != nil {
+			t.Fatalf("Unable to parse %s: %v", entry, err)
+		}
+		trie.insert(ipnet, time.Time{})
+	}
+	ipnet, _ := parseIPNet("11.0.0.0/8")
+	trie.insert(ipnet, expiration)
+
+	cases := []struct {
+		ip         string
+		found      bool
+		expiration time.Time
+	}{
+		{"10.1.2.3", true, time.Time{}},
+		{"10.200.2.3", true, time.Time{}},
+		{"::ffff:10.1.2.3", true, time.Time{}},
+		{"11.1.2.3", true, expiration},
+		{"12.1.2.3", false, time.Time{}},
+		{"192.168.1.1", true, time.Time{}},
+		{"192.168.1.2", false, time.Time{}},
+		{"172.16.1.1", true, time.Time{}},
+		{"172.32.1.1", false, time.Time{}},
+		{"2001:db8::1", true, time.Time{}},
+		{"2001:db9::1", false, time.Time{}},
+	}
+
+	for _, c := range cases {
+		found, exp := trie.lookup(net.ParseIP(c.ip), now)
+		if found != c.found || !exp.Equal(c.expiration) {
+			t.Errorf("lookup(%s) = %v, %v, expecting %v, %v", c.ip, found, exp, c.found, c.expiration)
+		}
+	}
+}
+
+func BenchmarkIPList(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+
+	entries := make([]string, 0, 100000)
+	for i := 0; i < cap(entries); i++ {
+		ip := randomIP(r, net.IPv4len)
+		entries = append(entries, fmt.Sprintf("%v/%d", ip, 16+r.Intn(17)))
+	}
+
+	l, err := parseIPList(nil, entries)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	symbols := make([]string, 1000)
+	for i := range symbols {
+		symbols[i] = randomIP(r, net.IPv4len).String()
+	}
+
+	now := time.Now()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = l.checkList(symbols[i%len(symbols)], now)
+	}
+}
@@ -16,6 +16,12 @@
 
 // Package list provides an adapter that implements the listEntry
 // template to enable blacklist / whitelist checking of values.
+//
+// Lists are fetched from an HTTP provider, or read from a local file for
+// file:// provider URLs, and are refreshed periodically. Local files are also
+// watched, so that their changes are picked up as soon as they are written.
+// Any list entry may carry an expiration time, after which it is no longer
+// part of the list.
 package list // import "istio.io/istio/mixer/adapter/list"
 
 import (
@@ -24,13 +30,15 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/howeyc/fsnotify"
+
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/adapter/list/config"
 	"istio.io/istio/mixer/pkg/adapter"
@@ -42,6 +50,7 @@ type (
 	handler struct {
 		log           adapter.Logger
 		closing       chan bool
+		closeOnce     sync.Once
 		refreshTicker *time.Ticker
 		purgeTimer    *time.Timer
 		config        config.Params
@@ -52,17 +61,58 @@ type (
 
 		latestSHA [sha1.Size]byte
 
+		// path of the list for file:// provider URLs
+		providerPath string
+
+		// watches the directory of providerPath, if any. Closed by listRefresher.
+		watcher *fsnotify.Watcher
+
 		// indirection to enable fault injection
 		readAll func(io.Reader) ([]byte, error)
+
+		// indirection to support fast deterministic tests
+		getTime func() time.Time
 	}
 
 	// a specific list we use to check against
 	list interface {
-		checkList(symbol string) (bool, error)
+		// checkList returns whether symbol is in the list at the given time, and when the
+		// matching entry expires. A zero expiration means that the entry does not expire.
+		checkList(symbol string, now time.Time) (bool, time.Time, error)
 		numEntries() int
 	}
 )
 
+// expirationSeparator separates a list entry from its optional expiration time, which is
+// formatted according to RFC 3339. For example: "10.1.2.3;expires=2018-06-01T00:00:00Z".
+const expirationSeparator = ";expires="
+
+// parseEntry splits a list entry into its value and its expiration time. The expiration
+// time is zero for entries which don't expire.
+func parseEntry(entry string) (string, time.Time, error) {
+	idx := strings.LastIndex(entry, expirationSeparator)
+	if idx < 0 {
+		return entry, time.Time{}, nil
+	}
+
+	expiration, err := time.Parse(time.RFC3339, entry[idx+len(expirationSeparator):])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not parse expiration of list entry %s: %v", entry, err)
+	}
+	return entry[:idx], expiration, nil
+}
+
+// laterExpiration returns the later of two expiration times, where a zero time never expires.
+func laterExpiration(a, b time.Time) time.Time {
+	if a.IsZero() || b.IsZero() {
+		return time.Time{}
+	}
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
 ///////////////// Runtime Methods ///////////////
 
 func (h *handler) HandleListEntry(_ context.Context, entry *listentry.Instance) (adapter.CheckResult, error) {
@@ -76,10 +126,17 @@ func (h *handler) HandleListEntry(_ context.Context, entry *listentry.Instance)
 		return adapter.CheckResult{}, err
 	}
 
-	found, err := l.checkList(entry.Value)
+	now := h.getTime()
+	found, expiration, err := l.checkList(entry.Value, now)
 	code := rpc.OK
 	msg := ""
 
+	validDuration := h.config.CachingInterval
+	if found && !expiration.IsZero() && expiration.Sub(now) < validDuration {
+		// the answer must not be cached past the expiration of the entry
+		validDuration = expiration.Sub(now)
+	}
+
 	if err != nil {
 		code = rpc.INVALID_ARGUMENT
 		msg = err.Error()
@@ -95,29 +152,59 @@ func (h *handler) HandleListEntry(_ context.Context, entry *listentry.Instance)
 
 	return adapter.CheckResult{
 		Status:        status.WithMessage(code, msg),
-		ValidDuration: h.config.CachingInterval,
+		ValidDuration: validDuration,
 		ValidUseCount: h.config.CachingUseCount,
 	}, nil
 }
 
 func (h *handler) Close() error {
-	close(h.closing)
+	h.closeOnce.Do(func() {
+		close(h.closing)
 
-	if h.refreshTicker != nil {
-		h.refreshTicker.Stop()
-		h.purgeTimer.Stop()
-	}
+		if h.refreshTicker != nil {
+			h.refreshTicker.Stop()
+			h.purgeTimer.Stop()
+		}
+	})
 
 	return nil
 }
 
-// listRefresher updates the list by polling from the provider on a fixed interval
+// watchDebounceDelay is the time to wait after a change to a watched list file
+// before reading it, so that the events of a single update cause a single read.
+const watchDebounceDelay = 100 * time.Millisecond
+
+// listRefresher updates the list by polling from the provider on a fixed interval,
+// and whenever the watched list file changes.
 func (h *handler) listRefresher() {
+	var events <-chan *fsnotify.FileEvent
+	var watchErrors <-chan error
+	if h.watcher != nil {
+		defer func() { _ = h.watcher.Close() }()
+		events = h.watcher.Event
+		watchErrors = h.watcher.Error
+	}
+
+	var changed <-chan time.Time
 	for {
 		select {
 		case <-h.refreshTicker.C:
 			h.fetchList()
 
+		case <-events:
+			// any file of the directory may be a symlink to the list, as in
+			// ConfigMap volumes. Unchanged lists are detected by fetchList.
+			if changed == nil {
+				changed = time.After(watchDebounceDelay)
+			}
+
+		case <-changed:
+			changed = nil
+			h.fetchList()
+
+		case err := <-watchErrors:
+			h.log.Warningf("Error while watching %s: %v", h.config.ProviderUrl, err)
+
 		case <-h.purgeTimer.C:
 			h.purgeList()
 
@@ -144,24 +231,16 @@ func (h *handler) fetchList() {
 	if h.config.ProviderUrl != "" {
 		h.log.Infof("Fetching list from %s", h.config.ProviderUrl)
 
-		var resp *http.Response
-		resp, err = http.Get(h.config.ProviderUrl)
-		if err != nil || resp.StatusCode != http.StatusOK {
+		if h.providerPath != "" {
+			buf, err = ioutil.ReadFile(h.providerPath)
 			if err != nil {
-				err = h.log.Errorf("could not fetch list from %s: %v", h.config.ProviderUrl, err)
-			} else {
-				err = h.log.Errorf("could not fetch list from %s: %v", h.config.ProviderUrl, resp.StatusCode)
+				err = h.log.Errorf("could not read list from %s: %v", h.config.ProviderUrl, err)
 			}
-			h.lock.Lock()
-			h.lastFetchError = err
-			h.lock.Unlock()
-			return
+		} else {
+			buf, err = h.fetchURL()
 		}
 
-		// TODO: could lead to OOM since this is unbounded
-		buf, err = h.readAll(resp.Body)
 		if err != nil {
-			err = h.log.Errorf("Could not read from %s: %v", h.config.ProviderUrl, err)
 			h.lock.Lock()
 			h.lastFetchError = err
 			h.lock.Unlock()
@@ -182,27 +261,21 @@ func (h *handler) fetchList() {
 
 	switch h.config.EntryType {
 	case config.STRINGS:
-		l = parseStringList(buf, h.config.Overrides)
+		l, err = parseStringList(buf, h.config.Overrides)
 	case config.CASE_INSENSITIVE_STRINGS:
-		l = parseCaseInsensitiveStringList(buf, h.config.Overrides)
+		l, err = parseCaseInsensitiveStringList(buf, h.config.Overrides)
 	case config.IP_ADDRESSES:
 		l, err = parseIPList(buf, h.config.Overrides)
-		if err != nil {
-			err = h.log.Errorf("Could not parse data from %s: %v", h.config.ProviderUrl, err)
-			h.lock.Lock()
-			h.lastFetchError = err
-			h.lock.Unlock()
-			return
-		}
 	case config.REGEX:
 		l, err = parseRegexList(buf, h.config.Overrides)
-		if err != nil {
-			err = h.log.Errorf("Could not parse data from %s: %v", h.config.ProviderUrl, err)
-			h.lock.Lock()
-			h.lastFetchError = err
-			h.lock.Unlock()
-			return
-		}
+	}
+
+	if err != nil {
+		err = h.log.Errorf("Could not parse data from %s: %v", h.config.ProviderUrl, err)
+		h.lock.Lock()
+		h.lastFetchError = err
+		h.lock.Unlock()
+		return
 	}
 
 	// install the new list
@@ -217,6 +290,26 @@ func (h *handler) fetchList() {
 	h.resetPurgeTimer()
 }
 
+// fetchURL retrieves the list from an HTTP provider.
+func (h *handler) fetchURL() ([]byte, error) {
+	resp, err := http.Get(h.config.ProviderUrl)
+	if err != nil {
+		return nil, h.log.Errorf("could not fetch list from %s: %v", h.config.ProviderUrl, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, h.log.Errorf("could not fetch list from %s: %v", h.config.ProviderUrl, resp.StatusCode)
+	}
+
+	// TODO: could lead to OOM since this is unbounded
+	buf, err := h.readAll(resp.Body)
+	if err != nil {
+		return nil, h.log.Errorf("Could not read from %s: %v", h.config.ProviderUrl, err)
+	}
+	return buf, nil
+}
+
 func (h *handler) resetPurgeTimer() {
 	if h.purgeTimer == nil {
 		return
@@ -279,6 +372,10 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 		u, err := url.Parse(ac.ProviderUrl)
 		if err != nil {
 			ce = ce.Append("providerUrl", err)
+		} else if u.Scheme == "file" {
+			if u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+				ce = ce.Appendf("providerUrl", "file URL must have an absolute path and no host")
+			}
 		} else if u.Scheme == "" || u.Host == "" {
 			ce = ce.Appendf("providerUrl", "URL scheme and host cannot be empty")
 		}
@@ -300,16 +397,16 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 		ce = ce.Appendf("cachingUseCount", "caching use count must be >= 0, it is %v", ac.CachingUseCount)
 	}
 
-	if ac.EntryType == config.IP_ADDRESSES {
-		for _, ip := range ac.Overrides {
-			orig := ip
-			if !strings.Contains(ip, "/") {
-				ip += "/32"
-			}
+	for _, entry := range ac.Overrides {
+		ip, _, err := parseEntry(entry)
+		if err != nil {
+			ce = ce.Appendf("overrides", "%v", err)
+			continue
+		}
 
-			_, _, err := net.ParseCIDR(ip)
-			if err != nil {
-				ce = ce.Appendf("overrides", "could not parse override %s: %v", orig, err)
+		if ac.EntryType == config.IP_ADDRESSES {
+			if _, err = parseIPNet(ip); err != nil {
+				ce = ce.Appendf("overrides", "could not parse override %s: %v", ip, err)
 			}
 		}
 	}
@@ -325,6 +422,11 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 		closing: make(chan bool),
 		config:  *ac,
 		readAll: ioutil.ReadAll,
+		getTime: time.Now,
+	}
+
+	if u, err := url.Parse(ac.ProviderUrl); err == nil && u.Scheme == "file" {
+		h.providerPath = u.Path
 	}
 
 	if ac.ProviderUrl != "" {
@@ -336,9 +438,29 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 	h.fetchList()
 
 	if ac.ProviderUrl != "" {
+		if h.providerPath != "" {
+			h.watcher = h.watch(filepath.Dir(h.providerPath))
+		}
+
 		// goroutine to periodically refresh the list
 		env.ScheduleDaemon(h.listRefresher)
 	}
 
 	return h, nil
 }
+
+// watch returns a watcher of the given directory, or nil if it cannot be watched,
+// in which case the list is only refreshed periodically.
+func (h *handler) watch(dir string) *fsnotify.Watcher {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		h.log.Warningf("Unable to watch %s, it will be refreshed periodically: %v", h.config.ProviderUrl, err)
+		return nil
+	}
+	if err = w.Watch(dir); err != nil {
+		_ = w.Close()
+		h.log.Warningf("Unable to watch %s, it will be refreshed periodically: %v", h.config.ProviderUrl, err)
+		return nil
+	}
+	return w
+}
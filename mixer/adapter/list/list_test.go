@@ -18,8 +18,11 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -30,6 +33,7 @@ import (
 
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/adapter/list/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/listentry"
 )
@@ -620,6 +624,31 @@ func TestValidateConfig(t *testing.T) {
 			cfg:   config.Params{EntryType: config.IP_ADDRESSES, Overrides: []string{"1.2.3.4"}},
 			field: "",
 		},
+
+		{
+			cfg:   config.Params{EntryType: config.IP_ADDRESSES, Overrides: []string{"1.2.3.4;expires=2018-06-01T00:00:00Z"}},
+			field: "",
+		},
+
+		{
+			cfg:   config.Params{Overrides: []string{"ABC;expires=tomorrow"}},
+			field: "overrides",
+		},
+
+		{
+			cfg:   config.Params{ProviderUrl: "file:///etc/list.txt", RefreshInterval: 1 * time.Second, Ttl: 2 * time.Second},
+			field: "",
+		},
+
+		{
+			cfg:   config.Params{ProviderUrl: "file://list.txt", RefreshInterval: 1 * time.Second, Ttl: 2 * time.Second},
+			field: "providerUrl",
+		},
+
+		{
+			cfg:   config.Params{ProviderUrl: "file:list.txt", RefreshInterval: 1 * time.Second, Ttl: 2 * time.Second},
+			field: "providerUrl",
+		},
 	}
 
 	for i, c := range cases {
@@ -641,3 +670,177 @@ func TestValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "list")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "list.txt")
+	if err = ioutil.WriteFile(path, []byte("ABC\nDEF\n"), 0644); err != nil {
+		t.Fatalf("Unable to write list: %v", err)
+	}
+
+	cfg := config.Params{
+		ProviderUrl:     "file://" + path,
+		RefreshInterval: 10000 * time.Second,
+		Ttl:             20000 * time.Second,
+		EntryType:       config.STRINGS,
+	}
+	info := GetInfo()
+	b := info.NewBuilder().(*builder)
+	b.SetAdapterConfig(&cfg)
+
+	if ce := b.Validate(); ce != nil {
+		t.Fatalf("Got error %v, expecting success", ce)
+	}
+
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	leh := h.(*handler)
+	defer func() { _ = leh.Close() }()
+
+	code := func(symbol string) rpc.Code {
+		result, err := leh.HandleListEntry(context.Background(), &listentry.Instance{Value: symbol})
+		if err != nil {
+			t.Fatalf("Got error %v, expecting success", err)
+		}
+		return rpc.Code(result.Status.Code)
+	}
+	check := func(symbol string, expected rpc.Code) {
+		if got := code(symbol); got != expected {
+			t.Errorf("%s: got '%v', expecting '%v'", symbol, got, expected)
+		}
+	}
+	// the file is watched, so changes are picked up long before the next refresh.
+	eventually := func(what string, cond func() bool) {
+		for start := time.Now(); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+		}
+	}
+
+	check("ABC", rpc.OK)
+	check("GHI", rpc.NOT_FOUND)
+
+	if err = ioutil.WriteFile(path, []byte("GHI\n"), 0644); err != nil {
+		t.Fatalf("Unable to write list: %v", err)
+	}
+	eventually("the updated list", func() bool { return code("GHI") == rpc.OK })
+	check("ABC", rpc.NOT_FOUND)
+
+	// keep the last list while the file is unreadable
+	if err = os.Remove(path); err != nil {
+		t.Fatalf("Unable to remove list: %v", err)
+	}
+	eventually("the read error", func() bool {
+		leh.lock.Lock()
+		defer leh.lock.Unlock()
+		return leh.lastFetchError != nil
+	})
+	check("GHI", rpc.OK)
+}
+
+func TestEntryExpiration(t *testing.T) {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		entryType config.Params_ListEntryType
+		overrides []string
+		symbol    string
+	}{
+		{config.STRINGS, []string{"ABC;expires=2018-06-01T00:01:00Z"}, "ABC"},
+		{config.CASE_INSENSITIVE_STRINGS, []string{"ABC;expires=2018-06-01T00:01:00Z"}, "abc"},
+		{config.IP_ADDRESSES, []string{"10.0.0.0/8;expires=2018-06-01T00:01:00Z"}, "10.1.2.3"},
+		{config.REGEX, []string{"A.C;expires=2018-06-01T00:01:00Z"}, "ABC"},
+
+		// the last expiration wins for duplicate entries
+		{config.STRINGS, []string{"ABC;expires=2018-06-01T00:00:30Z", "ABC;expires=2018-06-01T00:01:00Z"}, "ABC"},
+		{config.IP_ADDRESSES, []string{"10.1.0.0/16;expires=2018-06-01T00:00:30Z", "10.0.0.0/8;expires=2018-06-01T00:01:00Z"}, "10.1.2.3"},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cfg := &config.Params{
+				Overrides:       c.overrides,
+				EntryType:       c.entryType,
+				CachingInterval: 5 * time.Minute,
+			}
+			info := GetInfo()
+			b := info.NewBuilder().(*builder)
+			b.SetAdapterConfig(cfg)
+
+			if ce := b.Validate(); ce != nil {
+				t.Fatalf("Got error %v, expecting success", ce)
+			}
+
+			h, err := b.Build(context.Background(), test.NewEnv(t))
+			if err != nil {
+				t.Fatalf("Got error %v, expecting success", err)
+			}
+			leh := h.(*handler)
+
+			leh.getTime = func() time.Time { return now }
+			result, err := leh.HandleListEntry(context.Background(), &listentry.Instance{Value: c.symbol})
+			if err != nil {
+				t.Fatalf("Got error %v, expecting success", err)
+			}
+			if result.Status.Code != int32(rpc.OK) {
+				t.Errorf("Got '%v', expecting '%v'", result.Status.Code, rpc.OK)
+			}
+			if result.ValidDuration != time.Minute {
+				t.Errorf("Got valid duration %v, expecting %v", result.ValidDuration, time.Minute)
+			}
+
+			leh.getTime = func() time.Time { return now.Add(time.Minute) }
+			result, err = leh.HandleListEntry(context.Background(), &listentry.Instance{Value: c.symbol})
+			if err != nil {
+				t.Fatalf("Got error %v, expecting success", err)
+			}
+			if result.Status.Code != int32(rpc.NOT_FOUND) {
+				t.Errorf("Got '%v', expecting '%v'", result.Status.Code, rpc.NOT_FOUND)
+			}
+			if result.ValidDuration != cfg.CachingInterval {
+				t.Errorf("Got valid duration %v, expecting %v", result.ValidDuration, cfg.CachingInterval)
+			}
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "list")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "list.txt")
+	if err = ioutil.WriteFile(path, []byte("ABC\nDEF\n"), 0644); err != nil {
+		t.Fatalf("Unable to write list: %v", err)
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Config: &config.Params{
+			ProviderUrl:     "file://" + path,
+			RefreshInterval: 1 * time.Second,
+			Ttl:             2 * time.Second,
+			EntryType:       config.STRINGS,
+		},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{ProviderUrl: "file://list.txt", RefreshInterval: 1 * time.Second, Ttl: 2 * time.Second},
+			&config.Params{CachingInterval: -1},
+		},
+		Instances: map[string][]interface{}{
+			listentry.TemplateName: {
+				&listentry.Instance{Name: "allowed", Value: "ABC"},
+				&listentry.Instance{Name: "denied", Value: "XYZ"},
+			},
+		},
+	})
+}
@@ -17,49 +17,75 @@ package list
 import (
 	"regexp"
 	"strings"
+	"time"
 )
 
 type regexList struct {
-	regexpList []*regexp.Regexp
+	regexpList []regexEntry
 }
 
-func (l *regexList) checkList(symbol string) (bool, error) {
-	for _, exp := range l.regexpList {
-		if exp.MatchString(symbol) {
-			return true, nil
+type regexEntry struct {
+	exp        *regexp.Regexp
+	expiration time.Time
+}
+
+func (l *regexList) checkList(symbol string, now time.Time) (bool, time.Time, error) {
+	found := false
+	var expiration time.Time
+	for _, e := range l.regexpList {
+		if !e.expiration.IsZero() && !now.Before(e.expiration) {
+			continue
+		}
+
+		if e.exp.MatchString(symbol) {
+			if e.expiration.IsZero() {
+				return true, time.Time{}, nil
+			}
+			if !found || e.expiration.After(expiration) {
+				expiration = e.expiration
+			}
+			found = true
 		}
 	}
-	return false, nil
+	return found, expiration, nil
 }
 
 func (l *regexList) numEntries() int {
 	return len(l.regexpList)
 }
 
-// parseRegexList parses regexp list from buf and overrides. buf is assumed to be '\n' separated regular expressions.
-// Each entry in overrides is a regular expression as well. regexList accepts RE2 regex syntax.
-// See https://github.com/google/re2/wiki/Syntax
-// Example:
-// buf:  "a+.*\nabc" expands to two regex, "a+.*" and "abc"
 func parseRegexList(buf []byte, overrides []string) (*regexList, error) {
 	lines := strings.Split(string(buf), "\n")
-	entries := make([]*regexp.Regexp, 0, len(lines)+len(overrides))
+	entries := make([]regexEntry, 0, len(lines)+len(overrides))
 	for _, line := range lines {
 		if line != "" {
-			exp, err := regexp.Compile(line)
+			e, err := parseRegexEntry(line)
 			if err != nil {
 				return nil, err
 			}
-			entries = append(entries, exp)
+			entries = append(entries, e)
 		}
 	}
 
 	for _, override := range overrides {
-		exp, err := regexp.Compile(override)
+		e, err := parseRegexEntry(override)
 		if err != nil {
 			return nil, err
 		}
-		entries = append(entries, exp)
+		entries = append(entries, e)
 	}
 	return &regexList{entries}, nil
 }
+
+func parseRegexEntry(entry string) (regexEntry, error) {
+	s, expiration, err := parseEntry(entry)
+	if err != nil {
+		return regexEntry{}, err
+	}
+
+	exp, err := regexp.Compile(s)
+	if err != nil {
+		return regexEntry{}, err
+	}
+	return regexEntry{exp, expiration}, nil
+}
@@ -16,68 +16,77 @@ package list
 
 import (
 	"strings"
+	"time"
 )
 
 type stringList struct {
-	entries map[string]bool
+	entries map[string]time.Time
 }
 
 type caseInsensitiveStringList struct {
-	entries map[string]bool
+	entries map[string]time.Time
 }
 
-func parseStringList(buf []byte, overrides []string) list {
-	lines := strings.Split(string(buf), "\n")
-
-	entries := make(map[string]bool, len(lines)+len(overrides))
-
-	// copy the main strings
-	for _, s := range lines {
-		if s != "" {
-			entries[s] = true
-		}
+func parseStringList(buf []byte, overrides []string) (list, error) {
+	entries, err := parseStringEntries(buf, overrides, func(s string) string { return s })
+	if err != nil {
+		return nil, err
 	}
+	return &stringList{entries}, nil
+}
 
-	// apply overrides
-	for _, s := range overrides {
-		if s != "" {
-			entries[s] = true
-		}
+func parseCaseInsensitiveStringList(buf []byte, overrides []string) (list, error) {
+	entries, err := parseStringEntries(buf, overrides, strings.ToUpper)
+	if err != nil {
+		return nil, err
 	}
-
-	return &stringList{entries}
+	return &caseInsensitiveStringList{entries}, nil
 }
 
-func parseCaseInsensitiveStringList(buf []byte, overrides []string) list {
+// parseStringEntries returns the expiration of every line of buf and override, keyed by their
+// normalized value.
+func parseStringEntries(buf []byte, overrides []string, normalize func(string) string) (map[string]time.Time, error) {
 	lines := strings.Split(string(buf), "\n")
 
-	entries := make(map[string]bool, len(lines)+len(overrides))
-
-	// copy the main strings
-	for _, s := range lines {
-		if s != "" {
-			entries[strings.ToUpper(s)] = true
+	entries := make(map[string]time.Time, len(lines)+len(overrides))
+
+	// copy the main strings, then apply overrides
+	for _, ss := range [][]string{lines, overrides} {
+		for _, s := range ss {
+			if s == "" {
+				continue
+			}
+
+			s, expiration, err := parseEntry(s)
+			if err != nil {
+				return nil, err
+			}
+
+			s = normalize(s)
+			if prev, ok := entries[s]; ok {
+				expiration = laterExpiration(prev, expiration)
+			}
+			entries[s] = expiration
 		}
 	}
 
-	// apply overrides
-	for _, s := range overrides {
-		if s != "" {
-			entries[strings.ToUpper(s)] = true
-		}
-	}
+	return entries, nil
+}
 
-	return &caseInsensitiveStringList{entries}
+func checkStringEntry(entries map[string]time.Time, symbol string, now time.Time) (bool, time.Time, error) {
+	expiration, ok := entries[symbol]
+	if !ok || !(expiration.IsZero() || now.Before(expiration)) {
+		return false, time.Time{}, nil
+	}
+	return true, expiration, nil
 }
 
-func (ls *stringList) checkList(symbol string) (bool, error) {
-	_, ok := ls.entries[symbol]
-	return ok, nil
+func (ls *stringList) checkList(symbol string, now time.Time) (bool, time.Time, error) {
+	return checkStringEntry(ls.entries, symbol, now)
 }
 
-func (ls *caseInsensitiveStringList) checkList(symbol string) (bool, error) {
-	_, ok := ls.entries[strings.ToUpper(symbol)]
-	return ok, nil
+func (ls *caseInsensitiveStringList) checkList(symbol string, now time.Time) (bool, time.Time, error) {
+	return checkStringEntry(ls.entries, strings.ToUpper(symbol), now)
 }
 
 func (ls *stringList) numEntries() int {
@@ -28,6 +28,8 @@ import _ "github.com/gogo/protobuf/gogoproto"
 
 import time "time"
 
+import strconv "strconv"
+
 import github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
 
 import strings "strings"
@@ -48,12 +50,68 @@ var _ = time.Kitchen
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+// Algorithm selects how rate limit quotas are tracked.
+type Params_Algorithm int32
+
+const (
+	// Allocations are tracked in a window of 1/10th second slots rolling
+	// with time. Allocations are reclaimed when their slot moves out of
+	// the window. This is the default value.
+	ROLLING_WINDOW Params_Algorithm = 0
+	// Every allocation is logged with its time and reclaimed exactly
+	// valid_duration after it was made. Memory use grows with the number
+	// of allocations in the window.
+	SLIDING_LOG Params_Algorithm = 1
+	// Allocations are taken from a bucket holding up to burst tokens,
+	// refilled at a rate of max_amount tokens per valid_duration.
+	TOKEN_BUCKET Params_Algorithm = 2
+)
+
+var Params_Algorithm_name = map[int32]string{
+	0: "ROLLING_WINDOW",
+	1: "SLIDING_LOG",
+	2: "TOKEN_BUCKET",
+}
+var Params_Algorithm_value = map[string]int32{
+	"ROLLING_WINDOW": 0,
+	"SLIDING_LOG":    1,
+	"TOKEN_BUCKET":   2,
+}
+
+func (Params_Algorithm) EnumDescriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0, 0} }
+
 // Configuration format for the `memquota` adapter.
+//
+// Example configuration:
+//
+// ```yaml
+// quotas:
+// - name: requestcount.quota.istio-system
+//   maxAmount: 500
+//   validDuration: 1s
+//   algorithm: TOKEN_BUCKET
+//   burst: 1000
+//   overrides:
+//   - dimensions:
+//       destination: ratings
+//       source: "*"
+//     maxAmount: 100
+//     burst: 100
+// minDeduplicationDuration: 1s
+// debugAddress: "localhost:9095"
+// ```
 type Params struct {
 	// The set of known quotas.
 	Quotas []Params_Quota `protobuf:"bytes,1,rep,name=quotas" json:"quotas"`
 	// Minimum number of seconds that deduplication is possible for a given operation.
 	MinDeduplicationDuration time.Duration `protobuf:"bytes,2,opt,name=min_deduplication_duration,json=minDeduplicationDuration,stdduration" json:"min_deduplication_duration"`
+	// Address of an HTTP endpoint exporting the current consumption of
+	// every quota key as JSON at `/debug/quotas`. The endpoint is disabled
+	// when empty, which is the default.
+	DebugAddress string `protobuf:"bytes,3,opt,name=debug_address,json=debugAddress,proto3" json:"debug_address,omitempty"`
+	// How often the consumption exported by the debug endpoint is
+	// refreshed. Defaults to 10 seconds.
+	DebugExportInterval time.Duration `protobuf:"bytes,4,opt,name=debug_export_interval,json=debugExportInterval,stdduration" json:"debug_export_interval"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -72,6 +130,12 @@ type Params_Quota struct {
 	// Overrides associated with this quota.
 	// The first matching override is applied.
 	Overrides []Params_Override `protobuf:"bytes,4,rep,name=overrides" json:"overrides"`
+	// The algorithm used to track this quota when it is a rate limit
+	// quota. Overrides use the algorithm of their quota.
+	Algorithm Params_Algorithm `protobuf:"varint,5,opt,name=algorithm,proto3,enum=adapter.memquota.config.Params_Algorithm" json:"algorithm,omitempty"`
+	// The most that can be allocated at once by the TOKEN_BUCKET
+	// algorithm. Defaults to max_amount.
+	Burst int64 `protobuf:"varint,6,opt,name=burst,proto3" json:"burst,omitempty"`
 }
 
 func (m *Params_Quota) Reset()                    { *m = Params_Quota{} }
@@ -106,9 +170,24 @@ func (m *Params_Quota) GetOverrides() []Params_Override {
 	return nil
 }
 
+func (m *Params_Quota) GetAlgorithm() Params_Algorithm {
+	if m != nil {
+		return m.Algorithm
+	}
+	return ROLLING_WINDOW
+}
+
+func (m *Params_Quota) GetBurst() int64 {
+	if m != nil {
+		return m.Burst
+	}
+	return 0
+}
+
 type Params_Override struct {
 	// The specific dimensions for which this override applies.
 	// String representation of instance dimensions is used to check against configured dimensions.
+	// A value of "*" matches any value, as long as the instance has the dimension.
 	Dimensions map[string]string `protobuf:"bytes,1,rep,name=dimensions" json:"dimensions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// The upper limit for this quota.
 	MaxAmount int64 `protobuf:"varint,2,opt,name=max_amount,json=maxAmount,proto3" json:"max_amount,omitempty"`
@@ -116,6 +195,9 @@ type Params_Override struct {
 	// automatically released. This is only meaningful for rate limit
 	// quotas, otherwise the value must be zero.
 	ValidDuration time.Duration `protobuf:"bytes,3,opt,name=valid_duration,json=validDuration,stdduration" json:"valid_duration"`
+	// The most that can be allocated at once by the TOKEN_BUCKET
+	// algorithm. Defaults to max_amount.
+	Burst int64 `protobuf:"varint,4,opt,name=burst,proto3" json:"burst,omitempty"`
 }
 
 func (m *Params_Override) Reset()                    { *m = Params_Override{} }
@@ -143,10 +225,25 @@ func (m *Params_Override) GetValidDuration() time.Duration {
 	return 0
 }
 
+func (m *Params_Override) GetBurst() int64 {
+	if m != nil {
+		return m.Burst
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Params)(nil), "adapter.memquota.config.Params")
 	proto.RegisterType((*Params_Quota)(nil), "adapter.memquota.config.Params.Quota")
 	proto.RegisterType((*Params_Override)(nil), "adapter.memquota.config.Params.Override")
+	proto.RegisterEnum("adapter.memquota.config.Params_Algorithm", Params_Algorithm_name, Params_Algorithm_value)
+}
+func (x Params_Algorithm) String() string {
+	s, ok := Params_Algorithm_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
 }
 func (m *Params) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -183,6 +280,20 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		return 0, err
 	}
 	i += n1
+	if len(m.DebugAddress) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.DebugAddress)))
+		i += copy(dAtA[i:], m.DebugAddress)
+	}
+	dAtA[i] = 0x22
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.DebugExportInterval)))
+	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.DebugExportInterval, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n2
 	return i, nil
 }
 
@@ -215,11 +326,11 @@ func (m *Params_Quota) MarshalTo(dAtA []byte) (int, error) {
 	dAtA[i] = 0x1a
 	i++
 	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.ValidDuration)))
-	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ValidDuration, dAtA[i:])
+	n3, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ValidDuration, dAtA[i:])
 	if err != nil {
 		return 0, err
 	}
-	i += n2
+	i += n3
 	if len(m.Overrides) > 0 {
 		for _, msg := range m.Overrides {
 			dAtA[i] = 0x22
@@ -232,6 +343,16 @@ func (m *Params_Quota) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.Algorithm != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.Algorithm))
+	}
+	if m.Burst != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.Burst))
+	}
 	return i, nil
 }
 
@@ -275,11 +396,16 @@ func (m *Params_Override) MarshalTo(dAtA []byte) (int, error) {
 	dAtA[i] = 0x1a
 	i++
 	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.ValidDuration)))
-	n3, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ValidDuration, dAtA[i:])
+	n4, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ValidDuration, dAtA[i:])
 	if err != nil {
 		return 0, err
 	}
-	i += n3
+	i += n4
+	if m.Burst != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.Burst))
+	}
 	return i, nil
 }
 
@@ -303,6 +429,12 @@ func (m *Params) Size() (n int) {
 	}
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.MinDeduplicationDuration)
 	n += 1 + l + sovConfig(uint64(l))
+	l = len(m.DebugAddress)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.DebugExportInterval)
+	n += 1 + l + sovConfig(uint64(l))
 	return n
 }
 
@@ -324,6 +456,12 @@ func (m *Params_Quota) Size() (n int) {
 			n += 1 + l + sovConfig(uint64(l))
 		}
 	}
+	if m.Algorithm != 0 {
+		n += 1 + sovConfig(uint64(m.Algorithm))
+	}
+	if m.Burst != 0 {
+		n += 1 + sovConfig(uint64(m.Burst))
+	}
 	return n
 }
 
@@ -343,6 +481,9 @@ func (m *Params_Override) Size() (n int) {
 	}
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.ValidDuration)
 	n += 1 + l + sovConfig(uint64(l))
+	if m.Burst != 0 {
+		n += 1 + sovConfig(uint64(m.Burst))
+	}
 	return n
 }
 
@@ -366,6 +507,8 @@ func (this *Params) String() string {
 	s := strings.Join([]string{`&Params{`,
 		`Quotas:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Quotas), "Params_Quota", "Params_Quota", 1), `&`, ``, 1) + `,`,
 		`MinDeduplicationDuration:` + strings.Replace(strings.Replace(this.MinDeduplicationDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`DebugAddress:` + fmt.Sprintf("%v", this.DebugAddress) + `,`,
+		`DebugExportInterval:` + strings.Replace(strings.Replace(this.DebugExportInterval.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -379,6 +522,8 @@ func (this *Params_Quota) String() string {
 		`MaxAmount:` + fmt.Sprintf("%v", this.MaxAmount) + `,`,
 		`ValidDuration:` + strings.Replace(strings.Replace(this.ValidDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
 		`Overrides:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Overrides), "Params_Override", "Params_Override", 1), `&`, ``, 1) + `,`,
+		`Algorithm:` + fmt.Sprintf("%v", this.Algorithm) + `,`,
+		`Burst:` + fmt.Sprintf("%v", this.Burst) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -401,6 +546,7 @@ func (this *Params_Override) String() string {
 		`Dimensions:` + mapStringForDimensions + `,`,
 		`MaxAmount:` + fmt.Sprintf("%v", this.MaxAmount) + `,`,
 		`ValidDuration:` + strings.Replace(strings.Replace(this.ValidDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`Burst:` + fmt.Sprintf("%v", this.Burst) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -503,6 +649,65 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DebugAddress", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DebugAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DebugExportInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.DebugExportInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -662,6 +867,44 @@ func (m *Params_Quota) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Algorithm", wireType)
+			}
+			m.Algorithm = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Algorithm |= (Params_Algorithm(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Burst", wireType)
+			}
+			m.Burst = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Burst |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -879,6 +1122,25 @@ func (m *Params_Override) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Burst", wireType)
+			}
+			m.Burst = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Burst |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -1008,34 +1270,43 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/memquota/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 450 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x91, 0x3f, 0x8b, 0x14, 0x31,
-	0x18, 0xc6, 0x93, 0xfd, 0xc7, 0xed, 0x7b, 0xf8, 0x87, 0x70, 0xe0, 0x38, 0x60, 0x76, 0x11, 0x84,
-	0xc5, 0x22, 0x03, 0x67, 0xb3, 0x1c, 0x58, 0xb8, 0xae, 0x8d, 0x08, 0xea, 0x54, 0x62, 0xb3, 0xe4,
-	0x6e, 0x72, 0x43, 0x70, 0x93, 0xac, 0xd9, 0x99, 0x65, 0xaf, 0xb3, 0xb4, 0xb4, 0xb0, 0xb0, 0xb4,
-	0xb0, 0xf0, 0xa3, 0x6c, 0x79, 0xa5, 0x58, 0xa8, 0x33, 0x36, 0x96, 0xf7, 0x11, 0x64, 0x92, 0x19,
-	0xef, 0x10, 0xc4, 0xad, 0xae, 0x9a, 0x77, 0x92, 0xe7, 0x79, 0xde, 0x1f, 0x4f, 0xe0, 0xae, 0x92,
-	0x6b, 0x61, 0x23, 0x9e, 0xf0, 0x45, 0x26, 0x6c, 0xa4, 0x84, 0x7a, 0x9d, 0x9b, 0x8c, 0x47, 0x47,
-	0x46, 0x1f, 0xcb, 0xb4, 0xfe, 0xb0, 0x85, 0x35, 0x99, 0x21, 0x37, 0x6a, 0x15, 0x6b, 0x54, 0xcc,
-	0x5f, 0x87, 0x34, 0x35, 0x26, 0x9d, 0x8b, 0xc8, 0xc9, 0x0e, 0xf3, 0xe3, 0x28, 0xc9, 0x2d, 0xcf,
-	0xa4, 0xd1, 0xde, 0x18, 0xee, 0xa5, 0x26, 0x35, 0x6e, 0x8c, 0xaa, 0xc9, 0x9f, 0xde, 0xfe, 0xd4,
-	0x85, 0xde, 0x33, 0x6e, 0xb9, 0x5a, 0x92, 0x87, 0xd0, 0x73, 0x81, 0xcb, 0x00, 0x0f, 0xdb, 0xa3,
-	0xdd, 0xfd, 0x3b, 0xec, 0x1f, 0xab, 0x98, 0x37, 0xb0, 0xe7, 0xd5, 0xd9, 0xa4, 0xb3, 0xf9, 0x36,
-	0x40, 0x71, 0x6d, 0x25, 0x1c, 0x42, 0x25, 0xf5, 0x2c, 0x11, 0x49, 0xbe, 0x98, 0xcb, 0x23, 0x07,
-	0x30, 0x6b, 0x48, 0x82, 0xd6, 0x10, 0x8f, 0x76, 0xf7, 0x6f, 0x32, 0x8f, 0xca, 0x1a, 0x54, 0x36,
-	0xad, 0x05, 0x93, 0x9d, 0x2a, 0xec, 0xc3, 0xf7, 0x01, 0x8e, 0x03, 0x25, 0xf5, 0xf4, 0x62, 0x4a,
-	0xa3, 0x09, 0xbf, 0x62, 0xe8, 0xba, 0xd5, 0x84, 0x40, 0x47, 0x73, 0x25, 0x02, 0x3c, 0xc4, 0xa3,
-	0x7e, 0xec, 0x66, 0x72, 0x0b, 0x40, 0xf1, 0xf5, 0x8c, 0x2b, 0x93, 0xeb, 0xcc, 0x2d, 0x6c, 0xc7,
-	0x7d, 0xc5, 0xd7, 0x0f, 0xdc, 0x01, 0x79, 0x0c, 0x57, 0x57, 0x7c, 0x2e, 0x93, 0x73, 0xa6, 0xf6,
-	0xf6, 0x4c, 0x57, 0x9c, 0xb5, 0xb9, 0x20, 0x4f, 0xa0, 0x6f, 0x56, 0xc2, 0x5a, 0x99, 0x88, 0x65,
-	0xd0, 0x71, 0x9d, 0x8d, 0xfe, 0xd7, 0xd9, 0xd3, 0xda, 0x50, 0xd7, 0x76, 0x1e, 0x70, 0xd0, 0x79,
-	0xfb, 0x71, 0x80, 0xc3, 0xf7, 0x2d, 0xd8, 0x69, 0x34, 0xe4, 0x05, 0x40, 0x22, 0x95, 0xd0, 0x4b,
-	0x69, 0x74, 0xf3, 0x2a, 0xe3, 0x6d, 0x37, 0xb0, 0xe9, 0x1f, 0xeb, 0x23, 0x9d, 0xd9, 0x93, 0xf8,
-	0x42, 0xd6, 0x25, 0xb6, 0x14, 0xde, 0x87, 0x6b, 0x7f, 0x91, 0x90, 0xeb, 0xd0, 0x7e, 0x25, 0x4e,
-	0xea, 0x67, 0xab, 0x46, 0xb2, 0x07, 0xdd, 0x15, 0x9f, 0xe7, 0xc2, 0xa1, 0xf4, 0x63, 0xff, 0x73,
-	0xd0, 0x1a, 0x63, 0x5f, 0xcb, 0x64, 0xbc, 0x29, 0x28, 0x3a, 0x2d, 0x28, 0xfa, 0x52, 0x50, 0x74,
-	0x56, 0x50, 0xf4, 0xa6, 0xa4, 0xf8, 0x73, 0x49, 0xd1, 0xa6, 0xa4, 0xf8, 0xb4, 0xa4, 0xf8, 0x47,
-	0x49, 0xf1, 0xaf, 0x92, 0xa2, 0xb3, 0x92, 0xe2, 0x77, 0x3f, 0x29, 0x7a, 0xd9, 0xf3, 0xad, 0x1c,
-	0xf6, 0x1c, 0xea, 0xbd, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xb0, 0x4b, 0x3f, 0x47, 0x64, 0x03,
-	0x00, 0x00,
+	// 599 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x52, 0x3f, 0x6f, 0xd3, 0x4e,
+	0x18, 0xf6, 0x25, 0x69, 0x7e, 0xf5, 0xf5, 0x5f, 0x74, 0xbf, 0x22, 0x8c, 0x25, 0xae, 0x51, 0x11,
+	0x52, 0x60, 0xb0, 0xa5, 0xb2, 0x54, 0x95, 0x18, 0x9a, 0x26, 0xaa, 0x42, 0xa3, 0x06, 0x4c, 0x51,
+	0x11, 0x8b, 0x75, 0xa9, 0xaf, 0xe6, 0x84, 0xcf, 0x17, 0xce, 0x76, 0x94, 0x6e, 0x8c, 0x8c, 0x8c,
+	0x8c, 0x8c, 0x88, 0x6f, 0xc0, 0x37, 0xe8, 0xd8, 0x11, 0x09, 0x09, 0x88, 0x59, 0x18, 0xfb, 0x11,
+	0x90, 0xcf, 0x76, 0x5a, 0x21, 0xa1, 0x76, 0x62, 0xf2, 0x7b, 0xaf, 0xdf, 0xe7, 0xb9, 0xe7, 0x79,
+	0xde, 0x83, 0xf7, 0x39, 0x9b, 0x50, 0x69, 0x13, 0x8f, 0x8c, 0x62, 0x2a, 0x6d, 0x4e, 0xf9, 0xeb,
+	0x44, 0xc4, 0xc4, 0x3e, 0x12, 0xe1, 0x31, 0xf3, 0x8b, 0x8f, 0x35, 0x92, 0x22, 0x16, 0xe8, 0x66,
+	0x31, 0x65, 0x95, 0x53, 0x56, 0xfe, 0xdb, 0xc4, 0xbe, 0x10, 0x7e, 0x40, 0x6d, 0x35, 0x36, 0x4c,
+	0x8e, 0x6d, 0x2f, 0x91, 0x24, 0x66, 0x22, 0xcc, 0x81, 0xe6, 0xaa, 0x2f, 0x7c, 0xa1, 0x4a, 0x3b,
+	0xab, 0xf2, 0xee, 0xfa, 0xd7, 0xff, 0x60, 0xfd, 0x31, 0x91, 0x84, 0x47, 0x68, 0x07, 0xd6, 0x15,
+	0x61, 0x64, 0x80, 0x66, 0xb5, 0xb5, 0xb0, 0x71, 0xd7, 0xfa, 0xcb, 0x55, 0x56, 0x0e, 0xb0, 0x9e,
+	0x64, 0xbd, 0x76, 0xed, 0xf4, 0xdb, 0x9a, 0xe6, 0x14, 0x50, 0x44, 0xa0, 0xc9, 0x59, 0xe8, 0x7a,
+	0xd4, 0x4b, 0x46, 0x01, 0x3b, 0x52, 0x02, 0xdc, 0x52, 0x89, 0x51, 0x69, 0x82, 0xd6, 0xc2, 0xc6,
+	0x2d, 0x2b, 0x97, 0x6a, 0x95, 0x52, 0xad, 0x4e, 0x31, 0xd0, 0x9e, 0xcf, 0xc8, 0xde, 0x7f, 0x5f,
+	0x03, 0x8e, 0xc1, 0x59, 0xd8, 0xb9, 0xcc, 0x52, 0xce, 0xa0, 0x3b, 0x70, 0xc9, 0xa3, 0xc3, 0xc4,
+	0x77, 0x89, 0xe7, 0x49, 0x1a, 0x45, 0x46, 0xb5, 0x09, 0x5a, 0xba, 0xb3, 0xa8, 0x9a, 0xdb, 0x79,
+	0x0f, 0x1d, 0xc2, 0x1b, 0xf9, 0x10, 0x9d, 0x8c, 0x84, 0x8c, 0x5d, 0x16, 0xc6, 0x54, 0x8e, 0x49,
+	0x60, 0xd4, 0xae, 0x2f, 0xe1, 0x7f, 0xc5, 0xd0, 0x55, 0x04, 0xbd, 0x02, 0x6f, 0x7e, 0xae, 0xc0,
+	0x39, 0x65, 0x1c, 0x21, 0x58, 0x0b, 0x09, 0xa7, 0x06, 0x50, 0xd7, 0xab, 0x1a, 0xdd, 0x86, 0x90,
+	0x93, 0x89, 0x4b, 0xb8, 0x48, 0xc2, 0x58, 0xd9, 0xad, 0x3a, 0x3a, 0x27, 0x93, 0x6d, 0xd5, 0x40,
+	0x8f, 0xe0, 0xf2, 0x98, 0x04, 0xcc, 0xbb, 0x48, 0xa4, 0x7a, 0x7d, 0x39, 0x4b, 0x0a, 0x3a, 0x8b,
+	0xa1, 0x0f, 0x75, 0x31, 0xa6, 0x52, 0x32, 0x8f, 0x46, 0x46, 0x4d, 0x6d, 0xac, 0x75, 0xd5, 0xc6,
+	0x06, 0x05, 0xa0, 0x58, 0xda, 0x05, 0x01, 0xda, 0x85, 0x3a, 0x09, 0x7c, 0x21, 0x59, 0xfc, 0x92,
+	0x1b, 0x73, 0x4d, 0xd0, 0x5a, 0xde, 0xb8, 0x77, 0x15, 0xdb, 0x76, 0x09, 0x70, 0x2e, 0xb0, 0x68,
+	0x15, 0xce, 0x0d, 0x13, 0x19, 0xc5, 0x46, 0x5d, 0x99, 0xcf, 0x0f, 0x5b, 0xb5, 0xb7, 0x1f, 0xd6,
+	0x80, 0xf9, 0xa9, 0x02, 0xe7, 0x4b, 0x09, 0xe8, 0x39, 0x84, 0x1e, 0xe3, 0x34, 0x8c, 0x98, 0x08,
+	0xcb, 0x27, 0xb7, 0x79, 0x5d, 0x03, 0x56, 0x67, 0x06, 0xed, 0x86, 0xb1, 0x3c, 0x71, 0x2e, 0x71,
+	0xfd, 0xcb, 0x25, 0xcc, 0xdc, 0xd6, 0x2e, 0xb9, 0x35, 0x1f, 0xc2, 0x95, 0x3f, 0xf4, 0xa1, 0x06,
+	0xac, 0xbe, 0xa2, 0x27, 0xc5, 0x5b, 0xc9, 0xca, 0x0c, 0x3a, 0x26, 0x41, 0x42, 0x95, 0x40, 0xdd,
+	0xc9, 0x0f, 0x5b, 0x95, 0x4d, 0x90, 0x87, 0xb5, 0xde, 0x86, 0xfa, 0x2c, 0x60, 0x84, 0xe0, 0xb2,
+	0x33, 0xe8, 0xf7, 0x7b, 0xfb, 0xbb, 0xee, 0x61, 0x6f, 0xbf, 0x33, 0x38, 0x6c, 0x68, 0x68, 0x05,
+	0x2e, 0x3c, 0xed, 0xf7, 0x3a, 0x59, 0xaf, 0x3f, 0xd8, 0x6d, 0x00, 0xd4, 0x80, 0x8b, 0x07, 0x83,
+	0xbd, 0xee, 0xbe, 0xdb, 0x7e, 0xb6, 0xb3, 0xd7, 0x3d, 0x68, 0x54, 0xda, 0x9b, 0xa7, 0x53, 0xac,
+	0x9d, 0x4d, 0xb1, 0xf6, 0x65, 0x8a, 0xb5, 0xf3, 0x29, 0xd6, 0xde, 0xa4, 0x18, 0x7c, 0x4c, 0xb1,
+	0x76, 0x9a, 0x62, 0x70, 0x96, 0x62, 0xf0, 0x23, 0xc5, 0xe0, 0x57, 0x8a, 0xb5, 0xf3, 0x14, 0x83,
+	0x77, 0x3f, 0xb1, 0xf6, 0xa2, 0x9e, 0xe7, 0x3d, 0xac, 0xab, 0x10, 0x1e, 0xfc, 0x1e, 0x00, 0xc9,
+	0x8f, 0xec, 0x87, 0x9b, 0x04, 0x00, 0x00,
 }
@@ -0,0 +1,174 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+const (
+	debugPath                  = "/debug/quotas"
+	defaultDebugExportInterval = 10 * time.Second
+)
+
+type (
+	// usageSnapshot is the consumption of every quota key at a point in time.
+	usageSnapshot struct {
+		Time time.Time  `json:"time"`
+		Keys []keyUsage `json:"keys"`
+	}
+
+	// keyUsage is the consumption of a single quota key.
+	keyUsage struct {
+		Key  string `json:"key"`
+		Used int64  `json:"used"`
+	}
+
+	// debugServer serves the consumption exported by memquota handlers. Handlers
+	// configured with the same address share a server, which serves the handler
+	// that started using it last.
+	debugServer struct {
+		addr     string
+		listener net.Listener
+		srv      *http.Server
+
+		lock     sync.RWMutex // protects delegate
+		delegate http.Handler
+
+		refCnt int // protected by serversLock
+	}
+)
+
+var (
+	serversLock sync.Mutex
+	servers     = make(map[string]*debugServer)
+)
+
+// startDebugServer starts serving the given handler on the given address, or switches
+// the server already listening on the address over to the handler.
+func startDebugServer(addr string, h http.Handler, env adapter.Env) (*debugServer, error) {
+	serversLock.Lock()
+	defer serversLock.Unlock()
+
+	if s := servers[addr]; s != nil {
+		s.refCnt++
+		s.setDelegate(h)
+		return s, nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not start memquota debug server: %v", err)
+	}
+
+	s := &debugServer{
+		addr:     addr,
+		listener: listener,
+		delegate: h,
+		refCnt:   1,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(debugPath, s)
+	s.srv = &http.Server{Addr: addr, Handler: mux}
+
+	env.ScheduleDaemon(func() {
+		env.Logger().Infof("serving memquota consumption on %s%s", listener.Addr(), debugPath)
+		if err := s.srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			_ = env.Logger().Errorf("memquota debug server error: %v", err)
+		}
+	})
+	servers[addr] = s
+
+	return s, nil
+}
+
+func (s *debugServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.RLock()
+	h := s.delegate
+	s.lock.RUnlock()
+
+	h.ServeHTTP(w, r)
+}
+
+func (s *debugServer) setDelegate(h http.Handler) {
+	s.lock.Lock()
+	s.delegate = h
+	s.lock.Unlock()
+}
+
+// Close stops the server once every handler using it has closed it.
+func (s *debugServer) Close() error {
+	serversLock.Lock()
+	defer serversLock.Unlock()
+
+	s.refCnt--
+	if s.refCnt > 0 {
+		return nil
+	}
+
+	delete(servers, s.addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
+
+// exportUsage records the current consumption of every quota key, to be served by the debug endpoint.
+func (h *handler) exportUsage() {
+	h.common.Lock()
+
+	now := h.common.getTime()
+	keys := make([]keyUsage, 0, len(h.cells)+len(h.windows))
+	for k, inUse := range h.cells {
+		keys = append(keys, keyUsage{Key: k, Used: inUse})
+	}
+	for k, l := range h.windows {
+		keys = append(keys, keyUsage{Key: k, Used: l.capacity() - l.available(now)})
+	}
+
+	h.common.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+
+	h.usageLock.Lock()
+	h.usage = &usageSnapshot{Time: now, Keys: keys}
+	h.usageLock.Unlock()
+}
+
+// serveUsage serves the last recorded consumption of every quota key as JSON.
+func (h *handler) serveUsage(w http.ResponseWriter, _ *http.Request) {
+	h.usageLock.RLock()
+	usage := h.usage
+	h.usageLock.RUnlock()
+
+	if usage == nil {
+		usage = &usageSnapshot{Keys: []keyUsage{}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(usage); err != nil {
+		h.logger.Warningf("Unable to write quota consumption: %v", err)
+	}
+}
@@ -57,6 +57,11 @@ const (
 	nanosPerTick = int64(time.Second / ticksPerSecond)
 )
 
+// toTick returns the quantized time interval the given time falls in.
+func toTick(t time.Time) int64 {
+	return t.UnixNano() / nanosPerTick
+}
+
 // handleDedup is a wrapper function that handles dedupping semantics.
 func (du *dedupUtil) handleDedup(instance *quota.Instance, args adapter.QuotaArgs, qf quotaFunc) (int64, time.Duration, string, error) {
 	key := makeKey(instance.Name, instance.Dimensions)
@@ -64,7 +69,7 @@ func (du *dedupUtil) handleDedup(instance *quota.Instance, args adapter.QuotaArg
 	du.Lock()
 
 	currentTime := du.getTime()
-	currentTick := currentTime.UnixNano() / nanosPerTick
+	currentTick := toTick(currentTime)
 
 	var amount int64
 	var t time.Time
@@ -26,11 +26,19 @@
 // - Since the data is all memory-resident and there isn't any cross-node
 // synchronization, this adapter can't be used in an Istio mixer where
 // a single service can be handled by different mixer instances.
+//
+// Rate limit quotas are tracked by a rolling window, a sliding log or a
+// token bucket. Overrides are indexed by the dimensions they match, so
+// quotas can carry thousands of overrides, and an override dimension
+// value of "*" matches any value. The current consumption of every quota
+// key can be exported as JSON through a debug endpoint.
 package memquota
 
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"istio.io/istio/mixer/adapter/memquota/config"
@@ -46,68 +54,89 @@ type handler struct {
 	// the counters we track for non-expiring quotas, protected by lock
 	cells map[string]int64
 
-	// the rolling windows we track for expiring quotas, protected by lock
-	windows map[string]*rollingWindow
+	// the rate limiters we track for expiring quotas, protected by lock
+	windows map[string]rateLimiter
 
 	// the limits we know about
-	limits map[string]*config.Params_Quota
+	limits map[string]*quotaLimit
 
 	// logger provided by the framework
 	logger adapter.Logger
+
+	// the last recorded consumption of every quota key, protected by usageLock
+	usageLock sync.RWMutex
+	usage     *usageSnapshot
+
+	// the debug endpoint serving the consumption, nil when disabled
+	debug      *debugServer
+	exportDone chan struct{}
+
+	// closed when the handler is closed, to stop the background tasks
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // Limit is implemented by Quota and Override messages.
 type Limit interface {
 	GetMaxAmount() int64
 	GetValidDuration() time.Duration
+	GetBurst() int64
 }
 
-// matchDimensions matches configured dimensions with dimensions of the instance.
-func matchDimensions(cfg map[string]string, inst map[string]interface{}) bool {
-	for k, val := range cfg {
-		rval := inst[k]
-		if rval == val { // this dimension matches, on to next comparison.
-			continue
-		}
+// rateLimiter is implemented by the algorithms tracking expiring quotas.
+type rateLimiter interface {
+	// alloc allocates the given amount, returning false if there isn't enough room.
+	alloc(amount int64, now time.Time) bool
 
-		// if rval has a string representation then compare it with val
-		// For example net.ip has a useful string representation.
-		switch v := rval.(type) {
-		case fmt.Stringer:
-			if v.String() == val {
-				continue
-			}
+	// release releases up to the given amount, returning the amount released.
+	release(amount int64, now time.Time) int64
+
+	// available returns the amount that can currently be allocated.
+	available(now time.Time) int64
+
+	// capacity returns the most that can be available at once.
+	capacity() int64
+}
+
+// newRateLimiter creates a rate limiter for the given limit, tracked by the given algorithm.
+func newRateLimiter(algorithm config.Params_Algorithm, q Limit) rateLimiter {
+	switch algorithm {
+	case config.SLIDING_LOG:
+		return newSlidingLog(q.GetMaxAmount(), q.GetValidDuration())
+	case config.TOKEN_BUCKET:
+		burst := q.GetBurst()
+		if burst == 0 {
+			burst = q.GetMaxAmount()
 		}
-		// rval does not match val.
-		return false
+		return newTokenBucket(q.GetMaxAmount(), q.GetValidDuration(), burst)
+	default:
+		return newWindowLimiter(q.GetMaxAmount(), q.GetValidDuration())
 	}
-	return true
 }
 
 // limit returns the limit associated with this particular request.
 // Check if the instance matches an override, else return the default limit.
-func limit(cfg *config.Params_Quota, instance *quota.Instance, l adapter.Logger) Limit {
-	for idx := range cfg.Overrides {
-		o := cfg.Overrides[idx]
-		if matchDimensions(o.Dimensions, instance.Dimensions) {
-			if l.VerbosityLevel(4) {
-				l.Infof("quota override: %v selected for %v", o, *instance)
-			}
-			// all dimensions matched, we found the override.
-			return &o
+func limit(q *quotaLimit, instance *quota.Instance, l adapter.Logger) Limit {
+	if idx := q.overrides.find(instance.Dimensions); idx >= 0 {
+		o := &q.Overrides[idx]
+		if l.VerbosityLevel(4) {
+			l.Infof("quota override: %v selected for %v", o, *instance)
 		}
+		// all dimensions matched, we found the override.
+		return o
 	}
 	if l.VerbosityLevel(4) {
-		l.Infof("quota default: %v selected for %v", cfg.MaxAmount, *instance)
+		l.Infof("quota default: %v selected for %v", q.MaxAmount, *instance)
 	}
 	// no overrides, use default limit.
-	return cfg
+	return q.Params_Quota
 }
 
 func (h *handler) HandleQuota(context context.Context, instance *quota.Instance, args adapter.QuotaArgs) (adapter.QuotaResult, error) {
-	q := limit(h.limits[instance.Name], instance, h.logger)
+	ql := h.limits[instance.Name]
+	q := limit(ql, instance, h.logger)
 	if args.QuotaAmount > 0 {
-		return h.alloc(instance, args, q)
+		return h.alloc(instance, args, ql.GetAlgorithm(), q)
 	} else if args.QuotaAmount < 0 {
 		args.QuotaAmount = -args.QuotaAmount
 		return h.free(instance, args, q)
@@ -115,7 +144,7 @@ func (h *handler) HandleQuota(context context.Context, instance *quota.Instance,
 	return adapter.QuotaResult{}, nil
 }
 
-func (h *handler) alloc(instance *quota.Instance, args adapter.QuotaArgs, q Limit) (adapter.QuotaResult, error) {
+func (h *handler) alloc(instance *quota.Instance, args adapter.QuotaArgs, algorithm config.Params_Algorithm, q Limit) (adapter.QuotaResult, error) {
 	amount, exp, key, err := h.common.handleDedup(instance, args, func(key string, currentTime time.Time, currentTick int64) (int64, time.Time,
 		time.Duration) {
 		result := args.QuotaAmount
@@ -138,19 +167,18 @@ func (h *handler) alloc(instance *quota.Instance, args adapter.QuotaArgs, q Limi
 
 		window, ok := h.windows[key]
 		if !ok {
-			seconds := int32((q.GetValidDuration() + time.Second - 1) / time.Second)
-			window = newRollingWindow(q.GetMaxAmount(), int64(seconds)*ticksPerSecond)
+			window = newRateLimiter(algorithm, q)
 			h.windows[key] = window
 		}
 
-		if !window.alloc(result, currentTick) {
+		if !window.alloc(result, currentTime) {
 			if !args.BestEffort {
 				return 0, time.Time{}, 0
 			}
 
 			// grab as much as we can
-			result = window.available()
-			_ = window.alloc(result, currentTick)
+			result = window.available(currentTime)
+			_ = window.alloc(result, currentTime)
 		}
 
 		return result, currentTime.Add(q.GetValidDuration()), q.GetValidDuration()
@@ -194,9 +222,9 @@ func (h *handler) free(instance *quota.Instance, args adapter.QuotaArgs, q Limit
 			return 0, time.Time{}, 0
 		}
 
-		result = window.release(result, currentTick)
+		result = window.release(result, currentTime)
 
-		if window.available() == q.GetMaxAmount() {
+		if window.available(currentTime) == window.capacity() {
 			// delete the cell since it contains no useful state
 			delete(h.windows, key)
 		}
@@ -211,8 +239,17 @@ func (h *handler) free(instance *quota.Instance, args adapter.QuotaArgs, q Limit
 }
 
 func (h *handler) Close() error {
-	h.common.ticker.Stop()
-	return nil
+	var err error
+	h.closeOnce.Do(func() {
+		h.common.ticker.Stop()
+		close(h.done)
+
+		if h.debug != nil {
+			close(h.exportDone)
+			err = h.debug.Close()
+		}
+	})
+	return err
 }
 
 ////////////////// Config //////////////////////////
@@ -228,6 +265,7 @@ func GetInfo() adapter.Info {
 		},
 		DefaultConfig: &config.Params{
 			MinDeduplicationDuration: 1 * time.Second,
+			DebugExportInterval:      defaultDebugExportInterval,
 		},
 
 		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
@@ -248,9 +286,41 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	if ac.MinDeduplicationDuration <= 0 {
 		ce = ce.Appendf("minDeduplicationDuration", "deduplication window of %v is invalid, must be > 0", ac.MinDeduplicationDuration)
 	}
+
+	if ac.DebugExportInterval < 0 {
+		ce = ce.Appendf("debugExportInterval", "export interval of %v is invalid, must be >= 0", ac.DebugExportInterval)
+	}
+
+	for i, q := range ac.Quotas {
+		field := fmt.Sprintf("quotas[%d]", i)
+		ce = validateLimit(ce, field, q.Algorithm, &q)
+		for j, o := range q.Overrides {
+			ce = validateLimit(ce, fmt.Sprintf("%s.overrides[%d]", field, j), q.Algorithm, &o)
+		}
+	}
 	return
 }
 
+func validateLimit(ce *adapter.ConfigErrors, field string, algorithm config.Params_Algorithm, l Limit) *adapter.ConfigErrors {
+	if l.GetMaxAmount() < 0 {
+		ce = ce.Appendf(field+".maxAmount", "limit of %d is invalid, must be >= 0", l.GetMaxAmount())
+	}
+
+	if l.GetValidDuration() < 0 {
+		ce = ce.Appendf(field+".validDuration", "duration of %v is invalid, must be >= 0", l.GetValidDuration())
+	} else if algorithm != config.ROLLING_WINDOW && l.GetValidDuration() == 0 {
+		ce = ce.Appendf(field+".validDuration", "the %v algorithm only applies to rate limit quotas, validDuration must be > 0", algorithm)
+	}
+
+	if l.GetBurst() < 0 {
+		ce = ce.Appendf(field+".burst", "burst of %d is invalid, must be >= 0", l.GetBurst())
+	} else if l.GetBurst() > 0 && algorithm != config.TOKEN_BUCKET {
+		ce = ce.Appendf(field+".burst", "burst only applies to the %v algorithm", config.TOKEN_BUCKET)
+	}
+
+	return ce
+}
+
 func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handler, error) {
 	ac := b.adapterConfig
 	return b.buildWithDedup(context, env, time.NewTicker(ac.MinDeduplicationDuration))
@@ -259,10 +329,10 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 func (b *builder) buildWithDedup(_ context.Context, env adapter.Env, ticker *time.Ticker) (*handler, error) {
 	ac := b.adapterConfig
 
-	limits := make(map[string]*config.Params_Quota, len(ac.Quotas))
+	limits := make(map[string]*quotaLimit, len(ac.Quotas))
 	for idx := range ac.Quotas {
 		l := ac.Quotas[idx]
-		limits[l.Name] = &l
+		limits[l.Name] = newQuotaLimit(&l)
 	}
 
 	for k := range b.quotaTypes {
@@ -280,16 +350,51 @@ func (b *builder) buildWithDedup(_ context.Context, env adapter.Env, ticker *tim
 			logger:      env.Logger(),
 		},
 		cells:   make(map[string]int64),
-		windows: make(map[string]*rollingWindow),
+		windows: make(map[string]rateLimiter),
 		limits:  limits,
 		logger:  env.Logger(),
+		done:    make(chan struct{}),
+	}
+
+	if ac.DebugAddress != "" {
+		srv, err := startDebugServer(ac.DebugAddress, http.HandlerFunc(h.serveUsage), env)
+		if err != nil {
+			return nil, err
+		}
+		h.debug = srv
+		h.exportDone = make(chan struct{})
+
+		interval := ac.DebugExportInterval
+		if interval == 0 {
+			interval = defaultDebugExportInterval
+		}
+
+		h.exportUsage()
+		env.ScheduleDaemon(func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					h.exportUsage()
+				case <-h.exportDone:
+					return
+				}
+			}
+		})
 	}
 
 	env.ScheduleDaemon(func() {
-		for range h.common.ticker.C {
-			h.common.Lock()
-			h.common.reapDedup()
-			h.common.Unlock()
+		for {
+			select {
+			case <-h.common.ticker.C:
+				h.common.Lock()
+				h.common.reapDedup()
+				h.common.Unlock()
+			case <-h.done:
+				return
+			}
 		}
 	})
 
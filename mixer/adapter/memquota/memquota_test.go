@@ -16,11 +16,16 @@ package memquota
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+
 	"istio.io/istio/mixer/adapter/memquota/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
@@ -456,6 +461,52 @@ func TestHandler_Limit(t *testing.T) {
 			inst:  instIP,
 			limit: limit2,
 		},
+		{
+			desc: "override wildcard",
+			cfg: config.Params_Quota{
+				MaxAmount: limit1,
+				Overrides: []config.Params_Override{
+					{
+						Dimensions: map[string]string{"destination": "dest1", "source": "*"},
+						MaxAmount:  limit2,
+					},
+				},
+			},
+			inst:  inst1,
+			limit: limit2,
+		},
+		{
+			desc: "override wildcard missing dim",
+			cfg: config.Params_Quota{
+				MaxAmount: limit1,
+				Overrides: []config.Params_Override{
+					{
+						Dimensions: map[string]string{"source": "*"},
+						MaxAmount:  limit2,
+					},
+				},
+			},
+			inst:  inst2,
+			limit: limit1,
+		},
+		{
+			desc: "first override wins",
+			cfg: config.Params_Quota{
+				MaxAmount: limit1,
+				Overrides: []config.Params_Override{
+					{
+						Dimensions: map[string]string{"source": "*"},
+						MaxAmount:  limit2,
+					},
+					{
+						Dimensions: cfgDim1,
+						MaxAmount:  limit1 + limit2,
+					},
+				},
+			},
+			inst:  inst1,
+			limit: limit2,
+		},
 		{
 			desc: "override no dim",
 			cfg: config.Params_Quota{
@@ -473,7 +524,7 @@ func TestHandler_Limit(t *testing.T) {
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			env := test.NewEnv(t)
-			l := limit(&tc.cfg, &tc.inst, env.Logger())
+			l := limit(newQuotaLimit(&tc.cfg), &tc.inst, env.Logger())
 
 			if l.GetMaxAmount() != tc.limit {
 				t.Fatalf("got %v, want %v\n", l.GetMaxAmount(), tc.limit)
@@ -481,3 +532,279 @@ func TestHandler_Limit(t *testing.T) {
 		})
 	}
 }
+
+func TestAlgorithms(t *testing.T) {
+	cfg := config.Params{
+		MinDeduplicationDuration: time.Second,
+		Quotas: []config.Params_Quota{
+			{
+				Name:          "bucket",
+				MaxAmount:     10,
+				ValidDuration: time.Second,
+				Algorithm:     config.TOKEN_BUCKET,
+				Burst:         20,
+				Overrides: []config.Params_Override{
+					{
+						Dimensions:    map[string]string{"source": "*"},
+						MaxAmount:     1,
+						ValidDuration: time.Second,
+					},
+				},
+			},
+			{
+				Name:          "log",
+				MaxAmount:     10,
+				ValidDuration: time.Second,
+				Algorithm:     config.SLIDING_LOG,
+			},
+		},
+	}
+
+	info := GetInfo()
+	b := info.NewBuilder()
+	b.SetAdapterConfig(&cfg)
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	hndlr, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	h := hndlr.(*handler)
+
+	cases := []struct {
+		name        string
+		dims        map[string]interface{}
+		millis      int64
+		allocAmount int64
+		allocResult int64
+	}{
+		// the burst can be allocated at once, then tokens trickle in
+		{"bucket", nil, 0, 20, 20},
+		{"bucket", nil, 0, 1, 0},
+		{"bucket", nil, 500, 10, 5},
+
+		// overrides default their burst to their limit
+		{"bucket", map[string]interface{}{"source": "a"}, 0, 2, 1},
+		{"bucket", map[string]interface{}{"source": "a"}, 1000, 1, 1},
+
+		{"log", nil, 0, 10, 10},
+		{"log", nil, 999, 1, 0},
+		{"log", nil, 1000, 10, 10},
+	}
+
+	now := time.Now()
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h.common.getTime = func() time.Time {
+				return now.Add(time.Duration(c.millis) * time.Millisecond)
+			}
+
+			qa := adapter.QuotaArgs{
+				DeduplicationID: strconv.Itoa(i),
+				QuotaAmount:     c.allocAmount,
+				BestEffort:      true,
+			}
+
+			qr, err := h.HandleQuota(context.Background(), &quota.Instance{Name: c.name, Dimensions: c.dims}, qa)
+			if err != nil {
+				t.Errorf("Expecting success, got %v", err)
+			}
+
+			if qr.Amount != c.allocResult {
+				t.Errorf("Expecting %d, got %d", c.allocResult, qr.Amount)
+			}
+		})
+	}
+
+	if err := h.Close(); err != nil {
+		t.Errorf("Unable to close handler: %v", err)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	cases := []struct {
+		name   string
+		quota  config.Params_Quota
+		fields []string
+	}{
+		{"rolling window", config.Params_Quota{MaxAmount: 10}, nil},
+		{"token bucket", config.Params_Quota{MaxAmount: 10, ValidDuration: time.Second, Algorithm: config.TOKEN_BUCKET, Burst: 5}, nil},
+		{"negative amount", config.Params_Quota{MaxAmount: -1}, []string{"quotas[0].maxAmount"}},
+		{"negative duration", config.Params_Quota{MaxAmount: 10, ValidDuration: -1}, []string{"quotas[0].validDuration"}},
+		{"sliding log allocation quota", config.Params_Quota{MaxAmount: 10, Algorithm: config.SLIDING_LOG}, []string{"quotas[0].validDuration"}},
+		{"negative burst", config.Params_Quota{MaxAmount: 10, ValidDuration: time.Second, Algorithm: config.TOKEN_BUCKET, Burst: -1},
+			[]string{"quotas[0].burst"}},
+		{"burst without token bucket", config.Params_Quota{MaxAmount: 10, ValidDuration: time.Second, Burst: 5}, []string{"quotas[0].burst"}},
+		{"override", config.Params_Quota{
+			MaxAmount:     10,
+			ValidDuration: time.Second,
+			Algorithm:     config.TOKEN_BUCKET,
+			Overrides:     []config.Params_Override{{MaxAmount: 1}},
+		}, []string{"quotas[0].overrides[0].validDuration"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := GetInfo().NewBuilder()
+			b.SetAdapterConfig(&config.Params{
+				MinDeduplicationDuration: time.Second,
+				Quotas:                   []config.Params_Quota{c.quota},
+			})
+
+			ce := b.Validate()
+			if ce == nil {
+				if len(c.fields) > 0 {
+					t.Fatalf("Expecting errors for %v, got success", c.fields)
+				}
+				return
+			}
+
+			if len(ce.Multi.Errors) != len(c.fields) {
+				t.Fatalf("Expecting errors for %v, got %v", c.fields, ce)
+			}
+			for i, f := range c.fields {
+				if got := ce.Multi.Errors[i].(adapter.ConfigError).Field; got != f {
+					t.Errorf("Expecting error for %s, got %s", f, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDebugEndpoint(t *testing.T) {
+	cfg := config.Params{
+		MinDeduplicationDuration: time.Second,
+		DebugAddress:             "127.0.0.1:0",
+		DebugExportInterval:      time.Hour,
+		Quotas: []config.Params_Quota{
+			{Name: "cell", MaxAmount: 10},
+			{Name: "window", MaxAmount: 10, ValidDuration: time.Minute},
+		},
+	}
+
+	info := GetInfo()
+	b := info.NewBuilder()
+	b.SetAdapterConfig(&cfg)
+
+	hndlr, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	h := hndlr.(*handler)
+
+	for i, name := range []string{"cell", "window"} {
+		qa := adapter.QuotaArgs{DeduplicationID: strconv.Itoa(i), QuotaAmount: int64(3 + i)}
+		if _, err := h.HandleQuota(context.Background(), &quota.Instance{Name: name}, qa); err != nil {
+			t.Fatalf("Expecting success, got %v", err)
+		}
+	}
+
+	url := fmt.Sprintf("http://%s%s", h.debug.listener.Addr(), debugPath)
+
+	// consumption is only exported periodically
+	usage := getUsage(t, url)
+	if len(usage.Keys) != 0 {
+		t.Errorf("Expecting no consumption before the first export, got %v", usage.Keys)
+	}
+
+	h.exportUsage()
+
+	usage = getUsage(t, url)
+	want := []keyUsage{{Key: "cell", Used: 3}, {Key: "window", Used: 4}}
+	if len(usage.Keys) != len(want) {
+		t.Fatalf("Expecting %v, got %v", want, usage.Keys)
+	}
+	for i := range want {
+		if usage.Keys[i] != want[i] {
+			t.Errorf("Expecting %v, got %v", want[i], usage.Keys[i])
+		}
+	}
+
+	if err := h.Close(); err != nil {
+		t.Errorf("Unable to close handler: %v", err)
+	}
+
+	if _, err := http.Get(url); err == nil {
+		t.Error("Expecting the debug endpoint to be closed")
+	}
+}
+
+func TestDebugEndpoint_SharedAddress(t *testing.T) {
+	cfg := config.Params{
+		MinDeduplicationDuration: time.Second,
+		DebugAddress:             "127.0.0.1:0",
+	}
+
+	info := GetInfo()
+	b := info.NewBuilder()
+	b.SetAdapterConfig(&cfg)
+
+	h1, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	h2, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	if h1.(*handler).debug != h2.(*handler).debug {
+		t.Error("Expecting handlers to share the debug server")
+	}
+
+	url := fmt.Sprintf("http://%s%s", h2.(*handler).debug.listener.Addr(), debugPath)
+	if err := h1.Close(); err != nil {
+		t.Errorf("Unable to close handler: %v", err)
+	}
+
+	// the server stays up until the last handler closes
+	_ = getUsage(t, url)
+
+	if err := h2.Close(); err != nil {
+		t.Errorf("Unable to close handler: %v", err)
+	}
+}
+
+func getUsage(t *testing.T, url string) *usageSnapshot {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Unable to fetch consumption: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	usage := &usageSnapshot{}
+	if err := json.NewDecoder(resp.Body).Decode(usage); err != nil {
+		t.Fatalf("Unable to decode consumption: %v", err)
+	}
+	return usage
+}
+
+func TestConformance(t *testing.T) {
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Config: &config.Params{
+			MinDeduplicationDuration: time.Second,
+			DebugAddress:             "127.0.0.1:0",
+			DebugExportInterval:      time.Second,
+			Quotas: []config.Params_Quota{
+				{Name: "cell", MaxAmount: 10},
+				{Name: "window", MaxAmount: 10, ValidDuration: time.Minute},
+			},
+		},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{},
+			&config.Params{MinDeduplicationDuration: time.Second, Quotas: []config.Params_Quota{{Name: "q", MaxAmount: -1}}},
+		},
+		Types: map[string]map[string]proto.Message{
+			quota.TemplateName: {"cell": &quota.Type{}, "window": &quota.Type{}},
+		},
+		Instances: map[string][]interface{}{
+			quota.TemplateName: {
+				&quota.Instance{Name: "cell", Dimensions: map[string]interface{}{"source": "foo"}},
+				&quota.Instance{Name: "window"},
+			},
+		},
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/mixer/adapter/memquota/config"
+)
+
+// wildcard is the override dimension value matching any value of the dimension.
+const wildcard = "*"
+
+// separates dimension names and values in the keys of the override index
+const keySeparator = "\x00"
+
+// quotaLimit holds the configuration of a quota, along with an index of its overrides.
+type quotaLimit struct {
+	*config.Params_Quota
+
+	overrides *overrideIndex
+}
+
+func newQuotaLimit(cfg *config.Params_Quota) *quotaLimit {
+	return &quotaLimit{
+		Params_Quota: cfg,
+		overrides:    newOverrideIndex(cfg.Overrides),
+	}
+}
+
+// overrideIndex finds the first override matching the dimensions of an instance without
+// comparing the instance against every override.
+//
+// Overrides are grouped by the dimensions they match exactly and the dimensions they
+// match by wildcard. Within a group, overrides are indexed by the values of the dimensions
+// they match exactly. Finding an override takes one lookup per group, and there are
+// usually far fewer groups than overrides.
+type overrideIndex struct {
+	groups []*overrideGroup
+}
+
+// overrideGroup indexes overrides matching the same set of dimensions.
+type overrideGroup struct {
+	// the dimensions matched exactly, sorted
+	exact []string
+
+	// the dimensions matched by any value, sorted
+	wildcards []string
+
+	// maps the values of the exact dimensions to the position of the first override matching them
+	overrides map[string]int
+}
+
+func newOverrideIndex(overrides []config.Params_Override) *overrideIndex {
+	idx := &overrideIndex{}
+	groups := make(map[string]*overrideGroup)
+
+	for i := range overrides {
+		dims := overrides[i].Dimensions
+
+		var exact, wildcards []string
+		for k, v := range dims {
+			if v == wildcard {
+				wildcards = append(wildcards, k)
+			} else {
+				exact = append(exact, k)
+			}
+		}
+		sort.Strings(exact)
+		sort.Strings(wildcards)
+
+		name := strings.Join(exact, keySeparator) + "|" + strings.Join(wildcards, keySeparator)
+		g := groups[name]
+		if g == nil {
+			g = &overrideGroup{
+				exact:     exact,
+				wildcards: wildcards,
+				overrides: make(map[string]int),
+			}
+			groups[name] = g
+			idx.groups = append(idx.groups, g)
+		}
+
+		values := make([]string, len(exact))
+		for j, k := range exact {
+			values[j] = dims[k]
+		}
+
+		// the first matching override is applied, so later duplicates are ignored
+		key := strings.Join(values, keySeparator)
+		if _, ok := g.overrides[key]; !ok {
+			g.overrides[key] = i
+		}
+	}
+
+	return idx
+}
+
+// find returns the position of the first override matching the given dimensions, or -1.
+func (idx *overrideIndex) find(dims map[string]interface{}) int {
+	found := -1
+	for _, g := range idx.groups {
+		if i, ok := g.find(dims); ok && (found < 0 || i < found) {
+			found = i
+			if found == 0 {
+				break
+			}
+		}
+	}
+	return found
+}
+
+func (g *overrideGroup) find(dims map[string]interface{}) (int, bool) {
+	for _, k := range g.wildcards {
+		if _, ok := dims[k]; !ok {
+			return 0, false
+		}
+	}
+
+	values := make([]string, len(g.exact))
+	for j, k := range g.exact {
+		v, ok := dimensionString(dims[k])
+		if !ok {
+			return 0, false
+		}
+		values[j] = v
+	}
+
+	i, ok := g.overrides[strings.Join(values, keySeparator)]
+	return i, ok
+}
+
+// dimensionString returns the string representation of an instance dimension used to match
+// configured dimensions. For example net.ip has a useful string representation.
+func dimensionString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"istio.io/istio/mixer/adapter/memquota/config"
+)
+
+// findLinear is the reference implementation of overrideIndex.find, comparing every override in order.
+func findLinear(overrides []config.Params_Override, dims map[string]interface{}) int {
+	for i, o := range overrides {
+		matched := true
+		for k, val := range o.Dimensions {
+			v, ok := dims[k]
+			if !ok {
+				matched = false
+				break
+			}
+			if val == wildcard {
+				continue
+			}
+			if s, ok := dimensionString(v); !ok || s != val {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
+
+func manyOverrides(n int) []config.Params_Override {
+	overrides := make([]config.Params_Override, 0, n)
+	for i := 0; i < n; i++ {
+		dims := map[string]string{}
+		switch i % 4 {
+		case 0:
+			dims["destination"] = fmt.Sprintf("svc%d", i%50)
+			dims["source"] = fmt.Sprintf("svc%d", i%70)
+		case 1:
+			dims["destination"] = fmt.Sprintf("svc%d", i%50)
+		case 2:
+			dims["destination"] = fmt.Sprintf("svc%d", i%50)
+			dims["source"] = wildcard
+		case 3:
+			dims["source.ip"] = fmt.Sprintf("10.0.%d.%d", i/256%256, i%256)
+		}
+		overrides = append(overrides, config.Params_Override{Dimensions: dims, MaxAmount: int64(i)})
+	}
+	return overrides
+}
+
+func TestOverrideIndex(t *testing.T) {
+	overrides := manyOverrides(4000)
+	idx := newOverrideIndex(overrides)
+
+	if len(idx.groups) != 4 {
+		t.Errorf("Got %d groups, want 4", len(idx.groups))
+	}
+
+	for i := 0; i < 500; i++ {
+		cases := []map[string]interface{}{
+			{"destination": fmt.Sprintf("svc%d", i%60), "source": fmt.Sprintf("svc%d", i%80)},
+			{"destination": fmt.Sprintf("svc%d", i%60)},
+			{"source": fmt.Sprintf("svc%d", i%80)},
+			{"source.ip": net.ParseIP(fmt.Sprintf("10.0.%d.%d", i/256, i%256))},
+			{"source.ip": []byte{10, 0, 0, byte(i)}},
+			{},
+		}
+
+		for _, dims := range cases {
+			if got, want := idx.find(dims), findLinear(overrides, dims); got != want {
+				t.Fatalf("find(%v): got %d, want %d", dims, got, want)
+			}
+		}
+	}
+}
+
+func TestOverrideIndex_Empty(t *testing.T) {
+	idx := newOverrideIndex(nil)
+	if got := idx.find(map[string]interface{}{"destination": "svc1"}); got != -1 {
+		t.Errorf("Got %d, want -1", got)
+	}
+}
+
+func BenchmarkOverrideIndex(b *testing.B) {
+	idx := newOverrideIndex(manyOverrides(4000))
+	dims := map[string]interface{}{"destination": "svc49", "source": "svc69", "source.ip": net.ParseIP("10.0.0.1")}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.find(dims)
+	}
+}
@@ -14,6 +14,10 @@
 
 package memquota
 
+import (
+	"time"
+)
+
 // Implements a rolling window that allows N units to be allocated per rolling time interval.
 // Time is abstracted in terms of ticks, provided by the caller, decoupling the
 // implementation from real-time, enabling much easier testing and more flexibility.
@@ -112,3 +116,34 @@ func (w *rollingWindow) roll(currentTick int64) {
 func (w *rollingWindow) available() int64 {
 	return w.avail
 }
+
+// windowLimiter adapts a rollingWindow to the rateLimiter interface, quantizing time into ticks.
+type windowLimiter struct {
+	window *rollingWindow
+	limit  int64
+}
+
+func newWindowLimiter(limit int64, interval time.Duration) *windowLimiter {
+	seconds := int64((interval + time.Second - 1) / time.Second)
+	return &windowLimiter{
+		window: newRollingWindow(limit, seconds*ticksPerSecond),
+		limit:  limit,
+	}
+}
+
+func (l *windowLimiter) alloc(amount int64, now time.Time) bool {
+	return l.window.alloc(amount, toTick(now))
+}
+
+func (l *windowLimiter) release(amount int64, now time.Time) int64 {
+	return l.window.release(amount, toTick(now))
+}
+
+func (l *windowLimiter) available(now time.Time) int64 {
+	l.window.roll(toTick(now))
+	return l.window.available()
+}
+
+func (l *windowLimiter) capacity() int64 {
+	return l.limit
+}
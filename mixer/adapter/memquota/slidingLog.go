@@ -0,0 +1,125 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"time"
+)
+
+// Implements a sliding log that allows N units to be allocated per sliding time interval.
+// Unlike the rolling window, time isn't quantized: every allocation is logged along with
+// its time and reclaimed exactly one interval later. The price is memory proportional to
+// the number of allocations made within the interval.
+type slidingLog struct {
+	// the maximum amount that can be allocated within the interval
+	limit int64
+
+	// how long allocations are held for
+	interval time.Duration
+
+	// the allocations within the interval, oldest first
+	entries []logEntry
+
+	// the total # of units allocated within the interval
+	used int64
+}
+
+// An allocation recorded in a sliding log.
+type logEntry struct {
+	time   time.Time
+	amount int64
+}
+
+// Creates a new sliding log used to implement rate limiting.
+func newSlidingLog(limit int64, interval time.Duration) *slidingLog {
+	return &slidingLog{
+		limit:    limit,
+		interval: interval,
+	}
+}
+
+func (l *slidingLog) alloc(amount int64, now time.Time) bool {
+	l.expire(now)
+
+	if amount > l.limit-l.used {
+		// not enough room
+		return false
+	}
+
+	if amount == 0 {
+		return true
+	}
+
+	// coalesce allocations made at the same time
+	if n := len(l.entries); n > 0 && l.entries[n-1].time.Equal(now) {
+		l.entries[n-1].amount += amount
+	} else {
+		l.entries = append(l.entries, logEntry{time: now, amount: amount})
+	}
+	l.used += amount
+
+	return true
+}
+
+func (l *slidingLog) release(amount int64, now time.Time) int64 {
+	l.expire(now)
+
+	// we release the most recent allocations first, moving back in time
+	// until we've released enough or released everything, whichever is first...
+	var total int64
+	for len(l.entries) > 0 && amount > 0 {
+		last := &l.entries[len(l.entries)-1]
+
+		if last.amount > amount {
+			last.amount -= amount
+			total += amount
+			break
+		}
+
+		total += last.amount
+		amount -= last.amount
+		l.entries = l.entries[:len(l.entries)-1]
+	}
+
+	l.used -= total
+	return total
+}
+
+func (l *slidingLog) available(now time.Time) int64 {
+	l.expire(now)
+	return l.limit - l.used
+}
+
+func (l *slidingLog) capacity() int64 {
+	return l.limit
+}
+
+// expire reclaims the allocations that are now outside of the interval.
+func (l *slidingLog) expire(now time.Time) {
+	cutoff := now.Add(-l.interval)
+
+	i := 0
+	for i < len(l.entries) && !l.entries[i].time.After(cutoff) {
+		l.used -= l.entries[i].amount
+		i++
+	}
+
+	if i == len(l.entries) {
+		// reuse the backing array once the log drains
+		l.entries = l.entries[:0]
+	} else {
+		l.entries = l.entries[i:]
+	}
+}
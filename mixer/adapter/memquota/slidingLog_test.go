@@ -0,0 +1,85 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingLogAlloc(t *testing.T) {
+	cases := []struct {
+		amount int64
+		millis int64
+		avail  int64
+		result bool
+	}{
+		{6, 0, 5, false},
+		{1, 0, 4, true},
+		{2, 10, 2, true},
+		{2, 20, 0, true},
+		{1, 999, 0, false},
+		{1, 1000, 0, true}, // the allocation made at 0 has expired
+		{1, 1005, 0, false},
+		{1, 1010, 1, true}, // the allocation made at 10 has expired
+		{5, 3000, 0, true},
+	}
+
+	start := time.Now()
+	l := newSlidingLog(5, time.Second)
+
+	for i, c := range cases {
+		now := start.Add(time.Duration(c.millis) * time.Millisecond)
+		if ok := l.alloc(c.amount, now); ok != c.result {
+			t.Errorf("Expecting %v got %v, case %d", c.result, ok, i)
+		}
+
+		if avail := l.available(now); avail != c.avail {
+			t.Errorf("Expecting %d available, got %d, case %d", c.avail, avail, i)
+		}
+	}
+
+	if len(l.entries) != 1 {
+		t.Errorf("Expecting expired entries to be dropped, got %v", l.entries)
+	}
+}
+
+func TestSlidingLogRelease(t *testing.T) {
+	start := time.Now()
+	l := newSlidingLog(10, time.Second)
+
+	for i := 0; i < 3; i++ {
+		if !l.alloc(3, start.Add(time.Duration(i)*time.Millisecond)) {
+			t.Fatalf("Expecting to succeed, allocation %d", i)
+		}
+	}
+
+	// releases the most recent allocations first
+	if released := l.release(4, start.Add(2*time.Millisecond)); released != 4 {
+		t.Errorf("Expecting 4 released, got %d", released)
+	}
+	if len(l.entries) != 2 || l.entries[1].amount != 2 {
+		t.Errorf("Expecting the last allocation to be released, got %v", l.entries)
+	}
+
+	// the first allocation expires, leaving 2 to release
+	if released := l.release(100, start.Add(time.Second)); released != 2 {
+		t.Errorf("Expecting 2 released, got %d", released)
+	}
+
+	if avail := l.available(start.Add(time.Second)); avail != l.capacity() {
+		t.Errorf("Expecting %d available, got %d", l.capacity(), avail)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"time"
+)
+
+// tokenEpsilon absorbs the rounding errors of refilling the bucket in fractions of tokens.
+const tokenEpsilon = 1e-9
+
+// Implements a token bucket that holds up to burst tokens and is refilled at a steady
+// rate. Allocations take tokens from the bucket, allowing bursts of up to burst units
+// while bounding the long term rate to the refill rate.
+type tokenBucket struct {
+	// the most tokens the bucket can hold
+	burst int64
+
+	// the number of tokens added to the bucket per nanosecond
+	rate float64
+
+	// the tokens currently in the bucket
+	tokens float64
+
+	// the time the bucket was last refilled
+	last time.Time
+}
+
+// Creates a new token bucket used to implement rate limiting.
+//
+// The bucket starts full and is refilled with limit tokens per interval, never holding
+// more than burst tokens.
+func newTokenBucket(limit int64, interval time.Duration, burst int64) *tokenBucket {
+	return &tokenBucket{
+		burst:  burst,
+		rate:   float64(limit) / float64(interval),
+		tokens: float64(burst),
+	}
+}
+
+func (b *tokenBucket) alloc(amount int64, now time.Time) bool {
+	b.refill(now)
+
+	if float64(amount) > b.tokens+tokenEpsilon {
+		// not enough tokens
+		return false
+	}
+
+	b.tokens -= float64(amount)
+	if b.tokens < 0 {
+		b.tokens = 0
+	}
+
+	return true
+}
+
+func (b *tokenBucket) release(amount int64, now time.Time) int64 {
+	b.refill(now)
+
+	// tokens are returned to the bucket, until it is full
+	room := int64(float64(b.burst) - b.tokens + tokenEpsilon)
+	if amount > room {
+		amount = room
+	}
+
+	b.tokens += float64(amount)
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+
+	return amount
+}
+
+func (b *tokenBucket) available(now time.Time) int64 {
+	b.refill(now)
+	return int64(b.tokens + tokenEpsilon)
+}
+
+func (b *tokenBucket) capacity() int64 {
+	return b.burst
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		b.last = now
+		return
+	}
+
+	// ignore time moving backwards
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens += float64(elapsed) * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAlloc(t *testing.T) {
+	// 10 tokens per second, up to 20 at once
+	cases := []struct {
+		amount int64
+		millis int64
+		avail  int64
+		result bool
+	}{
+		{20, 0, 0, true},
+		{1, 0, 0, false},
+		{1, 50, 0, false},
+		{1, 100, 0, true},
+		{5, 600, 0, true},
+		{6, 1100, 5, false},
+		{5, 1100, 0, true},
+		{10, 10000, 10, true},
+		{30, 20000, 20, false},
+	}
+
+	start := time.Now()
+	b := newTokenBucket(10, time.Second, 20)
+
+	for i, c := range cases {
+		now := start.Add(time.Duration(c.millis) * time.Millisecond)
+		if ok := b.alloc(c.amount, now); ok != c.result {
+			t.Errorf("Expecting %v got %v, case %d", c.result, ok, i)
+		}
+
+		if avail := b.available(now); avail != c.avail {
+			t.Errorf("Expecting %d available, got %d, case %d", c.avail, avail, i)
+		}
+	}
+}
+
+func TestTokenBucketRelease(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(10, time.Second, 20)
+
+	if !b.alloc(15, start) {
+		t.Fatal("Expecting to succeed")
+	}
+
+	if released := b.release(10, start); released != 10 {
+		t.Errorf("Expecting 10 released, got %d", released)
+	}
+
+	// only 5 tokens fit in the bucket
+	if released := b.release(10, start); released != 5 {
+		t.Errorf("Expecting 5 released, got %d", released)
+	}
+
+	if avail := b.available(start); avail != b.capacity() {
+		t.Errorf("Expecting %d available, got %d", b.capacity(), avail)
+	}
+}
+
+func TestTokenBucketClockSkew(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(10, time.Second, 10)
+
+	if !b.alloc(10, start) {
+		t.Fatal("Expecting to succeed")
+	}
+
+	// time moving backwards doesn't refill the bucket
+	if b.alloc(1, start.Add(-time.Hour)) {
+		t.Error("Expecting to fail")
+	}
+
+	if !b.alloc(1, start.Add(100*time.Millisecond)) {
+		t.Error("Expecting to succeed")
+	}
+}
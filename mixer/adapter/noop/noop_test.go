@@ -153,3 +153,19 @@ func contains(s []string, e string) bool {
 	}
 	return false
 }
+
+func TestConformance(t *testing.T) {
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Instances: map[string][]interface{}{
+			authorization.TemplateName: {&authorization.Instance{Name: "authorization"}},
+			checknothing.TemplateName:  {&checknothing.Instance{Name: "checknothing"}},
+			listentry.TemplateName:     {&listentry.Instance{Name: "listentry", Value: "foo"}},
+			logentry.TemplateName:      {&logentry.Instance{Name: "logentry", Severity: "INFO"}},
+			metric.TemplateName:        {&metric.Instance{Name: "metric", Value: int64(1)}},
+			quota.TemplateName:         {&quota.Instance{Name: "quota"}},
+			reportnothing.TemplateName: {&reportnothing.Instance{Name: "reportnothing"}},
+			tracespan.TemplateName:     {&tracespan.Instance{Name: "tracespan"}},
+		},
+	})
+}
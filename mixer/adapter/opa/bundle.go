@@ -0,0 +1,273 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+const (
+	dataFileName     = "data.json"
+	manifestFileName = ".manifest"
+	regoExtension    = ".rego"
+)
+
+// bundle holds the policies and data loaded from a policy bundle.
+type bundle struct {
+	modules  map[string]*ast.Module
+	data     map[string]interface{}
+	revision string
+}
+
+// readBundleFiles reads the files making up the bundle at the given path, keyed by
+// their slash separated path relative to the root of the bundle. The bundle is
+// either a directory or a gzipped tarball.
+func readBundleFiles(bundlePath string) (map[string][]byte, error) {
+	fi, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to access bundle: %v", err)
+	}
+
+	if fi.IsDir() {
+		return readBundleDir(bundlePath)
+	}
+
+	if !strings.HasSuffix(bundlePath, ".tar.gz") && !strings.HasSuffix(bundlePath, ".tgz") {
+		return nil, fmt.Errorf("bundle %s is neither a directory nor a gzipped tarball", bundlePath)
+	}
+
+	return readBundleTarball(bundlePath)
+}
+
+func readBundleDir(root string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if (info.Mode()&os.ModeType) != 0 || !isBundleFile(p) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read bundle: %v", err)
+	}
+
+	return files, nil
+}
+
+func readBundleTarball(p string) (map[string][]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read bundle: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read bundle: %v", err)
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read bundle: %v", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || !isBundleFile(hdr.Name) {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s from bundle: %v", hdr.Name, err)
+		}
+
+		files[strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")] = content
+	}
+
+	return files, nil
+}
+
+func isBundleFile(p string) bool {
+	base := path.Base(filepath.ToSlash(p))
+	return base == dataFileName || base == manifestFileName || path.Ext(base) == regoExtension
+}
+
+// digestFiles returns a digest of the given bundle files, used to detect bundle changes.
+func digestFiles(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "%s:%d:", name, len(files[name]))
+		_, _ = h.Write(files[name])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parseBundle parses the policies and data of a bundle. The revision of the bundle
+// is taken from its manifest when present, and from the given digest otherwise.
+func parseBundle(files map[string][]byte, digest string) (*bundle, error) {
+	b := &bundle{
+		modules:  map[string]*ast.Module{},
+		data:     map[string]interface{}{},
+		revision: digest,
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content := files[name]
+
+		switch path.Base(name) {
+		case manifestFileName:
+			if path.Dir(name) != "." {
+				continue
+			}
+
+			var manifest struct {
+				Revision string `json:"revision"`
+			}
+			if err := json.Unmarshal(content, &manifest); err != nil {
+				return nil, fmt.Errorf("invalid bundle manifest: %v", err)
+			}
+			if manifest.Revision != "" {
+				b.revision = manifest.Revision
+			}
+
+		case dataFileName:
+			var doc interface{}
+			dec := json.NewDecoder(bytes.NewReader(content))
+			dec.UseNumber()
+			if err := dec.Decode(&doc); err != nil {
+				return nil, fmt.Errorf("invalid data document %s: %v", name, err)
+			}
+
+			var keys []string
+			if dir := path.Dir(name); dir != "." {
+				keys = strings.Split(dir, "/")
+			}
+
+			if err := insertData(b.data, keys, doc); err != nil {
+				return nil, fmt.Errorf("invalid data document %s: %v", name, err)
+			}
+
+		default:
+			parsed, err := ast.ParseModule(name, string(content))
+			if err != nil {
+				return nil, err
+			}
+			b.modules[name] = parsed
+		}
+	}
+
+	return b, nil
+}
+
+// insertData merges a data document into the data tree at the location given by keys.
+func insertData(root map[string]interface{}, keys []string, doc interface{}) error {
+	if len(keys) == 0 {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("root data document must be an object")
+		}
+		return mergeData(root, obj, "data")
+	}
+
+	node := root
+	for i, key := range keys[:len(keys)-1] {
+		child, ok := node[key]
+		if !ok {
+			child = map[string]interface{}{}
+			node[key] = child
+		}
+
+		obj, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("conflicting data at data.%s", strings.Join(keys[:i+1], "."))
+		}
+		node = obj
+	}
+
+	return mergeData(node, map[string]interface{}{keys[len(keys)-1]: doc}, "data."+strings.Join(keys[:len(keys)-1], "."))
+}
+
+// mergeData merges src into dst, failing on non-object values present in both.
+func mergeData(dst map[string]interface{}, src map[string]interface{}, at string) error {
+	for key, value := range src {
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = value
+			continue
+		}
+
+		dstObj, dstOk := existing.(map[string]interface{})
+		srcObj, srcOk := value.(map[string]interface{})
+		if !dstOk || !srcOk {
+			return fmt.Errorf("conflicting data at %s", strings.TrimSuffix(at, ".")+"."+key)
+		}
+
+		if err := mergeData(dstObj, srcObj, strings.TrimSuffix(at, ".")+"."+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
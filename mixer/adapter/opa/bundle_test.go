@@ -0,0 +1,254 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rolesPolicy = `package mixerauthz
+
+default allow = false
+
+allow = true {
+	data.roles[input.subject.user][_] = input.action.method
+}`
+
+func writeBundleDir(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Unable to create directory for %s: %v", name, err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Unable to write %s: %v", name, err)
+		}
+	}
+}
+
+func writeBundleTarball(t *testing.T, p string, files map[string]string) {
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("Unable to create %s: %v", p, err)
+	}
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err = tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("Unable to write header for %s: %v", name, err)
+		}
+		if _, err = tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Unable to write %s: %v", name, err)
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		t.Fatalf("Unable to close tar writer: %v", err)
+	}
+	if err = gz.Close(); err != nil {
+		t.Fatalf("Unable to close gzip writer: %v", err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatalf("Unable to close %s: %v", p, err)
+	}
+}
+
+func TestReadBundleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestReadBundleFiles")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	files := map[string]string{
+		"policy.rego":     rolesPolicy,
+		"roles/data.json": `{"alice": ["GET"]}`,
+		".manifest":       `{"revision": "rev1"}`,
+		"README.md":       "ignored",
+	}
+	expected := map[string][]byte{
+		"policy.rego":     []byte(rolesPolicy),
+		"roles/data.json": []byte(`{"alice": ["GET"]}`),
+		".manifest":       []byte(`{"revision": "rev1"}`),
+	}
+
+	bundleDir := filepath.Join(dir, "bundle")
+	writeBundleDir(t, bundleDir, files)
+	writeBundleTarball(t, filepath.Join(dir, "bundle.tar.gz"), files)
+	writeBundleTarball(t, filepath.Join(dir, "bundle.tar"), files)
+
+	for _, p := range []string{bundleDir, filepath.Join(dir, "bundle.tar.gz")} {
+		actual, err := readBundleFiles(p)
+		if err != nil {
+			t.Errorf("%s: Got error %v, expecting success", p, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: Got %v, expecting %v", p, actual, expected)
+		}
+	}
+
+	for _, p := range []string{filepath.Join(dir, "missing"), filepath.Join(dir, "bundle.tar")} {
+		if _, err := readBundleFiles(p); err == nil {
+			t.Errorf("%s: Got success, expecting failure", p)
+		}
+	}
+}
+
+func TestParseBundle(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		data     string
+		revision string
+		modules  []string
+		err      string
+	}{
+		{
+			name: "policies and data",
+			files: map[string]string{
+				"policy.rego":          rolesPolicy,
+				"data.json":            `{"version": 1}`,
+				"roles/data.json":      `{"alice": ["GET"]}`,
+				"org/teams/data.json":  `{"storage": ["alice"]}`,
+				"org/owners/data.json": `["bob"]`,
+			},
+			data:     `{"version": 1, "roles": {"alice": ["GET"]}, "org": {"teams": {"storage": ["alice"]}, "owners": ["bob"]}}`,
+			revision: "digest",
+			modules:  []string{"policy.rego"},
+		},
+		{
+			name: "manifest",
+			files: map[string]string{
+				"policy.rego": rolesPolicy,
+				".manifest":   `{"revision": "rev1"}`,
+			},
+			data:     `{}`,
+			revision: "rev1",
+			modules:  []string{"policy.rego"},
+		},
+		{
+			name: "nested manifest ignored",
+			files: map[string]string{
+				"policy.rego":   rolesPolicy,
+				"sub/.manifest": `{"revision": "rev1"}`,
+			},
+			data:     `{}`,
+			revision: "digest",
+			modules:  []string{"policy.rego"},
+		},
+		{
+			name:  "invalid manifest",
+			files: map[string]string{".manifest": `{`},
+			err:   "invalid bundle manifest",
+		},
+		{
+			name:  "invalid data",
+			files: map[string]string{"roles/data.json": `{`},
+			err:   "invalid data document roles/data.json",
+		},
+		{
+			name:  "non object root data",
+			files: map[string]string{"data.json": `[]`},
+			err:   "root data document must be an object",
+		},
+		{
+			name: "conflicting data",
+			files: map[string]string{
+				"data.json":       `{"roles": []}`,
+				"roles/data.json": `{"alice": ["GET"]}`,
+			},
+			err: "conflicting data at data.roles",
+		},
+		{
+			name:  "invalid policy",
+			files: map[string]string{"policy.rego": "package mixerauthz\n  +\n"},
+			err:   "policy.rego",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			files := make(map[string][]byte, len(c.files))
+			for name, content := range c.files {
+				files[name] = []byte(content)
+			}
+
+			b, err := parseBundle(files, "digest")
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("Got error %v, expecting %s", err, c.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Got error %v, expecting success", err)
+			}
+
+			var data map[string]interface{}
+			if err = json.Unmarshal([]byte(c.data), &data); err != nil {
+				t.Fatalf("Invalid expected data: %v", err)
+			}
+
+			// normalize the json.Number values of the bundle
+			actual, _ := json.Marshal(b.data)
+			var actualData map[string]interface{}
+			_ = json.Unmarshal(actual, &actualData)
+
+			if !reflect.DeepEqual(actualData, data) {
+				t.Errorf("Got data %v, expecting %v", actualData, data)
+			}
+
+			if b.revision != c.revision {
+				t.Errorf("Got revision %s, expecting %s", b.revision, c.revision)
+			}
+
+			if len(b.modules) != len(c.modules) {
+				t.Errorf("Got %d modules, expecting %v", len(b.modules), c.modules)
+			}
+			for _, m := range c.modules {
+				if _, ok := b.modules[m]; !ok {
+					t.Errorf("Missing module %s", m)
+				}
+			}
+		})
+	}
+}
+
+func TestDigestFiles(t *testing.T) {
+	a := digestFiles(map[string][]byte{"a.rego": []byte("x"), "b.rego": []byte("y")})
+	b := digestFiles(map[string][]byte{"b.rego": []byte("y"), "a.rego": []byte("x")})
+	c := digestFiles(map[string][]byte{"a.rego": []byte("xb.rego"), "": []byte("y")})
+
+	if a != b {
+		t.Errorf("Got different digests %s and %s for the same files", a, b)
+	}
+
+	if a == c {
+		t.Errorf("Got the same digest %s for different files", a)
+	}
+}
@@ -19,6 +19,11 @@ import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
 import _ "github.com/gogo/protobuf/gogoproto"
+import _ "github.com/gogo/protobuf/types"
+
+import time "time"
+
+import github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
 
 import strings "strings"
 import reflect "reflect"
@@ -29,6 +34,7 @@ import io "io"
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -66,6 +72,16 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 // checkMethod: "data.mixerauthz.allow"
 // failClose: true
 // ```
+//
+// Policies and data can also be loaded from a bundle, and are reloaded whenever
+// the bundle changes:
+// ```
+// bundlePath: /etc/opa/bundle.tar.gz
+// bundlePollInterval: 10s
+// checkMethod: "data.mixerauthz.allow"
+// cacheDuration: 30s
+// decisionLogPath: /var/log/mixer/opa-decisions.log
+// ```
 type Params struct {
 	// List of OPA policies
 	Policy []string `protobuf:"bytes,1,rep,name=policy" json:"policy,omitempty"`
@@ -76,6 +92,30 @@ type Params struct {
 	// If failClose is set to true and there is a runtime error,
 	// instead of disabling the adapter, close the client request
 	FailClose bool `protobuf:"varint,3,opt,name=fail_close,json=failClose,proto3" json:"fail_close,omitempty"`
+	// Path of a policy bundle to load in addition to the inline policies. The
+	// bundle is either a directory or a gzipped tarball (`.tar.gz` or `.tgz`)
+	// holding `.rego` policy files and `data.json` documents. A `data.json`
+	// document is loaded under the path of the directory holding it, so
+	// `roles/data.json` is available to policies as `data.roles`. The revision
+	// of the bundle is read from an optional `.manifest` JSON document holding a
+	// `revision` string, and otherwise derived from the content of the bundle.
+	BundlePath string `protobuf:"bytes,4,opt,name=bundle_path,json=bundlePath,proto3" json:"bundle_path,omitempty"`
+	// How often the bundle is checked for changes. Changed bundles are loaded
+	// and swapped in without rebuilding the handler; a bundle that fails to
+	// load or compile is reported and the previous policies stay in effect.
+	// 0 disables reloading. Defaults to 5 seconds.
+	BundlePollInterval time.Duration `protobuf:"bytes,5,opt,name=bundle_poll_interval,json=bundlePollInterval,stdduration" json:"bundle_poll_interval"`
+	// How long a decision is cached for. Decisions are cached per authorization
+	// instance and policy revision, so a reloaded bundle never serves stale
+	// decisions. 0 disables caching, which is the default.
+	CacheDuration time.Duration `protobuf:"bytes,6,opt,name=cache_duration,json=cacheDuration,stdduration" json:"cache_duration"`
+	// The maximum number of decisions cached. Defaults to 1000.
+	CacheSize int32 `protobuf:"varint,7,opt,name=cache_size,json=cacheSize,proto3" json:"cache_size,omitempty"`
+	// Path of a file decisions are logged to. Each decision is written as a
+	// JSON document on its own line recording the input, the result, the
+	// policy revision and whether the decision was served from the cache.
+	// Decision logging is disabled when empty, which is the default.
+	DecisionLogPath string `protobuf:"bytes,8,opt,name=decision_log_path,json=decisionLogPath,proto3" json:"decision_log_path,omitempty"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -131,6 +171,39 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if len(m.BundlePath) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.BundlePath)))
+		i += copy(dAtA[i:], m.BundlePath)
+	}
+	dAtA[i] = 0x2a
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.BundlePollInterval)))
+	n1, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.BundlePollInterval, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n1
+	dAtA[i] = 0x32
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.CacheDuration)))
+	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.CacheDuration, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n2
+	if m.CacheSize != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.CacheSize))
+	}
+	if len(m.DecisionLogPath) > 0 {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.DecisionLogPath)))
+		i += copy(dAtA[i:], m.DecisionLogPath)
+	}
 	return i, nil
 }
 
@@ -159,6 +232,21 @@ func (m *Params) Size() (n int) {
 	if m.FailClose {
 		n += 2
 	}
+	l = len(m.BundlePath)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.BundlePollInterval)
+	n += 1 + l + sovConfig(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.CacheDuration)
+	n += 1 + l + sovConfig(uint64(l))
+	if m.CacheSize != 0 {
+		n += 1 + sovConfig(uint64(m.CacheSize))
+	}
+	l = len(m.DecisionLogPath)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
 	return n
 }
 
@@ -183,6 +271,11 @@ func (this *Params) String() string {
 		`Policy:` + fmt.Sprintf("%v", this.Policy) + `,`,
 		`CheckMethod:` + fmt.Sprintf("%v", this.CheckMethod) + `,`,
 		`FailClose:` + fmt.Sprintf("%v", this.FailClose) + `,`,
+		`BundlePath:` + fmt.Sprintf("%v", this.BundlePath) + `,`,
+		`BundlePollInterval:` + strings.Replace(strings.Replace(this.BundlePollInterval.String(), "Duration", "google_protobuf1.Duration", 1), `&`, ``, 1) + `,`,
+		`CacheDuration:` + strings.Replace(strings.Replace(this.CacheDuration.String(), "Duration", "google_protobuf1.Duration", 1), `&`, ``, 1) + `,`,
+		`CacheSize:` + fmt.Sprintf("%v", this.CacheSize) + `,`,
+		`DecisionLogPath:` + fmt.Sprintf("%v", this.DecisionLogPath) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -302,6 +395,143 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.FailClose = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BundlePath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BundlePath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BundlePollInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.BundlePollInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CacheDuration", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.CacheDuration, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CacheSize", wireType)
+			}
+			m.CacheSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CacheSize |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DecisionLogPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DecisionLogPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -431,20 +661,30 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/opa/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 228 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0xcd, 0xcd, 0xac, 0x48,
-	0x2d, 0xd2, 0x4f, 0x4c, 0x49, 0x2c, 0x28, 0x49, 0x2d, 0xd2, 0xcf, 0x2f, 0x48, 0xd4, 0x4f, 0xce,
-	0xcf, 0x4b, 0xcb, 0x4c, 0x87, 0x52, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x42, 0x50, 0x05,
-	0x7a, 0xf9, 0x05, 0x89, 0x7a, 0x10, 0x19, 0x29, 0x91, 0xf4, 0xfc, 0xf4, 0x7c, 0xb0, 0xb4, 0x3e,
-	0x88, 0x05, 0x51, 0xa9, 0x94, 0xc4, 0xc5, 0x16, 0x90, 0x58, 0x94, 0x98, 0x5b, 0x2c, 0x24, 0xc6,
-	0xc5, 0x56, 0x90, 0x9f, 0x93, 0x99, 0x5c, 0x29, 0xc1, 0xa8, 0xc0, 0xac, 0xc1, 0x19, 0x04, 0xe5,
-	0x09, 0x29, 0x72, 0xf1, 0x24, 0x67, 0xa4, 0x26, 0x67, 0xc7, 0xe7, 0xa6, 0x96, 0x64, 0xe4, 0xa7,
-	0x48, 0x30, 0x29, 0x30, 0x6a, 0x70, 0x06, 0x71, 0x83, 0xc5, 0x7c, 0xc1, 0x42, 0x42, 0xb2, 0x5c,
-	0x5c, 0x69, 0x89, 0x99, 0x39, 0xf1, 0xc9, 0x39, 0xf9, 0xc5, 0xa9, 0x12, 0xcc, 0x0a, 0x8c, 0x1a,
-	0x1c, 0x41, 0x9c, 0x20, 0x11, 0x67, 0x90, 0x80, 0x93, 0xc5, 0x89, 0x87, 0x72, 0x0c, 0x17, 0x1e,
-	0xca, 0x31, 0xdc, 0x78, 0x28, 0xc7, 0xf0, 0xe1, 0xa1, 0x1c, 0x43, 0xc3, 0x23, 0x39, 0xc6, 0x15,
-	0x8f, 0xe4, 0x18, 0x4e, 0x3c, 0x92, 0x63, 0xbc, 0xf0, 0x48, 0x8e, 0xf1, 0xc1, 0x23, 0x39, 0xc6,
-	0x17, 0x8f, 0xe4, 0x18, 0x3e, 0x3c, 0x92, 0x63, 0x9c, 0xf0, 0x58, 0x8e, 0x21, 0x8a, 0x0d, 0xe2,
-	0xe6, 0x24, 0x36, 0xb0, 0x23, 0x8d, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0xac, 0xa2, 0x5e, 0xec,
-	0xf7, 0x00, 0x00, 0x00,
+	// 388 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x91, 0xbf, 0xae, 0xd3, 0x30,
+	0x18, 0xc5, 0xed, 0x7b, 0x69, 0x68, 0x5c, 0xfe, 0x08, 0xab, 0x42, 0xa1, 0x12, 0x6e, 0x40, 0x42,
+	0x8a, 0x18, 0x12, 0x09, 0x16, 0xe6, 0xc2, 0x02, 0x02, 0xa9, 0x0a, 0x62, 0x61, 0x89, 0x5c, 0xc7,
+	0x4d, 0x2c, 0xdc, 0x7c, 0x51, 0x92, 0x22, 0xe8, 0xc4, 0x23, 0x30, 0xf2, 0x08, 0x3c, 0x4a, 0xc7,
+	0x8e, 0x4c, 0x40, 0xc2, 0xc2, 0x58, 0xde, 0x00, 0xc5, 0x4e, 0xf6, 0x3b, 0xd9, 0xdf, 0x39, 0xe7,
+	0x3b, 0xf2, 0x4f, 0x26, 0x8f, 0x76, 0xea, 0x93, 0xac, 0x22, 0x9e, 0xf2, 0xb2, 0x91, 0x55, 0x04,
+	0x25, 0x8f, 0x04, 0x14, 0x5b, 0x95, 0x0d, 0x47, 0x58, 0x56, 0xd0, 0x00, 0xa5, 0x43, 0x20, 0x84,
+	0x92, 0x87, 0xd6, 0x59, 0xcc, 0x33, 0xc8, 0xc0, 0xd8, 0x51, 0x7f, 0xb3, 0xc9, 0x05, 0xcb, 0x00,
+	0x32, 0x2d, 0x23, 0x33, 0x6d, 0xf6, 0xdb, 0x28, 0xdd, 0x57, 0xbc, 0x51, 0x50, 0x58, 0xff, 0xe1,
+	0xbf, 0x0b, 0xe2, 0xac, 0x79, 0xc5, 0x77, 0x35, 0xbd, 0x4b, 0x9c, 0x12, 0xb4, 0x12, 0x9f, 0x3d,
+	0xec, 0x5f, 0x06, 0x6e, 0x3c, 0x4c, 0xf4, 0x01, 0xb9, 0x21, 0x72, 0x29, 0x3e, 0x24, 0x3b, 0xd9,
+	0xe4, 0x90, 0x7a, 0x17, 0x3e, 0x0e, 0xdc, 0x78, 0x66, 0xb4, 0x37, 0x46, 0xa2, 0xf7, 0x09, 0xd9,
+	0x72, 0xa5, 0x13, 0xa1, 0xa1, 0x96, 0xde, 0xa5, 0x8f, 0x83, 0x69, 0xec, 0xf6, 0xca, 0xf3, 0x5e,
+	0xa0, 0x4b, 0x32, 0xdb, 0xec, 0x8b, 0x54, 0xcb, 0xa4, 0xe4, 0x4d, 0xee, 0x5d, 0x33, 0x05, 0xc4,
+	0x4a, 0x6b, 0xde, 0xe4, 0xf4, 0x1d, 0x99, 0x8f, 0x01, 0xd0, 0x3a, 0x51, 0x45, 0x23, 0xab, 0x8f,
+	0x5c, 0x7b, 0x13, 0x1f, 0x07, 0xb3, 0x27, 0xf7, 0x42, 0x0b, 0x11, 0x8e, 0x10, 0xe1, 0x8b, 0x01,
+	0x62, 0x35, 0x3d, 0xfe, 0x5c, 0xa2, 0x6f, 0xbf, 0x96, 0x38, 0xa6, 0x43, 0x1d, 0x68, 0xfd, 0x72,
+	0x58, 0xa7, 0xaf, 0xc8, 0x2d, 0xc1, 0x45, 0x2e, 0x93, 0x11, 0xda, 0x73, 0xae, 0x5e, 0x78, 0xd3,
+	0xac, 0x8e, 0x46, 0x8f, 0x68, 0xbb, 0x6a, 0x75, 0x90, 0xde, 0x75, 0x1f, 0x07, 0x93, 0xd8, 0x35,
+	0xca, 0x5b, 0x75, 0x90, 0xf4, 0x31, 0xb9, 0x93, 0x4a, 0xa1, 0x6a, 0x05, 0x45, 0xa2, 0x21, 0xb3,
+	0xa0, 0x53, 0x03, 0x7a, 0x7b, 0x34, 0x5e, 0x43, 0xd6, 0xd3, 0xae, 0x9e, 0x1d, 0x5b, 0x86, 0x4e,
+	0x2d, 0x43, 0x3f, 0x5a, 0x86, 0xce, 0x2d, 0x43, 0x5f, 0x3a, 0x86, 0xbf, 0x77, 0x0c, 0x1d, 0x3b,
+	0x86, 0x4f, 0x1d, 0xc3, 0xbf, 0x3b, 0x86, 0xff, 0x76, 0x0c, 0x9d, 0x3b, 0x86, 0xbf, 0xfe, 0x61,
+	0xe8, 0xbd, 0x63, 0xff, 0x78, 0xe3, 0x98, 0x07, 0x3f, 0xfd, 0x3f, 0x00, 0x0a, 0xe1, 0xec, 0x4c,
+	0x27, 0x02, 0x00, 0x00,
 }
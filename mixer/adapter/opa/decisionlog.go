@@ -0,0 +1,68 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	// decision is a single entry of the decision log.
+	decision struct {
+		Time     time.Time              `json:"time"`
+		Instance string                 `json:"instance"`
+		Revision string                 `json:"revision"`
+		Input    map[string]interface{} `json:"input"`
+		Result   bool                   `json:"result"`
+		Cached   bool                   `json:"cached"`
+		Error    string                 `json:"error,omitempty"`
+	}
+
+	// decisionLogger appends decisions to a file, one JSON document per line.
+	decisionLogger struct {
+		mu   sync.Mutex
+		file *os.File
+	}
+)
+
+func newDecisionLogger(path string) (*decisionLogger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decisionLogger{file: file}, nil
+}
+
+func (l *decisionLogger) log(d *decision) error {
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	_, err = l.file.Write(line)
+	l.mu.Unlock()
+
+	return err
+}
+
+func (l *decisionLogger) close() error {
+	return l.file.Close()
+}
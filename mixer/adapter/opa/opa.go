@@ -18,15 +18,24 @@ package opa // import "istio.io/istio/mixer/adapter/opa"
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
 
 	"istio.io/istio/mixer/adapter/opa/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/status"
 	"istio.io/istio/mixer/template/authorization"
+	"istio.io/istio/pkg/cache"
 )
 
 type (
@@ -34,19 +43,45 @@ type (
 		adapterConfig *config.Params
 		configErrors  []error
 		compiler      *ast.Compiler
+		inline        map[string]*ast.Module
+		bundle        *bundle
+		bundleDigest  string
+	}
+
+	// policy is a compiled set of policies along with the data they are evaluated against.
+	policy struct {
+		compiler *ast.Compiler
+		store    storage.Store
+		revision string
 	}
 
 	handler struct {
 		checkMethod    string
-		compiler       *ast.Compiler
 		logger         adapter.Logger
 		hasConfigError bool
 		failClose      bool
+
+		policyMutex sync.RWMutex
+		policy      *policy
+
+		// used to reload the bundle, only touched by the reload loop after Build
+		inline       map[string]*ast.Module
+		bundlePath   string
+		bundleDigest string
+		done         chan struct{}
+		stopped      chan struct{}
+		closeOnce    sync.Once
+		closeErr     error
+
+		decisions   cache.ExpiringCache
+		decisionLog *decisionLogger
+		getTime     func() time.Time
 	}
 )
 
 const (
 	policyFileNamePrefix = "opa_policy"
+	defaultCacheSize     = 1000
 )
 
 ///////////////// Configuration Methods ///////////////
@@ -60,31 +95,47 @@ func (b *builder) SetAdapterConfig(cfg adapter.Config) {
 // To support fail close, Validate will not append errors to ce but will be appended to the configErrors.
 // HandleAuthorization handle request based on configErrors and failClose option
 func (b *builder) Validate() (ce *adapter.ConfigErrors) {
-	if len(b.adapterConfig.CheckMethod) == 0 {
-		msg := "check method is not configured"
-		if b.adapterConfig.FailClose {
-			b.configErrors = append(b.configErrors, fmt.Errorf(msg))
-		} else {
-			ce = ce.Appendf("CheckMethod", msg)
-		}
+	ac := b.adapterConfig
+
+	if len(ac.CheckMethod) == 0 {
+		ce = b.appendError(ce, "CheckMethod", fmt.Errorf("check method is not configured"))
+	}
+
+	if ac.BundlePollInterval < 0 {
+		ce = b.appendError(ce, "BundlePollInterval", fmt.Errorf("bundle poll interval must be >= 0, it is %v", ac.BundlePollInterval))
+	}
+
+	if ac.CacheDuration < 0 {
+		ce = b.appendError(ce, "CacheDuration", fmt.Errorf("cache duration must be >= 0, it is %v", ac.CacheDuration))
+	}
+
+	if ac.CacheSize < 0 {
+		ce = b.appendError(ce, "CacheSize", fmt.Errorf("cache size must be >= 0, it is %d", ac.CacheSize))
 	}
 
-	parsedModuleCount := 0
 	moduleParseErrorCount := 0
-	modules := map[string]*ast.Module{}
-	for index, policy := range b.adapterConfig.Policy {
+	inline := map[string]*ast.Module{}
+	for index, policy := range ac.Policy {
 		filename := fmt.Sprintf("%v.%v", policyFileNamePrefix, index)
 		parsed, err := ast.ParseModule(filename, policy)
 		if err != nil {
-			if b.adapterConfig.FailClose {
-				b.configErrors = append(b.configErrors, err)
-			} else {
-				ce = ce.Append("Policy", err)
-			}
+			ce = b.appendError(ce, "Policy", err)
 			moduleParseErrorCount++
 		} else {
-			modules[filename] = parsed
-			parsedModuleCount++
+			inline[filename] = parsed
+		}
+	}
+
+	if ac.BundlePath != "" {
+		files, err := readBundleFiles(ac.BundlePath)
+		if err == nil {
+			b.bundleDigest = digestFiles(files)
+			b.bundle, err = parseBundle(files, b.bundleDigest)
+		}
+
+		if err != nil {
+			ce = b.appendError(ce, "BundlePath", err)
+			moduleParseErrorCount++
 		}
 	}
 
@@ -92,31 +143,74 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 		return
 	}
 
-	if parsedModuleCount == 0 {
-		msg := "policies are not configured"
-		if b.adapterConfig.FailClose {
-			b.configErrors = append(b.configErrors, fmt.Errorf(msg))
-		} else {
-			ce = ce.Appendf("Policy", msg)
-		}
+	if len(inline) == 0 && (b.bundle == nil || len(b.bundle.modules) == 0) {
+		ce = b.appendError(ce, "Policy", fmt.Errorf("policies are not configured"))
 		return
 	}
 
+	compiler, errs := compile(inline, b.bundle)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			ce = b.appendError(ce, "Policy", err)
+		}
+	} else {
+		b.compiler = compiler
+		b.inline = inline
+	}
+
+	return
+}
+
+// appendError records a configuration error, either as a validation error or,
+// when failing close, as an error reported through rejected requests.
+func (b *builder) appendError(ce *adapter.ConfigErrors, field string, err error) *adapter.ConfigErrors {
+	if b.adapterConfig.FailClose {
+		b.configErrors = append(b.configErrors, err)
+		return ce
+	}
+	return ce.Append(field, err)
+}
+
+// compile compiles the inline policies together with the policies of the given bundle.
+func compile(inline map[string]*ast.Module, b *bundle) (*ast.Compiler, []error) {
+	modules := make(map[string]*ast.Module, len(inline))
+	for name, module := range inline {
+		modules[name] = module
+	}
+
+	if b != nil {
+		for name, module := range b.modules {
+			modules[name] = module
+		}
+	}
+
 	compiler := ast.NewCompiler()
 	compiler.Compile(modules)
 	if compiler.Failed() {
+		errs := make([]error, 0, len(compiler.Errors))
 		for _, err := range compiler.Errors {
-			if b.adapterConfig.FailClose {
-				b.configErrors = append(b.configErrors, fmt.Errorf("%v", err.Error()))
-			} else {
-				ce = ce.Appendf("Policy", err.Error())
-			}
+			errs = append(errs, fmt.Errorf("%v", err.Error()))
 		}
+		return nil, errs
+	}
+
+	return compiler, nil
+}
+
+// newPolicy wraps a compiled set of policies along with the data of the given bundle.
+func newPolicy(compiler *ast.Compiler, inline []string, b *bundle) *policy {
+	p := &policy{compiler: compiler}
+
+	if b != nil {
+		p.store = inmem.NewFromObject(b.data)
+		p.revision = b.revision
 	} else {
-		b.compiler = compiler
+		p.store = inmem.New()
+		sum := sha256.Sum256([]byte(strings.Join(inline, "\x00")))
+		p.revision = hex.EncodeToString(sum[:])
 	}
 
-	return
+	return p
 }
 
 func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handler, error) {
@@ -126,13 +220,54 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 		}
 	}
 
-	return &handler{
-		compiler:       b.compiler,
-		checkMethod:    b.adapterConfig.CheckMethod,
-		failClose:      b.adapterConfig.FailClose,
+	ac := b.adapterConfig
+	h := &handler{
+		checkMethod:    ac.CheckMethod,
+		failClose:      ac.FailClose,
 		logger:         env.Logger(),
 		hasConfigError: len(b.configErrors) > 0,
-	}, nil
+		inline:         b.inline,
+		bundlePath:     ac.BundlePath,
+		bundleDigest:   b.bundleDigest,
+		getTime:        time.Now,
+	}
+
+	if b.compiler != nil {
+		h.policy = newPolicy(b.compiler, ac.Policy, b.bundle)
+	}
+
+	var evictionInterval time.Duration
+	if ac.CacheDuration > 0 {
+		size := ac.CacheSize
+		if size <= 0 {
+			size = defaultCacheSize
+		}
+		// The expired decisions are evicted by the daemon of the handler, which stops once the handler is closed,
+		// instead of the evicter of the cache, which only stops once the cache is garbage collected.
+		h.decisions = cache.NewLRU(ac.CacheDuration, 0, size)
+		evictionInterval = ac.CacheDuration / 2
+	}
+
+	if ac.DecisionLogPath != "" {
+		dl, err := newDecisionLogger(ac.DecisionLogPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open decision log: %v", err)
+		}
+		h.decisionLog = dl
+	}
+
+	var pollInterval time.Duration
+	if ac.BundlePath != "" && ac.BundlePollInterval > 0 && h.policy != nil {
+		pollInterval = ac.BundlePollInterval
+	}
+
+	if pollInterval > 0 || evictionInterval > 0 {
+		h.done = make(chan struct{})
+		h.stopped = make(chan struct{})
+		env.ScheduleDaemon(func() { h.watch(pollInterval, evictionInterval) })
+	}
+
+	return h, nil
 }
 
 ////////////////// Runtime Methods //////////////////////////
@@ -220,43 +355,203 @@ func (h *handler) HandleAuthorization(context context.Context, instance *authori
 		return h.handleFailClose(fmt.Errorf("opa: request was rejected"))
 	}
 
+	p := h.currentPolicy()
+	if p == nil {
+		return h.handleFailClose(fmt.Errorf("opa: request was rejected"))
+	}
+
+	input := map[string]interface{}{
+		"action":  convertActionObjectToMap(instance.Action),
+		"subject": convertSubjectObjectToMap(instance.Subject),
+	}
+
+	var key string
+	if h.decisions != nil {
+		key = decisionKey(p.revision, input)
+		if key != "" {
+			if value, ok := h.decisions.Get(key); ok {
+				allowed := value.(bool)
+				h.logDecision(instance, p, input, allowed, true, nil)
+				return checkResult(allowed), nil
+			}
+		}
+	}
+
+	allowed, err := h.evaluate(context, p, input)
+	h.logDecision(instance, p, input, allowed, false, err)
+	if err != nil {
+		return h.handleFailClose(err)
+	}
+
+	if key != "" {
+		h.decisions.Set(key, allowed)
+	}
+
+	return checkResult(allowed), nil
+}
+
+// evaluate runs the check method of the given policy against the input.
+func (h *handler) evaluate(context context.Context, p *policy, input map[string]interface{}) (bool, error) {
 	// eval rego policy scripts
 	rs, err := rego.New(
-		rego.Compiler(h.compiler),
+		rego.Compiler(p.compiler),
+		rego.Store(p.store),
 		rego.Query(h.checkMethod),
-		rego.Input(map[string]interface{}{
-			"action":  convertActionObjectToMap(instance.Action),
-			"subject": convertSubjectObjectToMap(instance.Subject),
-		}),
+		rego.Input(input),
 	).Eval(context)
 
 	// Handle errors from OPA engine, policy scripts
 	if err != nil {
-		return h.handleFailClose(fmt.Errorf("opa: request was rejected. err: %v", err))
+		return false, fmt.Errorf("opa: request was rejected. err: %v", err)
 	}
 
 	if len(rs) != 1 {
-		return h.handleFailClose(fmt.Errorf("opa: request was rejected"))
+		return false, fmt.Errorf("opa: request was rejected")
 	}
 
 	result, ok := rs[0].Expressions[0].Value.(bool)
 	if !ok {
-		return h.handleFailClose(fmt.Errorf("opa: request was rejected"))
+		return false, fmt.Errorf("opa: request was rejected")
 	}
 
+	return result, nil
+}
+
+func checkResult(allowed bool) adapter.CheckResult {
 	// rejected by policy scripts
-	if !result {
+	if !allowed {
 		return adapter.CheckResult{
 			Status: status.WithPermissionDenied("opa: request was rejected"),
-		}, nil
+		}
 	}
 
 	// Accepted
-	return adapter.CheckResult{Status: status.OK}, nil
+	return adapter.CheckResult{Status: status.OK}
+}
+
+// decisionKey returns the key decisions for the given input are cached under, or
+// an empty string if the input can't be used as a key.
+func decisionKey(revision string, input map[string]interface{}) string {
+	// encoding/json sorts map keys, making the encoding stable
+	b, err := json.Marshal(input)
+	if err != nil {
+		return ""
+	}
+
+	return revision + "\x00" + string(b)
+}
+
+func (h *handler) logDecision(instance *authorization.Instance, p *policy, input map[string]interface{}, allowed, cached bool, err error) {
+	if h.decisionLog == nil {
+		return
+	}
+
+	d := &decision{
+		Time:     h.getTime(),
+		Instance: instance.Name,
+		Revision: p.revision,
+		Input:    input,
+		Result:   allowed,
+		Cached:   cached,
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	if lerr := h.decisionLog.log(d); lerr != nil {
+		_ = h.logger.Errorf("opa: unable to log decision: %v", lerr)
+	}
+}
+
+func (h *handler) currentPolicy() *policy {
+	h.policyMutex.RLock()
+	p := h.policy
+	h.policyMutex.RUnlock()
+	return p
+}
+
+// watch periodically reloads the bundle, and evicts the expired decisions, until the handler is closed. Zero
+// intervals disable the respective activity.
+func (h *handler) watch(pollInterval time.Duration, evictionInterval time.Duration) {
+	defer close(h.stopped)
+
+	var poll, evict <-chan time.Time
+	if pollInterval > 0 {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	if evictionInterval > 0 {
+		ticker := time.NewTicker(evictionInterval)
+		defer ticker.Stop()
+		evict = ticker.C
+	}
+
+	for {
+		select {
+		case <-poll:
+			h.reloadBundle()
+		case <-evict:
+			h.decisions.EvictExpired()
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// reloadBundle swaps in the bundle's policies and data if the bundle has changed since
+// it was last loaded. Bundles that fail to load leave the current policies in effect.
+func (h *handler) reloadBundle() {
+	files, err := readBundleFiles(h.bundlePath)
+	if err != nil {
+		_ = h.logger.Errorf("opa: unable to reload bundle: %v", err)
+		return
+	}
+
+	digest := digestFiles(files)
+	if digest == h.bundleDigest {
+		return
+	}
+
+	// don't retry the same content until it changes again
+	h.bundleDigest = digest
+
+	b, err := parseBundle(files, digest)
+	if err != nil {
+		_ = h.logger.Errorf("opa: unable to reload bundle: %v", err)
+		return
+	}
+
+	compiler, errs := compile(h.inline, b)
+	if len(errs) > 0 {
+		_ = h.logger.Errorf("opa: unable to compile bundle revision %s: %v", b.revision, errs)
+		return
+	}
+
+	h.policyMutex.Lock()
+	h.policy = newPolicy(compiler, nil, b)
+	h.policyMutex.Unlock()
+
+	if h.decisions != nil {
+		h.decisions.RemoveAll()
+	}
+
+	h.logger.Infof("opa: loaded bundle revision %s", b.revision)
 }
 
 func (h *handler) Close() error {
-	return nil
+	h.closeOnce.Do(func() {
+		if h.done != nil {
+			close(h.done)
+			<-h.stopped
+		}
+
+		if h.decisionLog != nil {
+			h.closeErr = h.decisionLog.close()
+		}
+	})
+
+	return h.closeErr
 }
 
 ////////////////// Bootstrap //////////////////////////
@@ -270,7 +565,10 @@ func GetInfo() adapter.Info {
 		SupportedTemplates: []string{
 			authorization.TemplateName,
 		},
-		DefaultConfig: &config.Params{},
-		NewBuilder:    func() adapter.HandlerBuilder { return &builder{} },
+		DefaultConfig: &config.Params{
+			BundlePollInterval: 5 * time.Second,
+			CacheSize:          defaultCacheSize,
+		},
+		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
 	}
 }
@@ -19,11 +19,18 @@ package opa
 
 import (
 	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/adapter/opa/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/authorization"
 )
@@ -453,6 +460,263 @@ func TestFailOpenClose(t *testing.T) {
 	}
 }
 
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestBundle")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	writeBundleDir(t, dir, map[string]string{
+		"policy.rego":     rolesPolicy,
+		"roles/data.json": `{"alice": ["GET"]}`,
+		".manifest":       `{"revision": "rev1"}`,
+	})
+
+	b := GetInfo().NewBuilder().(*builder)
+	b.SetAdapterConfig(&config.Params{
+		BundlePath:         dir,
+		BundlePollInterval: 10 * time.Millisecond,
+		CheckMethod:        "data.mixerauthz.allow",
+	})
+
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	env := test.NewEnv(t)
+	h, err := b.Build(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	handler := h.(*handler)
+
+	if code := check(t, handler, "alice", "GET"); code != rpc.OK {
+		t.Errorf("Got %v, expecting %v", code, rpc.OK)
+	}
+	if code := check(t, handler, "alice", "PUT"); code != rpc.PERMISSION_DENIED {
+		t.Errorf("Got %v, expecting %v", code, rpc.PERMISSION_DENIED)
+	}
+
+	// a broken bundle leaves the current policies in effect
+	writeBundleDir(t, dir, map[string]string{"roles/data.json": `{`})
+	waitForLog(t, env, "unable to reload bundle")
+
+	if code := check(t, handler, "alice", "GET"); code != rpc.OK {
+		t.Errorf("Got %v, expecting %v", code, rpc.OK)
+	}
+
+	// a fixed bundle is swapped in
+	writeBundleDir(t, dir, map[string]string{
+		"roles/data.json": `{"alice": ["GET", "PUT"]}`,
+		".manifest":       `{"revision": "rev2"}`,
+	})
+	waitForLog(t, env, "loaded bundle revision rev2")
+
+	if code := check(t, handler, "alice", "PUT"); code != rpc.OK {
+		t.Errorf("Got %v, expecting %v", code, rpc.OK)
+	}
+
+	if err = handler.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+}
+
+func TestValidateBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestValidateBundle")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	writeBundleDir(t, filepath.Join(dir, "good"), map[string]string{"policy.rego": rolesPolicy})
+	writeBundleDir(t, filepath.Join(dir, "bad"), map[string]string{"policy.rego": "package mixerauthz\n  +\n"})
+	writeBundleDir(t, filepath.Join(dir, "empty"), map[string]string{"data.json": `{}`})
+
+	cases := []struct {
+		name    string
+		cfg     config.Params
+		success bool
+	}{
+		{"good bundle", config.Params{BundlePath: filepath.Join(dir, "good")}, true},
+		{"bad bundle", config.Params{BundlePath: filepath.Join(dir, "bad")}, false},
+		{"bundle without policies", config.Params{BundlePath: filepath.Join(dir, "empty")}, false},
+		{"missing bundle", config.Params{BundlePath: filepath.Join(dir, "missing")}, false},
+		{"negative poll interval", config.Params{BundlePath: filepath.Join(dir, "good"), BundlePollInterval: -time.Second}, false},
+		{"negative cache duration", config.Params{BundlePath: filepath.Join(dir, "good"), CacheDuration: -time.Second}, false},
+		{"negative cache size", config.Params{BundlePath: filepath.Join(dir, "good"), CacheSize: -1}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.cfg.CheckMethod = "data.mixerauthz.allow"
+			b := GetInfo().NewBuilder().(*builder)
+			b.SetAdapterConfig(&c.cfg)
+
+			ce := b.Validate()
+			if ce != nil && c.success {
+				t.Errorf("Got error %v, expecting success", ce)
+			} else if ce == nil && !c.success {
+				t.Error("Got success, expecting failure")
+			}
+		})
+	}
+}
+
+func TestDecisionCacheAndLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestDecisionCacheAndLog")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	logPath := filepath.Join(dir, "decisions.log")
+
+	b := GetInfo().NewBuilder().(*builder)
+	b.SetAdapterConfig(&config.Params{
+		Policy: []string{`package mixerauthz
+			default allow = false
+			allow = true { input.subject.user = "alice" }`},
+		CheckMethod:     "data.mixerauthz.allow",
+		CacheDuration:   time.Minute,
+		DecisionLogPath: logPath,
+	})
+
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	handler := h.(*handler)
+
+	for _, user := range []string{"alice", "alice", "bob"} {
+		check(t, handler, user, "GET")
+	}
+
+	if stats := handler.decisions.Stats(); stats.Hits != 1 || stats.Writes != 2 {
+		t.Errorf("Got cache stats %+v, expecting 1 hit and 2 writes", stats)
+	}
+
+	if err = handler.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+	if err = handler.Close(); err != nil {
+		t.Errorf("Got error %v on the second Close, expecting success", err)
+	}
+
+	content, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Unable to read decision log: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	expected := []struct {
+		user   string
+		result bool
+		cached bool
+	}{
+		{"alice", true, false},
+		{"alice", true, true},
+		{"bob", false, false},
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("Got %d decisions, expecting %d: %v", len(lines), len(expected), lines)
+	}
+
+	for i, e := range expected {
+		var d decision
+		if err := json.Unmarshal([]byte(lines[i]), &d); err != nil {
+			t.Fatalf("Invalid decision %s: %v", lines[i], err)
+		}
+
+		user := d.Input["subject"].(map[string]interface{})["user"]
+		if user != e.user || d.Result != e.result || d.Cached != e.cached {
+			t.Errorf("Got decision %s, expecting user %s, result %v, cached %v", lines[i], e.user, e.result, e.cached)
+		}
+
+		if d.Revision != handler.currentPolicy().revision || d.Revision == "" {
+			t.Errorf("Got revision %q, expecting %q", d.Revision, handler.currentPolicy().revision)
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestConformance")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	bundle := filepath.Join(dir, "bundle")
+	writeBundleDir(t, bundle, map[string]string{
+		"policy.rego":     rolesPolicy,
+		"roles/data.json": `{"alice": ["GET"]}`,
+	})
+
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Config: &config.Params{
+			BundlePath:         bundle,
+			BundlePollInterval: 10 * time.Millisecond,
+			CheckMethod:        "data.mixerauthz.allow",
+			CacheDuration:      time.Minute,
+			DecisionLogPath:    filepath.Join(dir, "decisions.log"),
+		},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{BundlePath: filepath.Join(dir, "missing"), CheckMethod: "data.mixerauthz.allow"},
+			&config.Params{BundlePath: bundle, CheckMethod: "data.mixerauthz.allow", CacheSize: -1},
+		},
+		Instances: map[string][]interface{}{
+			authorization.TemplateName: {
+				&authorization.Instance{
+					Name:    "allowed",
+					Subject: &authorization.Subject{User: "alice"},
+					Action:  &authorization.Action{Method: "GET"},
+				},
+				&authorization.Instance{
+					Name:    "denied",
+					Subject: &authorization.Subject{User: "bob"},
+					Action:  &authorization.Action{Method: "PUT"},
+				},
+			},
+		},
+	})
+}
+
+func check(t *testing.T, h *handler, user, method string) rpc.Code {
+	instance := authorization.Instance{
+		Subject: &authorization.Subject{User: user},
+		Action:  &authorization.Action{Method: method},
+	}
+
+	result, err := h.HandleAuthorization(context.Background(), &instance)
+	if err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+
+	return rpc.Code(result.Status.Code)
+}
+
+func waitForLog(t *testing.T, env *test.Env, msg string) {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		for _, l := range env.GetLogs() {
+			if strings.Contains(l, msg) {
+				return
+			}
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for log %q, got %v", msg, env.GetLogs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -19,9 +19,14 @@ import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
 import _ "github.com/gogo/protobuf/gogoproto"
+import _ "github.com/gogo/protobuf/types"
+
+import time "time"
 
 import strconv "strconv"
 
+import github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
+
 import encoding_binary "encoding/binary"
 
 import strings "strings"
@@ -33,6 +38,7 @@ import io "io"
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -98,6 +104,16 @@ type Params_MetricInfo struct {
 	// The names of labels to use: these need to match the dimensions of the Istio metric.
 	// TODO: see if we can remove this and rely on only the dimensions in the future.
 	LabelNames []string `protobuf:"bytes,6,rep,name=label_names,json=labelNames" json:"label_names,omitempty"`
+	// Optional. Series of this metric, i.e. combinations of label values, which are not
+	// updated for this long are removed, and are no longer exported to Prometheus.
+	// If not specified, series never expire.
+	SeriesExpiry time.Duration `protobuf:"bytes,7,opt,name=series_expiry,json=seriesExpiry,stdduration" json:"series_expiry"`
+	// Optional. Maximum number of series of this metric. Once it is reached, values with
+	// new combinations of label values are recorded in a single overflow series, in which
+	// every label has the value `__overflow__`, and the dropped label values are counted by
+	// Mixer's `mixer_prometheus_dropped_label_set_count` metric. If not specified, the number
+	// of series is not limited.
+	MaxSeries int64 `protobuf:"varint,8,opt,name=max_series,json=maxSeries,proto3" json:"max_series,omitempty"`
 }
 
 func (m *Params_MetricInfo) Reset()                    { *m = Params_MetricInfo{} }
@@ -454,6 +470,19 @@ func (m *Params_MetricInfo) MarshalTo(dAtA []byte) (int, error) {
 			i += copy(dAtA[i:], s)
 		}
 	}
+	dAtA[i] = 0x3a
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.SeriesExpiry)))
+	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.SeriesExpiry, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n2
+	if m.MaxSeries != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.MaxSeries))
+	}
 	return i, nil
 }
 
@@ -473,11 +502,11 @@ func (m *Params_MetricInfo_BucketsDefinition) MarshalTo(dAtA []byte) (int, error
 	var l int
 	_ = l
 	if m.Definition != nil {
-		nn2, err := m.Definition.MarshalTo(dAtA[i:])
+		nn3, err := m.Definition.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += nn2
+		i += nn3
 	}
 	return i, nil
 }
@@ -488,11 +517,11 @@ func (m *Params_MetricInfo_BucketsDefinition_LinearBuckets) MarshalTo(dAtA []byt
 		dAtA[i] = 0xa
 		i++
 		i = encodeVarintConfig(dAtA, i, uint64(m.LinearBuckets.Size()))
-		n3, err := m.LinearBuckets.MarshalTo(dAtA[i:])
+		n4, err := m.LinearBuckets.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += n3
+		i += n4
 	}
 	return i, nil
 }
@@ -502,11 +531,11 @@ func (m *Params_MetricInfo_BucketsDefinition_ExponentialBuckets) MarshalTo(dAtA
 		dAtA[i] = 0x12
 		i++
 		i = encodeVarintConfig(dAtA, i, uint64(m.ExponentialBuckets.Size()))
-		n4, err := m.ExponentialBuckets.MarshalTo(dAtA[i:])
+		n5, err := m.ExponentialBuckets.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += n4
+		i += n5
 	}
 	return i, nil
 }
@@ -516,11 +545,11 @@ func (m *Params_MetricInfo_BucketsDefinition_ExplicitBuckets) MarshalTo(dAtA []b
 		dAtA[i] = 0x1a
 		i++
 		i = encodeVarintConfig(dAtA, i, uint64(m.ExplicitBuckets.Size()))
-		n5, err := m.ExplicitBuckets.MarshalTo(dAtA[i:])
+		n6, err := m.ExplicitBuckets.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += n5
+		i += n6
 	}
 	return i, nil
 }
@@ -671,6 +700,11 @@ func (m *Params_MetricInfo) Size() (n int) {
 			n += 1 + l + sovConfig(uint64(l))
 		}
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.SeriesExpiry)
+	n += 1 + l + sovConfig(uint64(l))
+	if m.MaxSeries != 0 {
+		n += 1 + sovConfig(uint64(m.MaxSeries))
+	}
 	return n
 }
 
@@ -783,6 +817,8 @@ func (this *Params_MetricInfo) String() string {
 		`Kind:` + fmt.Sprintf("%v", this.Kind) + `,`,
 		`Buckets:` + strings.Replace(fmt.Sprintf("%v", this.Buckets), "Params_MetricInfo_BucketsDefinition", "Params_MetricInfo_BucketsDefinition", 1) + `,`,
 		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
+		`SeriesExpiry:` + strings.Replace(strings.Replace(this.SeriesExpiry.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`MaxSeries:` + fmt.Sprintf("%v", this.MaxSeries) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1147,6 +1183,55 @@ func (m *Params_MetricInfo) Unmarshal(dAtA []byte) error {
 			}
 			m.LabelNames = append(m.LabelNames, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesExpiry", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.SeriesExpiry, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSeries", wireType)
+			}
+			m.MaxSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSeries |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -1700,44 +1785,49 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/prometheus/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 612 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xbf, 0x4f, 0xdb, 0x4e,
-	0x14, 0xf7, 0x91, 0x5f, 0xf0, 0x1c, 0xc0, 0xdf, 0xfb, 0xa2, 0x2a, 0xcd, 0x70, 0x8d, 0x60, 0xc9,
-	0x80, 0x1c, 0x95, 0x2e, 0x9d, 0x2a, 0x11, 0x70, 0x20, 0xfd, 0x11, 0xd0, 0x41, 0xa4, 0xaa, 0x4b,
-	0xe4, 0xd8, 0x97, 0x70, 0x22, 0xbe, 0x8b, 0xec, 0x4b, 0x61, 0xa9, 0xd4, 0xb1, 0x63, 0xff, 0x8c,
-	0xfe, 0x29, 0xa8, 0x13, 0x63, 0xc7, 0xc6, 0x5d, 0x3a, 0xb2, 0x75, 0xad, 0x7c, 0xb6, 0x81, 0xaa,
-	0xea, 0x80, 0x32, 0xe5, 0xde, 0xbb, 0xcf, 0xaf, 0xf7, 0xa2, 0x33, 0x6c, 0x07, 0xfc, 0x92, 0x85,
-	0x2d, 0xd7, 0x77, 0xa7, 0x8a, 0x85, 0xad, 0x69, 0x28, 0x03, 0xa6, 0xce, 0xd8, 0x2c, 0x6a, 0x79,
-	0x52, 0x8c, 0xf8, 0x38, 0xfb, 0xb1, 0xa7, 0xa1, 0x54, 0x12, 0x3f, 0xce, 0x70, 0xf6, 0x1d, 0xce,
-	0x4e, 0x01, 0xf5, 0x8d, 0xb1, 0x1c, 0x4b, 0x8d, 0x6a, 0x25, 0xa7, 0x94, 0xb0, 0xf9, 0x75, 0x19,
-	0xca, 0xc7, 0x6e, 0xe8, 0x06, 0x11, 0xee, 0x40, 0x25, 0x60, 0x2a, 0xe4, 0x5e, 0x54, 0x43, 0x8d,
-	0x42, 0xd3, 0xdc, 0xd9, 0xb6, 0xff, 0xa9, 0x66, 0xa7, 0x1c, 0xfb, 0x8d, 0x26, 0x74, 0xc5, 0x48,
-	0xd2, 0x9c, 0x5c, 0xff, 0x55, 0x01, 0xb8, 0xeb, 0x63, 0x0c, 0x45, 0xe1, 0x06, 0xac, 0x86, 0x1a,
-	0xa8, 0xb9, 0x42, 0xf5, 0x19, 0x6f, 0xc1, 0x2a, 0x17, 0x91, 0x72, 0x85, 0xc7, 0x06, 0xfa, 0x72,
-	0x49, 0x5f, 0x56, 0xf3, 0x66, 0x2f, 0x01, 0x35, 0xc0, 0xf4, 0x59, 0xe4, 0x85, 0x7c, 0xaa, 0xb8,
-	0x14, 0xb5, 0x82, 0x86, 0xdc, 0x6f, 0x61, 0x07, 0x8a, 0xe7, 0x5c, 0xf8, 0xb5, 0x62, 0x03, 0x35,
-	0xd7, 0x76, 0x9e, 0x3e, 0x24, 0xae, 0xfd, 0x8a, 0x0b, 0x9f, 0x6a, 0x3a, 0x7e, 0x0b, 0x95, 0xe1,
-	0xcc, 0x3b, 0x67, 0x2a, 0xaa, 0x95, 0x1a, 0xa8, 0x69, 0xee, 0xbc, 0x78, 0x90, 0x52, 0x3b, 0xe5,
-	0xee, 0xb3, 0x11, 0x17, 0x3c, 0xc9, 0x45, 0x73, 0x39, 0xfc, 0x04, 0xcc, 0x89, 0x3b, 0x64, 0x13,
-	0x3d, 0x64, 0x54, 0x2b, 0x37, 0x0a, 0xcd, 0x15, 0x0a, 0xba, 0x95, 0x8c, 0x18, 0xd5, 0x3f, 0x95,
-	0xe0, 0xbf, 0xbf, 0xf8, 0x58, 0xc0, 0xda, 0x84, 0x0b, 0xe6, 0x86, 0x83, 0x3c, 0x17, 0xd2, 0xb9,
-	0x9c, 0xc5, 0x72, 0xd9, 0xaf, 0xb5, 0xe8, 0xa1, 0x41, 0x57, 0x53, 0xf9, 0x0c, 0x81, 0x3f, 0xc0,
-	0xff, 0xec, 0x72, 0x2a, 0x05, 0x13, 0x8a, 0xbb, 0x93, 0x5b, 0xd3, 0x25, 0x6d, 0xfa, 0x72, 0x41,
-	0x53, 0xe7, 0x4e, 0xf9, 0xd0, 0xa0, 0xf8, 0x9e, 0x51, 0x6e, 0xaf, 0xc0, 0x62, 0x97, 0xd3, 0x09,
-	0xf7, 0xb8, 0xba, 0xf5, 0x2e, 0x68, 0xef, 0x83, 0xc5, 0xbd, 0xb5, 0xec, 0xa1, 0x41, 0xd7, 0x73,
-	0x8b, 0x0c, 0x55, 0xf7, 0xa1, 0x9c, 0xee, 0x03, 0x6f, 0x03, 0x16, 0xb3, 0x60, 0xa0, 0x59, 0xec,
-	0x8f, 0x95, 0x97, 0xa8, 0x25, 0x66, 0x41, 0x47, 0x5f, 0xe4, 0x69, 0x37, 0xa0, 0x74, 0xc1, 0x7d,
-	0x75, 0xa6, 0xd7, 0x83, 0x68, 0x5a, 0xe0, 0x47, 0x50, 0x96, 0xa3, 0x51, 0xc4, 0x94, 0x4e, 0x8e,
-	0x68, 0x56, 0xd5, 0xdf, 0x83, 0x79, 0x6f, 0x01, 0x0f, 0xb4, 0xda, 0x82, 0xd5, 0x71, 0x28, 0x2f,
-	0xd4, 0xd9, 0x60, 0xe4, 0x7a, 0x4a, 0x86, 0x99, 0x65, 0x35, 0x6d, 0x76, 0x74, 0x2f, 0xc9, 0x13,
-	0x79, 0xee, 0x84, 0x65, 0xc6, 0x69, 0x51, 0xdf, 0x84, 0xe5, 0x7c, 0xf8, 0x24, 0xdb, 0x50, 0xce,
-	0x84, 0x9f, 0xbe, 0x6b, 0x44, 0xb3, 0xaa, 0x5d, 0x05, 0xf0, 0x6f, 0x77, 0xb5, 0xb9, 0x0b, 0xc5,
-	0xe4, 0x4d, 0xe0, 0x75, 0x30, 0xfb, 0xbd, 0x93, 0x63, 0x67, 0xaf, 0xdb, 0xe9, 0x3a, 0xfb, 0x96,
-	0x81, 0x57, 0xa0, 0x74, 0xb0, 0xdb, 0x3f, 0x70, 0x2c, 0x84, 0x4d, 0xa8, 0xec, 0x1d, 0xf5, 0x7b,
-	0xa7, 0x0e, 0xb5, 0x96, 0xb0, 0x05, 0xd5, 0xfd, 0xee, 0xc9, 0x29, 0xed, 0xb6, 0xfb, 0xa7, 0xdd,
-	0xa3, 0x9e, 0x55, 0x68, 0x3f, 0xbf, 0x9a, 0x13, 0xe3, 0x7a, 0x4e, 0x8c, 0x6f, 0x73, 0x62, 0xdc,
-	0xcc, 0x89, 0xf1, 0x31, 0x26, 0xe8, 0x4b, 0x4c, 0x8c, 0xab, 0x98, 0xa0, 0xeb, 0x98, 0xa0, 0xef,
-	0x31, 0x41, 0x3f, 0x63, 0x62, 0xdc, 0xc4, 0x04, 0x7d, 0xfe, 0x41, 0x8c, 0x77, 0xe5, 0xf4, 0xcf,
-	0x1c, 0x96, 0xf5, 0xd7, 0xe8, 0xd9, 0xef, 0x00, 0x00, 0x00, 0xff, 0xff, 0x2f, 0xc4, 0xb1, 0x23,
-	0xee, 0x04, 0x00, 0x00,
+	// 694 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x3d, 0x4f, 0x1b, 0x4b,
+	0x14, 0xdd, 0xc1, 0xdf, 0xd7, 0x36, 0xf8, 0xcd, 0x43, 0x4f, 0x8b, 0xa5, 0xb7, 0x58, 0xd0, 0xb8,
+	0x40, 0x6b, 0x85, 0x34, 0xa9, 0x22, 0x61, 0xbc, 0xc6, 0xce, 0x87, 0x41, 0x03, 0x96, 0xa2, 0x34,
+	0xd6, 0x7a, 0x77, 0x6c, 0x46, 0x78, 0x67, 0xac, 0xdd, 0x75, 0x70, 0x8a, 0x48, 0x29, 0x53, 0xa6,
+	0xcc, 0x4f, 0x48, 0x9f, 0x3f, 0x41, 0x49, 0x99, 0x2a, 0xc1, 0x4e, 0x93, 0x92, 0x9f, 0x10, 0xed,
+	0xcc, 0x2e, 0x10, 0x45, 0x29, 0x10, 0x95, 0xf7, 0x9e, 0x39, 0xe7, 0x9e, 0x33, 0xf7, 0x6a, 0x0c,
+	0x3b, 0x1e, 0x9b, 0x53, 0xbf, 0x61, 0xbb, 0xf6, 0x34, 0xa4, 0x7e, 0x63, 0xea, 0x0b, 0x8f, 0x86,
+	0xa7, 0x74, 0x16, 0x34, 0x1c, 0xc1, 0x47, 0x6c, 0x1c, 0xff, 0x98, 0x53, 0x5f, 0x84, 0x02, 0x6f,
+	0xc4, 0x3c, 0xf3, 0x96, 0x67, 0x2a, 0x42, 0x75, 0x7d, 0x2c, 0xc6, 0x42, 0xb2, 0x1a, 0xd1, 0x97,
+	0x12, 0x54, 0x8d, 0xb1, 0x10, 0xe3, 0x09, 0x6d, 0xc8, 0x6a, 0x38, 0x1b, 0x35, 0xdc, 0x99, 0x6f,
+	0x87, 0x4c, 0x70, 0x75, 0xbe, 0xf5, 0xa5, 0x00, 0xd9, 0x23, 0xdb, 0xb7, 0xbd, 0x00, 0xb7, 0x21,
+	0xe7, 0xd1, 0xd0, 0x67, 0x4e, 0xa0, 0xa3, 0x5a, 0xaa, 0x5e, 0xdc, 0xdd, 0x31, 0xff, 0xea, 0x66,
+	0x2a, 0x8d, 0xf9, 0x52, 0x0a, 0xba, 0x7c, 0x24, 0x48, 0x22, 0xae, 0x5e, 0xe5, 0x01, 0x6e, 0x71,
+	0x8c, 0x21, 0xcd, 0x6d, 0x8f, 0xea, 0xa8, 0x86, 0xea, 0x05, 0x22, 0xbf, 0xf1, 0x36, 0x94, 0x19,
+	0x0f, 0x42, 0x9b, 0x3b, 0x74, 0x20, 0x0f, 0x57, 0xe4, 0x61, 0x29, 0x01, 0x7b, 0x11, 0xa9, 0x06,
+	0x45, 0x97, 0x06, 0x8e, 0xcf, 0xa6, 0x51, 0x5e, 0x3d, 0x25, 0x29, 0x77, 0x21, 0x6c, 0x41, 0xfa,
+	0x8c, 0x71, 0x57, 0x4f, 0xd7, 0x50, 0x7d, 0x75, 0xf7, 0xd1, 0x7d, 0xe2, 0x9a, 0xcf, 0x19, 0x77,
+	0x89, 0x94, 0xe3, 0x57, 0x90, 0x1b, 0xce, 0x9c, 0x33, 0x1a, 0x06, 0x7a, 0xa6, 0x86, 0xea, 0xc5,
+	0xdd, 0xa7, 0xf7, 0xea, 0xd4, 0x54, 0xda, 0x16, 0x1d, 0x31, 0xce, 0xa2, 0x5c, 0x24, 0x69, 0x87,
+	0x37, 0xa1, 0x38, 0xb1, 0x87, 0x74, 0x22, 0x2f, 0x19, 0xe8, 0xd9, 0x5a, 0xaa, 0x5e, 0x20, 0x20,
+	0xa1, 0xe8, 0x8a, 0x01, 0xee, 0x40, 0x39, 0xa0, 0x3e, 0xa3, 0xc1, 0x80, 0xce, 0xa7, 0xcc, 0x7f,
+	0xab, 0xe7, 0x64, 0x80, 0x0d, 0x53, 0xad, 0xcd, 0x4c, 0xd6, 0x66, 0xb6, 0xe2, 0xb5, 0x35, 0xf3,
+	0x17, 0xdf, 0x36, 0xb5, 0x4f, 0xdf, 0x37, 0x11, 0x29, 0x29, 0xa5, 0x25, 0x85, 0xf8, 0x7f, 0x00,
+	0xcf, 0x9e, 0x0f, 0x14, 0xa6, 0xe7, 0x6b, 0xa8, 0x9e, 0x22, 0x05, 0xcf, 0x9e, 0x1f, 0x4b, 0xa0,
+	0xfa, 0x21, 0x03, 0xff, 0xfc, 0x11, 0x14, 0x73, 0x58, 0x9d, 0x30, 0x4e, 0x6d, 0x7f, 0x90, 0x0c,
+	0x00, 0x49, 0x7f, 0xeb, 0x61, 0x03, 0x30, 0x5f, 0xc8, 0xa6, 0x1d, 0x8d, 0x94, 0x55, 0xfb, 0x98,
+	0x81, 0xdf, 0xc1, 0xbf, 0x74, 0x3e, 0x15, 0x9c, 0xf2, 0x90, 0xd9, 0x93, 0x1b, 0xd3, 0x15, 0x69,
+	0xfa, 0xec, 0x81, 0xa6, 0xd6, 0x6d, 0xe7, 0x8e, 0x46, 0xf0, 0x1d, 0xa3, 0xc4, 0x3e, 0x84, 0x0a,
+	0x9d, 0x4f, 0x27, 0xcc, 0x61, 0xe1, 0x8d, 0x77, 0x4a, 0x7a, 0x1f, 0x3c, 0xdc, 0x5b, 0xb6, 0xed,
+	0x68, 0x64, 0x2d, 0xb1, 0x88, 0x59, 0x55, 0x17, 0xb2, 0x6a, 0x1e, 0x78, 0x07, 0x30, 0x9f, 0x79,
+	0x03, 0xa9, 0xa2, 0xbf, 0x8d, 0x3c, 0x43, 0x2a, 0x7c, 0xe6, 0xb5, 0xe5, 0x41, 0x92, 0x76, 0x1d,
+	0x32, 0xe7, 0xcc, 0x0d, 0x4f, 0xe5, 0x78, 0x10, 0x51, 0x05, 0xfe, 0x0f, 0xb2, 0x62, 0x34, 0x0a,
+	0x68, 0x28, 0x93, 0x23, 0x12, 0x57, 0xd5, 0x37, 0x50, 0xbc, 0x33, 0x80, 0x7b, 0x5a, 0x6d, 0x43,
+	0x79, 0xec, 0x8b, 0xf3, 0xf0, 0x74, 0x30, 0xb2, 0x9d, 0x50, 0xf8, 0xb1, 0x65, 0x49, 0x81, 0x6d,
+	0x89, 0x45, 0x79, 0x02, 0xc7, 0x9e, 0xd0, 0xd8, 0x58, 0x15, 0xd5, 0x2d, 0xc8, 0x27, 0x97, 0x8f,
+	0xb2, 0x0d, 0xc5, 0x8c, 0xbb, 0xea, 0x0f, 0x04, 0x91, 0xb8, 0x6a, 0x96, 0x00, 0xdc, 0x9b, 0x59,
+	0x6d, 0xed, 0x41, 0x3a, 0x7a, 0x7c, 0x78, 0x0d, 0x8a, 0xfd, 0xde, 0xf1, 0x91, 0xb5, 0xdf, 0x6d,
+	0x77, 0xad, 0x56, 0x45, 0xc3, 0x05, 0xc8, 0x1c, 0xec, 0xf5, 0x0f, 0xac, 0x0a, 0xc2, 0x45, 0xc8,
+	0xed, 0x1f, 0xf6, 0x7b, 0x27, 0x16, 0xa9, 0xac, 0xe0, 0x0a, 0x94, 0x5a, 0xdd, 0xe3, 0x13, 0xd2,
+	0x6d, 0xf6, 0x4f, 0xba, 0x87, 0xbd, 0x4a, 0xaa, 0xf9, 0xe4, 0x62, 0x61, 0x68, 0x97, 0x0b, 0x43,
+	0xfb, 0xba, 0x30, 0xb4, 0xeb, 0x85, 0xa1, 0xbd, 0x5f, 0x1a, 0xe8, 0xf3, 0xd2, 0xd0, 0x2e, 0x96,
+	0x06, 0xba, 0x5c, 0x1a, 0xe8, 0x6a, 0x69, 0xa0, 0x9f, 0x4b, 0x43, 0xbb, 0x5e, 0x1a, 0xe8, 0xe3,
+	0x0f, 0x43, 0x7b, 0x9d, 0x55, 0xcb, 0x1c, 0x66, 0xe5, 0x8b, 0x7a, 0xfc, 0x6b, 0x00, 0x21, 0xe5,
+	0xee, 0x34, 0x77, 0x05, 0x00, 0x00,
 }
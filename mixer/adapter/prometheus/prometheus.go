@@ -25,6 +25,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -32,6 +33,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/prometheus/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/template/metric"
@@ -41,10 +43,13 @@ type (
 	// cinfo is a collector, its kind and the sha
 	// of config that produced the collector.
 	// sha is used to confirm a cache hit.
+	// series is set if the series of the collector
+	// expire or are capped.
 	cinfo struct {
-		c    prometheus.Collector
-		sha  [sha1.Size]byte
-		kind config.Params_MetricInfo_Kind
+		c      prometheus.Collector
+		sha    [sha1.Size]byte
+		kind   config.Params_MetricInfo_Kind
+		series *series
 	}
 
 	builder struct {
@@ -53,11 +58,23 @@ type (
 		registry *prometheus.Registry
 		srv      server
 		cfg      *config.Params
+		types    map[string]*metric.Type
+		// warnings found by Validate, logged by Build.
+		warnings []string
 	}
 
 	handler struct {
 		srv     server
 		metrics map[string]*cinfo
+
+		// collectors whose series expire.
+		expiring []*cinfo
+		done     chan struct{}
+		stopped  chan struct{}
+
+		// makes Close idempotent, so the shared server is released once.
+		closeOnce sync.Once
+		closeErr  error
 	}
 )
 
@@ -72,6 +89,17 @@ const (
 	namespace = "istio"
 )
 
+// unboundedLabelNames are names of labels which likely have a distinct value
+// for every request, once lower cased and stripped of separators.
+var unboundedLabelNames = map[string]bool{
+	"requestid":  true,
+	"xrequestid": true,
+	"traceid":    true,
+	"spanid":     true,
+	"sessionid":  true,
+	"uuid":       true,
+}
+
 // GetInfo returns the Info associated with this adapter.
 func GetInfo() adapter.Info {
 	// prometheus uses a singleton http port, so we make the
@@ -98,12 +126,57 @@ func (b *builder) clearState() {
 	b.metrics = make(map[string]*cinfo)
 }
 
-func (b *builder) SetMetricTypes(map[string]*metric.Type) {}
-func (b *builder) SetAdapterConfig(cfg adapter.Config)    { b.cfg = cfg.(*config.Params) }
-func (b *builder) Validate() *adapter.ConfigErrors        { return nil }
+func (b *builder) SetMetricTypes(types map[string]*metric.Type) { b.types = types }
+func (b *builder) SetAdapterConfig(cfg adapter.Config)          { b.cfg = cfg.(*config.Params) }
+
+// Validate checks the series limits of the metrics. As unbounded labels are
+// only likely to be a mistake, they are reported as warnings when building
+// the handler rather than as errors.
+func (b *builder) Validate() (ce *adapter.ConfigErrors) {
+	b.warnings = nil
+	for i, m := range b.cfg.Metrics {
+		if m.SeriesExpiry < 0 {
+			ce = ce.Appendf(fmt.Sprintf("metrics[%d].seriesExpiry", i), "series expiry must be >= 0, it is %v", m.SeriesExpiry)
+		}
+		if m.MaxSeries < 0 {
+			ce = ce.Appendf(fmt.Sprintf("metrics[%d].maxSeries", i), "maximum number of series must be >= 0, it is %d", m.MaxSeries)
+		}
+		for _, l := range m.LabelNames {
+			if unboundedLabel(l, b.types[m.InstanceName]) {
+				b.warnings = append(b.warnings, fmt.Sprintf("label %s of metric %s likely has a distinct value for every request, "+
+					"which makes the number of series of the metric unbounded", l, m.InstanceName))
+			}
+		}
+	}
+	return
+}
+
+// unboundedLabel returns whether a label likely has a distinct value for
+// every request, based on its name and on the type of the dimension it holds.
+func unboundedLabel(name string, t *metric.Type) bool {
+	if t != nil {
+		switch t.Dimensions[name] {
+		case descriptor.TIMESTAMP, descriptor.DURATION:
+			return true
+		}
+	}
+	n := strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+	return unboundedLabelNames[n]
+}
+
 func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler, error) {
 
 	cfg := b.cfg
+	for _, w := range b.warnings {
+		env.Logger().Warningf("%s", w)
+	}
+	b.warnings = nil
 	var metricErr *multierror.Error
 
 	// newMetrics collects new metric configuration
@@ -147,6 +220,9 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 			mname = m.Name
 		}
 		ci := &cinfo{kind: m.Kind, sha: computeSha(m, env.Logger())}
+		if m.SeriesExpiry > 0 || m.MaxSeries > 0 {
+			ci.series = newSeries(safeName(mname), labelNames(m.LabelNames), m.SeriesExpiry, m.MaxSeries)
+		}
 		switch m.Kind {
 		case config.GAUGE:
 			// TODO: make prometheus use the keys of metric.Type.Dimensions as the label names and remove from config.
@@ -179,7 +255,28 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 		return nil, err
 	}
 
-	return &handler{b.srv, b.metrics}, metricErr.ErrorOrNil()
+	h := &handler{srv: b.srv, metrics: b.metrics, done: make(chan struct{})}
+	var interval time.Duration
+	for _, m := range cfg.Metrics {
+		ci := b.metrics[m.InstanceName]
+		if ci == nil || m.SeriesExpiry <= 0 {
+			continue
+		}
+		h.expiring = append(h.expiring, ci)
+		if interval == 0 || m.SeriesExpiry < interval {
+			interval = m.SeriesExpiry
+		}
+	}
+	if len(h.expiring) > 0 {
+		// check twice per expiry, so series are removed at most 1.5 times the expiry after their last update
+		if interval > 1 {
+			interval /= 2
+		}
+		h.stopped = make(chan struct{})
+		env.ScheduleDaemon(func() { h.expireSeries(interval) })
+	}
+
+	return h, metricErr.ErrorOrNil()
 }
 
 func (h *handler) HandleMetric(_ context.Context, vals []*metric.Instance) error {
@@ -191,39 +288,67 @@ func (h *handler) HandleMetric(_ context.Context, vals []*metric.Instance) error
 			result = multierror.Append(result, fmt.Errorf("could not find metric info from adapter config for %s", val.Name))
 			continue
 		}
-		collector := ci.c
-		switch ci.kind {
-		case config.GAUGE:
-			vec := collector.(*prometheus.GaugeVec)
-			amt, err := promValue(val.Value)
-			if err != nil {
-				result = multierror.Append(result, fmt.Errorf("could not get value for metric %s: %v", val.Name, err))
-				continue
-			}
-			vec.With(promLabels(val.Dimensions)).Set(amt)
-		case config.COUNTER:
-			vec := collector.(*prometheus.CounterVec)
-			amt, err := promValue(val.Value)
-			if err != nil {
-				result = multierror.Append(result, fmt.Errorf("could not get value for metric %s: %v", val.Name, err))
-				continue
-			}
-			vec.With(promLabels(val.Dimensions)).Add(amt)
-		case config.DISTRIBUTION:
-			vec := collector.(*prometheus.HistogramVec)
-			amt, err := promValue(val.Value)
-			if err != nil {
-				result = multierror.Append(result, fmt.Errorf("could not get value for metric %s: %v", val.Name, err))
-				continue
-			}
-			vec.With(promLabels(val.Dimensions)).Observe(amt)
+		amt, err := promValue(val.Value)
+		if err != nil {
+			result = multierror.Append(result, fmt.Errorf("could not get value for metric %s: %v", val.Name, err))
+			continue
+		}
+
+		labels := promLabels(val.Dimensions)
+		if s := ci.series; s != nil {
+			s.lock.Lock()
+			ci.record(s.track(labels, time.Now()), amt)
+			s.lock.Unlock()
+		} else {
+			ci.record(labels, amt)
 		}
 	}
 
 	return result.ErrorOrNil()
 }
 
-func (h *handler) Close() error { return h.srv.Close() }
+// record records a value in the series with the given labels.
+func (ci *cinfo) record(labels prometheus.Labels, amt float64) {
+	switch ci.kind {
+	case config.GAUGE:
+		ci.c.(*prometheus.GaugeVec).With(labels).Set(amt)
+	case config.COUNTER:
+		ci.c.(*prometheus.CounterVec).With(labels).Add(amt)
+	case config.DISTRIBUTION:
+		ci.c.(*prometheus.HistogramVec).With(labels).Observe(amt)
+	}
+}
+
+// expireSeries periodically removes the series which are no longer updated,
+// until the handler is closed.
+func (h *handler) expireSeries(interval time.Duration) {
+	defer close(h.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, ci := range h.expiring {
+				ci.series.expire(ci.c.(deleter), now)
+			}
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *handler) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.done)
+		if h.stopped != nil {
+			<-h.stopped
+		}
+		h.closeErr = h.srv.Close()
+	})
+	return h.closeErr
+}
 
 func newCounterVec(name, desc string, labels []string) *prometheus.CounterVec {
 	if desc == "" {
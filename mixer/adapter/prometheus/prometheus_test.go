@@ -25,6 +25,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 
+	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/prometheus/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
@@ -33,6 +34,7 @@ import (
 
 type testServer struct {
 	errOnStart bool
+	closed     int
 }
 
 var _ server = &testServer{}
@@ -44,7 +46,10 @@ func (t testServer) Start(adapter.Env, http.Handler) error {
 	return nil
 }
 
-func (testServer) Close() error { return nil }
+func (t *testServer) Close() error {
+	t.closed++
+	return nil
+}
 
 func newBuilder(s server) *builder {
 	return &builder{
@@ -295,12 +300,19 @@ func TestFactory_Build_MetricDefinitionConflicts(t *testing.T) {
 }
 
 func TestProm_Close(t *testing.T) {
-	f := newBuilder(&testServer{})
+	srv := &testServer{}
+	f := newBuilder(srv)
 	f.SetAdapterConfig(&config.Params{})
 	prom, _ := f.Build(context.Background(), test.NewEnv(t))
 	if err := prom.Close(); err != nil {
 		t.Errorf("Close() should not have returned an error: %v", err)
 	}
+	if err := prom.Close(); err != nil {
+		t.Errorf("second Close() should not have returned an error: %v", err)
+	}
+	if srv.closed != 1 {
+		t.Errorf("server released %d times, want 1", srv.closed)
+	}
 }
 
 func TestProm_Record(t *testing.T) {
@@ -404,6 +416,172 @@ func TestProm_RecordFailures(t *testing.T) {
 	}
 }
 
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		metric   *config.Params_MetricInfo
+		types    map[string]*metric.Type
+		errors   int
+		warnings int
+	}{
+		{"Valid", counter, nil, 0, 0},
+		{"Negative Expiry", &config.Params_MetricInfo{InstanceName: "a", Kind: config.COUNTER, SeriesExpiry: -time.Second}, nil, 1, 0},
+		{"Negative Max Series", &config.Params_MetricInfo{InstanceName: "a", Kind: config.COUNTER, MaxSeries: -1}, nil, 1, 0},
+		{"Request ID Label", &config.Params_MetricInfo{InstanceName: "a", Kind: config.COUNTER,
+			LabelNames: []string{"request_id", "destination", "X-Request-Id"}}, nil, 0, 2},
+		{"Timestamp Label",
+			&config.Params_MetricInfo{InstanceName: "a", Kind: config.COUNTER, LabelNames: []string{"start", "destination"}},
+			map[string]*metric.Type{"a": {Dimensions: map[string]descriptor.ValueType{
+				"start":       descriptor.TIMESTAMP,
+				"destination": descriptor.STRING,
+			}}}, 0, 1},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			f := newBuilder(&testServer{})
+			f.SetMetricTypes(v.types)
+			f.SetAdapterConfig(makeConfig(v.metric))
+
+			ce := f.Validate()
+			errors := 0
+			if ce != nil {
+				errors = len(ce.Multi.Errors)
+			}
+			if errors != v.errors {
+				t.Errorf("Validate() => %v, want %d errors", ce, v.errors)
+			}
+			if len(f.warnings) != v.warnings {
+				t.Errorf("Validate() => warnings %v, want %d warnings", f.warnings, v.warnings)
+			}
+		})
+	}
+}
+
+func TestProm_MaxSeries(t *testing.T) {
+	f := newBuilder(&testServer{})
+	m := &config.Params_MetricInfo{
+		InstanceName: "capped_counter",
+		Kind:         config.COUNTER,
+		LabelNames:   []string{"destination"},
+		MaxSeries:    2,
+	}
+	f.SetAdapterConfig(makeConfig(m))
+	h, err := f.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Build() => unexpected error: %v", err)
+	}
+	defer func() { _ = h.Close() }()
+
+	dropped := counterValue(droppedLabelSets.WithLabelValues("capped_counter"))
+	for _, d := range []string{"a", "b", "a", "c", "d"} {
+		val := &metric.Instance{Name: m.InstanceName, Value: int64(1), Dimensions: map[string]interface{}{"destination": d}}
+		if err = h.(metric.Handler).HandleMetric(context.Background(), []*metric.Instance{val}); err != nil {
+			t.Fatalf("HandleMetric() => unexpected error: %v", err)
+		}
+	}
+
+	want := map[string]float64{"a": 2, "b": 1, overflowLabelValue: 2}
+	if got := seriesValues(t, f.registry, "destination"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got series %v, want %v", got, want)
+	}
+	if got := counterValue(droppedLabelSets.WithLabelValues("capped_counter")) - dropped; got != 2 {
+		t.Errorf("Got %v dropped label sets, want 2", got)
+	}
+}
+
+func TestProm_SeriesExpiry(t *testing.T) {
+	f := newBuilder(&testServer{})
+	m := &config.Params_MetricInfo{
+		InstanceName: "expiring_gauge",
+		Kind:         config.GAUGE,
+		LabelNames:   []string{"destination"},
+		SeriesExpiry: 50 * time.Millisecond,
+	}
+	f.SetAdapterConfig(makeConfig(m))
+	h, err := f.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Build() => unexpected error: %v", err)
+	}
+	defer func() { _ = h.Close() }()
+
+	record := func(d string) {
+		val := &metric.Instance{Name: m.InstanceName, Value: int64(1), Dimensions: map[string]interface{}{"destination": d}}
+		if err := h.(metric.Handler).HandleMetric(context.Background(), []*metric.Instance{val}); err != nil {
+			t.Fatalf("HandleMetric() => unexpected error: %v", err)
+		}
+	}
+
+	record("a")
+	record("b")
+	// keep b updated until a expired
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		record("b")
+		if len(seriesValues(t, f.registry, "destination")) == 1 {
+			break
+		}
+	}
+
+	want := map[string]float64{"b": 1}
+	if got := seriesValues(t, f.registry, "destination"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got series %v, want %v", got, want)
+	}
+}
+
+// seriesValues returns the value of each series of the registry, by the value of the given label.
+func TestConformance(t *testing.T) {
+	// the handlers use test servers, instead of serving metrics on the default address.
+	info := GetInfo()
+	info.NewBuilder = func() adapter.HandlerBuilder { return newBuilder(&testServer{}) }
+
+	expiring := &config.Params_MetricInfo{
+		InstanceName: "expiring_gauge",
+		Kind:         config.GAUGE,
+		LabelNames:   []string{"destination"},
+		SeriesExpiry: time.Minute,
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info:   info,
+		Config: makeConfig(counter, histogram, expiring),
+		InvalidConfigs: []adapter.Config{
+			makeConfig(&config.Params_MetricInfo{InstanceName: "a", Kind: config.COUNTER, MaxSeries: -1}),
+		},
+		Instances: map[string][]interface{}{
+			metric.TemplateName: {
+				counterVal,
+				histogramVal,
+				&metric.Instance{Name: expiring.InstanceName, Value: int64(1), Dimensions: map[string]interface{}{"destination": "a"}},
+			},
+		},
+	})
+}
+
+func seriesValues(t *testing.T, r *prometheus.Registry, label string) map[string]float64 {
+	t.Helper()
+	families, err := r.Gather()
+	if err != nil {
+		t.Fatalf("Gather() => unexpected error: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.Metric {
+			for _, l := range m.Label {
+				if l.GetName() == label {
+					values[l.GetValue()] = metricValue(m)
+				}
+			}
+		}
+	}
+	return values
+}
+
+func counterValue(c prometheus.Counter) float64 {
+	m := new(dto.Metric)
+	_ = c.Write(m)
+	return m.GetCounter().GetValue()
+}
+
 func metricValue(m *dto.Metric) float64 {
 	if c := m.GetCounter(); c != nil {
 		return *c.Value
@@ -0,0 +1,127 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// overflowLabelValue is the value of every label of the series in which values
+// are recorded once a metric reaches its maximum number of series.
+const overflowLabelValue = "__overflow__"
+
+var droppedLabelSets = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "mixer",
+	Subsystem: "prometheus",
+	Name:      "dropped_label_set_count",
+	Help:      "Total number of label value combinations recorded in the overflow series of a metric, as it had reached its maximum number of series.",
+}, []string{"metric"})
+
+func init() {
+	prometheus.MustRegister(droppedLabelSets)
+}
+
+type (
+	// series tracks the label values of a metric, in order to expire the
+	// series which are no longer updated and to cap the number of series.
+	// The lock must be held while tracking labels and recording the value,
+	// so that a series is not expired in between.
+	series struct {
+		lock sync.Mutex
+
+		expiry    time.Duration
+		maxSeries int
+		labels    []string
+		entries   map[string]*seriesEntry
+		overflow  *seriesEntry
+		dropped   prometheus.Counter
+	}
+
+	seriesEntry struct {
+		labels  prometheus.Labels
+		updated time.Time
+	}
+
+	// deleter is implemented by prometheus metric vectors.
+	deleter interface {
+		Delete(prometheus.Labels) bool
+	}
+)
+
+func newSeries(name string, labels []string, expiry time.Duration, maxSeries int64) *series {
+	return &series{
+		expiry:    expiry,
+		maxSeries: int(maxSeries),
+		labels:    labels,
+		entries:   make(map[string]*seriesEntry),
+		dropped:   droppedLabelSets.WithLabelValues(name),
+	}
+}
+
+// track records an update of the series with the given labels, and returns
+// the labels to record the value with.
+func (s *series) track(labels prometheus.Labels, now time.Time) prometheus.Labels {
+	key := s.key(labels)
+	if e, found := s.entries[key]; found {
+		e.updated = now
+		return e.labels
+	}
+
+	if s.maxSeries > 0 && len(s.entries) >= s.maxSeries {
+		s.dropped.Inc()
+		if s.overflow == nil {
+			overflow := make(prometheus.Labels, len(s.labels))
+			for _, l := range s.labels {
+				overflow[l] = overflowLabelValue
+			}
+			s.overflow = &seriesEntry{labels: overflow}
+		}
+		s.overflow.updated = now
+		return s.overflow.labels
+	}
+
+	s.entries[key] = &seriesEntry{labels: labels, updated: now}
+	return labels
+}
+
+// expire removes the series which were not updated since the expiry duration
+// from the metric vector.
+func (s *series) expire(vec deleter, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key, e := range s.entries {
+		if now.Sub(e.updated) >= s.expiry {
+			vec.Delete(e.labels)
+			delete(s.entries, key)
+		}
+	}
+	if s.overflow != nil && now.Sub(s.overflow.updated) >= s.expiry {
+		vec.Delete(s.overflow.labels)
+		s.overflow = nil
+	}
+}
+
+func (s *series) key(labels prometheus.Labels) string {
+	values := make([]string, len(s.labels))
+	for i, l := range s.labels {
+		values[i] = labels[l]
+	}
+	return strings.Join(values, "\xff")
+}
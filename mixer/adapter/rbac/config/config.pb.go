@@ -66,6 +66,10 @@ type Params struct {
 	ConfigStoreUrl string `protobuf:"bytes,1,opt,name=config_store_url,json=configStoreUrl,proto3" json:"config_store_url,omitempty"`
 	// The duration for which authorization results may be cached.
 	CacheDuration time.Duration `protobuf:"bytes,2,opt,name=cache_duration,json=cacheDuration,stdduration" json:"cache_duration"`
+	// Whether to explain authorization decisions. When enabled, the role, binding
+	// and rule that granted or denied a request are logged and returned in the
+	// message of the Check response status.
+	Explain bool `protobuf:"varint,3,opt,name=explain,proto3" json:"explain,omitempty"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -104,6 +108,16 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		return 0, err
 	}
 	i += n1
+	if m.Explain {
+		dAtA[i] = 0x18
+		i++
+		if m.Explain {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -125,6 +139,9 @@ func (m *Params) Size() (n int) {
 	}
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.CacheDuration)
 	n += 1 + l + sovConfig(uint64(l))
+	if m.Explain {
+		n += 2
+	}
 	return n
 }
 
@@ -148,6 +165,7 @@ func (this *Params) String() string {
 	s := strings.Join([]string{`&Params{`,
 		`ConfigStoreUrl:` + fmt.Sprintf("%v", this.ConfigStoreUrl) + `,`,
 		`CacheDuration:` + strings.Replace(strings.Replace(this.CacheDuration.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
+		`Explain:` + fmt.Sprintf("%v", this.Explain) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -248,6 +266,26 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Explain", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Explain = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -377,21 +415,23 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/rbac/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 256 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0xcb, 0xcd, 0xac, 0x48,
-	0x2d, 0xd2, 0x4f, 0x4c, 0x49, 0x2c, 0x28, 0x49, 0x2d, 0xd2, 0x2f, 0x4a, 0x4a, 0x4c, 0xd6, 0x4f,
-	0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0x87, 0x52, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0xc2, 0x50,
-	0x15, 0x7a, 0x20, 0x15, 0x7a, 0x10, 0x29, 0x29, 0xb9, 0xf4, 0xfc, 0xfc, 0xf4, 0x9c, 0x54, 0x7d,
-	0xb0, 0x92, 0xa4, 0xd2, 0x34, 0xfd, 0x94, 0xd2, 0xa2, 0xc4, 0x92, 0xcc, 0xfc, 0x3c, 0x88, 0x26,
-	0x29, 0x91, 0xf4, 0xfc, 0xf4, 0x7c, 0x30, 0x53, 0x1f, 0xc4, 0x82, 0x88, 0x2a, 0xd5, 0x71, 0xb1,
-	0x05, 0x24, 0x16, 0x25, 0xe6, 0x16, 0x0b, 0x69, 0x70, 0x09, 0x40, 0x4c, 0x8a, 0x2f, 0x2e, 0xc9,
-	0x2f, 0x4a, 0x8d, 0x2f, 0x2d, 0xca, 0x91, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0xe2, 0x83, 0x88,
-	0x07, 0x83, 0x84, 0x43, 0x8b, 0x72, 0x84, 0xbc, 0xb8, 0xf8, 0x92, 0x13, 0x93, 0x33, 0x52, 0xe3,
-	0x61, 0x36, 0x48, 0x30, 0x29, 0x30, 0x6a, 0x70, 0x1b, 0x49, 0xea, 0x41, 0x9c, 0xa0, 0x07, 0x73,
-	0x82, 0x9e, 0x0b, 0x54, 0x81, 0x13, 0xc7, 0x89, 0x7b, 0xf2, 0x0c, 0x33, 0xee, 0xcb, 0x33, 0x06,
-	0xf1, 0x82, 0xb5, 0xc2, 0x25, 0x2c, 0x4e, 0x3c, 0x94, 0x63, 0xb8, 0xf0, 0x50, 0x8e, 0xe1, 0xc6,
-	0x43, 0x39, 0x86, 0x0f, 0x0f, 0xe5, 0x18, 0x1a, 0x1e, 0xc9, 0x31, 0xae, 0x78, 0x24, 0xc7, 0x70,
-	0xe2, 0x91, 0x1c, 0xe3, 0x85, 0x47, 0x72, 0x8c, 0x0f, 0x1e, 0xc9, 0x31, 0xbe, 0x78, 0x24, 0xc7,
-	0xf0, 0xe1, 0x91, 0x1c, 0xe3, 0x84, 0xc7, 0x72, 0x0c, 0x51, 0x6c, 0x10, 0xd7, 0x24, 0xb1, 0x81,
-	0x6d, 0x31, 0x06, 0x04, 0x00, 0x00, 0xff, 0xff, 0x7c, 0xf7, 0x13, 0xb7, 0x35, 0x01, 0x00, 0x00,
+	// 275 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x34, 0x8e, 0xb1, 0x4e, 0xc3, 0x30,
+	0x10, 0x86, 0x7d, 0x20, 0x95, 0x12, 0x44, 0x85, 0x02, 0x43, 0xe8, 0x70, 0x8d, 0x18, 0x50, 0x26,
+	0x5b, 0x82, 0x85, 0xb9, 0x62, 0x62, 0x42, 0x41, 0x2c, 0x2c, 0x91, 0x93, 0xba, 0x26, 0x52, 0x1a,
+	0x47, 0x6e, 0x22, 0x75, 0xe4, 0x11, 0x18, 0xfb, 0x08, 0x3c, 0x4a, 0xc6, 0x8e, 0x4c, 0x40, 0xcc,
+	0xc2, 0xd8, 0x47, 0x40, 0x89, 0x93, 0xc9, 0xe7, 0xff, 0xbe, 0xff, 0xfe, 0xdf, 0xb9, 0x5e, 0xa5,
+	0x1b, 0xa1, 0x19, 0x5f, 0xf0, 0xa2, 0x14, 0x9a, 0xe9, 0x98, 0x27, 0x2c, 0x51, 0xf9, 0x32, 0x95,
+	0xfd, 0x43, 0x0b, 0xad, 0x4a, 0xe5, 0x9e, 0xf7, 0x04, 0x6d, 0x09, 0x6a, 0x57, 0x53, 0x94, 0x4a,
+	0xc9, 0x4c, 0xb0, 0x0e, 0x89, 0xab, 0x25, 0x5b, 0x54, 0x9a, 0x97, 0xa9, 0xca, 0xad, 0x69, 0x7a,
+	0x21, 0x95, 0x54, 0xdd, 0xc8, 0xda, 0xc9, 0xaa, 0x57, 0x5b, 0x70, 0x46, 0x8f, 0x5c, 0xf3, 0xd5,
+	0xda, 0x0d, 0x9c, 0x33, 0x7b, 0x2a, 0x5a, 0x97, 0x4a, 0x8b, 0xa8, 0xd2, 0x99, 0x07, 0x3e, 0x04,
+	0xc7, 0xe1, 0xc4, 0xea, 0x4f, 0xad, 0xfc, 0xac, 0x33, 0xf7, 0xc1, 0x99, 0x24, 0x3c, 0x79, 0x15,
+	0xd1, 0x10, 0xe1, 0x1d, 0xf8, 0x10, 0x9c, 0xdc, 0x5c, 0x52, 0xdb, 0x81, 0x0e, 0x1d, 0xe8, 0x7d,
+	0x0f, 0xcc, 0xc7, 0xf5, 0xd7, 0x8c, 0x6c, 0xbf, 0x67, 0x10, 0x9e, 0x76, 0xd6, 0x61, 0xe1, 0x7a,
+	0xce, 0x91, 0xd8, 0x14, 0x19, 0x4f, 0x73, 0xef, 0xd0, 0x87, 0x60, 0x1c, 0x0e, 0xdf, 0xf9, 0x5d,
+	0xdd, 0x20, 0xd9, 0x35, 0x48, 0x3e, 0x1b, 0x24, 0xfb, 0x06, 0xc9, 0x9b, 0x41, 0xf8, 0x30, 0x48,
+	0x6a, 0x83, 0xb0, 0x33, 0x08, 0x3f, 0x06, 0xe1, 0xcf, 0x20, 0xd9, 0x1b, 0x84, 0xf7, 0x5f, 0x24,
+	0x2f, 0x23, 0xdb, 0x33, 0x1e, 0x75, 0xf9, 0xb7, 0xff, 0x03, 0x00, 0x0b, 0x9c, 0xfe, 0x91, 0x50,
+	0x01, 0x00, 0x00,
 }
@@ -55,8 +55,29 @@ func (c *controller) processRBACRoles(env adapter.Env) {
 				rn = make(rolesByName)
 				roles[k.Namespace] = rn
 			}
-			rn[k.Name] = newRoleInfo(roleSpec)
-			env.Logger().Infof("Role namespace: %s, name: %s, spec: %v", k.Namespace, k.Name, roleSpec)
+			deny := false
+			if effect, ok := obj.Metadata.Labels[effectLabel]; ok {
+				switch effect {
+				case effectDeny:
+					deny = true
+				case effectAllow:
+				default:
+					// a mistyped deny role must not silently allow the requests it matches.
+					env.Logger().Errorf("Role %s has an invalid %s label %q, expected %s or %s, it will deny the requests it matches",
+						k.Name, effectLabel, effect, effectAllow, effectDeny)
+					deny = true
+				}
+			}
+			ri := newRoleInfo(roleSpec, deny)
+			for _, err := range ri.errors {
+				if ri.deny {
+					env.Logger().Errorf("Deny role %s has an invalid constraint, it will always match: %v", k.Name, err)
+				} else {
+					env.Logger().Errorf("Role %s has an invalid constraint, it will never match: %v", k.Name, err)
+				}
+			}
+			rn[k.Name] = ri
+			env.Logger().Infof("Role namespace: %s, name: %s, deny: %t, spec: %v", k.Namespace, k.Name, ri.deny, roleSpec)
 		}
 	}
 
@@ -118,3 +118,43 @@ func TestController_processRBACRoles(t *testing.T) {
 		t.Fatalf("Got %v, Want %v", binding, wantRoleBinding)
 	}
 }
+
+func TestController_processRBACRoles_DenyLabel(t *testing.T) {
+	role := &rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{{Services: []string{"*"}, Methods: []string{"*"}}},
+	}
+	configState := map[store.Key]*store.Resource{
+		{serviceRoleKind, "ns1", "allowed"}: {Spec: role},
+		{serviceRoleKind, "ns1", "explicit"}: {
+			Metadata: store.ResourceMeta{Labels: map[string]string{effectLabel: effectAllow}},
+			Spec:     role,
+		},
+		{serviceRoleKind, "ns1", "denied"}: {
+			Metadata: store.ResourceMeta{Labels: map[string]string{effectLabel: effectDeny}},
+			Spec:     role,
+		},
+		{serviceRoleKind, "ns1", "invalid"}: {
+			Metadata: store.ResourceMeta{Labels: map[string]string{effectLabel: "maybe"}},
+			Spec:     role,
+		},
+	}
+
+	r := &configStore{}
+	c := &controller{
+		configState: configState,
+		rbacStore:   r,
+	}
+
+	c.processRBACRoles(test.NewEnv(t))
+
+	want := map[string]bool{"allowed": false, "explicit": false, "denied": true, "invalid": true}
+	for name, deny := range want {
+		role := r.roles["ns1"][name]
+		if role == nil {
+			t.Fatalf("%s is not populated", name)
+		}
+		if role.deny != deny {
+			t.Errorf("Got deny %t for %s, want %t", role.deny, name, deny)
+		}
+	}
+}
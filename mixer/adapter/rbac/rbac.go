@@ -24,16 +24,28 @@
 // ServiceRole and the corresponding ServiceRoleBindings should be in the same namespace.
 // Please see "istio.io/istio/mixer/testdata/config/rbac.yaml" for an example of RBAC handler, plus ServieRole
 // ServiceRoleBinding specifications.
+//
+// A ServiceRole labeled "rbac.istio.io/effect: deny" is a deny role: requests it matches are denied,
+// and deny roles are evaluated before all other roles. Constraint values are matched as strings,
+// unless they are prefixed by "cidr:", "regex:" or "range:" to match IP addresses within a CIDR
+// block, strings matching a regular expression, or numbers within an inclusive "min..max" range.
+// Invalid roles fail closed: a role with an effect label other than "allow" or "deny" is a deny
+// role, and the constraints of a deny role with invalid values match any request.
+//
+// When explain is set, the role, binding and rule that granted or denied each request are logged
+// and returned in the message of the Check response status.
 package rbac
 
 import (
 	"context"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 
 	rbacproto "istio.io/api/rbac/v1alpha1"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/adapter/rbac/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	mixerconfig "istio.io/istio/mixer/pkg/config"
@@ -50,8 +62,10 @@ type (
 		rbac          authorizer
 		env           adapter.Env
 		cacheDuration time.Duration
+		explain       bool
 		closing       chan bool
 		done          chan bool
+		closeOnce     sync.Once
 	}
 )
 
@@ -93,6 +107,7 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 		rbac:          r,
 		env:           env,
 		cacheDuration: b.adapterConfig.CacheDuration,
+		explain:       b.adapterConfig.Explain,
 		closing:       make(chan bool),
 		done:          make(chan bool),
 	}
@@ -181,9 +196,19 @@ func startWatch(s store.Store) (map[store.Key]*store.Resource, <-chan store.Even
 func (h *handler) HandleAuthorization(ctx context.Context, inst *authorization.Instance) (adapter.CheckResult, error) {
 	s := status.OK
 	result, err := h.rbac.CheckPermission(inst, h.env)
-	if !result || err != nil {
+	if err != nil || !result.allowed {
 		s = status.WithPermissionDenied("RBAC: permission denied.")
 	}
+
+	if h.explain && err == nil {
+		h.env.Logger().Infof("RBAC: %s/%s %s %s for user %s %s", inst.Action.Namespace, inst.Action.Service,
+			inst.Action.Method, inst.Action.Path, inst.Subject.User, result)
+		if result.allowed {
+			s = status.WithMessage(rpc.OK, "RBAC: permission "+result.String())
+		} else {
+			s = status.WithPermissionDenied("RBAC: permission " + result.String())
+		}
+	}
 	return adapter.CheckResult{
 		Status:        s,
 		ValidDuration: h.cacheDuration,
@@ -193,10 +218,12 @@ func (h *handler) HandleAuthorization(ctx context.Context, inst *authorization.I
 
 // adapter.Handler#Close
 func (h *handler) Close() error {
-	h.closing <- true
-	close(h.closing)
+	h.closeOnce.Do(func() {
+		h.closing <- true
+		close(h.closing)
 
-	<-h.done
+		<-h.done
+	})
 	return nil
 }
 
@@ -15,6 +15,12 @@
 package rbac
 
 import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	rbacproto "istio.io/api/rbac/v1alpha1"
@@ -24,7 +30,73 @@ import (
 
 // authorizer interface
 type authorizer interface {
-	CheckPermission(inst *authorization.Instance, env adapter.Env) (bool, error)
+	CheckPermission(inst *authorization.Instance, env adapter.Env) (*decision, error)
+}
+
+// decision is the outcome of a permission check, along with the role, binding and rule
+// that produced it.
+type decision struct {
+	allowed bool
+
+	// The role, binding and index of the rule within the role that matched the request.
+	// role is empty when no role matched.
+	role    string
+	binding string
+	rule    int
+	deny    bool
+}
+
+func (d *decision) String() string {
+	if d.role == "" {
+		return "denied: no role matches the request"
+	}
+
+	kind := "role"
+	if d.deny {
+		kind = "deny role"
+	}
+
+	verb := "granted"
+	if !d.allowed {
+		verb = "denied"
+	}
+
+	return fmt.Sprintf("%s by rule %d of %s %s, bound by %s", verb, d.rule, kind, d.role, d.binding)
+}
+
+// The label of a ServiceRole selecting whether the role allows or denies the requests it matches.
+// Roles without the label allow requests.
+const (
+	effectLabel = "rbac.istio.io/effect"
+	effectAllow = "allow"
+	effectDeny  = "deny"
+)
+
+// Constraint values with these prefixes are matched by CIDR, regular expression and numeric range
+// instead of by string.
+const (
+	cidrPrefix  = "cidr:"
+	regexPrefix = "regex:"
+	rangePrefix = "range:"
+)
+
+// valueMatcher matches a single constraint value against the value of a request property.
+type valueMatcher func(value interface{}) bool
+
+// A constraint of an access rule, with its values compiled to matchers.
+type constraint struct {
+	key      string
+	matchers []valueMatcher
+
+	// Set for the constraints of deny roles with invalid values, which match any request so
+	// that the role fails closed.
+	always bool
+}
+
+// An access rule of a ServiceRole, with its constraints compiled.
+type accessRule struct {
+	*rbacproto.AccessRule
+	constraints []constraint
 }
 
 // Information about a ServiceRole and associted ServiceRoleBindings
@@ -32,6 +104,16 @@ type roleInfo struct {
 	// ServiceRole proto definition
 	info *rbacproto.ServiceRole
 
+	// Whether the role denies the requests it matches, rather than allowing them.
+	deny bool
+
+	// The rules of the role, compiled.
+	rules []*accessRule
+
+	// Errors found while compiling the rules. Invalid constraint values never match, except in
+	// deny roles, where the constraints with invalid values match any request.
+	errors []error
+
 	// A set of ServiceRoleBindings that refer to this role.
 	bindings map[string]*rbacproto.ServiceRoleBinding
 }
@@ -50,10 +132,31 @@ type configStore struct {
 }
 
 // Create a RoleInfo object.
-func newRoleInfo(spec *rbacproto.ServiceRole) *roleInfo {
-	return &roleInfo{
+func newRoleInfo(spec *rbacproto.ServiceRole, deny bool) *roleInfo {
+	ri := &roleInfo{
 		info: spec,
+		deny: deny,
 	}
+
+	for i, rule := range spec.GetRules() {
+		ar := &accessRule{AccessRule: rule}
+		for _, c := range rule.GetConstraints() {
+			compiled := constraint{key: c.GetKey()}
+			for _, v := range c.GetValues() {
+				m, err := newValueMatcher(v)
+				if err != nil {
+					ri.errors = append(ri.errors, fmt.Errorf("rule %d, constraint %s: %v", i, c.GetKey(), err))
+					compiled.always = deny
+					continue
+				}
+				compiled.matchers = append(compiled.matchers, m)
+			}
+			ar.constraints = append(ar.constraints, compiled)
+		}
+		ri.rules = append(ri.rules, ar)
+	}
+
+	return ri
 }
 
 // Set a binding for a given Service role.
@@ -74,75 +177,107 @@ func (rs *configStore) changeRoles(roles rolesMapByNamespace) {
 
 // CheckPermission checks permission for a given request. This is the main API called
 // by RBAC adapter at runtime to authorize requests.
-func (rs *configStore) CheckPermission(inst *authorization.Instance, env adapter.Env) (bool, error) {
+//
+// Deny roles are evaluated first: a request matched by a deny role and one of its bindings
+// is denied regardless of any other role. Otherwise the request is allowed if it is matched
+// by a role and one of its bindings. Roles are evaluated in the order of their names.
+func (rs *configStore) CheckPermission(inst *authorization.Instance, env adapter.Env) (*decision, error) {
 	namespace := inst.Action.Namespace
 	if namespace == "" {
-		return false, env.Logger().Errorf("Missing namespace")
+		return nil, env.Logger().Errorf("Missing namespace")
 	}
 
 	serviceName := inst.Action.Service
 	if serviceName == "" {
-		return false, env.Logger().Errorf("Missing service")
+		return nil, env.Logger().Errorf("Missing service")
 	}
 
 	path := inst.Action.Path
 	if path == "" {
-		return false, env.Logger().Errorf("Missing path")
+		return nil, env.Logger().Errorf("Missing path")
 	}
 
 	method := inst.Action.Method
 	if method == "" {
-		return false, env.Logger().Errorf("Missing method")
+		return nil, env.Logger().Errorf("Missing method")
 	}
 
-	extraAct := inst.Action.Properties
-
-	user := inst.Subject.User
-	groups := inst.Subject.Groups
-
-	instSub := inst.Subject.Properties
-
 	rn := rs.roles[namespace]
 	if rn == nil {
-		return false, nil
+		return &decision{}, nil
+	}
+
+	names := make([]string, 0, len(rn))
+	for name := range rn {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	for _, deny := range []bool{true, false} {
+		for _, rolename := range names {
+			roleInfo := rn[rolename]
+			if roleInfo.deny != deny {
+				continue
+			}
 
-	for rolename, roleInfo := range rn {
-		eligibleRole := false
-		env.Logger().Infof("Checking role: %s", rolename)
-		rules := roleInfo.info.GetRules()
-		for _, rule := range rules {
-			if matchRule(serviceName, path, method, extraAct, rule, env) {
-				eligibleRole = true
-				break
+			if d := matchRole(rolename, roleInfo, inst, env); d != nil {
+				return d, nil
 			}
 		}
-		if !eligibleRole {
-			env.Logger().Infof("role %s is not eligible", rolename)
-			continue
+	}
+
+	return &decision{}, nil
+}
+
+// Helper function to check whether or not a request matches a role and one of its bindings.
+func matchRole(rolename string, roleInfo *roleInfo, inst *authorization.Instance, env adapter.Env) *decision {
+	env.Logger().Infof("Checking role: %s", rolename)
+
+	ruleIndex := -1
+	for i, rule := range roleInfo.rules {
+		if matchRule(inst.Action.Service, inst.Action.Path, inst.Action.Method, inst.Action.Properties, rule, env) {
+			ruleIndex = i
+			break
 		}
-		env.Logger().Infof("role %s is eligible", rolename)
-		bindings := roleInfo.bindings
-		for _, binding := range bindings {
-			subjects := binding.GetSubjects()
-			for _, subject := range subjects {
-				if subject.GetUser() != "" && subject.GetUser() != user {
-					continue
-				}
-				if subject.GetGroup() != "" && subject.GetGroup() != groups {
-					continue
-				}
-				if checkSubject(instSub, subject.GetProperties()) {
-					return true, nil
+	}
+	if ruleIndex < 0 {
+		env.Logger().Infof("role %s is not eligible", rolename)
+		return nil
+	}
+	env.Logger().Infof("role %s is eligible", rolename)
+
+	bindingNames := make([]string, 0, len(roleInfo.bindings))
+	for name := range roleInfo.bindings {
+		bindingNames = append(bindingNames, name)
+	}
+	sort.Strings(bindingNames)
+
+	for _, bindingName := range bindingNames {
+		subjects := roleInfo.bindings[bindingName].GetSubjects()
+		for _, subject := range subjects {
+			if subject.GetUser() != "" && subject.GetUser() != inst.Subject.User {
+				continue
+			}
+			if subject.GetGroup() != "" && subject.GetGroup() != inst.Subject.Groups {
+				continue
+			}
+			if checkSubject(inst.Subject.Properties, subject.GetProperties()) {
+				return &decision{
+					allowed: !roleInfo.deny,
+					role:    rolename,
+					binding: bindingName,
+					rule:    ruleIndex,
+					deny:    roleInfo.deny,
 				}
 			}
 		}
 	}
-	return false, nil
+
+	return nil
 }
 
 // Helper function to check whether or not a request matches a rule in a ServiceRole specification.
-func matchRule(serviceName string, path string, method string, extraAct map[string]interface{}, rule *rbacproto.AccessRule, env adapter.Env) bool {
+func matchRule(serviceName string, path string, method string, extraAct map[string]interface{}, rule *accessRule, env adapter.Env) bool {
 	services := rule.GetServices()
 	paths := rule.GetPaths()
 	methods := rule.GetMethods()
@@ -152,7 +287,7 @@ func matchRule(serviceName string, path string, method string, extraAct map[stri
 	if stringMatch(serviceName, services) &&
 		(paths == nil || stringMatch(path, paths)) &&
 		stringMatch(method, methods) &&
-		checkConstraints(extraAct, constraints) {
+		checkConstraints(extraAct, rule.constraints) {
 		return true
 	}
 	return false
@@ -195,13 +330,18 @@ func valueInList(value interface{}, list []string) bool {
 }
 
 // Check if all constraints in a rule can be satisfied by the properties from the request.
-func checkConstraints(properties map[string]interface{}, constraints []*rbacproto.AccessRule_Constraint) bool {
+func checkConstraints(properties map[string]interface{}, constraints []constraint) bool {
 	for _, constraint := range constraints {
+		if constraint.always {
+			continue
+		}
 		foundMatch := false
-		for pn, pv := range properties {
-			if pn == constraint.GetKey() && valueInList(pv, constraint.GetValues()) {
-				foundMatch = true
-				break
+		if pv, ok := properties[constraint.key]; ok {
+			for _, m := range constraint.matchers {
+				if m(pv) {
+					foundMatch = true
+					break
+				}
 			}
 		}
 		if !foundMatch {
@@ -212,6 +352,107 @@ func checkConstraints(properties map[string]interface{}, constraints []*rbacprot
 	return true
 }
 
+// Create a matcher for a constraint value. Values are matched as strings, unless they
+// are prefixed by "cidr:", matching IP addresses within a CIDR block (e.g. "cidr:10.0.0.0/8"),
+// "regex:", matching strings fully matched by a regular expression (e.g. "regex:v[0-9]+"), or
+// "range:", matching numbers within an inclusive range (e.g. "range:100..200"). Either bound
+// of a range may be omitted (e.g. "range:1024..").
+func newValueMatcher(pattern string) (valueMatcher, error) {
+	switch {
+	case strings.HasPrefix(pattern, cidrPrefix):
+		_, ipnet, err := net.ParseCIDR(strings.TrimPrefix(pattern, cidrPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) bool {
+			ip := toIP(value)
+			return ip != nil && ipnet.Contains(ip)
+		}, nil
+
+	case strings.HasPrefix(pattern, regexPrefix):
+		re, err := regexp.Compile("^(?:" + strings.TrimPrefix(pattern, regexPrefix) + ")$")
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) bool {
+			str, ok := value.(string)
+			return ok && re.MatchString(str)
+		}, nil
+
+	case strings.HasPrefix(pattern, rangePrefix):
+		min, max, err := parseRange(strings.TrimPrefix(pattern, rangePrefix))
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) bool {
+			n, ok := toNumber(value)
+			return ok && n >= min && n <= max
+		}, nil
+	}
+
+	list := []string{pattern}
+	return func(value interface{}) bool {
+		return valueInList(value, list)
+	}, nil
+}
+
+// Parse a numeric range of the form "min..max", where either bound may be omitted.
+func parseRange(r string) (min float64, max float64, err error) {
+	bounds := strings.Split(r, "..")
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %q, expecting min..max", r)
+	}
+
+	min, max = math.Inf(-1), math.Inf(1)
+	if bounds[0] != "" {
+		if min, err = strconv.ParseFloat(bounds[0], 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid range %q: %v", r, err)
+		}
+	}
+	if bounds[1] != "" {
+		if max, err = strconv.ParseFloat(bounds[1], 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid range %q: %v", r, err)
+		}
+	}
+
+	if min > max {
+		return 0, 0, fmt.Errorf("invalid range %q, the lower bound exceeds the upper bound", r)
+	}
+
+	return min, max, nil
+}
+
+// Convert a property value to an IP address. Returns nil for values that aren't addresses.
+func toIP(value interface{}) net.IP {
+	switch v := value.(type) {
+	case net.IP:
+		return v
+	case []byte:
+		if len(v) == net.IPv4len || len(v) == net.IPv6len {
+			return net.IP(v)
+		}
+	case string:
+		return net.ParseIP(v)
+	}
+	return nil
+}
+
+// Convert a property value to a number.
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
 // Check if a given value is equal to a string.
 func valueMatch(a interface{}, b string) bool {
 	if str, ok := a.(string); ok {
@@ -15,6 +15,7 @@
 package rbac
 
 import (
+	"net"
 	"testing"
 
 	rbacproto "istio.io/api/rbac/v1alpha1"
@@ -54,7 +55,7 @@ func setupRBACStore() *configStore {
 		},
 	}
 
-	rn["role1"] = newRoleInfo(role1Spec)
+	rn["role1"] = newRoleInfo(role1Spec, false)
 	rn["role1"].setBinding("binding1", binding1Spec)
 
 	role2Spec := &rbacproto.ServiceRole{
@@ -90,7 +91,7 @@ func setupRBACStore() *configStore {
 		},
 	}
 
-	rn["role2"] = newRoleInfo(role2Spec)
+	rn["role2"] = newRoleInfo(role2Spec, false)
 	rn["role2"].setBinding("binding2", binding2Spec)
 
 	role3Spec := &rbacproto.ServiceRole{
@@ -118,7 +119,7 @@ func setupRBACStore() *configStore {
 		},
 	}
 
-	rn["role3"] = newRoleInfo(role3Spec)
+	rn["role3"] = newRoleInfo(role3Spec, false)
 	rn["role3"].setBinding("binding3", binding3Spec)
 
 	role4Spec := &rbacproto.ServiceRole{
@@ -148,7 +149,7 @@ func setupRBACStore() *configStore {
 		},
 	}
 
-	rn["role4"] = newRoleInfo(role4Spec)
+	rn["role4"] = newRoleInfo(role4Spec, false)
 	rn["role4"].setBinding("binding4", binding4Spec)
 	return s
 }
@@ -196,8 +197,201 @@ func TestRBACStore_CheckPermission(t *testing.T) {
 		instance.Action.Properties["version"] = c.version
 
 		result, _ := s.CheckPermission(instance, test.NewEnv(t))
-		if result != c.expected {
+		if result.allowed != c.expected {
 			t.Errorf("Does not meet expectation for case %v", c)
 		}
 	}
 }
+
+func newInstance(user string, properties map[string]interface{}) *authorization.Instance {
+	return &authorization.Instance{
+		Subject: &authorization.Subject{
+			User:       user,
+			Properties: map[string]interface{}{},
+		},
+		Action: &authorization.Action{
+			Namespace:  "ns1",
+			Service:    "products",
+			Path:       "/products",
+			Method:     "GET",
+			Properties: properties,
+		},
+	}
+}
+
+func TestRBACStore_DenyRoles(t *testing.T) {
+	s := &configStore{roles: rolesMapByNamespace{"ns1": make(rolesByName)}}
+	rn := s.roles["ns1"]
+
+	allow := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{{Services: []string{"products"}, Methods: []string{"*"}}},
+	}, false)
+	allow.setBinding("all-users", &rbacproto.ServiceRoleBinding{
+		Subjects: []*rbacproto.Subject{{Properties: map[string]string{}}},
+	})
+	rn["a-viewer"] = allow
+
+	deny := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{
+			{Services: []string{"reviews"}, Methods: []string{"*"}},
+			{Services: []string{"products"}, Methods: []string{"GET"}},
+		},
+	}, true)
+	deny.setBinding("blocked-users", &rbacproto.ServiceRoleBinding{
+		Subjects: []*rbacproto.Subject{{User: "mallory"}},
+	})
+	rn["z-blocked"] = deny
+
+	cases := []struct {
+		user     string
+		expected decision
+	}{
+		{"alice", decision{allowed: true, role: "a-viewer", binding: "all-users", rule: 0}},
+		{"mallory", decision{allowed: false, role: "z-blocked", binding: "blocked-users", rule: 1, deny: true}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.user, func(t *testing.T) {
+			result, err := s.CheckPermission(newInstance(c.user, nil), test.NewEnv(t))
+			if err != nil {
+				t.Fatalf("CheckPermission: %v", err)
+			}
+			if *result != c.expected {
+				t.Errorf("Got %+v, want %+v", *result, c.expected)
+			}
+		})
+	}
+}
+
+func TestRBACStore_ConstraintMatchers(t *testing.T) {
+	cases := []struct {
+		name     string
+		values   []string
+		property interface{}
+		expected bool
+	}{
+		{"string", []string{"v1"}, "v1", true},
+		{"string prefix", []string{"v*"}, "v1", true},
+		{"string mismatch", []string{"v1"}, "v2", false},
+		{"cidr bytes", []string{"cidr:10.0.0.0/8"}, []byte(net.ParseIP("10.1.2.3").To4()), true},
+		{"cidr ip", []string{"cidr:10.0.0.0/8"}, net.ParseIP("10.1.2.3"), true},
+		{"cidr string", []string{"cidr:10.0.0.0/8"}, "10.1.2.3", true},
+		{"cidr ipv6", []string{"cidr:fd00::/8"}, "fd00::1", true},
+		{"cidr outside", []string{"cidr:10.0.0.0/8"}, "192.168.0.1", false},
+		{"cidr not an address", []string{"cidr:10.0.0.0/8"}, "v1", false},
+		{"regex", []string{"regex:v[0-9]+"}, "v12", true},
+		{"regex anchored", []string{"regex:v[0-9]+"}, "xv12", false},
+		{"regex alternation anchored", []string{"regex:v1|v2"}, "v12", false},
+		{"regex not a string", []string{"regex:[0-9]+"}, int64(12), false},
+		{"range", []string{"range:100..200"}, int64(150), true},
+		{"range inclusive", []string{"range:100..200"}, int64(200), true},
+		{"range outside", []string{"range:100..200"}, int64(201), false},
+		{"range open lower", []string{"range:..10"}, int64(-5), true},
+		{"range open upper", []string{"range:1024.."}, "8080", true},
+		{"range float", []string{"range:0.5..1.5"}, 1.0, true},
+		{"range not a number", []string{"range:0..10"}, "five", false},
+		{"any value", []string{"v1", "cidr:10.0.0.0/8"}, "10.0.0.1", true},
+		{"invalid cidr", []string{"cidr:10.0.0.0/33"}, "10.0.0.1", false},
+		{"invalid regex", []string{"regex:("}, "(", false},
+		{"invalid range", []string{"range:10..1"}, int64(5), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			role := newRoleInfo(&rbacproto.ServiceRole{
+				Rules: []*rbacproto.AccessRule{
+					{
+						Services: []string{"products"},
+						Methods:  []string{"GET"},
+						Constraints: []*rbacproto.AccessRule_Constraint{
+							{Key: "prop", Values: c.values},
+						},
+					},
+				},
+			}, false)
+			role.setBinding("binding1", &rbacproto.ServiceRoleBinding{
+				Subjects: []*rbacproto.Subject{{User: "alice"}},
+			})
+			s := &configStore{roles: rolesMapByNamespace{"ns1": {"role1": role}}}
+
+			result, err := s.CheckPermission(newInstance("alice", map[string]interface{}{"prop": c.property}), test.NewEnv(t))
+			if err != nil {
+				t.Fatalf("CheckPermission: %v", err)
+			}
+			if result.allowed != c.expected {
+				t.Errorf("Got %v, want %v", result.allowed, c.expected)
+			}
+		})
+	}
+}
+
+func TestNewRoleInfo_InvalidConstraints(t *testing.T) {
+	role := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{
+			{
+				Services: []string{"*"},
+				Methods:  []string{"*"},
+				Constraints: []*rbacproto.AccessRule_Constraint{
+					{Key: "source.ip", Values: []string{"cidr:bad", "cidr:10.0.0.0/8"}},
+					{Key: "port", Values: []string{"range:1", "range:a..b"}},
+				},
+			},
+		},
+	}, false)
+
+	if len(role.errors) != 3 {
+		t.Errorf("Got %d errors, want 3: %v", len(role.errors), role.errors)
+	}
+	if len(role.rules) != 1 || len(role.rules[0].constraints) != 2 {
+		t.Fatalf("Got %v, want 1 rule with 2 constraints", role.rules)
+	}
+	if n := len(role.rules[0].constraints[0].matchers); n != 1 {
+		t.Errorf("Got %d matchers for source.ip, want 1", n)
+	}
+}
+
+func TestRBACStore_DenyRoleInvalidConstraint(t *testing.T) {
+	deny := newRoleInfo(&rbacproto.ServiceRole{
+		Rules: []*rbacproto.AccessRule{
+			{
+				Services: []string{"*"},
+				Methods:  []string{"*"},
+				Constraints: []*rbacproto.AccessRule_Constraint{
+					{Key: "source.ip", Values: []string{"cidr:10.0.0.0/33"}},
+				},
+			},
+		},
+	}, true)
+	deny.setBinding("blocked-users", &rbacproto.ServiceRoleBinding{
+		Subjects: []*rbacproto.Subject{{User: "mallory"}},
+	})
+	s := &configStore{roles: rolesMapByNamespace{"ns1": {"blocked": deny}}}
+
+	// the deny role fails closed, whether or not the request has the property.
+	for _, props := range []map[string]interface{}{{"source.ip": "192.168.0.1"}, {}} {
+		result, err := s.CheckPermission(newInstance("mallory", props), test.NewEnv(t))
+		if err != nil {
+			t.Fatalf("CheckPermission: %v", err)
+		}
+		if result.allowed || result.role != "blocked" {
+			t.Errorf("Got %v for %v, want a denial by the deny role", result, props)
+		}
+	}
+}
+
+func TestDecision_String(t *testing.T) {
+	cases := []struct {
+		d        decision
+		expected string
+	}{
+		{decision{}, "denied: no role matches the request"},
+		{decision{allowed: true, role: "viewer", binding: "users", rule: 2}, "granted by rule 2 of role viewer, bound by users"},
+		{decision{role: "blocked", binding: "mallory", rule: 0, deny: true}, "denied by rule 0 of deny role blocked, bound by mallory"},
+	}
+
+	for _, c := range cases {
+		if got := c.d.String(); got != c.expected {
+			t.Errorf("Got %q, want %q", got, c.expected)
+		}
+	}
+}
@@ -16,9 +16,15 @@ package rbac
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/adapter/rbac/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/authorization"
@@ -28,18 +34,18 @@ type fakedAllowRBACStore struct {
 	called int
 }
 
-func (f *fakedAllowRBACStore) CheckPermission(inst *authorization.Instance, env adapter.Env) (bool, error) {
+func (f *fakedAllowRBACStore) CheckPermission(inst *authorization.Instance, env adapter.Env) (*decision, error) {
 	f.called++
-	return true, nil
+	return &decision{allowed: true, role: "role1", binding: "binding1"}, nil
 }
 
 type fakedDenyRBACStore struct {
 	called int
 }
 
-func (f *fakedDenyRBACStore) CheckPermission(inst *authorization.Instance, env adapter.Env) (bool, error) {
+func (f *fakedDenyRBACStore) CheckPermission(inst *authorization.Instance, env adapter.Env) (*decision, error) {
 	f.called++
-	return false, nil
+	return &decision{}, nil
 }
 
 func TestHandleAuthorization_Success(t *testing.T) {
@@ -73,3 +79,107 @@ func TestHandleAuthorization_Deny(t *testing.T) {
 		t.Fatalf("Got %v, want PermissionDenied status", result.Status)
 	}
 }
+
+func TestHandleAuthorization_Explain(t *testing.T) {
+	cases := []struct {
+		name    string
+		rbac    authorizer
+		code    rpc.Code
+		message string
+	}{
+		{"allow", &fakedAllowRBACStore{}, rpc.OK, "RBAC: permission granted by rule 0 of role role1, bound by binding1"},
+		{"deny", &fakedDenyRBACStore{}, rpc.PERMISSION_DENIED, "RBAC: permission denied: no role matches the request"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env := test.NewEnv(t)
+			handler := &handler{rbac: c.rbac, env: env, explain: true, closing: make(chan bool), done: make(chan bool)}
+
+			instance := authorization.Instance{
+				Subject: &authorization.Subject{User: "alice"},
+				Action:  &authorization.Action{Namespace: "ns1", Service: "products", Method: "GET", Path: "/"},
+			}
+			result, _ := handler.HandleAuthorization(context.Background(), &instance)
+
+			if result.Status.Code != int32(c.code) {
+				t.Errorf("Got %v, want %v status", result.Status, c.code)
+			}
+			if result.Status.Message != c.message {
+				t.Errorf("Got message %q, want %q", result.Status.Message, c.message)
+			}
+
+			found := false
+			for _, l := range env.GetLogs() {
+				if strings.Contains(l, strings.TrimPrefix(c.message, "RBAC: permission ")) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("The decision was not logged: %v", env.GetLogs())
+			}
+		})
+	}
+}
+
+const conformanceRoles = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: ServiceRole
+metadata:
+  name: products-viewer
+  namespace: ns1
+spec:
+  rules:
+  - services: ["products"]
+    methods: ["GET"]
+---
+apiVersion: "config.istio.io/v1alpha2"
+kind: ServiceRoleBinding
+metadata:
+  name: products-viewers
+  namespace: ns1
+spec:
+  subjects:
+  - user: "alice"
+  roleRef:
+    kind: ServiceRole
+    name: "products-viewer"
+`
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestConformance")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "roles.yaml"), []byte(conformanceRoles), 0644); err != nil {
+		t.Fatalf("Unable to write roles: %v", err)
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Config: &config.Params{
+			ConfigStoreUrl: "fs://" + dir,
+			CacheDuration:  time.Minute,
+		},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{ConfigStoreUrl: "://" + dir},
+			&config.Params{ConfigStoreUrl: "fs://" + dir, CacheDuration: -time.Second},
+		},
+		Instances: map[string][]interface{}{
+			authorization.TemplateName: {
+				&authorization.Instance{
+					Name:    "allowed",
+					Subject: &authorization.Subject{User: "alice"},
+					Action:  &authorization.Action{Namespace: "ns1", Service: "products", Method: "GET", Path: "/"},
+				},
+				&authorization.Instance{
+					Name:    "denied",
+					Subject: &authorization.Subject{User: "bob"},
+					Action:  &authorization.Action{Namespace: "ns1", Service: "products", Method: "DELETE", Path: "/"},
+				},
+			},
+		},
+	})
+}
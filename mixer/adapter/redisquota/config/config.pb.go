@@ -68,8 +68,66 @@ func (Params_QuotaAlgorithm) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptorConfig, []int{0, 0}
 }
 
+// How Redis is deployed.
+type Params_DeploymentType int32
+
+const (
+	// SINGLE A single Redis server at redis_server_url.
+	SINGLE Params_DeploymentType = 0
+	// SENTINEL A Redis master monitored by the Redis Sentinels at redis_server_urls.
+	SENTINEL Params_DeploymentType = 1
+	// CLUSTER A Redis Cluster reachable through the nodes at redis_server_urls.
+	CLUSTER Params_DeploymentType = 2
+)
+
+var Params_DeploymentType_name = map[int32]string{
+	0: "SINGLE",
+	1: "SENTINEL",
+	2: "CLUSTER",
+}
+var Params_DeploymentType_value = map[string]int32{
+	"SINGLE":   0,
+	"SENTINEL": 1,
+	"CLUSTER":  2,
+}
+
+func (Params_DeploymentType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptorConfig, []int{0, 1}
+}
+
+// What to do with quota requests while Redis is unreachable.
+type Params_FailurePolicy int32
+
+const (
+	// FAIL_CLOSE Quota requests are not granted.
+	FAIL_CLOSE Params_FailurePolicy = 0
+	// FAIL_OPEN Quota requests are granted in full.
+	FAIL_OPEN Params_FailurePolicy = 1
+	// LOCAL_FALLBACK Quota is allocated from an in-process approximation, in which every
+	// Mixer replica allows its share of max_amount. Local allocations are reconciled
+	// into Redis once it is reachable again.
+	LOCAL_FALLBACK Params_FailurePolicy = 2
+)
+
+var Params_FailurePolicy_name = map[int32]string{
+	0: "FAIL_CLOSE",
+	1: "FAIL_OPEN",
+	2: "LOCAL_FALLBACK",
+}
+var Params_FailurePolicy_value = map[string]int32{
+	"FAIL_CLOSE":     0,
+	"FAIL_OPEN":      1,
+	"LOCAL_FALLBACK": 2,
+}
+
+func (Params_FailurePolicy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptorConfig, []int{0, 2}
+}
+
 // redisquota adapter supports the rate limit quota using either fixed or
 // rolling window algorithm. And it is using Redis as a shared data storage.
+// Redis can be deployed as a single server, behind Redis Sentinel or as a
+// Redis Cluster.
 //
 // Example configuration:
 //
@@ -89,6 +147,22 @@ func (Params_QuotaAlgorithm) EnumDescriptor() ([]byte, []int) {
 //       - dimensions:
 //           destination: reviews
 //         maxAmount: 5
+//
+// Example configuration using Redis Sentinel, which falls back to a local
+// approximation of the quotas while Redis is unreachable:
+//
+// deploymentType: SENTINEL
+// redisServerUrls:
+//   - sentinel-0:26379
+//   - sentinel-1:26379
+// sentinelMasterName: mymaster
+// failurePolicy: LOCAL_FALLBACK
+// mixerReplicas: 3
+// healthCheckInterval: 5s
+// quotas:
+//   - name: requestCount.quota.istio-system
+//     maxAmount: 300
+//     validDuration: 60s
 type Params struct {
 	// The set of known quotas. At least one quota configuration is required
 	Quotas []Params_Quota `protobuf:"bytes,1,rep,name=quotas" json:"quotas"`
@@ -98,6 +172,24 @@ type Params struct {
 	// Maximum number of idle connections to redis
 	// Default is 10 connections per every CPU as reported by runtime.NumCPU.
 	ConnectionPoolSize int64 `protobuf:"varint,3,opt,name=connection_pool_size,json=connectionPoolSize,proto3" json:"connection_pool_size,omitempty"`
+	// How Redis is deployed. The default value is SINGLE
+	DeploymentType Params_DeploymentType `protobuf:"varint,4,opt,name=deployment_type,json=deploymentType,proto3,enum=adapter.redisquota.config.Params_DeploymentType" json:"deployment_type,omitempty"`
+	// Addresses <hostname>:<port number> of the Redis Sentinels for SENTINEL, or of the
+	// Redis Cluster nodes for CLUSTER deployments. At least one address is required
+	// for these deployment types.
+	RedisServerUrls []string `protobuf:"bytes,5,rep,name=redis_server_urls,json=redisServerUrls" json:"redis_server_urls,omitempty"`
+	// Name of the master monitored by the Redis Sentinels.
+	// Required for SENTINEL deployments.
+	SentinelMasterName string `protobuf:"bytes,6,opt,name=sentinel_master_name,json=sentinelMasterName,proto3" json:"sentinel_master_name,omitempty"`
+	// What to do with quota requests while Redis is unreachable. The default value is FAIL_CLOSE
+	FailurePolicy Params_FailurePolicy `protobuf:"varint,7,opt,name=failure_policy,json=failurePolicy,proto3,enum=adapter.redisquota.config.Params_FailurePolicy" json:"failure_policy,omitempty"`
+	// Number of Mixer replicas sharing the quotas. With LOCAL_FALLBACK, each replica allows
+	// max_amount / mixer_replicas (at least 1) while Redis is unreachable.
+	// Default is 1.
+	MixerReplicas int64 `protobuf:"varint,8,opt,name=mixer_replicas,json=mixerReplicas,proto3" json:"mixer_replicas,omitempty"`
+	// How often Redis is checked for recovery while it is unreachable.
+	// Default is 5s.
+	HealthCheckInterval time.Duration `protobuf:"bytes,9,opt,name=health_check_interval,json=healthCheckInterval,stdduration" json:"health_check_interval"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -202,6 +294,8 @@ func init() {
 	proto.RegisterType((*Params_Override)(nil), "adapter.redisquota.config.Params.Override")
 	proto.RegisterType((*Params_Quota)(nil), "adapter.redisquota.config.Params.Quota")
 	proto.RegisterEnum("adapter.redisquota.config.Params_QuotaAlgorithm", Params_QuotaAlgorithm_name, Params_QuotaAlgorithm_value)
+	proto.RegisterEnum("adapter.redisquota.config.Params_DeploymentType", Params_DeploymentType_name, Params_DeploymentType_value)
+	proto.RegisterEnum("adapter.redisquota.config.Params_FailurePolicy", Params_FailurePolicy_name, Params_FailurePolicy_value)
 }
 func (x Params_QuotaAlgorithm) String() string {
 	s, ok := Params_QuotaAlgorithm_name[int32(x)]
@@ -210,6 +304,20 @@ func (x Params_QuotaAlgorithm) String() string {
 	}
 	return strconv.Itoa(int(x))
 }
+func (x Params_DeploymentType) String() string {
+	s, ok := Params_DeploymentType_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
+func (x Params_FailurePolicy) String() string {
+	s, ok := Params_FailurePolicy_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
 func (m *Params) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -248,6 +356,50 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintConfig(dAtA, i, uint64(m.ConnectionPoolSize))
 	}
+	if m.DeploymentType != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.DeploymentType))
+	}
+	if len(m.RedisServerUrls) > 0 {
+		for _, s := range m.RedisServerUrls {
+			dAtA[i] = 0x2a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.SentinelMasterName) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.SentinelMasterName)))
+		i += copy(dAtA[i:], m.SentinelMasterName)
+	}
+	if m.FailurePolicy != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.FailurePolicy))
+	}
+	if m.MixerReplicas != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.MixerReplicas))
+	}
+	dAtA[i] = 0x4a
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.HealthCheckInterval)))
+	n1, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.HealthCheckInterval, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n1
 	return i, nil
 }
 
@@ -320,19 +472,19 @@ func (m *Params_Quota) MarshalTo(dAtA []byte) (int, error) {
 	dAtA[i] = 0x1a
 	i++
 	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.ValidDuration)))
-	n1, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ValidDuration, dAtA[i:])
+	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ValidDuration, dAtA[i:])
 	if err != nil {
 		return 0, err
 	}
-	i += n1
+	i += n2
 	dAtA[i] = 0x22
 	i++
 	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.BucketDuration)))
-	n2, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.BucketDuration, dAtA[i:])
+	n3, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.BucketDuration, dAtA[i:])
 	if err != nil {
 		return 0, err
 	}
-	i += n2
+	i += n3
 	if m.RateLimitAlgorithm != 0 {
 		dAtA[i] = 0x28
 		i++
@@ -378,6 +530,27 @@ func (m *Params) Size() (n int) {
 	if m.ConnectionPoolSize != 0 {
 		n += 1 + sovConfig(uint64(m.ConnectionPoolSize))
 	}
+	if m.DeploymentType != 0 {
+		n += 1 + sovConfig(uint64(m.DeploymentType))
+	}
+	if len(m.RedisServerUrls) > 0 {
+		for _, s := range m.RedisServerUrls {
+			l = len(s)
+			n += 1 + l + sovConfig(uint64(l))
+		}
+	}
+	l = len(m.SentinelMasterName)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	if m.FailurePolicy != 0 {
+		n += 1 + sovConfig(uint64(m.FailurePolicy))
+	}
+	if m.MixerReplicas != 0 {
+		n += 1 + sovConfig(uint64(m.MixerReplicas))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.HealthCheckInterval)
+	n += 1 + l + sovConfig(uint64(l))
 	return n
 }
 
@@ -445,6 +618,12 @@ func (this *Params) String() string {
 		`Quotas:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Quotas), "Params_Quota", "Params_Quota", 1), `&`, ``, 1) + `,`,
 		`RedisServerUrl:` + fmt.Sprintf("%v", this.RedisServerUrl) + `,`,
 		`ConnectionPoolSize:` + fmt.Sprintf("%v", this.ConnectionPoolSize) + `,`,
+		`DeploymentType:` + fmt.Sprintf("%v", this.DeploymentType) + `,`,
+		`RedisServerUrls:` + fmt.Sprintf("%v", this.RedisServerUrls) + `,`,
+		`SentinelMasterName:` + fmt.Sprintf("%v", this.SentinelMasterName) + `,`,
+		`FailurePolicy:` + fmt.Sprintf("%v", this.FailurePolicy) + `,`,
+		`MixerReplicas:` + fmt.Sprintf("%v", this.MixerReplicas) + `,`,
+		`HealthCheckInterval:` + strings.Replace(strings.Replace(this.HealthCheckInterval.String(), "Duration", "google_protobuf.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -601,6 +780,151 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeploymentType", wireType)
+			}
+			m.DeploymentType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeploymentType |= (Params_DeploymentType(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RedisServerUrls", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RedisServerUrls = append(m.RedisServerUrls, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SentinelMasterName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SentinelMasterName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailurePolicy", wireType)
+			}
+			m.FailurePolicy = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FailurePolicy |= (Params_FailurePolicy(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MixerReplicas", wireType)
+			}
+			m.MixerReplicas = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MixerReplicas |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HealthCheckInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.HealthCheckInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -1125,41 +1449,56 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/redisquota/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 570 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0xb1, 0x6f, 0xd3, 0x4e,
-	0x18, 0xf5, 0xd5, 0xa9, 0xd5, 0x5e, 0x7f, 0x3f, 0x37, 0x3a, 0x65, 0x70, 0x23, 0x71, 0x8d, 0xba,
-	0x10, 0x21, 0x64, 0x57, 0x45, 0x42, 0x55, 0x25, 0x86, 0x56, 0x09, 0x10, 0x14, 0x35, 0xc5, 0x15,
-	0x2a, 0x62, 0x31, 0x97, 0xf8, 0x6a, 0x4e, 0xb5, 0x7d, 0xe1, 0x6c, 0x47, 0x69, 0x27, 0xc6, 0x8e,
-	0x8c, 0x8c, 0x8c, 0xfc, 0x1b, 0x6c, 0x19, 0x33, 0x32, 0x01, 0x31, 0x0b, 0x63, 0xff, 0x04, 0xe4,
-	0xb3, 0x9d, 0x00, 0x12, 0x6a, 0x26, 0x7f, 0x7e, 0xf7, 0xde, 0xbb, 0xef, 0xfb, 0xde, 0xc1, 0xfb,
-	0x01, 0x1b, 0x53, 0x61, 0x11, 0x97, 0x0c, 0x63, 0x2a, 0x2c, 0x41, 0x5d, 0x16, 0xbd, 0x4d, 0x78,
-	0x4c, 0xac, 0x01, 0x0f, 0xcf, 0x99, 0x57, 0x7c, 0xcc, 0xa1, 0xe0, 0x31, 0x47, 0x5b, 0x05, 0xcf,
-	0x5c, 0xf0, 0xcc, 0x9c, 0x50, 0xc7, 0x1e, 0xe7, 0x9e, 0x4f, 0x2d, 0x49, 0xec, 0x27, 0xe7, 0x96,
-	0x9b, 0x08, 0x12, 0x33, 0x1e, 0xe6, 0xd2, 0x7a, 0xcd, 0xe3, 0x1e, 0x97, 0xa5, 0x95, 0x55, 0x39,
-	0xba, 0xf3, 0x59, 0x83, 0xda, 0x09, 0x11, 0x24, 0x88, 0x50, 0x1b, 0x6a, 0xd2, 0x30, 0x32, 0x40,
-	0x43, 0x6d, 0x6e, 0xec, 0xdd, 0x35, 0xff, 0x79, 0x99, 0x99, 0x4b, 0xcc, 0xe7, 0x19, 0x76, 0x54,
-	0x99, 0x7c, 0xdd, 0x56, 0xec, 0x42, 0x8c, 0x9a, 0xb0, 0x2a, 0xf9, 0x4e, 0x44, 0xc5, 0x88, 0x0a,
-	0x27, 0x11, 0xbe, 0xb1, 0xd2, 0x00, 0xcd, 0x75, 0x5b, 0x97, 0xf8, 0xa9, 0x84, 0x5f, 0x08, 0x1f,
-	0xed, 0xc2, 0xda, 0x80, 0x87, 0x21, 0x1d, 0x64, 0x5d, 0x3a, 0x43, 0xce, 0x7d, 0x27, 0x62, 0x57,
-	0xd4, 0x50, 0x1b, 0xa0, 0xa9, 0xda, 0x68, 0x71, 0x76, 0xc2, 0xb9, 0x7f, 0xca, 0xae, 0x68, 0x7d,
-	0x0a, 0xe0, 0x5a, 0x6f, 0x44, 0x85, 0x60, 0x2e, 0x45, 0xaf, 0x21, 0x74, 0x59, 0x40, 0xc3, 0x88,
-	0xf1, 0xb0, 0xec, 0xf9, 0xe0, 0xf6, 0x9e, 0x4b, 0xbd, 0xd9, 0x9a, 0x8b, 0xdb, 0x61, 0x2c, 0x2e,
-	0x8b, 0x31, 0x7e, 0xf3, 0x44, 0x77, 0x20, 0x0c, 0xc8, 0xd8, 0x21, 0x01, 0x4f, 0xc2, 0x58, 0x0e,
-	0xa1, 0xda, 0xeb, 0x01, 0x19, 0x1f, 0x4a, 0xa0, 0xfe, 0x08, 0x6e, 0xfe, 0xe5, 0x81, 0xaa, 0x50,
-	0xbd, 0xa0, 0x97, 0x06, 0x90, 0xf3, 0x66, 0x25, 0xaa, 0xc1, 0xd5, 0x11, 0xf1, 0x13, 0x5a, 0xec,
-	0x20, 0xff, 0x39, 0x58, 0xd9, 0x07, 0x07, 0x95, 0xeb, 0x8f, 0xdb, 0xa0, 0x7e, 0xad, 0xc2, 0x55,
-	0xb9, 0x46, 0x84, 0x60, 0x25, 0x24, 0x01, 0x2d, 0xc4, 0xb2, 0xbe, 0xa5, 0x03, 0xf4, 0x0c, 0xea,
-	0x23, 0xe2, 0x33, 0xd7, 0x29, 0xb3, 0x96, 0xbb, 0xdb, 0xd8, 0xdb, 0x32, 0xf3, 0xc7, 0x60, 0x96,
-	0x8f, 0xc1, 0x6c, 0x15, 0x84, 0xa3, 0xb5, 0x6c, 0xca, 0x0f, 0xdf, 0xb6, 0x81, 0xfd, 0xbf, 0x94,
-	0x96, 0x07, 0xa8, 0x0b, 0x37, 0xfb, 0xc9, 0xe0, 0x82, 0xc6, 0x0b, 0xb3, 0xca, 0xf2, 0x66, 0x7a,
-	0xae, 0x9d, 0xbb, 0xf5, 0x61, 0x4d, 0x90, 0x98, 0x3a, 0x3e, 0x0b, 0x58, 0xec, 0x10, 0xdf, 0xe3,
-	0x82, 0xc5, 0x6f, 0x02, 0x63, 0xb5, 0x01, 0x9a, 0xfa, 0xde, 0xee, 0x92, 0x4f, 0xeb, 0xb0, 0xd4,
-	0xd9, 0x28, 0x73, 0xeb, 0x66, 0x66, 0x73, 0x0c, 0x3d, 0x85, 0xeb, 0xbc, 0x08, 0x33, 0x32, 0x34,
-	0x99, 0xff, 0xbd, 0xe5, 0xf3, 0xb7, 0x17, 0xe2, 0x3c, 0x8a, 0x9d, 0x87, 0x50, 0xff, 0xf3, 0x56,
-	0x54, 0x85, 0xff, 0x3d, 0xee, 0xbc, 0x6c, 0xb7, 0x9c, 0xb3, 0xce, 0x71, 0xab, 0x77, 0x56, 0x55,
-	0x10, 0x82, 0xba, 0xdd, 0xeb, 0x76, 0x3b, 0xc7, 0x4f, 0x4a, 0x0c, 0x1c, 0xed, 0x4f, 0x66, 0x58,
-	0x99, 0xce, 0xb0, 0xf2, 0x65, 0x86, 0x95, 0x9b, 0x19, 0x56, 0xde, 0xa5, 0x18, 0x7c, 0x4a, 0xb1,
-	0x32, 0x49, 0x31, 0x98, 0xa6, 0x18, 0x7c, 0x4f, 0x31, 0xf8, 0x99, 0x62, 0xe5, 0x26, 0xc5, 0xe0,
-	0xfd, 0x0f, 0xac, 0xbc, 0xd2, 0xf2, 0x96, 0xfa, 0x9a, 0x5c, 0xe9, 0x83, 0x5f, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0x82, 0x98, 0x9b, 0xbf, 0x05, 0x04, 0x00, 0x00,
+	// 816 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcf, 0x6f, 0xe3, 0x44,
+	0x18, 0xf5, 0x34, 0x69, 0xb6, 0xf9, 0xba, 0x71, 0xcd, 0x50, 0x24, 0x6f, 0x24, 0xdc, 0xa8, 0x12,
+	0x22, 0x5a, 0x21, 0x67, 0x55, 0x24, 0x58, 0x55, 0xe2, 0x90, 0x5f, 0x5d, 0x02, 0x26, 0x29, 0x4e,
+	0x97, 0x02, 0x97, 0x61, 0x9a, 0x4c, 0xd3, 0x51, 0x6d, 0x4f, 0x18, 0x4f, 0xa2, 0x66, 0x4f, 0x1c,
+	0xf7, 0xc8, 0x91, 0x23, 0x47, 0xfe, 0x94, 0xde, 0xe8, 0x91, 0x13, 0xd0, 0x70, 0xe1, 0xb8, 0x7f,
+	0x02, 0xf2, 0xd8, 0x6e, 0x37, 0x95, 0x50, 0x7b, 0xf2, 0xcc, 0x9b, 0xef, 0xbd, 0xf9, 0x3c, 0xef,
+	0x7d, 0xf0, 0x51, 0xc8, 0x2f, 0x98, 0x6c, 0xd0, 0x31, 0x9d, 0x2a, 0x26, 0x1b, 0x92, 0x8d, 0x79,
+	0xfc, 0xe3, 0x4c, 0x28, 0xda, 0x18, 0x89, 0xe8, 0x94, 0x4f, 0xb2, 0x8f, 0x3b, 0x95, 0x42, 0x09,
+	0xfc, 0x24, 0xab, 0x73, 0x6f, 0xeb, 0xdc, 0xb4, 0xa0, 0xea, 0x4c, 0x84, 0x98, 0x04, 0xac, 0xa1,
+	0x0b, 0x4f, 0x66, 0xa7, 0x8d, 0xf1, 0x4c, 0x52, 0xc5, 0x45, 0x94, 0x52, 0xab, 0xdb, 0x13, 0x31,
+	0x11, 0x7a, 0xd9, 0x48, 0x56, 0x29, 0xba, 0xfb, 0x3b, 0x40, 0xe9, 0x90, 0x4a, 0x1a, 0xc6, 0xb8,
+	0x0b, 0x25, 0x2d, 0x18, 0xdb, 0xa8, 0x56, 0xa8, 0x6f, 0xee, 0x7d, 0xe8, 0xfe, 0xef, 0x65, 0x6e,
+	0x4a, 0x71, 0xbf, 0x4e, 0xb0, 0x56, 0xf1, 0xf2, 0xcf, 0x1d, 0xc3, 0xcf, 0xc8, 0xb8, 0x0e, 0x96,
+	0xae, 0x27, 0x31, 0x93, 0x73, 0x26, 0xc9, 0x4c, 0x06, 0xf6, 0x5a, 0x0d, 0xd5, 0xcb, 0xbe, 0xa9,
+	0xf1, 0xa1, 0x86, 0x5f, 0xca, 0x00, 0x3f, 0x83, 0xed, 0x91, 0x88, 0x22, 0x36, 0x4a, 0xba, 0x24,
+	0x53, 0x21, 0x02, 0x12, 0xf3, 0x57, 0xcc, 0x2e, 0xd4, 0x50, 0xbd, 0xe0, 0xe3, 0xdb, 0xb3, 0x43,
+	0x21, 0x82, 0x21, 0x7f, 0xc5, 0xf0, 0x77, 0xb0, 0x35, 0x66, 0xd3, 0x40, 0x2c, 0x42, 0x16, 0x29,
+	0xa2, 0x16, 0x53, 0x66, 0x17, 0x6b, 0xa8, 0x6e, 0xee, 0x3d, 0xbb, 0xbf, 0xd7, 0xce, 0x0d, 0xf1,
+	0x68, 0x31, 0x65, 0xbe, 0x39, 0x5e, 0xd9, 0xe3, 0xa7, 0xf0, 0xce, 0xdd, 0xb6, 0x63, 0x7b, 0xbd,
+	0x56, 0xa8, 0x97, 0xfd, 0xad, 0xd5, 0xbe, 0xe3, 0xa4, 0xf1, 0x98, 0x45, 0x8a, 0x47, 0x2c, 0x20,
+	0x21, 0x8d, 0x15, 0x93, 0x24, 0xa2, 0x21, 0xb3, 0x4b, 0xfa, 0x37, 0x71, 0x7e, 0xf6, 0x95, 0x3e,
+	0xea, 0xd3, 0x90, 0xe1, 0x6f, 0xc0, 0x3c, 0xa5, 0x3c, 0x98, 0x49, 0x46, 0xa6, 0x22, 0xe0, 0xa3,
+	0x85, 0xfd, 0x48, 0xf7, 0xdd, 0xb8, 0xbf, 0xef, 0x83, 0x94, 0x77, 0xa8, 0x69, 0x7e, 0xe5, 0xf4,
+	0xed, 0x2d, 0xfe, 0x00, 0x4c, 0x9d, 0x1f, 0x22, 0xd9, 0x34, 0xe0, 0x23, 0x1a, 0xdb, 0x1b, 0xfa,
+	0xf1, 0x2a, 0x1a, 0xf5, 0x33, 0x10, 0x1f, 0xc3, 0x7b, 0x67, 0x8c, 0x06, 0xea, 0x8c, 0x8c, 0xce,
+	0xd8, 0xe8, 0x9c, 0xf0, 0x48, 0x31, 0x39, 0xa7, 0x81, 0x5d, 0xae, 0xa1, 0xfa, 0xe6, 0xde, 0x13,
+	0x37, 0xcd, 0x8e, 0x9b, 0x67, 0xc7, 0xed, 0x64, 0xd9, 0x69, 0x6d, 0x24, 0xde, 0xfe, 0xf2, 0xd7,
+	0x0e, 0xf2, 0xdf, 0x4d, 0x15, 0xda, 0x89, 0x40, 0x2f, 0xe3, 0x57, 0xaf, 0x10, 0x6c, 0x0c, 0xe6,
+	0x4c, 0x4a, 0x3e, 0x66, 0xf8, 0x07, 0x80, 0x31, 0x0f, 0x59, 0x14, 0x73, 0x11, 0xe5, 0x21, 0xda,
+	0xbf, 0xff, 0x07, 0x73, 0xbe, 0xdb, 0xb9, 0x21, 0x77, 0x23, 0x25, 0x17, 0x59, 0xae, 0xde, 0xd2,
+	0xc4, 0xef, 0x03, 0x84, 0xf4, 0x82, 0xd0, 0x50, 0xcc, 0x22, 0xa5, 0x53, 0x55, 0xf0, 0xcb, 0x21,
+	0xbd, 0x68, 0x6a, 0xa0, 0xfa, 0x19, 0x6c, 0xdd, 0xd1, 0xc0, 0x16, 0x14, 0xce, 0xd9, 0xc2, 0x46,
+	0xda, 0x99, 0x64, 0x89, 0xb7, 0x61, 0x7d, 0x4e, 0x83, 0x19, 0xcb, 0x42, 0x99, 0x6e, 0xf6, 0xd7,
+	0x9e, 0xa3, 0xfd, 0xe2, 0xeb, 0x5f, 0x77, 0x50, 0xf5, 0x75, 0x01, 0xd6, 0x75, 0xae, 0x31, 0x86,
+	0xa2, 0xb6, 0x35, 0x25, 0xeb, 0xf5, 0x3d, 0x1d, 0xe0, 0x2f, 0xc0, 0x9c, 0xd3, 0x80, 0x8f, 0x49,
+	0x3e, 0x7c, 0x76, 0xe1, 0xe1, 0x2f, 0x5c, 0xd1, 0xd4, 0xfc, 0x00, 0x7b, 0xb0, 0x75, 0x32, 0x1b,
+	0x9d, 0x33, 0x75, 0x2b, 0x56, 0x7c, 0xb8, 0x98, 0x99, 0x72, 0x6f, 0xd4, 0x4e, 0x60, 0x5b, 0x52,
+	0xc5, 0x48, 0xc0, 0x43, 0xae, 0x08, 0x0d, 0x26, 0x42, 0x72, 0x75, 0x16, 0xda, 0xeb, 0x0f, 0x9d,
+	0x1f, 0xfd, 0x26, 0xcd, 0x9c, 0xe7, 0xe3, 0x44, 0xcd, 0x4b, 0xc4, 0x6e, 0x30, 0xfc, 0x39, 0x94,
+	0x45, 0x66, 0x66, 0x6c, 0x97, 0xb4, 0xff, 0x4f, 0x1f, 0xee, 0xbf, 0x7f, 0x4b, 0x4e, 0xad, 0xd8,
+	0xfd, 0x04, 0xcc, 0xd5, 0x5b, 0xb1, 0x05, 0x8f, 0x0f, 0x7a, 0xdf, 0x76, 0x3b, 0xe4, 0xb8, 0xd7,
+	0xef, 0x0c, 0x8e, 0x2d, 0x03, 0x63, 0x30, 0xfd, 0x81, 0xe7, 0xf5, 0xfa, 0x2f, 0x72, 0x0c, 0xed,
+	0x7e, 0x0a, 0xe6, 0xea, 0xb4, 0x63, 0x80, 0xd2, 0xb0, 0xd7, 0x7f, 0xe1, 0x75, 0x2d, 0x03, 0x3f,
+	0x86, 0x8d, 0x61, 0xb7, 0x7f, 0xd4, 0xeb, 0x77, 0x3d, 0x0b, 0xe1, 0x4d, 0x78, 0xd4, 0xf6, 0x5e,
+	0x0e, 0x8f, 0xba, 0xbe, 0xb5, 0xb6, 0xdb, 0x82, 0xca, 0xca, 0xb8, 0x61, 0x13, 0xe0, 0xa0, 0xd9,
+	0xf3, 0x48, 0xdb, 0x1b, 0x0c, 0x13, 0x6e, 0x05, 0xca, 0x7a, 0x3f, 0x38, 0xec, 0xf6, 0x2d, 0x94,
+	0x5c, 0xee, 0x0d, 0xda, 0x4d, 0x8f, 0x1c, 0x34, 0x3d, 0xaf, 0xd5, 0x6c, 0x7f, 0x69, 0xad, 0xb5,
+	0x9e, 0x5f, 0x5e, 0x3b, 0xc6, 0xd5, 0xb5, 0x63, 0xfc, 0x71, 0xed, 0x18, 0x6f, 0xae, 0x1d, 0xe3,
+	0xa7, 0xa5, 0x83, 0x7e, 0x5b, 0x3a, 0xc6, 0xe5, 0xd2, 0x41, 0x57, 0x4b, 0x07, 0xfd, 0xbd, 0x74,
+	0xd0, 0xbf, 0x4b, 0xc7, 0x78, 0xb3, 0x74, 0xd0, 0xcf, 0xff, 0x38, 0xc6, 0xf7, 0xa5, 0xf4, 0x3d,
+	0x4e, 0x4a, 0xda, 0xcf, 0x8f, 0xff, 0x1b, 0x00, 0xca, 0xa4, 0xca, 0x4d, 0x13, 0x06, 0x00, 0x00,
 }
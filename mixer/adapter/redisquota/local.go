@@ -0,0 +1,104 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisquota
+
+import (
+	"sync"
+	"time"
+
+	"istio.io/istio/mixer/adapter/redisquota/config"
+)
+
+type (
+	// localQuota approximates the Redis quotas in-process while Redis is unreachable.
+	// Every key is limited to a fixed window of the quota's valid_duration, in which
+	// this replica allows its share of the key's max amount.
+	localQuota struct {
+		lock     sync.Mutex
+		replicas int64
+		windows  map[string]*localWindow
+	}
+
+	// localWindow tracks the amount allocated locally for a key.
+	localWindow struct {
+		limit     *config.Params_Quota
+		maxAmount int64
+		expire    time.Time
+		used      int64
+	}
+)
+
+func newLocalQuota(replicas int64) *localQuota {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &localQuota{
+		replicas: replicas,
+		windows:  make(map[string]*localWindow),
+	}
+}
+
+// share returns the amount of maxAmount this replica may allocate on its own.
+func (l *localQuota) share(maxAmount int64) int64 {
+	share := maxAmount / l.replicas
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+// alloc allocates up to amount from the window of key, and returns the allocated amount
+// along with the time left in the window.
+func (l *localQuota) alloc(key string, limit *config.Params_Quota, maxAmount int64, amount int64,
+	bestEffort bool, now time.Time) (int64, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	w := l.windows[key]
+	if w == nil || !now.Before(w.expire) {
+		w = &localWindow{
+			limit:     limit,
+			maxAmount: maxAmount,
+			expire:    now.Add(limit.ValidDuration),
+		}
+		l.windows[key] = w
+	}
+
+	available := l.share(maxAmount) - w.used
+	if amount > available {
+		if !bestEffort || available <= 0 {
+			return 0, 0
+		}
+		amount = available
+	}
+
+	w.used += amount
+	return amount, w.expire.Sub(now)
+}
+
+// drain returns the windows that have not expired yet and forgets all windows.
+func (l *localQuota) drain(now time.Time) map[string]*localWindow {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	windows := make(map[string]*localWindow, len(l.windows))
+	for key, w := range l.windows {
+		if now.Before(w.expire) && w.used > 0 {
+			windows[key] = w
+		}
+	}
+	l.windows = make(map[string]*localWindow)
+	return windows
+}
@@ -13,7 +13,13 @@
 // limitations under the License.
 
 // Package redisquota provides a quota implementation with redis as backend.
-// The prerequisite is to have a redis server running.
+// The prerequisite is to have a redis server running. Redis can be a single
+// server, a master monitored by Redis Sentinels or a Redis Cluster.
+//
+// While Redis is unreachable, quota requests are either denied, granted, or
+// allocated from a local approximation of the quotas, depending on the
+// configured failure policy. Local allocations are replayed into Redis once it
+// is reachable again.
 //
 //go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f mixer/adapter/redisquota/config/config.proto
 package redisquota // import "istio.io/istio/mixer/adapter/redisquota"
@@ -23,8 +29,11 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
+	"net"
 	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis"
@@ -52,7 +61,10 @@ type (
 	handler struct {
 		// go-redis client
 		// connection pool with redis
-		client *redis.Client
+		client redis.UniversalClient
+
+		// whether keys need a hash tag to map all keys of a quota to the same cluster slot
+		hashTag bool
 
 		// the limits we know about
 		limits map[string]*config.Params_Quota
@@ -68,9 +80,31 @@ type (
 
 		// logger provided by the framework
 		logger adapter.Logger
+
+		// what to do with quota requests while redis is unreachable
+		failurePolicy config.Params_FailurePolicy
+
+		// local approximation of the quotas used with LOCAL_FALLBACK
+		local *localQuota
+
+		// set to 1 while redis is unreachable
+		degraded int32
+
+		// how often redis is checked for recovery while degraded
+		healthCheckInterval time.Duration
+
+		closing chan bool
+		done    chan bool
+
+		// makes Close idempotent
+		closeOnce sync.Once
+		closeErr  error
 	}
 )
 
+// defaultHealthCheckInterval is used when health_check_interval is not configured.
+const defaultHealthCheckInterval = 5 * time.Second
+
 ///////////////// Configuration Methods ///////////////
 
 func (b *builder) SetQuotaTypes(quotaTypes map[string]*quota.Type) {
@@ -143,22 +177,51 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 			b.adapterConfig.ConnectionPoolSize)
 	}
 
-	if len(b.adapterConfig.RedisServerUrl) == 0 {
-		ce = ce.Appendf("redis_server_url", "redis_server_url should not be empty")
+	if b.adapterConfig.MixerReplicas < 0 {
+		ce = ce.Appendf("mixer_replicas", "mixer_replicas of %v is invalid, must be >= 0",
+			b.adapterConfig.MixerReplicas)
 	}
 
-	// test redis connection
-	option := redis.Options{
-		Addr: b.adapterConfig.RedisServerUrl,
+	if b.adapterConfig.HealthCheckInterval < 0 {
+		ce = ce.Appendf("health_check_interval", "health_check_interval of %v is invalid, must be >= 0",
+			b.adapterConfig.HealthCheckInterval)
+	}
+
+	// a sentinel or cluster client can only be created from a complete configuration
+	deploymentValid := true
+	switch b.adapterConfig.DeploymentType {
+	case config.SENTINEL:
+		if len(b.adapterConfig.RedisServerUrls) == 0 {
+			ce = ce.Appendf("redis_server_urls", "redis_server_urls should not be empty for SENTINEL deployment")
+			deploymentValid = false
+		}
+		if len(b.adapterConfig.SentinelMasterName) == 0 {
+			ce = ce.Appendf("sentinel_master_name", "sentinel_master_name should not be empty for SENTINEL deployment")
+			deploymentValid = false
+		}
+	case config.CLUSTER:
+		if len(b.adapterConfig.RedisServerUrls) == 0 {
+			ce = ce.Appendf("redis_server_urls", "redis_server_urls should not be empty for CLUSTER deployment")
+			deploymentValid = false
+		}
+	default:
+		if len(b.adapterConfig.RedisServerUrl) == 0 {
+			ce = ce.Appendf("redis_server_url", "redis_server_url should not be empty")
+		}
 	}
 
-	if b.adapterConfig.ConnectionPoolSize > 0 {
-		option.PoolSize = int(b.adapterConfig.ConnectionPoolSize)
+	if !deploymentValid {
+		return
 	}
 
-	client := redis.NewClient(&option)
+	// test redis connection
+	client := newClient(b.adapterConfig)
 	if _, err := client.Ping().Result(); err != nil {
-		ce = ce.Appendf(info.Name, "could not create a connection to redis server: %v", err)
+		_ = client.Close()
+		// the handler copes with an unreachable redis unless it fails closed
+		if b.adapterConfig.FailurePolicy == config.FAIL_CLOSE {
+			ce = ce.Appendf(info.Name, "could not create a connection to redis server: %v", err)
+		}
 		return
 	}
 
@@ -167,6 +230,7 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	for algorithm, script := range rateLimitingLUAScripts {
 		scripts[algorithm] = redis.NewScript(script)
 		if _, err := scripts[algorithm].Load(client).Result(); err != nil {
+			_ = client.Close()
 			ce = ce.Appendf(info.Name, "unable to initialized redis service: %v", err)
 			return
 		}
@@ -176,6 +240,41 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 	return
 }
 
+// idleCheckFrequency disables the reaper of idle connections of the go-redis clients. The reaper only stops on its
+// next tick after the client is closed, which would let it outlive the handler. Stale connections are still discarded
+// when they are taken from the pool.
+const idleCheckFrequency = -1
+
+// newClient returns a go-redis client for the configured redis deployment.
+func newClient(cfg *config.Params) redis.UniversalClient {
+	poolSize := 0
+	if cfg.ConnectionPoolSize > 0 {
+		poolSize = int(cfg.ConnectionPoolSize)
+	}
+
+	switch cfg.DeploymentType {
+	case config.SENTINEL:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:         cfg.SentinelMasterName,
+			SentinelAddrs:      cfg.RedisServerUrls,
+			PoolSize:           poolSize,
+			IdleCheckFrequency: idleCheckFrequency,
+		})
+	case config.CLUSTER:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:              cfg.RedisServerUrls,
+			PoolSize:           poolSize,
+			IdleCheckFrequency: idleCheckFrequency,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:               cfg.RedisServerUrl,
+			PoolSize:           poolSize,
+			IdleCheckFrequency: idleCheckFrequency,
+		})
+	}
+}
+
 // getOverrideHash returns hash key of the given dimension in sorted by key
 func getDimensionHash(dimensions map[string]string) string {
 	var keys []string
@@ -207,17 +306,10 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 	}
 
 	// initialize redis client
-	option := redis.Options{
-		Addr: b.adapterConfig.RedisServerUrl,
-	}
-
-	if b.adapterConfig.ConnectionPoolSize > 0 {
-		option.PoolSize = int(b.adapterConfig.ConnectionPoolSize)
-	}
-
-	client := redis.NewClient(&option)
-	if _, err := client.Ping().Result(); err != nil {
-		return nil, fmt.Errorf("could not create a connection to redis server: %v", err)
+	client := newClient(b.adapterConfig)
+	_, pingErr := client.Ping().Result()
+	if pingErr != nil && b.adapterConfig.FailurePolicy == config.FAIL_CLOSE {
+		return nil, fmt.Errorf("could not create a connection to redis server: %v", pingErr)
 	}
 
 	// load scripts into redis
@@ -227,14 +319,35 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 	}
 
 	h := &handler{
-		client:        client,
-		limits:        limits,
-		scripts:       scripts,
-		logger:        env.Logger(),
-		getTime:       time.Now,
-		dimensionHash: dimensionHash,
+		client:              client,
+		hashTag:             b.adapterConfig.DeploymentType == config.CLUSTER,
+		limits:              limits,
+		scripts:             scripts,
+		logger:              env.Logger(),
+		getTime:             time.Now,
+		dimensionHash:       dimensionHash,
+		failurePolicy:       b.adapterConfig.FailurePolicy,
+		healthCheckInterval: b.adapterConfig.HealthCheckInterval,
 	}
 
+	if h.failurePolicy == config.FAIL_CLOSE {
+		return h, nil
+	}
+
+	if h.failurePolicy == config.LOCAL_FALLBACK {
+		h.local = newLocalQuota(b.adapterConfig.MixerReplicas)
+	}
+	if h.healthCheckInterval <= 0 {
+		h.healthCheckInterval = defaultHealthCheckInterval
+	}
+	if pingErr != nil {
+		h.setDegraded(pingErr)
+	}
+
+	h.closing = make(chan bool)
+	h.done = make(chan bool)
+	env.ScheduleDaemon(h.healthCheck)
+
 	return h, nil
 }
 
@@ -331,23 +444,17 @@ func (h *handler) HandleQuota(context context.Context, instance *quota.Instance,
 				h.logger.Infof("key: %v maxAmount: %v", key, maxAmount)
 			}
 
-			// execute lua algorithm script
-			result, err := script.Run(
-				h.client,
-				[]string{
-					key + ".meta", // KEY[1]
-					key + ".data", // KEY[2]
-				},
-				maxAmount, // ARGV[1] credit
-				limit.GetValidDuration().Nanoseconds(),  // ARGV[2] window length
-				limit.GetBucketDuration().Nanoseconds(), // ARGV[3] bucket length
-				args.BestEffort,                         // ARGV[4] best effort
-				args.QuotaAmount,                        // ARGV[5] token
-				now.UnixNano(),                          // ARGV[6] timestamp
-				args.DeduplicationID,                    // ARGS[8] deduplication id
-			).Result()
+			if h.isDegraded() {
+				return h.handleUnavailable(key, limit, maxAmount, args, now), nil
+			}
 
+			result, err := h.runScript(script, key, limit, maxAmount, args.QuotaAmount,
+				args.BestEffort, args.DeduplicationID, now)
 			if err != nil {
+				if h.failurePolicy != config.FAIL_CLOSE && isUnavailable(err) {
+					h.setDegraded(err)
+					return h.handleUnavailable(key, limit, maxAmount, args, now), nil
+				}
 				_ = h.logger.Errorf("failed to run quota script: %v", err)
 				return adapter.QuotaResult{}, nil
 			}
@@ -373,8 +480,142 @@ func (h *handler) HandleQuota(context context.Context, instance *quota.Instance,
 	return adapter.QuotaResult{}, nil
 }
 
-func (h handler) Close() error {
-	return h.client.Close()
+// runScript executes the lua algorithm script for key.
+func (h *handler) runScript(script *redis.Script, key string, limit *config.Params_Quota, maxAmount int64,
+	amount int64, bestEffort bool, deduplicationID string, now time.Time) (interface{}, error) {
+	// In a cluster all keys of a script must be in the same slot. Only the part of a key within
+	// braces is hashed, which also covers the deduplication keys derived from KEY[1].
+	if h.hashTag {
+		key = "{" + key + "}"
+	}
+
+	return script.Run(
+		h.client,
+		[]string{
+			key + ".meta", // KEY[1]
+			key + ".data", // KEY[2]
+		},
+		maxAmount,                               // ARGV[1] credit
+		limit.GetValidDuration().Nanoseconds(),  // ARGV[2] window length
+		limit.GetBucketDuration().Nanoseconds(), // ARGV[3] bucket length
+		bestEffort,                              // ARGV[4] best effort
+		amount,                                  // ARGV[5] token
+		now.UnixNano(),                          // ARGV[6] timestamp
+		deduplicationID,                         // ARGS[8] deduplication id
+	).Result()
+}
+
+// handleUnavailable allocates quota according to the failure policy while redis is unreachable.
+func (h *handler) handleUnavailable(key string, limit *config.Params_Quota, maxAmount int64,
+	args adapter.QuotaArgs, now time.Time) adapter.QuotaResult {
+	if h.failurePolicy == config.FAIL_OPEN {
+		return adapter.QuotaResult{
+			Status:        status.OK,
+			Amount:        args.QuotaAmount,
+			ValidDuration: limit.ValidDuration,
+		}
+	}
+
+	allocated, expiration := h.local.alloc(key, limit, maxAmount, args.QuotaAmount, args.BestEffort, now)
+	if allocated <= 0 {
+		return adapter.QuotaResult{
+			Status: status.WithResourceExhausted("redisquota: Resource exhausted"),
+		}
+	}
+
+	return adapter.QuotaResult{
+		Status:        status.OK,
+		Amount:        allocated,
+		ValidDuration: expiration,
+	}
+}
+
+// isUnavailable returns true if err means that redis could not be reached, rather than an
+// error reported by redis itself.
+func isUnavailable(err error) bool {
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	// go-redis does not export the errors it returns when no sentinel or connection is available
+	msg := err.Error()
+	return msg == "redis: all sentinels are unreachable" || msg == "redis: connection pool timeout"
+}
+
+func (h *handler) isDegraded() bool {
+	return atomic.LoadInt32(&h.degraded) == 1
+}
+
+func (h *handler) setDegraded(err error) {
+	if atomic.CompareAndSwapInt32(&h.degraded, 0, 1) {
+		h.logger.Warningf("redis is unreachable, handling quota requests with %v: %v", h.failurePolicy, err)
+	}
+}
+
+// healthCheck periodically pings redis while degraded, and reconciles the local allocations
+// into redis once it is reachable again.
+func (h *handler) healthCheck() {
+	ticker := time.NewTicker(h.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !h.isDegraded() {
+				continue
+			}
+			if _, err := h.client.Ping().Result(); err != nil {
+				continue
+			}
+
+			// New requests go to redis from here on. The few that raced with recovery still
+			// end up in the local windows before they are drained.
+			atomic.StoreInt32(&h.degraded, 0)
+			h.logger.Infof("redis is reachable again")
+			if h.local != nil {
+				h.reconcile()
+			}
+		case <-h.closing:
+			h.done <- true
+			return
+		}
+	}
+}
+
+// reconcile replays the allocations made locally while redis was unreachable, so that they
+// count against the quotas in redis for the rest of their windows.
+func (h *handler) reconcile() {
+	now := h.getTime()
+	windows := h.local.drain(now)
+	for key, w := range windows {
+		script, ok := h.scripts[w.limit.RateLimitAlgorithm]
+		if !ok {
+			continue
+		}
+		if _, err := h.runScript(script, key, w.limit, w.maxAmount, w.used, true, "", now); err != nil {
+			_ = h.logger.Errorf("unable to reconcile local quota allocations for %v: %v", key, err)
+		}
+	}
+
+	if len(windows) > 0 {
+		h.logger.Infof("reconciled local quota allocations of %d keys into redis", len(windows))
+	}
+}
+
+func (h *handler) Close() error {
+	h.closeOnce.Do(func() {
+		if h.closing != nil {
+			h.closing <- true
+			close(h.closing)
+			<-h.done
+		}
+		h.closeErr = h.client.Close()
+	})
+	return h.closeErr
 }
 
 ////////////////// Bootstrap //////////////////////////
@@ -389,8 +630,10 @@ func GetInfo() adapter.Info {
 			quota.TemplateName,
 		},
 		DefaultConfig: &config.Params{
-			RedisServerUrl:     "localhost:6379",
-			ConnectionPoolSize: 10,
+			RedisServerUrl:      "localhost:6379",
+			ConnectionPoolSize:  10,
+			MixerReplicas:       1,
+			HealthCheckInterval: defaultHealthCheckInterval,
 		},
 		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
 	}
@@ -19,11 +19,14 @@ package redisquota
 
 import (
 	"context"
+	"net"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis"
 	"github.com/alicebob/miniredis/server"
+	"github.com/gogo/protobuf/proto"
 	"github.com/golang/glog"
 
 	"istio.io/istio/mixer/adapter/redisquota/config"
@@ -51,6 +54,13 @@ func TestBuilderValidate(t *testing.T) {
 	}
 	defer mockRedis.Close()
 
+	unreachableRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Unable to start mock redis server: %v", err)
+	}
+	unreachableAddr := unreachableRedis.Addr()
+	unreachableRedis.Close()
+
 	cases := map[string]struct {
 		quotaTypes map[string]*quota.Type
 		config     *config.Params
@@ -79,6 +89,46 @@ func TestBuilderValidate(t *testing.T) {
 				"redisquota: could not create a connection to redis server: dial tcp: missing address",
 			},
 		},
+		"Empty sentinel config": {
+			config: &config.Params{
+				DeploymentType: config.SENTINEL,
+			},
+			errMsg: []string{
+				"quotas: quota should not be empty",
+				"redis_server_urls: redis_server_urls should not be empty for SENTINEL deployment",
+				"sentinel_master_name: sentinel_master_name should not be empty for SENTINEL deployment",
+			},
+		},
+		"Empty cluster config": {
+			config: &config.Params{
+				DeploymentType: config.CLUSTER,
+			},
+			errMsg: []string{
+				"quotas: quota should not be empty",
+				"redis_server_urls: redis_server_urls should not be empty for CLUSTER deployment",
+			},
+		},
+		"Invalid mixer replicas and health check interval": {
+			config: &config.Params{
+				RedisServerUrl:      mockRedis.Addr(),
+				MixerReplicas:       -1,
+				HealthCheckInterval: -time.Second,
+			},
+			errMsg: []string{
+				"quotas: quota should not be empty",
+				"mixer_replicas: mixer_replicas of -1 is invalid, must be >= 0",
+				"health_check_interval: health_check_interval of -1s is invalid, must be >= 0",
+			},
+		},
+		"Unreachable redis server with local fallback": {
+			config: &config.Params{
+				RedisServerUrl: unreachableAddr,
+				FailurePolicy:  config.LOCAL_FALLBACK,
+			},
+			errMsg: []string{
+				"quotas: quota should not be empty",
+			},
+		},
 		"Empty quota config": {
 			config: &config.Params{
 				RedisServerUrl:     mockRedis.Addr(),
@@ -662,3 +712,375 @@ func contains(s []string, e string) bool {
 	}
 	return false
 }
+
+// waitFor polls cond until it returns true, or fails the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for start := time.Now(); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestFailurePolicy(t *testing.T) {
+	quotas := []config.Params_Quota{
+		{
+			Name:          "fixed-window",
+			MaxAmount:     10,
+			ValidDuration: time.Second * 10,
+		},
+	}
+	instance := quota.Instance{
+		Name:       "fixed-window",
+		Dimensions: map[string]interface{}{},
+	}
+
+	cases := map[string]struct {
+		policy        config.Params_FailurePolicy
+		bestEffort    bool
+		allocated     int64
+		validDuration time.Duration
+	}{
+		"fail close": {
+			policy:    config.FAIL_CLOSE,
+			allocated: 0,
+		},
+		"fail open": {
+			policy:        config.FAIL_OPEN,
+			allocated:     7,
+			validDuration: time.Second * 10,
+		},
+		"local fallback": {
+			policy:    config.LOCAL_FALLBACK,
+			allocated: 0,
+		},
+		"local fallback, best effort": {
+			policy:        config.LOCAL_FALLBACK,
+			bestEffort:    true,
+			allocated:     5,
+			validDuration: time.Second * 10,
+		},
+	}
+
+	for id, c := range cases {
+		mockRedis, err := miniredis.Run()
+		if err != nil {
+			t.Fatalf("Unable to start mock redis server: %v", err)
+		}
+
+		b := GetInfo().NewBuilder().(*builder)
+		b.SetAdapterConfig(&config.Params{
+			Quotas:         quotas,
+			RedisServerUrl: mockRedis.Addr(),
+			FailurePolicy:  c.policy,
+			MixerReplicas:  2,
+		})
+
+		adapterHandler, err := b.Build(context.Background(), test.NewEnv(t))
+		if err != nil {
+			t.Fatalf("%v: Got error %v, expecting success", id, err)
+		}
+		quotaHandler := adapterHandler.(*handler)
+
+		mockRedis.Close()
+
+		qr, err := quotaHandler.HandleQuota(context.Background(), &instance, adapter.QuotaArgs{
+			QuotaAmount: 7,
+			BestEffort:  c.bestEffort,
+		})
+		if err != nil {
+			t.Errorf("%v: Unexpected error %v", id, err)
+		}
+
+		if qr.Amount != c.allocated {
+			t.Errorf("%v: Expecting token %d, got %d", id, c.allocated, qr.Amount)
+		}
+
+		if qr.ValidDuration != c.validDuration {
+			t.Errorf("%v: Expecting valid duration %v, got %v", id, c.validDuration, qr.ValidDuration)
+		}
+
+		if quotaHandler.isDegraded() != (c.policy != config.FAIL_CLOSE) {
+			t.Errorf("%v: Expecting degraded %v, got %v", id, c.policy != config.FAIL_CLOSE, quotaHandler.isDegraded())
+		}
+
+		_ = quotaHandler.Close()
+	}
+}
+
+func TestBuildUnreachable(t *testing.T) {
+	mockRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Unable to start mock redis server: %v", err)
+	}
+	addr := mockRedis.Addr()
+	mockRedis.Close()
+
+	b := GetInfo().NewBuilder().(*builder)
+	b.SetAdapterConfig(&config.Params{
+		RedisServerUrl: addr,
+	})
+	if _, err = b.Build(context.Background(), test.NewEnv(t)); err == nil {
+		t.Error("Succeeded with an unreachable redis server and FAIL_CLOSE, error expected")
+	}
+
+	b.SetAdapterConfig(&config.Params{
+		RedisServerUrl: addr,
+		FailurePolicy:  config.FAIL_OPEN,
+	})
+	adapterHandler, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got error %v with an unreachable redis server and FAIL_OPEN, expecting success", err)
+	}
+	if !adapterHandler.(*handler).isDegraded() {
+		t.Error("Handler built with an unreachable redis server is not degraded")
+	}
+	if err = adapterHandler.Close(); err != nil {
+		t.Errorf("Got error %v on Close, expecting success", err)
+	}
+	if err = adapterHandler.Close(); err != nil {
+		t.Errorf("Got error %v on the second Close, expecting success", err)
+	}
+}
+
+func TestLocalFallbackReconcile(t *testing.T) {
+	mockRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Unable to start mock redis server: %v", err)
+	}
+	defer mockRedis.Close()
+
+	b := GetInfo().NewBuilder().(*builder)
+	b.SetAdapterConfig(&config.Params{
+		Quotas: []config.Params_Quota{
+			{
+				Name:          "fixed-window",
+				MaxAmount:     10,
+				ValidDuration: time.Second * 10,
+			},
+		},
+		RedisServerUrl:      mockRedis.Addr(),
+		ConnectionPoolSize:  1,
+		FailurePolicy:       config.LOCAL_FALLBACK,
+		MixerReplicas:       2,
+		HealthCheckInterval: 10 * time.Millisecond,
+	})
+
+	env := test.NewEnv(t)
+	adapterHandler, err := b.Build(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Got error %v, expecting success", err)
+	}
+	quotaHandler := adapterHandler.(*handler)
+	defer func() { _ = quotaHandler.Close() }()
+
+	now := time.Now()
+	quotaHandler.getTime = func() time.Time { return now }
+
+	instance := quota.Instance{
+		Name:       "fixed-window",
+		Dimensions: map[string]interface{}{},
+	}
+
+	requests := []struct {
+		down       bool
+		token      int64
+		bestEffort bool
+		allocated  int64
+	}{
+		{false, 2, false, 2},
+		// the local share of each of the 2 replicas is 5
+		{true, 3, false, 3},
+		{true, 3, false, 0},
+		{true, 3, true, 2},
+		{true, 1, true, 0},
+	}
+
+	for idx, req := range requests {
+		if req.down && !quotaHandler.isDegraded() {
+			mockRedis.Close()
+		}
+
+		qr, err := quotaHandler.HandleQuota(context.Background(), &instance, adapter.QuotaArgs{
+			QuotaAmount: req.token,
+			BestEffort:  req.bestEffort,
+		})
+		if err != nil {
+			t.Errorf("%d: Unexpected error %v", idx, err)
+		}
+
+		if qr.Amount != req.allocated {
+			t.Errorf("%d: Expecting token %d, got %d", idx, req.allocated, qr.Amount)
+		}
+	}
+
+	if err = mockRedis.Restart(); err != nil {
+		t.Fatalf("Unable to restart mock redis server: %v", err)
+	}
+
+	// 2 tokens allocated from redis, and 5 allocated locally
+	waitFor(t, "reconciliation", func() bool {
+		return mockRedis.HGet("fixed-window.meta", "token") == "7"
+	})
+	waitFor(t, "recovery", func() bool {
+		return !quotaHandler.isDegraded()
+	})
+
+	qr, err := quotaHandler.HandleQuota(context.Background(), &instance, adapter.QuotaArgs{
+		QuotaAmount: 5,
+		BestEffort:  true,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if qr.Amount != 3 {
+		t.Errorf("Expecting token 3 after reconciliation, got %d", qr.Amount)
+	}
+}
+
+func TestDeploymentTypes(t *testing.T) {
+	mockRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Unable to start mock redis server: %v", err)
+	}
+	defer mockRedis.Close()
+
+	host, port, err := net.SplitHostPort(mockRedis.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+
+	cases := map[string]struct {
+		deploymentType config.Params_DeploymentType
+		commands       map[string]func(c *server.Peer, cmd string, args []string)
+		key            string
+	}{
+		"sentinel": {
+			deploymentType: config.SENTINEL,
+			commands: map[string]func(c *server.Peer, cmd string, args []string){
+				"SENTINEL": func(c *server.Peer, cmd string, args []string) {
+					if len(args) == 2 && args[0] == "get-master-addr-by-name" && args[1] == "mymaster" {
+						c.WriteLen(2)
+						c.WriteBulk(host)
+						c.WriteBulk(port)
+						return
+					}
+					c.WriteLen(0)
+				},
+				"SUBSCRIBE": func(c *server.Peer, cmd string, args []string) {
+					c.WriteLen(3)
+					c.WriteBulk("subscribe")
+					c.WriteBulk(args[0])
+					c.WriteInt(1)
+				},
+			},
+			key: "fixed-window.meta",
+		},
+		"cluster": {
+			deploymentType: config.CLUSTER,
+			commands: map[string]func(c *server.Peer, cmd string, args []string){
+				// a single node, the mock redis server, serves all slots
+				"CLUSTER": func(c *server.Peer, cmd string, args []string) {
+					c.WriteLen(1)
+					c.WriteLen(3)
+					c.WriteInt(0)
+					c.WriteInt(16383)
+					c.WriteLen(2)
+					c.WriteBulk(host)
+					c.WriteInt(portNum)
+				},
+			},
+			key: "{fixed-window}.meta",
+		},
+	}
+
+	for id, c := range cases {
+		s, err := server.NewServer(":0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for cmd, handler := range c.commands {
+			s.Register(cmd, handler)
+		}
+
+		b := GetInfo().NewBuilder().(*builder)
+		b.SetAdapterConfig(&config.Params{
+			Quotas: []config.Params_Quota{
+				{
+					Name:          "fixed-window",
+					MaxAmount:     10,
+					ValidDuration: time.Second * 10,
+				},
+			},
+			DeploymentType:     c.deploymentType,
+			RedisServerUrls:    []string{s.Addr().String()},
+			SentinelMasterName: "mymaster",
+		})
+
+		if ce := b.Validate(); ce != nil {
+			t.Errorf("%v: Unexpected validation error %v", id, ce)
+		}
+
+		adapterHandler, err := b.Build(context.Background(), test.NewEnv(t))
+		if err != nil {
+			t.Fatalf("%v: Got error %v, expecting success", id, err)
+		}
+
+		qr, err := adapterHandler.(*handler).HandleQuota(context.Background(), &quota.Instance{
+			Name:       "fixed-window",
+			Dimensions: map[string]interface{}{},
+		}, adapter.QuotaArgs{
+			QuotaAmount: 3,
+		})
+		if err != nil {
+			t.Errorf("%v: Unexpected error %v", id, err)
+		}
+		if qr.Amount != 3 {
+			t.Errorf("%v: Expecting token 3, got %d", id, qr.Amount)
+		}
+
+		if got := mockRedis.HGet(c.key, "token"); got != "3" {
+			t.Errorf("%v: Expecting 3 tokens in %s, got %q", id, c.key, got)
+		}
+
+		mockRedis.FlushAll()
+		_ = adapterHandler.Close()
+		s.Close()
+	}
+}
+
+func TestConformance(t *testing.T) {
+	mockRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Unable to start mock redis server: %v", err)
+	}
+	defer mockRedis.Close()
+
+	quotas := []config.Params_Quota{
+		{Name: "fixed", MaxAmount: 10, ValidDuration: time.Minute, RateLimitAlgorithm: config.FIXED_WINDOW},
+		{Name: "rolling", MaxAmount: 10, ValidDuration: time.Minute, BucketDuration: time.Second, RateLimitAlgorithm: config.ROLLING_WINDOW},
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Config: &config.Params{
+			RedisServerUrl:     mockRedis.Addr(),
+			ConnectionPoolSize: 10,
+			Quotas:             quotas,
+		},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{RedisServerUrl: mockRedis.Addr(), ConnectionPoolSize: 10},
+			&config.Params{RedisServerUrl: mockRedis.Addr(), ConnectionPoolSize: -1, Quotas: quotas},
+		},
+		Types: map[string]map[string]proto.Message{
+			quota.TemplateName: {"fixed": &quota.Type{}, "rolling": &quota.Type{}},
+		},
+		Instances: map[string][]interface{}{
+			quota.TemplateName: {
+				&quota.Instance{Name: "fixed", Dimensions: map[string]interface{}{"source": "a"}},
+				&quota.Instance{Name: "rolling", Dimensions: map[string]interface{}{}},
+			},
+		},
+	})
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f mixer/adapter/remote/config/config.proto
+
+// Package remote provides an adapter that dispatches instances to out-of-process adapters, over the
+// HandlerService gRPC services generated for each template.
+//
+// The adapter does not list any supported templates. Instead, its builder and handler implement the
+// template-agnostic interfaces of the istio.io/istio/mixer/pkg/adapter/remote package, which Mixer uses for
+// any template that a rule dispatches to a remote handler.
+package remote // import "istio.io/istio/mixer/adapter/remote"
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/mixer/adapter/remote/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/remote"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+type (
+	builder struct {
+		adapterConfig *config.Params
+
+		// inferred types of the instances, by template and instance name.
+		types map[string]map[string]proto.Message
+	}
+
+	handler struct {
+		conn          *grpc.ClientConn
+		adapterConfig *types.Any
+
+		// inferred types of the instances, by template and instance name.
+		types map[string]map[string]*types.Any
+	}
+)
+
+var (
+	_ remote.Builder = &builder{}
+	_ remote.Handler = &handler{}
+)
+
+///////////////// Configuration-time Methods ///////////////
+
+// adapter.HandlerBuilder#SetAdapterConfig
+func (b *builder) SetAdapterConfig(cfg adapter.Config) {
+	b.adapterConfig = cfg.(*config.Params)
+}
+
+// remote.Builder#SetInstanceTypes
+func (b *builder) SetInstanceTypes(template string, types map[string]proto.Message) {
+	b.types[template] = types
+}
+
+// adapter.HandlerBuilder#Validate
+func (b *builder) Validate() (ce *adapter.ConfigErrors) {
+	if b.adapterConfig.Address == "" {
+		ce = ce.Appendf("address", "must be specified")
+	}
+	return
+}
+
+// adapter.HandlerBuilder#Build
+func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler, error) {
+	h := &handler{
+		types: make(map[string]map[string]*types.Any, len(b.types)),
+	}
+
+	if b.adapterConfig.Params != nil {
+		var err error
+		if h.adapterConfig, err = types.MarshalAny(b.adapterConfig.Params); err != nil {
+			return nil, env.Logger().Errorf("invalid params: %v", err)
+		}
+	}
+
+	for tmpl, inferred := range b.types {
+		h.types[tmpl] = make(map[string]*types.Any, len(inferred))
+		for name, t := range inferred {
+			a, err := types.MarshalAny(t)
+			if err != nil {
+				return nil, env.Logger().Errorf("unable to encode the type of instance %s: %v", name, err)
+			}
+			h.types[tmpl][name] = a
+		}
+	}
+
+	// The connection is established in the background, and reestablished whenever it is lost.
+	var err error
+	if h.conn, err = grpc.Dial(b.adapterConfig.Address, grpc.WithInsecure(), grpc.WithCodec(remote.Codec{})); err != nil {
+		return nil, env.Logger().Errorf("unable to connect to %s: %v", b.adapterConfig.Address, err)
+	}
+
+	return h, nil
+}
+
+////////////////// Request-time Methods //////////////////////////
+
+// remote.Handler#HandleRemoteCheck
+func (h *handler) HandleRemoteCheck(ctx context.Context, ti *template.Info, instance interface{}) (adapter.CheckResult, error) {
+	out, err := h.invoke(ctx, ti, []interface{}{instance}, adapter.QuotaArgs{})
+	if err != nil {
+		return adapter.CheckResult{}, err
+	}
+	return remote.UnmarshalCheckResult(out)
+}
+
+// remote.Handler#HandleRemoteReport
+func (h *handler) HandleRemoteReport(ctx context.Context, ti *template.Info, instances []interface{}) error {
+	_, err := h.invoke(ctx, ti, instances, adapter.QuotaArgs{})
+	return err
+}
+
+// remote.Handler#HandleRemoteQuota
+func (h *handler) HandleRemoteQuota(ctx context.Context, ti *template.Info, instance interface{},
+	args adapter.QuotaArgs) (adapter.QuotaResult, error) {
+	out, err := h.invoke(ctx, ti, []interface{}{instance}, args)
+	if err != nil {
+		return adapter.QuotaResult{}, err
+	}
+	return remote.UnmarshalQuotaResult(out)
+}
+
+// adapter.Handler#Close
+func (h *handler) Close() error {
+	return h.conn.Close()
+}
+
+func (h *handler) invoke(ctx context.Context, ti *template.Info, instances []interface{}, args adapter.QuotaArgs) ([]byte, error) {
+	in, err := remote.MarshalRequest(ti, &remote.Request{
+		Instances:     instances,
+		AdapterConfig: h.adapterConfig,
+		InstanceTypes: h.types[ti.Name],
+		QuotaArgs:     args,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	if err = grpc.Invoke(ctx, remote.MethodName(ti), in, &out, h.conn); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+////////////////// Bootstrap //////////////////////////
+
+// GetInfo returns the adapter.Info specific to this adapter.
+func GetInfo() adapter.Info {
+	return adapter.Info{
+		Name:        "remote",
+		Impl:        "istio.io/istio/mixer/adapter/remote",
+		Description: "Dispatches instances to out-of-process adapters",
+		// Any template is supported, see remote.Builder and remote.Handler.
+		SupportedTemplates: []string{},
+		NewBuilder: func() adapter.HandlerBuilder {
+			return &builder{types: make(map[string]map[string]proto.Message)}
+		},
+		DefaultConfig: &config.Params{},
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/mixer/v1/config/descriptor"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/adapter/remote/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	sdk "istio.io/istio/mixer/pkg/adapter/remote"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/pkg/status"
+	tmpl "istio.io/istio/mixer/template"
+	"istio.io/istio/mixer/template/listentry"
+	"istio.io/istio/mixer/template/metric"
+	"istio.io/istio/mixer/template/quota"
+)
+
+// sample is an out-of-process adapter that records the instances it receives. It denies list entries that start
+// with the configured prefix, and grants half of the requested quota to best effort allocations.
+type (
+	sample struct {
+		sync.Mutex
+		builds      int
+		metricTypes map[string]*metric.Type
+		metrics     []*metric.Instance
+		dedupIDs    []string
+	}
+
+	sampleBuilder struct {
+		s           *sample
+		cfg         *types.Struct
+		metricTypes map[string]*metric.Type
+	}
+
+	sampleHandler struct {
+		s      *sample
+		prefix string
+	}
+)
+
+func (s *sample) info() adapter.Info {
+	return adapter.Info{
+		Name:               "sample",
+		SupportedTemplates: []string{metric.TemplateName, listentry.TemplateName, quota.TemplateName},
+		NewBuilder:         func() adapter.HandlerBuilder { return &sampleBuilder{s: s} },
+		DefaultConfig:      &types.Struct{},
+	}
+}
+
+func (b *sampleBuilder) SetAdapterConfig(cfg adapter.Config)          { b.cfg = cfg.(*types.Struct) }
+func (b *sampleBuilder) Validate() *adapter.ConfigErrors              { return nil }
+func (b *sampleBuilder) SetMetricTypes(types map[string]*metric.Type) { b.metricTypes = types }
+func (b *sampleBuilder) SetListEntryTypes(map[string]*listentry.Type) {}
+func (b *sampleBuilder) SetQuotaTypes(map[string]*quota.Type)         {}
+func (b *sampleBuilder) Build(context.Context, adapter.Env) (adapter.Handler, error) {
+	b.s.Lock()
+	defer b.s.Unlock()
+
+	b.s.builds++
+	if b.metricTypes != nil {
+		b.s.metricTypes = b.metricTypes
+	}
+
+	h := &sampleHandler{s: b.s}
+	if v, found := b.cfg.Fields["prefix"]; found {
+		h.prefix = v.GetStringValue()
+	}
+	return h, nil
+}
+
+func (h *sampleHandler) HandleMetric(_ context.Context, instances []*metric.Instance) error {
+	h.s.Lock()
+	defer h.s.Unlock()
+
+	for _, inst := range instances {
+		if inst.Name == "fail" {
+			return errors.New("metric failure")
+		}
+	}
+	h.s.metrics = append(h.s.metrics, instances...)
+	return nil
+}
+
+func (h *sampleHandler) HandleListEntry(_ context.Context, inst *listentry.Instance) (adapter.CheckResult, error) {
+	r := adapter.CheckResult{ValidDuration: 5 * time.Second, ValidUseCount: 3}
+	if strings.HasPrefix(inst.Value, h.prefix) {
+		r.Status = status.WithPermissionDenied(inst.Value + " is blacklisted")
+	}
+	return r, nil
+}
+
+func (h *sampleHandler) HandleQuota(_ context.Context, _ *quota.Instance, args adapter.QuotaArgs) (adapter.QuotaResult, error) {
+	h.s.Lock()
+	h.s.dedupIDs = append(h.s.dedupIDs, args.DeduplicationID)
+	h.s.Unlock()
+
+	amount := args.QuotaAmount
+	if args.BestEffort {
+		amount /= 2
+	}
+	return adapter.QuotaResult{Amount: amount, ValidDuration: time.Minute}, nil
+}
+
+func (h *sampleHandler) Close() error { return nil }
+
+func startSample(t *testing.T) (*sample, *sdk.Server) {
+	s := &sample{}
+	srv, err := sdk.NewServer("127.0.0.1:0", s.info(), tmpl.SupportedTmplInfo, test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("NewServer() => unexpected error: %v", err)
+	}
+	go func() {
+		_ = srv.Run()
+	}()
+	return s, srv
+}
+
+func buildHandler(t *testing.T, address string) *handler {
+	info := GetInfo()
+	b := info.NewBuilder().(*builder)
+	b.SetAdapterConfig(&config.Params{
+		Address: address,
+		Params: &types.Struct{Fields: map[string]*types.Value{
+			"prefix": {Kind: &types.Value_StringValue{StringValue: "10."}},
+		}},
+	})
+	b.SetInstanceTypes(metric.TemplateName, map[string]proto.Message{
+		"requestcount.metric.istio-system": &metric.Type{
+			Value:      descriptor.INT64,
+			Dimensions: map[string]descriptor.ValueType{"code": descriptor.INT64},
+		},
+	})
+	b.SetInstanceTypes(listentry.TemplateName, map[string]proto.Message{
+		"source.listentry.istio-system": &listentry.Type{},
+	})
+
+	if ce := b.Validate(); ce != nil {
+		t.Fatalf("Validate() => unexpected error: %v", ce)
+	}
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Build() => unexpected error: %v", err)
+	}
+	return h.(*handler)
+}
+
+func TestRemote(t *testing.T) {
+	s, srv := startSample(t)
+	defer func() { _ = srv.Close() }()
+
+	h := buildHandler(t, srv.Addr().String())
+	defer func() { _ = h.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	metricInfo := tmpl.SupportedTmplInfo[metric.TemplateName]
+	instances := []interface{}{
+		&metric.Instance{
+			Name:       "requestcount.metric.istio-system",
+			Value:      int64(1),
+			Dimensions: map[string]interface{}{"code": int64(200)},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if err := h.HandleRemoteReport(ctx, &metricInfo, instances); err != nil {
+			t.Fatalf("HandleRemoteReport() => unexpected error: %v", err)
+		}
+	}
+
+	err := h.HandleRemoteReport(ctx, &metricInfo, []interface{}{&metric.Instance{Name: "fail"}})
+	if err == nil || !strings.Contains(err.Error(), "metric failure") {
+		t.Errorf("HandleRemoteReport() => got error %v, want metric failure", err)
+	}
+
+	s.Lock()
+	if s.builds != 1 {
+		t.Errorf("got %d handler builds, want 1", s.builds)
+	}
+	want := []*metric.Instance{instances[0].(*metric.Instance), instances[0].(*metric.Instance)}
+	if !reflect.DeepEqual(s.metrics, want) {
+		t.Errorf("got metrics %v, want %v", s.metrics, want)
+	}
+	wantTypes := map[string]*metric.Type{
+		"requestcount.metric.istio-system": {
+			Value:      descriptor.INT64,
+			Dimensions: map[string]descriptor.ValueType{"code": descriptor.INT64},
+		},
+	}
+	if !reflect.DeepEqual(s.metricTypes, wantTypes) {
+		t.Errorf("got metric types %v, want %v", s.metricTypes, wantTypes)
+	}
+	s.Unlock()
+
+	listentryInfo := tmpl.SupportedTmplInfo[listentry.TemplateName]
+	checks := []struct {
+		value string
+		code  rpc.Code
+	}{
+		{"10.0.0.1", rpc.PERMISSION_DENIED},
+		{"192.168.0.1", rpc.OK},
+	}
+	for _, c := range checks {
+		r, err := h.HandleRemoteCheck(ctx, &listentryInfo, &listentry.Instance{Name: "source.listentry.istio-system", Value: c.value})
+		if err != nil {
+			t.Fatalf("HandleRemoteCheck(%s) => unexpected error: %v", c.value, err)
+		}
+		if r.Status.Code != int32(c.code) || r.ValidDuration != 5*time.Second || r.ValidUseCount != 3 {
+			t.Errorf("HandleRemoteCheck(%s) => got %v, want code %v", c.value, r, c.code)
+		}
+	}
+
+	quotaInfo := tmpl.SupportedTmplInfo[quota.TemplateName]
+	args := adapter.QuotaArgs{DeduplicationID: "dedup", QuotaAmount: 10, BestEffort: true}
+	qr, err := h.HandleRemoteQuota(ctx, &quotaInfo, &quota.Instance{Name: "requestcount.quota.istio-system"}, args)
+	if err != nil {
+		t.Fatalf("HandleRemoteQuota() => unexpected error: %v", err)
+	}
+	if qr.Amount != 5 || qr.ValidDuration != time.Minute {
+		t.Errorf("HandleRemoteQuota() => got %v, want amount 5", qr)
+	}
+
+	s.Lock()
+	if !reflect.DeepEqual(s.dedupIDs, []string{"dedup"}) {
+		t.Errorf("got dedup IDs %v, want [dedup]", s.dedupIDs)
+	}
+	// one handler for each template, as each template has its own instance types.
+	if s.builds != 3 {
+		t.Errorf("got %d handler builds, want 3", s.builds)
+	}
+	s.Unlock()
+}
+
+func TestUnsupportedTemplate(t *testing.T) {
+	_, srv := startSample(t)
+	defer func() { _ = srv.Close() }()
+
+	h := buildHandler(t, srv.Addr().String())
+	defer func() { _ = h.Close() }()
+
+	// The sample adapter does not implement the HandlerService of the checknothing template.
+	ti := tmpl.SupportedTmplInfo["checknothing"]
+	if _, err := h.HandleRemoteCheck(context.Background(), &ti, ti.NewInstance()); err == nil {
+		t.Error("HandleRemoteCheck() => got no error for an unsupported template")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	_ = l.Close()
+
+	h := buildHandler(t, address)
+	defer func() { _ = h.Close() }()
+
+	ti := tmpl.SupportedTmplInfo[metric.TemplateName]
+	if err = h.HandleRemoteReport(context.Background(), &ti, []interface{}{&metric.Instance{}}); err == nil {
+		t.Error("HandleRemoteReport() => got no error for an unavailable adapter")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	b := GetInfo().NewBuilder()
+	b.SetAdapterConfig(&config.Params{})
+	ce := b.Validate()
+	if ce == nil || !strings.Contains(ce.Error(), "address") {
+		t.Errorf("Validate() => got %v, want address error", ce)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	_, srv := startSample(t)
+	defer func() { _ = srv.Close() }()
+
+	test.RunConformance(t, test.Conformance{
+		Info:           GetInfo(),
+		Config:         &config.Params{Address: srv.Addr().String()},
+		InvalidConfigs: []adapter.Config{&config.Params{}},
+		Types: map[string]map[string]proto.Message{
+			metric.TemplateName: {
+				"requestcount.metric.istio-system": &metric.Type{
+					Value:      descriptor.INT64,
+					Dimensions: map[string]descriptor.ValueType{"code": descriptor.INT64},
+				},
+			},
+			listentry.TemplateName: {"source.listentry.istio-system": &listentry.Type{}},
+			quota.TemplateName:     {"requestcount.quota.istio-system": &quota.Type{}},
+		},
+		Instances: map[string][]interface{}{
+			metric.TemplateName: {
+				&metric.Instance{
+					Name:       "requestcount.metric.istio-system",
+					Value:      int64(1),
+					Dimensions: map[string]interface{}{"code": int64(200)},
+				},
+			},
+			listentry.TemplateName: {&listentry.Instance{Name: "source.listentry.istio-system", Value: "192.168.0.1"}},
+			quota.TemplateName:     {&quota.Instance{Name: "requestcount.quota.istio-system"}},
+		},
+		Templates: tmpl.SupportedTmplInfo,
+	})
+}
+
+func TestGetInfo(t *testing.T) {
+	info := GetInfo()
+	if _, ok := info.NewBuilder().(sdk.Builder); !ok {
+		t.Error("builder does not implement remote.Builder")
+	}
+	if len(info.SupportedTemplates) != 0 {
+		t.Errorf("got supported templates %v, want none", info.SupportedTemplates)
+	}
+}
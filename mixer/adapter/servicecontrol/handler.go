@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"time"
 
 	sc "google.golang.org/api/servicecontrol/v1"
 
@@ -77,6 +78,10 @@ type (
 		// Istio mesh service name to serviceProcessor map. Each serviceProcessor instance handles a single
 		// service.
 		svcProcMap map[string]*serviceProcessor
+
+		// done is closed when the handler is closed, to stop the eviction of the expired check responses.
+		done      chan struct{}
+		closeOnce sync.Once
 	}
 )
 
@@ -140,15 +145,34 @@ func (h *handler) HandleQuota(ctx context.Context, instance *quota.Instance,
 // Close closes a handler.
 // TODO(manlinl): Run svcProc.Close in goroutine after reportProcessor implements buffering.
 func (h *handler) Close() error {
-	h.lock.Lock()
-	defer h.lock.Lock()
-	for _, svcProc := range h.svcProcMap {
-		// TODO: handle Close errors
-		_ = svcProc.Close()
-	}
+	h.closeOnce.Do(func() {
+		close(h.done)
+
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		for _, svcProc := range h.svcProcMap {
+			// TODO: handle Close errors
+			_ = svcProc.Close()
+		}
+	})
 	return nil
 }
 
+// evictExpired periodically evicts the expired check responses, until the handler is closed.
+func (h *handler) evictExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.ctx.checkResponseCache.EvictExpired()
+		case <-h.done:
+			return
+		}
+	}
+}
+
 func (h *handler) getServiceProcessor(ctx context.Context) (*serviceProcessor, error) {
 	requestData, ok := adapter.RequestDataFromContext(ctx)
 	if !ok {
@@ -179,5 +203,6 @@ func newHandler(ctx *handlerContext) (*handler, error) {
 	return &handler{
 		ctx:        ctx,
 		svcProcMap: make(map[string]*serviceProcessor, len(ctx.config.ServiceConfigs)),
+		done:       make(chan struct{}),
 	}, nil
 }
@@ -39,6 +39,9 @@ type builder struct {
 	checkDataShape  map[string]*apikey.Type
 	reportDataShape map[string]*servicecontrolreport.Type
 	quotaDataShape  map[string]*quota.Type
+
+	// creates the service control client, replaced for testing purposes
+	newClientFn func(credentialPath string) (serviceControlClient, error)
 }
 
 ////// Builder method from supported template //////
@@ -142,7 +145,7 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 	var _ servicecontrolreport.HandlerBuilder = (*builder)(nil)
 	var _ quota.HandlerBuilder = (*builder)(nil)
 
-	client, err := newClient(b.config.CredentialPath)
+	client, err := b.newClientFn(b.config.CredentialPath)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +156,16 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 	}
 	ctx.checkDataShape = b.checkDataShape
 	ctx.reportDataShape = b.reportDataShape
-	return newHandler(ctx)
+	h, err := newHandler(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan and evict the expired check responses every half of the expiration time period.
+	if evictionInterval := toDuration(b.config.RuntimeConfig.CheckResultExpiration) / 2; evictionInterval > 0 {
+		env.ScheduleDaemon(func() { h.evictExpired(evictionInterval) })
+	}
+	return h, nil
 }
 
 func initializeHandlerContext(env adapter.Env, adapterCfg *config.Params,
@@ -165,9 +177,9 @@ func initializeHandlerContext(env adapter.Env, adapterCfg *config.Params,
 	}
 
 	cacheExp := toDuration(adapterCfg.RuntimeConfig.CheckResultExpiration)
-	// Set eviction interval to half of expiration time. That said the cache would scan and evict expired entries every
-	// half of expiation time period.
-	checkCache := cache.NewLRU(cacheExp, cacheExp/2, adapterCfg.RuntimeConfig.CheckCacheSize)
+	// Eviction is left to the handler (see handler.evictExpired), as the evicter goroutine of the cache would outlive
+	// the handler until the cache is garbage collected.
+	checkCache := cache.NewLRU(cacheExp, 0, adapterCfg.RuntimeConfig.CheckCacheSize)
 
 	return &handlerContext{
 		env:                env,
@@ -190,6 +202,6 @@ func GetInfo() adapter.Info {
 			quota.TemplateName,
 		},
 		DefaultConfig: &config.Params{},
-		NewBuilder:    func() adapter.HandlerBuilder { return &builder{} },
+		NewBuilder:    func() adapter.HandlerBuilder { return &builder{newClientFn: newClient} },
 	}
 }
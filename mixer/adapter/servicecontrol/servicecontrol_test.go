@@ -15,13 +15,18 @@
 package servicecontrol
 
 import (
+	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	pbtypes "github.com/gogo/protobuf/types"
+	"google.golang.org/api/googleapi"
+	sc "google.golang.org/api/servicecontrol/v1"
 
 	"istio.io/istio/mixer/adapter/servicecontrol/config"
 	"istio.io/istio/mixer/adapter/servicecontrol/template/servicecontrolreport"
+	"istio.io/istio/mixer/pkg/adapter"
 	at "istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/apikey"
 	"istio.io/istio/mixer/template/quota"
@@ -124,6 +129,73 @@ func TestGetInfo(t *testing.T) {
 	}
 }
 
+// conformanceClient is a service control client that accepts all requests. Unlike mockSvcctrlClient, it can be
+// used concurrently.
+type conformanceClient struct{}
+
+var okResponse = googleapi.ServerResponse{HTTPStatusCode: http.StatusOK}
+
+func (conformanceClient) Check(string, *sc.CheckRequest) (*sc.CheckResponse, error) {
+	return &sc.CheckResponse{ServerResponse: okResponse}, nil
+}
+
+func (conformanceClient) Report(string, *sc.ReportRequest) (*sc.ReportResponse, error) {
+	return &sc.ReportResponse{ServerResponse: okResponse}, nil
+}
+
+func (conformanceClient) AllocateQuota(string, *sc.AllocateQuotaRequest) (*sc.AllocateQuotaResponse, error) {
+	return &sc.AllocateQuotaResponse{ServerResponse: okResponse}, nil
+}
+
+func TestConformance(t *testing.T) {
+	info := GetInfo()
+	info.NewBuilder = func() adapter.HandlerBuilder {
+		return &builder{newClientFn: func(string) (serviceControlClient, error) {
+			return conformanceClient{}, nil
+		}}
+	}
+
+	invalid := getTestAdapterConfig()
+	invalid.ServiceConfigs = nil
+
+	now := time.Now()
+	at.RunConformance(t, at.Conformance{
+		Info:           info,
+		Config:         getTestAdapterConfig(),
+		InvalidConfigs: []adapter.Config{&config.Params{}, invalid},
+		Instances: map[string][]interface{}{
+			apikey.TemplateName: {
+				&apikey.Instance{Name: "apikey", ApiKey: "test_key", ApiOperation: "echo", Timestamp: now},
+			},
+			servicecontrolreport.TemplateName: {
+				&servicecontrolreport.Instance{
+					Name:            "report",
+					ApiVersion:      "v1.0",
+					ApiOperation:    "echo",
+					ApiProtocol:     "REST",
+					ApiService:      "echo.test.com",
+					ApiKey:          "test_key",
+					RequestTime:     now,
+					RequestMethod:   "POST",
+					RequestPath:     "echo.test.com/echo",
+					RequestBytes:    10,
+					ResponseTime:    now.Add(time.Microsecond),
+					ResponseCode:    200,
+					ResponseBytes:   1024,
+					ResponseLatency: time.Microsecond,
+				},
+			},
+			quota.TemplateName: {
+				&quota.Instance{
+					Name:       "request-count",
+					Dimensions: map[string]interface{}{"api_key": "test_key", "api_operation": "echo"},
+				},
+			},
+		},
+		RequestData: &adapter.RequestData{DestinationService: adapter.Service{FullName: "service_a"}},
+	})
+}
+
 func getTestAdapterConfig() *config.Params {
 	return &config.Params{
 		RuntimeConfig: &config.RuntimeConfig{CheckCacheSize: 10,
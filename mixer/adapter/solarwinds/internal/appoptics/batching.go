@@ -28,23 +28,31 @@ func BatchMeasurements(loopFactor *bool, prepChan <-chan []*Measurement,
 	dobrk := false
 	ticker := time.NewTicker(time.Millisecond * 500)
 	defer ticker.Stop()
+
+	// push hands a batch over to the persisting loop, unless stopped.
+	push := func(batch []*Measurement) {
+		select {
+		case pushChan <- batch:
+		case <-stopChan:
+			dobrk = true
+		}
+	}
+
 	for *loopFactor {
 		select {
 		case mslice := <-prepChan:
 			currentBatch = append(currentBatch, mslice...)
 			if len(currentBatch) >= batchSize {
-				pushBatch := currentBatch[:batchSize]
-				pushChan <- pushBatch
+				push(currentBatch[:batchSize])
 				currentBatch = currentBatch[batchSize:]
 			}
 		case <-ticker.C: // to drain based on time as well
 			if len(currentBatch) > 0 {
 				if len(currentBatch) >= batchSize {
-					pushBatch := currentBatch[:batchSize]
-					pushChan <- pushBatch
+					push(currentBatch[:batchSize])
 					currentBatch = currentBatch[batchSize:]
 				} else {
-					pushChan <- currentBatch
+					push(currentBatch)
 					currentBatch = []*Measurement{}
 				}
 			}
@@ -67,9 +75,13 @@ func PersistBatches(loopFactor *bool, lc ServiceAccessor, pushChan <-chan []*Mea
 	for *loopFactor {
 		select {
 		case <-ticker.C:
-			batch := <-pushChan
-			if err := persistBatch(lc, batch, logger); err != nil {
-				_ = logger.Errorf("metric persistence errors: %v", err)
+			select {
+			case batch := <-pushChan:
+				if err := persistBatch(lc, batch, logger); err != nil {
+					_ = logger.Errorf("metric persistence errors: %v", err)
+				}
+			case <-stopChan:
+				dobrk = true
 			}
 		case <-stopChan:
 			dobrk = true
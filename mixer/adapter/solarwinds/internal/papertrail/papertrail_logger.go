@@ -69,7 +69,8 @@ type Logger struct {
 
 	maxWorkers int
 
-	loopFactor bool
+	// stop is closed to stop flushing the logs.
+	stop chan struct{}
 
 	loopWait chan struct{}
 }
@@ -91,7 +92,7 @@ func NewLogger(paperTrailURL string, retention time.Duration, logConfigs map[str
 		log:             logger,
 		env:             env,
 		maxWorkers:      defaultWorkerCount * runtime.NumCPU(),
-		loopFactor:      true,
+		stop:            make(chan struct{}),
 		loopWait:        make(chan struct{}),
 	}
 
@@ -125,13 +126,18 @@ func (p *Logger) Log(msg *logentry.Instance) error {
 		return p.log.Errorf("Got an unknown instance of log: %s. Hence Skipping.", msg.Name)
 	}
 	buf := pool.GetBuffer()
-	msg.Variables["timestamp"] = time.Now()
-	ipval, ok := msg.Variables["originIp"].([]byte)
+	// The instance may be shared with other handlers, so its variables are not modified.
+	variables := make(map[string]interface{}, len(msg.Variables)+1)
+	for k, v := range msg.Variables {
+		variables[k] = v
+	}
+	variables["timestamp"] = time.Now()
+	ipval, ok := variables["originIp"].([]byte)
 	if ok {
-		msg.Variables["originIp"] = net.IP(ipval).String()
+		variables["originIp"] = net.IP(ipval).String()
 	}
 
-	if err := linfo.tmpl.Execute(buf, msg.Variables); err != nil {
+	if err := linfo.tmpl.Execute(buf, variables); err != nil {
 		_ = p.log.Errorf("failed to execute template for log '%s': %v", msg.Name, err)
 		// proceeding anyways
 	}
@@ -160,12 +166,11 @@ func (p *Logger) sendLogs(data string) error {
 
 // This should be run in a routine
 func (p *Logger) flushLogs() {
-	var err error
 	defer func() {
 		p.loopWait <- struct{}{}
 	}()
 	re := regexp.MustCompile(keyPattern)
-	for p.loopFactor {
+	for {
 		hose := make(chan interface{}, p.maxWorkers)
 		var wg sync.WaitGroup
 
@@ -176,7 +181,7 @@ func (p *Logger) flushLogs() {
 					key, _ := keyI.(string)
 					match := re.FindStringSubmatch(key)
 					if len(match) > 2 {
-						if err = p.sendLogs(match[2]); err == nil {
+						if err := p.sendLogs(match[2]); err == nil {
 							p.cmap.Delete(key)
 							wg.Done()
 							continue
@@ -201,13 +206,18 @@ func (p *Logger) flushLogs() {
 		})
 		wg.Wait()
 		close(hose)
-		time.Sleep(500 * time.Millisecond)
+
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
 	}
 }
 
 // Close - closes the Logger instance
 func (p *Logger) Close() error {
-	p.loopFactor = false
+	close(p.stop)
 	defer close(p.loopWait)
 	<-p.loopWait
 	return nil
@@ -67,7 +67,6 @@ func TestNewLogger(t *testing.T) {
 }
 
 func TestLog(t *testing.T) {
-	loopFactor := true
 	t.Run("No log info for msg name", func(t *testing.T) {
 		env := test.NewEnv(t)
 		logger := env.Logger()
@@ -79,7 +78,6 @@ func TestLog(t *testing.T) {
 			log:           logger,
 			env:           env,
 			logInfos:      map[string]*logInfo{},
-			loopFactor:    loopFactor,
 		}
 
 		if pp.Log(&logentry.Instance{
@@ -121,16 +121,14 @@ func (h *metricsHandler) handleMetric(_ context.Context, vals []*metric.Instance
 }
 
 func (h *metricsHandler) close() error {
-	close(h.prepChan)
-	close(h.pushChan)
+	// The batching and persisting loops are stopped before closing the channels they use.
 	close(h.stopChan)
-	defer close(h.batchWait)
-	defer close(h.persistWait)
-	*h.loopFactor = false
 	if h.lc != nil {
 		<-h.batchWait
 		<-h.persistWait
 	}
+	close(h.prepChan)
+	close(h.pushChan)
 	return nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sync"
 
 	"istio.io/istio/mixer/adapter/solarwinds/config"
 	"istio.io/istio/mixer/pkg/adapter"
@@ -40,6 +41,7 @@ type (
 		logger         adapter.Logger
 		metricsHandler metricsHandlerInterface
 		logHandler     logHandlerInterface
+		closeOnce      sync.Once
 	}
 )
 
@@ -139,11 +141,13 @@ func (h *handler) HandleLogEntry(ctx context.Context, values []*logentry.Instanc
 }
 
 func (h *handler) Close() error {
-	if h.metricsHandler != nil {
-		_ = h.metricsHandler.close()
-	}
-	if h.logHandler != nil {
-		_ = h.logHandler.close()
-	}
+	h.closeOnce.Do(func() {
+		if h.metricsHandler != nil {
+			_ = h.metricsHandler.close()
+		}
+		if h.logHandler != nil {
+			_ = h.logHandler.close()
+		}
+	})
 	return nil
 }
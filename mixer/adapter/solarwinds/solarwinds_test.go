@@ -13,3 +13,35 @@
 // limitations under the License.
 
 package solarwinds
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/adapter/solarwinds/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/template/logentry"
+	"istio.io/istio/mixer/template/metric"
+)
+
+func TestConformance(t *testing.T) {
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		// No metric is configured, so that the batching and persisting loops of the handler run without sending
+		// anything to AppOptics.
+		Config: &config.Params{AppopticsAccessToken: "token"},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{AppopticsBatchSize: -1},
+			&config.Params{PapertrailUrl: "hello.world.org"},
+		},
+		Instances: map[string][]interface{}{
+			metric.TemplateName: {
+				&metric.Instance{Name: "requestcount", Value: int64(1)},
+			},
+			logentry.TemplateName: {
+				&logentry.Instance{Name: "accesslog", Variables: map[string]interface{}{"user": "alice"}, Timestamp: time.Now()},
+			},
+		},
+	})
+}
@@ -17,6 +17,7 @@ package log
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -26,11 +27,17 @@ import (
 	"time"
 
 	"cloud.google.com/go/logging"
+	"github.com/gogo/protobuf/proto"
 	"golang.org/x/net/context"
 	"google.golang.org/api/option"
 	"google.golang.org/genproto/googleapis/api/monitoredres"
+	loggingpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+
+	descriptor "istio.io/api/mixer/v1/config/descriptor"
 
 	"istio.io/istio/mixer/adapter/stackdriver/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/logentry"
 )
@@ -284,3 +291,66 @@ func TestHandleLogEntry_Errors(t *testing.T) {
 		})
 	}
 }
+
+// loggingServer accepts the log entries of the conformance test.
+type loggingServer struct {
+	loggingpb.LoggingServiceV2Server
+}
+
+func (loggingServer) WriteLogEntries(context.Context, *loggingpb.WriteLogEntriesRequest) (*loggingpb.WriteLogEntriesResponse, error) {
+	return &loggingpb.WriteLogEntriesResponse{}, nil
+}
+
+func TestConformance(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	loggingpb.RegisterLoggingServiceV2Server(srv, loggingServer{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	info := adapter.Info{
+		Name:               "stackdriver-log",
+		Impl:               "istio.io/istio/mixer/adapter/stackdriver/log",
+		Description:        "Publishes StackDriver logs.",
+		SupportedTemplates: []string{logentry.TemplateName},
+		DefaultConfig:      &config.Params{},
+		NewBuilder: func() adapter.HandlerBuilder {
+			return &builder{makeClient: func(ctx context.Context, projectID string, _ ...option.ClientOption) (*logging.Client, error) {
+				conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+				if err != nil {
+					return nil, err
+				}
+				return logging.NewClient(ctx, projectID, option.WithGRPCConn(conn))
+			}}
+		},
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info: info,
+		Config: &config.Params{
+			ProjectId: "project",
+			LogInfo: map[string]*config.Params_LogInfo{
+				"accesslog": {PayloadTemplate: "{{.method}} {{.url}}", LabelNames: []string{"method"}},
+			},
+		},
+		Types: map[string]map[string]proto.Message{
+			logentry.TemplateName: {
+				"accesslog": &logentry.Type{
+					Variables: map[string]descriptor.ValueType{"method": descriptor.STRING, "url": descriptor.STRING},
+				},
+			},
+		},
+		Instances: map[string][]interface{}{
+			logentry.TemplateName: {
+				&logentry.Instance{
+					Name:      "accesslog",
+					Severity:  "INFO",
+					Variables: map[string]interface{}{"method": "GET", "url": "/index.html"},
+				},
+			},
+		},
+	})
+}
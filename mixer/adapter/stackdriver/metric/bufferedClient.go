@@ -40,17 +40,27 @@ type buffered struct {
 	pushMetrics pushFunc
 	l           adapter.Logger
 
-	closeMe io.Closer
+	closeMe   io.Closer
+	stop      chan struct{}
+	closeOnce sync.Once
 
 	// Guards buffer
 	m      sync.Mutex
 	buffer []*monitoringpb.TimeSeries
 }
 
+// start sends the buffered data on every tick, until the client is closed. Stopping the ticker does not close
+// its channel, so the loop has to watch the client instead.
 func (b *buffered) start(env adapter.Env, ticker *time.Ticker) {
+	b.stop = make(chan struct{})
 	env.ScheduleDaemon(func() {
-		for range ticker.C {
-			b.Send()
+		for {
+			select {
+			case <-ticker.C:
+				b.Send()
+			case <-b.stop:
+				return
+			}
 		}
 	})
 }
@@ -90,8 +100,14 @@ func (b *buffered) Send() {
 	}
 }
 
-func (b *buffered) Close() error {
-	b.l.Infof("Sending last data before shutting down")
-	b.Send()
-	return b.closeMe.Close()
+func (b *buffered) Close() (err error) {
+	b.closeOnce.Do(func() {
+		if b.stop != nil {
+			close(b.stop)
+		}
+		b.l.Infof("Sending last data before shutting down")
+		b.Send()
+		err = b.closeMe.Close()
+	})
+	return err
 }
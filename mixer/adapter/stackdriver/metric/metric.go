@@ -142,17 +142,18 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 	}
 
 	// Per the documentation on config.proto, if push_interval is zero we'll default to a 1 minute push interval
-	if cfg.PushInterval == time.Duration(0) {
-		cfg.PushInterval = 1 * time.Minute
+	pushInterval := cfg.PushInterval
+	if pushInterval == time.Duration(0) {
+		pushInterval = 1 * time.Minute
 	}
 
-	ticker := time.NewTicker(cfg.PushInterval)
-
 	var err error
 	var client *monitoring.MetricClient
 	if client, err = b.createClient(cfg); err != nil {
 		return nil, err
 	}
+
+	ticker := time.NewTicker(pushInterval)
 	buffered := &buffered{
 		pushMetrics: client.CreateTimeSeries,
 		closeMe:     client,
@@ -17,20 +17,26 @@ package metric
 import (
 	"context"
 	"fmt"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"github.com/gogo/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/api/option"
 	"google.golang.org/genproto/googleapis/api/distribution"
 	metricpb "google.golang.org/genproto/googleapis/api/metric"
 	"google.golang.org/genproto/googleapis/api/monitoredres"
 	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/grpc"
 
 	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/stackdriver/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	metrict "istio.io/istio/mixer/template/metric"
 )
@@ -350,3 +356,63 @@ func TestRecord(t *testing.T) {
 		})
 	}
 }
+
+// metricServer accepts the time series of the conformance test.
+type metricServer struct {
+	monitoringpb.MetricServiceServer
+}
+
+func (metricServer) CreateTimeSeries(context.Context, *monitoringpb.CreateTimeSeriesRequest) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+func TestConformance(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	monitoringpb.RegisterMetricServiceServer(srv, metricServer{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	info := adapter.Info{
+		Name:               "stackdriver-metric",
+		Impl:               "istio.io/istio/mixer/adapter/stackdriver/metric",
+		Description:        "Publishes StackDriver metrics.",
+		SupportedTemplates: []string{metrict.TemplateName},
+		DefaultConfig:      &config.Params{},
+		NewBuilder: func() adapter.HandlerBuilder {
+			return &builder{createClient: func(*config.Params) (*monitoring.MetricClient, error) {
+				conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+				if err != nil {
+					return nil, err
+				}
+				return monitoring.NewMetricClient(context.Background(), option.WithGRPCConn(conn))
+			}}
+		},
+	}
+
+	test.RunConformance(t, test.Conformance{
+		Info: info,
+		Config: &config.Params{
+			ProjectId: "project",
+			MetricInfo: map[string]*config.Params_MetricInfo{
+				"requestcount": {Kind: metricpb.MetricDescriptor_CUMULATIVE, Value: metricpb.MetricDescriptor_INT64},
+			},
+		},
+		Types: map[string]map[string]proto.Message{
+			metrict.TemplateName: {
+				"requestcount": &metrict.Type{
+					Value:      descriptor.INT64,
+					Dimensions: map[string]descriptor.ValueType{"code": descriptor.INT64},
+				},
+			},
+		},
+		Instances: map[string][]interface{}{
+			metrict.TemplateName: {
+				&metrict.Instance{Name: "requestcount", Value: int64(1), Dimensions: map[string]interface{}{"code": int64(200)}},
+			},
+		},
+	})
+}
@@ -20,6 +20,7 @@ package stackdriver
 
 import (
 	"context"
+	"sync"
 
 	multierror "github.com/hashicorp/go-multierror"
 
@@ -40,6 +41,8 @@ type (
 	handler struct {
 		m metric.Handler
 		l logentry.Handler
+
+		closeOnce sync.Once
 	}
 )
 
@@ -55,7 +58,7 @@ var (
 func GetInfo() adapter.Info {
 	return adapter.Info{
 		Name:        "stackdriver",
-		Impl:        "istio.io/istio/mixer/adapte/stackdriver",
+		Impl:        "istio.io/istio/mixer/adapter/stackdriver",
 		Description: "Publishes StackDriver metrics and logs.",
 		SupportedTemplates: []string{
 			metric.TemplateName,
@@ -92,6 +95,7 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 
 	l, err := b.l.Build(ctx, env)
 	if err != nil {
+		_ = m.Close()
 		return nil, err
 	}
 	lh, _ := l.(logentry.Handler)
@@ -99,8 +103,11 @@ func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler,
 	return &handler{m: mh, l: lh}, nil
 }
 
-func (h *handler) Close() error {
-	return multierror.Append(h.m.Close(), h.l.Close()).ErrorOrNil()
+func (h *handler) Close() (err error) {
+	h.closeOnce.Do(func() {
+		err = multierror.Append(h.m.Close(), h.l.Close()).ErrorOrNil()
+	})
+	return err
 }
 
 func (h *handler) HandleMetric(ctx context.Context, values []*metric.Instance) error {
@@ -53,7 +53,10 @@ const (
 	UNKNOWN      Params_MetricInfo_Type = 0
 	COUNTER      Params_MetricInfo_Type = 1
 	GAUGE        Params_MetricInfo_Type = 2
+	// Reported as a statsd timer, or as a DogStatsD distribution (`d`) when `dogstatsd_tags` is set.
 	DISTRIBUTION Params_MetricInfo_Type = 3
+	// Reported as a histogram (`h`).
+	HISTOGRAM Params_MetricInfo_Type = 4
 )
 
 var Params_MetricInfo_Type_name = map[int32]string{
@@ -61,12 +64,14 @@ var Params_MetricInfo_Type_name = map[int32]string{
 	1: "COUNTER",
 	2: "GAUGE",
 	3: "DISTRIBUTION",
+	4: "HISTOGRAM",
 }
 var Params_MetricInfo_Type_value = map[string]int32{
 	"UNKNOWN":      0,
 	"COUNTER":      1,
 	"GAUGE":        2,
 	"DISTRIBUTION": 3,
+	"HISTOGRAM":    4,
 }
 
 func (Params_MetricInfo_Type) EnumDescriptor() ([]byte, []int) {
@@ -89,6 +94,10 @@ type Params struct {
 	SamplingRate float32 `protobuf:"fixed32,5,opt,name=sampling_rate,json=samplingRate,proto3" json:"sampling_rate,omitempty"`
 	// Map of metric name -> info. If a metric's name is not in the map then the metric will not be exported to statsd.
 	Metrics map[string]*Params_MetricInfo `protobuf:"bytes,6,rep,name=metrics" json:"metrics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
+	// If true, the metric's dimensions are attached to each value as DogStatsD-style tags, e.g.
+	// `request_count:1|c|#response_code:200,source:productpage`. Only enable this when the backend
+	// understands the DogStatsD protocol extensions.
+	DogstatsdTags bool `protobuf:"varint,7,opt,name=dogstatsd_tags,json=dogstatsdTags,proto3" json:"dogstatsd_tags,omitempty"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
@@ -106,6 +115,9 @@ type Params_MetricInfo struct {
 	//
 	// If name_template is the empty string the Istio metric name will be used for statsd metric's name.
 	NameTemplate string `protobuf:"bytes,2,opt,name=name_template,json=nameTemplate,proto3" json:"name_template,omitempty"`
+	// Chance that any particular value of this metric is sampled; can take the range [0, 1]. If unspecified
+	// the adapter-wide `sampling_rate` is used.
+	SamplingRate float32 `protobuf:"fixed32,3,opt,name=sampling_rate,json=samplingRate,proto3" json:"sampling_rate,omitempty"`
 }
 
 func (m *Params_MetricInfo) Reset()                    { *m = Params_MetricInfo{} }
@@ -198,6 +210,16 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 			}
 		}
 	}
+	if m.DogstatsdTags {
+		dAtA[i] = 0x38
+		i++
+		if m.DogstatsdTags {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -227,6 +249,12 @@ func (m *Params_MetricInfo) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintConfig(dAtA, i, uint64(len(m.NameTemplate)))
 		i += copy(dAtA[i:], m.NameTemplate)
 	}
+	if m.SamplingRate != 0 {
+		dAtA[i] = 0x1d
+		i++
+		encoding_binary.LittleEndian.PutUint32(dAtA[i:], uint32(math.Float32bits(float32(m.SamplingRate))))
+		i += 4
+	}
 	return i, nil
 }
 
@@ -271,6 +299,9 @@ func (m *Params) Size() (n int) {
 			n += mapEntrySize + 1 + sovConfig(uint64(mapEntrySize))
 		}
 	}
+	if m.DogstatsdTags {
+		n += 2
+	}
 	return n
 }
 
@@ -284,6 +315,9 @@ func (m *Params_MetricInfo) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovConfig(uint64(l))
 	}
+	if m.SamplingRate != 0 {
+		n += 5
+	}
 	return n
 }
 
@@ -321,6 +355,7 @@ func (this *Params) String() string {
 		`FlushBytes:` + fmt.Sprintf("%v", this.FlushBytes) + `,`,
 		`SamplingRate:` + fmt.Sprintf("%v", this.SamplingRate) + `,`,
 		`Metrics:` + mapStringForMetrics + `,`,
+		`DogstatsdTags:` + fmt.Sprintf("%v", this.DogstatsdTags) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -332,6 +367,7 @@ func (this *Params_MetricInfo) String() string {
 	s := strings.Join([]string{`&Params_MetricInfo{`,
 		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
 		`NameTemplate:` + fmt.Sprintf("%v", this.NameTemplate) + `,`,
+		`SamplingRate:` + fmt.Sprintf("%v", this.SamplingRate) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -614,6 +650,26 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 			}
 			m.Metrics[mapkey] = mapvalue
 			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DogstatsdTags", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DogstatsdTags = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -712,6 +768,17 @@ func (m *Params_MetricInfo) Unmarshal(dAtA []byte) error {
 			}
 			m.NameTemplate = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 5 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplingRate", wireType)
+			}
+			var v uint32
+			if (iNdEx + 4) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint32(encoding_binary.LittleEndian.Uint32(dAtA[iNdEx:]))
+			iNdEx += 4
+			m.SamplingRate = float32(math.Float32frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -841,36 +908,39 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/statsd/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 494 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0xbd, 0x8e, 0xd3, 0x40,
-	0x10, 0xf6, 0xe6, 0xef, 0xb8, 0x4d, 0xee, 0x64, 0xad, 0x00, 0x99, 0x14, 0x9b, 0xe8, 0x68, 0x2c,
-	0x24, 0xd6, 0x52, 0x68, 0x4e, 0x48, 0x20, 0x5d, 0x48, 0x74, 0x0a, 0x08, 0x07, 0x2d, 0x8e, 0x90,
-	0x68, 0xa2, 0xcd, 0x79, 0x6d, 0x2c, 0xfc, 0xa7, 0xf5, 0x06, 0x9d, 0x3b, 0x1e, 0x81, 0x92, 0x47,
-	0xa0, 0xa2, 0xe1, 0x25, 0x52, 0x5e, 0x49, 0x05, 0xc4, 0x34, 0x94, 0xf7, 0x08, 0xc8, 0x5e, 0x5b,
-	0xa2, 0xa0, 0xb8, 0xca, 0x33, 0xdf, 0x7c, 0xdf, 0xcc, 0x37, 0xe3, 0x85, 0x66, 0x14, 0x5c, 0x72,
-	0x61, 0x31, 0x97, 0xa5, 0x92, 0x0b, 0x2b, 0x93, 0x4c, 0x66, 0xae, 0x75, 0x91, 0xc4, 0x5e, 0xe0,
-	0xd7, 0x1f, 0x92, 0x8a, 0x44, 0x26, 0xe8, 0x4e, 0xcd, 0x21, 0x8a, 0x43, 0x54, 0x71, 0x88, 0xfd,
-	0x24, 0xf1, 0x43, 0x6e, 0x55, 0xa4, 0xcd, 0xd6, 0xb3, 0xdc, 0xad, 0x60, 0x32, 0x48, 0x62, 0x25,
-	0x1b, 0xde, 0xf6, 0x13, 0x3f, 0xa9, 0x42, 0xab, 0x8c, 0x14, 0x7a, 0xf2, 0xb5, 0x03, 0x7b, 0xaf,
-	0x98, 0x60, 0x51, 0x86, 0x0c, 0x78, 0xc0, 0x5c, 0x57, 0xf0, 0x2c, 0x33, 0xc0, 0x18, 0x98, 0x87,
-	0xb4, 0x49, 0xd1, 0x5d, 0xd8, 0x4b, 0x05, 0xf7, 0x82, 0x4b, 0xa3, 0x55, 0x15, 0xea, 0x0c, 0x3d,
-	0x87, 0xc7, 0x5e, 0xb8, 0xcd, 0xde, 0xad, 0x9b, 0x51, 0x46, 0x7b, 0x0c, 0xcc, 0xfe, 0xe4, 0x1e,
-	0x51, 0x5e, 0x48, 0xe3, 0x85, 0xcc, 0x6a, 0xc2, 0xf4, 0xd6, 0xee, 0xc7, 0x48, 0xfb, 0xfc, 0x73,
-	0x04, 0xe8, 0x51, 0x25, 0x6d, 0x0a, 0x68, 0x04, 0xfb, 0xaa, 0xd7, 0x26, 0x97, 0x3c, 0x33, 0x3a,
-	0x63, 0x60, 0x76, 0x29, 0xac, 0xa0, 0x69, 0x89, 0xa0, 0xfb, 0xf0, 0x28, 0x63, 0x51, 0x1a, 0x06,
-	0xb1, 0xbf, 0x16, 0x4c, 0x72, 0xa3, 0x3b, 0x06, 0x66, 0x8b, 0x0e, 0x1a, 0x90, 0x32, 0xc9, 0xd1,
-	0x0c, 0x1e, 0x44, 0x5c, 0x8a, 0xe0, 0x22, 0x33, 0x7a, 0xe3, 0xb6, 0xd9, 0x9f, 0x3c, 0x20, 0xff,
-	0xbd, 0x16, 0x51, 0x3b, 0x93, 0x97, 0x8a, 0x3c, 0x8f, 0xa5, 0xc8, 0x69, 0x23, 0x1d, 0x7e, 0x03,
-	0x10, 0xaa, 0xca, 0x22, 0xf6, 0x12, 0x74, 0x06, 0x3b, 0x32, 0x4f, 0x79, 0x75, 0x95, 0xe3, 0xc9,
-	0xc3, 0x9b, 0x74, 0x2c, 0x75, 0xc4, 0xc9, 0x53, 0x4e, 0x2b, 0x69, 0x69, 0x3e, 0x66, 0x11, 0x5f,
-	0x4b, 0x1e, 0xa5, 0x61, 0x69, 0x5e, 0x1d, 0x72, 0x50, 0x82, 0x4e, 0x8d, 0x9d, 0x3c, 0x81, 0x9d,
-	0x52, 0x82, 0xfa, 0xf0, 0x60, 0x65, 0xbf, 0xb0, 0x97, 0x6f, 0x6c, 0x5d, 0x2b, 0x93, 0x67, 0xcb,
-	0x95, 0xed, 0xcc, 0xa9, 0x0e, 0xd0, 0x21, 0xec, 0x9e, 0x9f, 0xad, 0xce, 0xe7, 0x7a, 0x0b, 0xe9,
-	0x70, 0x30, 0x5b, 0xbc, 0x76, 0xe8, 0x62, 0xba, 0x72, 0x16, 0x4b, 0x5b, 0x6f, 0x0f, 0x5d, 0x38,
-	0xf8, 0x77, 0x1d, 0xa4, 0xc3, 0xf6, 0x7b, 0x9e, 0xd7, 0xff, 0xb2, 0x0c, 0xd1, 0x53, 0xd8, 0xfd,
-	0xc0, 0xc2, 0xad, 0x9a, 0xde, 0x9f, 0x98, 0x37, 0xdd, 0x84, 0x2a, 0xd9, 0xe3, 0xd6, 0x29, 0x98,
-	0x9e, 0xee, 0xf6, 0x58, 0xbb, 0xda, 0x63, 0xed, 0xfb, 0x1e, 0x6b, 0xd7, 0x7b, 0xac, 0x7d, 0x2c,
-	0x30, 0xf8, 0x52, 0x60, 0x6d, 0x57, 0x60, 0x70, 0x55, 0x60, 0xf0, 0xab, 0xc0, 0xe0, 0x4f, 0x81,
-	0xb5, 0xeb, 0x02, 0x83, 0x4f, 0xbf, 0xb1, 0xf6, 0xb6, 0xa7, 0xda, 0x6e, 0x7a, 0xd5, 0x6b, 0x78,
-	0xf4, 0x37, 0x00, 0x00, 0xff, 0xff, 0x6a, 0x40, 0x2c, 0x67, 0xea, 0x02, 0x00, 0x00,
+	// 532 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0x3d, 0x8f, 0xd3, 0x40,
+	0x10, 0xf5, 0xe6, 0xf3, 0xb2, 0xf9, 0x90, 0xb5, 0x02, 0x64, 0x52, 0x6c, 0xac, 0x43, 0x48, 0x16,
+	0x12, 0xb6, 0x14, 0x9a, 0x13, 0x05, 0x52, 0x42, 0xa2, 0x10, 0xe0, 0x12, 0xb4, 0xe7, 0x08, 0x89,
+	0x26, 0xda, 0x9c, 0x37, 0xc6, 0x22, 0xfe, 0x90, 0x77, 0x83, 0x2e, 0x1d, 0x2d, 0x1d, 0x25, 0x3f,
+	0x81, 0x9f, 0x92, 0xf2, 0x4a, 0x2a, 0x20, 0xa6, 0x80, 0xf2, 0x7e, 0x02, 0xb2, 0xd7, 0x46, 0x48,
+	0x47, 0x71, 0xd5, 0xce, 0xbc, 0x79, 0x6f, 0x76, 0xe6, 0x69, 0xa0, 0xe1, 0x7b, 0x17, 0x2c, 0xb6,
+	0xa8, 0x43, 0x23, 0xc1, 0x62, 0x8b, 0x0b, 0x2a, 0xb8, 0x63, 0x9d, 0x87, 0xc1, 0xda, 0x73, 0xf3,
+	0xc7, 0x8c, 0xe2, 0x50, 0x84, 0xe8, 0x76, 0xce, 0x31, 0x25, 0xc7, 0x94, 0xc5, 0x2e, 0x76, 0xc3,
+	0xd0, 0xdd, 0x30, 0x2b, 0x23, 0xad, 0xb6, 0x6b, 0xcb, 0xd9, 0xc6, 0x54, 0x78, 0x61, 0x20, 0x65,
+	0xdd, 0x5b, 0x6e, 0xe8, 0x86, 0x59, 0x68, 0xa5, 0x91, 0x44, 0x8f, 0x3f, 0x56, 0x61, 0xed, 0x15,
+	0x8d, 0xa9, 0xcf, 0x91, 0x06, 0xeb, 0xd4, 0x71, 0x62, 0xc6, 0xb9, 0x06, 0x74, 0x60, 0x34, 0x48,
+	0x91, 0xa2, 0x3b, 0xb0, 0x16, 0xc5, 0x6c, 0xed, 0x5d, 0x68, 0xa5, 0xac, 0x90, 0x67, 0xe8, 0x39,
+	0xec, 0xac, 0x37, 0x5b, 0xfe, 0x76, 0x59, 0x7c, 0xa5, 0x95, 0x75, 0x60, 0x34, 0xfb, 0x77, 0x4d,
+	0x39, 0x8b, 0x59, 0xcc, 0x62, 0x8e, 0x72, 0xc2, 0xf0, 0x68, 0xff, 0xad, 0xa7, 0x7c, 0xfe, 0xde,
+	0x03, 0xa4, 0x9d, 0x49, 0x8b, 0x02, 0xea, 0xc1, 0xa6, 0xec, 0xb5, 0xda, 0x09, 0xc6, 0xb5, 0x8a,
+	0x0e, 0x8c, 0x2a, 0x81, 0x19, 0x34, 0x4c, 0x11, 0x74, 0x0f, 0xb6, 0x39, 0xf5, 0xa3, 0x8d, 0x17,
+	0xb8, 0xcb, 0x98, 0x0a, 0xa6, 0x55, 0x75, 0x60, 0x94, 0x48, 0xab, 0x00, 0x09, 0x15, 0x0c, 0x8d,
+	0x60, 0xdd, 0x67, 0x22, 0xf6, 0xce, 0xb9, 0x56, 0xd3, 0xcb, 0x46, 0xb3, 0xff, 0xc0, 0xfc, 0xaf,
+	0x5b, 0xa6, 0xdc, 0xd9, 0x3c, 0x95, 0xe4, 0x71, 0x20, 0xe2, 0x1d, 0x29, 0xa4, 0xe8, 0x3e, 0xec,
+	0x38, 0xa1, 0x2b, 0x05, 0x4b, 0x41, 0x5d, 0xae, 0xd5, 0x75, 0x60, 0x1c, 0x91, 0xf6, 0x5f, 0xd4,
+	0xa6, 0x2e, 0xef, 0xfe, 0x02, 0x10, 0xca, 0x06, 0xd3, 0x60, 0x1d, 0xa2, 0x01, 0xac, 0x88, 0x5d,
+	0xc4, 0x32, 0xf3, 0x3a, 0xfd, 0x87, 0x37, 0xf9, 0x38, 0xd5, 0x99, 0xf6, 0x2e, 0x62, 0x24, 0x93,
+	0xa6, 0x3b, 0x06, 0xd4, 0x67, 0x4b, 0xc1, 0xfc, 0x68, 0x93, 0xee, 0x28, 0xfd, 0x6e, 0xa5, 0xa0,
+	0x9d, 0x63, 0xd7, 0x8d, 0x28, 0x5f, 0x37, 0xe2, 0xf8, 0x25, 0xac, 0xa4, 0x7d, 0x51, 0x13, 0xd6,
+	0x17, 0xb3, 0x17, 0xb3, 0xf9, 0xeb, 0x99, 0xaa, 0xa4, 0xc9, 0xd3, 0xf9, 0x62, 0x66, 0x8f, 0x89,
+	0x0a, 0x50, 0x03, 0x56, 0x27, 0x83, 0xc5, 0x64, 0xac, 0x96, 0x90, 0x0a, 0x5b, 0xa3, 0xe9, 0x99,
+	0x4d, 0xa6, 0xc3, 0x85, 0x3d, 0x9d, 0xcf, 0xd4, 0x32, 0x6a, 0xc3, 0xc6, 0xb3, 0xe9, 0x99, 0x3d,
+	0x9f, 0x90, 0xc1, 0xa9, 0x5a, 0xe9, 0x3a, 0xb0, 0xf5, 0xaf, 0x53, 0x48, 0x85, 0xe5, 0x77, 0x6c,
+	0x97, 0x9f, 0x49, 0x1a, 0xa2, 0x27, 0xb0, 0xfa, 0x9e, 0x6e, 0xb6, 0x72, 0xe2, 0x66, 0xdf, 0xb8,
+	0xe9, 0xf6, 0x44, 0xca, 0x1e, 0x97, 0x4e, 0xc0, 0xf0, 0x64, 0x7f, 0xc0, 0xca, 0xe5, 0x01, 0x2b,
+	0x5f, 0x0f, 0x58, 0xb9, 0x3a, 0x60, 0xe5, 0x43, 0x82, 0xc1, 0x97, 0x04, 0x2b, 0xfb, 0x04, 0x83,
+	0xcb, 0x04, 0x83, 0x1f, 0x09, 0x06, 0xbf, 0x13, 0xac, 0x5c, 0x25, 0x18, 0x7c, 0xfa, 0x89, 0x95,
+	0x37, 0x35, 0xd9, 0x76, 0x55, 0xcb, 0x0e, 0xed, 0xd1, 0x9f, 0x01, 0x00, 0xe9, 0xc0, 0x03, 0x0f,
+	0x45, 0x03, 0x00, 0x00,
 }
@@ -0,0 +1,126 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"istio.io/istio/mixer/adapter/statsd/config"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/template/metric"
+)
+
+// tagReplacer strips the characters that delimit fields, tags and lines in the DogStatsD protocol.
+var tagReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+
+// send writes a single line of the form `name:value|type[|@rate][|#tag:value,...]` to the handler's sender.
+// Sampling happens here rather than in the statsd client since the line never passes through the client.
+func (h *handler) send(mname string, t info, value *metric.Instance) error {
+	val, mtype, err := formatValue(mname, t.mtype, h.tags, value.Value)
+	if err != nil {
+		return err
+	}
+
+	if t.rate < 1 && rand.Float32() >= t.rate {
+		return nil
+	}
+
+	buf := pool.GetBuffer()
+	defer pool.PutBuffer(buf)
+
+	if h.prefix != "" {
+		buf.WriteString(h.prefix)
+		buf.WriteByte('.')
+	}
+	buf.WriteString(mname)
+	buf.WriteByte(':')
+	buf.WriteString(val)
+	buf.WriteByte('|')
+	buf.WriteString(mtype)
+	if t.rate < 1 {
+		buf.WriteString("|@")
+		buf.WriteString(strconv.FormatFloat(float64(t.rate), 'f', -1, 32))
+	}
+	if h.tags && len(value.Dimensions) > 0 {
+		buf.WriteString("|#")
+		writeTags(buf, value.Dimensions)
+	}
+
+	// the sender may hold on to the line until its next flush, so it can't share the pooled buffer's storage.
+	line := make([]byte, buf.Len())
+	copy(line, buf.Bytes())
+	_, err = h.sender.Send(line)
+	return err
+}
+
+// formatValue returns the wire representation of v and the statsd type suffix for a metric of type mtype.
+func formatValue(mname string, mtype config.Params_MetricInfo_Type, tags bool, v interface{}) (string, string, error) {
+	switch mtype {
+	case config.GAUGE:
+		if i, ok := v.(int64); ok {
+			return strconv.FormatInt(i, 10), "g", nil
+		}
+		return "", "", fmt.Errorf("could not record gauge '%s' expected int value, got %v", mname, v)
+	case config.COUNTER:
+		if i, ok := v.(int64); ok {
+			return strconv.FormatInt(i, 10), "c", nil
+		}
+		return "", "", fmt.Errorf("could not record counter '%s' expected int value, got %v", mname, v)
+	case config.DISTRIBUTION, config.HISTOGRAM:
+		suffix := "h"
+		if mtype == config.DISTRIBUTION {
+			// Without tags distributions are sent as plain statsd timers.
+			suffix = "ms"
+			if tags {
+				suffix = "d"
+			}
+		}
+		switch val := v.(type) {
+		case time.Duration:
+			return strconv.FormatFloat(float64(val)/float64(time.Millisecond), 'f', -1, 64), suffix, nil
+		case int64:
+			return strconv.FormatInt(val, 10), suffix, nil
+		case float64:
+			return strconv.FormatFloat(val, 'f', -1, 64), suffix, nil
+		}
+		return "", "", fmt.Errorf("could not record distribution '%s'; expected int, float or duration, got %v", mname, v)
+	default:
+		return "", "", fmt.Errorf("unknown metric type '%v' for metric: %s", mtype, mname)
+	}
+}
+
+// writeTags writes dims as comma separated `key:value` pairs, sorted by key so that the output is stable.
+func writeTags(buf *bytes.Buffer, dims map[string]interface{}) {
+	keys := make([]string, 0, len(dims))
+	for k := range dims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(tagReplacer.Replace(k))
+		buf.WriteByte(':')
+		buf.WriteString(tagReplacer.Replace(fmt.Sprintf("%v", dims[k])))
+	}
+}
@@ -42,11 +42,14 @@ type (
 	info struct {
 		mtype config.Params_MetricInfo_Type
 		tmpl  *template.Template
+		rate  float32
 	}
 
 	handler struct {
-		rate      float32
 		client    statsd.Statter
+		sender    statsd.Sender // shared with client; used for lines the client can't express
+		prefix    string
+		tags      bool
 		templates map[string]info // metric name -> template
 	}
 )
@@ -77,30 +80,35 @@ func (h *handler) record(value *metric.Instance) error {
 		pool.PutBuffer(buf)
 	}
 
+	// The statsd client knows nothing of tags or histograms, so those are formatted by hand.
+	if h.tags || t.mtype == config.HISTOGRAM {
+		return h.send(mname, t, value)
+	}
+
 	switch t.mtype {
 	case config.GAUGE:
 		v, ok := value.Value.(int64)
 		if !ok {
 			return fmt.Errorf("could not record counter '%s' expected int value, got %v", mname, value.Value)
 		}
-		return h.client.Gauge(mname, v, h.rate)
+		return h.client.Gauge(mname, v, t.rate)
 	case config.COUNTER:
 		v, ok := value.Value.(int64)
 		if !ok {
 			return fmt.Errorf("could not record counter '%s' expected int value, got %v", mname, value.Value)
 		}
-		return h.client.Inc(mname, v, h.rate)
+		return h.client.Inc(mname, v, t.rate)
 	case config.DISTRIBUTION:
 		// TODO: figure out how to program histograms via config.*
 		// updates
 		v, ok := value.Value.(time.Duration)
 		if ok {
-			return h.client.TimingDuration(mname, v, h.rate)
+			return h.client.TimingDuration(mname, v, t.rate)
 		}
 		// TODO: figure out support for non-duration distributions.
 		vint, ok := value.Value.(int64)
 		if ok {
-			return h.client.Inc(mname, vint, h.rate)
+			return h.client.Inc(mname, vint, t.rate)
 		}
 		return fmt.Errorf("could not record distribution '%s'; expected int or duration, got %v", mname, value.Value)
 	default:
@@ -156,6 +164,9 @@ func (b *builder) Validate() (ce *adapter.ConfigErrors) {
 		if _, err := template.New(metricName).Parse(s.NameTemplate); err != nil {
 			ce = ce.Appendf("metricNameTemplateStrings", "failed to parse template '%s' for metric '%s': %v", s, metricName, err)
 		}
+		if s.SamplingRate < 0 || s.SamplingRate > 1 {
+			ce = ce.Appendf(fmt.Sprintf("metrics[%s].samplingRate", metricName), "sampling rate must be in the range [0, 1]")
+		}
 	}
 	return
 }
@@ -170,7 +181,10 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 		flushBytes = defaultFlushBytes
 	}
 
-	client, _ := statsd.NewBufferedClient(ac.Address, ac.Prefix, ac.FlushDuration, flushBytes)
+	// The sender is built separately so that lines the client can't produce (tags, histograms) are batched into the
+	// same packets as everything else.
+	sender, _ := statsd.NewBufferedSender(ac.Address, ac.FlushDuration, flushBytes)
+	client, _ := statsd.NewClientWithSender(sender, ac.Prefix)
 
 	templates := make(map[string]info)
 	for metricName, s := range ac.Metrics {
@@ -190,7 +204,17 @@ func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handl
 				continue
 			}
 		}
-		templates[metricName] = info{mtype: s.Type, tmpl: t}
+		rate := ac.SamplingRate
+		if s.SamplingRate > 0 {
+			rate = s.SamplingRate
+		}
+		templates[metricName] = info{mtype: s.Type, tmpl: t, rate: rate}
 	}
-	return &handler{ac.SamplingRate, client, templates}, nil
+	return &handler{
+		client:    client,
+		sender:    sender,
+		prefix:    ac.Prefix,
+		tags:      ac.DogstatsdTags,
+		templates: templates,
+	}, nil
 }
@@ -17,16 +17,19 @@ package statsd
 import (
 	"context"
 	"fmt"
+	"net"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/cactus/go-statsd-client/statsd"
 	"github.com/cactus/go-statsd-client/statsd/statsdtest"
-	"github.com/golang/protobuf/proto"
+	"github.com/gogo/protobuf/proto"
 
 	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/statsd/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/metric"
 )
@@ -42,6 +45,8 @@ func TestValidateConfig(t *testing.T) {
 		{&config.Params{FlushDuration: -1}, "flushDuration"},
 		{&config.Params{SamplingRate: -1}, "samplingRate"},
 		{&config.Params{FlushBytes: -1}, "flushBytes"},
+		{&config.Params{Metrics: map[string]*config.Params_MetricInfo{"a": {SamplingRate: -1}}}, "metrics[a].samplingRate"},
+		{&config.Params{Metrics: map[string]*config.Params_MetricInfo{"a": {SamplingRate: 1.5}}}, "metrics[a].samplingRate"},
 	}
 	for idx, c := range cases {
 		errString := ""
@@ -281,3 +286,220 @@ func TestRecord(t *testing.T) {
 		})
 	}
 }
+
+// listen starts a local UDP listener standing in for the statsd server.
+func listen(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() = %v", err)
+	}
+	return conn
+}
+
+// readLines reads statsd lines from conn until n have arrived or nothing more shows up for a while.
+func readLines(t *testing.T, conn net.PacketConn, n int) []string {
+	t.Helper()
+	var lines []string
+	buf := make([]byte, 65536)
+	for len(lines) < n {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		l, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		for _, line := range strings.Split(string(buf[:l]), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func buildHandler(t *testing.T, conf *config.Params, metrics map[string]*metric.Type) *handler {
+	t.Helper()
+	b := GetInfo().NewBuilder().(*builder)
+	b.SetAdapterConfig(conf)
+	b.SetMetricTypes(metrics)
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Build() = _, %v", err)
+	}
+	return h.(*handler)
+}
+
+func TestDogStatsD(t *testing.T) {
+	conn := listen(t)
+	defer func() { _ = conn.Close() }()
+
+	dims := map[string]descriptor.ValueType{"source": descriptor.STRING, "code": descriptor.INT64}
+	conf := &config.Params{
+		Address:       conn.LocalAddr().String(),
+		Prefix:        "istio",
+		FlushDuration: 10 * time.Millisecond,
+		SamplingRate:  1.0,
+		DogstatsdTags: true,
+		Metrics: map[string]*config.Params_MetricInfo{
+			"counter":      {Type: config.COUNTER},
+			"gauge":        {Type: config.GAUGE},
+			"distribution": {Type: config.DISTRIBUTION},
+			"histogram":    {Type: config.HISTOGRAM, NameTemplate: `histogram-{{.source}}`},
+			"untagged":     {Type: config.COUNTER},
+		},
+	}
+	metrics := map[string]*metric.Type{
+		"counter":      {Dimensions: dims},
+		"gauge":        {Dimensions: dims},
+		"distribution": {Dimensions: dims},
+		"histogram":    {Dimensions: dims},
+		"untagged":     {},
+	}
+	h := buildHandler(t, conf, metrics)
+
+	d := map[string]interface{}{"source": "a|b,c", "code": 200}
+	vals := []*metric.Instance{
+		{Name: "counter", Value: int64(3), Dimensions: d},
+		{Name: "gauge", Value: int64(42), Dimensions: d},
+		{Name: "distribution", Value: 1500 * time.Microsecond, Dimensions: d},
+		{Name: "histogram", Value: 2.5, Dimensions: map[string]interface{}{"source": "x", "code": 404}},
+		{Name: "untagged", Value: int64(1)},
+	}
+	if err := h.HandleMetric(context.Background(), vals); err != nil {
+		t.Fatalf("HandleMetric() = %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+
+	want := []string{
+		"istio.counter:3|c|#code:200,source:a_b_c",
+		"istio.distribution:1.5|d|#code:200,source:a_b_c",
+		"istio.gauge:42|g|#code:200,source:a_b_c",
+		"istio.histogram-x:2.5|h|#code:404,source:x",
+		"istio.untagged:1|c",
+	}
+	got := readLines(t, conn, len(want))
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got lines:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHistogramWithoutTags(t *testing.T) {
+	conn := listen(t)
+	defer func() { _ = conn.Close() }()
+
+	conf := &config.Params{
+		Address:       conn.LocalAddr().String(),
+		FlushDuration: 10 * time.Millisecond,
+		SamplingRate:  1.0,
+		Metrics: map[string]*config.Params_MetricInfo{
+			"histogram": {Type: config.HISTOGRAM},
+		},
+	}
+	h := buildHandler(t, conf, map[string]*metric.Type{"histogram": {}})
+
+	vals := []*metric.Instance{
+		{Name: "histogram", Value: 20 * time.Millisecond, Dimensions: map[string]interface{}{"source": "x"}},
+		{Name: "histogram", Value: int64(7)},
+	}
+	if err := h.HandleMetric(context.Background(), vals); err != nil {
+		t.Fatalf("HandleMetric() = %v", err)
+	}
+	if err := h.HandleMetric(context.Background(), []*metric.Instance{{Name: "histogram", Value: "bad"}}); err == nil {
+		t.Error("HandleMetric() with a string value succeeded; want error")
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+
+	want := []string{"histogram:20|h", "histogram:7|h"}
+	got := readLines(t, conn, len(want))
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got lines %v, want %v", got, want)
+	}
+}
+
+func TestMetricSamplingRate(t *testing.T) {
+	conn := listen(t)
+	defer func() { _ = conn.Close() }()
+
+	conf := &config.Params{
+		Address:       conn.LocalAddr().String(),
+		FlushDuration: 10 * time.Millisecond,
+		SamplingRate:  1.0,
+		DogstatsdTags: true,
+		Metrics: map[string]*config.Params_MetricInfo{
+			"sampled": {Type: config.COUNTER, SamplingRate: 0.5},
+			"full":    {Type: config.COUNTER},
+		},
+	}
+	h := buildHandler(t, conf, map[string]*metric.Type{"sampled": {}, "full": {}})
+	if rate := h.templates["sampled"].rate; rate != 0.5 {
+		t.Errorf("sampled rate = %v, want 0.5", rate)
+	}
+	if rate := h.templates["full"].rate; rate != 1 {
+		t.Errorf("full rate = %v, want the adapter-wide 1", rate)
+	}
+
+	const n = 200
+	vals := make([]*metric.Instance, n)
+	for i := range vals {
+		vals[i] = &metric.Instance{Name: "sampled", Value: int64(1)}
+	}
+	if err := h.HandleMetric(context.Background(), vals); err != nil {
+		t.Fatalf("HandleMetric() = %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+
+	got := readLines(t, conn, n)
+	if len(got) == 0 || len(got) == n {
+		t.Fatalf("got %d of %d sampled lines; want some but not all", len(got), n)
+	}
+	for _, line := range got {
+		if line != "sampled:1|c|@0.5" {
+			t.Errorf("got line %q, want %q", line, "sampled:1|c|@0.5")
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	conn := listen(t)
+	defer func() { _ = conn.Close() }()
+
+	dims := map[string]descriptor.ValueType{"source": descriptor.STRING}
+	test.RunConformance(t, test.Conformance{
+		Info: GetInfo(),
+		Config: &config.Params{
+			Address:       conn.LocalAddr().String(),
+			FlushDuration: 10 * time.Millisecond,
+			SamplingRate:  1.0,
+			Metrics: map[string]*config.Params_MetricInfo{
+				"requests": {Type: config.COUNTER, NameTemplate: `requests-{{.source}}`},
+				"latency":  {Type: config.DISTRIBUTION},
+			},
+		},
+		InvalidConfigs: []adapter.Config{
+			&config.Params{FlushDuration: -1},
+			&config.Params{Metrics: map[string]*config.Params_MetricInfo{"requests": {SamplingRate: 2}}},
+		},
+		Types: map[string]map[string]proto.Message{
+			metric.TemplateName: {
+				"requests": &metric.Type{Dimensions: dims},
+				"latency":  &metric.Type{Dimensions: dims},
+			},
+		},
+		Instances: map[string][]interface{}{
+			metric.TemplateName: {
+				&metric.Instance{Name: "requests", Value: int64(1), Dimensions: map[string]interface{}{"source": "a"}},
+				&metric.Instance{Name: "latency", Value: 10 * time.Millisecond, Dimensions: map[string]interface{}{"source": "a"}},
+			},
+		},
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdio // import "istio.io/istio/mixer/adapter/stdio"
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+// asyncWriter is a zapcore.WriteSyncer which hands lines over to a background
+// task for writing, such that callers never block on I/O. Lines written while
+// the buffer is full are dropped.
+type asyncWriter struct {
+	env     adapter.Env
+	out     zapcore.WriteSyncer
+	lines   chan []byte
+	done    chan struct{}
+	stopped chan struct{}
+
+	// number of lines dropped since the last report, accessed atomically
+	dropped int64
+}
+
+func newAsyncWriter(out zapcore.WriteSyncer, size int, env adapter.Env) *asyncWriter {
+	w := &asyncWriter{
+		env:     env,
+		out:     out,
+		lines:   make(chan []byte, size),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	env.ScheduleDaemon(w.run)
+	return w
+}
+
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	// the caller reuses p once we return
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	select {
+	case w.lines <- line:
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+	}
+
+	return len(p), nil
+}
+
+// Sync is a no-op, lines are flushed to the underlying output when the writer is closed.
+func (w *asyncWriter) Sync() error {
+	return nil
+}
+
+// close stops the background task after it has written all buffered lines.
+func (w *asyncWriter) close() {
+	close(w.done)
+	<-w.stopped
+	_ = w.out.Sync()
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.stopped)
+
+	for {
+		select {
+		case line := <-w.lines:
+			w.write(line)
+		case <-w.done:
+			for {
+				select {
+				case line := <-w.lines:
+					w.write(line)
+				default:
+					w.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *asyncWriter) write(line []byte) {
+	if _, err := w.out.Write(line); err != nil {
+		_ = w.env.Logger().Errorf("Unable to write log entry: %v", err)
+	}
+	w.reportDropped()
+}
+
+func (w *asyncWriter) reportDropped() {
+	if n := atomic.SwapInt64(&w.dropped, 0); n > 0 {
+		w.env.Logger().Warningf("Dropped %d log entries, the output buffer is full", n)
+	}
+}
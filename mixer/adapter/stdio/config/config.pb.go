@@ -23,9 +23,14 @@ import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
 import _ "github.com/gogo/protobuf/gogoproto"
+import _ "github.com/gogo/protobuf/types"
+
+import time "time"
 
 import strconv "strconv"
 
+import github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
+
 import strings "strings"
 import reflect "reflect"
 import github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
@@ -36,6 +41,7 @@ import io "io"
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -94,6 +100,40 @@ var Params_Level_value = map[string]int32{
 
 func (Params_Level) EnumDescriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0, 1} }
 
+// LineFormat selects how log entries are rendered into output lines.
+type Params_LineFormat int32
+
+const (
+	// Entries are encoded as JSON or console text, depending on output_as_json. This is the default value.
+	STRUCTURED Params_LineFormat = 0
+	// Entries are rendered in the combined log format shared by Apache and NGINX:
+	//
+	// ```
+	// 10.0.0.1 - alice [21/Aug/2017:10:04:00 +0000] "GET /index.html http" 200 1024 "-" "curl/7.54.0"
+	// ```
+	//
+	// Fields are taken from the `sourceIp`, `sourceUser`, `method`, `url`, `protocol`,
+	// `responseCode`, `responseSize`, `referer` and `userAgent` variables of the logentry
+	// instance. Missing or empty variables are rendered as `-`.
+	COMBINED Params_LineFormat = 1
+	// Entries are rendered with the Go [text/template](https://golang.org/pkg/text/template/)
+	// given by line_template.
+	TEMPLATE Params_LineFormat = 2
+)
+
+var Params_LineFormat_name = map[int32]string{
+	0: "STRUCTURED",
+	1: "COMBINED",
+	2: "TEMPLATE",
+}
+var Params_LineFormat_value = map[string]int32{
+	"STRUCTURED": 0,
+	"COMBINED":   1,
+	"TEMPLATE":   2,
+}
+
+func (Params_LineFormat) EnumDescriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0, 2} }
+
 // Configuration format for the `stdio` adapter
 type Params struct {
 	// Selects which standard stream to write to for log entries.
@@ -144,16 +184,63 @@ type Params struct {
 	// The maximum number of old rotated log files to retain.  The default
 	// is to retain at most 1000 logs. 0 indicates no limit.
 	MaxRotatedFiles int32 `protobuf:"varint,9,opt,name=max_rotated_files,json=maxRotatedFiles,proto3" json:"max_rotated_files,omitempty"`
+	// Whether rotated log files are compressed using gzip. Defaults to false.
+	CompressRotatedFiles bool `protobuf:"varint,10,opt,name=compress_rotated_files,json=compressRotatedFiles,proto3" json:"compress_rotated_files,omitempty"`
+	// The maximum amount of time a log file is written to before it gets rotated,
+	// regardless of its size. 0 disables time based rotation, which is the default.
+	RotationInterval time.Duration `protobuf:"bytes,11,opt,name=rotation_interval,json=rotationInterval,stdduration" json:"rotation_interval"`
+	// The format used to render log entries. Defaults to STRUCTURED.
+	LineFormat Params_LineFormat `protobuf:"varint,12,opt,name=line_format,json=lineFormat,proto3,enum=adapter.stdio.config.Params_LineFormat" json:"line_format,omitempty"`
+	// The template used to render log entries when line_format is TEMPLATE.
+	// The template is executed against a structure with the following fields:
+	//
+	// - `Name`: the name of the logentry instance.
+	// - `Level`: the level the entry's severity maps to, such as `info`.
+	// - `Timestamp`: the timestamp of the entry, as a Go `time.Time`.
+	// - `Variables`: the variables of the instance, keyed by name.
+	//
+	// For example:
+	//
+	// ```
+	// {{.Timestamp.Format "2006-01-02T15:04:05Z07:00"}} {{.Variables.method}} {{.Variables.url}} {{.Variables.responseCode}}
+	// ```
+	LineTemplate string `protobuf:"bytes,13,opt,name=line_template,json=lineTemplate,proto3" json:"line_template,omitempty"`
+	// Per instance output settings, keyed by the fully qualified name of the logentry
+	// instance (for example `accesslog.logentry.istio-system`). Log entries of the
+	// listed instances are written to their own file instead of the output selected
+	// by log_stream.
+	InstanceOutputs map[string]*Params_InstanceOutput `protobuf:"bytes,14,rep,name=instance_outputs,json=instanceOutputs" json:"instance_outputs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
+	// The number of output lines buffered for writing by a background task. Writes
+	// then never block request processing; lines produced while the buffer is full
+	// are dropped and counted. 0 selects synchronous writes, which is the default.
+	BufferSize int32 `protobuf:"varint,15,opt,name=buffer_size,json=bufferSize,proto3" json:"buffer_size,omitempty"`
 }
 
 func (m *Params) Reset()                    { *m = Params{} }
 func (*Params) ProtoMessage()               {}
 func (*Params) Descriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0} }
 
+// Output settings for the log entries produced by a single logentry instance.
+type Params_InstanceOutput struct {
+	// The file system path log entries of the instance are written to. The file is
+	// rotated according to the rotation options when log_stream is ROTATED_FILE.
+	OutputPath string `protobuf:"bytes,1,opt,name=output_path,json=outputPath,proto3" json:"output_path,omitempty"`
+	// The format used to render the log entries of the instance.
+	LineFormat Params_LineFormat `protobuf:"varint,2,opt,name=line_format,json=lineFormat,proto3,enum=adapter.stdio.config.Params_LineFormat" json:"line_format,omitempty"`
+	// The template used when line_format is TEMPLATE.
+	LineTemplate string `protobuf:"bytes,3,opt,name=line_template,json=lineTemplate,proto3" json:"line_template,omitempty"`
+}
+
+func (m *Params_InstanceOutput) Reset()                    { *m = Params_InstanceOutput{} }
+func (*Params_InstanceOutput) ProtoMessage()               {}
+func (*Params_InstanceOutput) Descriptor() ([]byte, []int) { return fileDescriptorConfig, []int{0, 0} }
+
 func init() {
 	proto.RegisterType((*Params)(nil), "adapter.stdio.config.Params")
+	proto.RegisterType((*Params_InstanceOutput)(nil), "adapter.stdio.config.Params.InstanceOutput")
 	proto.RegisterEnum("adapter.stdio.config.Params_Stream", Params_Stream_name, Params_Stream_value)
 	proto.RegisterEnum("adapter.stdio.config.Params_Level", Params_Level_name, Params_Level_value)
+	proto.RegisterEnum("adapter.stdio.config.Params_LineFormat", Params_LineFormat_name, Params_LineFormat_value)
 }
 func (x Params_Stream) String() string {
 	s, ok := Params_Stream_name[int32(x)]
@@ -169,6 +256,13 @@ func (x Params_Level) String() string {
 	}
 	return strconv.Itoa(int(x))
 }
+func (x Params_LineFormat) String() string {
+	s, ok := Params_LineFormat_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
 func (m *Params) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -246,6 +340,103 @@ func (m *Params) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintConfig(dAtA, i, uint64(m.MaxRotatedFiles))
 	}
+	if m.CompressRotatedFiles {
+		dAtA[i] = 0x50
+		i++
+		if m.CompressRotatedFiles {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	dAtA[i] = 0x5a
+	i++
+	i = encodeVarintConfig(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdDuration(m.RotationInterval)))
+	n1, err := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.RotationInterval, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n1
+	if m.LineFormat != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.LineFormat))
+	}
+	if len(m.LineTemplate) > 0 {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.LineTemplate)))
+		i += copy(dAtA[i:], m.LineTemplate)
+	}
+	if len(m.InstanceOutputs) > 0 {
+		for k, _ := range m.InstanceOutputs {
+			dAtA[i] = 0x72
+			i++
+			v := m.InstanceOutputs[k]
+			msgSize := 0
+			if v != nil {
+				msgSize = v.Size()
+				msgSize += 1 + sovConfig(uint64(msgSize))
+			}
+			mapSize := 1 + len(k) + sovConfig(uint64(len(k))) + msgSize
+			i = encodeVarintConfig(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintConfig(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			if v != nil {
+				dAtA[i] = 0x12
+				i++
+				i = encodeVarintConfig(dAtA, i, uint64(v.Size()))
+				n2, err := v.MarshalTo(dAtA[i:])
+				if err != nil {
+					return 0, err
+				}
+				i += n2
+			}
+		}
+	}
+	if m.BufferSize != 0 {
+		dAtA[i] = 0x78
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.BufferSize))
+	}
+	return i, nil
+}
+
+func (m *Params_InstanceOutput) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Params_InstanceOutput) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.OutputPath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.OutputPath)))
+		i += copy(dAtA[i:], m.OutputPath)
+	}
+	if m.LineFormat != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(m.LineFormat))
+	}
+	if len(m.LineTemplate) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintConfig(dAtA, i, uint64(len(m.LineTemplate)))
+		i += copy(dAtA[i:], m.LineTemplate)
+	}
 	return i, nil
 }
 
@@ -294,6 +485,51 @@ func (m *Params) Size() (n int) {
 	if m.MaxRotatedFiles != 0 {
 		n += 1 + sovConfig(uint64(m.MaxRotatedFiles))
 	}
+	if m.CompressRotatedFiles {
+		n += 2
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.RotationInterval)
+	n += 1 + l + sovConfig(uint64(l))
+	if m.LineFormat != 0 {
+		n += 1 + sovConfig(uint64(m.LineFormat))
+	}
+	l = len(m.LineTemplate)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	if len(m.InstanceOutputs) > 0 {
+		for k, v := range m.InstanceOutputs {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovConfig(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovConfig(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovConfig(uint64(mapEntrySize))
+		}
+	}
+	if m.BufferSize != 0 {
+		n += 1 + sovConfig(uint64(m.BufferSize))
+	}
+	return n
+}
+
+func (m *Params_InstanceOutput) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.OutputPath)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
+	if m.LineFormat != 0 {
+		n += 1 + sovConfig(uint64(m.LineFormat))
+	}
+	l = len(m.LineTemplate)
+	if l > 0 {
+		n += 1 + l + sovConfig(uint64(l))
+	}
 	return n
 }
 
@@ -324,6 +560,16 @@ func (this *Params) String() string {
 		mapStringForSeverityLevels += fmt.Sprintf("%v: %v,", k, this.SeverityLevels[k])
 	}
 	mapStringForSeverityLevels += "}"
+	keysForInstanceOutputs := make([]string, 0, len(this.InstanceOutputs))
+	for k, _ := range this.InstanceOutputs {
+		keysForInstanceOutputs = append(keysForInstanceOutputs, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForInstanceOutputs)
+	mapStringForInstanceOutputs := "map[string]*Params_InstanceOutput{"
+	for _, k := range keysForInstanceOutputs {
+		mapStringForInstanceOutputs += fmt.Sprintf("%v: %v,", k, this.InstanceOutputs[k])
+	}
+	mapStringForInstanceOutputs += "}"
 	s := strings.Join([]string{`&Params{`,
 		`LogStream:` + fmt.Sprintf("%v", this.LogStream) + `,`,
 		`SeverityLevels:` + mapStringForSeverityLevels + `,`,
@@ -334,6 +580,24 @@ func (this *Params) String() string {
 		`MaxMegabytesBeforeRotation:` + fmt.Sprintf("%v", this.MaxMegabytesBeforeRotation) + `,`,
 		`MaxDaysBeforeRotation:` + fmt.Sprintf("%v", this.MaxDaysBeforeRotation) + `,`,
 		`MaxRotatedFiles:` + fmt.Sprintf("%v", this.MaxRotatedFiles) + `,`,
+		`CompressRotatedFiles:` + fmt.Sprintf("%v", this.CompressRotatedFiles) + `,`,
+		`RotationInterval:` + strings.Replace(strings.Replace(this.RotationInterval.String(), "Duration", "google_protobuf1.Duration", 1), `&`, ``, 1) + `,`,
+		`LineFormat:` + fmt.Sprintf("%v", this.LineFormat) + `,`,
+		`LineTemplate:` + fmt.Sprintf("%v", this.LineTemplate) + `,`,
+		`InstanceOutputs:` + mapStringForInstanceOutputs + `,`,
+		`BufferSize:` + fmt.Sprintf("%v", this.BufferSize) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Params_InstanceOutput) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Params_InstanceOutput{`,
+		`OutputPath:` + fmt.Sprintf("%v", this.OutputPath) + `,`,
+		`LineFormat:` + fmt.Sprintf("%v", this.LineFormat) + `,`,
+		`LineTemplate:` + fmt.Sprintf("%v", this.LineTemplate) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -645,6 +909,373 @@ func (m *Params) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompressRotatedFiles", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CompressRotatedFiles = bool(v != 0)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RotationInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.RotationInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LineFormat", wireType)
+			}
+			m.LineFormat = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LineFormat |= (Params_LineFormat(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LineTemplate", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LineTemplate = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InstanceOutputs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.InstanceOutputs == nil {
+				m.InstanceOutputs = make(map[string]*Params_InstanceOutput)
+			}
+			var mapkey string
+			var mapvalue *Params_InstanceOutput
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowConfig
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowConfig
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthConfig
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowConfig
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= (int(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthConfig
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if mapmsglen < 0 {
+						return ErrInvalidLengthConfig
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &Params_InstanceOutput{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipConfig(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthConfig
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.InstanceOutputs[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BufferSize", wireType)
+			}
+			m.BufferSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BufferSize |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipConfig(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthConfig
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Params_InstanceOutput) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowConfig
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: InstanceOutput: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: InstanceOutput: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutputPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OutputPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LineFormat", wireType)
+			}
+			m.LineFormat = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LineFormat |= (Params_LineFormat(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LineTemplate", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConfig
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthConfig
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LineTemplate = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConfig(dAtA[iNdEx:])
@@ -774,39 +1405,56 @@ var (
 func init() { proto.RegisterFile("mixer/adapter/stdio/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 538 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0x41, 0x6f, 0xd3, 0x3c,
-	0x18, 0xc7, 0xe3, 0x76, 0xc9, 0xda, 0x67, 0xd3, 0x96, 0xd7, 0xef, 0x90, 0xa2, 0x49, 0x98, 0xaa,
-	0x20, 0x51, 0x38, 0xa4, 0x68, 0x1c, 0x98, 0x10, 0x97, 0x4e, 0xcb, 0xd0, 0xd0, 0x58, 0x27, 0xaf,
-	0x08, 0xc1, 0x25, 0x72, 0x57, 0x37, 0x0b, 0x24, 0x75, 0x15, 0xbb, 0x55, 0x73, 0xe3, 0x23, 0xf0,
-	0x31, 0xf8, 0x28, 0x3b, 0xee, 0xc8, 0x91, 0x86, 0x0b, 0xc7, 0x1d, 0xf8, 0x00, 0x28, 0x76, 0x38,
-	0x00, 0xd3, 0xb4, 0x53, 0x1e, 0xff, 0xf3, 0xfb, 0x3d, 0x8f, 0xe5, 0x38, 0xf0, 0x30, 0x8d, 0x17,
-	0x3c, 0xeb, 0xb2, 0x11, 0x9b, 0x2a, 0x9e, 0x75, 0xa5, 0x1a, 0xc5, 0xa2, 0x7b, 0x26, 0x26, 0xe3,
-	0x38, 0xaa, 0x1e, 0xfe, 0x34, 0x13, 0x4a, 0xe0, 0xad, 0x0a, 0xf1, 0x35, 0xe2, 0x9b, 0x77, 0xdb,
-	0x5b, 0x91, 0x88, 0x84, 0x06, 0xba, 0x65, 0x65, 0xd8, 0xf6, 0x4f, 0x1b, 0x9c, 0x13, 0x96, 0xb1,
-	0x54, 0xe2, 0x3d, 0x80, 0x44, 0x44, 0xa1, 0x54, 0x19, 0x67, 0xa9, 0x87, 0x5a, 0xa8, 0xb3, 0xb1,
-	0x73, 0xdf, 0xbf, 0xae, 0x97, 0x6f, 0x0c, 0xff, 0x54, 0xa3, 0xb4, 0x99, 0x88, 0xc8, 0x94, 0xf8,
-	0x1d, 0x6c, 0x4a, 0x3e, 0xe7, 0x59, 0xac, 0xf2, 0x30, 0xe1, 0x73, 0x9e, 0x48, 0xaf, 0xd6, 0xaa,
-	0x77, 0xd6, 0x76, 0x9e, 0xdc, 0xdc, 0xa8, 0x72, 0x8e, 0xb4, 0x12, 0x4c, 0x54, 0x96, 0xd3, 0x0d,
-	0xf9, 0x47, 0x88, 0x03, 0x58, 0x4f, 0xb9, 0xca, 0xe2, 0x33, 0xd3, 0xd8, 0xab, 0xeb, 0x0d, 0xb6,
-	0x6f, 0xec, 0xab, 0x55, 0xba, 0x66, 0x3c, 0xbd, 0xc0, 0x0f, 0x60, 0x43, 0xcc, 0xd4, 0x74, 0xa6,
-	0x42, 0x26, 0xc3, 0x0f, 0x52, 0x4c, 0xbc, 0x95, 0x16, 0xea, 0x34, 0xe8, 0xba, 0x49, 0x7b, 0xf2,
-	0x95, 0x14, 0x93, 0x72, 0x58, 0x45, 0x99, 0x61, 0xf6, 0xed, 0x87, 0x19, 0xcf, 0x0c, 0xbb, 0x07,
-	0xd5, 0x32, 0x9c, 0x32, 0x75, 0xee, 0x39, 0x2d, 0xd4, 0x69, 0x52, 0x30, 0xd1, 0x09, 0x53, 0xe7,
-	0xb8, 0x07, 0x77, 0x53, 0xb6, 0x08, 0x53, 0x1e, 0xb1, 0x61, 0xae, 0xb8, 0x0c, 0x87, 0x7c, 0x2c,
-	0x32, 0x1e, 0x66, 0x42, 0x31, 0x15, 0x8b, 0x89, 0xb7, 0xda, 0x42, 0x1d, 0x9b, 0x6e, 0xa7, 0x6c,
-	0xf1, 0xfa, 0x37, 0xb3, 0xa7, 0x11, 0x5a, 0x11, 0xf8, 0x19, 0x78, 0x65, 0x8b, 0x11, 0xcb, 0xff,
-	0xb5, 0x1b, 0xda, 0xbe, 0x93, 0xb2, 0xc5, 0x3e, 0xcb, 0xff, 0x16, 0x1f, 0xc3, 0x7f, 0xa5, 0xa8,
-	0x61, 0x3e, 0x0a, 0xc7, 0x71, 0xc2, 0xa5, 0xd7, 0xd4, 0xc6, 0x66, 0xca, 0x16, 0xd4, 0xe4, 0x07,
-	0x65, 0xbc, 0xcd, 0xe1, 0xff, 0x6b, 0xbe, 0x11, 0x76, 0xa1, 0xfe, 0x91, 0xe7, 0xfa, 0xae, 0x34,
-	0x69, 0x59, 0xe2, 0x5d, 0xb0, 0xe7, 0x2c, 0x99, 0x71, 0xaf, 0x76, 0xeb, 0x13, 0x33, 0xc2, 0xf3,
-	0xda, 0x2e, 0x6a, 0xbf, 0x00, 0xa7, 0xba, 0x48, 0x00, 0xce, 0xe9, 0x60, 0xbf, 0xff, 0x66, 0xe0,
-	0x5a, 0x55, 0x1d, 0x50, 0xea, 0x22, 0xdc, 0x80, 0x95, 0x83, 0xc3, 0xa3, 0xc0, 0xad, 0x61, 0x17,
-	0xd6, 0x69, 0x7f, 0xd0, 0x1b, 0x04, 0xfb, 0xa1, 0x4e, 0xea, 0xed, 0x47, 0x60, 0x9b, 0x63, 0x6f,
-	0xc0, 0xca, 0xe1, 0xf1, 0x41, 0xdf, 0xb5, 0xf0, 0x1a, 0xac, 0xbe, 0xed, 0xd1, 0xe3, 0xc3, 0xe3,
-	0x97, 0x2e, 0xc2, 0x4d, 0xb0, 0x03, 0x4a, 0xfb, 0xd4, 0xad, 0xed, 0xed, 0x5e, 0x2c, 0x89, 0x75,
-	0xb9, 0x24, 0xd6, 0xd7, 0x25, 0xb1, 0xae, 0x96, 0xc4, 0xfa, 0x54, 0x10, 0xf4, 0xa5, 0x20, 0xd6,
-	0x45, 0x41, 0xd0, 0x65, 0x41, 0xd0, 0xb7, 0x82, 0xa0, 0x1f, 0x05, 0xb1, 0xae, 0x0a, 0x82, 0x3e,
-	0x7f, 0x27, 0xd6, 0x7b, 0xc7, 0x6c, 0x7d, 0xe8, 0xe8, 0xff, 0xe6, 0xe9, 0xaf, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0xcd, 0x2c, 0xa2, 0x33, 0x8e, 0x03, 0x00, 0x00,
+	// 807 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x94, 0xcf, 0x6f, 0xe3, 0x44,
+	0x14, 0xc7, 0x3d, 0x49, 0x93, 0x4d, 0x9f, 0xb3, 0xa9, 0x77, 0x28, 0xc8, 0x44, 0xc2, 0x8d, 0xb2,
+	0x48, 0x1b, 0x40, 0x72, 0xa0, 0x20, 0x51, 0x21, 0x2e, 0xc9, 0xc6, 0x85, 0xa0, 0xb6, 0xa9, 0xa6,
+	0xae, 0x10, 0x08, 0xc9, 0x9a, 0x34, 0x13, 0xaf, 0xc1, 0xf6, 0x44, 0x9e, 0x49, 0x95, 0xec, 0x89,
+	0x3f, 0x81, 0x23, 0x47, 0x8e, 0xfc, 0x09, 0xfc, 0x09, 0x3d, 0xee, 0x91, 0x13, 0xd0, 0x70, 0xe1,
+	0xb8, 0x7f, 0x02, 0xf2, 0x8c, 0x03, 0xdb, 0x1f, 0xaa, 0x8a, 0xc4, 0x29, 0x33, 0xdf, 0xf9, 0x7e,
+	0xde, 0x9b, 0xbc, 0xf7, 0xc6, 0xf0, 0x24, 0x89, 0x16, 0x2c, 0xeb, 0xd2, 0x09, 0x9d, 0x49, 0x96,
+	0x75, 0x85, 0x9c, 0x44, 0xbc, 0x7b, 0xc6, 0xd3, 0x69, 0x14, 0x16, 0x3f, 0xee, 0x2c, 0xe3, 0x92,
+	0xe3, 0xed, 0xc2, 0xe2, 0x2a, 0x8b, 0xab, 0xcf, 0x9a, 0xdb, 0x21, 0x0f, 0xb9, 0x32, 0x74, 0xf3,
+	0x95, 0xf6, 0x36, 0x9d, 0x90, 0xf3, 0x30, 0x66, 0x5d, 0xb5, 0x1b, 0xcf, 0xa7, 0xdd, 0xc9, 0x3c,
+	0xa3, 0x32, 0xe2, 0xa9, 0x3e, 0x6f, 0xff, 0x62, 0x42, 0xf5, 0x98, 0x66, 0x34, 0x11, 0xb8, 0x0f,
+	0x10, 0xf3, 0x30, 0x10, 0x32, 0x63, 0x34, 0xb1, 0x51, 0x0b, 0x75, 0x1a, 0xbb, 0x8f, 0xdd, 0xdb,
+	0x72, 0xb9, 0x9a, 0x70, 0x4f, 0x94, 0x95, 0x6c, 0xc6, 0x3c, 0xd4, 0x4b, 0xfc, 0x15, 0x6c, 0x09,
+	0x76, 0xce, 0xb2, 0x48, 0x2e, 0x83, 0x98, 0x9d, 0xb3, 0x58, 0xd8, 0xa5, 0x56, 0xb9, 0x63, 0xee,
+	0xbe, 0x7f, 0x77, 0xa0, 0x82, 0x39, 0x50, 0x88, 0x97, 0xca, 0x6c, 0x49, 0x1a, 0xe2, 0x8a, 0x88,
+	0x3d, 0xa8, 0x27, 0x4c, 0x66, 0xd1, 0x99, 0x0e, 0x6c, 0x97, 0xd5, 0x05, 0xdb, 0x77, 0xc6, 0x55,
+	0x28, 0x31, 0x35, 0xa7, 0x36, 0xf8, 0x6d, 0x68, 0xf0, 0xb9, 0x9c, 0xcd, 0x65, 0x40, 0x45, 0xf0,
+	0xad, 0xe0, 0xa9, 0xbd, 0xd1, 0x42, 0x9d, 0x1a, 0xa9, 0x6b, 0xb5, 0x27, 0xbe, 0x10, 0x3c, 0xcd,
+	0x93, 0x15, 0x2e, 0x9d, 0xac, 0x72, 0xff, 0x64, 0x9a, 0xd3, 0xc9, 0x76, 0xa0, 0xd8, 0x06, 0x33,
+	0x2a, 0x9f, 0xd9, 0xd5, 0x16, 0xea, 0x6c, 0x12, 0xd0, 0xd2, 0x31, 0x95, 0xcf, 0x70, 0x0f, 0xde,
+	0x4a, 0xe8, 0x22, 0x48, 0x58, 0x48, 0xc7, 0x4b, 0xc9, 0x44, 0x30, 0x66, 0x53, 0x9e, 0xb1, 0x20,
+	0xe3, 0x52, 0x75, 0xc9, 0x7e, 0xd0, 0x42, 0x9d, 0x0a, 0x69, 0x26, 0x74, 0x71, 0xb8, 0xf6, 0xf4,
+	0x95, 0x85, 0x14, 0x0e, 0xfc, 0x31, 0xd8, 0x79, 0x88, 0x09, 0x5d, 0xde, 0xa4, 0x6b, 0x8a, 0x7e,
+	0x3d, 0xa1, 0x8b, 0x01, 0x5d, 0x5e, 0x07, 0xdf, 0x85, 0x47, 0x39, 0xa8, 0xcc, 0x6c, 0x12, 0x4c,
+	0xa3, 0x98, 0x09, 0x7b, 0x53, 0x11, 0x5b, 0x09, 0x5d, 0x10, 0xad, 0xef, 0xe7, 0x32, 0xfe, 0x08,
+	0xde, 0x38, 0xe3, 0xc9, 0x2c, 0x63, 0x42, 0x5c, 0x03, 0x40, 0x55, 0x6f, 0x7b, 0x7d, 0x7a, 0x85,
+	0x3a, 0x86, 0x47, 0xeb, 0xab, 0x04, 0x51, 0x2a, 0x59, 0x76, 0x4e, 0x63, 0xdb, 0x6c, 0xa1, 0x8e,
+	0xb9, 0xfb, 0xa6, 0xab, 0x07, 0xd3, 0x5d, 0x0f, 0xa6, 0x3b, 0x28, 0x06, 0xb3, 0x5f, 0xbb, 0xf8,
+	0x6d, 0xc7, 0xf8, 0xf1, 0xf7, 0x1d, 0x44, 0xac, 0x35, 0x3d, 0x2c, 0x60, 0xfc, 0x39, 0x98, 0x71,
+	0x94, 0xb2, 0x60, 0xca, 0xb3, 0x84, 0x4a, 0xbb, 0xae, 0xda, 0xf2, 0xe4, 0xee, 0xb6, 0x44, 0x29,
+	0xdb, 0x57, 0x76, 0x02, 0xf1, 0x3f, 0x6b, 0xfc, 0x18, 0x1e, 0xaa, 0x48, 0x92, 0x25, 0xb3, 0x98,
+	0x4a, 0x66, 0x3f, 0x54, 0xcd, 0xa9, 0xe7, 0xa2, 0x5f, 0x68, 0xf8, 0x1b, 0xb0, 0xa2, 0x54, 0x48,
+	0x9a, 0x9e, 0xb1, 0x40, 0x77, 0x4d, 0xd8, 0x0d, 0x35, 0xcf, 0x1f, 0xdc, 0x99, 0x73, 0x58, 0x40,
+	0x23, 0xcd, 0xe8, 0x81, 0xde, 0x8a, 0xae, 0xaa, 0xf9, 0x74, 0x8c, 0xe7, 0xd3, 0x29, 0xcb, 0x02,
+	0x11, 0x3d, 0x67, 0xf6, 0x96, 0x2a, 0x3d, 0x68, 0xe9, 0x24, 0x7a, 0xce, 0x9a, 0x3f, 0x21, 0x68,
+	0x5c, 0x0d, 0x75, 0x7d, 0xa2, 0xd0, 0x8d, 0x89, 0xba, 0x56, 0xa1, 0xd2, 0xff, 0x58, 0xa1, 0xf2,
+	0xcd, 0x0a, 0x35, 0x19, 0xbc, 0x76, 0xcb, 0xe3, 0xc5, 0x16, 0x94, 0xbf, 0x63, 0xcb, 0xe2, 0x7a,
+	0xf9, 0x12, 0xef, 0x41, 0xe5, 0x9c, 0xc6, 0x73, 0x66, 0x97, 0xee, 0xfd, 0x94, 0x34, 0xf0, 0x49,
+	0x69, 0x0f, 0x35, 0x39, 0x6c, 0xdf, 0x56, 0xd3, 0x5b, 0xf2, 0xf4, 0x5e, 0xcd, 0x63, 0xee, 0xbe,
+	0xf7, 0x1f, 0xfa, 0xf4, 0x4a, 0xc2, 0xf6, 0xa7, 0x50, 0x2d, 0x3e, 0x69, 0x00, 0xd5, 0x13, 0x7f,
+	0x30, 0x3a, 0xf5, 0x2d, 0xa3, 0x58, 0x7b, 0x84, 0x58, 0x08, 0xd7, 0x60, 0x63, 0x7f, 0x78, 0xe0,
+	0x59, 0x25, 0x6c, 0x41, 0x9d, 0x8c, 0xfc, 0x9e, 0xef, 0x0d, 0x02, 0xa5, 0x94, 0xdb, 0xef, 0x40,
+	0x45, 0x7f, 0x00, 0x6a, 0xb0, 0x31, 0x3c, 0xda, 0x1f, 0x59, 0x06, 0x36, 0xe1, 0xc1, 0x97, 0x3d,
+	0x72, 0x34, 0x3c, 0xfa, 0xcc, 0x42, 0x78, 0x13, 0x2a, 0x1e, 0x21, 0x23, 0x62, 0x95, 0xda, 0x7b,
+	0x00, 0xff, 0xd6, 0x1f, 0x37, 0x00, 0x4e, 0x7c, 0x72, 0xfa, 0xd4, 0x3f, 0x25, 0xde, 0xc0, 0x32,
+	0x70, 0x1d, 0x6a, 0x4f, 0x47, 0x87, 0xfd, 0xe1, 0x91, 0x37, 0xb0, 0x50, 0xbe, 0xf3, 0xbd, 0xc3,
+	0xe3, 0x83, 0x9e, 0xef, 0x59, 0xa5, 0xfe, 0xde, 0xc5, 0xa5, 0x63, 0xbc, 0xb8, 0x74, 0x8c, 0x5f,
+	0x2f, 0x1d, 0xe3, 0xe5, 0xa5, 0x63, 0x7c, 0xbf, 0x72, 0xd0, 0xcf, 0x2b, 0xc7, 0xb8, 0x58, 0x39,
+	0xe8, 0xc5, 0xca, 0x41, 0x7f, 0xac, 0x1c, 0xf4, 0xd7, 0xca, 0x31, 0x5e, 0xae, 0x1c, 0xf4, 0xc3,
+	0x9f, 0x8e, 0xf1, 0x75, 0x55, 0xff, 0xfb, 0x71, 0x55, 0x3d, 0xba, 0x0f, 0xff, 0x1e, 0x00, 0xad,
+	0x10, 0x4c, 0x36, 0x72, 0x06, 0x00, 0x00,
 }
@@ -0,0 +1,118 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdio // import "istio.io/istio/mixer/adapter/stdio"
+
+import (
+	"net"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+
+	"istio.io/istio/mixer/adapter/stdio/config"
+)
+
+// combinedTemplate renders entries in the combined log format used by Apache and NGINX.
+const combinedTemplate = `{{or .Variables.sourceIp "-"}} - {{or .Variables.sourceUser "-"}} ` +
+	`[{{.Timestamp.Format "02/Jan/2006:15:04:05 -0700"}}] ` +
+	`"{{or .Variables.method "-"}} {{or .Variables.url "-"}} {{or .Variables.protocol "-"}}" ` +
+	`{{or .Variables.responseCode "-"}} {{or .Variables.responseSize "-"}} ` +
+	`"{{or .Variables.referer "-"}}" "{{or .Variables.userAgent "-"}}"`
+
+var bufferPool = buffer.NewPool()
+
+type (
+	// templateEncoder is a zapcore.Encoder which renders each entry through a text template.
+	templateEncoder struct {
+		*zapcore.MapObjectEncoder
+		tmpl *template.Template
+	}
+
+	// templateData is the data made available to line templates.
+	templateData struct {
+		Name      string
+		Level     string
+		Timestamp time.Time
+		Variables map[string]interface{}
+	}
+)
+
+// lineTemplate returns the template source to use for the given line format.
+func lineTemplate(format config.Params_LineFormat, tmpl string) string {
+	if format == config.COMBINED {
+		return combinedTemplate
+	}
+	return tmpl
+}
+
+// parseLineTemplate parses a line template.
+func parseLineTemplate(tmpl string) (*template.Template, error) {
+	return template.New("line").Parse(tmpl)
+}
+
+func newTemplateEncoder(tmpl *template.Template) zapcore.Encoder {
+	return &templateEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		tmpl:             tmpl,
+	}
+}
+
+func (e *templateEncoder) Clone() zapcore.Encoder {
+	clone := &templateEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		tmpl:             e.tmpl,
+	}
+
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+
+	return clone
+}
+
+func (e *templateEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		enc.Fields[k] = v
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	// IP addresses reach us as raw bytes, render them in their usual textual form
+	for k, v := range enc.Fields {
+		if b, ok := v.([]byte); ok && (len(b) == net.IPv4len || len(b) == net.IPv6len) {
+			enc.Fields[k] = net.IP(b)
+		}
+	}
+
+	data := templateData{
+		Name:      entry.LoggerName,
+		Level:     entry.Level.String(),
+		Timestamp: entry.Time,
+		Variables: enc.Fields,
+	}
+
+	buf := bufferPool.Get()
+	if err := e.tmpl.Execute(buf, data); err != nil {
+		buf.Free()
+		return nil, err
+	}
+	buf.AppendString(zapcore.DefaultLineEnding)
+
+	return buf, nil
+}
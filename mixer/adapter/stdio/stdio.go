@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
@@ -35,17 +36,19 @@ import (
 )
 
 type (
-	zapBuilderFn func(options *config.Params) (*zap.Logger, func(), error)
+	zapBuilderFn func(options *config.Params, env adapter.Env) (*zap.Logger, func(), error)
 	getTimeFn    func() time.Time
 	writeFn      func(entry zapcore.Entry, fields []zapcore.Field) error
 
 	handler struct {
 		logger         *zap.Logger
 		closer         func()
+		closeOnce      sync.Once
 		severityLevels map[string]zapcore.Level
 		metricLevel    zapcore.Level
 		getTime        getTimeFn
 		write          writeFn
+		instanceWrites map[string]writeFn
 		logEntryVars   map[string][]string
 		metricDims     map[string][]string
 	}
@@ -68,7 +71,12 @@ func (h *handler) HandleLogEntry(_ context.Context, instances []*logentry.Instan
 			}
 		}
 
-		if err := h.write(entry, fields); err != nil {
+		write := h.write
+		if w, ok := h.instanceWrites[instance.Name]; ok {
+			write = w
+		}
+
+		if err := write(entry, fields); err != nil {
 			errors = multierror.Append(errors, err)
 		}
 		fields = fields[:0]
@@ -104,8 +112,10 @@ func (h *handler) HandleMetric(_ context.Context, instances []*metric.Instance)
 }
 
 func (h *handler) Close() error {
-	_ = h.logger.Sync()
-	h.closer()
+	h.closeOnce.Do(func() {
+		_ = h.logger.Sync()
+		h.closer()
+	})
 	return nil
 }
 
@@ -136,7 +146,7 @@ func GetInfo() adapter.Info {
 			OutputLevel:                config.INFO,
 			OutputAsJson:               true,
 			MaxDaysBeforeRotation:      30,
-			MaxMegabytesBeforeRotation: 100 * 1024 * 1024,
+			MaxMegabytesBeforeRotation: 100,
 			MaxRotatedFiles:            1000,
 			SeverityLevels: map[string]config.Params_Level{
 				"INFORMATIONAL": config.INFO,
@@ -171,24 +181,75 @@ func (b *builder) SetMetricTypes(types map[string]*metric.Type)     { b.metricTy
 func (b *builder) SetAdapterConfig(cfg adapter.Config)              { b.adapterConfig = cfg.(*config.Params) }
 
 func (b *builder) Validate() (ce *adapter.ConfigErrors) {
-	if b.adapterConfig.LogStream == config.STDERR || b.adapterConfig.LogStream == config.STDOUT {
-		if b.adapterConfig.OutputPath != "" {
+	ac := b.adapterConfig
+
+	if ac.LogStream == config.STDERR || ac.LogStream == config.STDOUT {
+		if ac.OutputPath != "" {
 			ce = ce.Appendf("outputPath", "cannot specify an output path when using a STDOUT or STDERR log stream")
 		}
 	} else {
-		if b.adapterConfig.OutputPath == "" {
+		if ac.OutputPath == "" {
 			ce = ce.Appendf("outputPath", "need a valid output path when using a FILE or ROTATED_FILE log stream")
 		}
 	}
 
+	if ac.LineFormat == config.TEMPLATE {
+		if err := validateLineTemplate(ac.LineTemplate); err != nil {
+			ce = ce.Append("lineTemplate", err)
+		}
+	}
+
+	if ac.RotationInterval < 0 {
+		ce = ce.Appendf("rotationInterval", "rotation interval must be >= 0, it is %v", ac.RotationInterval)
+	}
+
+	if ac.BufferSize < 0 {
+		ce = ce.Appendf("bufferSize", "buffer size must be >= 0, it is %d", ac.BufferSize)
+	}
+
+	paths := make(map[string]string, len(ac.InstanceOutputs))
+	for name, out := range ac.InstanceOutputs {
+		field := "instanceOutputs[" + name + "]"
+
+		if out == nil || out.OutputPath == "" {
+			ce = ce.Appendf(field+".outputPath", "need a valid output path")
+			continue
+		}
+
+		if out.OutputPath == ac.OutputPath {
+			ce = ce.Appendf(field+".outputPath", "output path %s is already used as the adapter's output path", out.OutputPath)
+		} else if other, ok := paths[out.OutputPath]; ok {
+			ce = ce.Appendf(field+".outputPath", "output path %s is already used by instance %s", out.OutputPath, other)
+		}
+		paths[out.OutputPath] = name
+
+		if out.LineFormat == config.TEMPLATE {
+			if err := validateLineTemplate(out.LineTemplate); err != nil {
+				ce = ce.Append(field+".lineTemplate", err)
+			}
+		}
+	}
+
 	return
 }
 
+func validateLineTemplate(tmpl string) error {
+	if tmpl == "" {
+		return fmt.Errorf("need a line template when using the TEMPLATE line format")
+	}
+
+	if _, err := parseLineTemplate(tmpl); err != nil {
+		return fmt.Errorf("invalid line template: %v", err)
+	}
+
+	return nil
+}
+
 func (b *builder) Build(context context.Context, env adapter.Env) (adapter.Handler, error) {
 	return b.buildWithZapBuilder(context, env, newZapLogger)
 }
 
-func (b *builder) buildWithZapBuilder(_ context.Context, _ adapter.Env, zb zapBuilderFn) (adapter.Handler, error) {
+func (b *builder) buildWithZapBuilder(_ context.Context, env adapter.Env, zb zapBuilderFn) (adapter.Handler, error) {
 	// We produce sorted tables of the variables we'll receive such that
 	// we send output to the zap logger in a consistent order at runtime
 	varLists := make(map[string][]string, len(b.logEntryTypes))
@@ -215,11 +276,33 @@ func (b *builder) buildWithZapBuilder(_ context.Context, _ adapter.Env, zb zapBu
 		dimLists[tn] = l
 	}
 
-	logger, closer, err := zb(b.adapterConfig)
+	logger, closer, err := zb(b.adapterConfig, env)
 	if err != nil {
 		return nil, fmt.Errorf("could not build logger: %v", err)
 	}
 
+	// Log entries of instances with a dedicated output get a logger of their own
+	instanceWrites := make(map[string]writeFn, len(b.adapterConfig.InstanceOutputs))
+	for name, out := range b.adapterConfig.InstanceOutputs {
+		options := *b.adapterConfig
+		options.OutputPath = out.OutputPath
+		options.LineFormat = out.LineFormat
+		options.LineTemplate = out.LineTemplate
+		options.InstanceOutputs = nil
+		if options.LogStream != config.ROTATED_FILE {
+			options.LogStream = config.FILE
+		}
+
+		il, ic, err := zb(&options, env)
+		if err != nil {
+			closer()
+			return nil, fmt.Errorf("could not build logger for instance %s: %v", name, err)
+		}
+
+		instanceWrites[name] = il.Core().Write
+		closer = chainCloser(closer, il, ic)
+	}
+
 	sl := make(map[string]zapcore.Level)
 	for k, v := range b.adapterConfig.SeverityLevels {
 		sl[k] = levelToZap[v]
@@ -232,7 +315,17 @@ func (b *builder) buildWithZapBuilder(_ context.Context, _ adapter.Env, zb zapBu
 		closer:         closer,
 		getTime:        time.Now,
 		write:          logger.Core().Write,
+		instanceWrites: instanceWrites,
 		logEntryVars:   varLists,
 		metricDims:     dimLists,
 	}, nil
 }
+
+// chainCloser returns a closer which syncs and closes the given logger after invoking the previous closer.
+func chainCloser(previous func(), logger *zap.Logger, closer func()) func() {
+	return func() {
+		previous()
+		_ = logger.Sync()
+		closer()
+	}
+}
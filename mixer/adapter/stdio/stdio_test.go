@@ -20,16 +20,20 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/adapter/stdio/config"
+	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/adapter/test"
 	"istio.io/istio/mixer/template/logentry"
 	"istio.io/istio/mixer/template/metric"
@@ -117,6 +121,41 @@ func TestBuilder(t *testing.T) {
 			OutputPath: name,
 		}, zapcore.InfoLevel, false, true},
 
+		{config.Params{
+			LogStream:            config.ROTATED_FILE,
+			OutputPath:           name,
+			CompressRotatedFiles: true,
+			RotationInterval:     time.Hour,
+		}, zapcore.InfoLevel, false, true},
+
+		{config.Params{
+			LogStream:  config.STDOUT,
+			BufferSize: 10,
+		}, zapcore.InfoLevel, false, true},
+
+		{config.Params{
+			LineFormat: config.COMBINED,
+		}, zapcore.InfoLevel, false, true},
+
+		{config.Params{
+			LineFormat:   config.TEMPLATE,
+			LineTemplate: "{{.Name",
+		}, zapcore.InfoLevel, false, false},
+
+		{config.Params{
+			LogStream: config.STDOUT,
+			InstanceOutputs: map[string]*config.Params_InstanceOutput{
+				"Foo": {OutputPath: name},
+			},
+		}, zapcore.InfoLevel, false, true},
+
+		{config.Params{
+			LogStream: config.STDOUT,
+			InstanceOutputs: map[string]*config.Params_InstanceOutput{
+				"Foo": {OutputPath: "/"},
+			},
+		}, zapcore.InfoLevel, false, false},
+
 		{config.Params{
 			MetricLevel: config.INFO,
 		}, zapcore.InfoLevel, false, true},
@@ -141,12 +180,12 @@ func TestBuilder(t *testing.T) {
 
 	for i, c := range cases {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			zb := func(options *config.Params) (*zap.Logger, func(), error) {
+			zb := func(options *config.Params, env adapter.Env) (*zap.Logger, func(), error) {
 				if c.induceError {
 					return nil, func() {}, errors.New("expected")
 				}
 
-				return newZapLogger(options)
+				return newZapLogger(options, env)
 			}
 
 			info := GetInfo()
@@ -643,6 +682,343 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateOutputs(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(cfg *config.Params)
+		result bool
+	}{
+		{"default", func(cfg *config.Params) {}, true},
+		{"combined", func(cfg *config.Params) { cfg.LineFormat = config.COMBINED }, true},
+		{"template", func(cfg *config.Params) {
+			cfg.LineFormat = config.TEMPLATE
+			cfg.LineTemplate = "{{.Name}} {{.Variables.method}}"
+		}, true},
+		{"missing template", func(cfg *config.Params) { cfg.LineFormat = config.TEMPLATE }, false},
+		{"bad template", func(cfg *config.Params) {
+			cfg.LineFormat = config.TEMPLATE
+			cfg.LineTemplate = "{{.Name"
+		}, false},
+		{"negative rotation interval", func(cfg *config.Params) { cfg.RotationInterval = -time.Second }, false},
+		{"negative buffer size", func(cfg *config.Params) { cfg.BufferSize = -1 }, false},
+		{"instance outputs", func(cfg *config.Params) {
+			cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{
+				"a": {OutputPath: "/tmp/a.log"},
+				"b": {OutputPath: "/tmp/b.log", LineFormat: config.TEMPLATE, LineTemplate: "{{.Name}}"},
+			}
+		}, true},
+		{"instance output without path", func(cfg *config.Params) {
+			cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{"a": {}}
+		}, false},
+		{"nil instance output", func(cfg *config.Params) {
+			cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{"a": nil}
+		}, false},
+		{"duplicate instance output paths", func(cfg *config.Params) {
+			cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{
+				"a": {OutputPath: "/tmp/a.log"},
+				"b": {OutputPath: "/tmp/a.log"},
+			}
+		}, false},
+		{"instance output using the adapter output path", func(cfg *config.Params) {
+			cfg.LogStream = config.FILE
+			cfg.OutputPath = "/tmp/a.log"
+			cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{"a": {OutputPath: "/tmp/a.log"}}
+		}, false},
+		{"instance output with bad template", func(cfg *config.Params) {
+			cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{
+				"a": {OutputPath: "/tmp/a.log", LineFormat: config.TEMPLATE},
+			}
+		}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info := GetInfo()
+			cfg := *info.DefaultConfig.(*config.Params)
+			c.modify(&cfg)
+			b := info.NewBuilder().(*builder)
+			b.SetAdapterConfig(&cfg)
+
+			err := b.Validate()
+			if err != nil && c.result {
+				t.Errorf("Got %v, expected success", err)
+			} else if err == nil && !c.result {
+				t.Error("Got success, expected failure")
+			}
+		})
+	}
+}
+
+func TestLineFormats(t *testing.T) {
+	types := map[string]*logentry.Type{
+		"Foo": {
+			Variables: map[string]descriptor.ValueType{
+				"sourceIp":     descriptor.IP_ADDRESS,
+				"sourceUser":   descriptor.STRING,
+				"method":       descriptor.STRING,
+				"url":          descriptor.STRING,
+				"protocol":     descriptor.STRING,
+				"responseCode": descriptor.INT64,
+				"responseSize": descriptor.INT64,
+				"referer":      descriptor.STRING,
+				"userAgent":    descriptor.STRING,
+			},
+		},
+	}
+
+	tm := time.Date(2017, time.August, 21, 10, 4, 00, 0, time.UTC)
+
+	full := &logentry.Instance{
+		Name:      "Foo",
+		Severity:  "WARNING",
+		Timestamp: tm,
+		Variables: map[string]interface{}{
+			"sourceIp":     []byte{10, 0, 0, 1},
+			"sourceUser":   "alice",
+			"method":       "GET",
+			"url":          "/index.html",
+			"protocol":     "http",
+			"responseCode": int64(200),
+			"responseSize": int64(1024),
+			"referer":      "http://foo.com",
+			"userAgent":    "curl/7.54.0",
+		},
+	}
+
+	sparse := &logentry.Instance{
+		Name:      "Foo",
+		Timestamp: tm,
+		Variables: map[string]interface{}{
+			"method":     "GET",
+			"url":        "/",
+			"sourceUser": "",
+		},
+	}
+
+	cases := []struct {
+		name      string
+		format    config.Params_LineFormat
+		template  string
+		instances []*logentry.Instance
+		expected  []string
+	}{
+		{
+			"combined",
+			config.COMBINED,
+			"",
+			[]*logentry.Instance{full, sparse},
+			[]string{
+				`10.0.0.1 - alice [21/Aug/2017:10:04:00 +0000] "GET /index.html http" 200 1024 "http://foo.com" "curl/7.54.0"`,
+				`- - - [21/Aug/2017:10:04:00 +0000] "GET / -" - - "-" "-"`,
+			},
+		},
+		{
+			"template",
+			config.TEMPLATE,
+			`{{.Timestamp.Format "2006-01-02"}} {{.Name}} {{.Level}} {{.Variables.sourceIp}} {{.Variables.method}} {{.Variables.responseCode}}`,
+			[]*logentry.Instance{full},
+			[]string{
+				`2017-08-21 Foo warn 10.0.0.1 GET 200`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lines, _ := captureStdout(func() {
+				info := GetInfo()
+				cfg := *info.DefaultConfig.(*config.Params)
+				cfg.LineFormat = c.format
+				cfg.LineTemplate = c.template
+				b := info.NewBuilder().(*builder)
+				b.SetAdapterConfig(&cfg)
+				b.SetLogEntryTypes(types)
+				h, err := b.Build(context.Background(), test.NewEnv(t))
+				if err != nil {
+					t.Fatalf("Got %v, expecting success", err)
+				}
+
+				if err := h.(*handler).HandleLogEntry(context.Background(), c.instances); err != nil {
+					t.Errorf("Got %v, expecting success", err)
+				}
+
+				if err := h.Close(); err != nil {
+					t.Errorf("Got error %v, expecting success", err)
+				}
+			})
+
+			if len(lines) != len(c.expected) {
+				t.Fatalf("Got %d lines of output, expected %d: %v", len(lines), len(c.expected), lines)
+			}
+
+			for i, l := range c.expected {
+				if lines[i] != l {
+					t.Errorf("Output line %d\nGot      : %s\nExpecting: %s", i, lines[i], l)
+				}
+			}
+		})
+	}
+}
+
+func TestInstanceOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestInstanceOutputs")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	types := map[string]*logentry.Type{
+		"access":  {Variables: map[string]descriptor.ValueType{"method": descriptor.STRING}},
+		"general": {Variables: map[string]descriptor.ValueType{"method": descriptor.STRING}},
+	}
+
+	info := GetInfo()
+	cfg := *info.DefaultConfig.(*config.Params)
+	cfg.LogStream = config.FILE
+	cfg.OutputPath = filepath.Join(dir, "mixer.log")
+	cfg.InstanceOutputs = map[string]*config.Params_InstanceOutput{
+		"access": {
+			OutputPath:   filepath.Join(dir, "access.log"),
+			LineFormat:   config.TEMPLATE,
+			LineTemplate: "{{.Name}} {{.Variables.method}}",
+		},
+	}
+
+	b := info.NewBuilder().(*builder)
+	b.SetAdapterConfig(&cfg)
+	b.SetLogEntryTypes(types)
+
+	if ce := b.Validate(); ce != nil {
+		t.Fatalf("Got %v, expecting success", ce)
+	}
+
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got %v, expecting success", err)
+	}
+
+	instances := []*logentry.Instance{
+		{Name: "access", Variables: map[string]interface{}{"method": "GET"}},
+		{Name: "general", Variables: map[string]interface{}{"method": "POST"}},
+		{Name: "access", Variables: map[string]interface{}{"method": "PUT"}},
+	}
+
+	if err = h.(*handler).HandleLogEntry(context.Background(), instances); err != nil {
+		t.Errorf("Got %v, expecting success", err)
+	}
+
+	if err = h.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+
+	access := readLines(t, filepath.Join(dir, "access.log"))
+	if len(access) != 2 || access[0] != "access GET" || access[1] != "access PUT" {
+		t.Errorf("Got access log %v, expecting the two access entries", access)
+	}
+
+	general := readLines(t, filepath.Join(dir, "mixer.log"))
+	if len(general) != 1 || !strings.Contains(general[0], `"instance":"general"`) {
+		t.Errorf("Got general log %v, expecting the single general entry", general)
+	}
+}
+
+func TestRotationInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRotationInterval")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	info := GetInfo()
+	cfg := *info.DefaultConfig.(*config.Params)
+	cfg.LogStream = config.ROTATED_FILE
+	cfg.OutputPath = filepath.Join(dir, "mixer.log")
+	cfg.RotationInterval = 10 * time.Millisecond
+
+	b := info.NewBuilder().(*builder)
+	b.SetAdapterConfig(&cfg)
+	h, err := b.Build(context.Background(), test.NewEnv(t))
+	if err != nil {
+		t.Fatalf("Got %v, expecting success", err)
+	}
+
+	instances := []*logentry.Instance{{Name: "Foo"}}
+	if err = h.(*handler).HandleLogEntry(context.Background(), instances); err != nil {
+		t.Errorf("Got %v, expecting success", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		files, _ := ioutil.ReadDir(dir)
+		if len(files) > 1 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %d files, expecting the log file to have been rotated", len(files))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = h.Close(); err != nil {
+		t.Errorf("Got error %v, expecting success", err)
+	}
+}
+
+// blockingSyncer is a zapcore.WriteSyncer which blocks writes until released.
+type blockingSyncer struct {
+	sync.Mutex
+	release chan struct{}
+	lines   []string
+}
+
+func (b *blockingSyncer) Write(p []byte) (int, error) {
+	<-b.release
+	b.Lock()
+	b.lines = append(b.lines, string(p))
+	b.Unlock()
+	return len(p), nil
+}
+
+func (b *blockingSyncer) Sync() error { return nil }
+
+func TestAsyncWriter(t *testing.T) {
+	env := test.NewEnv(t)
+	out := &blockingSyncer{release: make(chan struct{})}
+	w := newAsyncWriter(out, 2, env)
+
+	// the first line is picked up by the background task and blocks it,
+	// the next two fill the buffer, and the rest are dropped
+	_, _ = w.Write([]byte("0"))
+	for len(w.lines) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 1; i < 6; i++ {
+		if _, err := w.Write([]byte(strconv.Itoa(i))); err != nil {
+			t.Errorf("Got %v, expecting success", err)
+		}
+	}
+
+	close(out.release)
+	w.close()
+
+	if len(out.lines) != 3 || out.lines[0] != "0" || out.lines[1] != "1" || out.lines[2] != "2" {
+		t.Errorf("Got lines %v, expecting [0 1 2]", out.lines)
+	}
+
+	found := false
+	for _, l := range env.GetLogs() {
+		if strings.Contains(l, "Dropped 3 log entries") {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Got logs %v, expecting a report of 3 dropped entries", env.GetLogs())
+	}
+}
+
 func TestWriteErrors(t *testing.T) {
 	logEntryTypes := map[string]*logentry.Type{
 		"Foo": {
@@ -706,6 +1082,16 @@ func TestWriteErrors(t *testing.T) {
 	}
 }
 
+func readLines(t *testing.T, path string) []string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read %s: %v", path, err)
+	}
+
+	s := strings.Trim(string(content), "\n")
+	return strings.Split(s, "\n")
+}
+
 // Runs the given function while capturing everything sent to stdout
 func captureStdout(f func()) ([]string, error) {
 	tf, err := ioutil.TempFile("", "tracing_test")
@@ -733,3 +1119,51 @@ func captureStdout(f func()) ([]string, error) {
 	s := strings.Trim(string(content), "\n")
 	return strings.Split(s, "\n"), nil
 }
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestConformance")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	info := GetInfo()
+	cfg := *info.DefaultConfig.(*config.Params)
+	cfg.LogStream = config.ROTATED_FILE
+	cfg.OutputPath = filepath.Join(dir, "mixer.log")
+	cfg.RotationInterval = time.Second
+	cfg.BufferSize = 100
+
+	invalid := *info.DefaultConfig.(*config.Params)
+	invalid.LogStream = config.FILE
+
+	test.RunConformance(t, test.Conformance{
+		Info:           info,
+		Config:         &cfg,
+		InvalidConfigs: []adapter.Config{&invalid},
+		Types: map[string]map[string]proto.Message{
+			logentry.TemplateName: {
+				"accesslog": &logentry.Type{Variables: map[string]descriptor.ValueType{"code": descriptor.INT64}},
+			},
+			metric.TemplateName: {
+				"requestcount": &metric.Type{
+					Value:      descriptor.INT64,
+					Dimensions: map[string]descriptor.ValueType{"source": descriptor.STRING},
+				},
+			},
+		},
+		Instances: map[string][]interface{}{
+			logentry.TemplateName: {&logentry.Instance{
+				Name:      "accesslog",
+				Severity:  "INFO",
+				Timestamp: time.Now(),
+				Variables: map[string]interface{}{"code": int64(200)},
+			}},
+			metric.TemplateName: {&metric.Instance{
+				Name:       "requestcount",
+				Value:      int64(1),
+				Dimensions: map[string]interface{}{"source": "foo"},
+			}},
+		},
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"istio.io/istio/mixer/adapter/stdio/config"
+	"istio.io/istio/mixer/pkg/adapter"
 )
 
 var levelToZap = map[config.Params_Level]zapcore.Level{
@@ -32,29 +33,13 @@ var levelToZap = map[config.Params_Level]zapcore.Level{
 }
 
 // Creates a zap logger based on the given options
-func newZapLogger(options *config.Params) (*zap.Logger, func(), error) {
-	encCfg := zapcore.EncoderConfig{
-		TimeKey:        "time",
-		LevelKey:       "level",
-		NameKey:        "instance",
-		CallerKey:      "caller",
-		StacktraceKey:  "stack",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeTime:     formatDate,
-	}
-
-	var enc zapcore.Encoder
-	if options.OutputAsJson {
-		enc = zapcore.NewJSONEncoder(encCfg)
-	} else {
-		enc = zapcore.NewConsoleEncoder(encCfg)
+func newZapLogger(options *config.Params, env adapter.Env) (*zap.Logger, func(), error) {
+	enc, err := newEncoder(options)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var outputSink zapcore.WriteSyncer
-	var err error
 	var closer = func() {}
 
 	switch options.LogStream {
@@ -62,13 +47,22 @@ func newZapLogger(options *config.Params) (*zap.Logger, func(), error) {
 		lj := &lumberjack.Logger{
 			Filename:   options.OutputPath,
 			MaxSize:    int(options.MaxMegabytesBeforeRotation),
-			MaxBackups: int(options.MaxDaysBeforeRotation),
-			MaxAge:     int(options.MaxRotatedFiles),
+			MaxBackups: int(options.MaxRotatedFiles),
+			MaxAge:     int(options.MaxDaysBeforeRotation),
+			Compress:   options.CompressRotatedFiles,
 		}
 
 		outputSink = zapcore.AddSync(lj)
 		closer = func() { _ = lj.Close() }
 
+		if options.RotationInterval > 0 {
+			stop := rotatePeriodically(lj, options.RotationInterval, env)
+			closer = func() {
+				stop()
+				_ = lj.Close()
+			}
+		}
+
 	case config.FILE:
 		if outputSink, closer, err = zap.Open(options.OutputPath); err != nil {
 			return nil, nil, err
@@ -81,6 +75,17 @@ func newZapLogger(options *config.Params) (*zap.Logger, func(), error) {
 		outputSink = os.Stderr
 	}
 
+	if options.BufferSize > 0 {
+		aw := newAsyncWriter(outputSink, int(options.BufferSize), env)
+		outputSink = aw
+
+		sinkCloser := closer
+		closer = func() {
+			aw.close()
+			sinkCloser()
+		}
+	}
+
 	l := zap.New(
 		zapcore.NewCore(enc, outputSink, zap.NewAtomicLevelAt(levelToZap[options.OutputLevel])),
 		zap.ErrorOutput(os.Stderr))
@@ -88,6 +93,64 @@ func newZapLogger(options *config.Params) (*zap.Logger, func(), error) {
 	return l, closer, nil
 }
 
+// Creates the encoder producing output lines in the format selected by the given options
+func newEncoder(options *config.Params) (zapcore.Encoder, error) {
+	if options.LineFormat != config.STRUCTURED {
+		tmpl, err := parseLineTemplate(lineTemplate(options.LineFormat, options.LineTemplate))
+		if err != nil {
+			return nil, err
+		}
+		return newTemplateEncoder(tmpl), nil
+	}
+
+	encCfg := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "instance",
+		CallerKey:      "caller",
+		StacktraceKey:  "stack",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeTime:     formatDate,
+	}
+
+	if options.OutputAsJson {
+		return zapcore.NewJSONEncoder(encCfg), nil
+	}
+	return zapcore.NewConsoleEncoder(encCfg), nil
+}
+
+// Rotates the given file at a fixed interval until the returned function is called
+func rotatePeriodically(lj *lumberjack.Logger, interval time.Duration, env adapter.Env) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	env.ScheduleDaemon(func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := lj.Rotate(); err != nil {
+					_ = env.Logger().Errorf("Unable to rotate log file %s: %v", lj.Filename, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	})
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
 func formatDate(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	t = t.UTC()
 	year, month, day := t.Date()
@@ -0,0 +1,209 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+type exporterOptions struct {
+	maxBatchSize   int
+	flushInterval  time.Duration
+	maxQueueSize   int
+	maxRetries     int
+	retryBackoff   time.Duration
+	requestTimeout time.Duration
+}
+
+// exporter buffers spans in a bounded queue and posts them to the collector
+// in batches from a single background goroutine. Spans that arrive while the
+// queue is full are dropped rather than blocking the caller.
+type exporter struct {
+	env    adapter.Env
+	client *http.Client
+	url    string
+	enc    encoder
+	opts   exporterOptions
+
+	queue   chan *span
+	dropped int64 // accessed atomically
+
+	done      chan struct{} // closed to request shutdown
+	stopped   chan struct{} // closed once the final batch has been sent
+	closeOnce sync.Once
+}
+
+func newExporter(env adapter.Env, client *http.Client, url string, enc encoder, opts exporterOptions) *exporter {
+	return &exporter{
+		env:     env,
+		client:  client,
+		url:     url,
+		enc:     enc,
+		opts:    opts,
+		queue:   make(chan *span, opts.maxQueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (e *exporter) start() {
+	e.env.ScheduleDaemon(e.run)
+}
+
+// enqueue adds a span to the export queue, dropping it if the queue is full.
+func (e *exporter) enqueue(s *span) {
+	select {
+	case e.queue <- s:
+	default:
+		atomic.AddInt64(&e.dropped, 1)
+	}
+}
+
+// close stops the exporter after sending whatever is still queued. It is
+// safe to call close more than once.
+func (e *exporter) close() error {
+	e.closeOnce.Do(func() { close(e.done) })
+	<-e.stopped
+	return nil
+}
+
+func (e *exporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.opts.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*span, 0, e.opts.maxBatchSize)
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= e.opts.maxBatchSize {
+				e.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			e.flush(batch)
+			batch = batch[:0]
+
+		case <-e.done:
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+					if len(batch) >= e.opts.maxBatchSize {
+						e.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					e.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush posts a batch of spans. The export runs detached from any request,
+// so failures can only be logged.
+func (e *exporter) flush(batch []*span) {
+	if n := atomic.SwapInt64(&e.dropped, 0); n > 0 {
+		e.env.Logger().Warningf("Dropped %d trace spans because the export queue was full", n)
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := e.send(batch); err != nil {
+		_ = e.env.Logger().Errorf("Unable to export %d trace spans to %s: %v", len(batch), e.url, err)
+	} else if e.env.Logger().VerbosityLevel(4) {
+		e.env.Logger().Infof("Exported %d trace spans to %s", len(batch), e.url)
+	}
+}
+
+func (e *exporter) send(batch []*span) error {
+	bodies, err := e.enc.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	var result *multierror.Error
+	for _, body := range bodies {
+		if err := e.postWithRetry(body); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// postWithRetry posts a body, retrying transient failures with exponential
+// backoff. Once shutdown has been requested no further retries are made.
+func (e *exporter) postWithRetry(body []byte) error {
+	backoff := e.opts.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(body)
+		if err == nil || !retry || attempt >= e.opts.maxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-e.done:
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single request to the collector. It reports whether a failure
+// is worth retrying: network errors, 429 and 5xx responses are, any other
+// rejection is not.
+func (e *exporter) post(body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", e.enc.contentType())
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.requestTimeout)
+	defer cancel()
+
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("collector responded with %s", resp.Status)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
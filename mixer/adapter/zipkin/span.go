@@ -0,0 +1,262 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+// serviceTag is the span tag that names the service a span belongs to.
+const serviceTag = "source.service"
+
+// span is the collector-neutral form of a tracespan instance.
+type span struct {
+	traceIDHigh uint64
+	traceIDLow  uint64
+	id          uint64
+	parentID    uint64 // 0 for root spans
+	name        string
+	service     string
+	start       time.Time
+	duration    time.Duration
+	tags        map[string]string
+}
+
+// newSpan converts a tracespan instance, validating its identifiers. Spans
+// without a span ID are assigned a random one.
+func newSpan(inst *tracespan.Instance, defaultService string) (*span, error) {
+	s := &span{
+		name:     inst.SpanName,
+		service:  defaultService,
+		start:    inst.StartTime,
+		duration: inst.EndTime.Sub(inst.StartTime),
+		tags:     make(map[string]string, len(inst.SpanTags)),
+	}
+
+	var err error
+	if s.traceIDHigh, s.traceIDLow, err = parseTraceID(inst.TraceId); err != nil {
+		return nil, err
+	}
+
+	if inst.SpanId == "" {
+		s.id = uint64(rand.Int63())
+	} else if s.id, err = parseSpanID(inst.SpanId); err != nil {
+		return nil, fmt.Errorf("invalid span id %q: %v", inst.SpanId, err)
+	}
+
+	if inst.ParentSpanId != "" {
+		if s.parentID, err = parseSpanID(inst.ParentSpanId); err != nil {
+			return nil, fmt.Errorf("invalid parent span id %q: %v", inst.ParentSpanId, err)
+		}
+	}
+
+	if s.duration < 0 {
+		s.duration = 0
+	}
+
+	for k, v := range inst.SpanTags {
+		s.tags[k] = tagValue(v)
+	}
+	if svc := s.tags[serviceTag]; svc != "" {
+		s.service = svc
+	}
+
+	return s, nil
+}
+
+// parseTraceID parses a 64 or 128-bit hex-encoded trace ID.
+func parseTraceID(id string) (high, low uint64, err error) {
+	if id == "" || len(id) > 32 {
+		return 0, 0, fmt.Errorf("invalid trace id %q", id)
+	}
+	if len(id) > 16 {
+		if high, err = strconv.ParseUint(id[:len(id)-16], 16, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid trace id %q: %v", id, err)
+		}
+		id = id[len(id)-16:]
+	}
+	if low, err = strconv.ParseUint(id, 16, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid trace id %q: %v", id, err)
+	}
+	return high, low, nil
+}
+
+func parseSpanID(id string) (uint64, error) {
+	if len(id) > 16 {
+		return 0, errors.New("longer than 16 hex characters")
+	}
+	return strconv.ParseUint(id, 16, 64)
+}
+
+func tagValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		// IP_ADDRESS values are delivered as raw bytes.
+		if len(t) == net.IPv4len || len(t) == net.IPv6len {
+			return net.IP(t).String()
+		}
+		return string(t)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// sampler makes a per-trace sampling decision, so that all the spans of a
+// trace are either exported or dropped together.
+type sampler struct {
+	all       bool
+	threshold uint64
+}
+
+func newSampler(probability float64) sampler {
+	if probability <= 0 || probability >= 1 {
+		return sampler{all: true}
+	}
+	return sampler{threshold: uint64(probability * math.MaxUint64)}
+}
+
+func (s sampler) sample(sp *span) bool {
+	return s.all || sp.traceIDLow < s.threshold
+}
+
+// encoder serializes a batch of spans into one or more request bodies.
+type encoder interface {
+	contentType() string
+	encode(spans []*span) ([][]byte, error)
+}
+
+// zipkinEncoder encodes spans using the Zipkin v2 JSON model.
+type zipkinEncoder struct{}
+
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	Duration      int64             `json:"duration,omitempty"`
+	LocalEndpoint *zipkinEndpoint   `json:"localEndpoint,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+func (zipkinEncoder) contentType() string {
+	return "application/json"
+}
+
+func (zipkinEncoder) encode(spans []*span) ([][]byte, error) {
+	out := make([]zipkinSpan, 0, len(spans))
+	for _, s := range spans {
+		zs := zipkinSpan{
+			TraceID:       formatID(s.traceIDLow),
+			ID:            formatID(s.id),
+			Name:          s.name,
+			Timestamp:     toMicros(s.start),
+			Duration:      int64(s.duration / time.Microsecond),
+			LocalEndpoint: &zipkinEndpoint{ServiceName: s.service},
+			Tags:          s.tags,
+		}
+		if s.traceIDHigh != 0 {
+			zs.TraceID = formatID(s.traceIDHigh) + zs.TraceID
+		}
+		if s.parentID != 0 {
+			zs.ParentID = formatID(s.parentID)
+		}
+		out = append(out, zs)
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{b}, nil
+}
+
+// jaegerEncoder encodes spans as Jaeger Thrift batches. Jaeger attaches the
+// service name to a batch rather than to each span, so one batch is produced
+// per service.
+type jaegerEncoder struct{}
+
+func (jaegerEncoder) contentType() string {
+	return "application/x-thrift"
+}
+
+func (jaegerEncoder) encode(spans []*span) ([][]byte, error) {
+	var services []string
+	batches := make(map[string]*jaeger.Batch)
+	for _, s := range spans {
+		b, found := batches[s.service]
+		if !found {
+			b = &jaeger.Batch{Process: &jaeger.Process{ServiceName: s.service}}
+			batches[s.service] = b
+			services = append(services, s.service)
+		}
+		b.Spans = append(b.Spans, toJaegerSpan(s))
+	}
+
+	out := make([][]byte, 0, len(services))
+	for _, svc := range services {
+		buf := thrift.NewTMemoryBuffer()
+		if err := batches[svc].Write(thrift.NewTBinaryProtocolTransport(buf)); err != nil {
+			return nil, err
+		}
+		out = append(out, buf.Bytes())
+	}
+	return out, nil
+}
+
+func toJaegerSpan(s *span) *jaeger.Span {
+	js := &jaeger.Span{
+		TraceIdLow:    int64(s.traceIDLow),
+		TraceIdHigh:   int64(s.traceIDHigh),
+		SpanId:        int64(s.id),
+		ParentSpanId:  int64(s.parentID),
+		OperationName: s.name,
+		Flags:         1, // sampled
+		StartTime:     toMicros(s.start),
+		Duration:      int64(s.duration / time.Microsecond),
+		Tags:          make([]*jaeger.Tag, 0, len(s.tags)),
+	}
+	for k, v := range s.tags {
+		v := v
+		js.Tags = append(js.Tags, &jaeger.Tag{Key: k, VType: jaeger.TagType_STRING, VStr: &v})
+	}
+	return js
+}
+
+func formatID(id uint64) string {
+	return fmt.Sprintf("%016x", id)
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
@@ -0,0 +1,209 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f mixer/adapter/zipkin/config/config.proto
+
+// Package zipkin provides an adapter that exports trace spans to a Zipkin
+// collector using the v2 JSON API, or to a Jaeger collector using Thrift
+// over HTTP. Spans are sampled, queued and posted in batches by a background
+// exporter, so the request path never blocks on the collector.
+package zipkin
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"istio.io/istio/mixer/adapter/zipkin/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+const (
+	defaultServiceName    = "istio-mesh"
+	defaultMaxBatchSize   = 100
+	defaultFlushInterval  = time.Second
+	defaultMaxQueueSize   = 10000
+	defaultRetryBackoff   = 100 * time.Millisecond
+	defaultRequestTimeout = 5 * time.Second
+)
+
+type (
+	builder struct {
+		adpCfg *config.Params
+	}
+
+	handler struct {
+		env         adapter.Env
+		serviceName string
+		sampler     sampler
+		exporter    *exporter
+	}
+)
+
+// ensure types implement the requisite interfaces
+var _ tracespan.HandlerBuilder = &builder{}
+var _ tracespan.Handler = &handler{}
+
+///////////////// Configuration-time Methods ///////////////
+
+// adapter.HandlerBuilder#Build
+func (b *builder) Build(ctx context.Context, env adapter.Env) (adapter.Handler, error) {
+	ac := b.adpCfg
+
+	var enc encoder = zipkinEncoder{}
+	if ac.Format == config.JAEGER_THRIFT {
+		enc = jaegerEncoder{}
+	}
+
+	e := newExporter(env, &http.Client{}, ac.CollectorUrl, enc, exporterOptions{
+		maxBatchSize:   int(withDefault(ac.MaxBatchSize, defaultMaxBatchSize)),
+		flushInterval:  durationWithDefault(ac.FlushInterval, defaultFlushInterval),
+		maxQueueSize:   int(withDefault(ac.MaxQueueSize, defaultMaxQueueSize)),
+		maxRetries:     int(ac.MaxRetries),
+		retryBackoff:   durationWithDefault(ac.RetryBackoff, defaultRetryBackoff),
+		requestTimeout: durationWithDefault(ac.RequestTimeout, defaultRequestTimeout),
+	})
+	e.start()
+
+	serviceName := ac.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	return &handler{
+		env:         env,
+		serviceName: serviceName,
+		sampler:     newSampler(ac.SampleProbability),
+		exporter:    e,
+	}, nil
+}
+
+// adapter.HandlerBuilder#SetAdapterConfig
+func (b *builder) SetAdapterConfig(cfg adapter.Config) {
+	b.adpCfg = cfg.(*config.Params)
+}
+
+// adapter.HandlerBuilder#Validate
+func (b *builder) Validate() (ce *adapter.ConfigErrors) {
+	ac := b.adpCfg
+
+	if u, err := url.ParseRequestURI(ac.CollectorUrl); err != nil {
+		ce = ce.Append("collector_url", err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		ce = ce.Appendf("collector_url", "unsupported scheme %q, must be http or https", u.Scheme)
+	}
+
+	if _, ok := config.Params_Format_name[int32(ac.Format)]; !ok {
+		ce = ce.Appendf("format", "unknown format %v", ac.Format)
+	}
+
+	if ac.SampleProbability < 0 || ac.SampleProbability > 1 {
+		ce = ce.Appendf("sample_probability", "must be between 0 and 1, got %v", ac.SampleProbability)
+	}
+
+	if ac.MaxBatchSize < 0 {
+		ce = ce.Appendf("max_batch_size", "must be >= 0, got %d", ac.MaxBatchSize)
+	}
+	if ac.MaxQueueSize < 0 {
+		ce = ce.Appendf("max_queue_size", "must be >= 0, got %d", ac.MaxQueueSize)
+	}
+	if ac.MaxRetries < 0 {
+		ce = ce.Appendf("max_retries", "must be >= 0, got %d", ac.MaxRetries)
+	}
+
+	for field, d := range map[string]time.Duration{
+		"flush_interval":  ac.FlushInterval,
+		"retry_backoff":   ac.RetryBackoff,
+		"request_timeout": ac.RequestTimeout,
+	} {
+		if d < 0 {
+			ce = ce.Appendf(field, "must be >= 0, got %v", d)
+		}
+	}
+
+	return
+}
+
+// tracespan.HandlerBuilder#SetTraceSpanTypes
+func (b *builder) SetTraceSpanTypes(types map[string]*tracespan.Type) {}
+
+////////////////// Request-time Methods //////////////////////////
+
+// tracespan.Handler#HandleTraceSpan
+func (h *handler) HandleTraceSpan(ctx context.Context, insts []*tracespan.Instance) error {
+	for _, inst := range insts {
+		s, err := newSpan(inst, h.serviceName)
+		if err != nil {
+			// A malformed span must not fail the whole report; skip it and move on.
+			if h.env.Logger().VerbosityLevel(4) {
+				h.env.Logger().Infof("Dropping trace span %s: %v", inst.Name, err)
+			}
+			continue
+		}
+
+		if !h.sampler.sample(s) {
+			continue
+		}
+
+		h.exporter.enqueue(s)
+	}
+	return nil
+}
+
+// adapter.Handler#Close
+func (h *handler) Close() error {
+	return h.exporter.close()
+}
+
+////////////////// Bootstrap //////////////////////////
+
+// GetInfo returns the adapter.Info specific to this adapter.
+func GetInfo() adapter.Info {
+	return adapter.Info{
+		Name:        "zipkin",
+		Description: "Exports trace spans to a Zipkin or Jaeger collector",
+		Impl:        "istio.io/istio/mixer/adapter/zipkin",
+		SupportedTemplates: []string{
+			tracespan.TemplateName,
+		},
+		NewBuilder: func() adapter.HandlerBuilder { return &builder{} },
+		DefaultConfig: &config.Params{
+			CollectorUrl:   "http://zipkin:9411/api/v2/spans",
+			Format:         config.ZIPKIN_V2,
+			ServiceName:    defaultServiceName,
+			MaxBatchSize:   defaultMaxBatchSize,
+			FlushInterval:  defaultFlushInterval,
+			MaxQueueSize:   defaultMaxQueueSize,
+			MaxRetries:     3,
+			RetryBackoff:   defaultRetryBackoff,
+			RequestTimeout: defaultRequestTimeout,
+		},
+	}
+}
+
+func withDefault(v, def int64) int64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func durationWithDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,552 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+
+	"istio.io/istio/mixer/adapter/zipkin/config"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/template/tracespan"
+)
+
+// collector is a fake trace collector recording the requests it receives.
+type collector struct {
+	sync.Mutex
+	bodies       [][]byte
+	contentTypes []string
+
+	// responses are returned in order; once exhausted, 202 is returned.
+	responses []int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	c.Lock()
+	defer c.Unlock()
+	c.bodies = append(c.bodies, body)
+	c.contentTypes = append(c.contentTypes, r.Header.Get("Content-Type"))
+
+	code := http.StatusAccepted
+	if len(c.responses) > 0 {
+		code, c.responses = c.responses[0], c.responses[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (c *collector) requests() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.bodies)
+}
+
+func (c *collector) zipkinSpans(t *testing.T) []zipkinSpan {
+	c.Lock()
+	defer c.Unlock()
+
+	var all []zipkinSpan
+	for _, b := range c.bodies {
+		var spans []zipkinSpan
+		if err := json.Unmarshal(b, &spans); err != nil {
+			t.Fatalf("Unable to decode %q: %v", b, err)
+		}
+		all = append(all, spans...)
+	}
+	return all
+}
+
+var (
+	start = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rootSpan = &tracespan.Instance{
+		Name:      "root",
+		TraceId:   "463ac35c9f6413ad48485a3953bb6124",
+		SpanId:    "a2fb4a1d1a96d312",
+		SpanName:  "/productpage",
+		StartTime: start,
+		EndTime:   start.Add(10 * time.Millisecond),
+		SpanTags: map[string]interface{}{
+			"http.method":      "GET",
+			"http.status_code": int64(200),
+			"source.ip":        []byte{10, 0, 0, 1},
+			"source.service":   "productpage",
+		},
+	}
+
+	childSpan = &tracespan.Instance{
+		Name:         "child",
+		TraceId:      "48485a3953bb6124",
+		SpanId:       "0020000000000001",
+		ParentSpanId: "a2fb4a1d1a96d312",
+		SpanName:     "/reviews",
+		StartTime:    start,
+		EndTime:      start.Add(time.Millisecond),
+	}
+)
+
+func newHandler(t *testing.T, params *config.Params) (*handler, *test.Env) {
+	t.Helper()
+
+	b := GetInfo().NewBuilder().(*builder)
+	b.SetAdapterConfig(params)
+	b.SetTraceSpanTypes(nil)
+	if ce := b.Validate(); ce != nil {
+		t.Fatalf("Validate() => unexpected error: %v", ce)
+	}
+
+	env := test.NewEnv(t)
+	h, err := b.Build(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Build() => unexpected error: %v", err)
+	}
+	return h.(*handler), env
+}
+
+func testParams(url string) *config.Params {
+	p := *GetInfo().DefaultConfig.(*config.Params)
+	p.CollectorUrl = url
+	p.FlushInterval = time.Hour
+	p.RetryBackoff = time.Millisecond
+	return &p
+}
+
+func TestZipkinExport(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	h, _ := newHandler(t, testParams(srv.URL))
+	if err := h.HandleTraceSpan(context.Background(), []*tracespan.Instance{rootSpan, childSpan}); err != nil {
+		t.Fatalf("HandleTraceSpan() => unexpected error: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() => unexpected error: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("second Close() => unexpected error: %v", err)
+	}
+
+	if c.requests() != 1 {
+		t.Fatalf("Got %d requests, want 1", c.requests())
+	}
+	if c.contentTypes[0] != "application/json" {
+		t.Errorf("Got content type %q, want application/json", c.contentTypes[0])
+	}
+
+	want := []zipkinSpan{
+		{
+			TraceID:       "463ac35c9f6413ad48485a3953bb6124",
+			ID:            "a2fb4a1d1a96d312",
+			Name:          "/productpage",
+			Timestamp:     start.UnixNano() / 1000,
+			Duration:      10000,
+			LocalEndpoint: &zipkinEndpoint{ServiceName: "productpage"},
+			Tags: map[string]string{
+				"http.method":      "GET",
+				"http.status_code": "200",
+				"source.ip":        "10.0.0.1",
+				"source.service":   "productpage",
+			},
+		},
+		{
+			TraceID:       "48485a3953bb6124",
+			ID:            "0020000000000001",
+			ParentID:      "a2fb4a1d1a96d312",
+			Name:          "/reviews",
+			Timestamp:     start.UnixNano() / 1000,
+			Duration:      1000,
+			LocalEndpoint: &zipkinEndpoint{ServiceName: defaultServiceName},
+		},
+	}
+	if got := c.zipkinSpans(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got spans %#v, want %#v", got, want)
+	}
+}
+
+func TestJaegerExport(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	params := testParams(srv.URL)
+	params.Format = config.JAEGER_THRIFT
+	h, _ := newHandler(t, params)
+	if err := h.HandleTraceSpan(context.Background(), []*tracespan.Instance{rootSpan, childSpan}); err != nil {
+		t.Fatalf("HandleTraceSpan() => unexpected error: %v", err)
+	}
+	_ = h.Close()
+
+	// one batch per service
+	if c.requests() != 2 {
+		t.Fatalf("Got %d requests, want 2", c.requests())
+	}
+
+	got := make(map[string]*jaeger.Span)
+	for i, body := range c.bodies {
+		if c.contentTypes[i] != "application/x-thrift" {
+			t.Errorf("Got content type %q, want application/x-thrift", c.contentTypes[i])
+		}
+
+		buf := thrift.NewTMemoryBuffer()
+		_, _ = buf.Write(body)
+		batch := jaeger.NewBatch()
+		if err := batch.Read(thrift.NewTBinaryProtocolTransport(buf)); err != nil {
+			t.Fatalf("Unable to decode batch: %v", err)
+		}
+		if len(batch.Spans) != 1 {
+			t.Fatalf("Got %d spans in batch for %s, want 1", len(batch.Spans), batch.Process.ServiceName)
+		}
+		got[batch.Process.ServiceName] = batch.Spans[0]
+	}
+
+	root := got["productpage"]
+	if root == nil {
+		t.Fatalf("No batch for service productpage: %v", got)
+	}
+	if root.TraceIdHigh != 0x463ac35c9f6413ad || root.TraceIdLow != 0x48485a3953bb6124 {
+		t.Errorf("Got trace id %x%x, want %s", root.TraceIdHigh, root.TraceIdLow, rootSpan.TraceId)
+	}
+	if uint64(root.SpanId) != 0xa2fb4a1d1a96d312 || root.ParentSpanId != 0 {
+		t.Errorf("Got span id %x parent %x, want %s and no parent", root.SpanId, root.ParentSpanId, rootSpan.SpanId)
+	}
+	if root.OperationName != "/productpage" || root.Duration != 10000 || root.StartTime != start.UnixNano()/1000 {
+		t.Errorf("Got unexpected span %v", root)
+	}
+	var tags []string
+	for _, tag := range root.Tags {
+		tags = append(tags, tag.Key+"="+tag.GetVStr())
+	}
+	sort.Strings(tags)
+	if want := "http.method=GET,http.status_code=200,source.ip=10.0.0.1,source.service=productpage"; strings.Join(tags, ",") != want {
+		t.Errorf("Got tags %v, want %s", tags, want)
+	}
+
+	child := got[defaultServiceName]
+	if child == nil {
+		t.Fatalf("No batch for service %s: %v", defaultServiceName, got)
+	}
+	if uint64(child.ParentSpanId) != 0xa2fb4a1d1a96d312 {
+		t.Errorf("Got parent span id %x, want %s", child.ParentSpanId, childSpan.ParentSpanId)
+	}
+}
+
+func TestBatching(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	params := testParams(srv.URL)
+	params.MaxBatchSize = 2
+	h, _ := newHandler(t, params)
+
+	insts := make([]*tracespan.Instance, 5)
+	for i := range insts {
+		insts[i] = childSpan
+	}
+	_ = h.HandleTraceSpan(context.Background(), insts)
+	_ = h.Close()
+
+	if c.requests() != 3 {
+		t.Errorf("Got %d requests, want 3", c.requests())
+	}
+	if n := len(c.zipkinSpans(t)); n != 5 {
+		t.Errorf("Got %d spans, want 5", n)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	params := testParams(srv.URL)
+	params.FlushInterval = 10 * time.Millisecond
+	h, _ := newHandler(t, params)
+	defer func() { _ = h.Close() }()
+
+	_ = h.HandleTraceSpan(context.Background(), []*tracespan.Instance{childSpan})
+
+	waitForRequests(t, c, 1)
+}
+
+func TestCloseDoesNotRetry(t *testing.T) {
+	c := &collector{responses: []int{503}}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	params := testParams(srv.URL)
+	params.RetryBackoff = time.Hour
+	h, env := newHandler(t, params)
+	_ = h.HandleTraceSpan(context.Background(), []*tracespan.Instance{childSpan})
+	_ = h.Close()
+
+	if c.requests() != 1 {
+		t.Errorf("Got %d requests, want 1", c.requests())
+	}
+	if !hasLog(env, "Unable to export") {
+		t.Errorf("Expected export failure to be logged: %v", env.GetLogs())
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cases := []struct {
+		name      string
+		responses []int
+		retries   int32
+		requests  int
+		wantErr   bool
+	}{
+		{"success", nil, 3, 1, false},
+		{"retry 5xx", []int{503, 500}, 3, 3, false},
+		{"retry 429", []int{429}, 3, 2, false},
+		{"no retry 4xx", []int{400}, 3, 1, true},
+		{"retries exhausted", []int{503, 503, 503}, 2, 3, true},
+		{"no retries", []int{503}, 0, 1, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &collector{responses: tc.responses}
+			srv := httptest.NewServer(c)
+			defer srv.Close()
+
+			// flush from the export loop rather than on Close, which does not retry
+			params := testParams(srv.URL)
+			params.MaxBatchSize = 1
+			params.MaxRetries = tc.retries
+			h, env := newHandler(t, params)
+			_ = h.HandleTraceSpan(context.Background(), []*tracespan.Instance{childSpan})
+			waitForRequests(t, c, tc.requests)
+			_ = h.Close()
+
+			if c.requests() != tc.requests {
+				t.Errorf("Got %d requests, want %d", c.requests(), tc.requests)
+			}
+			if gotErr := hasLog(env, "Unable to export"); gotErr != tc.wantErr {
+				t.Errorf("Got export error logged %v, want %v: %v", gotErr, tc.wantErr, env.GetLogs())
+			}
+		})
+	}
+}
+
+func TestUnreachableCollector(t *testing.T) {
+	srv := httptest.NewServer(&collector{})
+	url := srv.URL
+	srv.Close()
+
+	params := testParams(url)
+	params.MaxRetries = 1
+	h, env := newHandler(t, params)
+	_ = h.HandleTraceSpan(context.Background(), []*tracespan.Instance{childSpan})
+	_ = h.Close()
+
+	if !hasLog(env, "Unable to export 1 trace spans") {
+		t.Errorf("Expected export failure to be logged: %v", env.GetLogs())
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	env := test.NewEnv(t)
+	e := newExporter(env, http.DefaultClient, "http://localhost", zipkinEncoder{}, exporterOptions{maxQueueSize: 2})
+
+	for i := 0; i < 5; i++ {
+		e.enqueue(&span{})
+	}
+	if len(e.queue) != 2 {
+		t.Errorf("Got %d queued spans, want 2", len(e.queue))
+	}
+
+	e.flush(nil)
+	if !hasLog(env, "Dropped 3 trace spans") {
+		t.Errorf("Expected dropped spans to be logged: %v", env.GetLogs())
+	}
+}
+
+func TestSampling(t *testing.T) {
+	cases := []struct {
+		probability float64
+		traceID     uint64
+		want        bool
+	}{
+		{0, 0xffffffffffffffff, true},
+		{1, 0xffffffffffffffff, true},
+		{0.5, 0x7000000000000000, true},
+		{0.5, 0x9000000000000000, false},
+		{0.01, 0x0000000000000001, true},
+		{0.01, 0x1000000000000000, false},
+	}
+
+	for _, tc := range cases {
+		s := newSampler(tc.probability)
+		if got := s.sample(&span{traceIDLow: tc.traceID}); got != tc.want {
+			t.Errorf("newSampler(%v).sample(%x) => %v, want %v", tc.probability, tc.traceID, got, tc.want)
+		}
+	}
+}
+
+func TestSampledHandler(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	params := testParams(srv.URL)
+	params.SampleProbability = 0.5
+	h, _ := newHandler(t, params)
+
+	kept := *childSpan
+	kept.TraceId = "1000000000000000"
+	dropped := *childSpan
+	dropped.TraceId = "f000000000000000"
+	_ = h.HandleTraceSpan(context.Background(), []*tracespan.Instance{&kept, &dropped})
+	_ = h.Close()
+
+	spans := c.zipkinSpans(t)
+	if len(spans) != 1 || spans[0].TraceID != kept.TraceId {
+		t.Errorf("Got spans %v, want only trace %s", spans, kept.TraceId)
+	}
+}
+
+func TestInvalidSpans(t *testing.T) {
+	cases := []struct {
+		name string
+		inst tracespan.Instance
+	}{
+		{"missing trace id", tracespan.Instance{}},
+		{"bad trace id", tracespan.Instance{TraceId: "xyz"}},
+		{"long trace id", tracespan.Instance{TraceId: strings.Repeat("a", 33)}},
+		{"bad span id", tracespan.Instance{TraceId: "1", SpanId: "xyz"}},
+		{"long span id", tracespan.Instance{TraceId: "1", SpanId: strings.Repeat("a", 17)}},
+		{"bad parent id", tracespan.Instance{TraceId: "1", SpanId: "1", ParentSpanId: "xyz"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if s, err := newSpan(&tc.inst, defaultServiceName); err == nil {
+				t.Errorf("newSpan() => %v, want error", s)
+			}
+		})
+	}
+
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*config.Params)
+		fields []string
+	}{
+		{"default", func(*config.Params) {}, nil},
+		{"bad url", func(p *config.Params) { p.CollectorUrl = "not a url" }, []string{"collector_url"}},
+		{"bad scheme", func(p *config.Params) { p.CollectorUrl = "ftp://zipkin/api" }, []string{"collector_url"}},
+		{"bad format", func(p *config.Params) { p.Format = 7 }, []string{"format"}},
+		{"bad probability", func(p *config.Params) { p.SampleProbability = 1.5 }, []string{"sample_probability"}},
+		{"negative sizes", func(p *config.Params) {
+			p.MaxBatchSize = -1
+			p.MaxQueueSize = -1
+			p.MaxRetries = -1
+		}, []string{"max_batch_size", "max_queue_size", "max_retries"}},
+		{"negative durations", func(p *config.Params) {
+			p.FlushInterval = -time.Second
+			p.RetryBackoff = -time.Second
+			p.RequestTimeout = -time.Second
+		}, []string{"flush_interval", "request_timeout", "retry_backoff"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := *GetInfo().DefaultConfig.(*config.Params)
+			tc.modify(&params)
+
+			b := GetInfo().NewBuilder().(*builder)
+			b.SetAdapterConfig(&params)
+			ce := b.Validate()
+
+			var fields []string
+			if ce != nil {
+				for _, err := range ce.Multi.Errors {
+					fields = append(fields, err.(adapter.ConfigError).Field)
+				}
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tc.fields) {
+				t.Errorf("Validate() => errors for %v, want %v", fields, tc.fields)
+			}
+		})
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	info := GetInfo()
+	if len(info.SupportedTemplates) != 1 || info.SupportedTemplates[0] != tracespan.TemplateName {
+		t.Errorf("Got supported templates %v, want [%s]", info.SupportedTemplates, tracespan.TemplateName)
+	}
+	if _, ok := info.NewBuilder().(tracespan.HandlerBuilder); !ok {
+		t.Error("Builder does not implement tracespan.HandlerBuilder")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	srv := httptest.NewServer(&collector{})
+	defer srv.Close()
+
+	invalidURL := testParams("ftp://collector")
+	invalidProbability := testParams(srv.URL)
+	invalidProbability.SampleProbability = 2
+
+	test.RunConformance(t, test.Conformance{
+		Info:           GetInfo(),
+		Config:         testParams(srv.URL),
+		InvalidConfigs: []adapter.Config{invalidURL, invalidProbability},
+		Instances: map[string][]interface{}{
+			tracespan.TemplateName: {rootSpan, childSpan},
+		},
+	})
+}
+
+func waitForRequests(t *testing.T, c *collector, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.requests() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %d requests before the deadline, want %d", c.requests(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func hasLog(env *test.Env, substr string) bool {
+	for _, l := range env.GetLogs() {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	return false
+}
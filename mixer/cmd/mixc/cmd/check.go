@@ -17,7 +17,10 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -33,6 +36,8 @@ import (
 
 func checkCmd(rootArgs *rootArgs, printf, fatalf shared.FormatFn) *cobra.Command {
 	quotas := ""
+	watch := false
+	interval := time.Second
 
 	cmd := &cobra.Command{
 		Use:   "check",
@@ -65,58 +70,223 @@ func checkCmd(rootArgs *rootArgs, printf, fatalf shared.FormatFn) *cobra.Command
 				}
 			}
 
+			if watch {
+				checkWatch(rootArgs, printf, fatalf, q, interval)
+				return
+			}
 			check(rootArgs, printf, fatalf, q)
 		}}
 
 	cmd.PersistentFlags().StringVarP(&quotas, "quotas", "q", "",
 		"List of quotas to allocate specified as name1=amount1,name2=amount2,...")
+	cmd.PersistentFlags().BoolVarP(&watch, "watch", "w", false,
+		"Repeats the checks until interrupted, and prints their results whenever they change, e.g. as Mixer's config changes")
+	cmd.PersistentFlags().DurationVarP(&interval, "interval", "", interval,
+		"Interval between the repeated checks of the watch mode")
 
 	return cmd
 }
 
-func check(rootArgs *rootArgs, printf, fatalf shared.FormatFn, quotas map[string]int64) {
-	var attrs *mixerpb.CompressedAttributes
-	var err error
+// checkResult is the result of a check. It is written as is in the json output format.
+type checkResult struct {
+	// Request is the index of the request, in the order the requests are read.
+	Request int `json:"request"`
+
+	// Error is the status of the RPC if it failed, or the error decoding its response.
+	Error string `json:"error,omitempty"`
+
+	Status               string                 `json:"status,omitempty"`
+	ValidDuration        string                 `json:"validDuration,omitempty"`
+	ValidUseCount        int32                  `json:"validUseCount,omitempty"`
+	Attributes           map[string]string      `json:"attributes,omitempty"`
+	ReferencedAttributes []string               `json:"referencedAttributes,omitempty"`
+	Quotas               map[string]quotaResult `json:"quotas,omitempty"`
+
+	response *mixerpb.CheckResponse
+}
+
+type quotaResult struct {
+	GrantedAmount int64  `json:"grantedAmount"`
+	ValidDuration string `json:"validDuration"`
+}
+
+// checker sends check requests with unique deduplication ids.
+type checker struct {
+	client mixerpb.MixerClient
+	salt   int
+	count  int
+}
+
+func (c *checker) check(ctx context.Context, index int, r *request) *checkResult {
+	c.count++
+	req := mixerpb.CheckRequest{
+		Attributes:      *r.attributes,
+		DeduplicationId: strconv.Itoa(c.salt + c.count),
+	}
+
+	req.Quotas = make(map[string]mixerpb.CheckRequest_QuotaParams)
+	for name, amount := range r.quotas {
+		req.Quotas[name] = mixerpb.CheckRequest_QuotaParams{Amount: amount, BestEffort: true}
+	}
+
+	response, err := c.client.Check(ctx, &req)
+	if err != nil {
+		return &checkResult{Request: index, Error: decodeError(err)}
+	}
+	return newCheckResult(index, response)
+}
+
+func newCheckResult(index int, response *mixerpb.CheckResponse) *checkResult {
+	result := &checkResult{
+		Request:              index,
+		Status:               decodeStatus(response.Precondition.Status),
+		ValidUseCount:        response.Precondition.ValidUseCount,
+		ReferencedAttributes: referencedAttributes(&response.Precondition.ReferencedAttributes),
+		response:             response,
+	}
+	if response.Precondition.ValidDuration != 0 {
+		result.ValidDuration = response.Precondition.ValidDuration.String()
+	}
+	if attrs, err := decodeAttributes(&response.Precondition.Attributes); err == nil {
+		result.Attributes = attrs
+	} else {
+		result.Error = fmt.Sprintf("unable to decode returned attributes: %v", err)
+	}
+	if len(response.Quotas) > 0 {
+		result.Quotas = make(map[string]quotaResult, len(response.Quotas))
+		for name, qr := range response.Quotas {
+			result.Quotas[name] = quotaResult{GrantedAmount: qr.GrantedAmount, ValidDuration: qr.ValidDuration.String()}
+		}
+	}
+	return result
+}
+
+// print prints the result in the given output format.
+func (r *checkResult) print(printf, fatalf shared.FormatFn, output string, numbered bool) {
+	if output == jsonOutput {
+		printJSON(printf, fatalf, r)
+		return
+	}
+
+	if numbered {
+		printf("Request #%d:", r.Request)
+	}
+	if r.response == nil {
+		printf("Check RPC failed with: %s", r.Error)
+		return
+	}
 
-	if attrs, err = parseAttributes(rootArgs); err != nil {
+	response := r.response
+	printf("Check RPC completed successfully. Check status was %s", decodeStatus(response.Precondition.Status))
+	printf("  Valid use count: %v, valid duration: %v", response.Precondition.ValidUseCount, response.Precondition.ValidDuration)
+	dumpAttributes(printf, fatalf, &response.Precondition.Attributes)
+	dumpReferencedAttributes(printf, fatalf, &response.Precondition.ReferencedAttributes)
+	dumpQuotas(printf, response.Quotas)
+}
+
+// key returns the part of the result that is compared by the watch mode.
+func (r *checkResult) key() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func check(rootArgs *rootArgs, printf, fatalf shared.FormatFn, quotas map[string]int64) {
+	if err := validateOutput(rootArgs); err != nil {
 		fatalf("%v", err)
 	}
 
-	var cs *clientState
-	if cs, err = createAPIClient(rootArgs.mixerAddress, rootArgs.tracingOptions); err != nil {
+	cs, err := createAPIClient(rootArgs.mixerAddress, rootArgs.tracingOptions)
+	if err != nil {
 		fatalf("Unable to establish connection to %s: %v", rootArgs.mixerAddress, err)
 	}
 	defer deleteAPIClient(cs)
 
-	salt := time.Now().Nanosecond()
+	c := &checker{client: cs.client, salt: time.Now().Nanosecond()}
 	span, ctx := ot.StartSpanFromContext(context.Background(), "mixc Check", ext.SpanKindRPCClient)
-	for i := 0; i < rootArgs.repeat; i++ {
-		dedup := strconv.Itoa(salt + i)
 
-		request := mixerpb.CheckRequest{
-			Attributes:      *attrs,
-			DeduplicationId: dedup,
+	index := 0
+	err = readRequests(rootArgs, quotas, os.Stdin, func(r *request) bool {
+		for i := 0; i < rootArgs.repeat; i++ {
+			c.check(ctx, index, r).print(printf, fatalf, rootArgs.output, rootArgs.requestsFile != "")
 		}
+		index++
+		return true
+	})
+
+	span.Finish()
+
+	if err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func checkWatch(rootArgs *rootArgs, printf, fatalf shared.FormatFn, quotas map[string]int64, interval time.Duration) {
+	if err := validateOutput(rootArgs); err != nil {
+		fatalf("%v", err)
+	}
+	if rootArgs.requestsFile == stdinFile {
+		fatalf("The watch mode cannot read requests from stdin")
+	}
+	if interval <= 0 {
+		fatalf("The interval must be > 0")
+	}
 
-		request.Quotas = make(map[string]mixerpb.CheckRequest_QuotaParams)
-		for name, amount := range quotas {
-			request.Quotas[name] = mixerpb.CheckRequest_QuotaParams{Amount: amount, BestEffort: true}
+	var requests []*request
+	if err := readRequests(rootArgs, quotas, nil, func(r *request) bool {
+		requests = append(requests, r)
+		return true
+	}); err != nil {
+		fatalf("%v", err)
+	}
+
+	cs, err := createAPIClient(rootArgs.mixerAddress, rootArgs.tracingOptions)
+	if err != nil {
+		fatalf("Unable to establish connection to %s: %v", rootArgs.mixerAddress, err)
+	}
+	defer deleteAPIClient(cs)
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+
+	c := &checker{client: cs.client, salt: time.Now().Nanosecond()}
+	watchChecks(requests, interval, stop, c.check, func(r *checkResult) {
+		if rootArgs.output == textOutput {
+			printf("%s", time.Now().Format(time.RFC3339))
 		}
+		r.print(printf, fatalf, rootArgs.output, len(requests) > 1)
+	})
+}
+
+// watchChecks checks the requests every interval, until the stop channel is closed, and emits the results of each
+// request that differ from its previous ones.
+func watchChecks(requests []*request, interval time.Duration, stop <-chan struct{},
+	check func(context.Context, int, *request) *checkResult, emit func(*checkResult)) {
 
-		response, err := cs.client.Check(ctx, &request)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		if err == nil {
-			printf("Check RPC completed successfully. Check status was %s", decodeStatus(response.Precondition.Status))
-			printf("  Valid use count: %v, valid duration: %v", response.Precondition.ValidUseCount, response.Precondition.ValidDuration)
-			dumpAttributes(printf, fatalf, &response.Precondition.Attributes)
-			dumpReferencedAttributes(printf, fatalf, &response.Precondition.ReferencedAttributes)
-			dumpQuotas(printf, response.Quotas)
-		} else {
-			printf("Check RPC failed with: %s", decodeError(err))
+	previous := make([]string, len(requests))
+	for {
+		for i, r := range requests {
+			result := check(context.Background(), i, r)
+			if key := result.key(); key != previous[i] {
+				previous[i] = key
+				emit(result)
+			}
 		}
-	}
 
-	span.Finish()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
 }
 
 func dumpQuotas(printf shared.FormatFn, quotas map[string]mixerpb.CheckResponse_QuotaResult) {
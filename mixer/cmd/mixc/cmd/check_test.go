@@ -0,0 +1,111 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	mixerpb "istio.io/api/mixer/v1"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+)
+
+func TestCheckResult_JSON(t *testing.T) {
+	response := &mixerpb.CheckResponse{
+		Precondition: mixerpb.CheckResponse_PreconditionResult{
+			Status:        rpc.Status{Code: int32(rpc.PERMISSION_DENIED), Message: "denied"},
+			ValidDuration: 10 * time.Second,
+			ValidUseCount: 100,
+			ReferencedAttributes: mixerpb.ReferencedAttributes{
+				Words: []string{"source.name", "request.headers", "x-user"},
+				AttributeMatches: []mixerpb.ReferencedAttributes_AttributeMatch{
+					{Name: -1, Condition: mixerpb.EXACT},
+					{Name: -2, MapKey: -3, Condition: mixerpb.ABSENCE},
+				},
+			},
+		},
+		Quotas: map[string]mixerpb.CheckResponse_QuotaResult{
+			"requestcount": {GrantedAmount: 1, ValidDuration: time.Minute},
+		},
+	}
+
+	b, err := json.Marshal(newCheckResult(3, response))
+	if err != nil {
+		t.Fatalf("Marshal() => unexpected error: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"request":              3.0,
+		"status":               "PERMISSION_DENIED (denied)",
+		"validDuration":        "10s",
+		"validUseCount":        100.0,
+		"referencedAttributes": []interface{}{"request.headers::x-user ABSENCE", "source.name EXACT"},
+		"quotas": map[string]interface{}{
+			"requestcount": map[string]interface{}{"grantedAmount": 1.0, "validDuration": "1m0s"},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("JSON => got %s", b)
+	}
+}
+
+func TestWatchChecks(t *testing.T) {
+	requests := []*request{{}, {}}
+
+	// the result of the second request changes on the third round.
+	rounds := make(map[int]int)
+	check := func(_ context.Context, i int, _ *request) *checkResult {
+		rounds[i]++
+		r := &checkResult{Request: i, Status: "OK"}
+		if i == 1 && rounds[i] >= 3 {
+			r.Status = "PERMISSION_DENIED"
+		}
+		return r
+	}
+
+	stop := make(chan struct{})
+	var emitted []string
+	watchChecks(requests, time.Millisecond, stop, check, func(r *checkResult) {
+		emitted = append(emitted, r.Status)
+		if len(emitted) == 3 {
+			close(stop)
+		}
+	})
+
+	if expected := []string{"OK", "OK", "PERMISSION_DENIED"}; !reflect.DeepEqual(emitted, expected) {
+		t.Fatalf("watchChecks() => emitted %v, want %v", emitted, expected)
+	}
+	if rounds[0] != 3 || rounds[1] != 3 {
+		t.Fatalf("watchChecks() => got rounds %v, want 3 each", rounds)
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	for _, output := range []string{textOutput, jsonOutput} {
+		if err := validateOutput(&rootArgs{output: output}); err != nil {
+			t.Errorf("validateOutput(%s) => unexpected error: %v", output, err)
+		}
+	}
+	if err := validateOutput(&rootArgs{output: "xml"}); err == nil {
+		t.Error("validateOutput(xml) => got no error")
+	}
+}
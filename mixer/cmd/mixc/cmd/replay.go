@@ -0,0 +1,279 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	mixerpb "istio.io/api/mixer/v1"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/cmd/shared"
+	"istio.io/istio/mixer/pkg/capture"
+)
+
+type replayArgs struct {
+	// captureFile is the file that contains the captured requests.
+	captureFile string
+
+	// resultsFile is the file to write the results of the replay to.
+	resultsFile string
+
+	// baselineFile is the results file of a previous replay to compare against.
+	baselineFile string
+
+	// rate is the number of requests to send per second. Zero means as fast as possible.
+	rate float64
+}
+
+func replayCmd(rootArgs *rootArgs, printf, fatalf shared.FormatFn) *cobra.Command {
+	ra := &replayArgs{}
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replays requests captured by Mixer against a running Mixer instance.",
+		Long: "The replay command sends the requests that were captured by a Mixer server\n" +
+			"(see the --captureFile option of 'mixs server') to a running Mixer instance,\n" +
+			"and reports the differences in check status and granted quota amounts, compared\n" +
+			"to either the captured results or the results of a previous replay.",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			replay(rootArgs, ra, printf, fatalf)
+		}}
+
+	cmd.PersistentFlags().StringVarP(&rootArgs.mixerAddress, "mixer", "m", "localhost:9091",
+		"Address and port of a running Mixer instance")
+	cmd.PersistentFlags().StringVarP(&ra.captureFile, "file", "f", "",
+		"File that contains the captured requests")
+	cmd.PersistentFlags().StringVarP(&ra.resultsFile, "results", "o", "",
+		"File to write the results of the replay to. The file can be used as a baseline of a later replay")
+	cmd.PersistentFlags().StringVarP(&ra.baselineFile, "baseline", "", "",
+		"Results file of a previous replay to compare against. If not specified, the captured results are used")
+	cmd.PersistentFlags().Float64VarP(&ra.rate, "rate", "", 0,
+		"Number of requests to send per second. If 0, the requests are sent as fast as possible")
+
+	return cmd
+}
+
+func replay(rootArgs *rootArgs, ra *replayArgs, printf, fatalf shared.FormatFn) {
+	if ra.captureFile == "" {
+		fatalf("A capture file must be specified")
+	}
+
+	records, err := readRecordFile(ra.captureFile)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	baseline := records
+	if ra.baselineFile != "" {
+		if baseline, err = readRecordFile(ra.baselineFile); err != nil {
+			fatalf("%v", err)
+		}
+		if len(baseline) != len(records) {
+			fatalf("Baseline has %d records, but the capture has %d", len(baseline), len(records))
+		}
+	}
+
+	var cs *clientState
+	if cs, err = createAPIClient(rootArgs.mixerAddress, rootArgs.tracingOptions); err != nil {
+		fatalf("Unable to establish connection to %s: %v", rootArgs.mixerAddress, err)
+	}
+	defer deleteAPIClient(cs)
+
+	var out *os.File
+	if ra.resultsFile != "" {
+		if out, err = os.Create(ra.resultsFile); err != nil {
+			fatalf("Unable to create results file: %v", err)
+		}
+		defer func() { _ = out.Close() }()
+	}
+
+	var tick <-chan time.Time
+	if ra.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / ra.rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	span, ctx := ot.StartSpanFromContext(context.Background(), "mixc Replay", ext.SpanKindRPCClient)
+
+	salt := time.Now().Nanosecond()
+	differences := 0
+	for i, rec := range records {
+		if tick != nil {
+			<-tick
+		}
+
+		var result *capture.Result
+		switch rec.Kind {
+		case capture.Check:
+			result = replayCheck(ctx, cs.client, rec, strconv.Itoa(salt+i))
+		case capture.Report:
+			result = replayReport(ctx, cs.client, rec)
+		default:
+			printf("Skipping record #%d with unknown kind '%s'", i, rec.Kind)
+			continue
+		}
+
+		for _, d := range diffResults(baseline[i].Result, result) {
+			printf("#%d %s: %s", i, rec.Kind, d)
+			differences++
+		}
+
+		if out != nil {
+			r := *rec
+			r.Result = result
+			if err = r.Write(out); err != nil {
+				fatalf("Unable to write results file: %v", err)
+			}
+		}
+	}
+
+	span.Finish()
+
+	printf("Replayed %d requests, found %d differences", len(records), differences)
+}
+
+func replayCheck(ctx context.Context, client mixerpb.MixerClient, rec *capture.Record, dedup string) *capture.Result {
+	request := mixerpb.CheckRequest{
+		DeduplicationId: dedup,
+	}
+	if len(rec.Attributes) > 0 {
+		request.Attributes = *rec.Attributes[0].ToProto()
+	}
+
+	if len(rec.Quotas) > 0 {
+		request.Quotas = make(map[string]mixerpb.CheckRequest_QuotaParams, len(rec.Quotas))
+		for name, q := range rec.Quotas {
+			request.Quotas[name] = mixerpb.CheckRequest_QuotaParams{Amount: q.Amount, BestEffort: q.BestEffort}
+		}
+	}
+
+	response, err := client.Check(ctx, &request)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	result := &capture.Result{
+		Code:    response.Precondition.Status.Code,
+		Message: response.Precondition.Status.Message,
+	}
+	if len(response.Quotas) > 0 {
+		result.Quotas = make(map[string]int64, len(response.Quotas))
+		for name, qr := range response.Quotas {
+			result.Quotas[name] = qr.GrantedAmount
+		}
+	}
+
+	return result
+}
+
+func replayReport(ctx context.Context, client mixerpb.MixerClient, rec *capture.Record) *capture.Result {
+	request := mixerpb.ReportRequest{
+		Attributes: make([]mixerpb.CompressedAttributes, 0, len(rec.Attributes)),
+	}
+	for _, a := range rec.Attributes {
+		request.Attributes = append(request.Attributes, *a.ToProto())
+	}
+
+	if _, err := client.Report(ctx, &request); err != nil {
+		return errorResult(err)
+	}
+
+	return &capture.Result{}
+}
+
+func errorResult(err error) *capture.Result {
+	st, ok := status.FromError(err)
+	if !ok {
+		return &capture.Result{Code: int32(rpc.UNKNOWN), Message: err.Error()}
+	}
+	return &capture.Result{Code: int32(st.Code()), Message: st.Message()}
+}
+
+// diffResults returns a human readable description of the differences between the baseline and the actual result.
+func diffResults(baseline *capture.Result, actual *capture.Result) []string {
+	if baseline == nil || baseline.Equal(actual) {
+		return nil
+	}
+
+	var diffs []string
+	if baseline.Code != actual.Code {
+		diffs = append(diffs, fmt.Sprintf("status %s -> %s", codeName(baseline.Code), codeName(actual.Code)))
+	}
+
+	names := make(map[string]bool, len(baseline.Quotas)+len(actual.Quotas))
+	for name := range baseline.Quotas {
+		names[name] = true
+	}
+	for name := range actual.Quotas {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		b, bFound := baseline.Quotas[name]
+		a, aFound := actual.Quotas[name]
+		if bFound != aFound || a != b {
+			diffs = append(diffs, fmt.Sprintf("quota '%s' %s -> %s", name, quotaAmount(b, bFound), quotaAmount(a, aFound)))
+		}
+	}
+
+	return diffs
+}
+
+func codeName(code int32) string {
+	if name, ok := rpc.Code_name[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("Code %d", code)
+}
+
+func quotaAmount(amount int64, found bool) string {
+	if !found {
+		return "<none>"
+	}
+	return strconv.FormatInt(amount, 10)
+}
+
+func readRecordFile(path string) ([]*capture.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open '%s': %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := capture.ReadRecords(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read records from '%s': %v", path, err)
+	}
+
+	return records, nil
+}
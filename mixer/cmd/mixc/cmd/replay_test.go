@@ -0,0 +1,78 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/capture"
+)
+
+func TestDiffResults(t *testing.T) {
+	cases := []struct {
+		name     string
+		baseline *capture.Result
+		actual   *capture.Result
+		expected []string
+	}{
+		{
+			name:     "no baseline",
+			actual:   &capture.Result{Code: int32(rpc.OK)},
+			expected: nil,
+		},
+		{
+			name:     "equal",
+			baseline: &capture.Result{Code: int32(rpc.OK), Quotas: map[string]int64{"q": 1}},
+			actual:   &capture.Result{Code: int32(rpc.OK), Quotas: map[string]int64{"q": 1}},
+			expected: nil,
+		},
+		{
+			name:     "status",
+			baseline: &capture.Result{Code: int32(rpc.OK)},
+			actual:   &capture.Result{Code: int32(rpc.PERMISSION_DENIED)},
+			expected: []string{"status OK -> PERMISSION_DENIED"},
+		},
+		{
+			name:     "quota",
+			baseline: &capture.Result{Quotas: map[string]int64{"a": 10, "b": 1}},
+			actual:   &capture.Result{Quotas: map[string]int64{"a": 5, "c": 1}},
+			expected: []string{
+				"quota 'a' 10 -> 5",
+				"quota 'b' 1 -> <none>",
+				"quota 'c' <none> -> 1",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			actual := diffResults(c.baseline, c.actual)
+			if !reflect.DeepEqual(actual, c.expected) {
+				tt.Fatalf("%v != %v", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestCodeName(t *testing.T) {
+	if codeName(int32(rpc.INTERNAL)) != "INTERNAL" {
+		t.Fatalf("unexpected code name: %s", codeName(int32(rpc.INTERNAL)))
+	}
+	if codeName(1234) != "Code 1234" {
+		t.Fatalf("unexpected code name: %s", codeName(1234))
+	}
+}
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"context"
+	"os"
 
 	ot "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -39,28 +40,50 @@ func reportCmd(rootArgs *rootArgs, printf, fatalf shared.FormatFn) *cobra.Comman
 		}}
 }
 
-func report(rootArgs *rootArgs, printf, fatalf shared.FormatFn) {
-	var attrs *mixerpb.CompressedAttributes
-	var err error
+// reportResult is the result of a report, as it is written in the json output format.
+type reportResult struct {
+	// Request is the index of the request, in the order the requests are read.
+	Request int `json:"request"`
+
+	// Status of the RPC.
+	Status string `json:"status"`
+}
 
-	if attrs, err = parseAttributes(rootArgs); err != nil {
+func report(rootArgs *rootArgs, printf, fatalf shared.FormatFn) {
+	if err := validateOutput(rootArgs); err != nil {
 		fatalf("%v", err)
 	}
 
-	var cs *clientState
-	if cs, err = createAPIClient(rootArgs.mixerAddress, rootArgs.tracingOptions); err != nil {
+	cs, err := createAPIClient(rootArgs.mixerAddress, rootArgs.tracingOptions)
+	if err != nil {
 		fatalf("Unable to establish connection to %s: %v", rootArgs.mixerAddress, err)
 	}
 	defer deleteAPIClient(cs)
 
 	span, ctx := ot.StartSpanFromContext(context.Background(), "mixc Report", ext.SpanKindRPCClient)
 
-	for i := 0; i < rootArgs.repeat; i++ {
-		request := mixerpb.ReportRequest{Attributes: []mixerpb.CompressedAttributes{*attrs}}
-		_, err := cs.client.Report(ctx, &request)
+	index := 0
+	err = readRequests(rootArgs, nil, os.Stdin, func(r *request) bool {
+		for i := 0; i < rootArgs.repeat; i++ {
+			request := mixerpb.ReportRequest{Attributes: []mixerpb.CompressedAttributes{*r.attributes}}
+			_, err := cs.client.Report(ctx, &request)
 
-		printf("Report RPC returned %s", decodeError(err))
-	}
+			switch {
+			case rootArgs.output == jsonOutput:
+				printJSON(printf, fatalf, &reportResult{Request: index, Status: decodeError(err)})
+			case rootArgs.requestsFile != "":
+				printf("Request #%d: Report RPC returned %s", index, decodeError(err))
+			default:
+				printf("Report RPC returned %s", decodeError(err))
+			}
+		}
+		index++
+		return true
+	})
 
 	span.Finish()
+
+	if err != nil {
+		fatalf("%v", err)
+	}
 }
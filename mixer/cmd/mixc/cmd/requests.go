@@ -0,0 +1,294 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	mixerpb "istio.io/api/mixer/v1"
+	descriptor "istio.io/api/mixer/v1/config/descriptor"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/config/store"
+)
+
+// stdinFile is the name of the requests file that reads the requests from stdin, one per line.
+const stdinFile = "-"
+
+// attributeManifestKind is the kind of the config resources that declare the types of attributes.
+const attributeManifestKind = "attributemanifest"
+
+// fileRequest is a request in a requests file. A requests file is a list of requests, e.g. in JSON:
+//
+//	[{"attributes": {"source.name": "reviews", "request.headers": {"x-user": "jason"}}, "quotas": {"requestcount": 1}}]
+type fileRequest struct {
+	// Attributes of the request. Their values are converted to the types declared in the attribute manifests.
+	Attributes map[string]interface{} `json:"attributes"`
+
+	// Quotas to allocate, by name. Ignored by reports.
+	Quotas map[string]int64 `json:"quotas,omitempty"`
+}
+
+// request is a request that is ready to be sent to Mixer.
+type request struct {
+	attributes *mixerpb.CompressedAttributes
+	quotas     map[string]int64
+}
+
+// manifest holds the types of attributes, by name.
+type manifest map[string]descriptor.ValueType
+
+// readManifests reads the attribute manifests from the given YAML files. Resources of other kinds are ignored, which
+// allows the full Mixer config to be used as a manifest.
+func readManifests(paths []string) (manifest, error) {
+	m := make(manifest)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read attribute manifest '%s': %v", path, err)
+		}
+		if err = m.parse(data); err != nil {
+			return nil, fmt.Errorf("unable to parse attribute manifest '%s': %v", path, err)
+		}
+	}
+	return m, nil
+}
+
+func (m manifest) parse(data []byte) error {
+	for _, chunk := range bytes.Split(data, []byte("\n---\n")) {
+		chunk = bytes.TrimSpace(chunk)
+		if len(chunk) == 0 {
+			continue
+		}
+		r, err := store.ParseChunk(chunk)
+		if err != nil {
+			return err
+		}
+		if r == nil || r.Kind != attributeManifestKind {
+			continue
+		}
+
+		attrs, _ := r.Spec["attributes"].(map[string]interface{})
+		for name, v := range attrs {
+			info, _ := v.(map[string]interface{})
+			typeName, _ := info["valueType"].(string)
+			t, found := descriptor.ValueType_value[typeName]
+			if !found {
+				return fmt.Errorf("attribute '%s' has an invalid value type '%s'", name, typeName)
+			}
+			m[name] = descriptor.ValueType(t)
+		}
+	}
+	return nil
+}
+
+// convert converts the value of an attribute, as decoded from a requests file, to the Go type of the attribute type
+// declared in the manifest. The type of an attribute that is not in the manifest is inferred from its value.
+func (m manifest) convert(name string, v interface{}) (interface{}, error) {
+	t, found := m[name]
+	if !found {
+		return inferValue(v)
+	}
+
+	var s string
+	if t != descriptor.STRING_MAP {
+		// numbers and booleans are accepted for all types, as if they were quoted.
+		switch t := v.(type) {
+		case string:
+			s = t
+		case float64:
+			s = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			s = fmt.Sprintf("%v", v)
+		}
+	}
+
+	switch t {
+	case descriptor.STRING, descriptor.DNS_NAME, descriptor.EMAIL_ADDRESS, descriptor.URI:
+		return s, nil
+	case descriptor.INT64:
+		return parseInt64(s)
+	case descriptor.DOUBLE:
+		return parseFloat64(s)
+	case descriptor.BOOL:
+		return parseBool(s)
+	case descriptor.TIMESTAMP:
+		return parseTime(s)
+	case descriptor.DURATION:
+		return parseDuration(s)
+	case descriptor.IP_ADDRESS:
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("'%s' is not a valid IP address", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		return []byte(ip), nil
+	case descriptor.STRING_MAP:
+		return toStringMap(v)
+	default:
+		return nil, fmt.Errorf("attributes of type %v are not supported", t)
+	}
+}
+
+// inferValue returns the value of an attribute without a declared type. Whole numbers are int64 values, other
+// numbers are double values, and objects are string maps.
+func inferValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string, bool:
+		return t, nil
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < math.MaxInt64 {
+			return int64(t), nil
+		}
+		return t, nil
+	case map[string]interface{}:
+		return toStringMap(t)
+	default:
+		return nil, fmt.Errorf("unable to infer the type of value %v", v)
+	}
+}
+
+func toStringMap(v interface{}) (interface{}, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v is not a string map", v)
+	}
+	result := make(map[string]string, len(m))
+	for k, e := range m {
+		s, ok := e.(string)
+		if !ok {
+			s = fmt.Sprintf("%v", e)
+		}
+		result[k] = s
+	}
+	return result, nil
+}
+
+// parseRequests parses a YAML or JSON list of requests.
+func parseRequests(data []byte) ([]*fileRequest, error) {
+	var requests []*fileRequest
+	if err := yaml.Unmarshal(data, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// toRequest builds a request from a request of a requests file. The attributes that are specified on the command
+// line are added to the request, unless the file request has attributes of the same names. The given quotas are
+// allocated, unless the file request has quotas of its own.
+func toRequest(rootArgs *rootArgs, m manifest, fr *fileRequest, quotas map[string]int64) (*request, error) {
+	b := attribute.GetMutableBag(nil)
+	defer b.Done()
+
+	if err := setAttributes(b, rootArgs); err != nil {
+		return nil, err
+	}
+	for name, v := range fr.Attributes {
+		value, err := m.convert(name, v)
+		if err != nil {
+			return nil, fmt.Errorf("attribute '%s': %v", name, err)
+		}
+		b.Set(name, value)
+	}
+
+	if len(fr.Quotas) > 0 {
+		quotas = fr.Quotas
+	}
+
+	var attrs mixerpb.CompressedAttributes
+	b.ToProto(&attrs, nil, 0)
+	return &request{attributes: &attrs, quotas: quotas}, nil
+}
+
+// readRequests calls fn with each of the requests that are specified by the arguments, in order:
+//
+//   - if no requests file is specified, a single request with the attributes of the command line,
+//   - if the requests file is "-", the requests that are read from the given input, one per line, as they are read,
+//   - otherwise, the requests of the requests file.
+//
+// fn returns false to stop reading requests.
+func readRequests(rootArgs *rootArgs, quotas map[string]int64, in io.Reader, fn func(*request) bool) error {
+	if rootArgs.requestsFile == "" {
+		attrs, err := parseAttributes(rootArgs)
+		if err != nil {
+			return err
+		}
+		fn(&request{attributes: attrs, quotas: quotas})
+		return nil
+	}
+
+	m, err := readManifests(rootArgs.manifestFiles)
+	if err != nil {
+		return err
+	}
+
+	if rootArgs.requestsFile == stdinFile {
+		scanner := bufio.NewScanner(in)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+
+			fr := &fileRequest{}
+			if err = yaml.Unmarshal([]byte(text), fr); err != nil {
+				return fmt.Errorf("unable to parse request on line %d: %v", line, err)
+			}
+			r, err := toRequest(rootArgs, m, fr, quotas)
+			if err != nil {
+				return fmt.Errorf("invalid request on line %d: %v", line, err)
+			}
+			if !fn(r) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	data, err := ioutil.ReadFile(rootArgs.requestsFile)
+	if err != nil {
+		return fmt.Errorf("unable to read requests file: %v", err)
+	}
+	frs, err := parseRequests(data)
+	if err != nil {
+		return fmt.Errorf("unable to parse requests file '%s': %v", rootArgs.requestsFile, err)
+	}
+
+	requests := make([]*request, 0, len(frs))
+	for i, fr := range frs {
+		r, err := toRequest(rootArgs, m, fr, quotas)
+		if err != nil {
+			return fmt.Errorf("invalid request #%d in '%s': %v", i, rootArgs.requestsFile, err)
+		}
+		requests = append(requests, r)
+	}
+	for _, r := range requests {
+		if !fn(r) {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2017 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/pkg/attribute"
+)
+
+const testManifest = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: attributemanifest
+metadata:
+  name: istio-proxy
+  namespace: istio-system
+spec:
+  attributes:
+    request.size:
+      valueType: INT64
+    request.time:
+      valueType: TIMESTAMP
+    response.duration:
+      valueType: DURATION
+    source.ip:
+      valueType: IP_ADDRESS
+    request.headers:
+      valueType: STRING_MAP
+    source.name:
+      valueType: STRING
+    ratio:
+      valueType: DOUBLE
+---
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: promhttp
+  namespace: istio-system
+spec:
+  actions: []
+`
+
+const testRequests = `
+- attributes:
+    request.size: 128
+    request.time: "2006-01-02T15:04:05Z"
+    response.duration: 10ms
+    source.ip: 10.0.0.1
+    request.headers:
+      x-user: jason
+      x-count: 1
+    source.name: 42
+    ratio: 1
+  quotas:
+    requestcount: 2
+- attributes:
+    target.name: reviews
+    count: 3
+    fraction: 0.5
+    ok: true
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func requestValues(t *testing.T, r *request) map[string]interface{} {
+	b, err := attribute.GetBagFromProto(r.attributes, nil)
+	if err != nil {
+		t.Fatalf("unable to decode attributes: %v", err)
+	}
+	values := make(map[string]interface{})
+	for _, name := range b.Names() {
+		values[name], _ = b.Get(name)
+	}
+	return values
+}
+
+func TestReadRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mixc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ra := &rootArgs{
+		stringAttributes: "destination.service=svc,target.name=default",
+		requestsFile:     writeFile(t, dir, "requests.yaml", testRequests),
+		manifestFiles:    []string{writeFile(t, dir, "manifest.yaml", testManifest)},
+	}
+
+	var requests []*request
+	err = readRequests(ra, map[string]int64{"q": 1}, nil, func(r *request) bool {
+		requests = append(requests, r)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("readRequests() => unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("readRequests() => got %d requests, want 2", len(requests))
+	}
+
+	expected := map[string]interface{}{
+		"destination.service": "svc",
+		"target.name":         "default",
+		"request.size":        int64(128),
+		"request.time":        time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		"response.duration":   10 * time.Millisecond,
+		"source.ip":           []byte{10, 0, 0, 1},
+		"request.headers":     map[string]string{"x-user": "jason", "x-count": "1"},
+		"source.name":         "42",
+		"ratio":               1.0,
+	}
+	if got := requestValues(t, requests[0]); !reflect.DeepEqual(got, expected) {
+		t.Errorf("request #0 => got %v, want %v", got, expected)
+	}
+	if !reflect.DeepEqual(requests[0].quotas, map[string]int64{"requestcount": 2}) {
+		t.Errorf("request #0 => got quotas %v", requests[0].quotas)
+	}
+
+	// the types of the undeclared attributes are inferred, and the file attributes override the command line ones.
+	expected = map[string]interface{}{
+		"destination.service": "svc",
+		"target.name":         "reviews",
+		"count":               int64(3),
+		"fraction":            0.5,
+		"ok":                  true,
+	}
+	if got := requestValues(t, requests[1]); !reflect.DeepEqual(got, expected) {
+		t.Errorf("request #1 => got %v, want %v", got, expected)
+	}
+	if !reflect.DeepEqual(requests[1].quotas, map[string]int64{"q": 1}) {
+		t.Errorf("request #1 => got quotas %v", requests[1].quotas)
+	}
+
+	// stops reading once fn returns false.
+	count := 0
+	_ = readRequests(ra, nil, nil, func(r *request) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("readRequests() => got %d requests after stopping, want 1", count)
+	}
+}
+
+func TestReadRequests_Stdin(t *testing.T) {
+	ra := &rootArgs{requestsFile: stdinFile}
+	in := strings.NewReader(`{"attributes": {"a": "x"}}
+
+# a comment
+attributes: {b: 2}
+`)
+
+	var requests []*request
+	if err := readRequests(ra, nil, in, func(r *request) bool {
+		requests = append(requests, r)
+		return true
+	}); err != nil {
+		t.Fatalf("readRequests() => unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("readRequests() => got %d requests, want 2", len(requests))
+	}
+	if got := requestValues(t, requests[0]); !reflect.DeepEqual(got, map[string]interface{}{"a": "x"}) {
+		t.Errorf("request #0 => got %v", got)
+	}
+	if got := requestValues(t, requests[1]); !reflect.DeepEqual(got, map[string]interface{}{"b": int64(2)}) {
+		t.Errorf("request #1 => got %v", got)
+	}
+
+	err := readRequests(ra, nil, strings.NewReader("{\"attributes\": {\"a\": \"x\"}}\n{\"attributes\": [}\n"),
+		func(r *request) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("readRequests() => got %v, want an error on line 2", err)
+	}
+}
+
+func TestReadRequests_Flags(t *testing.T) {
+	ra := &rootArgs{int64Attributes: "a=1"}
+	quotas := map[string]int64{"q": 1}
+
+	var requests []*request
+	if err := readRequests(ra, quotas, nil, func(r *request) bool {
+		requests = append(requests, r)
+		return true
+	}); err != nil {
+		t.Fatalf("readRequests() => unexpected error: %v", err)
+	}
+	if len(requests) != 1 || !reflect.DeepEqual(requests[0].quotas, quotas) {
+		t.Fatalf("readRequests() => got %v", requests)
+	}
+	if got := requestValues(t, requests[0]); !reflect.DeepEqual(got, map[string]interface{}{"a": int64(1)}) {
+		t.Errorf("request => got %v", got)
+	}
+}
+
+func TestReadRequests_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mixc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	manifest := writeFile(t, dir, "manifest.yaml", testManifest)
+
+	cases := []struct {
+		name      string
+		requests  string
+		manifests []string
+		err       string
+	}{
+		{"missing file", "", nil, "unable to read requests file"},
+		{"invalid file", "- attributes: [", nil, "unable to parse requests file"},
+		{"invalid value", "- attributes: {request.size: big}", []string{manifest}, "invalid request #0"},
+		{"invalid ip", "- attributes: {source.ip: foo}", []string{manifest}, "'foo' is not a valid IP address"},
+		{"invalid map", "- attributes: {request.headers: foo}", []string{manifest}, "foo is not a string map"},
+		{"list value", "- attributes: {a: [1, 2]}", nil, "unable to infer the type of value"},
+		{"missing manifest", "- attributes: {a: 1}", []string{filepath.Join(dir, "missing.yaml")},
+			"unable to read attribute manifest"},
+		{"invalid manifest", "- attributes: {a: 1}", []string{writeFile(t, dir, "invalid.yaml",
+			"kind: attributemanifest\nmetadata: {name: m, namespace: ns}\nspec: {attributes: {a: {valueType: FOO}}}")},
+			"attribute 'a' has an invalid value type 'FOO'"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			path := filepath.Join(dir, "missing-requests.yaml")
+			if c.requests != "" {
+				path = writeFile(tt, dir, "requests.yaml", c.requests)
+			}
+
+			ra := &rootArgs{requestsFile: path, manifestFiles: c.manifests}
+			err := readRequests(ra, nil, nil, func(r *request) bool { return true })
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				tt.Fatalf("readRequests() => got %v, want error containing '%s'", err, c.err)
+			}
+		})
+	}
+}
@@ -55,6 +55,16 @@ type rootArgs struct {
 	// stringMapAttributes is the list of string maps that will be sent with requests
 	stringMapAttributes string
 
+	// requestsFile is the YAML or JSON file of the requests to send. If it is "-", the requests are read from stdin,
+	// one per line.
+	requestsFile string
+
+	// manifestFiles are the attribute manifests that declare the types of the attributes in the requests file.
+	manifestFiles []string
+
+	// output is the format of the results: text or json.
+	output string
+
 	// mixerAddress is the full address (including port) of a mixer instance to call.
 	mixerAddress string
 
@@ -89,6 +99,15 @@ func addAttributeFlags(cmd *cobra.Command, rootArgs *rootArgs) {
 	cmd.PersistentFlags().StringVarP(&rootArgs.stringMapAttributes, "stringmap_attributes", "", "",
 		"List of name/value string map attributes specified as name1=k1:v1;k2:v2,name2=k3:v3...")
 
+	cmd.PersistentFlags().StringVarP(&rootArgs.requestsFile, "file", "f", "",
+		"YAML or JSON file with a list of requests, each with attributes and quotas. "+
+			"If '-', requests are read from stdin, one JSON or YAML flow object per line. "+
+			"Attributes specified with the other flags are added to each request")
+	cmd.PersistentFlags().StringSliceVarP(&rootArgs.manifestFiles, "attribute_manifest", "", nil,
+		"Files with the attribute manifests that declare the types of the attributes in the requests file. "+
+			"The types of undeclared attributes are inferred from their values")
+	cmd.PersistentFlags().StringVarP(&rootArgs.output, "output", "o", textOutput,
+		"Output format of the results: text or json. The json format writes one JSON object per line")
 }
 
 // GetRootCmd returns the root of the cobra command-tree.
@@ -129,6 +148,7 @@ func GetRootCmd(args []string, printf, fatalf shared.FormatFn) *cobra.Command {
 
 	rootCmd.AddCommand(cc)
 	rootCmd.AddCommand(rc)
+	rootCmd.AddCommand(replayCmd(rootArgs, printf, fatalf))
 	rootCmd.AddCommand(version.CobraCommand())
 	rootCmd.AddCommand(collateral.CobraCommand(rootCmd, &doc.GenManHeader{
 		Title:   "Istio Mixer Client",
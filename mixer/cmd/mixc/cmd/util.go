@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -35,6 +36,12 @@ import (
 	"istio.io/istio/pkg/tracing"
 )
 
+// Output formats of the results.
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
 type clientState struct {
 	client     mixerpb.MixerClient
 	connection *grpc.ClientConn
@@ -176,47 +183,54 @@ func process(b *attribute.MutableBag, s string, f convertFn) error {
 
 func parseAttributes(rootArgs *rootArgs) (*mixerpb.CompressedAttributes, error) {
 	b := attribute.GetMutableBag(nil)
+	defer b.Done()
 
-	if err := process(b, rootArgs.stringAttributes, parseString); err != nil {
+	if err := setAttributes(b, rootArgs); err != nil {
 		return nil, err
 	}
 
+	var attrs mixerpb.CompressedAttributes
+	b.ToProto(&attrs, nil, 0)
+
+	return &attrs, nil
+}
+
+// setAttributes sets the attributes that are specified on the command line in the bag.
+func setAttributes(b *attribute.MutableBag, rootArgs *rootArgs) error {
+
+	if err := process(b, rootArgs.stringAttributes, parseString); err != nil {
+		return err
+	}
+
 	if err := process(b, rootArgs.int64Attributes, parseInt64); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := process(b, rootArgs.doubleAttributes, parseFloat64); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := process(b, rootArgs.boolAttributes, parseBool); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := process(b, rootArgs.timestampAttributes, parseTime); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := process(b, rootArgs.durationAttributes, parseDuration); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := process(b, rootArgs.bytesAttributes, parseBytes); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := process(b, rootArgs.stringMapAttributes, parseStringMap); err != nil {
-		return nil, err
-	}
-
-	if err := process(b, rootArgs.attributes, parseAny); err != nil {
-		return nil, err
+		return err
 	}
 
-	var attrs mixerpb.CompressedAttributes
-	b.ToProto(&attrs, nil, 0)
-
-	return &attrs, nil
+	return process(b, rootArgs.attributes, parseAny)
 }
 
 func decodeError(err error) string {
@@ -279,6 +293,24 @@ func dumpAttributes(printf, fatalf shared.FormatFn, attrs *mixerpb.CompressedAtt
 }
 
 func dumpReferencedAttributes(printf, fatalf shared.FormatFn, attrs *mixerpb.ReferencedAttributes) {
+	vals := referencedAttributes(attrs)
+
+	buf := bytes.Buffer{}
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprint(tw, "  Referenced Attributes\n")
+
+	for _, v := range vals {
+		fmt.Fprintf(tw, "    %s\n", v)
+	}
+
+	_ = tw.Flush()
+	printf("%s", buf.String())
+
+}
+
+// referencedAttributes returns the sorted referenced attributes, in the form "name[::key] condition".
+func referencedAttributes(attrs *mixerpb.ReferencedAttributes) []string {
 	vals := make([]string, 0, len(attrs.AttributeMatches))
 	for _, at := range attrs.AttributeMatches {
 		out := attrs.Words[-1*at.Name-1]
@@ -290,17 +322,42 @@ func dumpReferencedAttributes(printf, fatalf shared.FormatFn, attrs *mixerpb.Ref
 	}
 
 	sort.Strings(vals)
+	return vals
+}
 
-	buf := bytes.Buffer{}
-	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+// decodeAttributes returns the string representation of the values of the attributes, by name.
+func decodeAttributes(attrs *mixerpb.CompressedAttributes) (map[string]string, error) {
+	b, err := attribute.GetBagFromProto(attrs, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	fmt.Fprint(tw, "  Referenced Attributes\n")
+	names := b.Names()
+	if len(names) == 0 {
+		return nil, nil
+	}
 
-	for _, v := range vals {
-		fmt.Fprintf(tw, "    %s\n", v)
+	result := make(map[string]string, len(names))
+	for _, name := range names {
+		v, _ := b.Get(name)
+		result[name] = fmt.Sprintf("%v", v)
 	}
+	return result, nil
+}
 
-	_ = tw.Flush()
-	printf("%s", buf.String())
+func validateOutput(rootArgs *rootArgs) error {
+	if rootArgs.output != textOutput && rootArgs.output != jsonOutput {
+		return fmt.Errorf("unknown output format '%s', must be %s or %s", rootArgs.output, textOutput, jsonOutput)
+	}
+	return nil
+}
 
+// printJSON prints the value as a single line of JSON.
+func printJSON(printf, fatalf shared.FormatFn, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		fatalf("Unable to encode result: %v", err)
+		return
+	}
+	printf("%s", b)
 }
@@ -0,0 +1,52 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/mixer/cmd/shared"
+	"istio.io/istio/mixer/pkg/loadgen"
+)
+
+func compareCmd(printf, fatalf shared.FormatFn) *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare <base report> <new report>",
+		Short: "Compares the reports of two runs, e.g. of two Mixer builds.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			compare(args[0], args[1], printf, fatalf)
+		},
+	}
+}
+
+func compare(basePath, newPath string, printf, fatalf shared.FormatFn) {
+	base, err := loadgen.ReadReport(basePath)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	other, err := loadgen.ReadReport(newPath)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	var b bytes.Buffer
+	if err = loadgen.Compare(&b, base, other); err != nil {
+		fatalf("Unable to compare the reports: %v", err)
+	}
+	printf("%s", b.String())
+}
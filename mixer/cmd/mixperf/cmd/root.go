@@ -0,0 +1,58 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"flag"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+
+	"istio.io/istio/mixer/cmd/shared"
+	"istio.io/istio/pkg/collateral"
+	"istio.io/istio/pkg/version"
+)
+
+// GetRootCmd returns the root of the cobra command-tree.
+func GetRootCmd(args []string, printf, fatalf shared.FormatFn) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "mixperf",
+		Short: "Utility to measure the performance of a running Mixer.",
+		Long: "This command applies a load that is described by a traffic profile\n" +
+			"on a running instance of Mixer, and reports the latencies and the\n" +
+			"error rates of the requests. The reports of different Mixer builds\n" +
+			"can be compared with each other.",
+	}
+	rootCmd.SetArgs(args)
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	// hack to make flag.Parsed return true such that glog is happy
+	// about the flags having been parsed
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	/* #nosec */
+	_ = fs.Parse([]string{})
+	flag.CommandLine = fs
+
+	rootCmd.AddCommand(runCmd(printf, fatalf))
+	rootCmd.AddCommand(compareCmd(printf, fatalf))
+	rootCmd.AddCommand(version.CobraCommand())
+	rootCmd.AddCommand(collateral.CobraCommand(rootCmd, &doc.GenManHeader{
+		Title:   "Istio Mixer Performance Tool",
+		Section: "mixperf CLI",
+		Manual:  "Istio Mixer Performance Tool",
+	}))
+
+	return rootCmd
+}
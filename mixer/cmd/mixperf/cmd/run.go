@@ -0,0 +1,164 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	mixerpb "istio.io/api/mixer/v1"
+	"istio.io/istio/mixer/cmd/shared"
+	"istio.io/istio/mixer/pkg/loadgen"
+)
+
+type runArgs struct {
+	// mixerAddress is the full address (including port) of the Mixer instance to load.
+	mixerAddress string
+
+	// monitorAddress is the address of the monitoring port of the Mixer instance, from which the profiles are
+	// captured. No profiles are captured if empty.
+	monitorAddress string
+
+	// outDir is the directory the report and the profiles are written to.
+	outDir string
+
+	// label identifies the run in the report.
+	label string
+}
+
+func runCmd(printf, fatalf shared.FormatFn) *cobra.Command {
+	ra := &runArgs{}
+
+	cmd := &cobra.Command{
+		Use:   "run <profile>",
+		Short: "Applies the load of a traffic profile on a running Mixer, and reports the results.",
+		Long: "The traffic profile is a YAML file that specifies the target rate of requests,\n" +
+			"the duration of the run, the mix of Check, Report and Quota requests and the\n" +
+			"distributions of the attribute values. Requests are sent at the target rate\n" +
+			"regardless of how fast Mixer responds, and their latency is measured from the\n" +
+			"time they were scheduled to be sent.\n\n" +
+			"The report is written to report.json in the output directory. If the monitoring\n" +
+			"address is set, and Mixer is started with --profile, CPU and heap profiles of\n" +
+			"Mixer are written to the output directory as well.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			run(ra, args[0], printf, fatalf)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&ra.mixerAddress, "mixer", "m", "localhost:9091",
+		"Address and port of a running Mixer instance")
+	cmd.PersistentFlags().StringVarP(&ra.monitorAddress, "monitor", "", "",
+		"Address and port of the monitoring port of the Mixer instance, to capture its profiles from")
+	cmd.PersistentFlags().StringVarP(&ra.outDir, "out", "o", ".",
+		"Directory to write the report and the profiles to")
+	cmd.PersistentFlags().StringVarP(&ra.label, "label", "l", "",
+		"Label of the run in the report, e.g. the Mixer build that is measured")
+
+	return cmd
+}
+
+func run(ra *runArgs, path string, printf, fatalf shared.FormatFn) {
+	p, err := loadgen.ReadProfile(path)
+	if err != nil {
+		fatalf("Unable to read the profile %s: %v", path, err)
+	}
+	if err = os.MkdirAll(ra.outDir, 0755); err != nil {
+		fatalf("Unable to create the output directory: %v", err)
+	}
+
+	clients := make([]mixerpb.MixerClient, p.Connections)
+	for i := range clients {
+		conn, err := grpc.Dial(ra.mixerAddress, grpc.WithInsecure())
+		if err != nil {
+			fatalf("Unable to connect to Mixer at %s: %v", ra.mixerAddress, err)
+		}
+		defer func() { _ = conn.Close() }()
+		clients[i] = mixerpb.NewMixerClient(conn)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		printf("Interrupted, reporting the results so far")
+		cancel()
+	}()
+
+	var profiler *loadgen.Profiler
+	var profiles []string
+	var wg sync.WaitGroup
+	if ra.monitorAddress != "" {
+		profiler = loadgen.NewProfiler(ra.monitorAddress, ra.outDir)
+
+		// Capture the CPU profile over the measurement, i.e. after the warmup.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.Warmup.Duration):
+			}
+			out, err := profiler.CPU(ctx, p.Duration.Duration)
+			if err != nil {
+				printf("Unable to capture the CPU profile: %v", err)
+				return
+			}
+			profiles = append(profiles, out)
+		}()
+	}
+
+	printf("Applying %.1f qps on %s for %v, after a warmup of %v", p.QPS, ra.mixerAddress, p.Duration, p.Warmup)
+	rep, err := loadgen.Run(ctx, p, clients)
+	if err != nil {
+		fatalf("Unable to run the load: %v", err)
+	}
+	rep.Label = ra.label
+
+	wg.Wait()
+	if profiler != nil {
+		out, err := profiler.Heap(context.Background())
+		if err != nil {
+			printf("Unable to capture the heap profile: %v", err)
+		} else {
+			profiles = append(profiles, out)
+		}
+	}
+	rep.Profiles = profiles
+
+	var b bytes.Buffer
+	if err = rep.Write(&b); err != nil {
+		fatalf("Unable to write the report: %v", err)
+	}
+	printf("%s", b.String())
+
+	out := filepath.Join(ra.outDir, "report.json")
+	if err = rep.WriteFile(out); err != nil {
+		fatalf("Unable to write the report: %v", err)
+	}
+	printf("Report written to %s", out)
+}
@@ -21,6 +21,8 @@ import (
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/il/evaluator"
 	mixerRuntime "istio.io/istio/mixer/pkg/runtime"
+	"istio.io/istio/mixer/pkg/runtime2"
+	"istio.io/istio/mixer/pkg/runtime2/dispatcher"
 	"istio.io/istio/mixer/pkg/server"
 	"istio.io/istio/mixer/pkg/template"
 	"istio.io/istio/pkg/version"
@@ -54,11 +56,41 @@ func serverCmd(info map[string]template.Info, adapters []adapter.InfoFn, printf,
 		"If true, each request to Mixer will be executed in a single go routine (useful for debugging)")
 
 	serverCmd.PersistentFlags().StringVarP(&sa.ConfigStoreURL, "configStoreURL", "", "",
-		"URL of the config store. Use k8s://path_to_kubeconfig or fs:// for file system. If path_to_kubeconfig is empty, in-cluster kubeconfig is used.")
+		"URL of the config store. Use k8s://path_to_kubeconfig, fs:// for file system, or etcd://host:port/prefix for etcd. "+
+			"If path_to_kubeconfig is empty, in-cluster kubeconfig is used.")
 
 	serverCmd.PersistentFlags().StringVarP(&sa.ConfigDefaultNamespace, "configDefaultNamespace", "", mixerRuntime.DefaultConfigNamespace,
 		"Namespace used to store mesh wide configuration.")
 
+	serverCmd.PersistentFlags().StringVarP(&sa.CaptureFile, "captureFile", "", "",
+		"If specified, a sample of the incoming requests is captured to this file, for later use with 'mixc replay'.")
+	serverCmd.PersistentFlags().Float64VarP(&sa.CaptureSampleRate, "captureSampleRate", "", 1.0,
+		"Fraction of the incoming requests, in the range (0, 1], to capture when captureFile is specified.")
+	serverCmd.PersistentFlags().Int64VarP(&sa.CaptureMaxSize, "captureMaxSize", "", server.DefaultCaptureMaxSize,
+		"Maximum size in bytes of the capture file, before it is rotated to a backup file with the '.1' suffix. "+
+			"Zero means no limit.")
+
+	serverCmd.PersistentFlags().BoolVarP(&sa.EnableProfiling, "profile", "", false,
+		"Enables the pprof profiling endpoints on the monitoring port.")
+
+	serverCmd.PersistentFlags().BoolVarP(&sa.UseNewRuntime, "useNewRuntime", "", false,
+		"If true, the new runtime is used, which keeps a history of config snapshots that can be rolled back to.")
+	serverCmd.PersistentFlags().IntVarP(&sa.ConfigHistorySize, "configHistorySize", "", runtime2.DefaultHistorySize,
+		"Number of most recent config snapshots that the new runtime keeps for rollbacks.")
+	serverCmd.PersistentFlags().BoolVarP(&sa.ConfigAutoRollback, "configAutoRollback", "", false,
+		"If true, the new runtime discards config snapshots whose handlers cannot all be built.")
+	serverCmd.PersistentFlags().BoolVarP(&sa.ConfigStrict, "configStrict", "", false,
+		"If true, the new runtime discards config snapshots that have any errors, such as references to unknown "+
+			"handlers or instances, and keeps the current config in use.")
+	serverCmd.PersistentFlags().StringVarP(&sa.NamespaceLimitsFile, "namespaceLimitsFile", "", "",
+		"Path of a JSON or YAML file with limits on the rules, instances and adapters of the config namespaces, "+
+			"which are enforced by the new runtime.")
+	serverCmd.PersistentFlags().IntVarP(&sa.ReportBatchSize, "reportBatchSize", "", dispatcher.DefaultMaxReportBatchSize,
+		"Maximum number of report instances that the new runtime stages, before it dispatches them to the handlers.")
+	serverCmd.PersistentFlags().DurationVarP(&sa.ReportBatchWindow, "reportBatchWindow", "", 0,
+		"If non-zero, the new runtime accumulates report instances across calls, and dispatches them at most this "+
+			"long after they were staged. Dispatch errors are then logged, instead of being returned to the clients.")
+
 	// Hide configIdentityAttribute and configIdentityAttributeDomain until we have a need to expose them.
 	// These parameters ensure that rest of Mixer makes no assumptions about specific identity attribute.
 	// Rules selection is based on scopes.
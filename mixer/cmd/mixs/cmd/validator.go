@@ -28,15 +28,19 @@ import (
 	"istio.io/istio/mixer/pkg/config"
 	"istio.io/istio/mixer/pkg/config/crd"
 	"istio.io/istio/mixer/pkg/config/store"
-	"istio.io/istio/mixer/pkg/runtime"
+	"istio.io/istio/mixer/pkg/runtime2"
+	runtimeConfig "istio.io/istio/mixer/pkg/runtime2/config"
 	"istio.io/istio/mixer/pkg/template"
 )
 
 func validatorCmd(info map[string]template.Info, adapters []adapter.InfoFn, printf, fatalf shared.FormatFn) *cobra.Command {
 	vc := crd.ControllerOptions{}
 	var kubeconfig string
+	var configStoreURL string
 	tmplRepo := template.NewRepository(info)
-	kinds := runtime.KindMap(config.AdapterInfoMap(adapters, tmplRepo.SupportsTemplate), info)
+	adapterMap := config.AdapterInfoMap(adapters, tmplRepo.SupportsTemplate)
+	templates := templateInfos(info)
+	kinds := runtimeConfig.KindMap(adapterMap, templates)
 	vc.ResourceNames = make([]string, 0, len(kinds))
 	for name := range kinds {
 		vc.ResourceNames = append(vc.ResourceNames, pluralize(name))
@@ -46,6 +50,9 @@ func validatorCmd(info map[string]template.Info, adapters []adapter.InfoFn, prin
 		Use:   "validator",
 		Short: "Runs an https server for validations. Works as an external admission webhook for k8s",
 		Run: func(cmd *cobra.Command, args []string) {
+			if configStoreURL != "" {
+				vc.Validator = store.NewValidator(newConfigValidator(configStoreURL, templates, adapterMap, fatalf), kinds)
+			}
 			runValidator(vc, kinds, kubeconfig, printf, fatalf)
 		},
 	}
@@ -60,9 +67,41 @@ func validatorCmd(info map[string]template.Info, adapters []adapter.InfoFn, prin
 	validatorCmd.PersistentFlags().DurationVar(&vc.RegistrationDelay, "registration-delay", 5*time.Second,
 		"Time to delay webhook registration after starting webhook server")
 	validatorCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Use a Kubernetes configuration file instead of in-cluster configuration")
+	validatorCmd.PersistentFlags().StringVar(&configStoreURL, "configStoreURL", "",
+		"URL of the config store. If set, changes are also validated against the current config, and rejected "+
+			"if they introduce errors into it, such as references to unknown handlers or instances. "+
+			"Use k8s://path_to_kubeconfig, fs:// for file system, or etcd://host:port/prefix for etcd.")
 	return validatorCmd
 }
 
+// newConfigValidator returns a validator that keeps the current config of the given config store, and validates changes
+// against it.
+func newConfigValidator(configStoreURL string, templates map[string]*template.Info, adapters map[string]*adapter.Info,
+	fatalf shared.FormatFn) store.Validator {
+
+	reg := store.NewRegistry(config.StoreInventory()...)
+	st, err := reg.NewStore(configStoreURL)
+	if err != nil {
+		fatalf("Failed to connect to the config store: %v", err)
+	}
+
+	v := runtime2.NewValidator(templates, adapters)
+	if err = v.StartListening(st); err != nil {
+		fatalf("Failed to read the config store: %v", err)
+	}
+	return v
+}
+
+// templateInfos returns the given template infos by reference, as they are used by the runtime2 packages.
+func templateInfos(info map[string]template.Info) map[string]*template.Info {
+	templates := make(map[string]*template.Info, len(info))
+	for name, t := range info {
+		t := t
+		templates[name] = &t
+	}
+	return templates
+}
+
 func createK8sClient(kubeconfig string) (*kubernetes.Clientset, error) {
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
@@ -25,3 +25,11 @@ type CacheabilityInfo struct {
 	// ValidUseCount represent the number of uses for which this result can be considered valid.
 	ValidUseCount int64
 }
+
+// CacheableHandler is implemented by handlers that limit how long the results they produce can be cached, for
+// example because they are based on data that is refreshed periodically.
+type CacheableHandler interface {
+	// Cacheability returns the limits on the validity of the results produced by the handler. A zero field places
+	// no limit on the corresponding validity.
+	Cacheability() CacheabilityInfo
+}
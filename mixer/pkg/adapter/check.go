@@ -51,6 +51,17 @@ func (r *CheckResult) Combine(otherPtr interface{}) interface{} {
 	return r
 }
 
+// LimitCacheability reduces the validity of the result to the given limits. A zero field of the limits places no
+// limit on the corresponding validity.
+func (r *CheckResult) LimitCacheability(c CacheabilityInfo) {
+	if c.ValidDuration > 0 && r.ValidDuration > c.ValidDuration {
+		r.ValidDuration = c.ValidDuration
+	}
+	if c.ValidUseCount > 0 && int64(r.ValidUseCount) > c.ValidUseCount {
+		r.ValidUseCount = int32(c.ValidUseCount)
+	}
+}
+
 // CombineCheckResult combines other result with self. It does not handle Status.
 func (r *CheckResult) CombineCheckResult(other *CheckResult) {
 	if r.ValidDuration > other.ValidDuration {
@@ -0,0 +1,94 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/pkg/log"
+)
+
+type (
+	env struct {
+		logger logger
+	}
+
+	logger struct {
+		l *zap.Logger
+		s *zap.SugaredLogger
+	}
+)
+
+var _ adapter.Env = env{}
+
+// NewEnv returns an adapter environment for out-of-process adapters, that logs through the process-wide logger
+// and runs work on new goroutines.
+func NewEnv(name string) adapter.Env {
+	// The logger is used through the adapter.Logger interface, so skip the interface method as well.
+	l := log.With(zap.String("adapter", name)).WithOptions(zap.AddCallerSkip(2))
+	return env{logger: logger{l: l, s: l.Sugar()}}
+}
+
+func (e env) Logger() adapter.Logger {
+	return e.logger
+}
+
+func (e env) ScheduleWork(fn adapter.WorkFunc) {
+	go e.run("worker", fn)
+}
+
+func (e env) ScheduleDaemon(fn adapter.DaemonFunc) {
+	go e.run("daemon", fn)
+}
+
+func (e env) run(kind string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			_ = e.logger.Errorf("Adapter %s failed: %v", kind, r) // nolint: gas
+		}
+	}()
+
+	fn()
+}
+
+func (l logger) VerbosityLevel(level adapter.VerbosityLevel) bool {
+	switch level {
+	case 0:
+		return l.l.Core().Enabled(zap.ErrorLevel)
+	case 1:
+		return l.l.Core().Enabled(zap.WarnLevel)
+	case 2:
+		return l.l.Core().Enabled(zap.InfoLevel)
+	}
+	return level >= 3 && l.l.Core().Enabled(zap.DebugLevel)
+}
+
+func (l logger) Infof(format string, args ...interface{}) {
+	l.s.Infof(format, args...)
+}
+
+func (l logger) Warningf(format string, args ...interface{}) {
+	l.s.Warnf(format, args...)
+}
+
+func (l logger) Errorf(format string, args ...interface{}) error {
+	s := fmt.Sprintf(format, args...)
+	l.s.Error(s)
+	return errors.New(s)
+}
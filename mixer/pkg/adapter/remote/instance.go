@@ -0,0 +1,499 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+// Instances are encoded as the InstanceMsg message of the template's HandlerService. The messages mirror the
+// instance structs generated by mixgenproc, and the field numbers are the ones of the template, which are also
+// carried by the InstanceParam messages. Fields of VALUE_TYPE type are encoded as Value messages.
+
+// NameFieldNum is the field number of the instance name in InstanceMsg messages.
+const NameFieldNum = 72295727
+
+// Field numbers of the Value message.
+const (
+	valueString = iota + 1
+	valueInt64
+	valueDouble
+	valueBool
+	valueIPAddress
+	valueTimestamp
+	valueDuration
+	valueEmailAddress
+	valueDNSName
+	valueURI
+	valueStringMap
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+type (
+	// field maps a field of an instance struct to its field number.
+	field struct {
+		index int
+		num   int
+
+		// The struct type of the InstanceParam message of the field, if the field is a resource.
+		param reflect.Type
+	}
+
+	// structInfo holds the encoding information of an instance struct.
+	structInfo struct {
+		fields []field
+		byNum  map[int]*field
+	}
+
+	structKey struct {
+		instance reflect.Type
+		param    reflect.Type
+	}
+)
+
+// structInfos caches the structInfo for each instance and InstanceParam struct pair.
+var structInfos sync.Map
+
+func getStructInfo(t reflect.Type, param reflect.Type) (*structInfo, error) {
+	key := structKey{t, param}
+	if si, ok := structInfos.Load(key); ok {
+		return si.(*structInfo), nil
+	}
+
+	si := &structInfo{byNum: make(map[int]*field, t.NumField())}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		pf, found := param.FieldByName(f.Name)
+		if !found {
+			if f.Name != "Name" {
+				return nil, fmt.Errorf("field %s of %v has no counterpart in %v", f.Name, t, param)
+			}
+			si.fields = append(si.fields, field{index: i, num: NameFieldNum})
+			continue
+		}
+
+		num, err := fieldNum(pf)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of %v: %v", pf.Name, param, err)
+		}
+		si.fields = append(si.fields, field{index: i, num: num, param: messageType(pf.Type)})
+	}
+	for i := range si.fields {
+		si.byNum[si.fields[i].num] = &si.fields[i]
+	}
+
+	structInfos.Store(key, si)
+	return si, nil
+}
+
+// fieldNum returns the field number from the protobuf struct tag, e.g. `protobuf:"bytes,1,opt,name=value"`.
+func fieldNum(f reflect.StructField) (int, error) {
+	parts := strings.Split(f.Tag.Get("protobuf"), ",")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("missing protobuf tag")
+	}
+	return strconv.Atoi(parts[1])
+}
+
+// messageType returns the struct type of a message field, or of the elements of a repeated or map field.
+func messageType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+// selfDescribing returns true if the fields of the instance struct carry their field numbers in protobuf tags,
+// which is the case for the instances of templates that are loaded at runtime.
+func selfDescribing(t reflect.Type) bool {
+	f, found := t.FieldByName("Name")
+	return found && f.Tag.Get("protobuf") != ""
+}
+
+// encodeInstance encodes an instance, e.g. a *metric.Instance, whose fields are numbered after param.
+func encodeInstance(e *encoder, instance interface{}, param reflect.Type) error {
+	v := reflect.ValueOf(instance)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unexpected instance type %T", instance)
+	}
+	if selfDescribing(v.Elem().Type()) {
+		param = v.Elem().Type()
+	}
+	return encodeStruct(e, v.Elem(), param)
+}
+
+func encodeStruct(e *encoder, v reflect.Value, param reflect.Type) error {
+	si, err := getStructInfo(v.Type(), param)
+	if err != nil {
+		return err
+	}
+	for _, f := range si.fields {
+		if err = encodeField(e, f.num, v.Field(f.index), f.param); err != nil {
+			return fmt.Errorf("%s: %v", v.Type().Field(f.index).Name, err)
+		}
+	}
+	return nil
+}
+
+// encodeField encodes the value of a field, unless it has the default value.
+func encodeField(e *encoder, num int, v reflect.Value, param reflect.Type) error {
+	switch v.Type() {
+	case timeType:
+		if t := v.Interface().(time.Time); !t.IsZero() {
+			e.timestamp(num, t)
+		}
+		return nil
+	case durationType:
+		if d := time.Duration(v.Int()); d != 0 {
+			e.duration(num, d)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() > 0 {
+			e.string(num, v.String())
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if v.Int() != 0 {
+			e.int64(num, v.Int())
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			e.bool(num, true)
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.Float() != 0 {
+			e.double(num, v.Float())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return e.message(num, func(m *encoder) error {
+			return encodeValue(m, v.Elem().Interface())
+		})
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeResource(e, num, v, param)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() > 0 {
+				e.bytes(num, v.Bytes())
+			}
+			return nil
+		}
+		if v.Type().Elem().Kind() != reflect.Ptr {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeResource(e, num, v.Index(i), param); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return encodeMap(e, num, v, param)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// encodeResource encodes a pointer to a resource struct as an embedded message.
+func encodeResource(e *encoder, num int, v reflect.Value, param reflect.Type) error {
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct || param == nil {
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return e.message(num, func(m *encoder) error {
+		if v.IsNil() {
+			return nil
+		}
+		return encodeStruct(m, v.Elem(), param)
+	})
+}
+
+// encodeMap encodes a map as repeated entry messages, in key order.
+func encodeMap(e *encoder, num int, v reflect.Value, param reflect.Type) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		err := e.message(num, func(m *encoder) error {
+			m.string(1, k.String())
+			return encodeField(m, 2, v.MapIndex(k), param)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeValue encodes the fields of a Value message.
+func encodeValue(e *encoder, v interface{}) error {
+	switch t := v.(type) {
+	case string:
+		e.string(valueString, t)
+	case int64:
+		e.int64(valueInt64, t)
+	case float64:
+		e.double(valueDouble, t)
+	case bool:
+		e.bool(valueBool, t)
+	case []byte:
+		e.bytes(valueIPAddress, t)
+	case net.IP:
+		e.bytes(valueIPAddress, t)
+	case time.Time:
+		e.timestamp(valueTimestamp, t)
+	case time.Duration:
+		e.duration(valueDuration, t)
+	case adapter.EmailAddress:
+		e.string(valueEmailAddress, string(t))
+	case adapter.DNSName:
+		e.string(valueDNSName, string(t))
+	case adapter.URI:
+		e.string(valueURI, string(t))
+	case map[string]string:
+		return e.message(valueStringMap, func(m *encoder) error {
+			return encodeMap(m, 1, reflect.ValueOf(t), nil)
+		})
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	return nil
+}
+
+// decodeInstance decodes an instance into the struct pointed to by instance, whose fields are numbered after
+// param.
+func decodeInstance(b []byte, instance interface{}, param reflect.Type) error {
+	v := reflect.ValueOf(instance)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unexpected instance type %T", instance)
+	}
+	if selfDescribing(v.Elem().Type()) {
+		param = v.Elem().Type()
+	}
+	return decodeStruct(b, v.Elem(), param)
+}
+
+func decodeStruct(b []byte, v reflect.Value, param reflect.Type) error {
+	si, err := getStructInfo(v.Type(), param)
+	if err != nil {
+		return err
+	}
+	return fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		f, found := si.byNum[num]
+		if !found {
+			return false, nil
+		}
+		if err := decodeField(d, wire, v.Field(f.index), f.param); err != nil {
+			return true, fmt.Errorf("%s: %v", v.Type().Field(f.index).Name, err)
+		}
+		return true, nil
+	})
+}
+
+// decodeField decodes the value of a field into v. Repeated and map fields are appended to.
+func decodeField(d *decoder, wire int, v reflect.Value, param reflect.Type) error {
+	switch v.Type() {
+	case timeType:
+		b, err := d.readBytes(wire)
+		if err != nil {
+			return err
+		}
+		t, err := decodeTimestamp(b)
+		v.Set(reflect.ValueOf(t))
+		return err
+	case durationType:
+		b, err := d.readBytes(wire)
+		if err != nil {
+			return err
+		}
+		dur, err := decodeDuration(b)
+		v.SetInt(int64(dur))
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		b, err := d.readBytes(wire)
+		v.SetString(string(b))
+		return err
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		u, err := d.readVarint(wire)
+		v.SetInt(int64(u))
+		return err
+	case reflect.Bool:
+		u, err := d.readVarint(wire)
+		v.SetBool(u != 0)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := d.readDouble(wire)
+		v.SetFloat(f)
+		return err
+	}
+
+	b, err := d.readBytes(wire)
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		val, err := decodeValue(b)
+		if err != nil {
+			return err
+		}
+		if val != nil {
+			v.Set(reflect.ValueOf(val))
+		}
+	case reflect.Ptr:
+		if param == nil || v.Type().Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeStruct(b, v.Elem(), param)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		if et := v.Type().Elem(); param == nil || et.Kind() != reflect.Ptr || et.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		elem := reflect.New(v.Type().Elem().Elem())
+		if err := decodeStruct(b, elem.Elem(), param); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, elem))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", v.Type())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := ""
+		val := reflect.New(v.Type().Elem()).Elem()
+		err := fields(b, func(d *decoder, num int, wire int) (bool, error) {
+			switch num {
+			case 1:
+				k, err := d.readBytes(wire)
+				key = string(k)
+				return true, err
+			case 2:
+				return true, decodeField(d, wire, val, param)
+			}
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), val)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// decodeValue decodes a Value message. It returns nil if no value is set.
+func decodeValue(b []byte) (interface{}, error) {
+	var v interface{}
+	err := fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		var err error
+		switch num {
+		case valueInt64:
+			var u uint64
+			u, err = d.readVarint(wire)
+			v = int64(u)
+		case valueBool:
+			var u uint64
+			u, err = d.readVarint(wire)
+			v = u != 0
+		case valueDouble:
+			v, err = d.readDouble(wire)
+		case valueString, valueIPAddress, valueTimestamp, valueDuration, valueEmailAddress, valueDNSName, valueURI,
+			valueStringMap:
+			var s []byte
+			if s, err = d.readBytes(wire); err != nil {
+				return true, err
+			}
+			v, err = decodeBytesValue(num, s)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	return v, err
+}
+
+func decodeBytesValue(num int, b []byte) (interface{}, error) {
+	switch num {
+	case valueString:
+		return string(b), nil
+	case valueIPAddress:
+		return append([]byte(nil), b...), nil
+	case valueTimestamp:
+		return decodeTimestamp(b)
+	case valueDuration:
+		return decodeDuration(b)
+	case valueEmailAddress:
+		return adapter.EmailAddress(b), nil
+	case valueDNSName:
+		return adapter.DNSName(b), nil
+	case valueURI:
+		return adapter.URI(b), nil
+	}
+
+	m := make(map[string]string)
+	err := fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		if num != 1 {
+			return false, nil
+		}
+		return true, decodeField(d, wire, reflect.ValueOf(m), nil)
+	})
+	return m, err
+}
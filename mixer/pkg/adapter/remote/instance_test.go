@@ -0,0 +1,291 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/mixer/pkg/adapter"
+)
+
+type (
+	testParam struct {
+		Value           string                        `protobuf:"bytes,1,opt,name=value,proto3"`
+		Dimensions      map[string]string             `protobuf:"bytes,2,rep,name=dimensions"`
+		Int64Primitive  string                        `protobuf:"bytes,3,opt,name=int64Primitive,proto3"`
+		BoolPrimitive   string                        `protobuf:"bytes,4,opt,name=boolPrimitive,proto3"`
+		DoublePrimitive string                        `protobuf:"bytes,5,opt,name=doublePrimitive,proto3"`
+		StringPrimitive string                        `protobuf:"bytes,6,opt,name=stringPrimitive,proto3"`
+		TimeStamp       string                        `protobuf:"bytes,7,opt,name=timeStamp,proto3"`
+		Duration        string                        `protobuf:"bytes,8,opt,name=duration,proto3"`
+		IpAddr          string                        `protobuf:"bytes,9,opt,name=ip_addr,proto3"`
+		DnsName         string                        `protobuf:"bytes,10,opt,name=dns_name,proto3"`
+		EmailAddr       string                        `protobuf:"bytes,11,opt,name=email_addr,proto3"`
+		Uri             string                        `protobuf:"bytes,12,opt,name=uri,proto3"`
+		Counts          map[string]string             `protobuf:"bytes,13,rep,name=counts"`
+		Res             *testResourceParam            `protobuf:"bytes,14,opt,name=res"`
+		ResList         []*testResourceParam          `protobuf:"bytes,15,rep,name=res_list"`
+		ResMap          map[string]*testResourceParam `protobuf:"bytes,16,rep,name=res_map"`
+	}
+
+	testResourceParam struct {
+		Value string `protobuf:"bytes,1,opt,name=value,proto3"`
+		Name  string `protobuf:"bytes,2,opt,name=name,proto3"`
+	}
+
+	testInstance struct {
+		Name            string
+		Value           interface{}
+		Dimensions      map[string]interface{}
+		Int64Primitive  int64
+		BoolPrimitive   bool
+		DoublePrimitive float64
+		StringPrimitive string
+		TimeStamp       time.Time
+		Duration        time.Duration
+		IpAddr          net.IP
+		DnsName         adapter.DNSName
+		EmailAddr       adapter.EmailAddress
+		Uri             adapter.URI
+		Counts          map[string]int64
+		Res             *testResource
+		ResList         []*testResource
+		ResMap          map[string]*testResource
+	}
+
+	testResource struct {
+		Value interface{}
+		Name  string
+	}
+)
+
+var testParamType = reflect.TypeOf(testParam{})
+
+func TestInstanceRoundTrip(t *testing.T) {
+	ts := time.Unix(1522000000, 1234).UTC()
+
+	cases := []struct {
+		name     string
+		instance *testInstance
+	}{
+		{"empty", &testInstance{}},
+		{"scalars", &testInstance{
+			Name:            "requestcount.metric.istio-system",
+			Value:           int64(42),
+			Int64Primitive:  -7,
+			BoolPrimitive:   true,
+			DoublePrimitive: 3.5,
+			StringPrimitive: "str",
+			TimeStamp:       ts,
+			Duration:        -1500 * time.Millisecond,
+			IpAddr:          net.ParseIP("10.0.0.1").To4(),
+			DnsName:         "foo.bar.svc.cluster.local",
+			EmailAddr:       "foo@bar.com",
+			Uri:             "http://foo/bar",
+		}},
+		{"maps", &testInstance{
+			Dimensions: map[string]interface{}{
+				"code":    int64(200),
+				"source":  "productpage",
+				"latency": 10 * time.Millisecond,
+				"empty":   "",
+			},
+			Counts: map[string]int64{"a": 1, "b": 0},
+		}},
+		{"resources", &testInstance{
+			Res:     &testResource{Value: "v", Name: "res"},
+			ResList: []*testResource{{Name: "first"}, {Value: 2.5}},
+			ResMap:  map[string]*testResource{"k": {Value: true}},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var e encoder
+			if err := encodeInstance(&e, c.instance, testParamType); err != nil {
+				t.Fatalf("encodeInstance() => unexpected error: %v", err)
+			}
+
+			got := &testInstance{}
+			if err := decodeInstance(e.buf, got, testParamType); err != nil {
+				t.Fatalf("decodeInstance() => unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.instance) {
+				t.Errorf("decodeInstance() =>\ngot  %#v\nwant %#v", got, c.instance)
+			}
+		})
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{"", ""},
+		{"str", "str"},
+		{int64(0), int64(0)},
+		{int64(-1), int64(-1)},
+		{0.0, 0.0},
+		{-2.5, -2.5},
+		{false, false},
+		{true, true},
+		{[]byte{10, 0, 0, 1}, []byte{10, 0, 0, 1}},
+		{net.ParseIP("::1"), []byte(net.ParseIP("::1"))},
+		{time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC()},
+		{time.Unix(1522000000, 999).UTC(), time.Unix(1522000000, 999).UTC()},
+		{time.Duration(0), time.Duration(0)},
+		{2*time.Second + 3, 2*time.Second + 3},
+		{adapter.EmailAddress("foo@bar.com"), adapter.EmailAddress("foo@bar.com")},
+		{adapter.DNSName("foo.com"), adapter.DNSName("foo.com")},
+		{adapter.URI("http://foo.com"), adapter.URI("http://foo.com")},
+		{map[string]string{}, map[string]string{}},
+		{map[string]string{"a": "b", "c": ""}, map[string]string{"a": "b", "c": ""}},
+	}
+
+	for _, c := range cases {
+		var e encoder
+		if err := encodeValue(&e, c.value); err != nil {
+			t.Errorf("encodeValue(%#v) => unexpected error: %v", c.value, err)
+			continue
+		}
+		got, err := decodeValue(e.buf)
+		if err != nil {
+			t.Errorf("decodeValue(%#v) => unexpected error: %v", c.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("decodeValue(%#v) => got %#v, want %#v", c.value, got, c.want)
+		}
+	}
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	ts := time.Unix(1522000000, 1234).UTC()
+	d := -90*time.Second - 5
+
+	var e encoder
+	e.timestamp(1, ts)
+	e.duration(2, d)
+
+	err := fields(e.buf, func(dec *decoder, num int, wire int) (bool, error) {
+		b, err := dec.readBytes(wire)
+		if err != nil {
+			return true, err
+		}
+		switch num {
+		case 1:
+			pb := &types.Timestamp{}
+			if err = proto.Unmarshal(b, pb); err != nil {
+				return true, err
+			}
+			if got, _ := types.TimestampFromProto(pb); !got.Equal(ts) {
+				t.Errorf("timestamp => got %v, want %v", got, ts)
+			}
+		case 2:
+			pb := &types.Duration{}
+			if err = proto.Unmarshal(b, pb); err != nil {
+				return true, err
+			}
+			if got, _ := types.DurationFromProto(pb); got != d {
+				t.Errorf("duration => got %v, want %v", got, d)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestInstanceErrors(t *testing.T) {
+	type unknownField struct {
+		Unknown string
+	}
+	type unsupportedType struct {
+		Value chan int
+	}
+
+	cases := []struct {
+		name     string
+		instance interface{}
+		want     string
+	}{
+		{"not a pointer", testInstance{}, "unexpected instance type"},
+		{"no counterpart", &unknownField{}, "no counterpart"},
+		{"unsupported type", &unsupportedType{Value: make(chan int)}, "unsupported type"},
+		{"unsupported value", &testInstance{Value: struct{}{}}, "unsupported value type"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var e encoder
+			err := encodeInstance(&e, c.instance, testParamType)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("encodeInstance() => got error %v, want %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var e encoder
+	if err := encodeInstance(&e, &testInstance{StringPrimitive: "str", Int64Primitive: 1}, testParamType); err != nil {
+		t.Fatalf("encodeInstance() => unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		b    []byte
+	}{
+		{"truncated", e.buf[:len(e.buf)-1]},
+		{"wrong wire type", []byte{6<<3 | wireVarint, 1}},
+		{"illegal field number", []byte{0}},
+		{"unsupported wire type", []byte{1<<3 | 3}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := decodeInstance(c.b, &testInstance{}, testParamType); err == nil {
+				t.Error("decodeInstance() => got no error")
+			}
+		})
+	}
+}
+
+func TestDecodeSkipsUnknownFields(t *testing.T) {
+	var e encoder
+	e.int64(100, 1)
+	e.double(101, 1)
+	e.string(102, "unknown")
+	e.tag(103, wireFixed32)
+	e.buf = append(e.buf, 0, 0, 0, 0)
+	e.string(6, "str")
+
+	got := &testInstance{}
+	if err := decodeInstance(e.buf, got, testParamType); err != nil {
+		t.Fatalf("decodeInstance() => unexpected error: %v", err)
+	}
+	if got.StringPrimitive != "str" {
+		t.Errorf("decodeInstance() => got %#v", got)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	adptTmpl "istio.io/api/mixer/v1/template"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+// Request is the HandleRequest message of a template's HandlerService.
+type Request struct {
+	// Instances to process. Requests of check and quota templates carry exactly one instance.
+	Instances []interface{}
+
+	// AdapterConfig is the adapter-specific configuration of the handler.
+	AdapterConfig *types.Any
+
+	// InstanceTypes are the inferred types of the instances the handler receives, keyed by instance name.
+	InstanceTypes map[string]*types.Any
+
+	// QuotaArgs are the arguments of a quota allocation. Only set for quota templates.
+	QuotaArgs adapter.QuotaArgs
+}
+
+// Field numbers of the HandleRequest message.
+const (
+	requestInstance      = 1
+	requestAdapterConfig = 2
+	requestDedupID       = 3
+	requestInstanceTypes = 4
+	requestQuotaRequest  = 5
+)
+
+// MarshalRequest encodes the HandleRequest message of the template's HandlerService.
+func MarshalRequest(ti *template.Info, r *Request) ([]byte, error) {
+	param := reflect.TypeOf(ti.CtrCfg).Elem()
+	if ti.Variety != adptTmpl.TEMPLATE_VARIETY_REPORT && len(r.Instances) != 1 {
+		return nil, fmt.Errorf("template %s: got %d instances, want 1", ti.Name, len(r.Instances))
+	}
+
+	var e encoder
+	for _, inst := range r.Instances {
+		err := e.message(requestInstance, func(m *encoder) error {
+			return encodeInstance(m, inst, param)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", ti.Name, err)
+		}
+	}
+
+	if r.AdapterConfig != nil {
+		b, err := proto.Marshal(r.AdapterConfig)
+		if err != nil {
+			return nil, err
+		}
+		e.bytes(requestAdapterConfig, b)
+	}
+
+	if ti.Variety == adptTmpl.TEMPLATE_VARIETY_QUOTA && r.QuotaArgs.DeduplicationID != "" {
+		e.string(requestDedupID, r.QuotaArgs.DeduplicationID)
+	}
+
+	names := make([]string, 0, len(r.InstanceTypes))
+	for name := range r.InstanceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b, err := proto.Marshal(r.InstanceTypes[name])
+		if err != nil {
+			return nil, err
+		}
+		_ = e.message(requestInstanceTypes, func(m *encoder) error {
+			m.string(1, name)
+			m.bytes(2, b)
+			return nil
+		})
+	}
+
+	if ti.Variety == adptTmpl.TEMPLATE_VARIETY_QUOTA {
+		_ = e.message(requestQuotaRequest, func(m *encoder) error {
+			if r.QuotaArgs.QuotaAmount != 0 {
+				m.int64(1, r.QuotaArgs.QuotaAmount)
+			}
+			if r.QuotaArgs.BestEffort {
+				m.bool(2, true)
+			}
+			return nil
+		})
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalRequest decodes the HandleRequest message of the template's HandlerService. Instances are created
+// with the template's NewInstance function.
+func UnmarshalRequest(ti *template.Info, b []byte) (*Request, error) {
+	if ti.NewInstance == nil {
+		return nil, fmt.Errorf("template %s does not support out-of-process adapters", ti.Name)
+	}
+	param := reflect.TypeOf(ti.CtrCfg).Elem()
+
+	r := &Request{}
+	err := fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		if num > requestQuotaRequest {
+			return false, nil
+		}
+
+		v, err := d.readBytes(wire)
+		if err != nil {
+			return true, err
+		}
+
+		switch num {
+		case requestInstance:
+			inst := ti.NewInstance()
+			if err = decodeInstance(v, inst, param); err != nil {
+				return true, fmt.Errorf("template %s: %v", ti.Name, err)
+			}
+			r.Instances = append(r.Instances, inst)
+		case requestAdapterConfig:
+			r.AdapterConfig = &types.Any{}
+			err = proto.Unmarshal(v, r.AdapterConfig)
+		case requestDedupID:
+			r.QuotaArgs.DeduplicationID = string(v)
+		case requestInstanceTypes:
+			if r.InstanceTypes == nil {
+				r.InstanceTypes = make(map[string]*types.Any)
+			}
+			err = decodeInstanceType(v, r.InstanceTypes)
+		case requestQuotaRequest:
+			err = fields(v, func(d *decoder, num int, wire int) (bool, error) {
+				switch num {
+				case 1:
+					u, err := d.readVarint(wire)
+					r.QuotaArgs.QuotaAmount = int64(u)
+					return true, err
+				case 2:
+					u, err := d.readVarint(wire)
+					r.QuotaArgs.BestEffort = u != 0
+					return true, err
+				}
+				return false, nil
+			})
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ti.Variety != adptTmpl.TEMPLATE_VARIETY_REPORT && len(r.Instances) != 1 {
+		return nil, fmt.Errorf("template %s: got %d instances, want 1", ti.Name, len(r.Instances))
+	}
+	return r, nil
+}
+
+func decodeInstanceType(b []byte, m map[string]*types.Any) error {
+	var name string
+	t := &types.Any{}
+	err := fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		switch num {
+		case 1:
+			v, err := d.readBytes(wire)
+			name = string(v)
+			return true, err
+		case 2:
+			v, err := d.readBytes(wire)
+			if err != nil {
+				return true, err
+			}
+			return true, proto.Unmarshal(v, t)
+		}
+		return false, nil
+	})
+	m[name] = t
+	return err
+}
+
+// Field numbers of the CheckResult and QuotaResult messages.
+const (
+	resultStatus        = 1
+	resultValidDuration = 2
+	resultAmount        = 3
+)
+
+// MarshalCheckResult encodes a CheckResult message.
+func MarshalCheckResult(r adapter.CheckResult) ([]byte, error) {
+	var e encoder
+	if err := encodeResult(&e, r.Status, r.ValidDuration); err != nil {
+		return nil, err
+	}
+	if r.ValidUseCount != 0 {
+		e.int64(resultAmount, int64(r.ValidUseCount))
+	}
+	return e.buf, nil
+}
+
+// UnmarshalCheckResult decodes a CheckResult message.
+func UnmarshalCheckResult(b []byte) (adapter.CheckResult, error) {
+	var r adapter.CheckResult
+	var count int64
+	err := decodeResult(b, &r.Status, &r.ValidDuration, &count)
+	r.ValidUseCount = int32(count)
+	return r, err
+}
+
+// MarshalQuotaResult encodes a QuotaResult message.
+func MarshalQuotaResult(r adapter.QuotaResult) ([]byte, error) {
+	var e encoder
+	if err := encodeResult(&e, r.Status, r.ValidDuration); err != nil {
+		return nil, err
+	}
+	if r.Amount != 0 {
+		e.int64(resultAmount, r.Amount)
+	}
+	return e.buf, nil
+}
+
+// UnmarshalQuotaResult decodes a QuotaResult message.
+func UnmarshalQuotaResult(b []byte) (adapter.QuotaResult, error) {
+	var r adapter.QuotaResult
+	err := decodeResult(b, &r.Status, &r.ValidDuration, &r.Amount)
+	return r, err
+}
+
+func encodeResult(e *encoder, status rpc.Status, validDuration time.Duration) error {
+	if status.Code != 0 || status.Message != "" || len(status.Details) > 0 {
+		b, err := proto.Marshal(&status)
+		if err != nil {
+			return err
+		}
+		e.bytes(resultStatus, b)
+	}
+	if validDuration != 0 {
+		e.duration(resultValidDuration, validDuration)
+	}
+	return nil
+}
+
+func decodeResult(b []byte, status *rpc.Status, validDuration *time.Duration, amount *int64) error {
+	return fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		switch num {
+		case resultStatus:
+			v, err := d.readBytes(wire)
+			if err != nil {
+				return true, err
+			}
+			return true, proto.Unmarshal(v, status)
+		case resultValidDuration:
+			v, err := d.readBytes(wire)
+			if err != nil {
+				return true, err
+			}
+			*validDuration, err = decodeDuration(v)
+			return true, err
+		case resultAmount:
+			u, err := d.readVarint(wire)
+			*amount = int64(u)
+			return true, err
+		}
+		return false, nil
+	})
+}
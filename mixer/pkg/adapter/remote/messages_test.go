@@ -0,0 +1,216 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	adptTmpl "istio.io/api/mixer/v1/template"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/mixer/template/listentry"
+	"istio.io/istio/mixer/template/metric"
+	"istio.io/istio/mixer/template/quota"
+)
+
+var (
+	metricInfo = &template.Info{
+		Name:        metric.TemplateName,
+		Impl:        "metric",
+		Variety:     adptTmpl.TEMPLATE_VARIETY_REPORT,
+		CtrCfg:      &metric.InstanceParam{},
+		NewInstance: func() interface{} { return &metric.Instance{} },
+	}
+	listentryInfo = &template.Info{
+		Name:        listentry.TemplateName,
+		Impl:        "listentry",
+		Variety:     adptTmpl.TEMPLATE_VARIETY_CHECK,
+		CtrCfg:      &listentry.InstanceParam{},
+		NewInstance: func() interface{} { return &listentry.Instance{} },
+	}
+	quotaInfo = &template.Info{
+		Name:        quota.TemplateName,
+		Impl:        "quota",
+		Variety:     adptTmpl.TEMPLATE_VARIETY_QUOTA,
+		CtrCfg:      &quota.InstanceParam{},
+		NewInstance: func() interface{} { return &quota.Instance{} },
+	}
+)
+
+func mustMarshalAny(t *testing.T, s string) *types.Any {
+	a, err := types.MarshalAny(&types.StringValue{Value: s})
+	if err != nil {
+		t.Fatalf("MarshalAny() => unexpected error: %v", err)
+	}
+	return a
+}
+
+func TestMethodName(t *testing.T) {
+	if got, want := MethodName(metricInfo), "/metric.HandlerService/Handle"; got != want {
+		t.Errorf("MethodName() => got %s, want %s", got, want)
+	}
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		ti   *template.Info
+		req  *Request
+	}{
+		{"report", metricInfo, &Request{
+			Instances: []interface{}{
+				&metric.Instance{
+					Name:       "requestcount.metric.istio-system",
+					Value:      int64(1),
+					Dimensions: map[string]interface{}{"code": int64(200)},
+				},
+				&metric.Instance{
+					Name:  "requestsize.metric.istio-system",
+					Value: int64(1024),
+				},
+			},
+			AdapterConfig: mustMarshalAny(t, "config"),
+			InstanceTypes: map[string]*types.Any{
+				"requestcount.metric.istio-system": mustMarshalAny(t, "count"),
+				"requestsize.metric.istio-system":  mustMarshalAny(t, "size"),
+			},
+		}},
+		{"check", listentryInfo, &Request{
+			Instances: []interface{}{&listentry.Instance{Name: "source.listentry.istio-system", Value: "10.0.0.1"}},
+		}},
+		{"quota", quotaInfo, &Request{
+			Instances: []interface{}{&quota.Instance{
+				Name:       "requestcount.quota.istio-system",
+				Dimensions: map[string]interface{}{"source": "productpage"},
+			}},
+			QuotaArgs: adapter.QuotaArgs{DeduplicationID: "dedup", QuotaAmount: 10, BestEffort: true},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := MarshalRequest(c.ti, c.req)
+			if err != nil {
+				t.Fatalf("MarshalRequest() => unexpected error: %v", err)
+			}
+			got, err := UnmarshalRequest(c.ti, b)
+			if err != nil {
+				t.Fatalf("UnmarshalRequest() => unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.req) {
+				t.Errorf("UnmarshalRequest() =>\ngot  %#v\nwant %#v", got, c.req)
+			}
+		})
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	two := []interface{}{&listentry.Instance{}, &listentry.Instance{}}
+	if _, err := MarshalRequest(listentryInfo, &Request{Instances: two}); err == nil {
+		t.Error("MarshalRequest() => got no error for two check instances")
+	}
+
+	b, err := MarshalRequest(metricInfo, &Request{Instances: []interface{}{&metric.Instance{}, &metric.Instance{}}})
+	if err != nil {
+		t.Fatalf("MarshalRequest() => unexpected error: %v", err)
+	}
+	if _, err = UnmarshalRequest(quotaInfo, b); err == nil || !strings.Contains(err.Error(), "want 1") {
+		t.Errorf("UnmarshalRequest() => got error %v, want instance count error", err)
+	}
+
+	ti := *metricInfo
+	ti.NewInstance = nil
+	if _, err = UnmarshalRequest(&ti, b); err == nil {
+		t.Error("UnmarshalRequest() => got no error for template without NewInstance")
+	}
+
+	if _, err = UnmarshalRequest(metricInfo, []byte{requestInstance<<3 | wireBytes, 10}); err == nil {
+		t.Error("UnmarshalRequest() => got no error for truncated request")
+	}
+}
+
+func TestResultRoundTrip(t *testing.T) {
+	checks := []adapter.CheckResult{
+		{},
+		{
+			Status:        rpc.Status{Code: int32(rpc.PERMISSION_DENIED), Message: "denied"},
+			ValidDuration: 5 * time.Second,
+			ValidUseCount: 100,
+		},
+	}
+	for _, want := range checks {
+		b, err := MarshalCheckResult(want)
+		if err != nil {
+			t.Fatalf("MarshalCheckResult() => unexpected error: %v", err)
+		}
+		got, err := UnmarshalCheckResult(b)
+		if err != nil {
+			t.Fatalf("UnmarshalCheckResult() => unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("UnmarshalCheckResult() => got %v, want %v", got, want)
+		}
+	}
+
+	quotas := []adapter.QuotaResult{
+		{},
+		{
+			Status:        rpc.Status{Code: int32(rpc.RESOURCE_EXHAUSTED)},
+			ValidDuration: time.Minute,
+			Amount:        42,
+		},
+	}
+	for _, want := range quotas {
+		b, err := MarshalQuotaResult(want)
+		if err != nil {
+			t.Fatalf("MarshalQuotaResult() => unexpected error: %v", err)
+		}
+		got, err := UnmarshalQuotaResult(b)
+		if err != nil {
+			t.Fatalf("UnmarshalQuotaResult() => unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("UnmarshalQuotaResult() => got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCodec(t *testing.T) {
+	var c Codec
+	in := []byte("encoded")
+
+	b, err := c.Marshal(&in)
+	if err != nil || string(b) != "encoded" {
+		t.Errorf("Marshal() => got (%q, %v)", b, err)
+	}
+
+	var out []byte
+	if err = c.Unmarshal(in, &out); err != nil || string(out) != "encoded" {
+		t.Errorf("Unmarshal() => got (%q, %v)", out, err)
+	}
+
+	if _, err = c.Marshal("foo"); err == nil {
+		t.Error("Marshal() => got no error for a string")
+	}
+	if err = c.Unmarshal(in, "foo"); err == nil {
+		t.Error("Unmarshal() => got no error for a string")
+	}
+}
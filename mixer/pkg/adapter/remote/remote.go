@@ -0,0 +1,109 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remote lets adapters run outside of the Mixer process.
+//
+// For each template, mixgenproc generates a gRPC service definition (template_handler_service.proto) with a
+// single Handle method. Out-of-process adapters implement this service for every template they support, and
+// Mixer calls it whenever a rule dispatches instances to a handler of the "remote" adapter, whose configuration
+// points to the address of the out-of-process adapter.
+//
+// This package contains the wire format shared by both sides, and a Server that exposes a regular Go adapter
+// (adapter.Info) as an out-of-process adapter.
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+type (
+	// Builder is implemented by handler builders that accept instances of any template, such as the builder of
+	// the "remote" adapter. Mixer passes the inferred types of the instances to SetInstanceTypes, instead of
+	// calling the template-specific Set<Template>Types methods.
+	Builder interface {
+		adapter.HandlerBuilder
+
+		// SetInstanceTypes sets the inferred types of the instances of the given template, keyed by
+		// instance name.
+		SetInstanceTypes(template string, types map[string]proto.Message)
+	}
+
+	// Handler is implemented by handlers that accept instances of any template. Mixer calls these methods
+	// instead of the template-specific Handle<Template> methods.
+	Handler interface {
+		adapter.Handler
+
+		// HandleRemoteCheck dispatches an instance of a check template.
+		HandleRemoteCheck(ctx context.Context, ti *template.Info, instance interface{}) (adapter.CheckResult, error)
+
+		// HandleRemoteReport dispatches instances of a report template.
+		HandleRemoteReport(ctx context.Context, ti *template.Info, instances []interface{}) error
+
+		// HandleRemoteQuota dispatches an instance of a quota template.
+		HandleRemoteQuota(ctx context.Context, ti *template.Info, instance interface{},
+			args adapter.QuotaArgs) (adapter.QuotaResult, error)
+	}
+
+	// Codec is a gRPC codec that passes already encoded messages through as is. The messages of the
+	// HandlerServices are template specific, and are encoded by MarshalRequest and friends instead.
+	Codec struct{}
+)
+
+const (
+	serviceSuffix = ".HandlerService"
+	methodName    = "Handle"
+)
+
+// ServiceName returns the fully qualified name of the HandlerService generated for the template.
+func ServiceName(ti *template.Info) string {
+	// The service lives in the proto package of the template, which is the Impl of the template.
+	return ti.Impl + serviceSuffix
+}
+
+// MethodName returns the full gRPC method name of the Handle method of the template's HandlerService.
+func MethodName(ti *template.Info) string {
+	return "/" + ServiceName(ti) + "/" + methodName
+}
+
+// Marshal returns the encoded message as is.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case *[]byte:
+		return *t, nil
+	}
+	return nil, fmt.Errorf("remote codec: unexpected message type %T", v)
+}
+
+// Unmarshal stores the encoded message in v, which must be a *[]byte.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("remote codec: unexpected message type %T", v)
+	}
+	*p = append((*p)[:0], data...)
+	return nil
+}
+
+// String returns the name of the codec.
+func (Codec) String() string {
+	return "remote"
+}
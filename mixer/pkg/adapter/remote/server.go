@@ -0,0 +1,268 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	multierror "github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	adptTmpl "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+// Server runs an adapter out of process, by serving the HandlerServices of the templates that the adapter
+// supports.
+//
+// Handlers are built on demand from the adapter configuration and the instance types carried by the requests,
+// and are reused for subsequent requests with the same configuration. Handlers are closed when the server is
+// closed.
+type Server struct {
+	info     adapter.Info
+	env      adapter.Env
+	listener net.Listener
+	server   *grpc.Server
+
+	handlersLock sync.Mutex
+	handlers     map[[sha256.Size]byte]adapter.Handler
+}
+
+// NewServer creates a Server for the adapter, listening on the given address, e.g. ":9070". templates holds the
+// Info of the templates, keyed by name, and must contain all the templates that the adapter supports. The
+// SupportedTmplInfo map that is generated for Mixer is a good fit.
+func NewServer(addr string, info adapter.Info, templates map[string]template.Info, env adapter.Env) (*Server, error) {
+	s := &Server{
+		info:     info,
+		env:      env,
+		server:   grpc.NewServer(grpc.CustomCodec(Codec{})),
+		handlers: make(map[[sha256.Size]byte]adapter.Handler),
+	}
+
+	for _, name := range info.SupportedTemplates {
+		t, found := templates[name]
+		if !found {
+			return nil, fmt.Errorf("adapter %s supports unknown template %s", info.Name, name)
+		}
+		if t.Variety == adptTmpl.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR || t.NewInstance == nil {
+			return nil, fmt.Errorf("template %s does not support out-of-process adapters", name)
+		}
+
+		ti := t
+		s.server.RegisterService(&grpc.ServiceDesc{
+			ServiceName: ServiceName(&ti),
+			HandlerType: (*interface{})(nil),
+			Methods: []grpc.MethodDesc{
+				{
+					MethodName: methodName,
+					Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error,
+						_ grpc.UnaryServerInterceptor) (interface{}, error) {
+						var in []byte
+						if err := dec(&in); err != nil {
+							return nil, err
+						}
+						return s.handle(ctx, &ti, in)
+					},
+				},
+			},
+			Streams: []grpc.StreamDesc{},
+		}, s)
+	}
+
+	var err error
+	if s.listener, err = net.Listen("tcp", addr); err != nil {
+		return nil, fmt.Errorf("unable to listen on %s: %v", addr, err)
+	}
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Run serves requests until the server is closed.
+func (s *Server) Run() error {
+	return s.server.Serve(s.listener)
+}
+
+// Close stops the server, and closes the handlers that it built.
+func (s *Server) Close() error {
+	s.server.Stop()
+
+	s.handlersLock.Lock()
+	defer s.handlersLock.Unlock()
+
+	var result *multierror.Error
+	for k, h := range s.handlers {
+		if err := h.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+		delete(s.handlers, k)
+	}
+	return result.ErrorOrNil()
+}
+
+func (s *Server) handle(ctx context.Context, ti *template.Info, in []byte) ([]byte, error) {
+	req, err := UnmarshalRequest(ti, in)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	h, err := s.getHandler(ti, req)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to build handler: %v", err)
+	}
+
+	var out []byte
+	switch ti.Variety {
+	case adptTmpl.TEMPLATE_VARIETY_CHECK:
+		var r adapter.CheckResult
+		if r, err = ti.DispatchCheck(ctx, h, req.Instances[0]); err == nil {
+			out, err = MarshalCheckResult(r)
+		}
+	case adptTmpl.TEMPLATE_VARIETY_REPORT:
+		err = ti.DispatchReport(ctx, h, req.Instances)
+	case adptTmpl.TEMPLATE_VARIETY_QUOTA:
+		var r adapter.QuotaResult
+		if r, err = ti.DispatchQuota(ctx, h, req.Instances[0], req.QuotaArgs); err == nil {
+			out, err = MarshalQuotaResult(r)
+		}
+	}
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return out, nil
+}
+
+// getHandler returns the handler for the configuration carried by the request, building it if necessary.
+func (s *Server) getHandler(ti *template.Info, req *Request) (adapter.Handler, error) {
+	key := handlerKey(ti, req)
+
+	s.handlersLock.Lock()
+	defer s.handlersLock.Unlock()
+
+	if h, found := s.handlers[key]; found {
+		return h, nil
+	}
+
+	h, err := s.buildHandler(ti, req)
+	if err != nil {
+		return nil, err
+	}
+	s.handlers[key] = h
+	return h, nil
+}
+
+func (s *Server) buildHandler(ti *template.Info, req *Request) (adapter.Handler, error) {
+	cfg, err := adapterConfig(s.info.DefaultConfig, req.AdapterConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	inferredTypes := make(map[string]proto.Message, len(req.InstanceTypes))
+	for name, t := range req.InstanceTypes {
+		var da types.DynamicAny
+		if err = types.UnmarshalAny(t, &da); err != nil {
+			return nil, fmt.Errorf("invalid type of instance %s: %v", name, err)
+		}
+		inferredTypes[name] = da.Message
+	}
+
+	b := s.info.NewBuilder()
+	ti.SetType(inferredTypes, b)
+	b.SetAdapterConfig(cfg)
+	if ce := b.Validate(); ce != nil {
+		return nil, fmt.Errorf("builder validation failed: %v", ce)
+	}
+
+	h, err := b.Build(context.Background(), s.env)
+	if err != nil {
+		return nil, err
+	}
+	if !ti.HandlerSupportsTemplate(h) {
+		_ = h.Close()
+		return nil, fmt.Errorf("handler does not implement %s", ti.HndlrInterfaceName)
+	}
+	return h, nil
+}
+
+// adapterConfig converts the configuration carried by a request to the configuration message of the adapter.
+// The configuration is either a google.protobuf.Struct, as sent by the remote adapter, or the adapter's own
+// configuration message.
+func adapterConfig(defaultConfig proto.Message, cfg *types.Any) (adapter.Config, error) {
+	out := proto.Clone(defaultConfig)
+	if cfg == nil {
+		return out, nil
+	}
+
+	var da types.DynamicAny
+	if err := types.UnmarshalAny(cfg, &da); err != nil {
+		return nil, fmt.Errorf("invalid adapter configuration: %v", err)
+	}
+
+	st, ok := da.Message.(*types.Struct)
+	if !ok {
+		if proto.MessageName(da.Message) != proto.MessageName(out) {
+			return nil, fmt.Errorf("unexpected adapter configuration type %s", proto.MessageName(da.Message))
+		}
+		return da.Message, nil
+	}
+
+	js, err := (&jsonpb.Marshaler{}).MarshalToString(st)
+	if err != nil {
+		return nil, fmt.Errorf("invalid adapter configuration: %v", err)
+	}
+	if err = jsonpb.Unmarshal(strings.NewReader(js), out); err != nil {
+		return nil, fmt.Errorf("invalid adapter configuration: %v", err)
+	}
+	return out, nil
+}
+
+// handlerKey identifies the handler for the template, adapter configuration and instance types of a request.
+func handlerKey(ti *template.Info, req *Request) [sha256.Size]byte {
+	var e encoder
+	e.string(1, ti.Name)
+	if req.AdapterConfig != nil {
+		e.string(2, req.AdapterConfig.TypeUrl)
+		e.bytes(3, req.AdapterConfig.Value)
+	}
+
+	names := make([]string, 0, len(req.InstanceTypes))
+	for name := range req.InstanceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e.string(4, name)
+		e.string(5, req.InstanceTypes[name].TypeUrl)
+		e.bytes(6, req.InstanceTypes[name].Value)
+	}
+
+	return sha256.Sum256(e.buf)
+}
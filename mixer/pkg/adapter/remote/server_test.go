@@ -0,0 +1,177 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	adptTmpl "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/test"
+	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/mixer/template/metric"
+)
+
+func TestAdapterConfig(t *testing.T) {
+	defaultConfig := &metric.InstanceParam{Value: "default"}
+
+	st := &types.Struct{Fields: map[string]*types.Value{
+		"value": {Kind: &types.Value_StringValue{StringValue: "request.size"}},
+		"dimensions": {Kind: &types.Value_StructValue{StructValue: &types.Struct{Fields: map[string]*types.Value{
+			"source": {Kind: &types.Value_StringValue{StringValue: "source.name"}},
+		}}}},
+	}}
+	stAny, err := types.MarshalAny(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	own := &metric.InstanceParam{Value: "own"}
+	ownAny, err := types.MarshalAny(own)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherAny, err := types.MarshalAny(&types.StringValue{Value: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		cfg  *types.Any
+		want proto.Message
+		err  string
+	}{
+		{"default", nil, defaultConfig, ""},
+		{"struct", stAny, &metric.InstanceParam{
+			Value:      "request.size",
+			Dimensions: map[string]string{"source": "source.name"},
+		}, ""},
+		{"own message", ownAny, own, ""},
+		{"other message", otherAny, nil, "unexpected adapter configuration type"},
+		{"unknown message", &types.Any{TypeUrl: "type.googleapis.com/foo.Bar"}, nil, "invalid adapter configuration"},
+		{"invalid struct", mustMarshalStruct(t, "unknownField"), nil, "invalid adapter configuration"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := adapterConfig(defaultConfig, c.cfg)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("adapterConfig() => got error %v, want %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("adapterConfig() => unexpected error: %v", err)
+			}
+			if !proto.Equal(got, c.want) {
+				t.Errorf("adapterConfig() => got %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	if defaultConfig.Value != "default" {
+		t.Errorf("adapterConfig() modified the default configuration: %v", defaultConfig)
+	}
+}
+
+func mustMarshalStruct(t *testing.T, field string) *types.Any {
+	a, err := types.MarshalAny(&types.Struct{Fields: map[string]*types.Value{
+		field: {Kind: &types.Value_BoolValue{BoolValue: true}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestHandlerKey(t *testing.T) {
+	a := &Request{AdapterConfig: mustMarshalAny(t, "a"), InstanceTypes: map[string]*types.Any{
+		"i1": mustMarshalAny(t, "t1"),
+		"i2": mustMarshalAny(t, "t2"),
+	}}
+	b := &Request{AdapterConfig: mustMarshalAny(t, "a"), InstanceTypes: map[string]*types.Any{
+		"i2": mustMarshalAny(t, "t2"),
+		"i1": mustMarshalAny(t, "t1"),
+	}}
+	if handlerKey(metricInfo, a) != handlerKey(metricInfo, b) {
+		t.Error("handlerKey() => got different keys for the same configuration")
+	}
+
+	c := &Request{AdapterConfig: mustMarshalAny(t, "b"), InstanceTypes: a.InstanceTypes}
+	if handlerKey(metricInfo, a) == handlerKey(metricInfo, c) {
+		t.Error("handlerKey() => got the same key for different adapter configurations")
+	}
+	if handlerKey(metricInfo, a) == handlerKey(quotaInfo, a) {
+		t.Error("handlerKey() => got the same key for different templates")
+	}
+}
+
+func TestNewServerErrors(t *testing.T) {
+	templates := map[string]template.Info{
+		metric.TemplateName: *metricInfo,
+		"apa": {Name: "apa", Variety: adptTmpl.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR,
+			NewInstance: func() interface{} { return &metric.Instance{} }},
+	}
+
+	cases := []struct {
+		name      string
+		templates []string
+		want      string
+	}{
+		{"unknown template", []string{"unknown"}, "unknown template"},
+		{"attribute generator", []string{"apa"}, "does not support out-of-process adapters"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info := adapter.Info{Name: "test", SupportedTemplates: c.templates}
+			s, err := NewServer("127.0.0.1:0", info, templates, test.NewEnv(t))
+			if err == nil {
+				_ = s.Close()
+			}
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("NewServer() => got error %v, want %q", err, c.want)
+			}
+		})
+	}
+
+	if _, err := NewServer("invalid:address:0", adapter.Info{}, templates, test.NewEnv(t)); err == nil {
+		t.Error("NewServer() => got no error for an invalid address")
+	}
+}
+
+func TestNewEnv(t *testing.T) {
+	env := NewEnv("test")
+	done := make(chan struct{})
+	env.ScheduleWork(func() {
+		defer close(done)
+		panic("recovered")
+	})
+	<-done
+
+	if !env.Logger().VerbosityLevel(0) {
+		t.Error("VerbosityLevel(0) => got false, want true")
+	}
+	if err := env.Logger().Errorf("error %d", 1); err == nil || err.Error() != "error 1" {
+		t.Errorf("Errorf() => got %v", err)
+	}
+}
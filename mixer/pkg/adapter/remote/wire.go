@@ -0,0 +1,268 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Wire types of the protobuf encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated message")
+
+// encoder appends protobuf encoded fields to a buffer.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(num int, wire int) {
+	e.varint(uint64(num)<<3 | uint64(wire))
+}
+
+func (e *encoder) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.buf = append(e.buf, tmp[:n]...)
+}
+
+// int64 encodes a varint field, even if it has the default value.
+func (e *encoder) int64(num int, v int64) {
+	e.tag(num, wireVarint)
+	e.varint(uint64(v))
+}
+
+// bool encodes a varint field, even if it has the default value.
+func (e *encoder) bool(num int, v bool) {
+	var u uint64
+	if v {
+		u = 1
+	}
+	e.tag(num, wireVarint)
+	e.varint(u)
+}
+
+// double encodes a fixed64 field, even if it has the default value.
+func (e *encoder) double(num int, v float64) {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v))
+	e.tag(num, wireFixed64)
+	e.buf = append(e.buf, tmp[:]...)
+}
+
+// bytes encodes a length delimited field, even if it is empty.
+func (e *encoder) bytes(num int, b []byte) {
+	e.tag(num, wireBytes)
+	e.varint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(num int, s string) {
+	e.tag(num, wireBytes)
+	e.varint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// message encodes an embedded message, whose fields are written by fn.
+func (e *encoder) message(num int, fn func(*encoder) error) error {
+	var m encoder
+	if err := fn(&m); err != nil {
+		return err
+	}
+	e.bytes(num, m.buf)
+	return nil
+}
+
+// timestamp encodes a google.protobuf.Timestamp message.
+func (e *encoder) timestamp(num int, t time.Time) {
+	_ = e.message(num, func(m *encoder) error {
+		m.secondsNanos(t.Unix(), int32(t.Nanosecond()))
+		return nil
+	})
+}
+
+// duration encodes a google.protobuf.Duration message.
+func (e *encoder) duration(num int, d time.Duration) {
+	_ = e.message(num, func(m *encoder) error {
+		m.secondsNanos(int64(d/time.Second), int32(d%time.Second))
+		return nil
+	})
+}
+
+func (e *encoder) secondsNanos(seconds int64, nanos int32) {
+	if seconds != 0 {
+		e.int64(1, seconds)
+	}
+	if nanos != 0 {
+		e.int64(2, int64(nanos))
+	}
+}
+
+// decoder reads protobuf encoded fields from a buffer.
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) done() bool {
+	return len(d.buf) == 0
+}
+
+func (d *decoder) tag() (num int, wire int, err error) {
+	var v uint64
+	if v, err = d.varint(); err != nil {
+		return 0, 0, err
+	}
+	num, wire = int(v>>3), int(v&0x7)
+	if num <= 0 {
+		return 0, 0, fmt.Errorf("illegal field number %d", num)
+	}
+	return num, wire, nil
+}
+
+func (d *decoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *decoder) fixed64() (uint64, error) {
+	if len(d.buf) < 8 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(d.buf)
+	d.buf = d.buf[8:]
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	l, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.buf)) < l {
+		return nil, errTruncated
+	}
+	b := d.buf[:l]
+	d.buf = d.buf[l:]
+	return b, nil
+}
+
+// skip skips the value of a field with an unknown field number.
+func (d *decoder) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = d.varint()
+	case wireFixed64:
+		_, err = d.fixed64()
+	case wireBytes:
+		_, err = d.bytes()
+	case wireFixed32:
+		if len(d.buf) < 4 {
+			return errTruncated
+		}
+		d.buf = d.buf[4:]
+	default:
+		err = fmt.Errorf("unsupported wire type %d", wire)
+	}
+	return err
+}
+
+// readVarint reads the value of a varint field.
+func (d *decoder) readVarint(wire int) (uint64, error) {
+	if wire != wireVarint {
+		return 0, fmt.Errorf("unexpected wire type %d for a varint field", wire)
+	}
+	return d.varint()
+}
+
+// readDouble reads the value of a double field.
+func (d *decoder) readDouble(wire int) (float64, error) {
+	if wire != wireFixed64 {
+		return 0, fmt.Errorf("unexpected wire type %d for a double field", wire)
+	}
+	v, err := d.fixed64()
+	return math.Float64frombits(v), err
+}
+
+// readBytes reads the value of a length delimited field.
+func (d *decoder) readBytes(wire int) ([]byte, error) {
+	if wire != wireBytes {
+		return nil, fmt.Errorf("unexpected wire type %d for a length delimited field", wire)
+	}
+	return d.bytes()
+}
+
+// fields calls fn for each field of the message in b. fn must consume the value of the field, or return
+// false to skip it.
+func fields(b []byte, fn func(d *decoder, num int, wire int) (bool, error)) error {
+	d := &decoder{buf: b}
+	for !d.done() {
+		num, wire, err := d.tag()
+		if err != nil {
+			return err
+		}
+		consumed, err := fn(d, num, wire)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			if err = d.skip(wire); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeSecondsNanos decodes a google.protobuf.Timestamp or google.protobuf.Duration message.
+func decodeSecondsNanos(b []byte) (seconds int64, nanos int32, err error) {
+	err = fields(b, func(d *decoder, num int, wire int) (bool, error) {
+		switch num {
+		case 1:
+			v, err := d.readVarint(wire)
+			seconds = int64(v)
+			return true, err
+		case 2:
+			v, err := d.readVarint(wire)
+			nanos = int32(v)
+			return true, err
+		}
+		return false, nil
+	})
+	return seconds, nanos, err
+}
+
+func decodeTimestamp(b []byte) (time.Time, error) {
+	s, n, err := decodeSecondsNanos(b)
+	return time.Unix(s, int64(n)).UTC(), err
+}
+
+func decodeDuration(b []byte) (time.Duration, error) {
+	s, n, err := decodeSecondsNanos(b)
+	return time.Duration(s)*time.Second + time.Duration(n), err
+}
@@ -40,3 +40,21 @@ func TestResult_Combine(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckResult_LimitCacheability(t *testing.T) {
+	for _, c := range []struct {
+		limits CacheabilityInfo
+		ans    CheckResult
+	}{
+		{CacheabilityInfo{}, CheckResult{ValidUseCount: 10, ValidDuration: time.Hour}},
+		{CacheabilityInfo{ValidDuration: time.Minute}, CheckResult{ValidUseCount: 10, ValidDuration: time.Minute}},
+		{CacheabilityInfo{ValidUseCount: 5}, CheckResult{ValidUseCount: 5, ValidDuration: time.Hour}},
+		{CacheabilityInfo{ValidUseCount: 50, ValidDuration: 2 * time.Hour}, CheckResult{ValidUseCount: 10, ValidDuration: time.Hour}},
+	} {
+		r := CheckResult{ValidUseCount: 10, ValidDuration: time.Hour}
+		r.LimitCacheability(c.limits)
+		if !reflect.DeepEqual(r, c.ans) {
+			t.Errorf("LimitCacheability(%v) => got %v, want %v", c.limits, r, c.ans)
+		}
+	}
+}
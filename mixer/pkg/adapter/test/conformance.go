@@ -0,0 +1,478 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	adptTmpl "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/template"
+	generatedTmplRepo "istio.io/istio/mixer/template"
+)
+
+const (
+	defaultConcurrency = 10
+
+	// the time allowed for a handler call with a cancelled context to return, and for the scheduled work, daemons and
+	// goroutines of a handler to stop after it is closed.
+	conformanceTimeout = 5 * time.Second
+)
+
+var adapterNameRegex = regexp.MustCompile("^[a-z]([-a-z0-9]*[a-z0-9])?$")
+
+// remoteBuilder and remoteHandler mirror remote.Builder and remote.Handler of the
+// istio.io/istio/mixer/pkg/adapter/remote package, whose tests depend on this package. They are implemented by the
+// adapters that accept instances of any template, and list no supported templates.
+type (
+	remoteBuilder interface {
+		adapter.HandlerBuilder
+		SetInstanceTypes(template string, types map[string]proto.Message)
+	}
+
+	remoteHandler interface {
+		adapter.Handler
+		HandleRemoteCheck(ctx context.Context, ti *template.Info, instance interface{}) (adapter.CheckResult, error)
+		HandleRemoteReport(ctx context.Context, ti *template.Info, instances []interface{}) error
+		HandleRemoteQuota(ctx context.Context, ti *template.Info, instance interface{},
+			args adapter.QuotaArgs) (adapter.QuotaResult, error)
+	}
+)
+
+// Conformance describes an adapter along with samples of its configuration and instances. RunConformance uses it
+// to check that the adapter honours the contracts of adapter.HandlerBuilder and adapter.Handler.
+type Conformance struct {
+	// Info of the adapter.
+	Info adapter.Info
+
+	// Config is a valid adapter configuration. If nil, the default configuration of the adapter is used.
+	Config adapter.Config
+
+	// InvalidConfigs are adapter configurations that Validate must reject.
+	InvalidConfigs []adapter.Config
+
+	// Types are the inferred types of the instances, by template and instance name. They are passed to the builder
+	// before it is validated.
+	Types map[string]map[string]proto.Message
+
+	// Instances are sample instances, by template. They are dispatched to the handler concurrently, and must not
+	// fail.
+	Instances map[string][]interface{}
+
+	// RequestData is carried by the contexts of the dispatches, for the adapters that depend on it. Optional.
+	RequestData *adapter.RequestData
+
+	// Concurrency is the number of goroutines that dispatch the instances concurrently. Defaults to 10.
+	Concurrency int
+
+	// Templates are the templates of the instances. Defaults to the templates that are compiled into Mixer.
+	Templates map[string]template.Info
+}
+
+// RunConformance checks that an adapter honours the contracts of adapter.HandlerBuilder and adapter.Handler:
+//
+//   - the adapter info is complete, and the builder supports the templates of the adapter, or any template if it
+//     implements remote.Builder,
+//   - Validate rejects the invalid configurations, and accepts the valid one,
+//   - Validate can be called on its own, and before Build: it starts no goroutines, and gives the same result when
+//     it is called again,
+//   - the handler supports the templates of the adapter, or any template if it implements remote.Handler, and
+//     handles the sample instances concurrently,
+//   - the handler returns promptly when called with a cancelled context,
+//   - the work and daemons the handler schedules through the environment stop once it is closed,
+//   - the goroutines the handler starts, directly or through the environment, stop once it is closed,
+//   - Close can be called more than once.
+func RunConformance(t *testing.T, c Conformance) {
+	if c.Config == nil {
+		c.Config = c.Info.DefaultConfig
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+	if c.Templates == nil {
+		c.Templates = generatedTmplRepo.SupportedTmplInfo
+	}
+
+	t.Run("Info", func(t *testing.T) {
+		checkInfo(t, c)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		for i, cfg := range c.InvalidConfigs {
+			if ce := newBuilder(c, cfg).Validate(); ce == nil {
+				t.Errorf("Validate() => got no error for invalid config #%d: %v", i, cfg)
+			}
+		}
+	})
+
+	t.Run("ValidateBeforeBuild", func(t *testing.T) {
+		checkValidateBeforeBuild(t, c)
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		h, env := build(t, c)
+		if _, ok := h.(remoteHandler); !ok && isRemote(c) {
+			t.Errorf("handler does not implement remote.Handler")
+		}
+		for _, name := range c.Info.SupportedTemplates {
+			if ti, found := c.Templates[name]; found && !ti.HandlerSupportsTemplate(h) {
+				t.Errorf("handler does not implement %s", ti.HndlrInterfaceName)
+			}
+		}
+		closeHandler(t, h, env)
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		h, env := build(t, c)
+		defer closeHandler(t, h, env)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, c.Concurrency)
+		for i := 0; i < c.Concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := dispatchAll(context.Background(), c, h); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("dispatch => %v", err)
+		}
+	})
+
+	t.Run("Leaks", func(t *testing.T) {
+		// The first cycle starts the goroutines that are shared by all the handlers of the adapter, if any.
+		cycle(t, c)
+		baseline := runtime.NumGoroutine()
+
+		cycle(t, c)
+		checkGoroutines(t, baseline, "the handler was closed")
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		h, env := build(t, c)
+		defer closeHandler(t, h, env)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		done := make(chan struct{})
+		go func() {
+			// The handler may fail the calls, as long as it returns.
+			_ = dispatchAll(ctx, c, h)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(conformanceTimeout):
+			t.Errorf("handler did not return within %v when called with a cancelled context", conformanceTimeout)
+		}
+	})
+}
+
+func checkInfo(t *testing.T, c Conformance) {
+	info := c.Info
+	if !adapterNameRegex.MatchString(info.Name) {
+		t.Errorf("adapter name '%s' is not a valid DNS label", info.Name)
+	}
+	if info.Impl == "" || info.Description == "" {
+		t.Errorf("adapter %s has no implementation or description", info.Name)
+	}
+	if info.NewBuilder == nil {
+		t.Fatalf("adapter %s has no builder", info.Name)
+	}
+	if info.DefaultConfig == nil {
+		t.Errorf("adapter %s has no default config", info.Name)
+	}
+	if len(info.SupportedTemplates) == 0 && !isRemote(c) {
+		t.Errorf("adapter %s supports no template", info.Name)
+	}
+
+	b := info.NewBuilder()
+	for _, name := range info.SupportedTemplates {
+		ti, found := c.Templates[name]
+		if !found {
+			t.Errorf("adapter %s supports the unknown template %s", info.Name, name)
+			continue
+		}
+		if !ti.BuilderSupportsTemplate(b) {
+			t.Errorf("builder of adapter %s does not implement %s", info.Name, ti.BldrInterfaceName)
+		}
+	}
+	for name := range c.Instances {
+		if !contains(info.SupportedTemplates, name) && !isRemote(c) {
+			t.Errorf("adapter %s does not support the template %s of the sample instances", info.Name, name)
+		}
+	}
+}
+
+// checkValidateBeforeBuild checks that the builder can be validated without being built, as Mixer does when it
+// validates config changes, and that Build relies on nothing but a prior successful Validate.
+func checkValidateBeforeBuild(t *testing.T, c Conformance) {
+	configs := append([]adapter.Config{c.Config}, c.InvalidConfigs...)
+
+	// Warm up the packages of the adapter, which may start goroutines of their own on first use.
+	for _, cfg := range configs {
+		_ = newBuilder(c, cfg).Validate()
+	}
+	baseline := runtime.NumGoroutine()
+
+	for i, cfg := range configs {
+		b := newBuilder(c, cfg)
+		first := b.Validate() == nil
+		if second := b.Validate() == nil; first != second {
+			t.Errorf("Validate() => got different results when called twice for config #%d: %v", i, cfg)
+		}
+	}
+	checkGoroutines(t, baseline, "the builders were validated")
+
+	// A builder that was validated more than once still builds.
+	b := newBuilder(c, c.Config)
+	for i := 0; i < 2; i++ {
+		if ce := b.Validate(); ce != nil {
+			t.Fatalf("Validate() => unexpected error: %v", ce)
+		}
+	}
+	env := &trackingEnv{Env: NewEnv(t)}
+	h, err := b.Build(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Build() => unexpected error after Validate: %v", err)
+	}
+	closeHandler(t, h, env)
+}
+
+// cycle builds a handler, dispatches the sample instances to it, and closes it.
+func cycle(t *testing.T, c Conformance) {
+	h, env := build(t, c)
+	// Dispatch errors are checked by the concurrency test.
+	_ = dispatchAll(context.Background(), c, h)
+	closeHandler(t, h, env)
+}
+
+// checkGoroutines waits up to the conformance timeout for the number of goroutines to drop to the given baseline,
+// and reports the goroutines that are still running otherwise.
+func checkGoroutines(t *testing.T, baseline int, after string) {
+	if n := waitGoroutines(baseline, conformanceTimeout); n > baseline {
+		var b bytes.Buffer
+		_ = pprof.Lookup("goroutine").WriteTo(&b, 1)
+		t.Errorf("%d goroutines still running %v after %s, instead of %d:\n%s", n, conformanceTimeout, after,
+			baseline, b.String())
+	}
+}
+
+// waitGoroutines waits up to the given timeout for the number of goroutines to drop to the given baseline, and
+// returns the number of goroutines that are running.
+func waitGoroutines(baseline int, timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= baseline || time.Now().After(deadline) {
+			return n
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newBuilder returns a builder of the adapter that is configured like Mixer does: the types of the instances are set
+// first, then the adapter configuration.
+func newBuilder(c Conformance, cfg adapter.Config) adapter.HandlerBuilder {
+	b := c.Info.NewBuilder()
+	for name, types := range c.Types {
+		if rb, ok := b.(remoteBuilder); ok {
+			rb.SetInstanceTypes(name, types)
+		} else if ti, found := c.Templates[name]; found && ti.SetType != nil {
+			ti.SetType(types, b)
+		}
+	}
+	b.SetAdapterConfig(cfg)
+	return b
+}
+
+// build validates and builds a handler with the valid configuration, in a new environment.
+func build(t *testing.T, c Conformance) (adapter.Handler, *trackingEnv) {
+	b := newBuilder(c, c.Config)
+	if ce := b.Validate(); ce != nil {
+		t.Fatalf("Validate() => unexpected error: %v", ce)
+	}
+
+	env := &trackingEnv{Env: NewEnv(t)}
+	h, err := b.Build(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Build() => unexpected error: %v", err)
+	}
+	if h == nil {
+		t.Fatal("Build() => got a nil handler")
+	}
+	return h, env
+}
+
+// closeHandler closes the handler twice, and checks that the work and daemons it scheduled have stopped.
+func closeHandler(t *testing.T, h adapter.Handler, env *trackingEnv) {
+	if err := h.Close(); err != nil {
+		t.Errorf("Close() => unexpected error: %v", err)
+	}
+	if err := safely(h.Close); err != nil {
+		t.Errorf("Close() => unexpected error when closing again: %v", err)
+	}
+	if n := env.wait(conformanceTimeout); n > 0 {
+		t.Errorf("%d scheduled work or daemons still running %v after the handler was closed", n, conformanceTimeout)
+	}
+}
+
+// dispatchAll dispatches all sample instances to the handler, and returns the first error.
+func dispatchAll(ctx context.Context, c Conformance, h adapter.Handler) error {
+	if c.RequestData != nil {
+		ctx = adapter.NewContextWithRequestData(ctx, c.RequestData)
+	}
+	for name, instances := range c.Instances {
+		ti, found := c.Templates[name]
+		if !found {
+			return fmt.Errorf("unknown template %s", name)
+		}
+		for _, instance := range instances {
+			if err := safely(func() error { return dispatch(ctx, ti, h, instance) }); err != nil {
+				return fmt.Errorf("%s instance %v: %v", name, instance, err)
+			}
+		}
+	}
+	return nil
+}
+
+func dispatch(ctx context.Context, ti template.Info, h adapter.Handler, instance interface{}) error {
+	if rh, ok := h.(remoteHandler); ok {
+		return dispatchRemote(ctx, ti, rh, instance)
+	}
+
+	switch ti.Variety {
+	case adptTmpl.TEMPLATE_VARIETY_CHECK:
+		_, err := ti.DispatchCheck(ctx, h, instance)
+		return err
+	case adptTmpl.TEMPLATE_VARIETY_REPORT:
+		return ti.DispatchReport(ctx, h, []interface{}{instance})
+	case adptTmpl.TEMPLATE_VARIETY_QUOTA:
+		_, err := ti.DispatchQuota(ctx, h, instance, adapter.QuotaArgs{QuotaAmount: 1, BestEffort: true})
+		return err
+	case adptTmpl.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR:
+		// The generated attributes are not checked, so no output is mapped back to the request attributes.
+		attrs := attribute.GetMutableBag(nil)
+		defer attrs.Done()
+		out, err := ti.DispatchGenAttrs(ctx, h, instance, attrs, func(attribute.Bag) (*attribute.MutableBag, error) {
+			return attribute.GetMutableBag(nil), nil
+		})
+		if out != nil {
+			out.Done()
+		}
+		return err
+	default:
+		return fmt.Errorf("instances of %v templates are not supported", ti.Variety)
+	}
+}
+
+func dispatchRemote(ctx context.Context, ti template.Info, h remoteHandler, instance interface{}) error {
+	switch ti.Variety {
+	case adptTmpl.TEMPLATE_VARIETY_CHECK:
+		_, err := h.HandleRemoteCheck(ctx, &ti, instance)
+		return err
+	case adptTmpl.TEMPLATE_VARIETY_REPORT:
+		return h.HandleRemoteReport(ctx, &ti, []interface{}{instance})
+	case adptTmpl.TEMPLATE_VARIETY_QUOTA:
+		_, err := h.HandleRemoteQuota(ctx, &ti, instance, adapter.QuotaArgs{QuotaAmount: 1, BestEffort: true})
+		return err
+	default:
+		return fmt.Errorf("instances of %v templates are not supported", ti.Variety)
+	}
+}
+
+// isRemote returns whether the builder of the adapter accepts instances of any template.
+func isRemote(c Conformance) bool {
+	_, ok := c.Info.NewBuilder().(remoteBuilder)
+	return ok
+}
+
+// safely calls fn, and returns the panic it raises as an error.
+func safely(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// trackingEnv is an adapter environment that keeps track of the scheduled work and daemons that are running.
+type trackingEnv struct {
+	*Env
+
+	// the number of scheduled functions that are running, accessed atomically.
+	running int64
+}
+
+// ScheduleWork runs the given function asynchronously.
+func (e *trackingEnv) ScheduleWork(fn adapter.WorkFunc) {
+	e.schedule(fn)
+}
+
+// ScheduleDaemon runs the given function asynchronously.
+func (e *trackingEnv) ScheduleDaemon(fn adapter.DaemonFunc) {
+	e.schedule(fn)
+}
+
+func (e *trackingEnv) schedule(fn func()) {
+	atomic.AddInt64(&e.running, 1)
+	go func() {
+		defer atomic.AddInt64(&e.running, -1)
+		fn()
+	}()
+}
+
+// wait waits up to the given timeout for the scheduled functions to return, and returns the number of the ones that
+// are still running.
+func (e *trackingEnv) wait(timeout time.Duration) int64 {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := atomic.LoadInt64(&e.running)
+		if n == 0 || time.Now().After(deadline) {
+			return n
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
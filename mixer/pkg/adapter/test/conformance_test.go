@@ -0,0 +1,149 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/status"
+	"istio.io/istio/mixer/template/checknothing"
+)
+
+// fakeBuilder builds handlers of the checknothing template, which deny the instances named in the config.
+type fakeBuilder struct {
+	cfg   *types.StringValue
+	types map[string]*checknothing.Type
+}
+
+func (b *fakeBuilder) SetCheckNothingTypes(t map[string]*checknothing.Type) { b.types = t }
+func (b *fakeBuilder) SetAdapterConfig(cfg adapter.Config)                  { b.cfg = cfg.(*types.StringValue) }
+
+func (b *fakeBuilder) Validate() (ce *adapter.ConfigErrors) {
+	if b.cfg.Value != "" && b.types[b.cfg.Value] == nil {
+		ce = ce.Appendf("value", "unknown instance %s", b.cfg.Value)
+	}
+	return
+}
+
+func (b *fakeBuilder) Build(_ context.Context, env adapter.Env) (adapter.Handler, error) {
+	h := &fakeHandler{deny: b.cfg.Value, done: make(chan struct{})}
+	env.ScheduleDaemon(func() {
+		<-h.done
+	})
+	return h, nil
+}
+
+type fakeHandler struct {
+	deny string
+
+	once sync.Once
+	done chan struct{}
+}
+
+func (h *fakeHandler) HandleCheckNothing(ctx context.Context, inst *checknothing.Instance) (adapter.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return adapter.CheckResult{}, err
+	}
+	if inst.Name == h.deny {
+		return adapter.CheckResult{Status: status.WithPermissionDenied("denied")}, nil
+	}
+	return adapter.CheckResult{Status: status.OK}, nil
+}
+
+func (h *fakeHandler) Close() error {
+	h.once.Do(func() { close(h.done) })
+	return nil
+}
+
+func fakeInfo() adapter.Info {
+	return adapter.Info{
+		Name:               "fake",
+		Impl:               "istio.io/istio/mixer/pkg/adapter/test",
+		Description:        "Denies the configured checknothing instance",
+		SupportedTemplates: []string{checknothing.TemplateName},
+		NewBuilder:         func() adapter.HandlerBuilder { return &fakeBuilder{} },
+		DefaultConfig:      &types.StringValue{},
+	}
+}
+
+func TestRunConformance(t *testing.T) {
+	RunConformance(t, Conformance{
+		Info:           fakeInfo(),
+		Config:         &types.StringValue{Value: "denied"},
+		InvalidConfigs: []adapter.Config{&types.StringValue{Value: "unknown"}},
+		Types: map[string]map[string]proto.Message{
+			checknothing.TemplateName: {"allowed": &checknothing.Type{}, "denied": &checknothing.Type{}},
+		},
+		Instances: map[string][]interface{}{
+			checknothing.TemplateName: {&checknothing.Instance{Name: "allowed"}, &checknothing.Instance{Name: "denied"}},
+		},
+	})
+}
+
+func TestTrackingEnv(t *testing.T) {
+	env := &trackingEnv{Env: NewEnv(t)}
+
+	stop := make(chan struct{})
+	env.ScheduleDaemon(func() { <-stop })
+	env.ScheduleWork(func() {})
+
+	if n := env.wait(50 * time.Millisecond); n != 1 {
+		t.Fatalf("wait() => got %d running, want 1", n)
+	}
+	close(stop)
+	if n := env.wait(time.Second); n != 0 {
+		t.Fatalf("wait() => got %d running, want 0", n)
+	}
+}
+
+func TestWaitGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		<-stop
+		close(done)
+	}()
+
+	if n := waitGoroutines(baseline, 50*time.Millisecond); n != baseline+1 {
+		t.Fatalf("waitGoroutines() => got %d running, want %d", n, baseline+1)
+	}
+	close(stop)
+	<-done
+	if n := waitGoroutines(baseline, time.Second); n > baseline {
+		t.Fatalf("waitGoroutines() => got %d running, want %d", n, baseline)
+	}
+}
+
+func TestSafely(t *testing.T) {
+	if err := safely(func() error { panic("boom") }); err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("safely() => got %v, want the panic as an error", err)
+	}
+	want := errors.New("failed")
+	if err := safely(func() error { return want }); err != want {
+		t.Errorf("safely() => got %v, want %v", err, want)
+	}
+}
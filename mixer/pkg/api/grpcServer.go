@@ -29,6 +29,7 @@ import (
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/capture"
 	"istio.io/istio/mixer/pkg/pool"
 	"istio.io/istio/mixer/pkg/runtime"
 	"istio.io/istio/mixer/pkg/status"
@@ -49,6 +50,9 @@ type (
 		// the global dictionary. This will eventually be writable via config
 		globalWordList []string
 		globalDict     map[string]int32
+
+		// recorder for capturing a sample of the incoming requests. Nil if capturing is disabled.
+		recorder *capture.Recorder
 	}
 )
 
@@ -66,6 +70,13 @@ var checkOk = &adapter.CheckResult{
 
 // NewGRPCServer creates a gRPC serving stack.
 func NewGRPCServer(dispatcher runtime.Dispatcher, gp *pool.GoroutinePool) mixerpb.MixerServer {
+	return NewGRPCServerWithRecorder(dispatcher, gp, nil)
+}
+
+// NewGRPCServerWithRecorder creates a gRPC serving stack that captures a sample of the incoming requests
+// using the given recorder. If the recorder is nil, requests are not captured.
+func NewGRPCServerWithRecorder(dispatcher runtime.Dispatcher, gp *pool.GoroutinePool,
+	recorder *capture.Recorder) mixerpb.MixerServer {
 	list := attribute.GlobalList()
 	globalDict := make(map[string]int32, len(list))
 	for i := 0; i < len(list); i++ {
@@ -77,6 +88,7 @@ func NewGRPCServer(dispatcher runtime.Dispatcher, gp *pool.GoroutinePool) mixerp
 		gp:             gp,
 		globalWordList: list,
 		globalDict:     globalDict,
+		recorder:       recorder,
 	}
 }
 
@@ -114,6 +126,14 @@ func (c *compatBag) Done() {
 	c.parent.Done()
 }
 
+// SetTracking enables or disables the tracking of the references made through the parent bag.
+func (c *compatBag) SetTracking(enabled bool) bool {
+	if t, ok := c.parent.(attribute.ReferenceTracker); ok {
+		return t.SetTracking(enabled)
+	}
+	return false
+}
+
 // Check is the entry point for the external Check method
 func (s *grpcServer) Check(legacyCtx legacyContext.Context, req *mixerpb.CheckRequest) (*mixerpb.CheckResponse, error) {
 	// TODO: this code doesn't distinguish between RPC failures when communicating with adapters and
@@ -147,6 +167,9 @@ func (s *grpcServer) Check(legacyCtx legacyContext.Context, req *mixerpb.CheckRe
 		log.Errora("Preprocess Check returned with: ", status.String(out))
 		requestBag.Done()
 		preprocResponseBag.Done()
+		if s.recorder.Sample() {
+			s.captureCheck(req, &capture.Result{Code: out.Code, Message: out.Message})
+		}
 		return nil, makeGRPCError(out)
 	}
 
@@ -228,9 +251,74 @@ func (s *grpcServer) Check(legacyCtx legacyContext.Context, req *mixerpb.CheckRe
 	requestBag.Done()
 	preprocResponseBag.Done()
 
+	if s.recorder.Sample() {
+		result := &capture.Result{
+			Code:    resp.Precondition.Status.Code,
+			Message: resp.Precondition.Status.Message,
+		}
+		if len(resp.Quotas) > 0 {
+			result.Quotas = make(map[string]int64, len(resp.Quotas))
+			for name, qr := range resp.Quotas {
+				result.Quotas[name] = qr.GrantedAmount
+			}
+		}
+		s.captureCheck(req, result)
+	}
+
 	return resp, nil
 }
 
+// captureCheck records the given check request and its result.
+func (s *grpcServer) captureCheck(req *mixerpb.CheckRequest, result *capture.Result) {
+	attrs, err := capture.FromProto([]mixerpb.CompressedAttributes{req.Attributes}, s.globalWordList)
+	if err != nil {
+		log.Warnf("Unable to decode attributes for capture: %v", err)
+		return
+	}
+
+	rec := &capture.Record{
+		Kind:       capture.Check,
+		Time:       time.Now(),
+		Attributes: attrs,
+		Result:     result,
+	}
+
+	if len(req.Quotas) > 0 {
+		rec.Quotas = make(map[string]capture.QuotaParams, len(req.Quotas))
+		for name, param := range req.Quotas {
+			rec.Quotas[name] = capture.QuotaParams{Amount: param.Amount, BestEffort: param.BestEffort}
+		}
+	}
+
+	s.recorder.Record(rec)
+}
+
+// captureReport records the given report request and its result.
+func (s *grpcServer) captureReport(req *mixerpb.ReportRequest, reportErr error) {
+	attrs, err := capture.FromProto(req.Attributes, s.globalWordList)
+	if err != nil {
+		log.Warnf("Unable to decode attributes for capture: %v", err)
+		return
+	}
+
+	result := &capture.Result{}
+	if reportErr != nil {
+		result.Code = int32(rpc.UNKNOWN)
+		result.Message = reportErr.Error()
+		if st, ok := grpc.FromError(reportErr); ok {
+			result.Code = int32(st.Code())
+			result.Message = st.Message()
+		}
+	}
+
+	s.recorder.Record(&capture.Record{
+		Kind:       capture.Report,
+		Time:       time.Now(),
+		Attributes: attrs,
+		Result:     result,
+	})
+}
+
 func quota(legacyCtx legacyContext.Context, d runtime.Dispatcher, bag attribute.Bag,
 	qma *runtime.QuotaMethodArgs) (*mixerpb.CheckResponse_QuotaResult, error) {
 	if d == nil {
@@ -277,6 +365,14 @@ func (s *grpcServer) Report(legacyCtx legacyContext.Context, req *mixerpb.Report
 	compatReqBag := &compatBag{requestBag}
 	mutableBag := attribute.GetMutableBag(requestBag)
 	preprocResponseBag := attribute.GetMutableBag(nil)
+
+	// If the dispatcher supports it, accumulate the instances of all the attribute bags, and dispatch them
+	// in batches.
+	var reporter runtime.Reporter
+	if bd, ok := s.dispatcher.(runtime.BatchDispatcher); ok {
+		reporter = bd.GetReporter(legacyCtx)
+	}
+
 	var err error
 	for i := 0; i < len(req.Attributes); i++ {
 		span, newctx := opentracing.StartSpanFromContext(legacyCtx, fmt.Sprintf("Attributes %d", i))
@@ -318,7 +414,11 @@ func (s *grpcServer) Report(legacyCtx legacyContext.Context, req *mixerpb.Report
 			log.Debuga("Attribute Bag: \n", mutableBag.DebugString())
 			log.Debugf("Dispatching Report %d out of %d", i, len(req.Attributes))
 		}
-		err = s.dispatcher.Report(legacyCtx, compatRespBag)
+		if reporter != nil {
+			err = reporter.Report(compatRespBag)
+		} else {
+			err = s.dispatcher.Report(legacyCtx, compatRespBag)
+		}
 		if err != nil {
 			out = status.WithError(err)
 			log.Warnf("Report returned %v", err)
@@ -341,10 +441,26 @@ func (s *grpcServer) Report(legacyCtx legacyContext.Context, req *mixerpb.Report
 		preprocResponseBag.Reset()
 	}
 
+	if reporter != nil {
+		if err == nil {
+			log.Debug("Flushing Report instances")
+			if err = reporter.Flush(); err != nil {
+				out := status.WithError(err)
+				log.Errorf("Report flush returned with: %s", status.String(out))
+				err = makeGRPCError(out)
+			}
+		}
+		reporter.Done()
+	}
+
 	preprocResponseBag.Done()
 	requestBag.Done()
 	protoBag.Done()
 
+	if s.recorder.Sample() {
+		s.captureReport(req, err)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -18,7 +18,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path"
 	"strings"
 	"testing"
 
@@ -28,6 +31,7 @@ import (
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/capture"
 	"istio.io/istio/mixer/pkg/pool"
 	"istio.io/istio/mixer/pkg/runtime"
 	"istio.io/istio/mixer/pkg/status"
@@ -446,3 +450,164 @@ func init() {
 	_ = o.SetOutputLevel(log.DebugLevel)
 	_ = log.Configure(o)
 }
+
+func TestCapture(t *testing.T) {
+	ts, err := prepTestState()
+	if err != nil {
+		t.Fatalf("Unable to prep test state: %v", err)
+	}
+	defer ts.cleanupTestState()
+
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	file := path.Join(dir, "capture.json")
+	if ts.s.recorder, err = capture.NewRecorder(file, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ts.check = func(ctx context.Context, requestBag attribute.Bag) (*adapter.CheckResult, error) {
+		return &adapter.CheckResult{Status: status.OK}, nil
+	}
+	ts.quota = func(ctx context.Context, requestBag attribute.Bag, qma *runtime.QuotaMethodArgs) (*adapter.QuotaResult, error) {
+		return &adapter.QuotaResult{Amount: 42}, nil
+	}
+	ts.report = func(ctx context.Context, requestBag attribute.Bag) error {
+		return nil
+	}
+
+	attr0 := mixerpb.CompressedAttributes{
+		Words:   []string{"A1", "A2"},
+		Int64S:  map[int32]int64{-1: 25},
+		Strings: map[int32]int32{-2: -1},
+	}
+	attr1 := mixerpb.CompressedAttributes{
+		Words:  []string{"A1"},
+		Int64S: map[int32]int64{-1: 26},
+	}
+
+	request := mixerpb.CheckRequest{Attributes: attr0}
+	request.Quotas = map[string]mixerpb.CheckRequest_QuotaParams{"RequestCount": {Amount: 42}}
+	if _, err = ts.client.Check(context.Background(), &request); err != nil {
+		t.Fatalf("Got %v, expected success", err)
+	}
+
+	report := mixerpb.ReportRequest{Attributes: []mixerpb.CompressedAttributes{attr0, attr1}}
+	if _, err = ts.client.Report(context.Background(), &report); err != nil {
+		t.Fatalf("Got %v, expected success", err)
+	}
+
+	if err = ts.s.recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := capture.ReadRecords(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Got %d records, expected 2", len(records))
+	}
+
+	chk := records[0]
+	if chk.Kind != capture.Check || chk.Quotas["RequestCount"].Amount != 42 || chk.Result.Quotas["RequestCount"] != 42 {
+		t.Errorf("Unexpected check record: %+v", chk)
+	}
+	if chk.Attributes[0]["A1"].V != int64(25) || chk.Attributes[0]["A2"].V != "A1" {
+		t.Errorf("Unexpected check attributes: %v", chk.Attributes)
+	}
+
+	rep := records[1]
+	if rep.Kind != capture.Report || len(rep.Attributes) != 2 {
+		t.Fatalf("Unexpected report record: %+v", rep)
+	}
+	// The second set should contain the resolved value of A2 from the first set, along with the updated A1.
+	if rep.Attributes[1]["A1"].V != int64(26) || rep.Attributes[1]["A2"].V != "A1" {
+		t.Errorf("Unexpected report attributes: %v", rep.Attributes)
+	}
+}
+
+// batchTestState is a dispatcher that supports batched dispatch of Report instances.
+type batchTestState struct {
+	*testState
+	reporter *testReporter
+}
+
+func (bs *batchTestState) GetReporter(ctx context.Context) runtime.Reporter {
+	return bs.reporter
+}
+
+type testReporter struct {
+	reported []int64
+	flushes  int
+	flushErr error
+	done     bool
+}
+
+func (r *testReporter) Report(bag attribute.Bag) error {
+	v, _ := bag.Get("A1")
+	r.reported = append(r.reported, v.(int64))
+	return nil
+}
+
+func (r *testReporter) Flush() error {
+	r.flushes++
+	return r.flushErr
+}
+
+func (r *testReporter) Done() {
+	r.done = true
+}
+
+func TestBatchReport(t *testing.T) {
+	ts, err := prepTestState()
+	if err != nil {
+		t.Fatalf("Unable to prep test state: %v", err)
+	}
+	defer ts.cleanupTestState()
+
+	ts.report = func(ctx context.Context, requestBag attribute.Bag) error {
+		t.Error("Report should be dispatched through the Reporter")
+		return nil
+	}
+
+	r := &testReporter{}
+	s := NewGRPCServer(&batchTestState{testState: ts, reporter: r}, ts.gp)
+
+	attr0 := mixerpb.CompressedAttributes{
+		Words:  []string{"A1"},
+		Int64S: map[int32]int64{-1: 25},
+	}
+	attr1 := mixerpb.CompressedAttributes{
+		Words:  []string{"A1"},
+		Int64S: map[int32]int64{-1: 26},
+	}
+	request := mixerpb.ReportRequest{Attributes: []mixerpb.CompressedAttributes{attr0, attr1}}
+
+	if _, err = s.Report(context.Background(), &request); err != nil {
+		t.Fatalf("Got %v, expected success", err)
+	}
+
+	if len(r.reported) != 2 || r.reported[0] != 25 || r.reported[1] != 26 {
+		t.Errorf("Got %v, expected [25 26]", r.reported)
+	}
+	if r.flushes != 1 || !r.done {
+		t.Errorf("Got %d flushes (done: %v), expected a single flush", r.flushes, r.done)
+	}
+
+	r.flushErr = errors.New("flush failed")
+	if _, err = s.Report(context.Background(), &request); err == nil {
+		t.Error("Got success, expected failure")
+	} else if !strings.Contains(err.Error(), "flush failed") {
+		t.Errorf("Got '%s', expected 'flush failed'", err.Error())
+	}
+}
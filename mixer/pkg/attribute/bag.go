@@ -29,3 +29,10 @@ type Bag interface {
 	// calculation of referenced attributes.
 	DebugString() string
 }
+
+// ReferenceTracker is implemented by bags that keep track of the attributes that are referenced through them.
+type ReferenceTracker interface {
+	// SetTracking enables or disables the tracking of references, and returns whether tracking was enabled. Lookups
+	// made while tracking is disabled are not recorded as references.
+	SetTracking(enabled bool) bool
+}
@@ -694,6 +694,38 @@ func TestReferenceTracking(t *testing.T) {
 	}
 }
 
+func TestReferenceTracking_SetTracking(t *testing.T) {
+	attrs := mixerpb.CompressedAttributes{
+		Words:   []string{"N1", "N2"},
+		Strings: map[int32]int32{-1: -1, -2: -1},
+	}
+
+	pb := NewProtoBag(&attrs, nil, nil)
+	mb := GetMutableBag(pb)
+	mb.Set("M1", "V1")
+
+	if previous := mb.SetTracking(false); !previous {
+		t.Error("SetTracking() => got false, expecting tracking to be enabled by default")
+	}
+	_, _ = mb.Get("N1")
+	_, _ = mb.Get("XX")
+
+	if previous := mb.SetTracking(true); previous {
+		t.Error("SetTracking() => got true, expecting tracking to be disabled")
+	}
+	_, _ = mb.Get("N2")
+	_, _ = mb.Get("M1")
+
+	ra := pb.GetReferencedAttributes(nil, 0)
+	if len(ra.AttributeMatches) != 1 || ra.Words[indexToSlot(ra.AttributeMatches[0].Name)] != "N2" {
+		t.Errorf("Got %v, expecting only N2 to be referenced", ra)
+	}
+
+	if GetMutableBag(nil).SetTracking(true) {
+		t.Error("SetTracking() => got true for a bag that does not track references")
+	}
+}
+
 func TestGlobalWordCount(t *testing.T) {
 	// ensure that a component with a larger global word list can
 	// produce an attribute message with a shorter word list to handle
@@ -161,6 +161,15 @@ func (mb *MutableBag) Names() []string {
 	return names
 }
 
+// SetTracking enables or disables the tracking of the references made through the parent of the bag, and returns
+// whether tracking was enabled. The attributes that are set on the bag itself are never tracked.
+func (mb *MutableBag) SetTracking(enabled bool) bool {
+	if t, ok := mb.parent.(ReferenceTracker); ok {
+		return t.SetTracking(enabled)
+	}
+	return false
+}
+
 // Set creates an override for a named attribute.
 func (mb *MutableBag) Set(name string, value interface{}) {
 	mb.values[name] = value
@@ -43,8 +43,13 @@ type ProtoBag struct {
 	// to keep track of attributes that are referenced
 	referencedAttrs      map[attributeRef]mixerpb.ReferencedAttributes_Condition
 	referencedAttrsMutex sync.Mutex
+
+	// whether the tracking of references is disabled
+	untracked bool
 }
 
+var _ ReferenceTracker = &ProtoBag{}
+
 // NewProtoBag creates a new proto-based attribute bag.
 func NewProtoBag(proto *mixerpb.CompressedAttributes, globalDict map[string]int32, globalWordList []string) *ProtoBag {
 	log.Debugf("Creating bag with attributes: %v", proto)
@@ -146,15 +151,28 @@ func (pb *ProtoBag) ClearReferencedAttributes() {
 	}
 }
 
+// SetTracking enables or disables the tracking of references, and returns whether tracking was enabled.
+func (pb *ProtoBag) SetTracking(enabled bool) bool {
+	pb.referencedAttrsMutex.Lock()
+	previous := !pb.untracked
+	pb.untracked = !enabled
+	pb.referencedAttrsMutex.Unlock()
+	return previous
+}
+
 func (pb *ProtoBag) trackMapReference(name string, key string, condition mixerpb.ReferencedAttributes_Condition) {
 	pb.referencedAttrsMutex.Lock()
-	pb.referencedAttrs[attributeRef{Name: name, MapKey: key}] = condition
+	if !pb.untracked {
+		pb.referencedAttrs[attributeRef{Name: name, MapKey: key}] = condition
+	}
 	pb.referencedAttrsMutex.Unlock()
 }
 
 func (pb *ProtoBag) trackReference(name string, condition mixerpb.ReferencedAttributes_Condition) {
 	pb.referencedAttrsMutex.Lock()
-	pb.referencedAttrs[attributeRef{Name: name}] = condition
+	if !pb.untracked {
+		pb.referencedAttrs[attributeRef{Name: name}] = condition
+	}
 	pb.referencedAttrsMutex.Unlock()
 }
 
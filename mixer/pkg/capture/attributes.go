@@ -0,0 +1,177 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mixerpb "istio.io/api/mixer/v1"
+	"istio.io/istio/mixer/pkg/attribute"
+)
+
+// Attributes is a set of dictionary-decoded attributes, keyed by attribute name.
+type Attributes map[string]Value
+
+// Value is a single attribute value. It is serialized along with its type, so that it can be restored faithfully.
+type Value struct {
+	V interface{}
+}
+
+const (
+	typeString    = "string"
+	typeInt64     = "int64"
+	typeDouble    = "double"
+	typeBool      = "bool"
+	typeTimestamp = "timestamp"
+	typeDuration  = "duration"
+	typeBytes     = "bytes"
+	typeStringMap = "stringmap"
+)
+
+type encodedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// FromBag returns the attributes contained in the given bag.
+func FromBag(bag attribute.Bag) Attributes {
+	names := bag.Names()
+	a := make(Attributes, len(names))
+	for _, name := range names {
+		if v, found := bag.Get(name); found {
+			a[name] = Value{V: v}
+		}
+	}
+	return a
+}
+
+// FromProto decodes the given sequence of attribute protos and returns the fully-resolved attribute set after
+// applying each of them. Each proto is applied as a delta on top of the previous ones, as is done for Report requests.
+func FromProto(attrs []mixerpb.CompressedAttributes, globalWordList []string) ([]Attributes, error) {
+	mb := attribute.GetMutableBag(nil)
+	defer mb.Done()
+
+	result := make([]Attributes, 0, len(attrs))
+	for i := range attrs {
+		if err := mb.UpdateBagFromProto(&attrs[i], globalWordList); err != nil {
+			return nil, err
+		}
+		result = append(result, FromBag(mb))
+	}
+
+	return result, nil
+}
+
+// ToProto encodes the attributes into an attribute proto, using a message-level word list only.
+func (a Attributes) ToProto() *mixerpb.CompressedAttributes {
+	b := attribute.GetMutableBag(nil)
+	defer b.Done()
+
+	for name, v := range a {
+		b.Set(name, v.V)
+	}
+
+	var attrs mixerpb.CompressedAttributes
+	b.ToProto(&attrs, nil, 0)
+	return &attrs
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v Value) MarshalJSON() ([]byte, error) {
+	var t string
+	var val interface{} = v.V
+
+	switch tv := v.V.(type) {
+	case string:
+		t = typeString
+	case int64:
+		t = typeInt64
+	case float64:
+		t = typeDouble
+	case bool:
+		t = typeBool
+	case time.Time:
+		t = typeTimestamp
+		val = tv.Format(time.RFC3339Nano)
+	case time.Duration:
+		t = typeDuration
+		val = tv.String()
+	case []byte:
+		t = typeBytes
+	case map[string]string:
+		t = typeStringMap
+	default:
+		return nil, fmt.Errorf("unsupported attribute value type: %T", v.V)
+	}
+
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encodedValue{Type: t, Value: raw})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Value) UnmarshalJSON(b []byte) error {
+	var e encodedValue
+	if err := json.Unmarshal(b, &e); err != nil {
+		return err
+	}
+
+	var err error
+	switch e.Type {
+	case typeString:
+		var s string
+		err = json.Unmarshal(e.Value, &s)
+		v.V = s
+	case typeInt64:
+		var i int64
+		err = json.Unmarshal(e.Value, &i)
+		v.V = i
+	case typeDouble:
+		var d float64
+		err = json.Unmarshal(e.Value, &d)
+		v.V = d
+	case typeBool:
+		var bl bool
+		err = json.Unmarshal(e.Value, &bl)
+		v.V = bl
+	case typeTimestamp:
+		var s string
+		if err = json.Unmarshal(e.Value, &s); err == nil {
+			v.V, err = time.Parse(time.RFC3339Nano, s)
+		}
+	case typeDuration:
+		var s string
+		if err = json.Unmarshal(e.Value, &s); err == nil {
+			v.V, err = time.ParseDuration(s)
+		}
+	case typeBytes:
+		var by []byte
+		err = json.Unmarshal(e.Value, &by)
+		v.V = by
+	case typeStringMap:
+		var m map[string]string
+		err = json.Unmarshal(e.Value, &m)
+		v.V = m
+	default:
+		err = fmt.Errorf("unknown attribute value type: '%s'", e.Type)
+	}
+
+	return err
+}
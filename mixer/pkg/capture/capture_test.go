@@ -0,0 +1,233 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/pkg/attribute"
+)
+
+func TestAttributes_RoundTrip(t *testing.T) {
+	ts := time.Date(2018, 1, 2, 3, 4, 5, 6, time.UTC)
+	values := map[string]interface{}{
+		"s":  "str",
+		"i":  int64(1) << 60,
+		"d":  3.5,
+		"b":  true,
+		"t":  ts,
+		"du": 42 * time.Second,
+		"by": []byte{1, 2, 3},
+		"sm": map[string]string{"k": "v"},
+	}
+
+	in := FromBag(attribute.GetFakeMutableBagForTesting(values))
+
+	// Round-trip through the proto form.
+	decoded, err := FromProto(nil, nil)
+	if err != nil || len(decoded) != 0 {
+		t.Fatalf("unexpected result for empty input: %v, %v", decoded, err)
+	}
+	p := in.ToProto()
+	bag, err := attribute.GetBagFromProto(p, nil)
+	if err != nil {
+		t.Fatalf("unable to decode proto: %v", err)
+	}
+	if !reflect.DeepEqual(FromBag(bag), in) {
+		t.Fatalf("proto round-trip mismatch: %v != %v", FromBag(bag), in)
+	}
+
+	// Round-trip through the JSON form.
+	rec := &Record{Kind: Check, Time: ts, Attributes: []Attributes{in}}
+	var buf bytes.Buffer
+	if err = rec.Write(&buf); err != nil {
+		t.Fatalf("unable to write record: %v", err)
+	}
+	if err = rec.Write(&buf); err != nil {
+		t.Fatalf("unable to write record: %v", err)
+	}
+
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatalf("unable to read records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if !reflect.DeepEqual(records[0].Attributes[0], in) {
+		t.Fatalf("JSON round-trip mismatch: %v != %v", records[0].Attributes[0], in)
+	}
+}
+
+func TestValue_Errors(t *testing.T) {
+	if _, err := (Value{V: struct{}{}}).MarshalJSON(); err == nil {
+		t.Fatal("expected error for unsupported type")
+	}
+
+	v := &Value{}
+	if err := v.UnmarshalJSON([]byte(`{"type": "foo", "value": 1}`)); err == nil {
+		t.Fatal("expected error for unknown type")
+	}
+	if err := v.UnmarshalJSON([]byte(`{"type": "duration", "value": "abc"}`)); err == nil {
+		t.Fatal("expected error for bad duration")
+	}
+}
+
+func TestResult_Equal(t *testing.T) {
+	a := &Result{Code: 0, Quotas: map[string]int64{"q": 1}}
+	b := &Result{Code: 0, Message: "different message", Quotas: map[string]int64{"q": 1}}
+	if !a.Equal(b) {
+		t.Fatal("results should be equal")
+	}
+
+	b.Quotas["q"] = 2
+	if a.Equal(b) {
+		t.Fatal("results should not be equal")
+	}
+
+	if a.Equal(nil) || !(*Result)(nil).Equal(nil) {
+		t.Fatal("nil comparison failed")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if _, err = NewRecorder(path.Join(dir, "c.json"), 0, 0); err == nil {
+		t.Fatal("expected error for invalid sample rate")
+	}
+	if _, err = NewRecorder(path.Join(dir, "c.json"), 1, -1); err == nil {
+		t.Fatal("expected error for invalid max size")
+	}
+
+	file := path.Join(dir, "c.json")
+	r, err := NewRecorder(file, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Sample() {
+		t.Fatal("recorder with sample rate 1 should always sample")
+	}
+
+	var nilRecorder *Recorder
+	if nilRecorder.Sample() {
+		t.Fatal("nil recorder should never sample")
+	}
+
+	r.Record(&Record{Kind: Report, Attributes: []Attributes{{"a": Value{V: "b"}}}})
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records after Close are dropped.
+	r.Record(&Record{Kind: Report})
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := ReadRecords(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Kind != Report || records[0].Attributes[0]["a"].V != "b" {
+		t.Fatalf("unexpected records: %v", records)
+	}
+}
+
+func readRecordFile(t *testing.T, file string) []*Record {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := ReadRecords(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestRecorder_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// Every record exceeds the maximum size, so each one starts a new file.
+	file := path.Join(dir, "c.json")
+	r, err := NewRecorder(file, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"r1", "r2", "r3"} {
+		r.Record(&Record{Kind: Report, Attributes: []Attributes{{"a": Value{V: v}}}})
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readRecordFile(t, file)
+	if len(records) != 1 || records[0].Attributes[0]["a"].V != "r3" {
+		t.Fatalf("unexpected records: %v", records)
+	}
+	records = readRecordFile(t, file+".1")
+	if len(records) != 1 || records[0].Attributes[0]["a"].V != "r2" {
+		t.Fatalf("unexpected records in the backup file: %v", records)
+	}
+}
+
+func TestRecorder_Flush(t *testing.T) {
+	defer func(d time.Duration) { flushInterval = d }(flushInterval)
+	flushInterval = time.Millisecond
+
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	file := path.Join(dir, "c.json")
+	r, err := NewRecorder(file, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+
+	r.Record(&Record{Kind: Check})
+
+	// The record is written to the file without closing the recorder.
+	for i := 0; len(readRecordFile(t, file)) == 0; i++ {
+		if i == 1000 {
+			t.Fatal("the record was not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package capture implements recording of Mixer API requests, along with their dictionary-decoded attributes,
+// so that they can later be replayed against a Mixer instance. Records are stored as a stream of JSON objects,
+// one per line.
+package capture
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Kind of a captured request.
+type Kind string
+
+const (
+	// Check indicates a captured Check request. Quota allocations are part of Check requests.
+	Check Kind = "check"
+
+	// Report indicates a captured Report request.
+	Report Kind = "report"
+)
+
+// Record is a single captured request.
+type Record struct {
+	// Kind of the request.
+	Kind Kind `json:"kind"`
+
+	// Time at which the request was captured.
+	Time time.Time `json:"time"`
+
+	// Attributes of the request. Check requests have a single attribute set. Report requests have one
+	// fully-resolved attribute set per entry in the original request.
+	Attributes []Attributes `json:"attributes"`
+
+	// Quotas that were requested as part of a check request, by quota name.
+	Quotas map[string]QuotaParams `json:"quotas,omitempty"`
+
+	// Result that was returned to the caller at capture time, if known.
+	Result *Result `json:"result,omitempty"`
+}
+
+// QuotaParams are the parameters of a quota allocation request.
+type QuotaParams struct {
+	Amount     int64 `json:"amount"`
+	BestEffort bool  `json:"bestEffort,omitempty"`
+}
+
+// Result is the outcome of a request.
+type Result struct {
+	// Code is the google.rpc.Code of the result.
+	Code int32 `json:"code"`
+
+	// Message is the status message, if any.
+	Message string `json:"message,omitempty"`
+
+	// Quotas contains the granted amounts, by quota name.
+	Quotas map[string]int64 `json:"quotas,omitempty"`
+}
+
+// Equal returns true if the two results have the same status code and granted quota amounts.
+func (r *Result) Equal(o *Result) bool {
+	if r == nil || o == nil {
+		return r == o
+	}
+
+	if r.Code != o.Code || len(r.Quotas) != len(o.Quotas) {
+		return false
+	}
+
+	for name, amount := range r.Quotas {
+		if other, found := o.Quotas[name]; !found || other != amount {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Write writes the record to the given writer, as a single line of JSON.
+func (r *Record) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// ReadRecords reads all the records from the given reader.
+func ReadRecords(r io.Reader) ([]*Record, error) {
+	var records []*Record
+
+	d := json.NewDecoder(r)
+	for {
+		rec := &Record{}
+		if err := d.Decode(rec); err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"istio.io/istio/pkg/log"
+)
+
+// flushInterval is the interval at which the records are flushed to the capture file.
+var flushInterval = time.Second
+
+// maxPendingRecords is the maximum number of records that wait to be written. Further records are dropped, until the
+// writing of the capture file catches up.
+const maxPendingRecords = 1000
+
+// Recorder writes a sample of the requests to a local file. The records are written by a background goroutine, and
+// the capture file is rotated to a single backup file, with the ".1" suffix, once it reaches its maximum size.
+type Recorder struct {
+	sampleRate float64
+	path       string
+	maxSize    int64
+
+	// the records that wait to be written.
+	records chan *Record
+
+	// closed once the background goroutine has written all records, and closed the capture file.
+	done chan struct{}
+
+	// the number of records that were dropped since the last flush.
+	dropped int64
+
+	// mu protects the fields below.
+	mu     sync.Mutex
+	rand   *rand.Rand
+	closed bool
+
+	// owned by the background goroutine.
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+	err    error
+}
+
+// NewRecorder returns a new Recorder that appends records to the file at the given path. The sample rate is the
+// fraction of requests, in the range (0, 1], that should be recorded. The file is rotated once it reaches maxSize
+// bytes, unless maxSize is zero.
+func NewRecorder(path string, sampleRate float64, maxSize int64) (*Recorder, error) {
+	if sampleRate <= 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("capture sample rate must be > 0 and <= 1, got %f", sampleRate)
+	}
+	if maxSize < 0 {
+		return nil, fmt.Errorf("capture file size must be >= 0, got %d", maxSize)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open capture file '%s': %v", path, err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("unable to open capture file '%s': %v", path, err)
+	}
+
+	r := &Recorder{
+		sampleRate: sampleRate,
+		path:       path,
+		maxSize:    maxSize,
+		records:    make(chan *Record, maxPendingRecords),
+		done:       make(chan struct{}),
+		rand:       rand.New(rand.NewSource(rand.Int63())),
+		file:       f,
+		writer:     bufio.NewWriter(f),
+		size:       fi.Size(),
+	}
+	go r.run()
+	return r, nil
+}
+
+// Sample returns true if the current request should be recorded. It is safe to call Sample on a nil Recorder.
+func (r *Recorder) Sample() bool {
+	if r == nil {
+		return false
+	}
+
+	if r.sampleRate >= 1 {
+		return true
+	}
+
+	r.mu.Lock()
+	s := r.rand.Float64() < r.sampleRate
+	r.mu.Unlock()
+	return s
+}
+
+// Record queues the given record to be written to the capture file. It does not block: the record is dropped if too
+// many records are already waiting to be written.
+func (r *Recorder) Record(rec *Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	select {
+	case r.records <- rec:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// Close writes the pending records, and closes the capture file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.records)
+	r.mu.Unlock()
+
+	<-r.done
+	return r.err
+}
+
+// run writes the records, and flushes them periodically, until the Recorder is closed.
+func (r *Recorder) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case rec, ok := <-r.records:
+			if !ok {
+				r.flush()
+				r.err = r.closeFile()
+				close(r.done)
+				return
+			}
+			r.write(rec)
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+func (r *Recorder) write(rec *Record) {
+	if r.file == nil {
+		return
+	}
+
+	var b bytes.Buffer
+	if err := rec.Write(&b); err != nil {
+		log.Warnf("Unable to write capture record: %v", err)
+		return
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(b.Len()) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			log.Errorf("Unable to rotate capture file, requests are no longer captured: %v", err)
+			return
+		}
+	}
+
+	n, err := r.writer.Write(b.Bytes())
+	r.size += int64(n)
+	if err != nil {
+		log.Warnf("Unable to write capture record: %v", err)
+	}
+}
+
+func (r *Recorder) flush() {
+	if dropped := atomic.SwapInt64(&r.dropped, 0); dropped > 0 {
+		log.Warnf("Dropped %d capture records, as writing the capture file fell behind", dropped)
+	}
+
+	if r.file == nil {
+		return
+	}
+	if err := r.writer.Flush(); err != nil {
+		log.Warnf("Unable to flush capture file: %v", err)
+	}
+}
+
+// rotate moves the capture file to the backup file, replacing any previous one, and starts a new capture file.
+func (r *Recorder) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.writer = bufio.NewWriter(f)
+	r.size = 0
+	return nil
+}
+
+// closeFile flushes and closes the capture file.
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.writer.Flush()
+	if e := r.file.Close(); err == nil {
+		err = e
+	}
+	r.file = nil
+	r.writer = nil
+
+	return err
+}
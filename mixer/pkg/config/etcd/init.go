@@ -0,0 +1,116 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/pkg/transport"
+
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/pkg/probe"
+)
+
+// Query parameters of the config URL.
+const (
+	// certParam is the path of the client certificate file.
+	certParam = "cert"
+
+	// keyParam is the path of the client key file.
+	keyParam = "key"
+
+	// caCertParam is the path of the CA certificate file that is used to verify the etcd servers.
+	caCertParam = "cacert"
+
+	// dialTimeoutParam is the timeout for establishing a connection to the etcd servers.
+	dialTimeoutParam = "dial-timeout"
+)
+
+// NewStore creates a new Store instance. The URL has the form
+//
+//	etcd://host1:2379,host2:2379/prefix?cacert=ca.pem&cert=client.pem&key=client-key.pem
+//
+// The resources are stored as YAML or JSON documents under the keys <prefix>/<kind>/<namespace>/<name>. TLS is
+// used if any of the certificate parameters is set. The user info of the URL is used to authenticate with etcd.
+func NewStore(u *url.URL) (store.Backend, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("no etcd endpoints in the config URL: %s", u)
+	}
+
+	q := u.Query()
+
+	dialTimeout := defaultDialTimeout
+	if p := q.Get(dialTimeoutParam); p != "" {
+		var err error
+		if dialTimeout, err = time.ParseDuration(p); err != nil {
+			return nil, fmt.Errorf("invalid %s in the config URL: %v", dialTimeoutParam, err)
+		}
+	}
+
+	scheme := "http"
+	conf := clientv3.Config{DialTimeout: dialTimeout}
+
+	tlsInfo := transport.TLSInfo{
+		CertFile:      q.Get(certParam),
+		KeyFile:       q.Get(keyParam),
+		TrustedCAFile: q.Get(caCertParam),
+	}
+	if !tlsInfo.Empty() || tlsInfo.TrustedCAFile != "" {
+		tlsConfig, err := tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS configuration of the config URL: %v", err)
+		}
+		conf.TLS = tlsConfig
+		scheme = "https"
+	}
+
+	for _, host := range strings.Split(u.Host, ",") {
+		conf.Endpoints = append(conf.Endpoints, scheme+"://"+host)
+	}
+
+	if u.User != nil {
+		conf.Username = u.User.Username()
+		conf.Password, _ = u.User.Password()
+	}
+
+	return &Store{
+		conf:   conf,
+		prefix: keyPrefix(u.Path),
+		kinds:  map[string]bool{},
+		data:   map[store.Key]*store.BackEndResource{},
+		Probe:  probe.NewProbe(),
+	}, nil
+}
+
+// keyPrefix returns the prefix of the keys of the resources, which always ends with a slash.
+func keyPrefix(p string) string {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return p
+	}
+	return p + "/"
+}
+
+// Register registers this module as a StoreBackend.
+// Do not use 'init()' for automatic registration; linker will drop
+// the whole module because it looks unused.
+func Register(builders map[string]store.Builder) {
+	builders["etcd"] = NewStore
+}
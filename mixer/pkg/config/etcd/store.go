@@ -0,0 +1,293 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package etcd provides the store interface to config resources stored in etcd v3.
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/probe"
+)
+
+// defaultDialTimeout is the default timeout for establishing a connection to the etcd servers. The timeout can be
+// customized through the "dial-timeout" query parameter in the config URL, like etcd://localhost:2379?dial-timeout=1m
+const defaultDialTimeout = 5 * time.Second
+
+// The interval to wait between the attempts to list the resources, when the watch has to be restarted. This is not
+// const to allow changing the value for unittests.
+var retryInterval = time.Second
+
+// Store offers store.Backend interface through etcd v3. The resources are listed at initialization, and are kept up
+// to date by watching the key prefix from the revision of the listing. If the watch fails, e.g. because its revision
+// was compacted, the resources are listed again and the differences are published as events.
+type Store struct {
+	conf   clientv3.Config
+	prefix string
+	kinds  map[string]bool
+
+	client *clientv3.Client
+
+	mu   sync.RWMutex
+	data map[store.Key]*store.BackEndResource
+
+	watchMutex sync.RWMutex
+	watchCtx   context.Context
+	watchCh    chan store.BackendEvent
+
+	*probe.Probe
+}
+
+var _ store.Backend = &Store{}
+
+// Init implements store.Backend interface.
+func (s *Store) Init(ctx context.Context, kinds []string) error {
+	for _, k := range kinds {
+		s.kinds[k] = true
+	}
+
+	client, err := clientv3.New(s.conf)
+	if err != nil {
+		return fmt.Errorf("unable to connect to etcd at %v: %v", s.conf.Endpoints, err)
+	}
+	s.client = client
+
+	rev, err := s.resync(ctx)
+	if err != nil {
+		_ = client.Close()
+		return err
+	}
+
+	go func() {
+		s.watch(ctx, rev)
+		_ = client.Close()
+	}()
+	return nil
+}
+
+// Watch implements store.Backend interface.
+func (s *Store) Watch(ctx context.Context) (<-chan store.BackendEvent, error) {
+	ch := make(chan store.BackendEvent)
+	s.watchMutex.Lock()
+	s.watchCtx = ctx
+	s.watchCh = ch
+	s.watchMutex.Unlock()
+	return ch, nil
+}
+
+// Get implements store.Backend interface.
+func (s *Store) Get(key store.Key) (*store.BackEndResource, error) {
+	s.mu.RLock()
+	r, ok := s.data[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return r, nil
+}
+
+// List implements store.Backend interface.
+func (s *Store) List() map[store.Key]*store.BackEndResource {
+	s.mu.RLock()
+	copied := make(map[store.Key]*store.BackEndResource, len(s.data))
+	for k, r := range s.data {
+		copied[k] = r
+	}
+	s.mu.RUnlock()
+	return copied
+}
+
+// watch applies the changes of the resources from the given revision on, until the context is done.
+func (s *Store) watch(ctx context.Context, rev int64) {
+	for {
+		rev = s.watchFrom(ctx, rev)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The watch failed, list the resources again and restart the watch from the revision of the listing.
+		for {
+			var err error
+			if rev, err = s.resync(ctx); err == nil {
+				break
+			}
+			log.Warnf("Unable to list the config resources in etcd, retrying in %v: %v", retryInterval, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+// watchFrom watches the changes after the given revision, until the watch fails or the context is done. It returns
+// the revision of the last change that was applied.
+func (s *Store) watchFrom(ctx context.Context, rev int64) int64 {
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for resp := range s.client.Watch(wctx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+		if err := resp.Err(); err != nil {
+			log.Warnf("Watch of the config resources in etcd failed at revision %d: %v", rev, err)
+			return rev
+		}
+
+		evs := make([]store.BackendEvent, 0, len(resp.Events))
+		s.mu.Lock()
+		for _, ev := range resp.Events {
+			if e, ok := s.apply(ev); ok {
+				evs = append(evs, e)
+			}
+		}
+		s.mu.Unlock()
+		s.publish(evs)
+
+		rev = resp.Header.Revision
+	}
+	return rev
+}
+
+// apply applies a change to the resources. It returns the resulting event, unless the change is not relevant to
+// the store. The caller must hold the lock.
+func (s *Store) apply(ev *clientv3.Event) (store.BackendEvent, bool) {
+	key, ok := s.parseKey(ev.Kv.Key)
+	if !ok {
+		return store.BackendEvent{}, false
+	}
+
+	if ev.Type == mvccpb.DELETE {
+		if _, found := s.data[key]; !found {
+			return store.BackendEvent{}, false
+		}
+		delete(s.data, key)
+		return store.BackendEvent{Type: store.Delete, Key: key}, true
+	}
+
+	r, ok := s.parseValue(key, ev.Kv)
+	if !ok {
+		return store.BackendEvent{}, false
+	}
+	s.data[key] = r
+	return store.BackendEvent{Type: store.Update, Key: key, Value: r}, true
+}
+
+// resync lists all resources, replaces the current resources with them, and publishes the differences. It returns
+// the revision of the listing.
+func (s *Store) resync(ctx context.Context) (int64, error) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	s.SetAvailable(err)
+	if err != nil {
+		return 0, fmt.Errorf("unable to list the config resources in etcd: %v", err)
+	}
+
+	newData := make(map[store.Key]*store.BackEndResource, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		key, ok := s.parseKey(kv.Key)
+		if !ok {
+			continue
+		}
+		if r, ok := s.parseValue(key, kv); ok {
+			newData[key] = r
+		}
+	}
+
+	s.mu.Lock()
+	evs := diff(s.data, newData)
+	s.data = newData
+	s.mu.Unlock()
+
+	s.publish(evs)
+	return resp.Header.Revision, nil
+}
+
+// diff returns the events that change the old resources into the new ones.
+func diff(oldData, newData map[store.Key]*store.BackEndResource) []store.BackendEvent {
+	var evs []store.BackendEvent
+	for k, r := range newData {
+		if old, found := oldData[k]; !found || old.Metadata.Revision != r.Metadata.Revision {
+			evs = append(evs, store.BackendEvent{Type: store.Update, Key: k, Value: r})
+		}
+	}
+	for k := range oldData {
+		if _, found := newData[k]; !found {
+			evs = append(evs, store.BackendEvent{Type: store.Delete, Key: k})
+		}
+	}
+	return evs
+}
+
+func (s *Store) publish(evs []store.BackendEvent) {
+	if len(evs) == 0 {
+		return
+	}
+
+	s.watchMutex.RLock()
+	defer s.watchMutex.RUnlock()
+	if s.watchCh == nil {
+		return
+	}
+	for _, ev := range evs {
+		select {
+		case <-s.watchCtx.Done():
+		case s.watchCh <- ev:
+		}
+	}
+}
+
+// parseKey returns the store key of an etcd key of the form <prefix><kind>/<namespace>/<name>. Keys that are not of
+// this form, or that are of an unknown kind, are ignored.
+func (s *Store) parseKey(k []byte) (store.Key, bool) {
+	parts := strings.Split(strings.TrimPrefix(string(k), s.prefix), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		log.Debugf("Ignoring etcd key %s", k)
+		return store.Key{}, false
+	}
+
+	key := store.Key{Kind: parts[0], Namespace: parts[1], Name: parts[2]}
+	if !s.kinds[key.Kind] {
+		return store.Key{}, false
+	}
+	return key, true
+}
+
+// parseValue parses the resource that is stored under the given key. The revision of the resource is the
+// modification revision of the key.
+func (s *Store) parseValue(key store.Key, kv *mvccpb.KeyValue) (*store.BackEndResource, bool) {
+	r, err := store.ParseChunk(kv.Value)
+	if err != nil {
+		log.Errorf("Error processing etcd key %s: %v", kv.Key, err)
+		return nil, false
+	}
+	if r == nil {
+		return nil, false
+	}
+	if r.Key() != key {
+		log.Errorf("Error processing etcd key %s: the resource %s does not match the key", kv.Key, r.Key())
+		return nil, false
+	}
+
+	r.Metadata.Revision = strconv.FormatInt(kv.ModRevision, 10)
+	return r, true
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	mixerpb "istio.io/api/mixer/v1"
+	"istio.io/istio/mixer/pkg/attribute"
+)
+
+// generator generates the requests of a profile. A pool of requests is generated up front for each request kind,
+// so that generating the load does not skew the latency measurements.
+type generator struct {
+	kinds []string
+	mix   *weighted
+	pools map[string][]interface{}
+}
+
+func newGenerator(p *Profile, r *rand.Rand) (*generator, error) {
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	// generate the attributes in a stable order, so that a seed always yields the same requests.
+	sort.Strings(names)
+
+	values := make([]valueGenerator, len(names))
+	for i, name := range names {
+		var err error
+		if values[i], err = newValueGenerator(p.Attributes[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	quotas := make(map[string]mixerpb.CheckRequest_QuotaParams, len(p.Quotas))
+	for name, q := range p.Quotas {
+		quotas[name] = mixerpb.CheckRequest_QuotaParams{Amount: q.Amount, BestEffort: q.BestEffort}
+	}
+
+	g := &generator{pools: make(map[string][]interface{})}
+	var weights []float64
+	for _, kind := range []string{CheckKind, ReportKind, QuotaKind} {
+		w := p.Mix[kind]
+		if w <= 0 {
+			continue
+		}
+		g.kinds = append(g.kinds, kind)
+		weights = append(weights, w)
+
+		pool := make([]interface{}, p.PoolSize)
+		for i := range pool {
+			pool[i] = newRequest(kind, names, values, quotas, r)
+		}
+		g.pools[kind] = pool
+	}
+
+	var err error
+	if g.mix, err = newWeighted(weights); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// next returns the kind of the next request, and the request.
+func (g *generator) next(r *rand.Rand) (string, interface{}) {
+	kind := g.kinds[g.mix.pick(r)]
+	pool := g.pools[kind]
+	return kind, pool[r.Intn(len(pool))]
+}
+
+func newRequest(kind string, names []string, values []valueGenerator,
+	quotas map[string]mixerpb.CheckRequest_QuotaParams, r *rand.Rand) interface{} {
+
+	now := time.Now()
+	bag := attribute.GetMutableBag(nil)
+	for i, name := range names {
+		bag.Set(name, values[i](r, now))
+	}
+
+	var attrs mixerpb.CompressedAttributes
+	bag.ToProto(&attrs, nil, 0)
+	bag.Done()
+
+	switch kind {
+	case ReportKind:
+		return &mixerpb.ReportRequest{Attributes: []mixerpb.CompressedAttributes{attrs}}
+	case QuotaKind:
+		return &mixerpb.CheckRequest{Attributes: attrs, Quotas: quotas}
+	default:
+		return &mixerpb.CheckRequest{Attributes: attrs}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadgen generates load against a running Mixer. The load is described by a Profile, which specifies the
+// request rate, the mix of Check, Report and Quota requests, and the distributions of the attribute values. The load
+// is applied open-loop, i.e. requests are sent at the target rate regardless of how fast Mixer responds, and the
+// latencies are measured from the time each request was scheduled to be sent.
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ghodss/yaml"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// Request kinds.
+const (
+	CheckKind  = "check"
+	ReportKind = "report"
+	QuotaKind  = "quota"
+)
+
+// Attribute types.
+const (
+	stringType    = "string"
+	int64Type     = "int64"
+	doubleType    = "double"
+	boolType      = "bool"
+	timestampType = "timestamp"
+	durationType  = "duration"
+	bytesType     = "bytes"
+	stringMapType = "string_map"
+)
+
+// Default values of the profile.
+const (
+	defaultConnections = 1
+	defaultMaxInFlight = 1000
+	defaultPoolSize    = 1000
+)
+
+// Profile describes the load to apply on Mixer.
+type Profile struct {
+	// QPS is the target rate of requests per second.
+	QPS float64 `json:"qps"`
+
+	// Duration of the measurement.
+	Duration Duration `json:"duration"`
+
+	// Warmup is the duration for which the load is applied before the measurement starts.
+	Warmup Duration `json:"warmup,omitempty"`
+
+	// Connections is the number of gRPC connections that the requests are spread over.
+	Connections int `json:"connections,omitempty"`
+
+	// MaxInFlight is the maximum number of outstanding requests. Requests that are due while this many requests are
+	// outstanding are not sent, and are counted as dropped.
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+
+	// PoolSize is the number of distinct requests of each kind that are generated up front and replayed.
+	PoolSize int `json:"poolSize,omitempty"`
+
+	// Seed of the random number generator. If zero, a time-based seed is used.
+	Seed int64 `json:"seed,omitempty"`
+
+	// Mix is the relative weight of each request kind: check, report and quota.
+	Mix map[string]float64 `json:"mix"`
+
+	// Attributes are the distributions of the attribute values that are sent with each request.
+	Attributes map[string]*Attribute `json:"attributes,omitempty"`
+
+	// Quotas are the quotas that are allocated by quota requests.
+	Quotas map[string]QuotaParams `json:"quotas,omitempty"`
+}
+
+// Attribute is the distribution of the values of an attribute. The values are either picked from Values, using the
+// optional Weights, or uniformly from the range [Min, Max]. Timestamp attributes without values are set to the time
+// the request is generated.
+type Attribute struct {
+	// Type of the attribute: string (the default), int64, double, bool, timestamp, duration, bytes or string_map.
+	Type string `json:"type,omitempty"`
+
+	// Values of the attribute. Durations and timestamps are specified as strings, in Go duration and RFC3339 format
+	// respectively.
+	Values []interface{} `json:"values,omitempty"`
+
+	// Weights are the relative weights of the values. If omitted, the values are equally likely.
+	Weights []float64 `json:"weights,omitempty"`
+
+	// Min and Max are the bounds of the range of int64, double and duration attributes.
+	Min interface{} `json:"min,omitempty"`
+	Max interface{} `json:"max,omitempty"`
+}
+
+// QuotaParams are the parameters of a quota allocation.
+type QuotaParams struct {
+	Amount     int64 `json:"amount"`
+	BestEffort bool  `json:"bestEffort,omitempty"`
+}
+
+// Duration is a time.Duration that is specified as a string in Go duration format.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s: %v", b, err)
+	}
+
+	var err error
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// ReadProfile reads a YAML profile from a file, and validates it.
+func ReadProfile(path string) (*Profile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProfile(b)
+}
+
+// ParseProfile parses a YAML profile, and validates it.
+func ParseProfile(b []byte) (*Profile, error) {
+	p := &Profile{}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("unable to parse profile: %v", err)
+	}
+
+	if p.Connections == 0 {
+		p.Connections = defaultConnections
+	}
+	if p.MaxInFlight == 0 {
+		p.MaxInFlight = defaultMaxInFlight
+	}
+	if p.PoolSize == 0 {
+		p.PoolSize = defaultPoolSize
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate returns an error if the profile is invalid.
+func (p *Profile) Validate() error {
+	var errs *multierror.Error
+
+	if p.QPS <= 0 {
+		errs = multierror.Append(errs, fmt.Errorf("qps must be > 0, got %v", p.QPS))
+	}
+	if p.Duration.Duration <= 0 {
+		errs = multierror.Append(errs, fmt.Errorf("duration must be > 0, got %v", p.Duration))
+	}
+	if p.Warmup.Duration < 0 {
+		errs = multierror.Append(errs, fmt.Errorf("warmup must be >= 0, got %v", p.Warmup))
+	}
+	if p.Connections <= 0 {
+		errs = multierror.Append(errs, fmt.Errorf("connections must be > 0, got %d", p.Connections))
+	}
+	if p.MaxInFlight <= 0 {
+		errs = multierror.Append(errs, fmt.Errorf("maxInFlight must be > 0, got %d", p.MaxInFlight))
+	}
+	if p.PoolSize <= 0 {
+		errs = multierror.Append(errs, fmt.Errorf("poolSize must be > 0, got %d", p.PoolSize))
+	}
+
+	total := 0.0
+	for kind, w := range p.Mix {
+		switch kind {
+		case CheckKind, ReportKind, QuotaKind:
+		default:
+			errs = multierror.Append(errs, fmt.Errorf("unknown request kind in mix: '%s'", kind))
+		}
+		if w < 0 {
+			errs = multierror.Append(errs, fmt.Errorf("weight of %s must be >= 0, got %v", kind, w))
+		}
+		total += w
+	}
+	if total <= 0 {
+		errs = multierror.Append(errs, fmt.Errorf("mix must have a request kind with a positive weight"))
+	}
+	if p.Mix[QuotaKind] > 0 && len(p.Quotas) == 0 {
+		errs = multierror.Append(errs, fmt.Errorf("quota requests require quotas"))
+	}
+
+	for name, a := range p.Attributes {
+		if _, err := newValueGenerator(a); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("attribute '%s': %v", name, err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
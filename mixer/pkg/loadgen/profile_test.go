@@ -0,0 +1,91 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadProfile(t *testing.T) {
+	p, err := ReadProfile("testdata/profile.yaml")
+	if err != nil {
+		t.Fatalf("ReadProfile() => unexpected error: %v", err)
+	}
+
+	if p.QPS != 500 || p.Duration.Duration != time.Minute || p.Warmup.Duration != 10*time.Second ||
+		p.Connections != 4 || p.MaxInFlight != defaultMaxInFlight || p.PoolSize != defaultPoolSize {
+		t.Fatalf("ReadProfile() => got %+v", p)
+	}
+	if len(p.Mix) != 3 || len(p.Attributes) != 8 || p.Quotas["requestcount"].Amount != 1 {
+		t.Fatalf("ReadProfile() => got %+v", p)
+	}
+
+	if _, err = ReadProfile("testdata/nonexistent.yaml"); err == nil {
+		t.Fatal("ReadProfile() => got no error for a missing file")
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"valid", "qps: 10\nduration: 1s\nmix: {check: 1}", ""},
+		{"invalid yaml", "qps: [", "unable to parse profile"},
+		{"invalid duration", "qps: 10\nduration: forever\nmix: {check: 1}", "unable to parse profile"},
+		{"no qps", "duration: 1s\nmix: {check: 1}", "qps must be > 0"},
+		{"no duration", "qps: 10\nmix: {check: 1}", "duration must be > 0"},
+		{"negative warmup", "qps: 10\nduration: 1s\nwarmup: -1s\nmix: {check: 1}", "warmup must be >= 0"},
+		{"negative connections", "qps: 10\nduration: 1s\nconnections: -1\nmix: {check: 1}", "connections must be > 0"},
+		{"no mix", "qps: 10\nduration: 1s", "mix must have a request kind"},
+		{"unknown kind", "qps: 10\nduration: 1s\nmix: {check: 1, foo: 1}", "unknown request kind in mix: 'foo'"},
+		{"negative weight", "qps: 10\nduration: 1s\nmix: {check: 1, report: -1}", "weight of report must be >= 0"},
+		{"no quotas", "qps: 10\nduration: 1s\nmix: {quota: 1}", "quota requests require quotas"},
+		{"invalid attribute", "qps: 10\nduration: 1s\nmix: {check: 1}\nattributes: {a: {type: int64, values: [x]}}",
+			"attribute 'a': invalid int64 value: x"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseProfile([]byte(tc.yaml))
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("ParseProfile() => unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("ParseProfile() => got %v, want error containing '%s'", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	b, err := json.Marshal(Duration{90 * time.Second})
+	if err != nil || string(b) != `"1m30s"` {
+		t.Fatalf("Marshal() => got %s, %v", b, err)
+	}
+
+	var d Duration
+	if err = json.Unmarshal(b, &d); err != nil || d.Duration != 90*time.Second {
+		t.Fatalf("Unmarshal() => got %v, %v", d, err)
+	}
+	if err = json.Unmarshal([]byte("90"), &d); err == nil {
+		t.Fatal("Unmarshal() => got no error for a number")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Profiler captures the profiles of a Mixer server through the pprof endpoints of its monitoring port. The server
+// must be started with profiling enabled.
+type Profiler struct {
+	// Address of the monitoring port of the server, as host:port.
+	Address string
+
+	// Dir is the directory the profiles are written to.
+	Dir string
+
+	client http.Client
+}
+
+// NewProfiler returns a profiler of the server with the given monitoring address, which writes the profiles to dir.
+func NewProfiler(address, dir string) *Profiler {
+	return &Profiler{Address: address, Dir: dir}
+}
+
+// CPU captures a CPU profile of the server over the given duration, and returns the path of the written profile.
+func (p *Profiler) CPU(ctx context.Context, d time.Duration) (string, error) {
+	secs := int(d / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return p.fetch(ctx, fmt.Sprintf("/debug/pprof/profile?seconds=%d", secs), "cpu.pprof")
+}
+
+// Heap captures a heap profile of the server, which includes the allocations since the server started, and returns
+// the path of the written profile.
+func (p *Profiler) Heap(ctx context.Context) (string, error) {
+	return p.fetch(ctx, "/debug/pprof/heap", "heap.pprof")
+}
+
+func (p *Profiler) fetch(ctx context.Context, path, file string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+p.Address+path, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch %s: %v", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to fetch %s: %s (is profiling enabled on the server?)", path, resp.Status)
+	}
+
+	out := filepath.Join(p.Dir, file)
+	f, err := os.Create(out)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return out, f.Close()
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfiler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loadgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	var cpuQuery string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		cpuQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte("cpu"))
+	})
+	mux.HandleFunc("/debug/pprof/heap", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("heap"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	p := NewProfiler(strings.TrimPrefix(s.URL, "http://"), dir)
+
+	out, err := p.CPU(context.Background(), 100*time.Millisecond)
+	if err != nil || out != filepath.Join(dir, "cpu.pprof") || cpuQuery != "seconds=1" {
+		t.Fatalf("CPU() => got %s, %v with query '%s'", out, err, cpuQuery)
+	}
+	if b, _ := ioutil.ReadFile(out); string(b) != "cpu" {
+		t.Fatalf("CPU() => wrote '%s'", b)
+	}
+
+	out, err = p.Heap(context.Background())
+	if err != nil || out != filepath.Join(dir, "heap.pprof") {
+		t.Fatalf("Heap() => got %s, %v", out, err)
+	}
+	if b, _ := ioutil.ReadFile(out); string(b) != "heap" {
+		t.Fatalf("Heap() => wrote '%s'", b)
+	}
+
+	// Profiling is not enabled.
+	disabled := httptest.NewServer(http.NotFoundHandler())
+	defer disabled.Close()
+	p = NewProfiler(strings.TrimPrefix(disabled.URL, "http://"), dir)
+	if _, err = p.Heap(context.Background()); err == nil || !strings.Contains(err.Error(), "is profiling enabled") {
+		t.Fatalf("Heap() => got %v, want an error", err)
+	}
+}
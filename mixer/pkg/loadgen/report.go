@@ -0,0 +1,318 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the result of a load run. Reports are written as JSON, so that the runs of different Mixer builds can be
+// compared.
+type Report struct {
+	// Label identifies the run, e.g. the Mixer build that was measured.
+	Label string `json:"label,omitempty"`
+
+	// Start is the time the measurement started, after the warmup.
+	Start time.Time `json:"start"`
+
+	// Duration of the measurement.
+	Duration time.Duration `json:"duration"`
+
+	// TargetQPS is the target rate of the profile.
+	TargetQPS float64 `json:"targetQPS"`
+
+	// AchievedQPS is the rate of the requests that completed during the measurement.
+	AchievedQPS float64 `json:"achievedQPS"`
+
+	// Kinds are the results per request kind.
+	Kinds map[string]*KindReport `json:"kinds"`
+
+	// Profiles are the paths of the server profiles that were captured during the run.
+	Profiles []string `json:"profiles,omitempty"`
+
+	latencies map[string][]time.Duration
+}
+
+// KindReport is the result of the requests of a kind.
+type KindReport struct {
+	// Requests is the number of requests that completed.
+	Requests int64 `json:"requests"`
+
+	// Errors is the number of requests that failed with an error.
+	Errors int64 `json:"errors"`
+
+	// Denied is the number of requests that Mixer denied, or for which it did not grant the quota.
+	Denied int64 `json:"denied"`
+
+	// Dropped is the number of requests that were not sent, because too many requests were outstanding.
+	Dropped int64 `json:"dropped"`
+
+	// ErrorCodes is the number of failed and denied requests per gRPC status code.
+	ErrorCodes map[string]int64 `json:"errorCodes,omitempty"`
+
+	// Latency of the completed requests, including the failed and denied ones.
+	Latency Latency `json:"latency"`
+}
+
+// Latency is a latency distribution.
+type Latency struct {
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	P999 time.Duration `json:"p999"`
+	Max  time.Duration `json:"max"`
+}
+
+func newReport(p *Profile) *Report {
+	return &Report{
+		TargetQPS: p.QPS,
+		Kinds:     make(map[string]*KindReport),
+		latencies: make(map[string][]time.Duration),
+	}
+}
+
+func (r *Report) kind(kind string) *KindReport {
+	k, found := r.Kinds[kind]
+	if !found {
+		k = &KindReport{}
+		r.Kinds[kind] = k
+	}
+	return k
+}
+
+func (r *Report) add(res result) {
+	k := r.kind(res.kind)
+	if res.outcome == dropped {
+		k.Dropped++
+		return
+	}
+
+	k.Requests++
+	switch res.outcome {
+	case failed:
+		k.Errors++
+	case denied:
+		k.Denied++
+	}
+	if res.outcome != succeeded {
+		if k.ErrorCodes == nil {
+			k.ErrorCodes = make(map[string]int64)
+		}
+		k.ErrorCodes[res.code.String()]++
+	}
+	r.latencies[res.kind] = append(r.latencies[res.kind], res.latency)
+}
+
+// finish computes the latency distributions, and the achieved rate over the measurement.
+func (r *Report) finish(start time.Time, d time.Duration) {
+	r.Start = start
+	r.Duration = d
+
+	var total int64
+	for kind, k := range r.Kinds {
+		k.Latency = distribution(r.latencies[kind])
+		total += k.Requests
+	}
+	if d > 0 {
+		r.AchievedQPS = float64(total) / d.Seconds()
+	}
+	r.latencies = nil
+}
+
+func distribution(l []time.Duration) Latency {
+	if len(l) == 0 {
+		return Latency{}
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+
+	var sum time.Duration
+	for _, d := range l {
+		sum += d
+	}
+	return Latency{
+		Mean: sum / time.Duration(len(l)),
+		P50:  percentile(l, 50),
+		P90:  percentile(l, 90),
+		P99:  percentile(l, 99),
+		P999: percentile(l, 99.9),
+		Max:  l[len(l)-1],
+	}
+}
+
+// percentile returns the p-th percentile of sorted latencies, using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// ReadReport reads a report from a JSON file.
+func ReadReport(path string) (*Report, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err = json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("unable to parse report %s: %v", path, err)
+	}
+	return r, nil
+}
+
+// WriteFile writes the report to a JSON file.
+func (r *Report) WriteFile(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// Write writes the report as a table.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if r.Label != "" {
+		fmt.Fprintf(tw, "Label:\t%s\n", r.Label)
+	}
+	fmt.Fprintf(tw, "Duration:\t%v\n", r.Duration)
+	fmt.Fprintf(tw, "QPS:\t%.1f (target %.1f)\n\n", r.AchievedQPS, r.TargetQPS)
+
+	fmt.Fprintln(tw, "KIND\tREQUESTS\tERRORS\tDENIED\tDROPPED\tMEAN\tP50\tP90\tP99\tP99.9\tMAX")
+	for _, kind := range sortedKinds(r) {
+		k := r.Kinds[kind]
+		l := k.Latency
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%v\t%v\t%v\t%v\t%v\t%v\n", kind, k.Requests, k.Errors, k.Denied,
+			k.Dropped, l.Mean, l.P50, l.P90, l.P99, l.P999, l.Max)
+	}
+
+	for _, kind := range sortedKinds(r) {
+		codes := r.Kinds[kind].ErrorCodes
+		if len(codes) == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "\n%s errors:\n", kind)
+		for _, code := range sortedKeys(codes) {
+			fmt.Fprintf(tw, "  %s\t%d\n", code, codes[code])
+		}
+	}
+
+	for _, p := range r.Profiles {
+		fmt.Fprintf(tw, "\nProfile:\t%s", p)
+	}
+	if len(r.Profiles) > 0 {
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// Compare writes the differences between a base report and another one as a table.
+func Compare(w io.Writer, base, other *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "\t%s\t%s\tDELTA\n", labelOf(base, "base"), labelOf(other, "new"))
+	fmt.Fprintf(tw, "qps\t%.1f\t%.1f\t%s\n", base.AchievedQPS, other.AchievedQPS,
+		delta(base.AchievedQPS, other.AchievedQPS))
+
+	kinds := map[string]bool{}
+	for k := range base.Kinds {
+		kinds[k] = true
+	}
+	for k := range other.Kinds {
+		kinds[k] = true
+	}
+	names := make([]string, 0, len(kinds))
+	for k := range kinds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, kind := range names {
+		b, o := base.Kinds[kind], other.Kinds[kind]
+		if b == nil {
+			b = &KindReport{}
+		}
+		if o == nil {
+			o = &KindReport{}
+		}
+
+		fmt.Fprintf(tw, "%s error rate\t%.3f%%\t%.3f%%\t\n", kind, errorRate(b), errorRate(o))
+		for _, l := range []struct {
+			name string
+			b, o time.Duration
+		}{
+			{"mean", b.Latency.Mean, o.Latency.Mean},
+			{"p50", b.Latency.P50, o.Latency.P50},
+			{"p90", b.Latency.P90, o.Latency.P90},
+			{"p99", b.Latency.P99, o.Latency.P99},
+			{"p99.9", b.Latency.P999, o.Latency.P999},
+			{"max", b.Latency.Max, o.Latency.Max},
+		} {
+			fmt.Fprintf(tw, "%s %s\t%v\t%v\t%s\n", kind, l.name, l.b, l.o, delta(float64(l.b), float64(l.o)))
+		}
+	}
+	return tw.Flush()
+}
+
+func labelOf(r *Report, def string) string {
+	if r.Label != "" {
+		return r.Label
+	}
+	return def
+}
+
+// errorRate returns the percentage of the requests of a kind that failed, were denied or were dropped.
+func errorRate(k *KindReport) float64 {
+	total := k.Requests + k.Dropped
+	if total == 0 {
+		return 0
+	}
+	return float64(k.Errors+k.Denied+k.Dropped) * 100 / float64(total)
+}
+
+func delta(b, o float64) string {
+	if b == 0 {
+		return "~"
+	}
+	return fmt.Sprintf("%+.1f%%", (o-b)*100/b)
+}
+
+func sortedKinds(r *Report) []string {
+	kinds := make([]string, 0, len(r.Kinds))
+	for k := range r.Kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestDistribution(t *testing.T) {
+	var l []time.Duration
+	for i := 1000; i > 0; i-- {
+		l = append(l, time.Duration(i)*time.Millisecond)
+	}
+
+	got := distribution(l)
+	want := Latency{
+		Mean: 500500 * time.Microsecond,
+		P50:  500 * time.Millisecond,
+		P90:  900 * time.Millisecond,
+		P99:  990 * time.Millisecond,
+		P999: 999 * time.Millisecond,
+		Max:  1000 * time.Millisecond,
+	}
+	if got != want {
+		t.Fatalf("distribution() => got %+v, want %+v", got, want)
+	}
+
+	if got = distribution(nil); got != (Latency{}) {
+		t.Fatalf("distribution(nil) => got %+v", got)
+	}
+	if got = distribution([]time.Duration{time.Second}); got.P50 != time.Second || got.P999 != time.Second {
+		t.Fatalf("distribution() => got %+v", got)
+	}
+}
+
+func newTestReport() *Report {
+	r := newReport(&Profile{QPS: 10})
+	r.Label = "base"
+	r.add(result{kind: CheckKind, latency: time.Millisecond, outcome: succeeded})
+	r.add(result{kind: CheckKind, latency: 3 * time.Millisecond, outcome: denied, code: codes.PermissionDenied})
+	r.add(result{kind: ReportKind, latency: 2 * time.Millisecond, outcome: failed, code: codes.Unavailable})
+	r.add(result{kind: ReportKind, outcome: dropped})
+	r.finish(time.Unix(1500000000, 0), time.Second)
+	return r
+}
+
+func TestReport(t *testing.T) {
+	r := newTestReport()
+
+	if r.AchievedQPS != 3 || r.latencies != nil {
+		t.Fatalf("finish() => got %+v", r)
+	}
+	want := map[string]*KindReport{
+		CheckKind: {
+			Requests:   2,
+			Denied:     1,
+			ErrorCodes: map[string]int64{"PermissionDenied": 1},
+			Latency: Latency{Mean: 2 * time.Millisecond, P50: time.Millisecond, P90: 3 * time.Millisecond,
+				P99: 3 * time.Millisecond, P999: 3 * time.Millisecond, Max: 3 * time.Millisecond},
+		},
+		ReportKind: {
+			Requests:   1,
+			Errors:     1,
+			Dropped:    1,
+			ErrorCodes: map[string]int64{"Unavailable": 1},
+			Latency: Latency{Mean: 2 * time.Millisecond, P50: 2 * time.Millisecond, P90: 2 * time.Millisecond,
+				P99: 2 * time.Millisecond, P999: 2 * time.Millisecond, Max: 2 * time.Millisecond},
+		},
+	}
+	if !reflect.DeepEqual(r.Kinds, want) {
+		t.Fatalf("report => got %+v, want %+v", r.Kinds, want)
+	}
+
+	var b bytes.Buffer
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"Label:", "base", "3.0 (target 10.0)", "PermissionDenied", "Unavailable", "P99.9"} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("Write() => got\n%s\nwant it to contain '%s'", b.String(), s)
+		}
+	}
+}
+
+func TestReport_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loadgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	r := newTestReport()
+	path := filepath.Join(dir, "report.json")
+	if err = r.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() => unexpected error: %v", err)
+	}
+
+	got, err := ReadReport(path)
+	if err != nil {
+		t.Fatalf("ReadReport() => unexpected error: %v", err)
+	}
+	if !got.Start.Equal(r.Start) || !reflect.DeepEqual(got.Kinds, r.Kinds) || got.Label != r.Label {
+		t.Fatalf("ReadReport() => got %+v, want %+v", got, r)
+	}
+
+	if err = ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadReport(path); err == nil {
+		t.Fatal("ReadReport() => got no error for an invalid report")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := newTestReport()
+	other := newTestReport()
+	other.Label = "new"
+	other.AchievedQPS = 6
+	other.Kinds[CheckKind].Latency.P99 = 6 * time.Millisecond
+	other.Kinds[QuotaKind] = &KindReport{Requests: 1}
+
+	var b bytes.Buffer
+	if err := Compare(&b, base, other); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{"base", "new", "+100.0%", "check p99", "3ms", "6ms", "quota p50", "~",
+		"report error rate", "100.000%"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Compare() => got\n%s\nwant it to contain '%s'", out, s)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	mixerpb "istio.io/api/mixer/v1"
+)
+
+// outcome of a request.
+type outcome int
+
+const (
+	succeeded outcome = iota
+	denied
+	failed
+	dropped
+)
+
+// result of a request that is sent during the measurement.
+type result struct {
+	kind    string
+	latency time.Duration
+	outcome outcome
+	code    codes.Code
+}
+
+// Run applies the load of the profile on Mixer, spreading the requests over the given clients, and reports the
+// results of the requests that are scheduled after the warmup. Run returns early with the results so far if the
+// context is done.
+func Run(ctx context.Context, p *Profile, clients []mixerpb.MixerClient) (*Report, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("no clients")
+	}
+
+	seed := p.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(seed))
+	g, err := newGenerator(p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := newReport(p)
+	results := make(chan result, p.MaxInFlight)
+	collected := make(chan struct{})
+	go func() {
+		for res := range results {
+			rep.add(res)
+		}
+		close(collected)
+	}()
+
+	inFlight := make(chan struct{}, p.MaxInFlight)
+	var wg sync.WaitGroup
+
+	interval := time.Duration(float64(time.Second) / p.QPS)
+	start := time.Now()
+	measureFrom := start.Add(p.Warmup.Duration)
+	end := measureFrom.Add(p.Duration.Duration)
+
+	var dedup int64
+loop:
+	for i := int64(0); ; i++ {
+		// The schedule is fixed up front: a slow response does not delay the requests that follow it.
+		intended := start.Add(time.Duration(i) * interval)
+		if !intended.Before(end) {
+			break
+		}
+		if d := time.Until(intended); d > 0 {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-time.After(d):
+			}
+		} else if ctx.Err() != nil {
+			break
+		}
+
+		kind, req := g.next(r)
+		measured := !intended.Before(measureFrom)
+
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			if measured {
+				results <- result{kind: kind, outcome: dropped}
+			}
+			continue
+		}
+
+		if kind == QuotaKind {
+			// Quota allocations are deduplicated by Mixer, so each of them needs a unique id.
+			cr := *req.(*mixerpb.CheckRequest)
+			dedup++
+			cr.DeduplicationId = fmt.Sprintf("mixperf-%d-%d", seed, dedup)
+			req = &cr
+		}
+
+		wg.Add(1)
+		go func(client mixerpb.MixerClient) {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+			o, code := send(ctx, client, req)
+			if measured {
+				results <- result{kind: kind, latency: time.Since(intended), outcome: o, code: code}
+			}
+		}(clients[i%int64(len(clients))])
+	}
+
+	wg.Wait()
+	close(results)
+	<-collected
+
+	// The measurement covers the schedule of the measured requests, unless the run was cut short.
+	stop := end
+	if now := time.Now(); ctx.Err() != nil && now.Before(end) {
+		stop = now
+	}
+	rep.finish(measureFrom, stop.Sub(measureFrom))
+	return rep, nil
+}
+
+// send sends a request, and returns its outcome.
+func send(ctx context.Context, client mixerpb.MixerClient, req interface{}) (outcome, codes.Code) {
+	switch req := req.(type) {
+	case *mixerpb.ReportRequest:
+		if _, err := client.Report(ctx, req); err != nil {
+			return failed, errorCode(err)
+		}
+		return succeeded, codes.OK
+
+	case *mixerpb.CheckRequest:
+		resp, err := client.Check(ctx, req)
+		if err != nil {
+			return failed, errorCode(err)
+		}
+		if resp.Precondition.Status.Code != int32(codes.OK) {
+			return denied, codes.Code(resp.Precondition.Status.Code)
+		}
+		for name := range req.Quotas {
+			if resp.Quotas[name].GrantedAmount == 0 {
+				return denied, codes.ResourceExhausted
+			}
+		}
+		return succeeded, codes.OK
+	}
+
+	return failed, codes.Internal
+}
+
+// errorCode returns the gRPC status code of an error.
+func errorCode(err error) codes.Code {
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	mixerpb "istio.io/api/mixer/v1"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+)
+
+// fakeClient is a Mixer client that denies the checks with the "deny" attribute, fails the reports, and grants no
+// quota.
+type fakeClient struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	checks  int
+	reports int
+	dedup   map[string]bool
+}
+
+func (c *fakeClient) Check(ctx context.Context, in *mixerpb.CheckRequest, opts ...grpc.CallOption) (*mixerpb.CheckResponse, error) {
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.checks++
+	if in.DeduplicationId != "" {
+		if c.dedup == nil {
+			c.dedup = map[string]bool{}
+		}
+		c.dedup[in.DeduplicationId] = true
+	}
+	c.mu.Unlock()
+
+	resp := &mixerpb.CheckResponse{}
+	for _, w := range in.Attributes.Words {
+		if w == "deny" {
+			resp.Precondition.Status = rpc.Status{Code: int32(rpc.PERMISSION_DENIED)}
+		}
+	}
+	if len(in.Quotas) > 0 {
+		resp.Quotas = map[string]mixerpb.CheckResponse_QuotaResult{}
+		for name := range in.Quotas {
+			resp.Quotas[name] = mixerpb.CheckResponse_QuotaResult{GrantedAmount: 0}
+		}
+	}
+	return resp, nil
+}
+
+func (c *fakeClient) Report(ctx context.Context, in *mixerpb.ReportRequest, opts ...grpc.CallOption) (*mixerpb.ReportResponse, error) {
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.reports++
+	c.mu.Unlock()
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+func TestRun(t *testing.T) {
+	p, err := ParseProfile([]byte(`
+qps: 1000
+duration: 500ms
+warmup: 100ms
+seed: 1
+mix: {check: 1, report: 1, quota: 1}
+quotas: {requestcount: {amount: 1}}
+attributes:
+  source.name:
+    values: [allow, deny]
+    weights: [3, 1]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients := []*fakeClient{{}, {}}
+	rep, err := Run(context.Background(), p, []mixerpb.MixerClient{clients[0], clients[1]})
+	if err != nil {
+		t.Fatalf("Run() => unexpected error: %v", err)
+	}
+
+	var total, sent int64
+	for _, c := range clients {
+		if c.checks+c.reports == 0 {
+			t.Errorf("Run() => a client got no requests")
+		}
+		sent += int64(c.checks + c.reports)
+	}
+	for _, kind := range []string{CheckKind, ReportKind, QuotaKind} {
+		k := rep.Kinds[kind]
+		if k == nil || k.Requests == 0 {
+			t.Fatalf("Run() => got no %s requests: %+v", kind, rep.Kinds)
+		}
+		if k.Dropped != 0 {
+			t.Errorf("Run() => got %d dropped %s requests", k.Dropped, kind)
+		}
+		if k.Latency.P50 <= 0 || k.Latency.P50 > k.Latency.Max {
+			t.Errorf("Run() => got %s latency %+v", kind, k.Latency)
+		}
+		total += k.Requests
+	}
+
+	// The warmup requests are sent, but not reported.
+	if total < 400 || total > 500 || sent <= total {
+		t.Errorf("Run() => got %d reported requests out of %d, want about 500 out of 600", total, sent)
+	}
+	if rep.AchievedQPS < 500 || rep.TargetQPS != 1000 || rep.Duration != 500*time.Millisecond {
+		t.Errorf("Run() => got %+v", rep)
+	}
+
+	if c := rep.Kinds[CheckKind]; c.Errors != 0 || c.Denied == 0 || c.Denied == c.Requests ||
+		c.ErrorCodes[codes.PermissionDenied.String()] != c.Denied {
+		t.Errorf("Run() => got checks %+v, want some of them denied", c)
+	}
+	if r := rep.Kinds[ReportKind]; r.Errors != r.Requests || r.ErrorCodes[codes.Unavailable.String()] != r.Requests {
+		t.Errorf("Run() => got reports %+v, want all of them failed", r)
+	}
+	if q := rep.Kinds[QuotaKind]; q.Denied != q.Requests {
+		t.Errorf("Run() => got quotas %+v, want all of them denied", q)
+	}
+
+	dedup := len(clients[0].dedup) + len(clients[1].dedup)
+	if int64(dedup) < rep.Kinds[QuotaKind].Requests {
+		t.Errorf("Run() => got %d distinct deduplication ids for %d quota requests", dedup, rep.Kinds[QuotaKind].Requests)
+	}
+}
+
+func TestRun_Dropped(t *testing.T) {
+	p, err := ParseProfile([]byte(`
+qps: 200
+duration: 200ms
+maxInFlight: 1
+mix: {check: 1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := Run(context.Background(), p, []mixerpb.MixerClient{&fakeClient{delay: 50 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("Run() => unexpected error: %v", err)
+	}
+
+	c := rep.Kinds[CheckKind]
+	if c.Requests == 0 || c.Dropped == 0 {
+		t.Fatalf("Run() => got %+v, want requests to be dropped", c)
+	}
+	// The latency includes the time the requests were delayed by the slow server.
+	if c.Latency.Max < 50*time.Millisecond {
+		t.Fatalf("Run() => got latency %+v, want at least 50ms", c.Latency)
+	}
+}
+
+func TestRun_Cancel(t *testing.T) {
+	p, err := ParseProfile([]byte("qps: 100\nduration: 1h\nmix: {check: 1}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err = Run(ctx, p, []mixerpb.MixerClient{&fakeClient{}}); err != nil {
+		t.Fatalf("Run() => unexpected error: %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("Run() => did not return when the context was done")
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	if _, err := Run(context.Background(), &Profile{}, []mixerpb.MixerClient{&fakeClient{}}); err == nil {
+		t.Error("Run() => got no error for an invalid profile")
+	}
+
+	p, err := ParseProfile([]byte("qps: 100\nduration: 1s\nmix: {check: 1}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Run(context.Background(), p, nil); err == nil {
+		t.Error("Run() => got no error without clients")
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// valueGenerator generates a value of an attribute. now is the time the request is generated.
+type valueGenerator func(r *rand.Rand, now time.Time) interface{}
+
+// weighted picks indexes at random, according to their relative weights.
+type weighted struct {
+	// cumulative weights.
+	cumulative []float64
+}
+
+func newWeighted(weights []float64) (*weighted, error) {
+	w := &weighted{cumulative: make([]float64, len(weights))}
+	total := 0.0
+	for i, v := range weights {
+		if v < 0 {
+			return nil, fmt.Errorf("weights must be >= 0, got %v", v)
+		}
+		total += v
+		w.cumulative[i] = total
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("weights must have a positive sum")
+	}
+	return w, nil
+}
+
+func (w *weighted) pick(r *rand.Rand) int {
+	x := r.Float64() * w.cumulative[len(w.cumulative)-1]
+	i := sort.Search(len(w.cumulative), func(i int) bool { return w.cumulative[i] > x })
+	if i == len(w.cumulative) {
+		i--
+	}
+	return i
+}
+
+func newValueGenerator(a *Attribute) (valueGenerator, error) {
+	if a == nil {
+		return nil, fmt.Errorf("values or min/max are required")
+	}
+
+	t := a.Type
+	if t == "" {
+		t = stringType
+	}
+
+	hasRange := a.Min != nil || a.Max != nil
+	switch {
+	case hasRange && len(a.Values) > 0:
+		return nil, fmt.Errorf("values and min/max are mutually exclusive")
+	case hasRange:
+		return newRangeGenerator(t, a.Min, a.Max)
+	case len(a.Values) == 0 && t == timestampType:
+		return func(_ *rand.Rand, now time.Time) interface{} { return now }, nil
+	case len(a.Values) == 0:
+		return nil, fmt.Errorf("values or min/max are required")
+	}
+
+	values := make([]interface{}, len(a.Values))
+	for i, v := range a.Values {
+		var err error
+		if values[i], err = convert(t, v); err != nil {
+			return nil, err
+		}
+	}
+
+	weights := a.Weights
+	if weights == nil {
+		weights = make([]float64, len(values))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(values) {
+		return nil, fmt.Errorf("got %d weights for %d values", len(weights), len(values))
+	}
+	w, err := newWeighted(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *rand.Rand, _ time.Time) interface{} {
+		return values[w.pick(r)]
+	}, nil
+}
+
+func newRangeGenerator(t string, min, max interface{}) (valueGenerator, error) {
+	if min == nil || max == nil {
+		return nil, fmt.Errorf("both min and max are required")
+	}
+
+	switch t {
+	case int64Type, durationType:
+		lo, err := convert(t, min)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := convert(t, max)
+		if err != nil {
+			return nil, err
+		}
+
+		l, h := toInt64(lo), toInt64(hi)
+		if l > h {
+			return nil, fmt.Errorf("min %v is greater than max %v", min, max)
+		}
+		return func(r *rand.Rand, _ time.Time) interface{} {
+			v := l + r.Int63n(h-l+1)
+			if t == durationType {
+				return time.Duration(v)
+			}
+			return v
+		}, nil
+
+	case doubleType:
+		lo, err := convert(t, min)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := convert(t, max)
+		if err != nil {
+			return nil, err
+		}
+
+		l, h := lo.(float64), hi.(float64)
+		if l > h {
+			return nil, fmt.Errorf("min %v is greater than max %v", min, max)
+		}
+		return func(r *rand.Rand, _ time.Time) interface{} {
+			return l + r.Float64()*(h-l)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("min/max are not supported for %s attributes", t)
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	if d, ok := v.(time.Duration); ok {
+		return int64(d)
+	}
+	return v.(int64)
+}
+
+// convert converts a value that is decoded from the profile to the Go type of the attribute type.
+func convert(t string, v interface{}) (interface{}, error) {
+	switch t {
+	case stringType:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+
+	case int64Type:
+		if f, ok := v.(float64); ok && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+
+	case doubleType:
+		if f, ok := v.(float64); ok {
+			return f, nil
+		}
+
+	case boolType:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+
+	case timestampType:
+		if s, ok := v.(string); ok {
+			ts, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %v", err)
+			}
+			return ts, nil
+		}
+
+	case durationType:
+		if s, ok := v.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration: %v", err)
+			}
+			return d, nil
+		}
+
+	case bytesType:
+		if s, ok := v.(string); ok {
+			return []byte(s), nil
+		}
+
+	case stringMapType:
+		if m, ok := v.(map[string]interface{}); ok {
+			sm := make(map[string]string, len(m))
+			for k, mv := range m {
+				s, ok := mv.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid value of key '%s' of string_map: %v", k, mv)
+				}
+				sm[k] = s
+			}
+			return sm, nil
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown attribute type: '%s'", t)
+	}
+
+	return nil, fmt.Errorf("invalid %s value: %v", t, v)
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeighted(t *testing.T) {
+	w, err := newWeighted([]float64{1, 0, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	counts := make([]int, 3)
+	for i := 0; i < 10000; i++ {
+		counts[w.pick(r)]++
+	}
+	if counts[1] != 0 || counts[0] < 2000 || counts[0] > 3000 || counts[2] < 7000 || counts[2] > 8000 {
+		t.Fatalf("pick() => got counts %v, want about [2500 0 7500]", counts)
+	}
+
+	for _, weights := range [][]float64{{}, {0, 0}, {1, -1}} {
+		if _, err = newWeighted(weights); err == nil {
+			t.Errorf("newWeighted(%v) => got no error", weights)
+		}
+	}
+}
+
+func TestValueGenerator(t *testing.T) {
+	now := time.Now()
+	ts, _ := time.Parse(time.RFC3339, "2018-01-02T03:04:05Z")
+
+	for _, tc := range []struct {
+		name string
+		attr *Attribute
+		want []interface{}
+	}{
+		{"string", &Attribute{Values: []interface{}{"a"}}, []interface{}{"a"}},
+		{"int64", &Attribute{Type: int64Type, Values: []interface{}{float64(42)}}, []interface{}{int64(42)}},
+		{"double", &Attribute{Type: doubleType, Values: []interface{}{1.5}}, []interface{}{1.5}},
+		{"bool", &Attribute{Type: boolType, Values: []interface{}{true}}, []interface{}{true}},
+		{"timestamp", &Attribute{Type: timestampType, Values: []interface{}{"2018-01-02T03:04:05Z"}}, []interface{}{ts}},
+		{"now", &Attribute{Type: timestampType}, []interface{}{now}},
+		{"duration", &Attribute{Type: durationType, Values: []interface{}{"1s"}}, []interface{}{time.Second}},
+		{"bytes", &Attribute{Type: bytesType, Values: []interface{}{"ab"}}, []interface{}{[]byte("ab")}},
+		{"string_map", &Attribute{Type: stringMapType, Values: []interface{}{map[string]interface{}{"k": "v"}}},
+			[]interface{}{map[string]string{"k": "v"}}},
+		{"weights", &Attribute{Values: []interface{}{"a", "b"}, Weights: []float64{0, 1}}, []interface{}{"b"}},
+		{"int64 range", &Attribute{Type: int64Type, Min: float64(3), Max: float64(3)}, []interface{}{int64(3)}},
+		{"duration range", &Attribute{Type: durationType, Min: "1s", Max: "1s"}, []interface{}{time.Second}},
+		{"double range", &Attribute{Type: doubleType, Min: 1.5, Max: 1.5}, []interface{}{1.5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := newValueGenerator(tc.attr)
+			if err != nil {
+				t.Fatalf("newValueGenerator() => unexpected error: %v", err)
+			}
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 10; i++ {
+				got := g(r, now)
+				if !reflect.DeepEqual(got, tc.want[0]) {
+					t.Fatalf("generator => got %v (%T), want %v (%T)", got, got, tc.want[0], tc.want[0])
+				}
+			}
+		})
+	}
+}
+
+func TestValueGenerator_Range(t *testing.T) {
+	g, err := newValueGenerator(&Attribute{Type: int64Type, Min: float64(10), Max: float64(20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	seen := map[int64]bool{}
+	for i := 0; i < 1000; i++ {
+		v := g(r, time.Time{}).(int64)
+		if v < 10 || v > 20 {
+			t.Fatalf("generator => got %d, want a value in [10, 20]", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != 11 {
+		t.Fatalf("generator => got %d distinct values, want 11", len(seen))
+	}
+}
+
+func TestValueGenerator_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		attr *Attribute
+		err  string
+	}{
+		{"nil", nil, "values or min/max are required"},
+		{"no values", &Attribute{}, "values or min/max are required"},
+		{"values and range", &Attribute{Type: int64Type, Values: []interface{}{float64(1)}, Min: float64(1), Max: float64(2)},
+			"mutually exclusive"},
+		{"min only", &Attribute{Type: int64Type, Min: float64(1)}, "both min and max are required"},
+		{"inverted range", &Attribute{Type: int64Type, Min: float64(2), Max: float64(1)}, "is greater than max"},
+		{"inverted double range", &Attribute{Type: doubleType, Min: 2.0, Max: 1.0}, "is greater than max"},
+		{"string range", &Attribute{Min: "a", Max: "b"}, "not supported for string attributes"},
+		{"unknown type", &Attribute{Type: "foo", Values: []interface{}{"a"}}, "unknown attribute type: 'foo'"},
+		{"fractional int64", &Attribute{Type: int64Type, Values: []interface{}{1.5}}, "invalid int64 value"},
+		{"invalid timestamp", &Attribute{Type: timestampType, Values: []interface{}{"now"}}, "invalid timestamp"},
+		{"invalid duration", &Attribute{Type: durationType, Values: []interface{}{"1 sec"}}, "invalid duration"},
+		{"invalid string_map", &Attribute{Type: stringMapType, Values: []interface{}{map[string]interface{}{"k": 1.0}}},
+			"invalid value of key 'k'"},
+		{"weights mismatch", &Attribute{Values: []interface{}{"a"}, Weights: []float64{1, 2}}, "got 2 weights for 1 values"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newValueGenerator(tc.attr)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("newValueGenerator() => got %v, want error containing '%s'", err, tc.err)
+			}
+		})
+	}
+}
@@ -59,6 +59,26 @@ type Dispatcher interface {
 		qma *QuotaMethodArgs) (*adapter.QuotaResult, error)
 }
 
+// BatchDispatcher is implemented by Dispatchers that can accumulate the Report instances of multiple
+// attribute bags, and dispatch them to each adapter in a single call.
+type BatchDispatcher interface {
+	// GetReporter returns a new Reporter for the handling of a single Report API call.
+	GetReporter(ctx context.Context) Reporter
+}
+
+// Reporter accumulates Report instances and dispatches them to adapters in batches.
+type Reporter interface {
+	// Report creates the instances for the given bag, and stages them for dispatch. The instances may be
+	// dispatched before Flush is called, if the amount of staged instances grows too large.
+	Report(requestBag attribute.Bag) error
+
+	// Flush dispatches the staged instances to the adapters.
+	Flush() error
+
+	// Done releases the resources held by the Reporter. Any instances that were not flushed are discarded.
+	Done()
+}
+
 // Resolver represents the current snapshot of the configuration database
 // and associated, initialized handlers.
 type Resolver interface {
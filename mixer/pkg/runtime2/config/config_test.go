@@ -15,9 +15,12 @@
 package config
 
 import (
+	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/golang/protobuf/ptypes/wrappers"
 
 	configpb "istio.io/api/mixer/v1/config"
@@ -26,6 +29,7 @@ import (
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/mixer/pkg/template"
+	dynamicpb "istio.io/istio/mixer/pkg/template/dynamic/config"
 	"istio.io/istio/pkg/log"
 )
 
@@ -694,6 +698,202 @@ Attributes:
 `,
 	},
 
+	{
+		Name: "dry-run rule and handler",
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "handler1",
+					Namespace: "ns",
+					Kind:      "adapter1",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Metadata: store.ResourceMeta{
+						Labels: map[string]string{DryRunLabel: "true"},
+					},
+					Spec: testParam1,
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "instance1",
+					Namespace: "ns",
+					Kind:      "check",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: testParam2,
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "rule1",
+					Namespace: "ns",
+					Kind:      "rule",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Metadata: store.ResourceMeta{
+						Labels: map[string]string{DryRunLabel: "true"},
+					},
+					Spec: &configpb.Rule{
+						Actions: []*configpb.Action{
+							{
+								Handler: "handler1.adapter1",
+								Instances: []string{
+									"instance1.check.ns",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: apa
+  Name: check
+  Name: quota
+  Name: report
+Adapters:
+  Name: adapter1
+  Name: adapter2
+Handlers:
+  Name:    handler1.adapter1.ns
+  Adapter: adapter1
+  Params:  value:"param1"
+  DryRun:  true
+Instances:
+  Name:     instance1.check.ns
+  Template: check
+  Params:   value:"param2"
+Rules:
+  Name:      rule1.rule.ns
+  Namespace: ns
+  Match:
+  ResourceType: ResourceType:{HTTP / Check Report Preprocess}
+  DryRun: true
+  Actions:
+    Handler: handler1.adapter1.ns
+    Instances:
+      Name: instance1.check.ns
+Attributes:
+  template.attr: BOOL
+`,
+	},
+
+	{
+		Name: "handler limits and rule fail policy",
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "handler1",
+					Namespace: "ns",
+					Kind:      "adapter1",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Metadata: store.ResourceMeta{
+						Labels: map[string]string{
+							TimeoutLabel:                "250ms",
+							MaxConcurrencyLabel:         "10",
+							CircuitBreakerFailuresLabel: "5",
+						},
+					},
+					Spec: testParam1,
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "handler2",
+					Namespace: "ns",
+					Kind:      "adapter2",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Metadata: store.ResourceMeta{
+						Labels: map[string]string{
+							TimeoutLabel:        "invalid",
+							MaxConcurrencyLabel: "-1",
+						},
+					},
+					Spec: testParam2,
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "instance1",
+					Namespace: "ns",
+					Kind:      "check",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: testParam2,
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "rule1",
+					Namespace: "ns",
+					Kind:      "rule",
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Metadata: store.ResourceMeta{
+						Labels: map[string]string{FailPolicyLabel: FailOpen},
+					},
+					Spec: &configpb.Rule{
+						Actions: []*configpb.Action{
+							{
+								Handler: "handler1.adapter1",
+								Instances: []string{
+									"instance1.check.ns",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: apa
+  Name: check
+  Name: quota
+  Name: report
+Adapters:
+  Name: adapter1
+  Name: adapter2
+Handlers:
+  Name:    handler1.adapter1.ns
+  Adapter: adapter1
+  Params:  value:"param1"
+  Limits:  {Timeout:250ms MaxConcurrency:10 CircuitBreakerFailures:5 CircuitBreakerReset:30s}
+  Name:    handler2.adapter2.ns
+  Adapter: adapter2
+  Params:  value:"param2"
+Instances:
+  Name:     instance1.check.ns
+  Template: check
+  Params:   value:"param2"
+Rules:
+  Name:      rule1.rule.ns
+  Namespace: ns
+  Match:
+  ResourceType: ResourceType:{HTTP / Check Report Preprocess}
+  FailOpen: true
+  Actions:
+    Handler: handler1.adapter1.ns
+    Instances:
+      Name: instance1.check.ns
+Attributes:
+  template.attr: BOOL
+`,
+	},
+
 	{
 		Name: "multiple rules with multiple actions referencing multiple instances",
 		Events1: []*store.Event{
@@ -1352,6 +1552,234 @@ Rules:
       Name: instance1.check.ns
 Attributes:
   template.attr: BOOL
+`,
+	},
+	{
+		Name: "instance kind for a compiled template",
+		T:    paramTemplates,
+		A:    noAdapters,
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "i1",
+					Namespace: "ns",
+					Kind:      InstanceKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Instance{
+						Template: "check",
+						Params: &types.Struct{Fields: map[string]*types.Value{
+							"match": {Kind: &types.Value_StringValue{StringValue: "foo"}},
+						}},
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: check
+Adapters:
+Handlers:
+Instances:
+  Name:     i1.instance.ns
+  Template: check
+  Params:   match:"foo"
+Rules:
+Attributes:
+`,
+	},
+
+	{
+		Name: "instance kind with invalid params is omitted",
+		T:    paramTemplates,
+		A:    noAdapters,
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "i1",
+					Namespace: "ns",
+					Kind:      InstanceKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Instance{
+						Template: "check",
+						Params: &types.Struct{Fields: map[string]*types.Value{
+							"unknown": {Kind: &types.Value_StringValue{StringValue: "foo"}},
+						}},
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: check
+Adapters:
+Handlers:
+Instances:
+Rules:
+Attributes:
+`,
+	},
+
+	{
+		Name: "instance kind referencing missing template is omitted",
+		T:    paramTemplates,
+		A:    noAdapters,
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "i1",
+					Namespace: "ns",
+					Kind:      InstanceKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Instance{
+						Template: "check1",
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: check
+Adapters:
+Handlers:
+Instances:
+Rules:
+Attributes:
+`,
+	},
+
+	{
+		Name: "template loaded at runtime",
+		T:    noTemplates,
+		A:    noAdapters,
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "metric",
+					Namespace: "ns",
+					Kind:      TemplateKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Template{DescriptorSet: metricDescriptorSet},
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "i1",
+					Namespace: "ns",
+					Kind:      InstanceKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Instance{
+						Template: "metric",
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: metric
+Adapters:
+Handlers:
+Instances:
+  Name:     i1.instance.ns
+  Template: metric
+  Params:
+Rules:
+Attributes:
+`,
+	},
+
+	{
+		Name: "template that cannot be loaded is omitted",
+		T:    noTemplates,
+		A:    noAdapters,
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "t1",
+					Namespace: "ns",
+					Kind:      TemplateKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Template{DescriptorSet: []byte("invalid")},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+Adapters:
+Handlers:
+Instances:
+Rules:
+Attributes:
+`,
+	},
+
+	{
+		Name: "compiled templates take precedence over loaded templates",
+		T: map[string]*template.Info{
+			"metric": {
+				Name:    "metric",
+				Variety: istio_mixer_v1_template.TEMPLATE_VARIETY_REPORT,
+				CtrCfg:  &configpb.Rule{},
+			},
+		},
+		A: noAdapters,
+		Events1: []*store.Event{
+			{
+				Key: store.Key{
+					Name:      "metric",
+					Namespace: "ns",
+					Kind:      TemplateKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Template{DescriptorSet: metricDescriptorSet},
+				},
+			},
+			{
+				Key: store.Key{
+					Name:      "i1",
+					Namespace: "ns",
+					Kind:      InstanceKind,
+				},
+				Type: store.Update,
+				Value: &store.Resource{
+					Spec: &dynamicpb.Instance{
+						Template: "metric",
+						Params: &types.Struct{Fields: map[string]*types.Value{
+							"match": {Kind: &types.Value_StringValue{StringValue: "foo"}},
+						}},
+					},
+				},
+			},
+		},
+		E: `
+ID: 1
+Templates:
+  Name: metric
+Adapters:
+Handlers:
+Instances:
+  Name:     i1.instance.ns
+  Template: metric
+  Params:   match:"foo"
+Rules:
+Attributes:
 `,
 	},
 }
@@ -1397,6 +1825,18 @@ var stdTemplates = map[string]*template.Info{
 	},
 }
 
+// paramTemplates have instance params, which lets instance configs of the instance kind refer to them.
+var paramTemplates = map[string]*template.Info{
+	"check": {
+		Name:    "check",
+		Variety: istio_mixer_v1_template.TEMPLATE_VARIETY_CHECK,
+		CtrCfg:  &configpb.Rule{},
+	},
+}
+
+var metricDescriptorSet = readFile("../../../template/metric/template_proto.descriptor_set")
+var listentryDescriptorSet = readFile("../../../template/listentry/template_proto.descriptor_set")
+
 var testParam1 = &wrappers.StringValue{Value: "param1"}
 var testParam2 = &wrappers.StringValue{Value: "param2"}
 var testParam3 = &wrappers.StringValue{Value: "param3"}
@@ -1412,6 +1852,267 @@ func TestConfigs(t *testing.T) {
 	runTests(t)
 }
 
+func TestLoadedTemplatesAreReused(t *testing.T) {
+	e := NewEphemeral(noTemplates, noAdapters)
+
+	key := store.Key{Name: "metric", Namespace: "ns", Kind: TemplateKind}
+	e.ApplyEvent(&store.Event{
+		Key:   key,
+		Type:  store.Update,
+		Value: &store.Resource{Spec: &dynamicpb.Template{DescriptorSet: metricDescriptorSet}},
+	})
+	s1 := e.BuildSnapshot()
+
+	e.ApplyEvent(&store.Event{
+		Key:   store.Key{Name: "i1", Namespace: "ns", Kind: InstanceKind},
+		Type:  store.Update,
+		Value: &store.Resource{Spec: &dynamicpb.Instance{Template: "metric"}},
+	})
+	s2 := e.BuildSnapshot()
+
+	if s1.Templates["metric"] == nil || s1.Templates["metric"] != s2.Templates["metric"] {
+		t.Fatalf("loaded template was not reused: %v != %v", s1.Templates["metric"], s2.Templates["metric"])
+	}
+	if s2.Instances["i1.instance.ns"].Template != s2.Templates["metric"] {
+		t.Fatalf("instance does not refer to the loaded template")
+	}
+
+	e.ApplyEvent(&store.Event{
+		Key:   key,
+		Type:  store.Update,
+		Value: &store.Resource{Spec: &dynamicpb.Template{DescriptorSet: listentryDescriptorSet}},
+	})
+	s3 := e.BuildSnapshot()
+
+	if _, found := s3.Templates["metric"]; found {
+		t.Fatalf("template was not reloaded after its descriptor set changed")
+	}
+	if _, found := s3.Templates["listentry"]; !found {
+		t.Fatalf("template was not reloaded after its descriptor set changed")
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	e := NewEphemeral(stdTemplates, stdAdapters)
+
+	e.ApplyEvent(&store.Event{
+		Key:  store.Key{Name: "rule1", Namespace: "ns", Kind: RulesKind},
+		Type: store.Update,
+		Value: &store.Resource{
+			Spec: &configpb.Rule{Actions: []*configpb.Action{{Handler: "handler1", Instances: []string{"instance1"}}}},
+		},
+	})
+	e.ApplyEvent(&store.Event{
+		Key:   store.Key{Name: "i1", Namespace: "ns", Kind: InstanceKind},
+		Type:  store.Update,
+		Value: &store.Resource{Spec: &dynamicpb.Instance{Template: "unknown"}},
+	})
+	s := e.BuildSnapshot()
+
+	var errs []string
+	for _, err := range s.Errors {
+		errs = append(errs, err.Error())
+	}
+	want := []string{
+		"Handler not found: handler='handler1.ns', action='rule1.rule.ns[0]'",
+		"No valid actions found in rule: rule1.rule.ns",
+		"Template not found: instance='i1.instance.ns', template='unknown'",
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Fatalf("Errors =>\ngot  %v\nwant %v", errs, want)
+	}
+
+	// The errors are not carried over to the next snapshot.
+	e.ApplyEvent(&store.Event{Key: store.Key{Name: "rule1", Namespace: "ns", Kind: RulesKind}, Type: store.Delete})
+	e.ApplyEvent(&store.Event{Key: store.Key{Name: "i1", Namespace: "ns", Kind: InstanceKind}, Type: store.Delete})
+	if s = e.BuildSnapshot(); len(s.Errors) != 0 {
+		t.Fatalf("Errors => got %v, want none", s.Errors)
+	}
+}
+
+func TestSnapshotErrors_InvalidLabels(t *testing.T) {
+	e := NewEphemeral(stdTemplates, stdAdapters)
+
+	e.ApplyEvent(&store.Event{
+		Key:  store.Key{Name: "handler1", Namespace: "ns", Kind: "adapter1"},
+		Type: store.Update,
+		Value: &store.Resource{
+			Metadata: store.ResourceMeta{
+				Labels: map[string]string{TimeoutLabel: "soon", MaxConcurrencyLabel: "-1"},
+			},
+			Spec: testParam1,
+		},
+	})
+	e.ApplyEvent(&store.Event{
+		Key:   store.Key{Name: "instance1", Namespace: "ns", Kind: "check"},
+		Type:  store.Update,
+		Value: &store.Resource{Spec: testParam2},
+	})
+	e.ApplyEvent(&store.Event{
+		Key:  store.Key{Name: "rule1", Namespace: "ns", Kind: RulesKind},
+		Type: store.Update,
+		Value: &store.Resource{
+			Metadata: store.ResourceMeta{Labels: map[string]string{FailPolicyLabel: "sometimes"}},
+			Spec: &configpb.Rule{
+				Actions: []*configpb.Action{{Handler: "handler1.adapter1", Instances: []string{"instance1.check.ns"}}},
+			},
+		},
+	})
+	s := e.BuildSnapshot()
+
+	var errs []string
+	for _, err := range s.Errors {
+		errs = append(errs, err.Error())
+	}
+	want := []string{
+		"Invalid duration label: name='handler1.adapter1.ns', label='istio-timeout', value='soon'",
+		"Invalid fail policy label: name='rule1.rule.ns', value='sometimes'",
+		"Invalid integer label: name='handler1.adapter1.ns', label='istio-max-concurrency', value='-1'",
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Fatalf("Errors =>\ngot  %v\nwant %v", errs, want)
+	}
+
+	// The resources stay in the snapshot, without the invalid settings.
+	if h := s.Handlers["handler1.adapter1.ns"]; h == nil || h.Limits != (HandlerLimits{}) {
+		t.Fatalf("handler => got %+v", h)
+	}
+	if len(s.Rules) != 1 || s.Rules[0].FailOpen {
+		t.Fatalf("rules => got %+v", s.Rules)
+	}
+}
+
+func TestDryRunLog(t *testing.T) {
+	templates := map[string]*template.Info{
+		"logentry": {Name: "logentry", Variety: istio_mixer_v1_template.TEMPLATE_VARIETY_REPORT},
+	}
+	for k, v := range stdTemplates {
+		templates[k] = v
+	}
+	adapters := map[string]*adapter.Info{
+		"adapter1": {Name: "adapter1", SupportedTemplates: []string{"logentry"}},
+		"adapter2": {Name: "adapter2"},
+	}
+
+	cases := []struct {
+		name     string
+		labels   map[string]string
+		handler  string
+		instance string
+		err      string
+	}{
+		{
+			name:     "Valid",
+			labels:   map[string]string{DryRunLogHandlerLabel: "handler1.adapter1", DryRunLogInstanceLabel: "log1.logentry"},
+			handler:  "handler1.adapter1.ns",
+			instance: "log1.logentry.ns",
+		},
+		{
+			name:   "None",
+			labels: map[string]string{},
+		},
+		{
+			name:   "Incomplete",
+			labels: map[string]string{DryRunLogHandlerLabel: "handler1.adapter1"},
+			err: "Incomplete dry-run log labels, both 'istio-dry-run-log-handler' and 'istio-dry-run-log-instance' " +
+				"are required: name='rule1.rule.ns'",
+		},
+		{
+			name:   "UnknownHandler",
+			labels: map[string]string{DryRunLogHandlerLabel: "handler3.adapter1", DryRunLogInstanceLabel: "log1.logentry"},
+			err:    "Dry-run log handler not found: name='rule1.rule.ns', handler='handler3.adapter1.ns'",
+		},
+		{
+			name:   "UnknownInstance",
+			labels: map[string]string{DryRunLogHandlerLabel: "handler1.adapter1", DryRunLogInstanceLabel: "log2.logentry"},
+			err:    "Dry-run log instance not found: name='rule1.rule.ns', instance='log2.logentry.ns'",
+		},
+		{
+			name:   "NotLogEntry",
+			labels: map[string]string{DryRunLogHandlerLabel: "handler1.adapter1", DryRunLogInstanceLabel: "instance1.check"},
+			err:    "Dry-run log instance is not a logentry instance: name='rule1.rule.ns', instance='instance1.check.ns'",
+		},
+		{
+			name:   "Unsupported",
+			labels: map[string]string{DryRunLogHandlerLabel: "handler2.adapter2", DryRunLogInstanceLabel: "log1.logentry"},
+			err: "Dry-run log handler does not support the logentry template: name='rule1.rule.ns', " +
+				"handler='handler2.adapter2.ns'",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEphemeral(templates, adapters)
+			for _, k := range []store.Key{
+				{Name: "handler1", Namespace: "ns", Kind: "adapter1"},
+				{Name: "handler2", Namespace: "ns", Kind: "adapter2"},
+			} {
+				e.ApplyEvent(&store.Event{Key: k, Type: store.Update, Value: &store.Resource{Spec: testParam1}})
+			}
+			for _, k := range []store.Key{
+				{Name: "instance1", Namespace: "ns", Kind: "check"},
+				{Name: "log1", Namespace: "ns", Kind: "logentry"},
+			} {
+				e.ApplyEvent(&store.Event{Key: k, Type: store.Update, Value: &store.Resource{Spec: testParam2}})
+			}
+			e.ApplyEvent(&store.Event{
+				Key:  store.Key{Name: "rule1", Namespace: "ns", Kind: RulesKind},
+				Type: store.Update,
+				Value: &store.Resource{
+					Metadata: store.ResourceMeta{Labels: tc.labels},
+					Spec: &configpb.Rule{
+						Actions: []*configpb.Action{{Handler: "handler1.adapter1", Instances: []string{"instance1.check.ns"}}},
+					},
+				},
+			})
+			s := e.BuildSnapshot()
+
+			var errs []string
+			for _, err := range s.Errors {
+				errs = append(errs, err.Error())
+			}
+			var want []string
+			if tc.err != "" {
+				want = []string{tc.err}
+			}
+			if !reflect.DeepEqual(errs, want) {
+				t.Fatalf("Errors =>\ngot  %v\nwant %v", errs, want)
+			}
+
+			if len(s.Rules) != 1 {
+				t.Fatalf("rules => got %+v", s.Rules)
+			}
+			a := s.Rules[0].DryRunLog
+			if tc.handler == "" {
+				if a != nil {
+					t.Fatalf("DryRunLog => got %+v, want nil", a)
+				}
+				return
+			}
+			if a == nil || a.Handler.Name != tc.handler || len(a.Instances) != 1 || a.Instances[0].Name != tc.instance {
+				t.Fatalf("DryRunLog => got %+v, want handler %s and instance %s", a, tc.handler, tc.instance)
+			}
+
+			// The log handler is built with the type of the log instance.
+			var found bool
+			for _, i := range GetInstancesGroupedByHandlers(s)[a.Handler] {
+				found = found || i.Name == tc.instance
+			}
+			if !found {
+				t.Fatalf("GetInstancesGroupedByHandlers() => the log instance is missing")
+			}
+		})
+	}
+}
+
+func readFile(path string) []byte {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
 func runTests(t *testing.T) {
 	for _, test := range tests {
 
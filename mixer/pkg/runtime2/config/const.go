@@ -20,12 +20,21 @@
 // incorporating otherwise complex queries within this package.
 package config
 
+import "time"
+
 // RulesKind defines the config kind Name of mixer Rules.
 const RulesKind = "rule"
 
 // AttributeManifestKind define the config kind Name of attribute manifests.
 const AttributeManifestKind = "attributemanifest"
 
+// TemplateKind defines the config kind Name of templates that are loaded at runtime.
+const TemplateKind = "template"
+
+// InstanceKind defines the config kind Name of instances of any template, including the ones that are loaded at
+// runtime.
+const InstanceKind = "instance"
+
 // ContextProtocolTCP defines constant for tcp protocol.
 const ContextProtocolTCP = "tcp"
 
@@ -33,3 +42,43 @@ const ContextProtocolTCP = "tcp"
 const ContextProtocolAttributeName = "context.protocol"
 
 const istioProtocol = "istio-protocol"
+
+// DryRunLabel is the label that marks a rule or a handler as dry-run. Check results from dry-run rules and handlers
+// are recorded in the metrics and the log of Mixer, but never enforced.
+const DryRunLabel = "istio-dry-run"
+
+// DryRunLogHandlerLabel is the label for configuring the handler of a log adapter that the would-be check results of
+// the dry-run actions of a rule are dispatched to, along with the instance of DryRunLogInstanceLabel.
+const DryRunLogHandlerLabel = "istio-dry-run-log-handler"
+
+// DryRunLogInstanceLabel is the label for configuring the logentry instance that is created for each would-be check
+// result of the dry-run actions of a rule. The handler, code and message of the check result are added to the
+// variables of the instance.
+const DryRunLogInstanceLabel = "istio-dry-run-log-instance"
+
+// TimeoutLabel is the label for configuring the timeout of a single dispatch to a handler, e.g. "250ms".
+const TimeoutLabel = "istio-timeout"
+
+// MaxConcurrencyLabel is the label for configuring the maximum number of concurrent dispatches to a handler.
+const MaxConcurrencyLabel = "istio-max-concurrency"
+
+// CircuitBreakerFailuresLabel is the label for configuring the number of consecutive dispatch failures that
+// opens the circuit breaker of a handler.
+const CircuitBreakerFailuresLabel = "istio-circuit-breaker-failures"
+
+// CircuitBreakerResetLabel is the label for configuring the duration for which an open circuit breaker of a handler
+// rejects dispatches, e.g. "30s".
+const CircuitBreakerResetLabel = "istio-circuit-breaker-reset"
+
+// DefaultCircuitBreakerReset is the duration for which an open circuit breaker rejects dispatches, if not configured.
+const DefaultCircuitBreakerReset = 30 * time.Second
+
+// FailPolicyLabel is the label for configuring the behavior of a rule when the dispatch to a handler fails. The
+// value is either FailOpen or FailClosed. Rules fail closed by default.
+const FailPolicyLabel = "istio-fail-policy"
+
+// FailOpen is the fail policy that ignores dispatch failures.
+const FailOpen = "open"
+
+// FailClosed is the fail policy that fails the request on dispatch failures.
+const FailClosed = "closed"
@@ -15,11 +15,24 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
 	"istio.io/api/mixer/v1/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/mixer/pkg/expr"
 	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/mixer/pkg/template/dynamic"
+	dynamicpb "istio.io/istio/mixer/pkg/template/dynamic/config"
+	"istio.io/istio/mixer/template/logentry"
 	"istio.io/istio/pkg/log"
 )
 
@@ -40,6 +53,19 @@ type Ephemeral struct {
 	// attributes from the last config state update. If the manifest hasn't changed since the last config update
 	// the attributes are reused.
 	cachedAttributes map[string]*config.AttributeManifest_AttributeInfo
+
+	// templates that were loaded at runtime during the last config state update. Templates are only reloaded if
+	// their descriptor set changes.
+	cachedTemplates map[store.Key]*loadedTemplate
+
+	// errors in the config resources that were left out of the snapshot that is being built.
+	errs []error
+}
+
+// loadedTemplate is a template that is loaded at runtime, from its descriptor set.
+type loadedTemplate struct {
+	descriptorSet []byte
+	info          *template.Info
 }
 
 // NewEphemeral returns a new Ephemeral instance.
@@ -102,27 +128,35 @@ func (e *Ephemeral) BuildSnapshot() *Snapshot {
 
 	// Allocate new counters, to use with the new snapshot.
 	counters := newCounters(id)
+	e.errs = nil
 
 	attributes := e.processAttributeManifests(counters)
 
+	templates := e.processTemplateConfigs(counters)
+
 	handlers := e.processHandlerConfigs(counters)
 
-	instances := e.processInstanceConfigs(counters)
+	instances := e.processInstanceConfigs(templates, counters)
 
 	rules := e.processRuleConfigs(handlers, instances, counters)
 
+	// Report the errors in a stable order.
+	sort.Slice(e.errs, func(i, j int) bool { return e.errs[i].Error() < e.errs[j].Error() })
+
 	s := &Snapshot{
 		ID:         id,
-		Templates:  e.templates,
+		Templates:  templates,
 		Adapters:   e.adapters,
 		Attributes: expr.NewFinder(attributes),
 		Handlers:   handlers,
 		Instances:  instances,
 		Rules:      rules,
 		Counters:   counters,
+		Errors:     e.errs,
 	}
 
 	e.cachedAttributes = attributes
+	e.errs = nil
 
 	log.Infof("Built new config.Snapshot: id='%d'", id)
 	log.Debugf("config.Snapshot contents:\n%s", s)
@@ -173,6 +207,54 @@ func (e *Ephemeral) processAttributeManifests(counters Counters) map[string]*con
 	return attrs
 }
 
+// processTemplateConfigs loads the templates of the template configs, and returns them together with the templates
+// that are compiled into Mixer. Compiled templates take precedence over loaded templates with the same name.
+func (e *Ephemeral) processTemplateConfigs(counters Counters) map[string]*template.Info {
+	templates := make(map[string]*template.Info, len(e.templates))
+	for name, info := range e.templates {
+		templates[name] = info
+	}
+
+	// Process the template configs in a stable order, so that name conflicts are always resolved the same way.
+	var keys []store.Key
+	for key := range e.entries {
+		if key.Kind == TemplateKind {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	loaded := make(map[store.Key]*loadedTemplate, len(keys))
+	for _, key := range keys {
+		cfg := e.entries[key].Spec.(*dynamicpb.Template)
+
+		t, found := e.cachedTemplates[key]
+		if !found || !bytes.Equal(t.descriptorSet, cfg.DescriptorSet) {
+			log.Debugf("Loading template: name='%s'", key)
+
+			info, err := dynamic.New(cfg.DescriptorSet)
+			if err != nil {
+				e.errorf("Unable to load template: name='%s', err='%v'", key, err)
+				counters.templateConfigError.Inc()
+				continue
+			}
+			t = &loadedTemplate{descriptorSet: cfg.DescriptorSet, info: info}
+		}
+		loaded[key] = t
+
+		if _, found = templates[t.info.Name]; found {
+			e.errorf("Template already exists: name='%s', template='%s'", key, t.info.Name)
+			counters.templateConfigError.Inc()
+			continue
+		}
+		templates[t.info.Name] = t.info
+	}
+
+	e.cachedTemplates = loaded
+	counters.templateConfig.Add(float64(len(loaded)))
+	return templates
+}
+
 func (e *Ephemeral) processHandlerConfigs(counters Counters) map[string]*Handler {
 	handlers := make(map[string]*Handler, len(e.adapters))
 
@@ -192,6 +274,8 @@ func (e *Ephemeral) processHandlerConfigs(counters Counters) map[string]*Handler
 			Name:    adapterName,
 			Adapter: info,
 			Params:  resource.Spec,
+			DryRun:  isDryRun(resource.Metadata.Labels),
+			Limits:  e.handlerLimits(adapterName, resource.Metadata.Labels),
 		}
 
 		handlers[cfg.Name] = cfg
@@ -201,13 +285,28 @@ func (e *Ephemeral) processHandlerConfigs(counters Counters) map[string]*Handler
 	return handlers
 }
 
-func (e *Ephemeral) processInstanceConfigs(counters Counters) map[string]*Instance {
+func (e *Ephemeral) processInstanceConfigs(templates map[string]*template.Info, counters Counters) map[string]*Instance {
 	instances := make(map[string]*Instance, len(e.templates))
 
 	for key, resource := range e.entries {
 		var info *template.Info
 		var found bool
-		if info, found = e.templates[key.Kind]; !found {
+		params := resource.Spec
+
+		if key.Kind == InstanceKind {
+			// An instance of any template, including the ones that are loaded at runtime.
+			spec := resource.Spec.(*dynamicpb.Instance)
+			if info, found = templates[spec.Template]; !found {
+				e.errorf("Template not found: instance='%s', template='%s'", key, spec.Template)
+				continue
+			}
+
+			var err error
+			if params, err = instanceParams(info, spec.Params); err != nil {
+				e.errorf("Invalid instance params: instance='%s', template='%s', err='%v'", key, spec.Template, err)
+				continue
+			}
+		} else if info, found = e.templates[key.Kind]; !found {
 			// This config resource is not for an instance (or at least not for one that Mixer is currently aware of).
 			continue
 		}
@@ -219,7 +318,7 @@ func (e *Ephemeral) processInstanceConfigs(counters Counters) map[string]*Instan
 		cfg := &Instance{
 			Name:     instanceName,
 			Template: info,
-			Params:   resource.Spec,
+			Params:   params,
 		}
 
 		instances[cfg.Name] = cfg
@@ -275,7 +374,7 @@ func (e *Ephemeral) processRuleConfigs(
 			var handler *Handler
 			handlerName := canonicalize(a.Handler, ruleKey.Namespace)
 			if handler, found = handlers[handlerName]; !found {
-				log.Errorf("Handler not found: handler='%s', action='%s[%d]'",
+				e.errorf("Handler not found: handler='%s', action='%s[%d]'",
 					handlerName, ruleName, i)
 				counters.ruleConfigError.Inc()
 				continue
@@ -289,7 +388,7 @@ func (e *Ephemeral) processRuleConfigs(
 			for _, instanceName := range a.Instances {
 				instanceName = canonicalize(instanceName, ruleKey.Namespace)
 				if _, found = uniqueInstances[instanceName]; found {
-					log.Errorf("Action specified the same instance multiple times: action='%s[%d]', instance='%s',",
+					e.errorf("Action specified the same instance multiple times: action='%s[%d]', instance='%s',",
 						ruleName, i, instanceName)
 					counters.ruleConfigError.Inc()
 					continue
@@ -298,7 +397,7 @@ func (e *Ephemeral) processRuleConfigs(
 
 				var instance *Instance
 				if instance, found = instances[instanceName]; !found {
-					log.Errorf("Instance not found: instance='%s', action='%s[%d]'",
+					e.errorf("Instance not found: instance='%s', action='%s[%d]'",
 						instanceName, ruleName, i)
 					counters.ruleConfigError.Inc()
 					continue
@@ -309,7 +408,7 @@ func (e *Ephemeral) processRuleConfigs(
 
 			// If there are no valid instances found for this action, then elide the action.
 			if len(actionInstances) == 0 {
-				log.Errorf("No valid instances found: action='%s[%d]'", ruleName, i)
+				e.errorf("No valid instances found: action='%s[%d]'", ruleName, i)
 				counters.ruleConfigError.Inc()
 				continue
 			}
@@ -324,7 +423,7 @@ func (e *Ephemeral) processRuleConfigs(
 
 		// If there are no valid actions found for this rule, then elide the rule.
 		if len(actions) == 0 {
-			log.Errorf("No valid actions found in rule: %s", ruleName)
+			e.errorf("No valid actions found in rule: %s", ruleName)
 			counters.ruleConfigError.Inc()
 			continue
 		}
@@ -335,6 +434,9 @@ func (e *Ephemeral) processRuleConfigs(
 			Actions:      actions,
 			ResourceType: rt,
 			Match:        cfg.Match,
+			DryRun:       isDryRun(resource.Metadata.Labels),
+			DryRunLog:    e.dryRunLog(ruleName, ruleKey.Namespace, resource.Metadata.Labels, handlers, instances),
+			FailOpen:     e.isFailOpen(ruleName, resource.Metadata.Labels),
 		}
 
 		rules = append(rules, rule)
@@ -343,6 +445,35 @@ func (e *Ephemeral) processRuleConfigs(
 	return rules
 }
 
+// errorf logs an error in a config resource that is left out of the snapshot, or in a label of a config resource that
+// is ignored, and records it in the errors of the snapshot.
+func (e *Ephemeral) errorf(format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	log.Error(err.Error())
+	e.errs = append(e.errs, err)
+}
+
+// instanceParams converts the params of an instance config to the instance params message of the template.
+func instanceParams(info *template.Info, params *types.Struct) (proto.Message, error) {
+	if info.CtrCfg == nil {
+		return nil, fmt.Errorf("template %s does not have instance params", info.Name)
+	}
+
+	p := proto.Clone(info.CtrCfg)
+	if params == nil {
+		return p, nil
+	}
+
+	s, err := (&jsonpb.Marshaler{}).MarshalToString(params)
+	if err != nil {
+		return nil, err
+	}
+	if err = jsonpb.UnmarshalString(s, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 // resourceType maps labels to rule types.
 func resourceType(labels map[string]string) ResourceType {
 	rt := defaultResourcetype()
@@ -351,3 +482,117 @@ func resourceType(labels map[string]string) ResourceType {
 	}
 	return rt
 }
+
+// isDryRun returns true if the labels mark the resource as dry-run.
+func isDryRun(labels map[string]string) bool {
+	return labels[DryRunLabel] == "true"
+}
+
+// dryRunLog returns the action that the would-be check results of the dry-run actions of a rule are logged with, as
+// configured by the labels. Invalid values are ignored, and recorded in the errors of the snapshot.
+func (e *Ephemeral) dryRunLog(name string, namespace string, labels map[string]string,
+	handlers map[string]*Handler, instances map[string]*Instance) *Action {
+
+	handlerName, hasHandler := labels[DryRunLogHandlerLabel]
+	instanceName, hasInstance := labels[DryRunLogInstanceLabel]
+	if !hasHandler && !hasInstance {
+		return nil
+	}
+	if !hasHandler || !hasInstance {
+		e.errorf("Incomplete dry-run log labels, both '%s' and '%s' are required: name='%s'",
+			DryRunLogHandlerLabel, DryRunLogInstanceLabel, name)
+		return nil
+	}
+
+	handlerName = canonicalize(handlerName, namespace)
+	handler, found := handlers[handlerName]
+	if !found {
+		e.errorf("Dry-run log handler not found: name='%s', handler='%s'", name, handlerName)
+		return nil
+	}
+
+	instanceName = canonicalize(instanceName, namespace)
+	instance, found := instances[instanceName]
+	if !found {
+		e.errorf("Dry-run log instance not found: name='%s', instance='%s'", name, instanceName)
+		return nil
+	}
+
+	if instance.Template.Name != logentry.TemplateName {
+		e.errorf("Dry-run log instance is not a %s instance: name='%s', instance='%s'",
+			logentry.TemplateName, name, instanceName)
+		return nil
+	}
+
+	if !contains(handler.Adapter.SupportedTemplates, logentry.TemplateName) {
+		e.errorf("Dry-run log handler does not support the %s template: name='%s', handler='%s'",
+			logentry.TemplateName, name, handlerName)
+		return nil
+	}
+
+	return &Action{
+		Handler:   handler,
+		Instances: []*Instance{instance},
+	}
+}
+
+// handlerLimits returns the limits of a handler, as configured by the labels. Invalid values are ignored, and
+// recorded in the errors of the snapshot.
+func (e *Ephemeral) handlerLimits(name string, labels map[string]string) HandlerLimits {
+	var l HandlerLimits
+
+	l.Timeout = e.durationLabel(name, labels, TimeoutLabel)
+	l.MaxConcurrency = e.intLabel(name, labels, MaxConcurrencyLabel)
+	l.CircuitBreakerFailures = e.intLabel(name, labels, CircuitBreakerFailuresLabel)
+	l.CircuitBreakerReset = e.durationLabel(name, labels, CircuitBreakerResetLabel)
+
+	if l.CircuitBreakerFailures > 0 && l.CircuitBreakerReset == 0 {
+		l.CircuitBreakerReset = DefaultCircuitBreakerReset
+	}
+
+	return l
+}
+
+func (e *Ephemeral) durationLabel(name string, labels map[string]string, label string) time.Duration {
+	v, found := labels[label]
+	if !found {
+		return 0
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		e.errorf("Invalid duration label: name='%s', label='%s', value='%s'", name, label, v)
+		return 0
+	}
+
+	return d
+}
+
+func (e *Ephemeral) intLabel(name string, labels map[string]string, label string) int {
+	v, found := labels[label]
+	if !found {
+		return 0
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		e.errorf("Invalid integer label: name='%s', label='%s', value='%s'", name, label, v)
+		return 0
+	}
+
+	return i
+}
+
+// isFailOpen returns true if the labels set the fail policy of the resource to FailOpen. An invalid fail policy is
+// recorded in the errors of the snapshot, and the rule fails closed.
+func (e *Ephemeral) isFailOpen(name string, labels map[string]string) bool {
+	switch v := labels[FailPolicyLabel]; v {
+	case "", FailClosed:
+		return false
+	case FailOpen:
+		return true
+	default:
+		e.errorf("Invalid fail policy label: name='%s', value='%s'", name, v)
+		return false
+	}
+}
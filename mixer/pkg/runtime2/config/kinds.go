@@ -20,6 +20,7 @@ import (
 	configpb "istio.io/api/mixer/v1/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/template"
+	dynamicpb "istio.io/istio/mixer/pkg/template/dynamic/config"
 	"istio.io/istio/pkg/log"
 )
 
@@ -40,6 +41,10 @@ func KindMap(adapterInfo map[string]*adapter.Info, templateInfo map[string]*temp
 	log.Infof("template Kind: %s", RulesKind)
 	kindMap[AttributeManifestKind] = &configpb.AttributeManifest{}
 	log.Infof("template Kind: %s", AttributeManifestKind)
+	kindMap[TemplateKind] = &dynamicpb.Template{}
+	log.Infof("template Kind: %s", TemplateKind)
+	kindMap[InstanceKind] = &dynamicpb.Instance{}
+	log.Infof("template Kind: %s", InstanceKind)
 
 	return kindMap
 }
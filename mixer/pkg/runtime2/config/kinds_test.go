@@ -23,6 +23,7 @@ import (
 	cpb "istio.io/api/mixer/v1/config"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/template"
+	dynamicpb "istio.io/istio/mixer/pkg/template/dynamic/config"
 )
 
 func TestKindMap(t *testing.T) {
@@ -44,6 +45,8 @@ func TestKindMap(t *testing.T) {
 		"a1":                  &cpb.Handler{},
 		RulesKind:             &cpb.Rule{},
 		AttributeManifestKind: &cpb.AttributeManifest{},
+		TemplateKind:          &dynamicpb.Template{},
+		InstanceKind:          &dynamicpb.Instance{},
 	}
 
 	if !reflect.DeepEqual(km, want) {
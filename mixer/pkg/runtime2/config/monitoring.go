@@ -48,6 +48,20 @@ var (
 		Help:      "The number of known instances in the current config.",
 	}, standardConfigLabels)
 
+	templateConfigCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "template_config_count",
+		Help:      "The number of templates loaded at runtime in the current config.",
+	}, standardConfigLabels)
+
+	templateConfigErrorCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "template_config_error_count",
+		Help:      "The number of errors encountered during loading of templates at runtime.",
+	}, standardConfigLabels)
+
 	ruleConfigCount = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "mixer",
 		Subsystem: "config",
@@ -81,6 +95,8 @@ func init() {
 	prometheus.MustRegister(attributeCount)
 	prometheus.MustRegister(handlerConfigCount)
 	prometheus.MustRegister(instanceConfigCount)
+	prometheus.MustRegister(templateConfigCount)
+	prometheus.MustRegister(templateConfigErrorCount)
 	prometheus.MustRegister(ruleConfigCount)
 	prometheus.MustRegister(ruleConfigErrorCount)
 	prometheus.MustRegister(matchErrorCount)
@@ -90,11 +106,13 @@ func init() {
 // Counters is the configuration related performance Counters. Other parts of the code can depend
 // on some of the Counters here as well.
 type Counters struct {
-	attributes      prometheus.Counter
-	handlerConfig   prometheus.Counter
-	instanceConfig  prometheus.Counter
-	ruleConfig      prometheus.Counter
-	ruleConfigError prometheus.Counter
+	attributes          prometheus.Counter
+	handlerConfig       prometheus.Counter
+	instanceConfig      prometheus.Counter
+	templateConfig      prometheus.Counter
+	templateConfigError prometheus.Counter
+	ruleConfig          prometheus.Counter
+	ruleConfigError     prometheus.Counter
 
 	// Externally visible counters
 	MatchErrors               prometheus.Counter
@@ -106,11 +124,13 @@ func newCounters(id int64) Counters {
 		configID: strconv.FormatInt(id, 10),
 	}
 	return Counters{
-		attributes:      attributeCount.With(labels),
-		handlerConfig:   handlerConfigCount.With(labels),
-		instanceConfig:  instanceConfigCount.With(labels),
-		ruleConfig:      ruleConfigCount.With(labels),
-		ruleConfigError: ruleConfigErrorCount.With(labels),
+		attributes:          attributeCount.With(labels),
+		handlerConfig:       handlerConfigCount.With(labels),
+		instanceConfig:      instanceConfigCount.With(labels),
+		templateConfig:      templateConfigCount.With(labels),
+		templateConfigError: templateConfigErrorCount.With(labels),
+		ruleConfig:          ruleConfigCount.With(labels),
+		ruleConfigError:     ruleConfigErrorCount.With(labels),
 
 		MatchErrors:               matchErrorCount.With(labels),
 		UnsatisfiedActionHandlers: unsatisfiedActionHandlerCount.With(labels),
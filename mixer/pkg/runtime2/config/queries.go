@@ -23,7 +23,13 @@ func GetInstancesGroupedByHandlers(s *Snapshot) map[*Handler][]*Instance {
 	// Grovel over rules/actions and for each handler create a map entry and place all the instances in that action
 	// as values.
 	for _, r := range s.Rules {
-		for _, a := range r.Actions {
+		actions := r.Actions
+		if r.DryRunLog != nil {
+			// The handler of the dry-run log also needs to be built with the type of the log instance.
+			actions = append(append([]*Action{}, r.Actions...), r.DryRunLog)
+		}
+
+		for _, a := range actions {
 			instances, found := m[a.Handler]
 			if !found {
 				instances = make(map[*Instance]bool)
@@ -15,6 +15,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/gogo/protobuf/proto"
 
 	"istio.io/istio/mixer/pkg/adapter"
@@ -28,7 +30,7 @@ type (
 	Snapshot struct {
 		ID int64
 
-		// Static information
+		// Static information, and the templates that are loaded at runtime.
 		Templates map[string]*template.Info
 		Adapters  map[string]*adapter.Info
 
@@ -41,6 +43,9 @@ type (
 
 		// Perf Counters relevant to configuration.
 		Counters Counters
+
+		// Errors in the config resources that were left out of the snapshot, and in the labels that were ignored.
+		Errors []error
 	}
 
 	// Handler configuration. Fully resolved.
@@ -54,6 +59,28 @@ type (
 
 		// parameters used to construct the Handler.
 		Params proto.Message
+
+		// DryRun indicates that the check results of this handler should not be enforced.
+		DryRun bool
+
+		// Limits that protect the dispatches to this handler.
+		Limits HandlerLimits
+	}
+
+	// HandlerLimits are the limits that protect the dispatches to a handler. Zero values disable the respective limit.
+	HandlerLimits struct {
+		// Timeout of a single dispatch to the handler. If zero, only the request deadline applies.
+		Timeout time.Duration
+
+		// MaxConcurrency is the maximum number of concurrent dispatches to the handler.
+		MaxConcurrency int
+
+		// CircuitBreakerFailures is the number of consecutive dispatch failures that opens the circuit breaker.
+		CircuitBreakerFailures int
+
+		// CircuitBreakerReset is the duration for which the open circuit breaker rejects dispatches, before
+		// allowing a trial dispatch.
+		CircuitBreakerReset time.Duration
 	}
 
 	// Instance configuration. Fully resolved.
@@ -82,6 +109,17 @@ type (
 		Actions []*Action
 
 		ResourceType ResourceType
+
+		// DryRun indicates that the check results of the actions of this rule should not be enforced.
+		DryRun bool
+
+		// DryRunLog is the action that the would-be check results of the dry-run actions of this rule are logged
+		// with. Nil, if the check results are only recorded in the metrics and the log of Mixer.
+		DryRunLog *Action
+
+		// FailOpen indicates that dispatch failures of the actions of this rule are ignored, instead of failing
+		// the request.
+		FailOpen bool
 	}
 
 	// Action configuration. Fully resolved.
@@ -102,3 +140,8 @@ func Empty() *Snapshot {
 		Counters: newCounters(-1),
 	}
 }
+
+// IsZero returns true if none of the limits are set.
+func (l HandlerLimits) IsZero() bool {
+	return l == HandlerLimits{}
+}
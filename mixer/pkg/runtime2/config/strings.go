@@ -108,6 +108,15 @@ func writeHandlers(w io.Writer, handlers map[string]*Handler) {
 
 		fmt.Fprintf(w, "  Params:  %+v", h.Params)
 		fmt.Fprintln(w)
+
+		if h.DryRun {
+			fmt.Fprintln(w, "  DryRun:  true")
+		}
+
+		if !h.Limits.IsZero() {
+			fmt.Fprintf(w, "  Limits:  %+v", h.Limits)
+			fmt.Fprintln(w)
+		}
 	}
 }
 
@@ -157,6 +166,19 @@ func writeRules(w io.Writer, rules []*Rule) {
 		fmt.Fprintf(w, "  ResourceType: %v", r.ResourceType)
 		fmt.Fprintln(w)
 
+		if r.DryRun {
+			fmt.Fprintln(w, "  DryRun: true")
+		}
+
+		if r.DryRunLog != nil {
+			fmt.Fprintln(w, "  DryRunLog:")
+			writeActions(w, []*Action{r.DryRunLog})
+		}
+
+		if r.FailOpen {
+			fmt.Fprintln(w, "  FailOpen: true")
+		}
+
 		fmt.Fprintln(w, "  Actions:")
 		writeActions(w, r.Actions)
 	}
@@ -32,6 +32,16 @@ func isFQN(name string) bool {
 	return c == 2
 }
 
+// contains returns true if the given value is in the list.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // canonicalize ensures that the name is fully qualified.
 func canonicalize(name string, namespace string) string {
 	if isFQN(name) {
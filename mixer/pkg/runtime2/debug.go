@@ -0,0 +1,263 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/pkg/log"
+)
+
+// SnapshotsDump is the JSON representation of the snapshot history.
+type SnapshotsDump struct {
+	// Current is the id of the snapshot that is in use.
+	Current int64 `json:"current"`
+
+	// Pinned indicates that config changes are not applied, until the runtime is unpinned.
+	Pinned bool `json:"pinned"`
+
+	// Snapshots are the most recent snapshots, in the order they were built.
+	Snapshots []SnapshotDump `json:"snapshots"`
+}
+
+// SnapshotDump is the JSON representation of a snapshot in the history.
+type SnapshotDump struct {
+	ID        int64     `json:"id"`
+	Created   time.Time `json:"created"`
+	Handlers  int       `json:"handlers"`
+	Instances int       `json:"instances"`
+	Rules     int       `json:"rules"`
+
+	// BuildFailures are the names of the handlers that could not be built when the snapshot was applied.
+	BuildFailures []string `json:"buildFailures,omitempty"`
+
+	// Errors are the errors of the snapshot when it was applied.
+	Errors []string `json:"errors,omitempty"`
+
+	// Rejected indicates that the snapshot was discarded, by an automatic rollback or by strict mode.
+	Rejected bool `json:"rejected,omitempty"`
+
+	// Pending indicates that the snapshot was built while the runtime was pinned, and has not been validated yet.
+	// Pending and rejected snapshots cannot be rolled back to.
+	Pending bool `json:"pending,omitempty"`
+}
+
+// ConfigStatus is the JSON representation of the status of the config.
+type ConfigStatus struct {
+	// Current is the id of the snapshot that is in use.
+	Current int64 `json:"current"`
+
+	// Latest is the id of the most recent snapshot that was built from the config store.
+	Latest int64 `json:"latest"`
+
+	// Strict indicates that snapshots with errors are discarded.
+	Strict bool `json:"strict"`
+
+	// Pinned indicates that config changes are not applied, until the runtime is unpinned.
+	Pinned bool `json:"pinned"`
+
+	// Errors are the errors of the latest snapshot. They are only known once the snapshot is applied.
+	Errors []string `json:"errors,omitempty"`
+
+	// Rejected indicates that the latest snapshot was discarded, by an automatic rollback or by strict mode.
+	Rejected bool `json:"rejected,omitempty"`
+}
+
+// NamespacesStatus is the JSON representation of the limits of the config namespaces, and of their violations by the
+// snapshot that is in use.
+type NamespacesStatus struct {
+	// Snapshot is the id of the snapshot that is in use.
+	Snapshot int64 `json:"snapshot"`
+
+	// Namespaces are the statuses of the namespaces that have rules or limits of their own, by namespace. The default
+	// config namespace is not subject to any limits.
+	Namespaces map[string]NamespaceStatus `json:"namespaces"`
+}
+
+// NamespaceStatus is the JSON representation of the status of a config namespace.
+type NamespaceStatus struct {
+	// Limits of the namespace.
+	Limits routing.NamespaceLimits `json:"limits"`
+
+	// Rules is the number of rules of the namespace that are in use.
+	Rules int `json:"rules"`
+
+	// Violations are the rules of the namespace that were left out, as they violate its limits.
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Query parameters of the snapshots endpoint.
+const (
+	actionParam = "action"
+	idParam     = "id"
+)
+
+// Snapshots returns the snapshot history.
+func (c *Runtime) Snapshots() *SnapshotsDump {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	d := &SnapshotsDump{
+		Current:   c.snapshot.ID,
+		Pinned:    c.pinned,
+		Snapshots: make([]SnapshotDump, 0, len(c.history.entries)),
+	}
+	for _, e := range c.history.entries {
+		d.Snapshots = append(d.Snapshots, SnapshotDump{
+			ID:            e.snapshot.ID,
+			Created:       e.created,
+			Handlers:      len(e.snapshot.Handlers),
+			Instances:     len(e.snapshot.Instances),
+			Rules:         len(e.snapshot.Rules),
+			BuildFailures: e.failures,
+			Errors:        e.errors,
+			Rejected:      e.rejected,
+			Pending:       e.pending,
+		})
+	}
+	return d
+}
+
+// Status returns the status of the config.
+func (c *Runtime) Status() *ConfigStatus {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	st := &ConfigStatus{
+		Current: c.snapshot.ID,
+		Latest:  c.latest.ID,
+		Strict:  c.options.Strict,
+		Pinned:  c.pinned,
+	}
+	if e := c.history.get(c.latest.ID); e != nil {
+		st.Errors = e.errors
+		st.Rejected = e.rejected
+	}
+	return st
+}
+
+// NamespaceStatus returns the limits of the config namespaces, and their violations by the snapshot in use.
+func (c *Runtime) NamespaceStatus() *NamespacesStatus {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	limits := c.options.Limits
+	if limits == nil {
+		limits = &routing.Limits{}
+	}
+
+	st := &NamespacesStatus{
+		Snapshot:   c.snapshot.ID,
+		Namespaces: make(map[string]NamespaceStatus),
+	}
+	for ns := range limits.Namespaces {
+		st.Namespaces[ns] = NamespaceStatus{Limits: limits.Get(ns)}
+	}
+	for _, rule := range c.snapshot.Rules {
+		ns := st.Namespaces[rule.Namespace]
+		ns.Limits = limits.Get(rule.Namespace)
+		ns.Rules++
+		st.Namespaces[rule.Namespace] = ns
+	}
+	if e := c.history.get(c.snapshot.ID); e != nil {
+		for name, violations := range e.violations {
+			ns := st.Namespaces[name]
+			ns.Rules -= len(violations)
+			ns.Violations = violations
+			st.Namespaces[name] = ns
+		}
+	}
+	if ns, found := st.Namespaces[c.defaultConfigNamespace]; found {
+		ns.Limits = routing.NamespaceLimits{}
+		st.Namespaces[c.defaultConfigNamespace] = ns
+	}
+	return st
+}
+
+// ServeNamespaceStatus writes the limits of the config namespaces, and their violations, as JSON.
+func (c *Runtime) ServeNamespaceStatus(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, c.NamespaceStatus())
+}
+
+// ServeConfigStatus writes the status of the config as JSON.
+func (c *Runtime) ServeConfigStatus(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, c.Status())
+}
+
+// ServeSnapshots writes the snapshot history as JSON. POST requests change the snapshot in use before the history
+// is written, based on the "action" query parameter:
+//
+//	action=rollback&id=<id>  installs the validated snapshot with the given id, and pins the runtime to it.
+//	action=pin               pins the runtime to the current snapshot.
+//	action=unpin             unpins the runtime, and applies the latest config.
+func (c *Runtime) ServeSnapshots(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		switch action := req.URL.Query().Get(actionParam); action {
+		case "rollback":
+			id, err := strconv.ParseInt(req.URL.Query().Get(idParam), 10, 64)
+			if err != nil {
+				http.Error(w, "invalid snapshot id: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err = c.Rollback(id); err != nil {
+				code := http.StatusConflict
+				if _, ok := err.(snapshotNotFoundError); ok {
+					code = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), code)
+				return
+			}
+		case "pin":
+			c.Pin()
+		case "unpin":
+			c.Unpin()
+		default:
+			http.Error(w, "unknown action: '"+action+"'", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, c.Snapshots())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Errorf("Unable to write response: %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	mixerpb "istio.io/api/mixer/v1"
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime2/handler"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+	"istio.io/istio/mixer/pkg/runtime2/testing/util"
+	"istio.io/istio/mixer/pkg/status"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+// instanceCaller is a tcheck instance of the name of the caller.
+var instanceCaller = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: tcheck
+metadata:
+  name: icaller
+  namespace: istio-system
+spec:
+  value: source.name
+`
+
+// instanceRequest is a tcheck instance of the id of the request, which is unique to each request.
+var instanceRequest = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: tcheck
+metadata:
+  name: irequest
+  namespace: istio-system
+spec:
+  value: attr.string
+`
+
+// ruleCaller checks the caller of the foo targets.
+var ruleCaller = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: rcaller
+  namespace: istio-system
+spec:
+  match: match(target.name, "foo*")
+  actions:
+  - handler: hcheck1.acheck
+    instances:
+    - icaller.tcheck.istio-system
+`
+
+// ruleRequestDryRun checks the id of every request, in dry-run.
+var ruleRequestDryRun = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: rrequest
+  namespace: istio-system
+  labels:
+    istio-dry-run: "true"
+spec:
+  actions:
+  - handler: hcheck1.acheck
+    instances:
+    - irequest.tcheck.istio-system
+`
+
+// cannedTraffic returns a sample of the attributes of n requests, spread evenly over a few callers and targets.
+// Each request has a unique id.
+func cannedTraffic(n int) []map[string]interface{} {
+	callers := []struct{ source, target string }{
+		{"alice", "foo.1"},
+		{"bob", "foo.1"},
+		{"mallory", "foo.1"},
+		{"alice", "foo.2"},
+		{"alice", "bar"},
+		{"bob", "baz"},
+	}
+
+	traffic := make([]map[string]interface{}, n)
+	for i := range traffic {
+		c := callers[i%len(callers)]
+		traffic[i] = map[string]interface{}{
+			"ident":       "dest.istio-system",
+			"source.name": c.source,
+			"target.name": c.target,
+			"attr.string": fmt.Sprintf("request-%d", i),
+		}
+	}
+	return traffic
+}
+
+func TestDispatcher_CacheHitRate(t *testing.T) {
+	// 600 requests over 6 distinct caller/target pairs.
+	traffic := cannedTraffic(600)
+
+	for _, tc := range []struct {
+		name         string
+		config       []string
+		cacheability adapter.CacheabilityInfo
+		hits         int
+	}{
+		{
+			// the results of the 4 foo pairs depend on the caller, and the results of the 2 other ones only on
+			// the target, which did not match the rule.
+			name:   "RuleDependencies",
+			config: []string{data.HandlerACheck1, instanceCaller, ruleCaller},
+			hits:   594,
+		},
+		{
+			// the dry-run rule references the unique request id, which must not end up in the cache key.
+			name:   "DryRunRule",
+			config: []string{data.HandlerACheck1, instanceCaller, instanceRequest, ruleCaller, ruleRequestDryRun},
+			hits:   594,
+		},
+		{
+			// each foo pair is refreshed every 10 requests, the other ones are not checked by the handler.
+			name:         "HandlerCacheability",
+			config:       []string{data.HandlerACheck1, instanceCaller, ruleCaller},
+			cacheability: adapter.CacheabilityInfo{ValidUseCount: 9},
+			hits:         558,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newCacheTestDispatcher(tc.config, tc.cacheability)
+			c := newProxyCache()
+
+			for _, attrs := range traffic {
+				c.advance(10 * time.Millisecond)
+
+				cached, hit := c.lookup(attrs)
+				r, refs := checkAndReference(t, d, attrs)
+				if hit {
+					if cached.Code != r.Status.Code {
+						t.Fatalf("cached status for %v is %v, want %v", attrs, cached, r.Status)
+					}
+					continue
+				}
+				c.store(attrs, refs, r)
+			}
+
+			if c.hits != tc.hits {
+				t.Fatalf("got %d cache hits for %d requests, want %d", c.hits, len(traffic), tc.hits)
+			}
+			t.Logf("hit rate: %.1f%%", float64(c.hits)*100/float64(len(traffic)))
+		})
+	}
+}
+
+func TestDispatcher_CheckReferencesNegativeMatches(t *testing.T) {
+	d := newCacheTestDispatcher([]string{data.HandlerACheck1, instanceCaller, ruleCaller}, adapter.CacheabilityInfo{})
+
+	_, refs := checkAndReference(t, d, map[string]interface{}{
+		"ident":       "dest.istio-system",
+		"source.name": "alice",
+		"target.name": "bar",
+	})
+
+	// the rule did not match, so the caller is not referenced. The target is, as the rule might match another one.
+	if got := fmt.Sprintf("%v", refs); got != "[context.protocol:ABSENCE ident:EXACT target.name:EXACT]" {
+		t.Fatalf("got referenced attributes %s", got)
+	}
+}
+
+// newCacheTestDispatcher returns a dispatcher for the given config. The tcheck instances evaluate to the string
+// expression of their value param, and the handler denies mallory.
+func newCacheTestDispatcher(config []string, cacheability adapter.CacheabilityInfo) *Dispatcher {
+	templates := data.BuildTemplates(nil)
+	tcheck := *templates["tcheck"]
+	tcheck.CreateInstanceBuilder = func(_ string, param proto.Message, b *compiled.ExpressionBuilder) (template.InstanceBuilderFn, error) {
+		expr, _, err := b.Compile(param.(*types.Struct).Fields["value"].GetStringValue())
+		if err != nil {
+			return nil, err
+		}
+		return func(bag attribute.Bag) (interface{}, error) {
+			return expr.EvaluateString(bag)
+		}, nil
+	}
+	tcheck.DispatchCheck = func(_ context.Context, _ adapter.Handler, instance interface{}) (adapter.CheckResult, error) {
+		r := adapter.CheckResult{ValidDuration: time.Minute, ValidUseCount: 1000}
+		if instance.(string) == "mallory" {
+			r.Status = status.WithPermissionDenied("mallory is denied")
+		}
+		return r, nil
+	}
+	templates["tcheck"] = &tcheck
+
+	adapters := data.BuildAdapters(nil, data.FakeAdapterSettings{Name: "acheck", Cacheability: cacheability})
+
+	s := util.GetSnapshot(templates, adapters, data.ServiceConfig, data.JoinConfigs(config...))
+	h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
+	r := routing.BuildTable(routing.Empty(), h, s, compiled.NewBuilder(s.Attributes), "istio-system", true)
+
+	d := New("ident", gp, false)
+	_ = d.ChangeRoute(r)
+	return d
+}
+
+// checkAndReference checks the request with the given attributes, and returns the result and the referenced
+// attributes, like the gRPC server does.
+func checkAndReference(t *testing.T, d *Dispatcher, attrs map[string]interface{}) (*adapter.CheckResult, []reference) {
+	mb := attribute.GetMutableBag(nil)
+	for k, v := range attrs {
+		mb.Set(k, v)
+	}
+	var ca mixerpb.CompressedAttributes
+	mb.ToProto(&ca, nil, 0)
+	mb.Done()
+
+	bag := attribute.NewProtoBag(&ca, nil, nil)
+	r, err := d.Check(context.Background(), bag)
+	if err != nil {
+		t.Fatalf("Check() => unexpected error: %v", err)
+	}
+	if r == nil {
+		// not subject to any check: the gRPC server returns its defaults.
+		r = &adapter.CheckResult{ValidDuration: 10 * time.Second, ValidUseCount: 200}
+	}
+
+	ra := bag.GetReferencedAttributes(nil, 0)
+	refs := make([]reference, 0, len(ra.AttributeMatches))
+	for _, m := range ra.AttributeMatches {
+		// all the words are in the message word list, as there is no global dictionary.
+		refs = append(refs, reference{name: ra.Words[-m.Name-1], condition: m.Condition})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+
+	return r, refs
+}
+
+// reference is an attribute referenced by a check, and the condition of the reference.
+type reference struct {
+	name      string
+	condition mixerpb.ReferencedAttributes_Condition
+}
+
+func (r reference) String() string {
+	return r.name + ":" + r.condition.String()
+}
+
+// proxyCache simulates the check cache of the proxy, which keys the check results on the values of the attributes
+// that were referenced by the checks.
+type proxyCache struct {
+	now time.Time
+
+	// distinct sets of references that were returned by the checks, in the order they were returned.
+	refs    [][]reference
+	refKeys map[string]bool
+
+	entries map[string]*cacheEntry
+
+	hits int
+}
+
+type cacheEntry struct {
+	status   rpc.Status
+	expiry   time.Time
+	usesLeft int32
+}
+
+func newProxyCache() *proxyCache {
+	return &proxyCache{
+		now:     time.Unix(0, 0),
+		refKeys: make(map[string]bool),
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+func (c *proxyCache) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// lookup returns the cached status of the request with the given attributes, if there is a valid one.
+func (c *proxyCache) lookup(attrs map[string]interface{}) (rpc.Status, bool) {
+	for _, refs := range c.refs {
+		key, ok := cacheKey(refs, attrs)
+		if !ok {
+			continue
+		}
+		e := c.entries[key]
+		if e == nil || !c.now.Before(e.expiry) || e.usesLeft <= 0 {
+			continue
+		}
+		e.usesLeft--
+		c.hits++
+		return e.status, true
+	}
+	return rpc.Status{}, false
+}
+
+// store caches the result of the check of the request with the given attributes.
+func (c *proxyCache) store(attrs map[string]interface{}, refs []reference, r *adapter.CheckResult) {
+	if k := fmt.Sprintf("%v", refs); !c.refKeys[k] {
+		c.refKeys[k] = true
+		c.refs = append(c.refs, refs)
+	}
+
+	key, _ := cacheKey(refs, attrs)
+	c.entries[key] = &cacheEntry{
+		status:   r.Status,
+		expiry:   c.now.Add(r.ValidDuration),
+		usesLeft: r.ValidUseCount,
+	}
+}
+
+// cacheKey returns the key of the request with the given attributes, for the given set of references. It returns
+// false, if the attributes do not satisfy the conditions of the references.
+func cacheKey(refs []reference, attrs map[string]interface{}) (string, bool) {
+	parts := make([]string, len(refs))
+	for i, ref := range refs {
+		v, found := attrs[ref.name]
+		switch {
+		case ref.condition == mixerpb.ABSENCE && found, ref.condition == mixerpb.EXACT && !found:
+			return "", false
+		case found:
+			parts[i] = fmt.Sprintf("%s=%v", ref.name, v)
+		default:
+			parts[i] = ref.name + " absent"
+		}
+	}
+	return strings.Join(parts, ","), true
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/pkg/log"
+)
+
+// namespaceParam is the query parameter used for restricting the routing table output to a single namespace.
+const namespaceParam = "namespace"
+
+// CurrentRoutes returns the routing table that is currently in use by the Dispatcher.
+func (d *Dispatcher) CurrentRoutes() *routing.Table {
+	d.contextLock.RLock()
+	r := d.context.Routes
+	d.contextLock.RUnlock()
+	return r
+}
+
+// ServeRoutingTable writes the current routing table, along with the per-destination dispatch counters, as JSON.
+// If the "namespace" query parameter is specified, then only the destinations that would be used for requests
+// targeting that namespace are written.
+func (d *Dispatcher) ServeRoutingTable(w http.ResponseWriter, req *http.Request) {
+	r := d.CurrentRoutes()
+
+	var dump *routing.TableDump
+	if ns := req.URL.Query().Get(namespaceParam); ns != "" {
+		dump = r.DumpNamespace(ns)
+	} else {
+		dump = r.Dump()
+	}
+
+	b, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		log.Errorf("Unable to write routing table: %v", err)
+	}
+}
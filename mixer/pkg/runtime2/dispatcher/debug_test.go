@@ -0,0 +1,66 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/runtime2/handler"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+	"istio.io/istio/mixer/pkg/runtime2/testing/util"
+)
+
+func TestServeRoutingTable(t *testing.T) {
+	d := New("ident", gp, true)
+
+	templates := data.BuildTemplates(nil)
+	adapters := data.BuildAdapters(nil)
+	config := data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1)
+
+	s := util.GetSnapshot(templates, adapters, data.ServiceConfig, config)
+	h := handler.NewTable(handler.Empty(), s, nil)
+	r := routing.BuildTable(routing.Empty(), h, s, compiled.NewBuilder(s.Attributes), "istio-system", true)
+	_ = d.ChangeRoute(r)
+
+	if d.CurrentRoutes() != r {
+		t.Fatal("CurrentRoutes should return the table that was set")
+	}
+
+	for _, url := range []string{"/debug/routing", "/debug/routing?namespace=ns2"} {
+		w := httptest.NewRecorder()
+		d.ServeRoutingTable(w, httptest.NewRequest("GET", url, nil))
+
+		if w.Code != 200 {
+			t.Fatalf("%s: unexpected status code: %d", url, w.Code)
+		}
+
+		dump := &routing.TableDump{}
+		if err := json.Unmarshal(w.Body.Bytes(), dump); err != nil {
+			t.Fatalf("%s: unable to unmarshal response: %v", url, err)
+		}
+
+		if dump.ID != r.ID() || len(dump.Varieties) != 1 {
+			t.Fatalf("%s: unexpected table: %+v", url, dump)
+		}
+		ns := dump.Varieties[0].Namespaces
+		if len(ns) != 1 || len(ns[0].Destinations) != 1 || ns[0].Destinations[0].Handler != data.FqnACheck1 {
+			t.Fatalf("%s: unexpected namespaces: %+v", url, ns)
+		}
+	}
+}
@@ -35,6 +35,7 @@ import (
 	tpb "istio.io/api/mixer/v1/template"
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
 	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/remote"
 	"istio.io/istio/mixer/pkg/attribute"
 	"istio.io/istio/mixer/pkg/pool"
 	"istio.io/istio/mixer/pkg/runtime"
@@ -61,9 +62,19 @@ type Dispatcher struct {
 	statePool *dispatchStatePool
 
 	gp *pool.GoroutinePool
+
+	// the maximum number of report instances that are staged, before they get dispatched to the handlers.
+	maxReportBatchSize int
+
+	// accumulates report instances across calls. Nil, if the instances are dispatched at the end of each call.
+	batcher *reportBatcher
 }
 
 var _ runtime.Dispatcher = &Dispatcher{}
+var _ runtime.BatchDispatcher = &Dispatcher{}
+
+// uncacheableCheckResult is combined into the result of a check, when the outcome of a dispatch is unknown.
+var uncacheableCheckResult = adapter.CheckResult{}
 
 // RoutingContext is the currently active dispatching context, based on a config snapshot. As config changes,
 // the current/live RoutingContext also changes.
@@ -93,10 +104,11 @@ func (t *RoutingContext) GetRefs() int32 {
 // New returns a new Dispatcher instance. The Dispatcher instance is initialized with an empty routing table.
 func New(identityAttribute string, handlerGP *pool.GoroutinePool, enableTracing bool) *Dispatcher {
 	return &Dispatcher{
-		identityAttribute: identityAttribute,
-		sessionPool:       newSessionPool(enableTracing),
-		statePool:         newDispatchStatePool(),
-		gp:                handlerGP,
+		identityAttribute:  identityAttribute,
+		sessionPool:        newSessionPool(enableTracing),
+		statePool:          newDispatchStatePool(),
+		gp:                 handlerGP,
+		maxReportBatchSize: DefaultMaxReportBatchSize,
 		context: &RoutingContext{
 			Routes: routing.Empty(),
 		},
@@ -210,7 +222,17 @@ func (d *Dispatcher) dispatch(session *session) error {
 	ndestinations := 0
 	for _, destination := range destinations.Entries() {
 		for _, group := range destination.InstanceGroups {
+			// Dry-run checks are never enforced, so the attributes they reference do not affect the result of the
+			// check, and are not reported to the caller as referenced.
+			untracked := session.variety == tpb.TEMPLATE_VARIETY_CHECK && group.DryRun
+			if untracked {
+				session.trackReferences(false)
+			}
+
 			if !group.Matches(session.bag) || group.ResourceType.IsTCP() != tcp {
+				if untracked {
+					session.trackReferences(true)
+				}
 				continue
 			}
 			ndestinations++
@@ -220,6 +242,7 @@ func (d *Dispatcher) dispatch(session *session) error {
 			// the state, so that we can use its instances field to stage the instance values before dispatch.
 			if session.variety == tpb.TEMPLATE_VARIETY_REPORT {
 				state = d.statePool.get(session, destination)
+				state.failOpen = group.FailOpen
 			}
 
 			for j, input := range group.Builders {
@@ -239,6 +262,11 @@ func (d *Dispatcher) dispatch(session *session) error {
 				// for other templates, dispatch for each instance individually.
 				state = d.statePool.get(session, destination)
 				state.instance = instance
+				state.failOpen = group.FailOpen
+				if session.variety == tpb.TEMPLATE_VARIETY_CHECK {
+					state.dryRun = group.DryRun
+					state.dryRunLog = group.DryRunLog
+				}
 				if session.variety == tpb.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR {
 					state.mapper = group.Mappers[j]
 				}
@@ -247,8 +275,13 @@ func (d *Dispatcher) dispatch(session *session) error {
 				d.dispatchToHandler(state)
 			}
 
+			if untracked {
+				session.trackReferences(true)
+			}
+
 			if session.variety == tpb.TEMPLATE_VARIETY_REPORT {
 				// Do a multi-instance dispatch for report.
+				recordReportBatchSize(destination.HandlerName, len(state.instances))
 				d.dispatchToHandler(state)
 			}
 		}
@@ -263,6 +296,27 @@ func (d *Dispatcher) dispatch(session *session) error {
 		state := <-session.completed
 		session.activeDispatches--
 
+		// Results of dry-run dispatches are recorded, but never enforced.
+		if state.dryRun {
+			recordDryRunCheck(state.destination.HandlerName, state.checkResult.Status, state.err)
+			if state.dryRunLog != nil {
+				d.logDryRunCheck(state)
+			}
+			d.statePool.put(state)
+			continue
+		}
+
+		// Failures of fail-open dispatches are recorded, but never enforced.
+		if state.err != nil && state.failOpen {
+			recordFailOpen(state.destination.HandlerName, state.err)
+			// The handler did not decide, so the result must not be cached.
+			if session.variety == tpb.TEMPLATE_VARIETY_CHECK {
+				session.combineCheckResult(&uncacheableCheckResult)
+			}
+			d.statePool.put(state)
+			continue
+		}
+
 		// Aggregate errors
 		if state.err != nil {
 			err = multierror.Append(err, state.err)
@@ -275,12 +329,10 @@ func (d *Dispatcher) dispatch(session *session) error {
 			// Do nothing
 
 		case tpb.TEMPLATE_VARIETY_CHECK:
-			if session.checkResult == nil {
-				r := state.checkResult
-				session.checkResult = &r
-			} else {
-				session.checkResult.CombineCheckResult(&state.checkResult)
+			if c, ok := state.destination.Handler.(adapter.CacheableHandler); ok {
+				state.checkResult.LimitCacheability(c.Cacheability())
 			}
+			session.combineCheckResult(&state.checkResult)
 			st = state.checkResult.Status
 
 		case tpb.TEMPLATE_VARIETY_QUOTA:
@@ -359,6 +411,7 @@ func (d *Dispatcher) beginSession(ctx context.Context, variety tpb.TemplateVarie
 	s.variety = variety
 	s.ctx = ctx
 	s.bag = bag
+	s.tracker, _ = bag.(attribute.ReferenceTracker)
 
 	return s
 }
@@ -371,6 +424,12 @@ func (d *Dispatcher) completeSession(s *session) {
 func (d *Dispatcher) dispatchToHandler(s *dispatchState) {
 	s.session.activeDispatches++
 
+	// Reject the dispatch right away, without occupying a worker, if the handler is not available.
+	if err := s.destination.Guard.Acquire(); err != nil {
+		s.reject(err)
+		return
+	}
+
 	d.gp.ScheduleWork(doDispatchToHandler, s)
 }
 
@@ -392,16 +451,50 @@ func doDispatchToHandler(param interface{}) {
 			log.Debugf("stack dump for handler dispatch panic:\n%s", debug.Stack())
 		}
 
+		s.destination.Guard.Record(s.err)
 		s.session.completed <- s
 	}()
 
-	ctx := s.session.ctx
+	ctx := s.dispatchContext()
 	var span opentracing.Span
 	var start time.Time
 	span, ctx, start = s.beginSpan(ctx)
 
 	log.Debugf("begin dispatch: destination='%s'", s.destination.FriendlyName)
 
+	// Attribute generators read the request bag while mapping the outputs, so they cannot outlive the dispatch.
+	// They only observe the timeout through the context deadline.
+	timeout := s.destination.Guard.Timeout()
+	if timeout > 0 && s.destination.Template.Variety != tpb.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR {
+		s.invokeHandlerWithTimeout(ctx, timeout)
+	} else {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		s.invokeHandler(ctx)
+	}
+
+	s.destination.Guard.Record(s.err)
+
+	log.Debugf("complete dispatch: destination='%s' {err:%v}", s.destination.FriendlyName, s.err)
+
+	s.completeSpan(span, time.Since(start), s.err)
+	s.session.completed <- s
+
+	reachedEnd = true
+}
+
+// invokeHandler dispatches to the handler, and releases the slot that was acquired from the guard.
+func (s *dispatchState) invokeHandler(ctx context.Context) {
+	defer s.destination.Guard.Release()
+
+	if h, ok := s.destination.Handler.(remote.Handler); ok {
+		s.invokeRemoteHandler(ctx, h)
+		return
+	}
+
 	switch s.destination.Template.Variety {
 	case tpb.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR:
 		s.outputBag, s.err = s.destination.Template.DispatchGenAttrs(
@@ -422,13 +515,86 @@ func doDispatchToHandler(param interface{}) {
 	default:
 		panic(fmt.Sprintf("unknown variety type: '%v'", s.destination.Template.Variety))
 	}
+}
 
-	log.Debugf("complete dispatch: destination='%s' {err:%v}", s.destination.FriendlyName, s.err)
+// invokeRemoteHandler dispatches to the handler of an out-of-process adapter, which accepts instances of any
+// template.
+func (s *dispatchState) invokeRemoteHandler(ctx context.Context, h remote.Handler) {
+	t := s.destination.Template
 
-	s.completeSpan(span, time.Since(start), s.err)
-	s.session.completed <- s
+	switch t.Variety {
+	case tpb.TEMPLATE_VARIETY_CHECK:
+		s.checkResult, s.err = h.HandleRemoteCheck(ctx, t, s.instance)
 
-	reachedEnd = true
+	case tpb.TEMPLATE_VARIETY_REPORT:
+		s.err = h.HandleRemoteReport(ctx, t, s.instances)
+
+	case tpb.TEMPLATE_VARIETY_QUOTA:
+		s.quotaResult, s.err = h.HandleRemoteQuota(ctx, t, s.instance, s.quotaArgs)
+
+	default:
+		s.err = fmt.Errorf("template '%s' is not supported by out-of-process adapters", t.Name)
+	}
+}
+
+// invokeHandlerWithTimeout dispatches to the handler on a copy of the state, and stops waiting for the handler
+// once the timeout elapses. The copy ensures that the state can be safely reused, even if the handler does not
+// return in time.
+func (s *dispatchState) invokeHandlerWithTimeout(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	shadow := *s
+	if len(s.instances) > 0 {
+		shadow.instances = make([]interface{}, len(s.instances))
+		copy(shadow.instances, s.instances)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				shadow.err = fmt.Errorf("panic during handler dispatch: %v", r)
+				log.Errorf("%v", shadow.err)
+			}
+			close(done)
+		}()
+
+		shadow.invokeHandler(ctx)
+	}()
+
+	select {
+	case <-done:
+		s.err = shadow.err
+		s.checkResult = shadow.checkResult
+		s.quotaResult = shadow.quotaResult
+
+	case <-ctx.Done():
+		s.err = ctx.Err()
+		if s.err == context.DeadlineExceeded {
+			s.err = routing.ErrTimeout
+		}
+		log.Warnf("handler dispatch did not complete in time: destination='%s', timeout='%v'",
+			s.destination.FriendlyName, timeout)
+	}
+}
+
+// reject completes the dispatch with the given error, without dispatching to the handler.
+func (s *dispatchState) reject(err error) {
+	log.Debugf("rejected dispatch: destination='%s' {err:%v}", s.destination.FriendlyName, err)
+
+	s.err = err
+	span, _, start := s.beginSpan(s.dispatchContext())
+	s.completeSpan(span, time.Since(start), err)
+
+	select {
+	case s.session.completed <- s:
+	default:
+		// The caller is also the consumer of the channel. Do not block it, if the channel is full.
+		go func() {
+			s.session.completed <- s
+		}()
+	}
 }
 
 func (s *dispatchState) beginSpan(ctx context.Context) (opentracing.Span, context.Context, time.Time) {
@@ -445,4 +611,5 @@ func (s *dispatchState) completeSpan(span opentracing.Span, duration time.Durati
 		logToDispatchSpan(span, s.destination.Template.Name, s.destination.HandlerName, s.destination.AdapterName, err)
 	}
 	s.destination.Counters.Update(duration, err != nil)
+	s.destination.Counters.UpdateGuardFailure(err)
 }
@@ -209,6 +209,115 @@ ident                         : dest.istio-system
 `,
 	},
 
+	{
+		name: "CheckResultLimitedByHandlerCacheability",
+		templates: []data.FakeTemplateSettings{{
+			Name: "tcheck",
+			CheckResults: []adapter.CheckResult{
+				{ValidUseCount: 10, ValidDuration: time.Minute},
+			},
+		}},
+		adapters: []data.FakeAdapterSettings{{
+			Name:         "acheck",
+			Cacheability: adapter.CacheabilityInfo{ValidUseCount: 5, ValidDuration: time.Second},
+		}},
+		config: []string{
+			data.HandlerACheck1,
+			data.InstanceCheck1,
+			data.RuleCheck1,
+		},
+		variety: tpb.TEMPLATE_VARIETY_CHECK,
+		expectedCheckResult: &adapter.CheckResult{
+			ValidUseCount: 5,
+			ValidDuration: time.Second,
+		},
+		log: `
+[tcheck] InstanceBuilderFn() => name: 'tcheck', bag: '---
+ident                         : dest.istio-system
+'
+[tcheck] InstanceBuilderFn() <= (SUCCESS)
+[tcheck] DispatchCheck => instance: '&Empty{}'
+[tcheck] DispatchCheck <= (SUCCESS)
+`,
+	},
+
+	{
+		name: "DryRunCheckIsNotEnforced",
+		templates: []data.FakeTemplateSettings{{
+			Name: "tcheck",
+			CheckResults: []adapter.CheckResult{
+				{
+					Status: rpc.Status{
+						Code:    int32(rpc.PERMISSION_DENIED),
+						Message: "denied",
+					},
+				},
+			},
+		}},
+		config: []string{
+			data.HandlerACheck1,
+			data.InstanceCheck1,
+			data.RuleCheck1DryRun,
+		},
+		variety:             tpb.TEMPLATE_VARIETY_CHECK,
+		expectedCheckResult: nil,
+		log: `
+[tcheck] InstanceBuilderFn() => name: 'tcheck', bag: '---
+ident                         : dest.istio-system
+'
+[tcheck] InstanceBuilderFn() <= (SUCCESS)
+[tcheck] DispatchCheck => instance: '&Empty{}'
+[tcheck] DispatchCheck <= (SUCCESS)
+`,
+	},
+
+	{
+		name: "DryRunCheckErrorIsNotEnforced",
+		templates: []data.FakeTemplateSettings{{
+			Name:                 "tcheck",
+			ErrorOnDispatchCheck: true,
+		}},
+		config: []string{
+			data.HandlerACheck1,
+			data.InstanceCheck1,
+			data.RuleCheck1DryRun,
+		},
+		variety:             tpb.TEMPLATE_VARIETY_CHECK,
+		expectedCheckResult: nil,
+		log: `
+[tcheck] InstanceBuilderFn() => name: 'tcheck', bag: '---
+ident                         : dest.istio-system
+'
+[tcheck] InstanceBuilderFn() <= (SUCCESS)
+[tcheck] DispatchCheck => instance: '&Empty{}'
+[tcheck] DispatchCheck <= (ERROR)
+`,
+	},
+
+	{
+		name: "FailOpenCheckErrorIsNotEnforced",
+		templates: []data.FakeTemplateSettings{{
+			Name:                 "tcheck",
+			ErrorOnDispatchCheck: true,
+		}},
+		config: []string{
+			data.HandlerACheck1,
+			data.InstanceCheck1,
+			data.RuleCheck1FailOpen,
+		},
+		variety: tpb.TEMPLATE_VARIETY_CHECK,
+		// the handler did not decide, so the result must not be cached.
+		expectedCheckResult: &adapter.CheckResult{},
+		log: `
+[tcheck] InstanceBuilderFn() => name: 'tcheck', bag: '---
+ident                         : dest.istio-system
+'
+[tcheck] InstanceBuilderFn() <= (SUCCESS)
+[tcheck] DispatchCheck => instance: '&Empty{}'
+[tcheck] DispatchCheck <= (ERROR)
+`,
+	},
+
 	{
 		name: "BasicReport",
 		config: []string{
@@ -537,7 +646,7 @@ func TestDispatcher(t *testing.T) {
 			h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
 
 			expb := compiled.NewBuilder(s.Attributes)
-			r := routing.BuildTable(h, s, expb, "istio-system", true)
+			r := routing.BuildTable(routing.Empty(), h, s, expb, "istio-system", true)
 			_ = dispatcher.ChangeRoute(r)
 
 			// clear logger, as we are not interested in adapter/template logs during config step.
@@ -628,3 +737,49 @@ func TestRefCount(t *testing.T) {
 	}
 
 }
+
+func TestDispatcher_HandlerLimits(t *testing.T) {
+	for _, failOpen := range []bool{false, true} {
+		block := make(chan struct{})
+
+		d := New("ident", gp, true)
+
+		templates := data.BuildTemplates(nil, data.FakeTemplateSettings{Name: "tcheck", BlockOnDispatchCheck: block})
+		adapters := data.BuildAdapters(nil)
+		rule := data.RuleCheck1
+		if failOpen {
+			rule = data.RuleCheck1FailOpen
+		}
+		config := data.JoinConfigs(data.HandlerACheck1Limited, data.InstanceCheck1, rule)
+
+		s := util.GetSnapshot(templates, adapters, data.ServiceConfig, config)
+		h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
+		r := routing.BuildTable(routing.Empty(), h, s, compiled.NewBuilder(s.Attributes), "istio-system", true)
+		_ = d.ChangeRoute(r)
+
+		bag := attribute.GetFakeMutableBagForTesting(map[string]interface{}{"ident": "dest.istio-system"})
+
+		// The first dispatch times out, which also opens the circuit breaker. The second one is rejected.
+		for _, expected := range []error{routing.ErrTimeout, routing.ErrCircuitOpen} {
+			_, err := d.Check(context.TODO(), bag)
+
+			if failOpen {
+				if err != nil {
+					t.Fatalf("failOpen: unexpected error: %v", err)
+				}
+				continue
+			}
+
+			if err == nil || !strings.Contains(err.Error(), expected.Error()) {
+				t.Fatalf("expected error '%v', got: %v", expected, err)
+			}
+		}
+
+		v := r.GetDestinations(tpb.TEMPLATE_VARIETY_CHECK, "istio-system").Entries()[0].Counters.Values()
+		if v.TimeoutCount == 0 || v.CircuitOpenCount == 0 {
+			t.Fatalf("expected guard failures to be counted: %+v", v)
+		}
+
+		close(block)
+	}
+}
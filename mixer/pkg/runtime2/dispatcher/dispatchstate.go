@@ -15,6 +15,7 @@
 package dispatcher
 
 import (
+	"context"
 	"sync"
 
 	"istio.io/istio/mixer/pkg/adapter"
@@ -28,6 +29,9 @@ import (
 type dispatchState struct {
 	session *session
 
+	// the context of the dispatch. The context of the session is used, if it is not set.
+	ctx context.Context
+
 	destination *routing.Destination
 	mapper      template.OutputMapperFn
 
@@ -36,6 +40,15 @@ type dispatchState struct {
 	instance  interface{}
 	instances []interface{}
 
+	// whether the result of the dispatch should only be recorded, and not enforced.
+	dryRun bool
+
+	// the destination that the result of a dry-run dispatch is logged to, if any.
+	dryRunLog *routing.DryRunLog
+
+	// whether the failure of the dispatch should be ignored.
+	failOpen bool
+
 	// output state that was collected from the handler.
 	err         error
 	outputBag   *attribute.MutableBag
@@ -43,13 +56,25 @@ type dispatchState struct {
 	quotaResult adapter.QuotaResult
 }
 
+// dispatchContext returns the context that the handler is invoked with.
+func (s *dispatchState) dispatchContext() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return s.session.ctx
+}
+
 func (s *dispatchState) clear() {
 	s.session = nil
+	s.ctx = nil
 	s.destination = nil
 	s.mapper = nil
 	s.inputBag = nil
 	s.quotaArgs = adapter.QuotaArgs{}
 	s.instance = nil
+	s.dryRun = false
+	s.dryRunLog = nil
+	s.failOpen = false
 	s.err = nil
 	s.outputBag = nil
 	s.checkResult = adapter.CheckResult{}
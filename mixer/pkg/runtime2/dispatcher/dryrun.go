@@ -0,0 +1,128 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/remote"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/status"
+	"istio.io/istio/mixer/template/logentry"
+	"istio.io/istio/pkg/log"
+)
+
+// The variables that carry the would-be check result in the logentry instances of dry-run logs.
+const (
+	dryRunHandlerVariable = "dry_run_handler"
+	dryRunCodeVariable    = "dry_run_code"
+	dryRunMessageVariable = "dry_run_message"
+)
+
+// dryRunLogTimeout is the deadline for the dispatch of a dry-run log entry, if the log handler has no timeout.
+var dryRunLogTimeout = 10 * time.Second
+
+// dryRunLogDispatch is the state of the dispatch of a dry-run log entry.
+type dryRunLogDispatch struct {
+	ctx      context.Context
+	log      *routing.DryRunLog
+	instance interface{}
+}
+
+// logDryRunCheck builds the logentry instance for the would-be result of a dry-run check dispatch, and dispatches it
+// to the log handler in the background. The instance is built from the request bag, so it must be called before the
+// session completes.
+func (d *Dispatcher) logDryRunCheck(state *dispatchState) {
+	l := state.dryRunLog
+
+	// Like the dry-run check instances, the log instance does not affect the result of the check.
+	state.session.trackReferences(false)
+	instance, err := l.Builder(state.session.bag)
+	state.session.trackReferences(true)
+	if err != nil {
+		log.Warnf("error creating dry-run log instance: handler='%s', error='%v'", l.HandlerName, err)
+		return
+	}
+
+	st := state.checkResult.Status
+	if state.err != nil {
+		st = status.WithError(state.err)
+	}
+	code, found := rpc.Code_name[st.Code]
+	if !found {
+		code = rpc.UNKNOWN.String()
+	}
+
+	if entry, ok := instance.(*logentry.Instance); ok {
+		if entry.Variables == nil {
+			entry.Variables = make(map[string]interface{}, 3)
+		}
+		entry.Variables[dryRunHandlerVariable] = state.destination.HandlerName
+		entry.Variables[dryRunCodeVariable] = code
+		entry.Variables[dryRunMessageVariable] = st.Message
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = time.Now()
+		}
+	}
+
+	// The log entry outlives the request, so only the request data is carried over from the request context.
+	ctx := context.Background()
+	if data, found := adapter.RequestDataFromContext(state.session.ctx); found {
+		ctx = adapter.NewContextWithRequestData(ctx, data)
+	}
+
+	if err = l.Guard.Acquire(); err != nil {
+		log.Warnf("dry-run log dispatch rejected: handler='%s', error='%v'", l.HandlerName, err)
+		return
+	}
+
+	d.gp.ScheduleWork(doDispatchDryRunLog, &dryRunLogDispatch{ctx: ctx, log: l, instance: instance})
+}
+
+// doDispatchDryRunLog dispatches a dry-run log entry to the log handler. It is run on the goroutine pool.
+func doDispatchDryRunLog(param interface{}) {
+	p := param.(*dryRunLogDispatch)
+	l := p.log
+
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during dry-run log dispatch: %v", r)
+		}
+		l.Guard.Release()
+		l.Guard.Record(err)
+		if err != nil {
+			log.Warnf("dry-run log dispatch failed: handler='%s', error='%v'", l.HandlerName, err)
+		}
+	}()
+
+	timeout := l.Guard.Timeout()
+	if timeout <= 0 {
+		timeout = dryRunLogTimeout
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel()
+
+	instances := []interface{}{p.instance}
+	if h, ok := l.Handler.(remote.Handler); ok {
+		err = h.HandleRemoteReport(ctx, l.Template, instances)
+		return
+	}
+	err = l.Template.DispatchReport(ctx, l.Handler, instances)
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime2/handler"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+	"istio.io/istio/mixer/pkg/runtime2/testing/util"
+)
+
+func TestDispatcher_DryRunLog(t *testing.T) {
+	l := &data.Logger{}
+	d := New("ident", gp, true)
+
+	templates := data.BuildTemplatesWithLogEntry(l, data.FakeTemplateSettings{
+		Name: "tcheck",
+		CheckResults: []adapter.CheckResult{{
+			Status: rpc.Status{Code: int32(rpc.PERMISSION_DENIED), Message: "denied"},
+		}},
+	})
+	adapters := data.BuildAdapters(l, data.FakeAdapterSettings{
+		Name:               "areport",
+		SupportedTemplates: []string{"treport", "logentry"},
+	})
+	config := data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1, data.HandlerAReport1, data.InstanceLog1,
+		data.RuleCheck1DryRunLog)
+
+	s := util.GetSnapshot(templates, adapters, data.ServiceConfig, config)
+	h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
+	r := routing.BuildTable(routing.Empty(), h, s, compiled.NewBuilder(s.Attributes), "istio-system", true)
+	_ = d.ChangeRoute(r)
+
+	l.Clear()
+	bag := attribute.GetFakeMutableBagForTesting(map[string]interface{}{"ident": "dest.istio-system"})
+	res, err := d.Check(context.TODO(), bag)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != nil && res.Status.Code != int32(rpc.OK) {
+		t.Fatalf("dry-run check was enforced: %+v", res)
+	}
+
+	// The would-be result is logged in the background.
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(l.String(), "[logentry] DispatchReport <= (SUCCESS)") {
+		if time.Now().After(deadline) {
+			t.Fatalf("the would-be check result was not logged: %s", l.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, expected := range []string{
+		"Name:ilog1.logentry.istio-system",
+		"dry_run_code:PERMISSION_DENIED",
+		"dry_run_handler:hcheck1.acheck.istio-system",
+		"dry_run_message:denied",
+	} {
+		if !strings.Contains(l.String(), expected) {
+			t.Fatalf("expected '%s' in the log entry: %s", expected, l.String())
+		}
+	}
+}
@@ -18,12 +18,20 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/status"
+	"istio.io/istio/pkg/log"
 )
 
 var (
-	buckets           = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
-	countBuckets      = []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 15, 20}
-	requestLabelNames = []string{errorStr}
+	buckets            = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	countBuckets       = []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 15, 20}
+	batchBuckets       = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000}
+	requestLabelNames  = []string{errorStr}
+	dryRunLabelNames   = []string{handlerName, responseCode}
+	batchLabelNames    = []string{handlerName}
+	failOpenLabelNames = []string{handlerName}
 
 	requestCountVector = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "mixer",
@@ -58,6 +66,35 @@ var (
 		Help:      "Histogram of inputs dispatched per request, by Mixer.",
 		Buckets:   countBuckets,
 	})
+
+	dryRunCheckCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "dry_run_check_count",
+		Help:      "Total number of check results from dry-run handlers, by the response code that would have been enforced.",
+	}, dryRunLabelNames)
+
+	failOpenCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "fail_open_count",
+		Help:      "Total number of failed dispatches that were ignored due to the fail-open policy of the rule.",
+	}, failOpenLabelNames)
+
+	reportBatchSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "report_batch_size",
+		Help:      "Histogram of the number of instances dispatched to a handler in a single report call, by handler.",
+		Buckets:   batchBuckets,
+	}, batchLabelNames)
+
+	droppedReportInstanceCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "dispatcher",
+		Name:      "dropped_report_instance_count",
+		Help:      "Total number of batched report instances that were dropped, as their dispatch fell behind, by handler.",
+	}, batchLabelNames)
 )
 
 func init() {
@@ -65,6 +102,10 @@ func init() {
 	prometheus.MustRegister(destinationsPerRequest)
 	prometheus.MustRegister(instancesPerRequest)
 	prometheus.MustRegister(requestDuration)
+	prometheus.MustRegister(dryRunCheckCount)
+	prometheus.MustRegister(failOpenCount)
+	prometheus.MustRegister(reportBatchSize)
+	prometheus.MustRegister(droppedReportInstanceCount)
 }
 
 // updateRequestCounters updates request related counters. Duration is the total request handling duration. Destinations
@@ -82,3 +123,39 @@ func updateRequestCounters(duration time.Duration, destinations int, inputs int,
 	instancesPerRequest.Observe(float64(inputs))
 	requestDuration.Observe(duration.Seconds())
 }
+
+// recordDryRunCheck records the would-be result of a check dispatch to a dry-run handler. Dispatch errors are
+// recorded as if the handler had returned the corresponding status. The would-be denials are also written to the
+// Mixer log as structured "dryRunCheck" entries, with the handler, code and message fields. Rules can additionally
+// log all the would-be results to a log adapter, see logDryRunCheck.
+func recordDryRunCheck(handler string, st rpc.Status, err error) {
+	if err != nil {
+		st = status.WithError(err)
+	}
+
+	code, found := rpc.Code_name[st.Code]
+	if !found {
+		code = rpc.UNKNOWN.String()
+	}
+	dryRunCheckCount.WithLabelValues(handler, code).Inc()
+
+	if !status.IsOK(st) {
+		log.Infow("dryRunCheck", "handler", handler, "code", code, "message", st.Message)
+	}
+}
+
+// recordFailOpen records a failed dispatch, whose failure was ignored due to the fail-open policy.
+func recordFailOpen(handler string, err error) {
+	failOpenCount.WithLabelValues(handler).Inc()
+	log.Warnf("Ignoring dispatch failure due to fail-open policy: handler='%s', error='%v'", handler, err)
+}
+
+// recordReportBatchSize records the number of instances that are dispatched to a handler in a single report call.
+func recordReportBatchSize(handler string, instances int) {
+	reportBatchSize.WithLabelValues(handler).Observe(float64(instances))
+}
+
+// recordDroppedReportInstances records the number of batched report instances for a handler that were dropped.
+func recordDroppedReportInstances(handler string, instances int) {
+	droppedReportInstanceCount.WithLabelValues(handler).Add(float64(instances))
+}
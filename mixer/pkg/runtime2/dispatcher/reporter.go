@@ -0,0 +1,483 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+
+	tpb "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/runtime"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/pkg/log"
+)
+
+// DefaultMaxReportBatchSize is the default upper bound on the number of report instances that are staged, before
+// they get dispatched to the handlers.
+const DefaultMaxReportBatchSize = 1000
+
+// maxPendingReportBatches is the maximum number of accumulated batches that wait to be dispatched. Further batches are
+// dropped, until the dispatch of the pending ones catches up.
+const maxPendingReportBatches = 16
+
+// reportBatchTimeout is the deadline for the dispatch of an accumulated batch of report instances.
+var reportBatchTimeout = 10 * time.Second
+
+// EnableReportBatching configures the batching of report instances. At most maxBatchSize instances are staged
+// before they get dispatched to the handlers. If flushWindow is non-zero, the instances are also accumulated
+// across Report calls, and get dispatched in the background, at most flushWindow after they were staged. In that
+// case, the instances are dispatched along with the request data of the calls that staged them, the dispatch errors
+// of the rules that do not fail open are logged, instead of being returned to the callers, and the instances are
+// dropped if their dispatch falls behind. EnableReportBatching must be called before the Dispatcher starts handling
+// calls.
+func (d *Dispatcher) EnableReportBatching(maxBatchSize int, flushWindow time.Duration) {
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxReportBatchSize
+	}
+	d.maxReportBatchSize = maxBatchSize
+
+	if d.batcher != nil {
+		_ = d.batcher.close()
+		d.batcher = nil
+	}
+	if flushWindow > 0 {
+		d.batcher = newReportBatcher(d, maxBatchSize, flushWindow)
+	}
+}
+
+// Close dispatches any report instances that are still pending dispatch, and stops accumulating report instances
+// across calls.
+func (d *Dispatcher) Close() error {
+	if d.batcher == nil {
+		return nil
+	}
+	return d.batcher.close()
+}
+
+// GetReporter implementation of runtime.BatchDispatcher.
+func (d *Dispatcher) GetReporter(ctx context.Context) runtime.Reporter {
+	return &reporter{
+		d:       d,
+		rc:      d.acquireRoutingContext(),
+		session: d.beginSession(ctx, tpb.TEMPLATE_VARIETY_REPORT, nil),
+		states:  make(map[*routing.Destination]*dispatchState),
+	}
+}
+
+// reporter stages the report instances of a single call, and dispatches them to the handlers in batches.
+type reporter struct {
+	d *Dispatcher
+
+	// the routing context that is used for the whole call.
+	rc *RoutingContext
+
+	session *session
+
+	// whether the context of the session has been updated with the request data.
+	contextUpdated bool
+
+	// the states of the staged dispatches, by destination.
+	states map[*routing.Destination]*dispatchState
+
+	// the number of instances that are currently staged.
+	staged int
+
+	// counters for the whole call.
+	ndestinations int
+	ninputs       int
+	failed        bool
+}
+
+var _ runtime.Reporter = &reporter{}
+
+// Report implementation of runtime.Reporter.
+func (r *reporter) Report(bag attribute.Bag) error {
+	identityAttributeValue, err := getIdentityAttributeValue(bag, r.d.identityAttribute)
+	if err != nil {
+		r.failed = true
+		log.Warnf("unable to determine identity attribute value: '%v', operation='%d'", err, r.session.variety)
+		return err
+	}
+	namespace := getNamespace(identityAttributeValue)
+
+	destinations := r.rc.Routes.GetDestinations(tpb.TEMPLATE_VARIETY_REPORT, namespace)
+
+	// The request data of the first bag is used for the context of the batched dispatches.
+	if !r.contextUpdated {
+		r.session.ctx = r.d.updateContext(r.session.ctx, bag)
+		r.contextUpdated = true
+	}
+
+	// TODO(Issue #2139): This is for old-style metadata based policy decisions. This should be eventually removed.
+	ctxProtocol, _ := bag.Get(config.ContextProtocolAttributeName)
+	tcp := ctxProtocol == config.ContextProtocolTCP
+
+	for _, destination := range destinations.Entries() {
+		for _, group := range destination.InstanceGroups {
+			if !group.Matches(bag) || group.ResourceType.IsTCP() != tcp {
+				continue
+			}
+
+			// All instances of a destination are dispatched together. The dispatch fails open, only if all of
+			// the contributing groups do.
+			state := r.states[destination]
+			if state == nil {
+				state = r.d.statePool.get(r.session, destination)
+				state.failOpen = group.FailOpen
+				r.states[destination] = state
+			} else if !group.FailOpen {
+				state.failOpen = false
+			}
+
+			for _, input := range group.Builders {
+				var instance interface{}
+				if instance, err = input(bag); err != nil {
+					log.Warnf("error creating instance: destination='%v', error='%v'", destination.FriendlyName, err)
+					continue
+				}
+
+				state.instances = append(state.instances, instance)
+				r.staged++
+				r.ninputs++
+			}
+		}
+	}
+
+	// Bound the amount of memory that is used for staging.
+	if r.staged >= r.d.maxReportBatchSize {
+		return r.Flush()
+	}
+
+	return nil
+}
+
+// Flush implementation of runtime.Reporter.
+func (r *reporter) Flush() error {
+	if r.d.batcher != nil && r.d.batcher.add(r.session.ctx, r.rc, r.states) {
+		r.releaseStates()
+		return nil
+	}
+
+	r.session.ensureParallelism(len(r.states))
+
+	for destination, state := range r.states {
+		delete(r.states, destination)
+
+		if len(state.instances) == 0 {
+			r.d.statePool.put(state)
+			continue
+		}
+
+		r.ndestinations++
+		recordReportBatchSize(destination.HandlerName, len(state.instances))
+		r.d.dispatchToHandler(state)
+	}
+	r.staged = 0
+
+	var err error
+	for r.session.activeDispatches > 0 {
+		state := <-r.session.completed
+		r.session.activeDispatches--
+
+		if state.err != nil {
+			if state.failOpen {
+				recordFailOpen(state.destination.HandlerName, state.err)
+			} else {
+				err = multierror.Append(err, state.err)
+			}
+		}
+
+		r.d.statePool.put(state)
+	}
+
+	if err != nil {
+		r.failed = true
+	}
+
+	return err
+}
+
+// Done implementation of runtime.Reporter.
+func (r *reporter) Done() {
+	r.releaseStates()
+	r.rc.DecRef()
+
+	updateRequestCounters(time.Since(r.session.start), r.ndestinations, r.ninputs, r.failed)
+	r.d.completeSession(r.session)
+}
+
+func (r *reporter) releaseStates() {
+	for destination, state := range r.states {
+		delete(r.states, destination)
+		r.d.statePool.put(state)
+	}
+	r.staged = 0
+}
+
+// reportBatcher accumulates report instances across calls, and dispatches them to the handlers, either when the
+// flush window elapses, or when the number of accumulated instances reaches the maximum batch size. The batches are
+// dispatched one at a time, by a single background goroutine.
+type reportBatcher struct {
+	d            *Dispatcher
+	maxBatchSize int
+	flushWindow  time.Duration
+
+	// the batches that wait to be dispatched.
+	batches chan reportBatch
+
+	// closed once all batches have been dispatched, after the batcher is closed.
+	stopped chan struct{}
+
+	mu sync.Mutex
+
+	// the routing context of the pending instances. A reference is held until the instances are dispatched.
+	rc *RoutingContext
+
+	// the pending instances, by destination and request data.
+	pending map[batchKey]*batchEntry
+
+	// the total number of pending instances.
+	staged int
+
+	// the timer for the flush window of the pending instances.
+	timer *time.Timer
+
+	// whether the batcher is closed, and no longer accepts instances.
+	closed bool
+}
+
+// reportBatch is a batch of instances, along with the routing context of their destinations.
+type reportBatch struct {
+	rc      *RoutingContext
+	pending map[batchKey]*batchEntry
+}
+
+// batchKey identifies the instances of a batch that are dispatched together: the instances of the same destination,
+// that were staged by calls with the same request data.
+type batchKey struct {
+	destination *routing.Destination
+	requestData adapter.RequestData
+}
+
+// batchEntry is the pending instances of a batch key.
+type batchEntry struct {
+	instances []interface{}
+
+	// whether the failure of the dispatch should be ignored. It is only set, if all the rules that contributed the
+	// instances fail open.
+	failOpen bool
+}
+
+func newReportBatcher(d *Dispatcher, maxBatchSize int, flushWindow time.Duration) *reportBatcher {
+	b := &reportBatcher{
+		d:            d,
+		maxBatchSize: maxBatchSize,
+		flushWindow:  flushWindow,
+		batches:      make(chan reportBatch, maxPendingReportBatches),
+		stopped:      make(chan struct{}),
+		pending:      make(map[batchKey]*batchEntry),
+	}
+	go b.run()
+	return b
+}
+
+// run dispatches the batches, until the batcher is closed.
+func (b *reportBatcher) run() {
+	for batch := range b.batches {
+		_ = b.dispatch(batch.rc, batch.pending)
+	}
+	close(b.stopped)
+}
+
+// dispatch dispatches a batch of instances, within the deadline for batches.
+func (b *reportBatcher) dispatch(rc *RoutingContext, pending map[batchKey]*batchEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reportBatchTimeout)
+	defer cancel()
+	return b.d.dispatchBatch(ctx, rc, pending)
+}
+
+// add the instances of the given dispatch states to the pending instances, along with the request data of the given
+// context. It returns false if the batcher is closed, in which case the caller is expected to dispatch the instances
+// itself.
+func (b *reportBatcher) add(ctx context.Context, rc *RoutingContext,
+	states map[*routing.Destination]*dispatchState) bool {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false
+	}
+
+	// The destinations are only valid in the context of the routing table that they belong to. Dispatch the
+	// instances of the old routing context, before accepting any instances from the new one.
+	if b.rc != nil && b.rc != rc {
+		b.enqueueLocked(b.takeLocked())
+	}
+
+	if b.rc == nil {
+		rc.IncRef()
+		b.rc = rc
+	}
+
+	key := batchKey{}
+	if data, found := adapter.RequestDataFromContext(ctx); found {
+		key.requestData = *data
+	}
+
+	for destination, state := range states {
+		if len(state.instances) == 0 {
+			continue
+		}
+
+		key.destination = destination
+		entry := b.pending[key]
+		if entry == nil {
+			entry = &batchEntry{failOpen: state.failOpen}
+			b.pending[key] = entry
+		} else if !state.failOpen {
+			entry.failOpen = false
+		}
+
+		entry.instances = append(entry.instances, state.instances...)
+		b.staged += len(state.instances)
+	}
+
+	if b.staged >= b.maxBatchSize {
+		b.enqueueLocked(b.takeLocked())
+	} else if b.timer == nil && b.staged > 0 {
+		b.timer = time.AfterFunc(b.flushWindow, b.flush)
+	}
+
+	return true
+}
+
+// flush queues the pending instances for dispatch. It is called when the flush window elapses.
+func (b *reportBatcher) flush() {
+	b.mu.Lock()
+	if !b.closed {
+		b.enqueueLocked(b.takeLocked())
+	}
+	b.mu.Unlock()
+}
+
+// enqueueLocked queues the given instances for dispatch. The instances are dropped if too many batches are already
+// waiting to be dispatched.
+func (b *reportBatcher) enqueueLocked(rc *RoutingContext, pending map[batchKey]*batchEntry) {
+	if rc == nil {
+		return
+	}
+
+	select {
+	case b.batches <- reportBatch{rc: rc, pending: pending}:
+		return
+	default:
+	}
+
+	dropped := 0
+	for key, entry := range pending {
+		recordDroppedReportInstances(key.destination.HandlerName, len(entry.instances))
+		dropped += len(entry.instances)
+	}
+	log.Warnf("Dropping %d report instances, as %d batches are already waiting to be dispatched", dropped,
+		maxPendingReportBatches)
+	rc.DecRef()
+}
+
+// close stops accepting instances, and dispatches the instances that are still pending.
+func (b *reportBatcher) close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	rc, pending := b.takeLocked()
+	close(b.batches)
+	b.mu.Unlock()
+
+	<-b.stopped
+
+	if rc == nil {
+		return nil
+	}
+	return b.dispatch(rc, pending)
+}
+
+// takeLocked removes and returns the pending instances, along with their routing context.
+func (b *reportBatcher) takeLocked() (*RoutingContext, map[batchKey]*batchEntry) {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	rc, pending := b.rc, b.pending
+	if rc == nil {
+		return nil, nil
+	}
+
+	b.rc = nil
+	b.pending = make(map[batchKey]*batchEntry)
+	b.staged = 0
+
+	return rc, pending
+}
+
+// dispatchBatch dispatches the given instances to their destinations, along with the request data they were staged
+// with, and releases the routing context. The dispatch errors of the entries that fail open are ignored.
+func (d *Dispatcher) dispatchBatch(ctx context.Context, rc *RoutingContext, pending map[batchKey]*batchEntry) error {
+	s := d.beginSession(ctx, tpb.TEMPLATE_VARIETY_REPORT, nil)
+	s.ensureParallelism(len(pending))
+
+	for key, entry := range pending {
+		data := key.requestData
+		state := d.statePool.get(s, key.destination)
+		state.ctx = adapter.NewContextWithRequestData(ctx, &data)
+		state.failOpen = entry.failOpen
+		state.instances = append(state.instances, entry.instances...)
+
+		recordReportBatchSize(key.destination.HandlerName, len(entry.instances))
+		d.dispatchToHandler(state)
+	}
+
+	var err error
+	for s.activeDispatches > 0 {
+		state := <-s.completed
+		s.activeDispatches--
+
+		if state.err != nil {
+			if state.failOpen {
+				recordFailOpen(state.destination.HandlerName, state.err)
+			} else {
+				log.Warnf("batched report dispatch failed: destination='%v', instances='%d', error='%v'",
+					state.destination.FriendlyName, len(state.instances), state.err)
+				err = multierror.Append(err, state.err)
+			}
+		}
+
+		d.statePool.put(state)
+	}
+
+	rc.DecRef()
+	d.completeSession(s)
+
+	return err
+}
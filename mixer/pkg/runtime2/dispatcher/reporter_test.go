@@ -0,0 +1,299 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime2/handler"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+	"istio.io/istio/mixer/pkg/runtime2/testing/util"
+)
+
+func initReportDispatcher(templates ...data.FakeTemplateSettings) (*Dispatcher, *data.Logger) {
+	l := &data.Logger{}
+	return newReportDispatcher(l, templates...), l
+}
+
+func newReportDispatcher(l *data.Logger, templates ...data.FakeTemplateSettings) *Dispatcher {
+	return newReportDispatcherWithRule(l, data.RuleReport1, templates...)
+}
+
+func newReportDispatcherWithRule(l *data.Logger, rule string, templates ...data.FakeTemplateSettings) *Dispatcher {
+	d := New("ident", gp, true)
+
+	t := data.BuildTemplates(l, templates...)
+	a := data.BuildAdapters(l)
+	config := data.JoinConfigs(data.HandlerAReport1, data.InstanceReport1, rule)
+
+	s := util.GetSnapshot(t, a, data.ServiceConfig, config)
+	h := handler.NewTable(handler.Empty(), s, pool.NewGoroutinePool(1, false))
+	r := routing.BuildTable(routing.Empty(), h, s, compiled.NewBuilder(s.Attributes), "istio-system", true)
+	_ = d.ChangeRoute(r)
+
+	l.Clear()
+	return d
+}
+
+func reportBag() attribute.Bag {
+	return reportBagFor("dest.istio-system")
+}
+
+func reportBagFor(destination string) attribute.Bag {
+	return attribute.GetFakeMutableBagForTesting(map[string]interface{}{
+		"ident": destination,
+	})
+}
+
+func TestReporter_Batch(t *testing.T) {
+	d, l := initReportDispatcher()
+
+	r := d.GetReporter(context.TODO())
+	for i := 0; i < 3; i++ {
+		if err := r.Report(reportBag()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if strings.Contains(l.String(), "DispatchReport") {
+		t.Fatalf("instances should not be dispatched before flush: %s", l.String())
+	}
+
+	if err := r.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Done()
+
+	if c := strings.Count(l.String(), "DispatchReport =>"); c != 1 {
+		t.Fatalf("expected a single dispatch, got %d: %s", c, l.String())
+	}
+	if !strings.Contains(l.String(), "DispatchReport => instances: '[&Empty{} &Empty{} &Empty{}]'") {
+		t.Fatalf("expected all instances to be dispatched together: %s", l.String())
+	}
+
+	if refs := d.context.GetRefs(); refs != 0 {
+		t.Fatalf("unexpected ref count on the routing context: %d", refs)
+	}
+}
+
+func TestReporter_MaxBatchSize(t *testing.T) {
+	d, l := initReportDispatcher()
+	d.EnableReportBatching(2, 0)
+
+	r := d.GetReporter(context.TODO())
+	for i := 0; i < 3; i++ {
+		if err := r.Report(reportBag()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if c := strings.Count(l.String(), "DispatchReport =>"); c != 1 {
+		t.Fatalf("expected a dispatch once the max batch size is reached, got %d: %s", c, l.String())
+	}
+
+	if err := r.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Done()
+
+	if c := strings.Count(l.String(), "DispatchReport =>"); c != 2 {
+		t.Fatalf("expected two dispatches, got %d: %s", c, l.String())
+	}
+}
+
+func TestReporter_DispatchError(t *testing.T) {
+	d, _ := initReportDispatcher(data.FakeTemplateSettings{
+		Name:                  "treport",
+		ErrorOnDispatchReport: true,
+	})
+
+	r := d.GetReporter(context.TODO())
+	defer r.Done()
+
+	if err := r.Report(reportBag()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := r.Flush()
+	if err == nil || !strings.Contains(err.Error(), "error at dispatch report, as expected") {
+		t.Fatalf("expected dispatch error, got: %v", err)
+	}
+}
+
+func TestReporter_MissingIdentity(t *testing.T) {
+	d, _ := initReportDispatcher()
+
+	r := d.GetReporter(context.TODO())
+	defer r.Done()
+
+	if err := r.Report(attribute.GetFakeMutableBagForTesting(map[string]interface{}{})); err == nil {
+		t.Fatal("expected error for missing identity attribute")
+	}
+}
+
+func TestReporter_FlushWindow(t *testing.T) {
+	d, l := initReportDispatcher()
+	d.EnableReportBatching(100, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		r := d.GetReporter(context.TODO())
+		if err := r.Report(reportBag()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := r.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		r.Done()
+	}
+
+	if strings.Contains(l.String(), "DispatchReport") {
+		t.Fatalf("instances should not be dispatched before the flush window elapses: %s", l.String())
+	}
+
+	if refs := d.context.GetRefs(); refs != 1 {
+		t.Fatalf("the batcher should hold a reference on the routing context: %d", refs)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(l.String(), "DispatchReport => instances: '[&Empty{} &Empty{}]'") {
+		t.Fatalf("expected instances of both calls to be dispatched together: %s", l.String())
+	}
+
+	if refs := d.context.GetRefs(); refs != 0 {
+		t.Fatalf("unexpected ref count on the routing context: %d", refs)
+	}
+}
+
+func TestReporter_FlushWindowRequestData(t *testing.T) {
+	d, l := initReportDispatcher(data.FakeTemplateSettings{Name: "treport", LogRequestDataOnDispatchReport: true})
+	d.EnableReportBatching(100, time.Hour)
+
+	for _, destination := range []string{"dest1.istio-system", "dest2.istio-system", "dest1.istio-system"} {
+		r := d.GetReporter(context.TODO())
+		if err := r.Report(reportBagFor(destination)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := r.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		r.Done()
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The instances of the calls with the same request data are dispatched together, along with the request data.
+	if c := strings.Count(l.String(), "DispatchReport => instances"); c != 2 {
+		t.Fatalf("expected a dispatch per destination service, got %d: %s", c, l.String())
+	}
+	if !strings.Contains(l.String(), "DispatchReport => instances: '[&Empty{} &Empty{}]'") {
+		t.Fatalf("expected instances of the same destination service to be dispatched together: %s", l.String())
+	}
+	for _, destination := range []string{"dest1.istio-system", "dest2.istio-system"} {
+		if !strings.Contains(l.String(), "DispatchReport => destination: '"+destination+"'") {
+			t.Fatalf("expected the request data of %s to be dispatched: %s", destination, l.String())
+		}
+	}
+}
+
+func TestReporter_FlushWindowFailPolicy(t *testing.T) {
+	for _, failOpen := range []bool{false, true} {
+		rule := data.RuleReport1
+		if failOpen {
+			rule = data.RuleReport1FailOpen
+		}
+
+		l := &data.Logger{}
+		d := newReportDispatcherWithRule(l, rule, data.FakeTemplateSettings{Name: "treport", ErrorOnDispatchReport: true})
+		d.EnableReportBatching(100, time.Hour)
+
+		r := d.GetReporter(context.TODO())
+		if err := r.Report(reportBag()); err != nil {
+			t.Fatalf("failOpen=%v: unexpected error: %v", failOpen, err)
+		}
+		if err := r.Flush(); err != nil {
+			t.Fatalf("failOpen=%v: unexpected error: %v", failOpen, err)
+		}
+		r.Done()
+
+		err := d.Close()
+		if !strings.Contains(l.String(), "DispatchReport <= (ERROR)") {
+			t.Fatalf("failOpen=%v: expected a failed dispatch: %s", failOpen, l.String())
+		}
+		if failOpen && err != nil {
+			t.Fatalf("failOpen=%v: unexpected error: %v", failOpen, err)
+		}
+		if !failOpen && (err == nil || !strings.Contains(err.Error(), "error at dispatch report, as expected")) {
+			t.Fatalf("failOpen=%v: expected dispatch error, got: %v", failOpen, err)
+		}
+	}
+}
+
+func TestReporter_DropBatches(t *testing.T) {
+	// The logger is not used, as the instances are dispatched concurrently to the calls.
+	block := make(chan struct{})
+	d := newReportDispatcher(nil, data.FakeTemplateSettings{Name: "treport", BlockOnDispatchReport: block})
+	d.EnableReportBatching(1, time.Hour)
+
+	report := func() {
+		r := d.GetReporter(context.TODO())
+		if err := r.Report(reportBag()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := r.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		r.Done()
+	}
+
+	// Wait for the first batch to be picked up for dispatch, which then blocks.
+	report()
+	for len(d.batcher.batches) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The next batches wait to be dispatched, and the ones beyond the maximum are dropped.
+	for i := 0; i < maxPendingReportBatches+2; i++ {
+		report()
+	}
+	if refs := d.context.GetRefs(); refs != maxPendingReportBatches+1 {
+		t.Fatalf("the dropped batches should release the routing context: %d", refs)
+	}
+
+	close(block)
+	if err := d.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refs := d.context.GetRefs(); refs != 0 {
+		t.Fatalf("unexpected ref count on the routing context: %d", refs)
+	}
+
+	// Instances are dispatched directly, once the batcher is closed.
+	report()
+	if refs := d.context.GetRefs(); refs != 0 {
+		t.Fatalf("unexpected ref count on the routing context: %d", refs)
+	}
+}
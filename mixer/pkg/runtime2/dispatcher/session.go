@@ -41,6 +41,7 @@ type session struct {
 	// input parameters that was collected as part of the call.
 	ctx             context.Context
 	bag             attribute.Bag
+	tracker         attribute.ReferenceTracker
 	quotaMethodArgs runtime.QuotaMethodArgs
 	responseBag     *attribute.MutableBag
 
@@ -61,6 +62,25 @@ type session struct {
 	trace bool
 }
 
+// trackReferences enables or disables the tracking of the attributes that are referenced through the bag of the
+// session, if the bag keeps track of them.
+func (s *session) trackReferences(enabled bool) {
+	if s.tracker != nil {
+		s.tracker.SetTracking(enabled)
+	}
+}
+
+// combineCheckResult combines the result of a dispatch into the result of the session. The combined result is valid
+// for the shortest duration and use count of the results.
+func (s *session) combineCheckResult(r *adapter.CheckResult) {
+	if s.checkResult == nil {
+		c := *r
+		s.checkResult = &c
+		return
+	}
+	s.checkResult.CombineCheckResult(r)
+}
+
 // pool of sessions
 type sessionPool struct {
 	sessions sync.Pool
@@ -78,6 +98,7 @@ func (s *session) clear() {
 	s.variety = 0
 	s.ctx = nil
 	s.bag = nil
+	s.tracker = nil
 	s.quotaMethodArgs = runtime.QuotaMethodArgs{}
 	s.responseBag = nil
 
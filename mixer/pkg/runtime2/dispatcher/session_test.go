@@ -80,6 +80,7 @@ func TestSession_Clear(t *testing.T) {
 		start:            time.Now(),
 		activeDispatches: 23,
 		bag:              attribute.GetMutableBag(nil),
+		tracker:          attribute.GetMutableBag(nil),
 		completed:        make(chan *dispatchState, 10),
 		err:              errors.New("some error"),
 		ctx:              context.TODO(),
@@ -122,6 +123,22 @@ func TestSession_Clear_LeftOverWork(t *testing.T) {
 	}
 }
 
+func TestSession_CombineCheckResult(t *testing.T) {
+	s := &session{}
+
+	first := adapter.CheckResult{ValidUseCount: 10, ValidDuration: time.Minute}
+	s.combineCheckResult(&first)
+	first.ValidUseCount = 1
+	if !reflect.DeepEqual(s.checkResult, &adapter.CheckResult{ValidUseCount: 10, ValidDuration: time.Minute}) {
+		t.Fatalf("the first result should be copied: %v", s.checkResult)
+	}
+
+	s.combineCheckResult(&adapter.CheckResult{ValidUseCount: 20, ValidDuration: time.Second})
+	if !reflect.DeepEqual(s.checkResult, &adapter.CheckResult{ValidUseCount: 10, ValidDuration: time.Second}) {
+		t.Fatalf("the combined result should be valid for the shortest duration and use count: %v", s.checkResult)
+	}
+}
+
 func TestSession_EnsureParallelism(t *testing.T) {
 	s := &session{
 		completed: make(chan *dispatchState, 10),
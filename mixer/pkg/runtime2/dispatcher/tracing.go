@@ -19,6 +19,7 @@ import (
 	tracelog "github.com/opentracing/opentracing-go/log"
 
 	rpc "istio.io/gogo-genproto/googleapis/google/rpc"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
 	"istio.io/istio/mixer/pkg/status"
 )
 
@@ -29,6 +30,7 @@ const (
 	responseCode = "response_code"
 	responseMsg  = "response_message"
 	errorStr     = "error"
+	guardReason  = "guard_reason"
 )
 
 // LogToDispatchSpan logs to the given Span in a structured manner. Span must be valid.
@@ -46,4 +48,8 @@ func logToDispatchSpan(span opentracing.Span, template string, handler string, a
 		tracelog.String(responseMsg, st.Message),
 		tracelog.Bool(errorStr, err != nil),
 	)
+
+	if reason := routing.GuardFailureReason(err); reason != "" {
+		span.LogFields(tracelog.String(guardReason, reason))
+	}
 }
@@ -23,6 +23,7 @@ import (
 
 	istio_mixer_v1_config_descriptor "istio.io/api/mixer/v1/config/descriptor"
 	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/remote"
 	"istio.io/istio/mixer/pkg/expr"
 	"istio.io/istio/mixer/pkg/il/evaluator"
 	"istio.io/istio/mixer/pkg/runtime2/config"
@@ -148,6 +149,13 @@ func (f *factory) buildHandler(
 
 	for tmplName := range inferredTypes {
 		types = inferredTypes[tmplName]
+
+		// Builders of out-of-process adapters accept the types of any template.
+		if rb, ok := builder.(remote.Builder); ok {
+			rb.SetInstanceTypes(tmplName, types)
+			continue
+		}
+
 		// ti should be there for a valid configuration.
 		ti = f.snapshot.Templates[tmplName]
 		if ti.SetType != nil { // for case like APA template that does not have SetType
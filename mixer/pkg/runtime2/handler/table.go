@@ -15,6 +15,8 @@
 package handler
 
 import (
+	"sort"
+
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/pool"
 	"istio.io/istio/mixer/pkg/runtime2/config"
@@ -25,6 +27,9 @@ import (
 type Table struct {
 	entries map[string]Entry
 
+	// names of the handlers that could not be built.
+	failures []string
+
 	counters tableCounters
 }
 
@@ -86,6 +91,7 @@ func NewTable(old *Table, snapshot *config.Snapshot, gp *pool.GoroutinePool) *Ta
 					"Please remove the handler or fix the configuration.",
 				snapshot.ID, handler.Name, handler.Adapter.Name, err.Error())
 
+			t.failures = append(t.failures, handler.Name)
 			continue
 		}
 
@@ -155,6 +161,13 @@ func (t *Table) Get(handlerName string) (Entry, bool) {
 	return e, true
 }
 
+// BuildFailures returns the names of the handlers that could not be built, in sorted order.
+func (t *Table) BuildFailures() []string {
+	failures := append([]string(nil), t.failures...)
+	sort.Strings(failures)
+	return failures
+}
+
 var emptyTable = &Table{}
 
 // Empty returns an empty table instance.
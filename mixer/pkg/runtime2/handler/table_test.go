@@ -112,6 +112,26 @@ func TestNew_NoReuse_DifferentConfig(t *testing.T) {
 	}
 }
 
+func TestNew_BuildFailures(t *testing.T) {
+	adapters := data.BuildAdapters(nil, data.FakeAdapterSettings{Name: "acheck", ErrorAtBuild: true})
+	templates := data.BuildTemplates(nil)
+
+	s := util.GetSnapshot(templates, adapters, data.ServiceConfig, globalCfg)
+
+	table := NewTable(Empty(), s, nil)
+	if _, found := table.Get(data.FqnACheck1); found {
+		t.Fatal("found")
+	}
+
+	if failures := table.BuildFailures(); len(failures) != 1 || failures[0] != data.FqnACheck1 {
+		t.Fatalf("BuildFailures() => got %v, want [%s]", failures, data.FqnACheck1)
+	}
+
+	if failures := Empty().BuildFailures(); len(failures) != 0 {
+		t.Fatalf("BuildFailures() => got %v, want none", failures)
+	}
+}
+
 func TestTable_Get(t *testing.T) {
 	table := &Table{
 		entries: make(map[string]Entry),
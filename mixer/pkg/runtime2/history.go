@@ -0,0 +1,78 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime2
+
+import (
+	"time"
+
+	"istio.io/istio/mixer/pkg/runtime2/config"
+)
+
+// history keeps the most recent snapshots, in the order they were built.
+type history struct {
+	size    int
+	entries []*historyEntry
+}
+
+// historyEntry is a snapshot in the history.
+type historyEntry struct {
+	snapshot *config.Snapshot
+	created  time.Time
+
+	// names of the handlers that could not be built when the snapshot was applied.
+	failures []string
+
+	// errors of the snapshot, when it was applied.
+	errors []string
+
+	// violations of the namespace limits by the rules of the snapshot, by namespace.
+	violations map[string][]string
+
+	// rejected indicates that the snapshot was discarded, by an automatic rollback or by strict mode.
+	rejected bool
+
+	// pending indicates that the snapshot was built while the runtime was pinned, and has not been applied, nor
+	// validated, yet.
+	pending bool
+}
+
+func newHistory(size int) *history {
+	return &history{size: size}
+}
+
+// add adds a snapshot to the history, and evicts the oldest snapshot if the history is full. If the snapshot is
+// already in the history, its entry is returned.
+func (h *history) add(s *config.Snapshot, created time.Time) *historyEntry {
+	if e := h.get(s.ID); e != nil {
+		return e
+	}
+
+	e := &historyEntry{snapshot: s, created: created}
+	h.entries = append(h.entries, e)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	return e
+}
+
+// get returns the entry of the snapshot with the given id, or nil if the snapshot is not in the history.
+func (h *history) get(id int64) *historyEntry {
+	for _, e := range h.entries {
+		if e.snapshot.ID == id {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime2
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	autoRollbackCount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "auto_rollback_count",
+		Help:      "The number of snapshots that were discarded, as their handlers could not be built.",
+	})
+
+	rollbackCount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "rollback_count",
+		Help:      "The number of rollbacks to previous snapshots.",
+	})
+
+	rejectedCount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "rejected_snapshot_count",
+		Help:      "The number of snapshots that were discarded in strict mode, as they had errors.",
+	})
+
+	snapshotErrorCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "snapshot_errors",
+		Help:      "The number of errors of the most recent snapshot.",
+	})
+
+	limitViolationCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "mixer",
+		Subsystem: "config",
+		Name:      "namespace_limit_violations",
+		Help:      "The number of rules of the most recent snapshot that were left out, as they violate the limits of their namespaces.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(autoRollbackCount)
+	prometheus.MustRegister(rollbackCount)
+	prometheus.MustRegister(rejectedCount)
+	prometheus.MustRegister(snapshotErrorCount)
+	prometheus.MustRegister(limitViolationCount)
+}
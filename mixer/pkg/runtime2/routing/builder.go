@@ -50,7 +50,9 @@ package routing
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	descriptor "istio.io/api/mixer/v1/config/descriptor"
 	tpb "istio.io/api/mixer/v1/template"
@@ -66,7 +68,11 @@ import (
 // builder keeps the ephemeral state while the routing table is built.
 type builder struct {
 	// table that is being built.
-	table                  *Table
+	table *Table
+
+	// the table that is in use, from which the Guards are carried over.
+	old *Table
+
 	handlers               *handler.Table
 	expb                   *compiled.ExpressionBuilder
 	defaultConfigNamespace string
@@ -91,24 +97,80 @@ type builder struct {
 
 	// compiled.Expressions by canonicalized rule match clauses
 	expressions map[string]compiled.Expression
+
+	// Guards by handler name. Nil entries indicate handlers without limits.
+	guards map[string]*Guard
+
+	// errors of the rules and instances that were left out of the table, by rule or instance name.
+	errors map[string]error
 }
 
-// BuildTable builds and returns a routing table. If debugInfo is set, the returned table will have debugging information
-// attached, which will show up in String() call.
+// BuildTable builds and returns a routing table. The Guards of the handlers are carried over from the old table, unless
+// the limits of the handlers changed. If debugInfo is set, the returned table will have debugging information attached,
+// which will show up in String() call.
 func BuildTable(
+	old *Table,
 	handlers *handler.Table,
 	config *config.Snapshot,
 	expb *compiled.ExpressionBuilder,
 	defaultConfigNamespace string,
 	debugInfo bool) *Table {
 
-	b := &builder{
+	b := newBuilder(old, handlers, config, expb, defaultConfigNamespace)
+	b.build(config)
+	b.table.errors = b.sortedErrors()
+	b.table.guards = b.guards
+
+	if debugInfo {
+		b.table.debugInfo = &tableDebugInfo{
+			matchesByID:       b.matchesByID,
+			instanceNamesByID: b.instanceNamesByID,
+		}
+	}
+
+	return b.table
+}
+
+// Validate compiles the match conditions of the rules and the instances of the snapshot, and returns the errors of the
+// rules and instances that would be left out of a routing table built for it. Unlike BuildTable, the instances are
+// compiled regardless of whether the handlers of their actions could be built.
+func Validate(config *config.Snapshot, expb *compiled.ExpressionBuilder) []error {
+	b := newBuilder(Empty(), handler.Empty(), config, expb, "")
+
+	for _, rule := range config.Rules {
+		if _, err := b.getConditionExpression(rule); err != nil {
+			b.errors[rule.Name] = conditionError(rule, err)
+			continue
+		}
+
+		for _, action := range rule.Actions {
+			for _, instance := range action.Instances {
+				if _, _, err := b.getBuilderAndMapper(config.Attributes, instance); err != nil {
+					b.errors[instance.Name] = instanceError(instance, err)
+				}
+			}
+		}
+	}
+
+	return b.sortedErrors()
+}
+
+func newBuilder(
+	old *Table,
+	handlers *handler.Table,
+	config *config.Snapshot,
+	expb *compiled.ExpressionBuilder,
+	defaultConfigNamespace string) *builder {
+
+	return &builder{
 
 		table: &Table{
 			id:      config.ID,
+			created: time.Now(),
 			entries: make(map[tpb.TemplateVariety]*varietyTable, 4),
 		},
 
+		old:      old,
 		handlers: handlers,
 		expb:     expb,
 		defaultConfigNamespace: defaultConfigNamespace,
@@ -120,18 +182,32 @@ func BuildTable(
 		builders:    make(map[string]template.InstanceBuilderFn, len(config.Instances)),
 		mappers:     make(map[string]template.OutputMapperFn, len(config.Instances)),
 		expressions: make(map[string]compiled.Expression, len(config.Rules)),
+		guards:      make(map[string]*Guard, len(config.Handlers)),
+		errors:      make(map[string]error),
 	}
+}
 
-	b.build(config)
+// sortedErrors returns the errors of the rules and instances that were left out of the table, sorted by name.
+func (b *builder) sortedErrors() []error {
+	names := make([]string, 0, len(b.errors))
+	for name := range b.errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	if debugInfo {
-		b.table.debugInfo = &tableDebugInfo{
-			matchesByID:       b.matchesByID,
-			instanceNamesByID: b.instanceNamesByID,
-		}
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, b.errors[name])
 	}
+	return errs
+}
 
-	return b.table
+func conditionError(rule *config.Rule, err error) error {
+	return fmt.Errorf("unable to compile match condition expression of rule '%s': '%s': %v", rule.Name, rule.Match, err)
+}
+
+func instanceError(instance *config.Instance, err error) error {
+	return fmt.Errorf("unable to create builder/mapper for instance '%s': %v", instance.Name, err)
 }
 
 func (b *builder) nextID() uint32 {
@@ -150,10 +226,13 @@ func (b *builder) build(config *config.Snapshot) {
 			log.Warnf("Unable to compile match condition expression: '%v', rule='%s', expression='%s'",
 				err, rule.Name, rule.Match)
 			config.Counters.MatchErrors.Inc()
+			b.errors[rule.Name] = conditionError(rule, err)
 			// Skip the rule
 			continue
 		}
 
+		dryRunLog := b.getDryRunLog(config, rule)
+
 		// For each action, find unique instances to use, and add entries to the map.
 		for i, action := range rule.Actions {
 
@@ -176,11 +255,19 @@ func (b *builder) build(config *config.Snapshot) {
 				builder, mapper, err := b.getBuilderAndMapper(config.Attributes, instance)
 				if err != nil {
 					log.Warnf("Unable to create builder/mapper for instance: instance='%s', err='%v'", instance.Name, err)
+					b.errors[instance.Name] = instanceError(instance, err)
 					continue
 				}
 
+				dryRun := rule.DryRun || action.Handler.DryRun
+				var groupDryRunLog *DryRunLog
+				if dryRun && instance.Template.Variety == tpb.TEMPLATE_VARIETY_CHECK {
+					groupDryRunLog = dryRunLog
+				}
+
 				b.add(rule.Namespace, instance.Template, entry.Adapter, entry.Handler, condition, builder, mapper,
-					entry.Name, instance.Name, rule.Match, rule.ResourceType)
+					entry.Name, instance.Name, rule.Match, rule.ResourceType, dryRun, groupDryRunLog,
+					rule.FailOpen, b.getGuard(action.Handler))
 			}
 		}
 	}
@@ -244,6 +331,53 @@ func (b *builder) getBuilderAndMapper(
 	return builder, mapper, nil
 }
 
+// get the destination that the would-be check results of the dry-run actions of the rule are logged to. Returns nil,
+// if the rule does not log the results, or if the log handler or instance is not usable.
+func (b *builder) getDryRunLog(config *config.Snapshot, rule *config.Rule) *DryRunLog {
+	if rule.DryRunLog == nil {
+		return nil
+	}
+
+	action := rule.DryRunLog
+	entry, found := b.handlers.Get(action.Handler.Name)
+	if !found {
+		log.Warnf("Unable to find the dry-run log handler: rule='%s', handler='%s'", rule.Name, action.Handler.Name)
+		config.Counters.UnsatisfiedActionHandlers.Inc()
+		return nil
+	}
+
+	instance := action.Instances[0]
+	builder, _, err := b.getBuilderAndMapper(config.Attributes, instance)
+	if err != nil {
+		log.Warnf("Unable to create builder for the dry-run log instance: instance='%s', err='%v'", instance.Name, err)
+		b.errors[instance.Name] = instanceError(instance, err)
+		return nil
+	}
+
+	return &DryRunLog{
+		Handler:     entry.Handler,
+		HandlerName: entry.Name,
+		Template:    instance.Template,
+		Builder:     builder,
+		Guard:       b.getGuard(action.Handler),
+	}
+}
+
+// get or create the Guard for the handler. All destinations of a handler share the same Guard, which is carried over
+// from the old table, so that the state of the circuit breaker and the in-flight dispatches survive config changes.
+func (b *builder) getGuard(handler *config.Handler) *Guard {
+	guard, found := b.guards[handler.Name]
+	if !found {
+		guard = b.old.guards[handler.Name]
+		if !guard.hasLimits(handler.Limits) {
+			guard = newGuard(handler.Limits)
+		}
+		b.guards[handler.Name] = guard
+	}
+
+	return guard
+}
+
 // get or create a compiled.Expression for the rule's match clause, if necessary.
 func (b *builder) getConditionExpression(rule *config.Rule) (compiled.Expression, error) {
 	text := strings.TrimSpace(rule.Match)
@@ -285,7 +419,11 @@ func (b *builder) add(
 	handlerName string,
 	instanceName string,
 	matchText string,
-	resourceType config.ResourceType) {
+	resourceType config.ResourceType,
+	dryRun bool,
+	dryRunLog *DryRunLog,
+	failOpen bool,
+	guard *Guard) {
 
 	// Find or create the variety entry.
 	byVariety, found := b.table.entries[t.Variety]
@@ -324,6 +462,7 @@ func (b *builder) add(
 			Template:       t,
 			InstanceGroups: []*InstanceGroup{},
 			Counters:       newDestinationCounters(t.Name, handlerName, a.Name),
+			Guard:          guard,
 		}
 		byNamespace.entries = append(byNamespace.entries, byHandler)
 	}
@@ -333,10 +472,11 @@ func (b *builder) add(
 	// Find or create the input set.
 	var instanceGroup *InstanceGroup
 	for _, set := range byHandler.InstanceGroups {
-		// Try to find an input set to place the entry by comparing the compiled expression and resource type.
-		// This doesn't flatten across all actions, but only for actions coming from the same rule. We can
-		// flatten based on the expression text as well.
-		if set.Condition == condition && set.ResourceType == resourceType {
+		// Try to find an input set to place the entry by comparing the compiled expression, resource type,
+		// dry-run and fail policy settings. This doesn't flatten across all actions, but only for actions coming
+		// from the same rule. We can flatten based on the expression text as well.
+		if set.Condition == condition && set.ResourceType == resourceType && set.DryRun == dryRun &&
+			set.DryRunLog == dryRunLog && set.FailOpen == failOpen {
 			instanceGroup = set
 			break
 		}
@@ -346,7 +486,11 @@ func (b *builder) add(
 		instanceGroup = &InstanceGroup{
 			id:           b.nextID(),
 			Condition:    condition,
+			matchText:    matchText,
 			ResourceType: resourceType,
+			DryRun:       dryRun,
+			DryRunLog:    dryRunLog,
+			FailOpen:     failOpen,
 			Builders:     []template.InstanceBuilderFn{},
 			Mappers:      []template.OutputMapperFn{},
 		}
@@ -66,6 +66,50 @@ ID: 1
 `,
 	},
 
+	{
+		Name:          "dry-run",
+		ServiceConfig: data.ServiceConfig,
+		Configs: []string{
+			data.HandlerACheck1,
+			data.InstanceCheck1,
+			data.RuleCheck1DryRun,
+		},
+
+		ExpectedTable: `
+[Routing ExpectedTable]
+ID: 1
+[#0] TEMPLATE_VARIETY_CHECK {V}
+  [#0] istio-system {NS}
+    [#0] hcheck1.acheck.istio-system {H}
+      [#0]
+        Condition: <NONE>
+        DryRun: true
+        [#0] icheck1.tcheck.istio-system {I}
+`,
+	},
+
+	{
+		Name:          "fail-open",
+		ServiceConfig: data.ServiceConfig,
+		Configs: []string{
+			data.HandlerACheck1Limited,
+			data.InstanceCheck1,
+			data.RuleCheck1FailOpen,
+		},
+
+		ExpectedTable: `
+[Routing ExpectedTable]
+ID: 1
+[#0] TEMPLATE_VARIETY_CHECK {V}
+  [#0] istio-system {NS}
+    [#0] hcheck1.acheck.istio-system {H}
+      [#0]
+        Condition: <NONE>
+        FailOpen: true
+        [#0] icheck1.tcheck.istio-system {I}
+`,
+	},
+
 	{
 		Name:          "multiple-instances",
 		ServiceConfig: data.ServiceConfig,
@@ -522,6 +566,56 @@ func normalize(str string) string {
 }
 
 // Convenience method for building a routing Table for tests.
+func TestBuildTable_Errors(t *testing.T) {
+	table, _ := buildTable(data.ServiceConfig, []string{data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1}, false)
+	if errs := table.Errors(); len(errs) != 0 {
+		t.Fatalf("Errors() => got %v, want none", errs)
+	}
+
+	table, _ = buildTable(data.ServiceConfig, []string{
+		data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1WithBadCondition}, false)
+	errs := table.Errors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "rcheck1.rule.istio-system") {
+		t.Fatalf("Errors() => got %v, want match condition error", errs)
+	}
+
+	templates := data.BuildTemplates(nil, data.FakeTemplateSettings{Name: "tcheck", ErrorAtCreateInstanceBuilder: true})
+	table, _ = buildTableWithTemplatesAndAdapters(templates, data.BuildAdapters(nil), data.ServiceConfig, []string{
+		data.HandlerACheck1, data.InstanceCheck1, data.InstanceCheck2, data.RuleCheck1WithInstance1And2}, false)
+	errs = table.Errors()
+	if len(errs) != 2 ||
+		!strings.Contains(errs[0].Error(), data.FqnI1) ||
+		!strings.Contains(errs[0].Error(), "error at create instance builder") {
+		t.Fatalf("Errors() => got %v, want instance builder errors", errs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	templates := data.BuildTemplates(nil, data.FakeTemplateSettings{Name: "tcheck", ErrorAtCreateInstanceBuilder: true})
+	// The instances are compiled, even though the handler cannot be built.
+	adapters := data.BuildAdapters(nil, data.FakeAdapterSettings{Name: "acheck", ErrorAtBuild: true})
+
+	s := util.GetSnapshot(templates, adapters, data.ServiceConfig, data.JoinConfigs(
+		data.HandlerACheck1, data.InstanceCheck1, data.InstanceCheck2, data.RuleCheck1WithInstance1And2))
+	errs := Validate(s, compiled.NewBuilder(s.Attributes))
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), data.FqnI1) {
+		t.Fatalf("Validate() => got %v, want instance builder errors", errs)
+	}
+
+	s = util.GetSnapshot(data.BuildTemplates(nil), adapters, data.ServiceConfig, data.JoinConfigs(
+		data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1WithBadCondition))
+	errs = Validate(s, compiled.NewBuilder(s.Attributes))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unable to compile match condition") {
+		t.Fatalf("Validate() => got %v, want match condition error", errs)
+	}
+
+	s = util.GetSnapshot(data.BuildTemplates(nil), adapters, data.ServiceConfig, data.JoinConfigs(
+		data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1))
+	if errs = Validate(s, compiled.NewBuilder(s.Attributes)); len(errs) != 0 {
+		t.Fatalf("Validate() => got %v, want none", errs)
+	}
+}
+
 func buildTable(serviceConfig string, globalConfigs []string, debugInfo bool) (*Table, *config.Snapshot) {
 	return buildTableWithTemplatesAndAdapters(data.BuildTemplates(nil), data.BuildAdapters(nil), serviceConfig, globalConfigs, debugInfo)
 }
@@ -540,5 +634,5 @@ func buildTableWithTemplatesAndAdapters(templates map[string]*template.Info, ada
 	ht := handler.NewTable(handler.Empty(), s, nil)
 	expb := compiled.NewBuilder(s.Attributes)
 
-	return BuildTable(ht, s, expb, "istio-system", debugInfo), s
+	return BuildTable(Empty(), ht, s, expb, "istio-system", debugInfo), s
 }
@@ -0,0 +1,211 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"sort"
+	"time"
+
+	tpb "istio.io/api/mixer/v1/template"
+)
+
+// TableDump is a serializable view of a routing table, intended for introspection.
+type TableDump struct {
+	// ID of the table.
+	ID int64 `json:"id"`
+
+	// Created is the time at which the table was built.
+	Created time.Time `json:"created"`
+
+	// Varieties are the per-variety entries of the table, ordered by variety.
+	Varieties []VarietyDump `json:"varieties"`
+}
+
+// VarietyDump is a serializable view of the destinations of a single template variety.
+type VarietyDump struct {
+	// Variety is the name of the template variety.
+	Variety string `json:"variety"`
+
+	// Namespaces are the per-namespace destination sets, ordered by namespace.
+	Namespaces []NamespaceDump `json:"namespaces"`
+}
+
+// NamespaceDump is a serializable view of the destinations for a namespace.
+type NamespaceDump struct {
+	// Namespace of the destination set.
+	Namespace string `json:"namespace"`
+
+	// Destinations in the namespace, ordered by handler name.
+	Destinations []DestinationDump `json:"destinations"`
+}
+
+// DestinationDump is a serializable view of a single destination.
+type DestinationDump struct {
+	Handler  string `json:"handler"`
+	Adapter  string `json:"adapter"`
+	Template string `json:"template"`
+
+	// InstanceGroups that are (conditionally) applied to the handler.
+	InstanceGroups []InstanceGroupDump `json:"instanceGroups"`
+
+	// Counters contains the current dispatch counter values for the destination.
+	Counters CounterValues `json:"counters"`
+}
+
+// InstanceGroupDump is a serializable view of an instance group.
+type InstanceGroupDump struct {
+	// Match is the text of the match condition. It is empty if there is no condition.
+	Match string `json:"match,omitempty"`
+
+	// Instances are the names of the instances in this group. Only available if the table was built
+	// with debug information.
+	Instances []string `json:"instances,omitempty"`
+
+	// InstanceCount is the number of instances in the group.
+	InstanceCount int `json:"instanceCount"`
+
+	// DryRun indicates that the results of the dispatches for this group are not enforced.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// DryRunLog is the name of the handler that the results of the dispatches for this group are logged to.
+	DryRunLog string `json:"dryRunLog,omitempty"`
+
+	// FailOpen indicates that dispatch failures for this group are ignored.
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// Dump returns a serializable view of the whole table.
+func (t *Table) Dump() *TableDump {
+	return t.dump(func(v *varietyTable) map[string]*NamespaceTable {
+		return v.entries
+	})
+}
+
+// DumpNamespace returns a serializable view of the table, that contains only the destinations that apply
+// to the given namespace. If there are no namespace specific entries for a variety, the defaults are used.
+// This mirrors the behavior of GetDestinations.
+func (t *Table) DumpNamespace(namespace string) *TableDump {
+	return t.dump(func(v *varietyTable) map[string]*NamespaceTable {
+		set := v.entries[namespace]
+		if set == nil {
+			set = v.defaultSet
+		}
+		if set == nil {
+			return nil
+		}
+		return map[string]*NamespaceTable{namespace: set}
+	})
+}
+
+func (t *Table) dump(namespaces func(*varietyTable) map[string]*NamespaceTable) *TableDump {
+	result := &TableDump{
+		ID:        t.id,
+		Created:   t.created,
+		Varieties: []VarietyDump{},
+	}
+
+	// Stable sort order for varieties.
+	keys := make([]int, 0, len(t.entries))
+	for k := range t.entries {
+		keys = append(keys, int(k))
+	}
+	sort.Ints(keys)
+
+	for _, k := range keys {
+		variety := tpb.TemplateVariety(k)
+		sets := namespaces(t.entries[variety])
+
+		vd := VarietyDump{
+			Variety:    variety.String(),
+			Namespaces: make([]NamespaceDump, 0, len(sets)),
+		}
+
+		// Stable sort order based on namespaces.
+		names := make([]string, 0, len(sets))
+		for n := range sets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		for _, n := range names {
+			vd.Namespaces = append(vd.Namespaces, NamespaceDump{
+				Namespace:    n,
+				Destinations: sets[n].dump(t.debugInfo),
+			})
+		}
+
+		result.Varieties = append(result.Varieties, vd)
+	}
+
+	return result
+}
+
+func (d *NamespaceTable) dump(debugInfo *tableDebugInfo) []DestinationDump {
+	// Copy and stable sort the entries by the handler name.
+	entries := make([]*Destination, len(d.entries))
+	copy(entries, d.entries)
+	sort.SliceStable(entries, func(i int, j int) bool {
+		return entries[i].HandlerName < entries[j].HandlerName
+	})
+
+	result := make([]DestinationDump, 0, len(entries))
+	for _, entry := range entries {
+		dd := DestinationDump{
+			Handler:        entry.HandlerName,
+			Adapter:        entry.AdapterName,
+			Template:       entry.Template.Name,
+			InstanceGroups: make([]InstanceGroupDump, 0, len(entry.InstanceGroups)),
+			Counters:       entry.Counters.Values(),
+		}
+
+		for _, group := range entry.InstanceGroups {
+			dd.InstanceGroups = append(dd.InstanceGroups, group.dump(debugInfo))
+		}
+
+		// Stable sort order based on match clause text.
+		sort.SliceStable(dd.InstanceGroups, func(i int, j int) bool {
+			return dd.InstanceGroups[i].Match < dd.InstanceGroups[j].Match
+		})
+
+		result = append(result, dd)
+	}
+
+	return result
+}
+
+func (i *InstanceGroup) dump(debugInfo *tableDebugInfo) InstanceGroupDump {
+	result := InstanceGroupDump{
+		InstanceCount: len(i.Builders),
+		DryRun:        i.DryRun,
+		FailOpen:      i.FailOpen,
+	}
+
+	if i.DryRunLog != nil {
+		result.DryRunLog = i.DryRunLog.HandlerName
+	}
+
+	if i.Condition != nil {
+		result.Match = i.matchText
+	}
+
+	if debugInfo != nil {
+		names := make([]string, len(debugInfo.instanceNamesByID[i.id]))
+		copy(names, debugInfo.instanceNamesByID[i.id])
+		sort.Strings(names)
+		result.Instances = names
+	}
+
+	return result
+}
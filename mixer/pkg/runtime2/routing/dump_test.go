@@ -0,0 +1,95 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"testing"
+
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+)
+
+func TestTable_Dump(t *testing.T) {
+	table, _ := buildTable(data.ServiceConfig, []string{data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1WithMatchClause}, true)
+
+	dump := table.Dump()
+	if dump.ID != table.ID() {
+		t.Fatalf("ID mismatch: %d != %d", dump.ID, table.ID())
+	}
+	if !dump.Created.Equal(table.Created()) || dump.Created.IsZero() {
+		t.Fatalf("Created mismatch: %v != %v", dump.Created, table.Created())
+	}
+
+	if len(dump.Varieties) != 1 || dump.Varieties[0].Variety != "TEMPLATE_VARIETY_CHECK" {
+		t.Fatalf("unexpected varieties: %+v", dump.Varieties)
+	}
+
+	namespaces := dump.Varieties[0].Namespaces
+	if len(namespaces) != 1 || namespaces[0].Namespace != "istio-system" {
+		t.Fatalf("unexpected namespaces: %+v", namespaces)
+	}
+
+	destinations := namespaces[0].Destinations
+	if len(destinations) != 1 {
+		t.Fatalf("unexpected destinations: %+v", destinations)
+	}
+	d := destinations[0]
+	if d.Handler != "hcheck1.acheck.istio-system" || d.Template != "tcheck" || d.Adapter != "acheck" {
+		t.Fatalf("unexpected destination: %+v", d)
+	}
+
+	if len(d.InstanceGroups) != 1 {
+		t.Fatalf("unexpected instance groups: %+v", d.InstanceGroups)
+	}
+	g := d.InstanceGroups[0]
+	if g.Match != `match(target.name, "foo*")` {
+		t.Fatalf("unexpected match: %q", g.Match)
+	}
+	if g.InstanceCount != 1 || len(g.Instances) != 1 || g.Instances[0] != "icheck1.tcheck.istio-system" {
+		t.Fatalf("unexpected instances: %+v", g)
+	}
+}
+
+func TestTable_Dump_NoDebugInfo(t *testing.T) {
+	table, _ := buildTable(data.ServiceConfig, []string{data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1WithMatchClause}, false)
+
+	g := table.Dump().Varieties[0].Namespaces[0].Destinations[0].InstanceGroups[0]
+	if g.Match != `match(target.name, "foo*")` {
+		t.Fatalf("unexpected match: %q", g.Match)
+	}
+	if g.InstanceCount != 1 || g.Instances != nil {
+		t.Fatalf("unexpected instances: %+v", g)
+	}
+}
+
+func TestTable_DumpNamespace(t *testing.T) {
+	table, _ := buildTable(data.ServiceConfig, []string{data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1}, true)
+
+	// There are no rules for ns2, so the defaults should be returned.
+	dump := table.DumpNamespace("ns2")
+	namespaces := dump.Varieties[0].Namespaces
+	if len(namespaces) != 1 || namespaces[0].Namespace != "ns2" {
+		t.Fatalf("unexpected namespaces: %+v", namespaces)
+	}
+	if len(namespaces[0].Destinations) != 1 || namespaces[0].Destinations[0].Handler != "hcheck1.acheck.istio-system" {
+		t.Fatalf("unexpected destinations: %+v", namespaces[0].Destinations)
+	}
+}
+
+func TestEmpty_Dump(t *testing.T) {
+	dump := Empty().Dump()
+	if dump.ID != -1 || len(dump.Varieties) != 0 {
+		t.Fatalf("unexpected dump: %+v", dump)
+	}
+}
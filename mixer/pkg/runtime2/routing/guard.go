@@ -0,0 +1,216 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"istio.io/istio/mixer/pkg/runtime2/config"
+)
+
+var (
+	// ErrTimeout is the error for dispatches that did not complete within the handler timeout.
+	ErrTimeout = errors.New("handler dispatch timed out")
+
+	// ErrConcurrencyLimit is the error for dispatches that were rejected, as the handler was at its concurrency limit.
+	ErrConcurrencyLimit = errors.New("handler dispatch rejected: concurrency limit reached")
+
+	// ErrCircuitOpen is the error for dispatches that were rejected, as the circuit breaker of the handler was open.
+	ErrCircuitOpen = errors.New("handler dispatch rejected: circuit breaker is open")
+)
+
+// Guard protects the dispatches to a handler with a timeout, a concurrency limit and a circuit breaker. A Guard
+// is shared by all the destinations of a handler within a routing table, and is carried over to the routing tables
+// that are built after it, as long as the limits of the handler do not change. A nil Guard imposes no limits.
+type Guard struct {
+	// the limits that the Guard was created for.
+	limits config.HandlerLimits
+
+	timeout time.Duration
+
+	// semaphore for limiting the number of concurrent dispatches. Nil, if there is no limit.
+	slots chan struct{}
+
+	// the circuit breaker of the handler. Nil, if circuit breaking is disabled.
+	breaker *circuitBreaker
+}
+
+// newGuard returns a new Guard for the given limits, or nil if no limits are set.
+func newGuard(limits config.HandlerLimits) *Guard {
+	if limits.IsZero() {
+		return nil
+	}
+
+	g := &Guard{
+		limits:  limits,
+		timeout: limits.Timeout,
+	}
+
+	if limits.MaxConcurrency > 0 {
+		g.slots = make(chan struct{}, limits.MaxConcurrency)
+	}
+
+	if limits.CircuitBreakerFailures > 0 {
+		g.breaker = &circuitBreaker{
+			threshold: limits.CircuitBreakerFailures,
+			reset:     limits.CircuitBreakerReset,
+			now:       time.Now,
+		}
+	}
+
+	return g
+}
+
+// hasLimits returns true if the Guard enforces the given limits.
+func (g *Guard) hasLimits(limits config.HandlerLimits) bool {
+	if g == nil {
+		return limits.IsZero()
+	}
+	return g.limits == limits
+}
+
+// Timeout returns the timeout for a single dispatch to the handler. Zero means that there is no timeout.
+func (g *Guard) Timeout() time.Duration {
+	if g == nil {
+		return 0
+	}
+	return g.timeout
+}
+
+// Acquire a slot for a dispatch to the handler. It does not block. If the dispatch is not allowed, either
+// ErrCircuitOpen or ErrConcurrencyLimit is returned. Otherwise, Release must be called once the dispatch completes.
+func (g *Guard) Acquire() error {
+	if g == nil {
+		return nil
+	}
+
+	if g.breaker != nil && !g.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	if g.slots != nil {
+		select {
+		case g.slots <- struct{}{}:
+		default:
+			// The rejection does not reflect on the health of the handler. Give back the trial dispatch, if any.
+			if g.breaker != nil {
+				g.breaker.cancel()
+			}
+			return ErrConcurrencyLimit
+		}
+	}
+
+	return nil
+}
+
+// Release the slot that was acquired for a dispatch.
+func (g *Guard) Release() {
+	if g == nil || g.slots == nil {
+		return
+	}
+	<-g.slots
+}
+
+// Record the outcome of a dispatch in the circuit breaker.
+func (g *Guard) Record(err error) {
+	if g == nil || g.breaker == nil {
+		return
+	}
+	g.breaker.record(err != nil)
+}
+
+// GuardFailureReason returns a short reason text if the error is caused by a Guard, or an empty string otherwise.
+func GuardFailureReason(err error) string {
+	switch err {
+	case ErrTimeout:
+		return "timeout"
+	case ErrConcurrencyLimit:
+		return "concurrency_limit"
+	case ErrCircuitOpen:
+		return "circuit_open"
+	default:
+		return ""
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker opens after a number of consecutive failures. Once open, it rejects all dispatches until the
+// reset duration elapses. Then a single trial dispatch is allowed, which either closes the breaker again if it
+// succeeds, or re-opens it.
+type circuitBreaker struct {
+	threshold int
+	reset     time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.reset {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+
+	case breakerHalfOpen:
+		// A trial dispatch is already in progress.
+		return false
+
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		b.state = breakerClosed
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tpb "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+	"istio.io/istio/mixer/pkg/runtime2/handler"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+	"istio.io/istio/mixer/pkg/runtime2/testing/util"
+)
+
+func TestGuard_Nil(t *testing.T) {
+	g := newGuard(config.HandlerLimits{})
+	if g != nil {
+		t.Fatalf("expected nil guard for zero limits: %+v", g)
+	}
+
+	// All operations should be no-ops.
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g.Record(errors.New("failure"))
+	g.Release()
+	if g.Timeout() != 0 {
+		t.Fatalf("unexpected timeout: %v", g.Timeout())
+	}
+}
+
+func TestGuard_ConcurrencyLimit(t *testing.T) {
+	g := newGuard(config.HandlerLimits{MaxConcurrency: 2, Timeout: time.Second})
+
+	if g.Timeout() != time.Second {
+		t.Fatalf("unexpected timeout: %v", g.Timeout())
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := g.Acquire(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := g.Acquire(); err != ErrConcurrencyLimit {
+		t.Fatalf("expected concurrency limit error, got: %v", err)
+	}
+
+	g.Release()
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("unexpected error after release: %v", err)
+	}
+}
+
+func TestGuard_CircuitBreaker(t *testing.T) {
+	now := time.Now()
+	g := newGuard(config.HandlerLimits{CircuitBreakerFailures: 2, CircuitBreakerReset: time.Minute})
+	g.breaker.now = func() time.Time { return now }
+
+	failure := errors.New("failure")
+
+	// A success resets the failure count.
+	g.Record(failure)
+	g.Record(nil)
+	g.Record(failure)
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("breaker should still be closed: %v", err)
+	}
+
+	// Second consecutive failure opens the breaker.
+	g.Record(failure)
+	if err := g.Acquire(); err != ErrCircuitOpen {
+		t.Fatalf("expected open circuit, got: %v", err)
+	}
+
+	// After the reset duration, a single trial is allowed.
+	now = now.Add(time.Minute)
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("expected trial dispatch to be allowed: %v", err)
+	}
+	if err := g.Acquire(); err != ErrCircuitOpen {
+		t.Fatalf("expected only a single trial dispatch, got: %v", err)
+	}
+
+	// A failed trial re-opens the breaker.
+	g.Record(failure)
+	if err := g.Acquire(); err != ErrCircuitOpen {
+		t.Fatalf("expected open circuit, got: %v", err)
+	}
+
+	// A successful trial closes the breaker.
+	now = now.Add(time.Minute)
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("expected trial dispatch to be allowed: %v", err)
+	}
+	g.Record(nil)
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("breaker should be closed: %v", err)
+	}
+}
+
+func TestGuard_CircuitBreakerTrialRejectedByConcurrencyLimit(t *testing.T) {
+	now := time.Now()
+	g := newGuard(config.HandlerLimits{MaxConcurrency: 1, CircuitBreakerFailures: 1, CircuitBreakerReset: time.Minute})
+	g.breaker.now = func() time.Time { return now }
+
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g.Record(errors.New("failure"))
+
+	now = now.Add(time.Minute)
+	if err := g.Acquire(); err != ErrConcurrencyLimit {
+		t.Fatalf("expected concurrency limit error, got: %v", err)
+	}
+
+	// The trial should be available again, once a slot frees up.
+	g.Release()
+	if err := g.Acquire(); err != nil {
+		t.Fatalf("expected trial dispatch to be allowed: %v", err)
+	}
+}
+
+func TestGuardFailureReason(t *testing.T) {
+	for err, expected := range map[error]string{
+		ErrTimeout:          "timeout",
+		ErrConcurrencyLimit: "concurrency_limit",
+		ErrCircuitOpen:      "circuit_open",
+		errors.New("other"): "",
+		nil:                 "",
+	} {
+		if actual := GuardFailureReason(err); actual != expected {
+			t.Errorf("%v: %q != %q", err, actual, expected)
+		}
+	}
+}
+
+func TestBuilder_SharedGuard(t *testing.T) {
+	table, _ := buildTable(data.ServiceConfig, []string{
+		data.HandlerACheck1Limited, data.InstanceCheck1, data.RuleCheck1,
+	}, true)
+
+	d := table.GetDestinations(tpb.TEMPLATE_VARIETY_CHECK, "istio-system").Entries()
+	if len(d) != 1 || d[0].Guard == nil || d[0].Guard.Timeout() != 50*time.Millisecond {
+		t.Fatalf("expected a guarded destination: %+v", d)
+	}
+}
+
+func TestBuildTable_CarriesGuards(t *testing.T) {
+	build := func(old *Table, handlerConfig string) *Table {
+		s := util.GetSnapshot(data.BuildTemplates(nil), data.BuildAdapters(nil), data.ServiceConfig,
+			data.JoinConfigs(handlerConfig, data.InstanceCheck1, data.RuleCheck1))
+		h := handler.NewTable(handler.Empty(), s, nil)
+		return BuildTable(old, h, s, compiled.NewBuilder(s.Attributes), "istio-system", false)
+	}
+	guard := func(table *Table) *Guard {
+		return table.GetDestinations(tpb.TEMPLATE_VARIETY_CHECK, "istio-system").Entries()[0].Guard
+	}
+
+	t1 := build(Empty(), data.HandlerACheck1Limited)
+	t2 := build(t1, data.HandlerACheck1Limited)
+	if guard(t2) == nil || guard(t2) != guard(t1) {
+		t.Fatalf("expected the guard to be carried over: %p != %p", guard(t2), guard(t1))
+	}
+
+	// The guard is replaced, once the limits of the handler change.
+	t3 := build(t2, strings.Replace(data.HandlerACheck1Limited, "50ms", "100ms", 1))
+	if guard(t3) == guard(t2) || guard(t3).Timeout() != 100*time.Millisecond {
+		t.Fatalf("expected a new guard for the new limits: %+v", guard(t3))
+	}
+
+	t4 := build(t3, data.HandlerACheck1)
+	if guard(t4) != nil {
+		t.Fatalf("expected no guard for a handler without limits: %+v", guard(t4))
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/mixer/pkg/runtime2/config"
+)
+
+// NamespaceLimits are the limits on the rules of a config namespace. Zero values disable the respective limit.
+type NamespaceLimits struct {
+	// MaxRules is the maximum number of rules in the namespace.
+	MaxRules int `json:"maxRules,omitempty"`
+
+	// MaxInstancesPerRule is the maximum number of instances that a rule of the namespace dispatches, over all of
+	// its actions.
+	MaxInstancesPerRule int `json:"maxInstancesPerRule,omitempty"`
+
+	// AllowedAdapters are the names of the adapters that the handlers of the rules of the namespace may use. All
+	// adapters are allowed if it is empty.
+	AllowedAdapters []string `json:"allowedAdapters,omitempty"`
+}
+
+// Limits are the admin-controlled limits on the rules of the config namespaces. The rules of the default config
+// namespace apply globally, and are not subject to any limits.
+type Limits struct {
+	// Default are the limits of the namespaces that have no limits of their own.
+	Default NamespaceLimits `json:"default"`
+
+	// Namespaces are the limits by namespace.
+	Namespaces map[string]NamespaceLimits `json:"namespaces,omitempty"`
+}
+
+// ReadLimits reads the limits from the given JSON or YAML file, e.g.:
+//
+//	{"default": {"maxRules": 20}, "namespaces": {"ns1": {"maxRules": 50, "allowedAdapters": ["prometheus"]}}}
+func ReadLimits(path string) (*Limits, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read namespace limits: %v", err)
+	}
+
+	limits := &Limits{}
+	if err = yaml.Unmarshal(data, limits); err != nil {
+		return nil, fmt.Errorf("unable to parse namespace limits '%s': %v", path, err)
+	}
+	return limits, nil
+}
+
+// Get returns the limits of the given namespace.
+func (l *Limits) Get(namespace string) NamespaceLimits {
+	if nl, found := l.Namespaces[namespace]; found {
+		return nl
+	}
+	return l.Default
+}
+
+// ApplyLimits returns a snapshot that only has the rules of the given snapshot that are within the limits of their
+// namespace, along with the violations of the limits by namespace. The rules of a namespace are considered in the
+// order of their names, and the ones beyond the maximum number of rules are left out. The given snapshot is returned
+// as is if there are no violations.
+func ApplyLimits(s *config.Snapshot, limits *Limits, defaultConfigNamespace string) (*config.Snapshot, map[string][]string) {
+	if limits == nil {
+		return s, nil
+	}
+
+	rules := make([]*config.Rule, len(s.Rules))
+	copy(rules, s.Rules)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+
+	violations := make(map[string][]string)
+	rejected := make(map[*config.Rule]bool)
+	admitted := make(map[string]int)
+	for _, rule := range rules {
+		if rule.Namespace == defaultConfigNamespace {
+			continue
+		}
+
+		nl := limits.Get(rule.Namespace)
+		if err := checkRule(rule, nl); err != "" {
+			violations[rule.Namespace] = append(violations[rule.Namespace], err)
+			rejected[rule] = true
+			continue
+		}
+		if nl.MaxRules > 0 && admitted[rule.Namespace] >= nl.MaxRules {
+			violations[rule.Namespace] = append(violations[rule.Namespace],
+				fmt.Sprintf("rule '%s' exceeds the maximum of %d rules", rule.Name, nl.MaxRules))
+			rejected[rule] = true
+			continue
+		}
+		admitted[rule.Namespace]++
+	}
+
+	if len(rejected) == 0 {
+		return s, nil
+	}
+
+	limited := *s
+	limited.Rules = make([]*config.Rule, 0, len(s.Rules)-len(rejected))
+	for _, rule := range s.Rules {
+		if !rejected[rule] {
+			limited.Rules = append(limited.Rules, rule)
+		}
+	}
+	return &limited, violations
+}
+
+// checkRule returns the violation of the instance and adapter limits by the rule, or an empty string.
+func checkRule(rule *config.Rule, nl NamespaceLimits) string {
+	instances := 0
+	for _, action := range rule.Actions {
+		if len(nl.AllowedAdapters) > 0 && !contains(nl.AllowedAdapters, action.Handler.Adapter.Name) {
+			return fmt.Sprintf("rule '%s' uses handler '%s' of adapter '%s', which is not allowed",
+				rule.Name, action.Handler.Name, action.Handler.Adapter.Name)
+		}
+		instances += len(action.Instances)
+	}
+	if nl.MaxInstancesPerRule > 0 && instances > nl.MaxInstancesPerRule {
+		return fmt.Sprintf("rule '%s' has %d instances, which exceeds the maximum of %d",
+			rule.Name, instances, nl.MaxInstancesPerRule)
+	}
+	return ""
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+)
+
+func limitsSnapshot() *config.Snapshot {
+	acheck := &config.Handler{Name: "hcheck.acheck", Adapter: &adapter.Info{Name: "acheck"}}
+	areport := &config.Handler{Name: "hreport.areport", Adapter: &adapter.Info{Name: "areport"}}
+	i1 := &config.Instance{Name: "i1"}
+	i2 := &config.Instance{Name: "i2"}
+
+	rule := func(name, namespace string, h *config.Handler, instances ...*config.Instance) *config.Rule {
+		return &config.Rule{
+			Name:      name,
+			Namespace: namespace,
+			Actions:   []*config.Action{{Handler: h, Instances: instances}},
+		}
+	}
+
+	return &config.Snapshot{
+		ID: 1,
+		Rules: []*config.Rule{
+			rule("r1.istio-system", "istio-system", areport, i1, i2),
+			rule("r3.ns1", "ns1", acheck, i1),
+			rule("r1.ns1", "ns1", acheck, i1),
+			rule("r2.ns1", "ns1", acheck, i1),
+			rule("r1.ns2", "ns2", acheck, i1, i2),
+			rule("r2.ns2", "ns2", areport, i1),
+		},
+	}
+}
+
+func ruleNames(s *config.Snapshot) []string {
+	var names []string
+	for _, r := range s.Rules {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestApplyLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		limits     *Limits
+		rules      []string
+		violations map[string][]string
+	}{
+		{
+			name:  "NoLimits",
+			rules: []string{"r1.istio-system", "r3.ns1", "r1.ns1", "r2.ns1", "r1.ns2", "r2.ns2"},
+		},
+		{
+			name:   "WithinLimits",
+			limits: &Limits{Default: NamespaceLimits{MaxRules: 3, MaxInstancesPerRule: 2}},
+			rules:  []string{"r1.istio-system", "r3.ns1", "r1.ns1", "r2.ns1", "r1.ns2", "r2.ns2"},
+		},
+		{
+			name:   "MaxRules",
+			limits: &Limits{Default: NamespaceLimits{MaxRules: 2}},
+			rules:  []string{"r1.istio-system", "r1.ns1", "r2.ns1", "r1.ns2", "r2.ns2"},
+			violations: map[string][]string{
+				"ns1": {"rule 'r3.ns1' exceeds the maximum of 2 rules"},
+			},
+		},
+		{
+			name:   "MaxInstancesPerRule",
+			limits: &Limits{Default: NamespaceLimits{MaxInstancesPerRule: 1}},
+			rules:  []string{"r1.istio-system", "r3.ns1", "r1.ns1", "r2.ns1", "r2.ns2"},
+			violations: map[string][]string{
+				"ns2": {"rule 'r1.ns2' has 2 instances, which exceeds the maximum of 1"},
+			},
+		},
+		{
+			name: "AllowedAdapters",
+			limits: &Limits{Namespaces: map[string]NamespaceLimits{
+				"ns2": {AllowedAdapters: []string{"acheck"}},
+			}},
+			rules: []string{"r1.istio-system", "r3.ns1", "r1.ns1", "r2.ns1", "r1.ns2"},
+			violations: map[string][]string{
+				"ns2": {"rule 'r2.ns2' uses handler 'hreport.areport' of adapter 'areport', which is not allowed"},
+			},
+		},
+		{
+			// rejected rules do not count towards the maximum number of rules.
+			name: "NamespaceOverridesDefault",
+			limits: &Limits{
+				Default: NamespaceLimits{MaxRules: 1},
+				Namespaces: map[string]NamespaceLimits{
+					"ns2": {MaxRules: 1, MaxInstancesPerRule: 1},
+				},
+			},
+			rules: []string{"r1.istio-system", "r1.ns1", "r2.ns2"},
+			violations: map[string][]string{
+				"ns1": {
+					"rule 'r2.ns1' exceeds the maximum of 1 rules",
+					"rule 'r3.ns1' exceeds the maximum of 1 rules",
+				},
+				"ns2": {"rule 'r1.ns2' has 2 instances, which exceeds the maximum of 1"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := limitsSnapshot()
+			limited, violations := ApplyLimits(s, tc.limits, "istio-system")
+
+			if got := ruleNames(limited); !reflect.DeepEqual(got, tc.rules) {
+				t.Fatalf("rules => got %v, want %v", got, tc.rules)
+			}
+			if len(violations) != len(tc.violations) || (len(tc.violations) > 0 && !reflect.DeepEqual(violations, tc.violations)) {
+				t.Fatalf("violations => got %v, want %v", violations, tc.violations)
+			}
+			if len(tc.violations) == 0 && limited != s {
+				t.Fatal("got a copy of the snapshot, without violations")
+			}
+			if len(s.Rules) != 6 {
+				t.Fatalf("the given snapshot was modified: %v", ruleNames(s))
+			}
+		})
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "limits.json")
+	data := `{"default": {"maxRules": 2}, "namespaces": {"ns1": {"maxInstancesPerRule": 1, "allowedAdapters": ["acheck"]}}}`
+	if err = ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	limits, err := ReadLimits(path)
+	if err != nil {
+		t.Fatalf("ReadLimits() => unexpected error: %v", err)
+	}
+	want := &Limits{
+		Default: NamespaceLimits{MaxRules: 2},
+		Namespaces: map[string]NamespaceLimits{
+			"ns1": {MaxInstancesPerRule: 1, AllowedAdapters: []string{"acheck"}},
+		},
+	}
+	if !reflect.DeepEqual(limits, want) {
+		t.Fatalf("ReadLimits() => got %+v, want %+v", limits, want)
+	}
+
+	if err = ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadLimits(path); err == nil {
+		t.Fatal("ReadLimits() => got no error for an invalid file")
+	}
+	if _, err = ReadLimits(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("ReadLimits() => got no error for a missing file")
+	}
+}
@@ -18,6 +18,9 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"istio.io/istio/pkg/log"
 )
 
 const (
@@ -25,11 +28,13 @@ const (
 	handlerName  = "handler"
 	adapterName  = "adapter"
 	errorStr     = "error"
+	reasonStr    = "reason"
 )
 
 var (
 	durationBuckets    = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
 	dispatchLabelNames = []string{meshFunction, handlerName, adapterName, errorStr}
+	guardLabelNames    = []string{meshFunction, handlerName, adapterName, reasonStr}
 
 	dispatchCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -47,11 +52,21 @@ var (
 			Help:      "Histogram of durations for adapter dispatches handled by Mixer.",
 			Buckets:   durationBuckets,
 		}, dispatchLabelNames)
+
+	guardFailureCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "mixer",
+			Subsystem: "runtime",
+			Name:      "dispatch_guard_failure_count",
+			Help: "Total number of adapter dispatches that timed out, or were rejected due to a concurrency limit " +
+				"or an open circuit breaker.",
+		}, guardLabelNames)
 )
 
 func init() {
 	prometheus.MustRegister(dispatchCount)
 	prometheus.MustRegister(dispatchDuration)
+	prometheus.MustRegister(guardFailureCount)
 }
 
 // DestinationCounters are used to track the total/failed dispatch counts and dispatch duration for a target destination,
@@ -61,6 +76,10 @@ type DestinationCounters struct {
 	failedTotalCount prometheus.Counter
 	duration         prometheus.Observer
 	failedDuration   prometheus.Observer
+
+	timeoutCount          prometheus.Counter
+	concurrencyLimitCount prometheus.Counter
+	circuitOpenCount      prometheus.Counter
 }
 
 // newDestinationCounters returns a new set of DestinationCounters instance.
@@ -79,11 +98,24 @@ func newDestinationCounters(template string, handler string, adapter string) Des
 		errorStr:     "true",
 	}
 
+	guardLabels := func(err error) prometheus.Labels {
+		return prometheus.Labels{
+			meshFunction: template,
+			handlerName:  handler,
+			adapterName:  adapter,
+			reasonStr:    GuardFailureReason(err),
+		}
+	}
+
 	return DestinationCounters{
 		totalCount:       dispatchCount.With(successLabels),
 		duration:         dispatchDuration.With(successLabels),
 		failedTotalCount: dispatchCount.With(failedLabels),
 		failedDuration:   dispatchDuration.With(failedLabels),
+
+		timeoutCount:          guardFailureCount.With(guardLabels(ErrTimeout)),
+		concurrencyLimitCount: guardFailureCount.With(guardLabels(ErrConcurrencyLimit)),
+		circuitOpenCount:      guardFailureCount.With(guardLabels(ErrCircuitOpen)),
 	}
 }
 
@@ -97,3 +129,85 @@ func (d DestinationCounters) Update(duration time.Duration, failed bool) {
 		d.duration.Observe(duration.Seconds())
 	}
 }
+
+// UpdateGuardFailure updates the guard failure counters, if the dispatch error was caused by a Guard.
+func (d DestinationCounters) UpdateGuardFailure(err error) {
+	var c prometheus.Counter
+	switch err {
+	case ErrTimeout:
+		c = d.timeoutCount
+	case ErrConcurrencyLimit:
+		c = d.concurrencyLimitCount
+	case ErrCircuitOpen:
+		c = d.circuitOpenCount
+	}
+
+	if c != nil {
+		c.Inc()
+	}
+}
+
+// CounterValues is a snapshot of the values of DestinationCounters.
+type CounterValues struct {
+	// TotalCount is the number of successful dispatches.
+	TotalCount uint64 `json:"totalCount"`
+
+	// FailedTotalCount is the number of failed dispatches.
+	FailedTotalCount uint64 `json:"failedTotalCount"`
+
+	// DurationSeconds is the total time spent in successful dispatches.
+	DurationSeconds float64 `json:"durationSeconds"`
+
+	// FailedDurationSeconds is the total time spent in failed dispatches.
+	FailedDurationSeconds float64 `json:"failedDurationSeconds"`
+
+	// TimeoutCount is the number of dispatches that timed out.
+	TimeoutCount uint64 `json:"timeoutCount"`
+
+	// ConcurrencyLimitCount is the number of dispatches that were rejected due to the concurrency limit.
+	ConcurrencyLimitCount uint64 `json:"concurrencyLimitCount"`
+
+	// CircuitOpenCount is the number of dispatches that were rejected due to an open circuit breaker.
+	CircuitOpenCount uint64 `json:"circuitOpenCount"`
+}
+
+// Values returns the current values of the counters. As the underlying metrics are shared by all destinations with
+// the same template/handler/adapter label set, the values are cumulative across routing tables.
+func (d DestinationCounters) Values() CounterValues {
+	return CounterValues{
+		TotalCount:            readCount(d.totalCount),
+		FailedTotalCount:      readCount(d.failedTotalCount),
+		DurationSeconds:       readDurationSum(d.duration),
+		FailedDurationSeconds: readDurationSum(d.failedDuration),
+		TimeoutCount:          readCount(d.timeoutCount),
+		ConcurrencyLimitCount: readCount(d.concurrencyLimitCount),
+		CircuitOpenCount:      readCount(d.circuitOpenCount),
+	}
+}
+
+func readCount(c prometheus.Counter) uint64 {
+	if c == nil {
+		return 0
+	}
+
+	m := dto.Metric{}
+	if err := c.Write(&m); err != nil {
+		log.Warnf("failed to fetch dispatch counter: %v", err)
+		return 0
+	}
+	return uint64(m.GetCounter().GetValue())
+}
+
+func readDurationSum(o prometheus.Observer) float64 {
+	c, ok := o.(prometheus.Metric)
+	if !ok {
+		return 0
+	}
+
+	m := dto.Metric{}
+	if err := c.Write(&m); err != nil {
+		log.Warnf("failed to fetch dispatch duration histogram: %v", err)
+		return 0
+	}
+	return m.GetHistogram().GetSampleSum()
+}
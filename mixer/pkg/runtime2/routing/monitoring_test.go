@@ -15,6 +15,7 @@
 package routing
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -41,3 +42,26 @@ func TestDestinationCounters_Update(t *testing.T) {
 
 	reachedEnd = true
 }
+
+func TestDestinationCounters_Values(t *testing.T) {
+	c := newDestinationCounters("TestDestinationCounters_Values", "h", "a")
+
+	c.Update(time.Second, false)
+	c.Update(time.Second, false)
+	c.Update(2*time.Second, true)
+	c.UpdateGuardFailure(ErrTimeout)
+	c.UpdateGuardFailure(ErrCircuitOpen)
+	c.UpdateGuardFailure(ErrCircuitOpen)
+	c.UpdateGuardFailure(errors.New("not a guard failure"))
+
+	v := c.Values()
+	if v.TotalCount != 2 || v.FailedTotalCount != 1 {
+		t.Fatalf("unexpected counts: %+v", v)
+	}
+	if v.DurationSeconds != 2 || v.FailedDurationSeconds != 2 {
+		t.Fatalf("unexpected durations: %+v", v)
+	}
+	if v.TimeoutCount != 1 || v.ConcurrencyLimitCount != 0 || v.CircuitOpenCount != 2 {
+		t.Fatalf("unexpected guard failure counts: %+v", v)
+	}
+}
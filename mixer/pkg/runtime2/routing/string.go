@@ -136,6 +136,21 @@ func (i *InstanceGroup) write(w io.Writer, indent int, debugInfo *tableDebugInfo
 	}
 	fmt.Fprintln(w)
 
+	if i.DryRun {
+		fmt.Fprintf(w, "%sDryRun: true", idnt)
+		fmt.Fprintln(w)
+	}
+
+	if i.DryRunLog != nil {
+		fmt.Fprintf(w, "%sDryRunLog: %s", idnt, i.DryRunLog.HandlerName)
+		fmt.Fprintln(w)
+	}
+
+	if i.FailOpen {
+		fmt.Fprintf(w, "%sFailOpen: true", idnt)
+		fmt.Fprintln(w)
+	}
+
 	if debugInfo != nil {
 		// Copy and stable sort the input instance names, based on match clause text.
 		instanceNames := make([]string, len(i.Builders))
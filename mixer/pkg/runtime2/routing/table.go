@@ -15,6 +15,8 @@
 package routing
 
 import (
+	"time"
+
 	tpb "istio.io/api/mixer/v1/template"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/attribute"
@@ -31,10 +33,19 @@ type Table struct {
 	// id of this table. This is based on the config snapshot id. IDs are unique within the life-span of a Mixer instance.
 	id int64
 
+	// creation time of this table.
+	created time.Time
+
 	// namespaceTables grouped by variety.
 	entries map[tpb.TemplateVariety]*varietyTable
 
 	debugInfo *tableDebugInfo
+
+	// Guards by handler name. Nil entries indicate handlers without limits.
+	guards map[string]*Guard
+
+	// errors in the rules and instances that were left out of the table.
+	errors []error
 }
 
 // varietyTable contains destination sets for a given template variety. It contains a mapping from namespaces
@@ -83,6 +94,9 @@ type Destination struct {
 
 	// Perf counters for keeping track of dispatches to adapters/handlers.
 	Counters DestinationCounters
+
+	// Guard that protects the dispatches to the handler. Nil, if the handler has no limits.
+	Guard *Guard
 }
 
 // InstanceGroup is a set of instances that needs to be sent to a handler, grouped by a condition expression.
@@ -93,10 +107,23 @@ type InstanceGroup struct {
 	// Condition for applying this instance group.
 	Condition compiled.Expression
 
+	// matchText is the text of the condition. Used for debugging.
+	matchText string
+
 	// TODO(Issue #2139): This should be removed when we stop doing resource-type based checks.
 	// ResourceType is the resource type condition for this instance group.
 	ResourceType config.ResourceType
 
+	// DryRun indicates that the check results of the instances in this group should be recorded, but not enforced.
+	DryRun bool
+
+	// DryRunLog is the destination that the check results of the instances in this group are logged to. Nil, if the
+	// group is not dry-run, or if its results are not logged.
+	DryRunLog *DryRunLog
+
+	// FailOpen indicates that dispatch failures for the instances in this group should be ignored.
+	FailOpen bool
+
 	// Builders for the instances in this group for each instance that should be applied.
 	Builders []template.InstanceBuilderFn
 
@@ -104,6 +131,25 @@ type InstanceGroup struct {
 	Mappers []template.OutputMapperFn
 }
 
+// DryRunLog is a log handler, along with the builder of the logentry instance that records the would-be check results
+// of dry-run instance groups.
+type DryRunLog struct {
+	// Handler to invoke
+	Handler adapter.Handler
+
+	// HandlerName is the name of the handler. Used for monitoring/logging purposes.
+	HandlerName string
+
+	// Template of the instance.
+	Template *template.Info
+
+	// Builder of the logentry instance.
+	Builder template.InstanceBuilderFn
+
+	// Guard that protects the dispatches to the handler. Nil, if the handler has no limits.
+	Guard *Guard
+}
+
 var emptyTable = &Table{id: -1}
 
 // Empty returns an empty routing table.
@@ -116,6 +162,17 @@ func (t *Table) ID() int64 {
 	return t.id
 }
 
+// Errors returns the errors in the rules and instances of the config snapshot that were left out of the table,
+// because their expressions could not be compiled.
+func (t *Table) Errors() []error {
+	return t.errors
+}
+
+// Created returns the time at which the table was built.
+func (t *Table) Created() time.Time {
+	return t.created
+}
+
 // GetDestinations returns the set of destinations (handlers) for the given template variety and for the given namespace.
 func (t *Table) GetDestinations(variety tpb.TemplateVariety, namespace string) *NamespaceTable {
 	destinations, ok := t.entries[variety]
@@ -0,0 +1,415 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runtime2 is the entry point to the runtime2 packages. The Runtime listens to the config store, builds
+// config snapshots and their handler and routing tables, and installs the routing tables on the dispatcher.
+package runtime2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+	"istio.io/istio/mixer/pkg/runtime2/dispatcher"
+	"istio.io/istio/mixer/pkg/runtime2/handler"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/pkg/log"
+)
+
+// DefaultHistorySize is the default number of snapshots that are kept for rollbacks.
+const DefaultHistorySize = 10
+
+// Options are the options of the snapshot history of the Runtime.
+type Options struct {
+	// HistorySize is the number of most recent snapshots that are kept for rollbacks. DefaultHistorySize is used if
+	// it is zero.
+	HistorySize int
+
+	// AutoRollback discards new snapshots, whose handlers cannot all be built, and keeps the current snapshot in use.
+	AutoRollback bool
+
+	// Strict discards new snapshots that have any errors, and keeps the current snapshot in use. The errors of a
+	// snapshot are the config resources that were left out of it, the rules and instances that could not be
+	// compiled, and the handlers that could not be built.
+	Strict bool
+
+	// Limits are the limits on the rules of the config namespaces. The rules that violate them are left out of the
+	// snapshots that are applied, and their handlers are not built. There are no limits if it is nil.
+	Limits *routing.Limits
+}
+
+// Runtime is the main entry point to the Mixer runtime. It listens to config change events from the config store,
+// and installs the routing tables of the resulting snapshots on the dispatcher. The most recent snapshots are kept,
+// which allows the runtime to be rolled back to, and pinned at, a previous snapshot.
+type Runtime struct {
+	defaultConfigNamespace string
+	options                Options
+
+	templates map[string]*template.Info
+	adapters  map[string]*adapter.Info
+	ephemeral *config.Ephemeral
+
+	store      store.Store
+	dispatcher *dispatcher.Dispatcher
+
+	handlerPool *pool.GoroutinePool
+
+	// stateLock serializes the changes of the current snapshot, which are caused by config change events and by
+	// rollbacks, and protects the fields below.
+	stateLock sync.Mutex
+
+	// the snapshot that is currently in use, and its handlers.
+	snapshot *config.Snapshot
+	handlers *handler.Table
+
+	// the most recent snapshots.
+	history *history
+
+	// latest is the most recent snapshot that was built from the config store. While the runtime is pinned, it
+	// differs from the current snapshot.
+	latest *config.Snapshot
+	pinned bool
+
+	shutdown             chan struct{}
+	waitQuiesceListening sync.WaitGroup
+}
+
+// New returns a new Runtime instance. The Runtime uses an empty config until StartListening is called.
+func New(
+	s store.Store,
+	templates map[string]*template.Info,
+	adapters map[string]*adapter.Info,
+	identityAttribute string,
+	defaultConfigNamespace string,
+	handlerPool *pool.GoroutinePool,
+	enableTracing bool,
+	options Options) *Runtime {
+
+	if options.HistorySize <= 0 {
+		options.HistorySize = DefaultHistorySize
+	}
+
+	return &Runtime{
+		defaultConfigNamespace: defaultConfigNamespace,
+		options:                options,
+		templates:              templates,
+		adapters:               adapters,
+		ephemeral:              config.NewEphemeral(templates, adapters),
+		store:                  s,
+		dispatcher:             dispatcher.New(identityAttribute, handlerPool, enableTracing),
+		handlerPool:            handlerPool,
+		snapshot:               config.Empty(),
+		handlers:               handler.Empty(),
+		history:                newHistory(options.HistorySize),
+		latest:                 config.Empty(),
+	}
+}
+
+// Dispatcher returns the dispatcher that the Runtime installs the routing tables on.
+func (c *Runtime) Dispatcher() *dispatcher.Dispatcher {
+	return c.dispatcher
+}
+
+// StartListening initializes the config store, applies its current state, and starts listening to config changes.
+func (c *Runtime) StartListening() error {
+	if c.shutdown != nil {
+		return fmt.Errorf("already listening")
+	}
+
+	kinds := config.KindMap(c.adapters, c.templates)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.store.Init(ctx, kinds); err != nil {
+		cancel()
+		return err
+	}
+
+	// create the watch channel before listing.
+	watchChan, err := c.store.Watch(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.ephemeral.SetState(c.store.List())
+	c.processNewConfig()
+
+	c.shutdown = make(chan struct{})
+	c.waitQuiesceListening.Add(1)
+	go func() {
+		defer c.waitQuiesceListening.Done()
+		defer cancel()
+		watchChanges(watchChan, c.shutdown, c.onConfigChange)
+	}()
+
+	return nil
+}
+
+// StopListening stops listening to config changes.
+func (c *Runtime) StopListening() {
+	if c.shutdown == nil {
+		return
+	}
+	close(c.shutdown)
+	c.waitQuiesceListening.Wait()
+	c.shutdown = nil
+}
+
+func (c *Runtime) onConfigChange(events []*store.Event) {
+	for _, e := range events {
+		c.ephemeral.ApplyEvent(e)
+	}
+	c.processNewConfig()
+}
+
+// processNewConfig builds a snapshot from the current config state, and applies it, unless the runtime is pinned.
+func (c *Runtime) processNewConfig() {
+	s := c.ephemeral.BuildSnapshot()
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	c.latest = s
+	if c.pinned {
+		c.history.add(s, time.Now()).pending = true
+		log.Infof("Runtime is pinned to snapshot %d, not applying snapshot %d", c.snapshot.ID, s.ID)
+		return
+	}
+
+	c.applyLatest()
+}
+
+// applyLatest applies the latest snapshot. The snapshot is discarded if its handlers cannot all be built and auto
+// rollback is enabled, or if it has any errors and strict mode is enabled.
+func (c *Runtime) applyLatest() {
+	s := c.latest
+	if s == c.snapshot {
+		return
+	}
+
+	handlers, r, violations := c.build(s)
+
+	failures := handlers.BuildFailures()
+	errs := snapshotErrors(s, r, failures)
+	snapshotErrorCount.Set(float64(len(errs)))
+	limitViolationCount.Set(float64(countViolations(violations)))
+	if len(violations) > 0 {
+		log.Warnf("Rules of snapshot %d violate the limits of their namespaces: %v", s.ID, violations)
+	}
+
+	e := c.history.add(s, time.Now())
+	e.pending = false
+	e.failures = failures
+	e.errors = errs
+	e.violations = violations
+
+	switch {
+	case len(failures) > 0 && c.options.AutoRollback:
+		autoRollbackCount.Inc()
+		log.Errorf("Discarding snapshot %d, as its handlers could not be built: %v. Snapshot %d stays in use.",
+			s.ID, failures, c.snapshot.ID)
+	case len(errs) > 0 && c.options.Strict:
+		rejectedCount.Inc()
+		log.Errorf("Discarding snapshot %d, as it has %d errors: %v. Snapshot %d stays in use.",
+			s.ID, len(errs), errs, c.snapshot.ID)
+	default:
+		c.install(s, handlers, r)
+		return
+	}
+
+	// Close the handlers that were built for the discarded snapshot.
+	e.rejected = true
+	handlers.Cleanup(c.handlers)
+}
+
+// build builds the handler and routing tables of the snapshot, leaving out the rules that violate the limits of their
+// namespaces. It returns the violations by namespace.
+func (c *Runtime) build(s *config.Snapshot) (*handler.Table, *routing.Table, map[string][]string) {
+	limited, violations := routing.ApplyLimits(s, c.options.Limits, c.defaultConfigNamespace)
+
+	handlers := handler.NewTable(c.handlers, limited, c.handlerPool)
+	expb := compiled.NewBuilder(limited.Attributes)
+	r := routing.BuildTable(c.dispatcher.CurrentRoutes(), handlers, limited, expb, c.defaultConfigNamespace,
+		log.DebugEnabled())
+	return handlers, r, violations
+}
+
+func countViolations(violations map[string][]string) int {
+	n := 0
+	for _, v := range violations {
+		n += len(v)
+	}
+	return n
+}
+
+// snapshotErrors returns the errors of the config resources that were left out of the snapshot, of the rules and
+// instances that were left out of its routing table, and of the handlers that could not be built.
+func snapshotErrors(s *config.Snapshot, r *routing.Table, failures []string) []string {
+	var errs []string
+	for _, err := range s.Errors {
+		errs = append(errs, err.Error())
+	}
+	for _, err := range r.Errors() {
+		errs = append(errs, err.Error())
+	}
+	for _, name := range failures {
+		errs = append(errs, fmt.Sprintf("unable to build handler '%s'", name))
+	}
+	return errs
+}
+
+// install installs the routing table of the snapshot on the dispatcher. The handlers that are no longer in use are
+// closed in the background, once the calls that use the old routing table complete, so that the state lock is not
+// held while waiting for them.
+func (c *Runtime) install(s *config.Snapshot, handlers *handler.Table, r *routing.Table) {
+	oldContext := c.dispatcher.ChangeRoute(r)
+	oldHandlers := c.handlers
+
+	c.snapshot = s
+	c.handlers = handlers
+
+	log.Infof("Installed snapshot %d with %d handlers, %d instances and %d rules", s.ID, len(s.Handlers),
+		len(s.Instances), len(s.Rules))
+
+	go cleanupHandlers(oldContext, oldHandlers, handlers, maxCleanupWait)
+}
+
+// snapshotNotFoundError is returned by Rollback for snapshots that are not in the history.
+type snapshotNotFoundError int64
+
+func (e snapshotNotFoundError) Error() string {
+	return fmt.Sprintf("snapshot %d is not in the history", int64(e))
+}
+
+// Rollback installs a snapshot from the history, and pins the runtime to it. Config changes are still tracked while
+// the runtime is pinned, but they are only applied once the runtime is unpinned. Only the snapshots that were
+// validated when they were applied can be rolled back to, and the runtime is left unchanged if their handlers cannot
+// all be built.
+func (c *Runtime) Rollback(id int64) error {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	e := c.history.get(id)
+	switch {
+	case e == nil:
+		return snapshotNotFoundError(id)
+	case e.pending:
+		return fmt.Errorf("snapshot %d was built while the runtime was pinned, and has not been validated", id)
+	case e.rejected:
+		return fmt.Errorf("snapshot %d was rejected when it was applied", id)
+	}
+
+	if e.snapshot != c.snapshot {
+		handlers, r, _ := c.build(e.snapshot)
+		if failures := handlers.BuildFailures(); len(failures) > 0 {
+			// Close the handlers that were built for the failed rollback.
+			handlers.Cleanup(c.handlers)
+			return fmt.Errorf("handlers of snapshot %d could not be built: %v", id, failures)
+		}
+
+		log.Infof("Rolling back from snapshot %d to snapshot %d", c.snapshot.ID, id)
+		c.install(e.snapshot, handlers, r)
+		rollbackCount.Inc()
+	}
+	c.pinned = true
+	return nil
+}
+
+// Pin pins the runtime to the current snapshot.
+func (c *Runtime) Pin() {
+	c.stateLock.Lock()
+	c.pinned = true
+	c.stateLock.Unlock()
+}
+
+// Unpin resumes applying config changes, and applies the latest snapshot that was built from the config store.
+func (c *Runtime) Unpin() {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if !c.pinned {
+		return
+	}
+	c.pinned = false
+	c.applyLatest()
+}
+
+// maxCleanupWait is the maximum amount of time to wait for the calls that use an old routing table to complete,
+// before closing the handlers that are no longer in use.
+var maxCleanupWait = 10 * time.Second
+
+// cleanupWaitInterval is the interval at which the reference count of an old routing context is checked.
+const cleanupWaitInterval = 100 * time.Millisecond
+
+func cleanupHandlers(oldContext *dispatcher.RoutingContext, oldHandlers *handler.Table, currentHandlers *handler.Table,
+	timeout time.Duration) {
+
+	start := time.Now()
+	for oldContext.GetRefs() > 0 {
+		if time.Since(start) > timeout {
+			log.Warnf("Closing handlers while %d calls still use the old routing table", oldContext.GetRefs())
+			break
+		}
+		time.Sleep(cleanupWaitInterval)
+	}
+
+	oldHandlers.Cleanup(currentHandlers)
+}
+
+// watchFlushDuration is the duration for which config change events are batched, before they are applied.
+var watchFlushDuration = time.Second
+
+// maxEvents is the likely maximum number of events we can expect in a second. It is used to avoid slice
+// reallocation.
+const maxEvents = 50
+
+// watchChanges watches for changes on a channel and applies batches of changes, until the shutdown channel or the
+// watch channel is closed.
+func watchChanges(wch <-chan store.Event, shutdown <-chan struct{}, applyEvents func([]*store.Event)) {
+	var timeChan <-chan time.Time
+	var timer *time.Timer
+	events := make([]*store.Event, 0, maxEvents)
+
+	for {
+		select {
+		case ev, ok := <-wch:
+			if !ok {
+				return
+			}
+			if len(events) == 0 {
+				timer = time.NewTimer(watchFlushDuration)
+				timeChan = timer.C
+			}
+			events = append(events, &ev)
+		case <-timeChan:
+			timer.Stop()
+			timeChan = nil
+			log.Infof("Publishing %d events", len(events))
+			applyEvents(events)
+			events = events[:0]
+		case <-shutdown:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
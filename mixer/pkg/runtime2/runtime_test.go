@@ -0,0 +1,452 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/mixer/pkg/config/storetest"
+	"istio.io/istio/mixer/pkg/pool"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+)
+
+var (
+	cfgCheck  = data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1)
+	cfgCheck2 = data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1, data.InstanceCheck2, data.RuleCheck1WithInstance1And2)
+	cfgReport = data.JoinConfigs(data.HandlerAReport1, data.InstanceReport1, data.RuleReport1)
+)
+
+func newRuntime(t *testing.T, options Options, adapters ...data.FakeAdapterSettings) *Runtime {
+	s, err := storetest.SetupStoreForTest(data.ServiceConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(s, data.BuildTemplates(nil), data.BuildAdapters(nil, adapters...), "ident", "istio-system",
+		pool.NewGoroutinePool(1, false), false, options)
+}
+
+// applyConfig applies the given config to the runtime, as if it was read from the config store.
+func applyConfig(t *testing.T, c *Runtime, cfg string) int64 {
+	s, err := storetest.SetupStoreForTest(data.ServiceConfig, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err = s.Init(ctx, config.KindMap(c.adapters, c.templates)); err != nil {
+		t.Fatal(err)
+	}
+
+	c.ephemeral.SetState(s.List())
+	c.processNewConfig()
+	return c.latest.ID
+}
+
+func current(c *Runtime) int64 {
+	return c.Dispatcher().CurrentRoutes().ID()
+}
+
+func ids(d *SnapshotsDump) []int64 {
+	var result []int64
+	for _, s := range d.Snapshots {
+		result = append(result, s.ID)
+	}
+	return result
+}
+
+func TestRuntime_History(t *testing.T) {
+	c := newRuntime(t, Options{})
+
+	id1 := applyConfig(t, c, cfgCheck)
+	id2 := applyConfig(t, c, cfgCheck2)
+	if current(c) != id2 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id2)
+	}
+
+	d := c.Snapshots()
+	if got, want := ids(d), []int64{id1, id2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("snapshots => got %v, want %v", got, want)
+	}
+	if d.Current != id2 || d.Pinned {
+		t.Fatalf("snapshots => got current=%d, pinned=%v", d.Current, d.Pinned)
+	}
+	if s := d.Snapshots[1]; s.Handlers != 1 || s.Instances != 2 || s.Rules != 1 || s.Created.IsZero() {
+		t.Fatalf("snapshot => got %+v", s)
+	}
+
+	if err := c.Rollback(id1); err != nil {
+		t.Fatalf("Rollback() => unexpected error: %v", err)
+	}
+	if current(c) != id1 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id1)
+	}
+	if _, found := c.handlers.Get(data.FqnACheck1); !found {
+		t.Fatal("handler of the snapshot was not built")
+	}
+
+	// Config changes are tracked, but not applied, while the runtime is pinned.
+	id3 := applyConfig(t, c, cfgReport)
+	if current(c) != id1 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id1)
+	}
+	d = c.Snapshots()
+	if got, want := ids(d), []int64{id1, id2, id3}; !reflect.DeepEqual(got, want) || !d.Pinned {
+		t.Fatalf("snapshots => got %v, pinned=%v, want %v", got, d.Pinned, want)
+	}
+
+	// Snapshots that were built while the runtime is pinned have not been validated, and cannot be rolled back to.
+	if !d.Snapshots[2].Pending {
+		t.Fatalf("snapshot => got %+v, want a pending snapshot", d.Snapshots[2])
+	}
+	if err := c.Rollback(id3); err == nil {
+		t.Fatal("Rollback() => got no error for a pending snapshot")
+	}
+	if current(c) != id1 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id1)
+	}
+
+	c.Unpin()
+	if current(c) != id3 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id3)
+	}
+	if c.Snapshots().Snapshots[2].Pending {
+		t.Fatal("applied snapshot is still pending")
+	}
+	if _, found := c.handlers.Get(data.FqnACheck1); found {
+		t.Fatal("handler of the previous snapshot is still in use")
+	}
+
+	c.Pin()
+	id4 := applyConfig(t, c, cfgCheck)
+	if current(c) != id3 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id3)
+	}
+	c.Unpin()
+	if current(c) != id4 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id4)
+	}
+}
+
+func TestRuntime_OldRoutesInUse(t *testing.T) {
+	defer func(d time.Duration) { maxCleanupWait = d }(maxCleanupWait)
+	maxCleanupWait = time.Minute
+
+	c := newRuntime(t, Options{})
+	id1 := applyConfig(t, c, cfgCheck)
+
+	// The reporter keeps the routing table of the current snapshot in use, until it is done.
+	r := c.Dispatcher().GetReporter(context.Background())
+	defer r.Done()
+
+	done := make(chan struct{})
+	go func() {
+		applyConfig(t, c, cfgCheck2)
+		if err := c.Rollback(id1); err != nil {
+			t.Errorf("Rollback() => unexpected error: %v", err)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config changes were blocked by the calls that use the old routing table")
+	}
+	if current(c) != id1 {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id1)
+	}
+}
+
+func TestRuntime_HistorySize(t *testing.T) {
+	c := newRuntime(t, Options{HistorySize: 2})
+
+	id1 := applyConfig(t, c, cfgCheck)
+	id2 := applyConfig(t, c, cfgCheck2)
+	id3 := applyConfig(t, c, cfgReport)
+
+	if got, want := ids(c.Snapshots()), []int64{id2, id3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("snapshots => got %v, want %v", got, want)
+	}
+	if err := c.Rollback(id1); err == nil {
+		t.Fatal("Rollback() => got no error for an evicted snapshot")
+	}
+	if current(c) != id3 || c.Snapshots().Pinned {
+		t.Fatalf("failed rollback changed the runtime")
+	}
+}
+
+func TestRuntime_AutoRollback(t *testing.T) {
+	failing := data.FakeAdapterSettings{Name: "acheck", ErrorAtBuild: true}
+
+	for _, autoRollback := range []bool{true, false} {
+		c := newRuntime(t, Options{AutoRollback: autoRollback}, failing)
+
+		id1 := applyConfig(t, c, cfgReport)
+		id2 := applyConfig(t, c, data.JoinConfigs(cfgReport, cfgCheck))
+
+		want := id2
+		if autoRollback {
+			want = id1
+		}
+		if current(c) != want {
+			t.Fatalf("autoRollback=%v: current snapshot => got %d, want %d", autoRollback, current(c), want)
+		}
+
+		s := c.Snapshots().Snapshots[1]
+		if s.Rejected != autoRollback || !reflect.DeepEqual(s.BuildFailures, []string{data.FqnACheck1}) ||
+			len(s.Errors) == 0 {
+			t.Fatalf("autoRollback=%v: snapshot => got %+v", autoRollback, s)
+		}
+
+		// The handlers of the current snapshot stay in use.
+		if _, found := c.handlers.Get(data.FqnAReport1); !found {
+			t.Fatalf("autoRollback=%v: handler of the current snapshot is not in use", autoRollback)
+		}
+
+		if !autoRollback {
+			if err := c.Rollback(id1); err != nil {
+				t.Fatalf("Rollback() => unexpected error: %v", err)
+			}
+		}
+
+		// Rejected snapshots, and snapshots whose handlers cannot be built, cannot be rolled back to.
+		if err := c.Rollback(id2); err == nil {
+			t.Fatalf("autoRollback=%v: Rollback() => got no error for snapshot %d", autoRollback, id2)
+		}
+		if current(c) != id1 {
+			t.Fatalf("autoRollback=%v: current snapshot => got %d, want %d", autoRollback, current(c), id1)
+		}
+		if _, found := c.handlers.Get(data.FqnAReport1); !found {
+			t.Fatalf("autoRollback=%v: handler of the current snapshot is not in use", autoRollback)
+		}
+	}
+}
+
+func TestRuntime_Strict(t *testing.T) {
+	for _, strict := range []bool{true, false} {
+		c := newRuntime(t, Options{Strict: strict})
+
+		id1 := applyConfig(t, c, cfgCheck)
+		// The report rule references a handler and an instance that do not exist.
+		id2 := applyConfig(t, c, data.JoinConfigs(cfgCheck, data.RuleReport1))
+
+		want := id2
+		if strict {
+			want = id1
+		}
+		if current(c) != want {
+			t.Fatalf("strict=%v: current snapshot => got %d, want %d", strict, current(c), want)
+		}
+
+		d := c.Snapshots()
+		if s := d.Snapshots[0]; s.Rejected || len(s.Errors) != 0 {
+			t.Fatalf("strict=%v: snapshot => got %+v", strict, s)
+		}
+		if s := d.Snapshots[1]; s.Rejected != strict || len(s.Errors) == 0 {
+			t.Fatalf("strict=%v: snapshot => got %+v", strict, s)
+		}
+
+		st := c.Status()
+		if st.Current != want || st.Latest != id2 || st.Strict != strict || st.Rejected != strict ||
+			!reflect.DeepEqual(st.Errors, d.Snapshots[1].Errors) {
+			t.Fatalf("strict=%v: Status() => got %+v", strict, st)
+		}
+
+		// The handlers of the current snapshot stay in use.
+		if _, found := c.handlers.Get(data.FqnACheck1); !found {
+			t.Fatalf("strict=%v: handler of the current snapshot is not in use", strict)
+		}
+	}
+}
+
+func TestRuntime_StartListening(t *testing.T) {
+	s, err := storetest.SetupStoreForTest(data.ServiceConfig, cfgCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(s, data.BuildTemplates(nil), data.BuildAdapters(nil), "ident", "istio-system",
+		pool.NewGoroutinePool(1, false), false, Options{})
+
+	if err = c.StartListening(); err != nil {
+		t.Fatalf("StartListening() => unexpected error: %v", err)
+	}
+	defer c.StopListening()
+
+	if _, found := c.handlers.Get(data.FqnACheck1); !found {
+		t.Fatal("config of the store was not applied")
+	}
+	if err = c.StartListening(); err == nil {
+		t.Fatal("StartListening() => got no error when already listening")
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	old := watchFlushDuration
+	watchFlushDuration = time.Millisecond
+	defer func() { watchFlushDuration = old }()
+
+	wch := make(chan store.Event)
+	shutdown := make(chan struct{})
+	applied := make(chan []*store.Event)
+	done := make(chan struct{})
+	go func() {
+		watchChanges(wch, shutdown, func(events []*store.Event) {
+			applied <- append([]*store.Event(nil), events...)
+		})
+		close(done)
+	}()
+
+	wch <- store.Event{Key: store.Key{Name: "r1"}}
+	if events := <-applied; len(events) != 1 || events[0].Name != "r1" {
+		t.Fatalf("applied events => got %v", events)
+	}
+
+	close(shutdown)
+	<-done
+}
+
+func TestServeSnapshots(t *testing.T) {
+	c := newRuntime(t, Options{})
+	id1 := applyConfig(t, c, cfgCheck)
+	id2 := applyConfig(t, c, cfgReport)
+
+	cases := []struct {
+		method  string
+		query   string
+		code    int
+		current int64
+		pinned  bool
+	}{
+		{http.MethodGet, "", http.StatusOK, id2, false},
+		{http.MethodPost, "action=rollback&id=" + strconv.FormatInt(id1, 10), http.StatusOK, id1, true},
+		{http.MethodPost, "action=unpin", http.StatusOK, id2, false},
+		{http.MethodPost, "action=pin", http.StatusOK, id2, true},
+		{http.MethodPost, "action=rollback&id=foo", http.StatusBadRequest, 0, false},
+		{http.MethodPost, "action=rollback&id=42", http.StatusNotFound, 0, false},
+		{http.MethodPost, "action=foo", http.StatusBadRequest, 0, false},
+		{http.MethodPut, "", http.StatusMethodNotAllowed, 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/debug/snapshots?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			c.ServeSnapshots(w, req)
+
+			if w.Code != tc.code {
+				t.Fatalf("ServeSnapshots() => got status %d, want %d: %s", w.Code, tc.code, w.Body.String())
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+
+			d := &SnapshotsDump{}
+			if err := json.Unmarshal(w.Body.Bytes(), d); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, w.Body.String())
+			}
+			if d.Current != tc.current || d.Pinned != tc.pinned || len(d.Snapshots) != 2 {
+				t.Fatalf("ServeSnapshots() => got %+v", d)
+			}
+		})
+	}
+}
+
+func TestServeConfigStatus(t *testing.T) {
+	c := newRuntime(t, Options{Strict: true})
+	id1 := applyConfig(t, c, cfgCheck)
+	id2 := applyConfig(t, c, data.JoinConfigs(cfgCheck, data.RuleReport1))
+
+	w := httptest.NewRecorder()
+	c.ServeConfigStatus(w, httptest.NewRequest(http.MethodGet, "/debug/config_status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ServeConfigStatus() => got status %d: %s", w.Code, w.Body.String())
+	}
+
+	st := &ConfigStatus{}
+	if err := json.Unmarshal(w.Body.Bytes(), st); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, w.Body.String())
+	}
+	if st.Current != id1 || st.Latest != id2 || !st.Strict || !st.Rejected || len(st.Errors) == 0 {
+		t.Fatalf("ServeConfigStatus() => got %+v", st)
+	}
+
+	w = httptest.NewRecorder()
+	c.ServeConfigStatus(w, httptest.NewRequest(http.MethodPost, "/debug/config_status", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("ServeConfigStatus() => got status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServeNamespaceStatus(t *testing.T) {
+	limits := &routing.Limits{
+		Namespaces: map[string]routing.NamespaceLimits{
+			"ns2": {AllowedAdapters: []string{"areport"}},
+			"ns3": {MaxRules: 1},
+		},
+	}
+	c := newRuntime(t, Options{Strict: true, Limits: limits})
+	id := applyConfig(t, c, data.JoinConfigs(cfgCheck, data.HandlerACheck3NS2, data.InstanceCheck4NS2, data.RuleCheck3NS2))
+
+	// the violations are not errors of the snapshot, and the rule is left out of it.
+	if current(c) != id {
+		t.Fatalf("current snapshot => got %d, want %d", current(c), id)
+	}
+	if _, found := c.handlers.Get("hcheck3.acheck.ns2"); found {
+		t.Fatal("handler of the rule that violates the limits was built")
+	}
+	if _, found := c.handlers.Get(data.FqnACheck1); !found {
+		t.Fatal("handler of the default namespace was not built")
+	}
+
+	w := httptest.NewRecorder()
+	c.ServeNamespaceStatus(w, httptest.NewRequest(http.MethodGet, "/debug/namespace_status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ServeNamespaceStatus() => got status %d: %s", w.Code, w.Body.String())
+	}
+
+	st := &NamespacesStatus{}
+	if err := json.Unmarshal(w.Body.Bytes(), st); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, w.Body.String())
+	}
+	if st.Snapshot != id || len(st.Namespaces) != 3 {
+		t.Fatalf("ServeNamespaceStatus() => got %+v", st)
+	}
+	if ns := st.Namespaces["istio-system"]; ns.Rules != 1 || len(ns.Violations) != 0 {
+		t.Fatalf("status of istio-system => got %+v", ns)
+	}
+	if ns := st.Namespaces["ns2"]; ns.Rules != 0 || len(ns.Violations) != 1 || !reflect.DeepEqual(ns.Limits, limits.Namespaces["ns2"]) {
+		t.Fatalf("status of ns2 => got %+v", ns)
+	}
+	if ns := st.Namespaces["ns3"]; ns.Rules != 0 || ns.Limits.MaxRules != 1 {
+		t.Fatalf("status of ns3 => got %+v", ns)
+	}
+
+	w = httptest.NewRecorder()
+	c.ServeNamespaceStatus(w, httptest.NewRequest(http.MethodPost, "/debug/namespace_status", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("ServeNamespaceStatus() => got status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -212,7 +212,13 @@ func (f *FakeHandler) Close() error {
 	return nil
 }
 
+// Cacheability is an implementation of adapter.CacheableHandler.Cacheability.
+func (f *FakeHandler) Cacheability() adapter.CacheabilityInfo {
+	return f.settings.Cacheability
+}
+
 var _ adapter.Handler = &FakeHandler{}
+var _ adapter.CacheableHandler = &FakeHandler{}
 
 // FakeAdapterSettings describes the behavior of a fake adapter.
 type FakeAdapterSettings struct {
@@ -232,4 +238,7 @@ type FakeAdapterSettings struct {
 	CloseGoRoutines         bool
 
 	SupportedTemplates []string
+
+	// Cacheability is the limits on the validity of the results of the handler.
+	Cacheability adapter.CacheabilityInfo
 }
@@ -145,6 +145,21 @@ metadata:
 spec:
 `
 
+// HandlerACheck1Limited is HandlerACheck1, with a timeout, a concurrency limit and a circuit breaker.
+var HandlerACheck1Limited = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: acheck
+metadata:
+  name: hcheck1
+  namespace: istio-system
+  labels:
+    istio-timeout: "50ms"
+    istio-max-concurrency: "1"
+    istio-circuit-breaker-failures: "1"
+    istio-circuit-breaker-reset: "1h"
+spec:
+`
+
 // FqnACheck1 is the fully qualified name of HandlerH1.
 var FqnACheck1 = "hcheck1.acheck.istio-system"
 
@@ -218,6 +233,67 @@ spec:
     - icheck1.tcheck.istio-system
 `
 
+// RuleCheck1DryRun is RuleCheck1, marked as dry-run.
+var RuleCheck1DryRun = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: rcheck1
+  namespace: istio-system
+  labels:
+    istio-dry-run: "true"
+spec:
+  actions:
+  - handler: hcheck1.acheck
+    instances:
+    - icheck1.tcheck.istio-system
+`
+
+// InstanceLog1 is a logentry instance, for logging the results of dry-run rules.
+var InstanceLog1 = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: logentry
+metadata:
+  name: ilog1
+  namespace: istio-system
+spec:
+`
+
+// RuleCheck1DryRunLog is RuleCheck1DryRun, which logs its results to HandlerAReport1, with InstanceLog1. It requires
+// the templates of BuildTemplatesWithLogEntry, and an areport adapter that supports the logentry template.
+var RuleCheck1DryRunLog = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: rcheck1
+  namespace: istio-system
+  labels:
+    istio-dry-run: "true"
+    istio-dry-run-log-handler: hreport1.areport
+    istio-dry-run-log-instance: ilog1.logentry
+spec:
+  actions:
+  - handler: hcheck1.acheck
+    instances:
+    - icheck1.tcheck.istio-system
+`
+
+// RuleCheck1FailOpen is RuleCheck1, with the fail-open policy.
+var RuleCheck1FailOpen = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: rcheck1
+  namespace: istio-system
+  labels:
+    istio-fail-policy: "open"
+spec:
+  actions:
+  - handler: hcheck1.acheck
+    instances:
+    - icheck1.tcheck.istio-system
+`
+
 // RuleCheck1TrueCondition is a standard testing instance config with name R1. It references I1 and H1.
 var RuleCheck1TrueCondition = `
 apiVersion: "config.istio.io/v1alpha2"
@@ -425,6 +501,22 @@ spec:
     - ireport1.treport.istio-system
 `
 
+// RuleReport1FailOpen is RuleReport1, with the fail-open policy.
+var RuleReport1FailOpen = `
+apiVersion: "config.istio.io/v1alpha2"
+kind: rule
+metadata:
+  name: rreport1
+  namespace: istio-system
+  labels:
+    istio-fail-policy: "open"
+spec:
+  actions:
+  - handler: hreport1.areport
+    instances:
+    - ireport1.treport.istio-system
+`
+
 // RuleQuota1 is a standard testing instance config with name rquota1. It references I1 and H1.
 var RuleQuota1 = `
 apiVersion: "config.istio.io/v1alpha2"
@@ -17,16 +17,21 @@ package data
 import (
 	"bytes"
 	"fmt"
+	"sync"
 )
 
-// Logger is used to capture the events that happen within fake adapters & templates during testing.
+// Logger is used to capture the events that happen within fake adapters & templates during testing. It is safe for
+// concurrent use, as the handlers may be dispatched to in the background.
 type Logger struct {
-	b bytes.Buffer
+	mu sync.Mutex
+	b  bytes.Buffer
 }
 
 func (l *Logger) write(name string, s string) {
 	if l != nil {
+		l.mu.Lock()
 		fmt.Fprintf(&l.b, "[%s] %s\n", name, s)
+		l.mu.Unlock()
 	}
 }
 
@@ -40,7 +45,9 @@ func (l *Logger) writeFormat(name string, format string, args ...interface{}) {
 // Clear the contents of this logger. Useful for reducing the event output to write more readable tests.
 func (l *Logger) Clear() {
 	if l != nil {
+		l.mu.Lock()
 		l.b.Reset()
+		l.mu.Unlock()
 	}
 }
 
@@ -49,5 +56,7 @@ func (l *Logger) String() string {
 		return ""
 	}
 
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.b.String()
 }
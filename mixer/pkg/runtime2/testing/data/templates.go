@@ -28,6 +28,7 @@ import (
 	"istio.io/istio/mixer/pkg/expr"
 	"istio.io/istio/mixer/pkg/il/compiled"
 	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/mixer/template/logentry"
 )
 
 // BuildTemplates builds a standard set of testing templates. The supplied settings is used to override behavior.
@@ -50,6 +51,40 @@ func BuildTemplates(l *Logger, settings ...FakeTemplateSettings) map[string]*tem
 	return t
 }
 
+// BuildTemplatesWithLogEntry builds the standard set of testing templates, along with a logentry template, whose
+// instance builders create logentry instances.
+func BuildTemplatesWithLogEntry(l *Logger, settings ...FakeTemplateSettings) map[string]*template.Info {
+	t := BuildTemplates(l, settings...)
+
+	var s FakeTemplateSettings
+	for _, setting := range settings {
+		if setting.Name == logentry.TemplateName {
+			s = setting
+		}
+	}
+
+	info := createFakeTemplate(logentry.TemplateName, s, l, istio_mixer_v1_template.TEMPLATE_VARIETY_REPORT)
+	create := info.CreateInstanceBuilder
+	info.CreateInstanceBuilder = func(instanceName string, instanceParam proto.Message,
+		builder *compiled.ExpressionBuilder) (template.InstanceBuilderFn, error) {
+
+		fn, err := create(instanceName, instanceParam, builder)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(bag attribute.Bag) (interface{}, error) {
+			if _, err := fn(bag); err != nil {
+				return nil, err
+			}
+			return &logentry.Instance{Name: instanceName}, nil
+		}, nil
+	}
+	t[logentry.TemplateName] = info
+
+	return t
+}
+
 func createFakeTemplate(name string, s FakeTemplateSettings, l *Logger, variety istio_mixer_v1_template.TemplateVariety) *template.Info {
 	callCount := 0
 
@@ -90,6 +125,12 @@ func createFakeTemplate(name string, s FakeTemplateSettings, l *Logger, variety
 				panic(s.PanicData)
 			}
 
+			if s.BlockOnDispatchCheck != nil {
+				// Do not log on return, as the caller may have stopped waiting.
+				<-s.BlockOnDispatchCheck
+				return adapter.CheckResult{}, nil
+			}
+
 			if s.ErrorOnDispatchCheck {
 				l.write(name, "DispatchCheck <= (ERROR)")
 				return adapter.CheckResult{}, errors.New("error at dispatch check, as expected")
@@ -106,11 +147,22 @@ func createFakeTemplate(name string, s FakeTemplateSettings, l *Logger, variety
 		},
 		DispatchReport: func(ctx context.Context, handler adapter.Handler, instances []interface{}) error {
 			l.writeFormat(name, "DispatchReport => instances: '%+v'", instances)
+			if s.LogRequestDataOnDispatchReport {
+				if data, found := adapter.RequestDataFromContext(ctx); found {
+					l.writeFormat(name, "DispatchReport => destination: '%s'", data.DestinationService.FullName)
+				}
+			}
 			if s.PanicOnDispatchReport {
 				l.write(name, "DispatchReport <= (PANIC)")
 				panic(s.PanicData)
 			}
 
+			if s.BlockOnDispatchReport != nil {
+				// Do not log on return, as the caller may have stopped waiting.
+				<-s.BlockOnDispatchReport
+				return nil
+			}
+
 			if s.ErrorOnDispatchReport {
 				l.write(name, "DispatchReport <= (ERROR)")
 				return errors.New("error at dispatch report, as expected")
@@ -211,8 +263,11 @@ type FakeTemplateSettings struct {
 	HandlerDoesNotSupportTemplate  bool
 	PanicOnDispatchCheck           bool
 	ErrorOnDispatchCheck           bool
+	BlockOnDispatchCheck           chan struct{}
 	PanicOnDispatchReport          bool
 	ErrorOnDispatchReport          bool
+	BlockOnDispatchReport          chan struct{}
+	LogRequestDataOnDispatchReport bool
 	PanicOnDispatchQuota           bool
 	ErrorOnDispatchQuota           bool
 	PanicOnDispatchGenAttrs        bool
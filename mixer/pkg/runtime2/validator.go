@@ -0,0 +1,160 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	multierror "github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+// Validator validates config changes against the current config. A change is rejected if the snapshot that is built
+// with it has errors that the snapshot of the current config does not have, i.e. if it causes config resources to be
+// left out of the snapshot, or rules and instances that cannot be compiled. Errors that already exist in the current
+// config do not cause changes to be rejected.
+//
+// Handlers are not built during validation.
+type Validator struct {
+	templates map[string]*template.Info
+	adapters  map[string]*adapter.Info
+
+	// stateLock protects the state.
+	stateLock sync.Mutex
+	state     map[store.Key]*store.Resource
+
+	shutdown             chan struct{}
+	waitQuiesceListening sync.WaitGroup
+}
+
+var _ store.Validator = &Validator{}
+
+// NewValidator returns a new Validator, with an empty current config.
+func NewValidator(templates map[string]*template.Info, adapters map[string]*adapter.Info) *Validator {
+	return &Validator{
+		templates: templates,
+		adapters:  adapters,
+		state:     make(map[store.Key]*store.Resource),
+	}
+}
+
+// StartListening initializes the config store, and keeps the current config of the validator in sync with it.
+func (v *Validator) StartListening(s store.Store) error {
+	if v.shutdown != nil {
+		return fmt.Errorf("already listening")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Init(ctx, config.KindMap(v.adapters, v.templates)); err != nil {
+		cancel()
+		return err
+	}
+
+	// create the watch channel before listing.
+	watchChan, err := s.Watch(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	v.SetState(s.List())
+
+	v.shutdown = make(chan struct{})
+	v.waitQuiesceListening.Add(1)
+	go func() {
+		defer v.waitQuiesceListening.Done()
+		defer cancel()
+		watchChanges(watchChan, v.shutdown, v.applyEvents)
+	}()
+
+	return nil
+}
+
+// StopListening stops listening to config changes.
+func (v *Validator) StopListening() {
+	if v.shutdown == nil {
+		return
+	}
+	close(v.shutdown)
+	v.waitQuiesceListening.Wait()
+	v.shutdown = nil
+}
+
+// SetState sets the current config.
+func (v *Validator) SetState(state map[store.Key]*store.Resource) {
+	v.stateLock.Lock()
+	v.state = state
+	v.stateLock.Unlock()
+}
+
+func (v *Validator) applyEvents(events []*store.Event) {
+	v.stateLock.Lock()
+	defer v.stateLock.Unlock()
+
+	for _, ev := range events {
+		applyEvent(v.state, ev)
+	}
+}
+
+// Validate implements store.Validator. It returns the errors that the change adds to the current config.
+func (v *Validator) Validate(ev *store.Event) error {
+	v.stateLock.Lock()
+	current := make(map[store.Key]*store.Resource, len(v.state)+1)
+	for k, r := range v.state {
+		current[k] = r
+	}
+	v.stateLock.Unlock()
+
+	existing := make(map[string]bool)
+	for _, err := range v.errors(current) {
+		existing[err.Error()] = true
+	}
+
+	applyEvent(current, ev)
+
+	var result *multierror.Error
+	for _, err := range v.errors(current) {
+		if !existing[err.Error()] {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// errors returns the errors of the snapshot that is built from the given config, and of its rules and instances.
+func (v *Validator) errors(state map[store.Key]*store.Resource) []error {
+	e := config.NewEphemeral(v.templates, v.adapters)
+	e.SetState(state)
+	s := e.BuildSnapshot()
+
+	return append(s.Errors, routing.Validate(s, compiled.NewBuilder(s.Attributes))...)
+}
+
+func applyEvent(state map[store.Key]*store.Resource, ev *store.Event) {
+	switch ev.Type {
+	case store.Update:
+		state[ev.Key] = ev.Value
+	case store.Delete:
+		delete(state, ev.Key)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime2
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"istio.io/istio/mixer/pkg/config/store"
+	"istio.io/istio/mixer/pkg/config/storetest"
+	"istio.io/istio/mixer/pkg/runtime2/config"
+	"istio.io/istio/mixer/pkg/runtime2/testing/data"
+)
+
+var (
+	rcheck1Key = store.Key{Kind: "rule", Name: "rcheck1", Namespace: "istio-system"}
+	icheck1Key = store.Key{Kind: "tcheck", Name: "icheck1", Namespace: "istio-system"}
+	hcheck1Key = store.Key{Kind: "acheck", Name: "hcheck1", Namespace: "istio-system"}
+)
+
+func newValidator() *Validator {
+	return NewValidator(data.BuildTemplates(nil), data.BuildAdapters(nil))
+}
+
+// state returns the resources of the given config, as they are read from the config store.
+func state(t *testing.T, v *Validator, cfg string) map[store.Key]*store.Resource {
+	s, err := storetest.SetupStoreForTest(data.ServiceConfig, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err = s.Init(ctx, config.KindMap(v.adapters, v.templates)); err != nil {
+		t.Fatal(err)
+	}
+	return s.List()
+}
+
+func updateEvent(t *testing.T, v *Validator, key store.Key, cfg string) *store.Event {
+	r, found := state(t, v, cfg)[key]
+	if !found {
+		t.Fatalf("%v is not in the config", key)
+	}
+	return &store.Event{Key: key, Type: store.Update, Value: r}
+}
+
+func TestValidator(t *testing.T) {
+	v := newValidator()
+
+	cases := []struct {
+		name    string
+		current string
+		event   *store.Event
+		err     string
+	}{
+		{
+			name:    "valid rule",
+			current: data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1),
+			event:   updateEvent(t, v, rcheck1Key, data.RuleCheck1),
+		},
+		{
+			name:    "unknown handler",
+			current: data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1),
+			event:   updateEvent(t, v, rcheck1Key, data.RuleCheck1WithBadHandler),
+			err:     "Handler not found",
+		},
+		{
+			name:    "bad condition",
+			current: data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1),
+			event:   updateEvent(t, v, rcheck1Key, data.RuleCheck1WithBadCondition),
+			err:     "unable to compile match condition",
+		},
+		{
+			name:    "instance in use",
+			current: data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1, data.RuleCheck1),
+			event:   &store.Event{Key: icheck1Key, Type: store.Delete},
+			err:     "Instance not found",
+		},
+		{
+			name:    "existing errors",
+			current: data.JoinConfigs(data.InstanceCheck1, data.RuleCheck1WithBadHandler),
+			event:   updateEvent(t, v, hcheck1Key, data.HandlerACheck1),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v.SetState(state(t, v, tc.current))
+
+			err := v.Validate(tc.event)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("Validate() => unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Validate() => got %v, want error containing '%s'", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidator_StartListening(t *testing.T) {
+	s, err := storetest.SetupStoreForTest(data.ServiceConfig, data.JoinConfigs(data.HandlerACheck1, data.InstanceCheck1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := newValidator()
+	if err = v.StartListening(s); err != nil {
+		t.Fatalf("StartListening() => unexpected error: %v", err)
+	}
+	defer v.StopListening()
+
+	if err = v.StartListening(s); err == nil {
+		t.Fatal("StartListening() => got no error when already listening")
+	}
+
+	// The handler and the instance of the store are known to the validator.
+	if err = v.Validate(updateEvent(t, v, rcheck1Key, data.RuleCheck1)); err != nil {
+		t.Fatalf("Validate() => unexpected error: %v", err)
+	}
+}
@@ -17,17 +17,23 @@ package server
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/mixer/pkg/il/evaluator"
 	mixerRuntime "istio.io/istio/mixer/pkg/runtime"
+	"istio.io/istio/mixer/pkg/runtime2"
+	"istio.io/istio/mixer/pkg/runtime2/dispatcher"
 	"istio.io/istio/mixer/pkg/template"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/probe"
 	"istio.io/istio/pkg/tracing"
 )
 
+// DefaultCaptureMaxSize is the default maximum size of the capture file, before it is rotated.
+const DefaultCaptureMaxSize = 100 * 1024 * 1024
+
 // Args contains the startup arguments to instantiate Mixer.
 type Args struct {
 	// The templates to register.
@@ -95,6 +101,41 @@ type Args struct {
 
 	// If true, each request to Mixer will be executed in a single go routine (useful for debugging)
 	SingleThreaded bool
+
+	// Path of the file to capture a sample of the incoming requests to. If empty, requests are not captured.
+	CaptureFile string
+
+	// Fraction of the incoming requests that should be captured, in the range (0, 1].
+	CaptureSampleRate float64
+
+	// Maximum size in bytes of the capture file, before it is rotated. If zero, the size is not limited.
+	CaptureMaxSize int64
+
+	// Enables the pprof profiling endpoints on the monitoring port.
+	EnableProfiling bool
+
+	// If true, the new runtime is used, which keeps a history of config snapshots that it can be rolled back to.
+	UseNewRuntime bool
+
+	// Number of most recent config snapshots that the new runtime keeps for rollbacks.
+	ConfigHistorySize int
+
+	// If true, the new runtime discards config snapshots whose handlers cannot all be built.
+	ConfigAutoRollback bool
+
+	// If true, the new runtime discards config snapshots that have any errors.
+	ConfigStrict bool
+
+	// Path of the JSON or YAML file with the limits on the rules of the config namespaces, which are enforced by the
+	// new runtime. If empty, there are no limits.
+	NamespaceLimitsFile string
+
+	// Maximum number of report instances that the new runtime stages, before it dispatches them to the handlers.
+	ReportBatchSize int
+
+	// If non-zero, the new runtime accumulates report instances across calls, and dispatches them at most this long
+	// after they were staged.
+	ReportBatchWindow time.Duration
 }
 
 // NewArgs allocates an Args struct initialized with Mixer's default configuration.
@@ -114,6 +155,10 @@ func NewArgs() *Args {
 		TracingOptions:                tracing.NewOptions(),
 		LivenessProbeOptions:          &probe.Options{},
 		ReadinessProbeOptions:         &probe.Options{},
+		CaptureSampleRate:             1.0,
+		CaptureMaxSize:                DefaultCaptureMaxSize,
+		ConfigHistorySize:             runtime2.DefaultHistorySize,
+		ReportBatchSize:               dispatcher.DefaultMaxReportBatchSize,
 	}
 }
 
@@ -130,6 +175,22 @@ func (a *Args) validate() error {
 		return fmt.Errorf("expressiion evaluation cache size must be >= 0 and <= 2^31-1, got cache size %d", a.ExpressionEvalCacheSize)
 	}
 
+	if a.CaptureFile != "" && (a.CaptureSampleRate <= 0 || a.CaptureSampleRate > 1) {
+		return fmt.Errorf("capture sample rate must be > 0 and <= 1, got %f", a.CaptureSampleRate)
+	}
+
+	if a.CaptureMaxSize < 0 {
+		return fmt.Errorf("capture file size must be >= 0, got %d", a.CaptureMaxSize)
+	}
+
+	if a.NamespaceLimitsFile != "" && !a.UseNewRuntime {
+		return fmt.Errorf("namespace limits are only supported by the new runtime")
+	}
+
+	if a.ReportBatchWindow != 0 && !a.UseNewRuntime {
+		return fmt.Errorf("report batching across calls is only supported by the new runtime")
+	}
+
 	return nil
 }
 
@@ -149,6 +210,17 @@ func (a *Args) String() string {
 	b.WriteString(fmt.Sprint("ConfigDefaultNamespace: ", a.ConfigDefaultNamespace, "\n"))
 	b.WriteString(fmt.Sprint("ConfigIdentityAttribute: ", a.ConfigIdentityAttribute, "\n"))
 	b.WriteString(fmt.Sprint("ConfigIdentityAttributeDomain: ", a.ConfigIdentityAttributeDomain, "\n"))
+	b.WriteString(fmt.Sprint("CaptureFile: ", a.CaptureFile, "\n"))
+	b.WriteString(fmt.Sprint("CaptureSampleRate: ", a.CaptureSampleRate, "\n"))
+	b.WriteString(fmt.Sprint("CaptureMaxSize: ", a.CaptureMaxSize, "\n"))
+	b.WriteString(fmt.Sprint("EnableProfiling: ", a.EnableProfiling, "\n"))
+	b.WriteString(fmt.Sprint("UseNewRuntime: ", a.UseNewRuntime, "\n"))
+	b.WriteString(fmt.Sprint("ConfigHistorySize: ", a.ConfigHistorySize, "\n"))
+	b.WriteString(fmt.Sprint("ConfigAutoRollback: ", a.ConfigAutoRollback, "\n"))
+	b.WriteString(fmt.Sprint("ConfigStrict: ", a.ConfigStrict, "\n"))
+	b.WriteString(fmt.Sprint("NamespaceLimitsFile: ", a.NamespaceLimitsFile, "\n"))
+	b.WriteString(fmt.Sprint("ReportBatchSize: ", a.ReportBatchSize, "\n"))
+	b.WriteString(fmt.Sprint("ReportBatchWindow: ", a.ReportBatchWindow, "\n"))
 	b.WriteString(fmt.Sprintf("LoggingOptions: %#v\n", *a.LoggingOptions))
 	b.WriteString(fmt.Sprintf("TracingOptions: %#v\n", *a.TracingOptions))
 	return b.String()
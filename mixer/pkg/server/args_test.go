@@ -16,6 +16,7 @@ package server
 
 import (
 	"testing"
+	"time"
 )
 
 func TestValidation(t *testing.T) {
@@ -41,6 +42,25 @@ func TestValidation(t *testing.T) {
 	if err := a.validate(); err == nil {
 		t.Errorf("Got unexpected success")
 	}
+
+	a = NewArgs()
+	a.CaptureFile = "capture.json"
+	a.CaptureSampleRate = 0
+	if err := a.validate(); err == nil {
+		t.Errorf("Got unexpected success")
+	}
+
+	a = NewArgs()
+	a.NamespaceLimitsFile = "limits.json"
+	if err := a.validate(); err == nil {
+		t.Errorf("Got unexpected success")
+	}
+
+	a = NewArgs()
+	a.ReportBatchWindow = time.Second
+	if err := a.validate(); err == nil {
+		t.Errorf("Got unexpected success")
+	}
 }
 
 func TestString(t *testing.T) {
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/pprof"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -28,15 +29,41 @@ import (
 
 type monitor struct {
 	monitoringServer *http.Server
+	mux              *http.ServeMux
 	// This channel is closed after the server stops serving requests.
 	closed chan struct{}
 }
 
 const (
-	metricsPath = "/metrics"
-	versionPath = "/version"
+	metricsPath         = "/metrics"
+	versionPath         = "/version"
+	routingTablePath    = "/debug/routing"
+	snapshotsPath       = "/debug/snapshots"
+	configStatusPath    = "/debug/config_status"
+	namespaceStatusPath = "/debug/namespace_status"
+	pprofPath           = "/debug/pprof/"
 )
 
+// routingTableServer is implemented by dispatchers that can expose their current routing table.
+type routingTableServer interface {
+	ServeRoutingTable(w http.ResponseWriter, req *http.Request)
+}
+
+// snapshotServer is implemented by runtimes that keep a history of config snapshots, and can be rolled back to them.
+type snapshotServer interface {
+	ServeSnapshots(w http.ResponseWriter, req *http.Request)
+}
+
+// configStatusServer is implemented by runtimes that can expose the errors of their config.
+type configStatusServer interface {
+	ServeConfigStatus(w http.ResponseWriter, req *http.Request)
+}
+
+// namespaceStatusServer is implemented by runtimes that enforce limits on the config of namespaces.
+type namespaceStatusServer interface {
+	ServeNamespaceStatus(w http.ResponseWriter, req *http.Request)
+}
+
 func startMonitor(port uint16) (*monitor, error) {
 	m := &monitor{
 		closed: make(chan struct{}),
@@ -61,6 +88,7 @@ func startMonitor(port uint16) (*monitor, error) {
 		}
 	})
 
+	m.mux = mux
 	m.monitoringServer = &http.Server{
 		Handler: mux,
 	}
@@ -73,6 +101,38 @@ func startMonitor(port uint16) (*monitor, error) {
 	return m, nil
 }
 
+// handleFunc registers an additional debug handler on the monitoring port.
+func (m *monitor) handleFunc(path string, handler func(http.ResponseWriter, *http.Request)) {
+	m.mux.HandleFunc(path, handler)
+}
+
+// registerDebugHandlers registers the debug endpoints that are implemented by the given components of the runtime.
+func (m *monitor) registerDebugHandlers(components ...interface{}) {
+	for _, c := range components {
+		if rs, ok := c.(routingTableServer); ok {
+			m.handleFunc(routingTablePath, rs.ServeRoutingTable)
+		}
+		if ss, ok := c.(snapshotServer); ok {
+			m.handleFunc(snapshotsPath, ss.ServeSnapshots)
+		}
+		if cs, ok := c.(configStatusServer); ok {
+			m.handleFunc(configStatusPath, cs.ServeConfigStatus)
+		}
+		if ns, ok := c.(namespaceStatusServer); ok {
+			m.handleFunc(namespaceStatusPath, ns.ServeNamespaceStatus)
+		}
+	}
+}
+
+// enableProfiling registers the pprof handlers on the monitoring port.
+func (m *monitor) enableProfiling() {
+	m.mux.HandleFunc(pprofPath, pprof.Index)
+	m.mux.HandleFunc(pprofPath+"cmdline", pprof.Cmdline)
+	m.mux.HandleFunc(pprofPath+"profile", pprof.Profile)
+	m.mux.HandleFunc(pprofPath+"symbol", pprof.Symbol)
+	m.mux.HandleFunc(pprofPath+"trace", pprof.Trace)
+}
+
 func (m *monitor) Close() error {
 	var err error
 
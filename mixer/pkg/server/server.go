@@ -29,12 +29,15 @@ import (
 	mixerpb "istio.io/api/mixer/v1"
 	"istio.io/istio/mixer/pkg/adapter"
 	"istio.io/istio/mixer/pkg/api"
+	"istio.io/istio/mixer/pkg/capture"
 	"istio.io/istio/mixer/pkg/config"
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/mixer/pkg/expr"
 	"istio.io/istio/mixer/pkg/il/evaluator"
 	"istio.io/istio/mixer/pkg/pool"
 	mixerRuntime "istio.io/istio/mixer/pkg/runtime"
+	"istio.io/istio/mixer/pkg/runtime2"
+	"istio.io/istio/mixer/pkg/runtime2/routing"
 	"istio.io/istio/mixer/pkg/template"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/probe"
@@ -50,10 +53,14 @@ type Server struct {
 	listener  net.Listener
 	monitor   *monitor
 	tracer    io.Closer
+	recorder  *capture.Recorder
 	configDir string
 
 	dispatcher mixerRuntime.Dispatcher
 
+	// the new runtime, if it is in use.
+	runtime *runtime2.Runtime
+
 	// probes
 	livenessProbe  probe.Controller
 	readinessProbe probe.Controller
@@ -144,6 +151,9 @@ func newServer(a *Args, p *patchTable) (*Server, error) {
 		_ = s.Close()
 		return nil, fmt.Errorf("unable to setup monitoring: %v", err)
 	}
+	if a.EnableProfiling {
+		s.monitor.enableProfiling()
+	}
 
 	// get the network stuff setup
 	if s.listener, err = p.listen("tcp", fmt.Sprintf(":%d", a.APIPort)); err != nil {
@@ -169,17 +179,55 @@ func newServer(a *Args, p *patchTable) (*Server, error) {
 	}
 
 	var dispatcher mixerRuntime.Dispatcher
-	if dispatcher, err = p.newRuntime(eval, evaluator.NewTypeChecker(), eval, s.gp, s.adapterGP,
-		a.ConfigIdentityAttribute, a.ConfigDefaultNamespace, st, adapterMap, a.Templates); err != nil {
-		_ = s.Close()
-		return nil, fmt.Errorf("unable to create runtime dispatcherForTesting: %v", err)
+	if a.UseNewRuntime {
+		templates := make(map[string]*template.Info, len(a.Templates))
+		for name, t := range a.Templates {
+			t := t
+			templates[name] = &t
+		}
+
+		options := runtime2.Options{
+			HistorySize:  a.ConfigHistorySize,
+			AutoRollback: a.ConfigAutoRollback,
+			Strict:       a.ConfigStrict,
+		}
+		if a.NamespaceLimitsFile != "" {
+			if options.Limits, err = routing.ReadLimits(a.NamespaceLimitsFile); err != nil {
+				_ = s.Close()
+				return nil, err
+			}
+		}
+
+		rt := runtime2.New(st, templates, adapterMap, a.ConfigIdentityAttribute, a.ConfigDefaultNamespace,
+			s.adapterGP, a.TracingOptions.TracingEnabled(), options)
+		rt.Dispatcher().EnableReportBatching(a.ReportBatchSize, a.ReportBatchWindow)
+		if err = rt.StartListening(); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("unable to start the runtime: %v", err)
+		}
+		s.runtime = rt
+		dispatcher = rt.Dispatcher()
+		s.monitor.registerDebugHandlers(dispatcher, rt)
+	} else {
+		if dispatcher, err = p.newRuntime(eval, evaluator.NewTypeChecker(), eval, s.gp, s.adapterGP,
+			a.ConfigIdentityAttribute, a.ConfigDefaultNamespace, st, adapterMap, a.Templates); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("unable to create runtime dispatcherForTesting: %v", err)
+		}
+		s.monitor.registerDebugHandlers(dispatcher)
 	}
 	s.dispatcher = dispatcher
 
 	// get the grpc server wired up
 	grpc.EnableTracing = a.EnableGRPCTracing
 	s.server = grpc.NewServer(grpcOptions...)
-	mixerpb.RegisterMixerServer(s.server, api.NewGRPCServer(dispatcher, s.gp))
+	if a.CaptureFile != "" {
+		if s.recorder, err = capture.NewRecorder(a.CaptureFile, a.CaptureSampleRate, a.CaptureMaxSize); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("unable to setup request capture: %v", err)
+		}
+	}
+	mixerpb.RegisterMixerServer(s.server, api.NewGRPCServerWithRecorder(dispatcher, s.gp, s.recorder))
 
 	if a.LivenessProbeOptions.IsValid() {
 		s.livenessProbe = probe.NewFileController(a.LivenessProbeOptions)
@@ -233,10 +281,19 @@ func (s *Server) Close() error {
 		_ = s.listener.Close()
 	}
 
+	if s.runtime != nil {
+		s.runtime.StopListening()
+		_ = s.runtime.Dispatcher().Close()
+	}
+
 	if s.tracer != nil {
 		_ = s.tracer.Close()
 	}
 
+	if s.recorder != nil {
+		_ = s.recorder.Close()
+	}
+
 	if s.monitor != nil {
 		_ = s.monitor.Close()
 	}
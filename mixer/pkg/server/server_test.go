@@ -19,6 +19,8 @@ import (
 	"errors"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 
@@ -138,6 +140,36 @@ func TestBasic(t *testing.T) {
 	}
 }
 
+func TestNewRuntime(t *testing.T) {
+	a := NewArgs()
+	a.APIPort = 0
+	a.MonitoringPort = 0
+	a.LoggingOptions.LogGrpc = false // Avoid introducing a race to the server tests.
+	a.UseNewRuntime = true
+	var err error
+	if a.ConfigStore, err = storetest.SetupStoreForTest(globalCfg, serviceCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(a)
+	if err != nil {
+		t.Fatalf("Unable to create server: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if s.runtime == nil || s.Dispatcher() != s.runtime.Dispatcher() {
+		t.Fatalf("got dispatcher %T, want the dispatcher of the new runtime", s.Dispatcher())
+	}
+
+	for _, path := range []string{routingTablePath, snapshotsPath, configStatusPath, namespaceStatusPath} {
+		w := httptest.NewRecorder()
+		s.monitor.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s => got status %d, want %d", path, w.Code, http.StatusOK)
+		}
+	}
+}
+
 func TestClient(t *testing.T) {
 	s, err := newTestServer(globalCfg, serviceCfg)
 	if err != nil {
@@ -229,3 +261,24 @@ func TestErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestMonitor_EnableProfiling(t *testing.T) {
+	m, err := startMonitor(0)
+	if err != nil {
+		t.Fatalf("Unable to start monitor: %v", err)
+	}
+	defer func() { _ = m.Close() }()
+
+	w := httptest.NewRecorder()
+	m.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, pprofPath+"heap", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("got status %d before profiling is enabled, want %d", w.Code, http.StatusNotFound)
+	}
+
+	m.enableProfiling()
+	w = httptest.NewRecorder()
+	m.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, pprofPath+"heap", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,400 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamic
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gogo/protobuf/types"
+
+	pb "istio.io/api/mixer/v1/config/descriptor"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/template"
+)
+
+type (
+	// builder builds the values of a message from compiled expressions, like the builder structs generated by
+	// mixgenproc.
+	builder struct {
+		msg    *message
+		fields []*fieldBuilder
+	}
+
+	fieldBuilder struct {
+		*field
+
+		expr    compiled.Expression
+		exprs   map[string]compiled.Expression
+		res     *builder
+		resList []*builder
+		resMap  map[string]*builder
+	}
+)
+
+// newBuilder compiles the expressions of the params of a message.
+func (m *message) newBuilder(expb *compiled.ExpressionBuilder, s *types.Struct) (*builder, template.ErrorPath) {
+	if err := m.checkParams(s); err != nil {
+		return nil, template.NewErrorPath(m.name, err)
+	}
+
+	b := &builder{msg: m, fields: make([]*fieldBuilder, 0, len(m.fields))}
+	for _, f := range m.fields {
+		fb := &fieldBuilder{field: f}
+		b.fields = append(b.fields, fb)
+
+		v := param(s, f)
+		if v == nil {
+			continue
+		}
+
+		var err error
+		switch f.kind {
+		case exprField:
+			if fb.expr, err = f.compile(expb, v); err != nil {
+				return nil, template.NewErrorPath(f.goName, err)
+			}
+
+		case exprMapField:
+			entries, err := structValue(v)
+			if err != nil {
+				return nil, template.NewErrorPath(f.goName, err)
+			}
+			fb.exprs = make(map[string]compiled.Expression, len(entries.Fields))
+			for k, ev := range entries.Fields {
+				if fb.exprs[k], err = f.compile(expb, ev); err != nil {
+					return nil, template.NewErrorPath(f.goName+"["+k+"]", err)
+				}
+			}
+
+		case resourceField:
+			rs, err := structValue(v)
+			if err != nil {
+				return nil, template.NewErrorPath(f.goName, err)
+			}
+			var errp template.ErrorPath
+			if fb.res, errp = f.msg.newBuilder(expb, rs); !errp.IsNil() {
+				return nil, errp.WithPrefix(f.goName)
+			}
+
+		case resourceListField:
+			l, ok := v.Kind.(*types.Value_ListValue)
+			if !ok || l.ListValue == nil {
+				return nil, template.NewErrorPath(f.goName, fmt.Errorf("expected a list, got %v", v))
+			}
+			for i, ev := range l.ListValue.Values {
+				path := fmt.Sprintf("%s[%d]", f.goName, i)
+				rs, err := structValue(ev)
+				if err != nil {
+					return nil, template.NewErrorPath(path, err)
+				}
+				rb, errp := f.msg.newBuilder(expb, rs)
+				if !errp.IsNil() {
+					return nil, errp.WithPrefix(path)
+				}
+				fb.resList = append(fb.resList, rb)
+			}
+
+		case resourceMapField:
+			entries, err := structValue(v)
+			if err != nil {
+				return nil, template.NewErrorPath(f.goName, err)
+			}
+			fb.resMap = make(map[string]*builder, len(entries.Fields))
+			for k, ev := range entries.Fields {
+				path := f.goName + "[" + k + "]"
+				rs, err := structValue(ev)
+				if err != nil {
+					return nil, template.NewErrorPath(path, err)
+				}
+				var errp template.ErrorPath
+				if fb.resMap[k], errp = f.msg.newBuilder(expb, rs); !errp.IsNil() {
+					return nil, errp.WithPrefix(path)
+				}
+			}
+		}
+	}
+
+	return b, template.ErrorPath{}
+}
+
+// build returns a pointer to a new value of the message, given a set of attributes.
+func (b *builder) build(attrs attribute.Bag) (reflect.Value, template.ErrorPath) {
+	r := b.msg.new()
+	s := r.Elem()
+
+	for _, fb := range b.fields {
+		fv := s.Field(fb.index)
+
+		switch fb.kind {
+		case exprField:
+			if fb.expr == nil {
+				continue
+			}
+			v, err := evaluate(fb.expr, attrs, fb.typ)
+			if err != nil {
+				return reflect.Value{}, template.NewErrorPath(fb.goName, err)
+			}
+			fv.Set(v)
+
+		case exprMapField:
+			if fb.exprs == nil {
+				continue
+			}
+			m := reflect.MakeMap(fv.Type())
+			for k, e := range fb.exprs {
+				v, err := evaluate(e, attrs, fb.typ)
+				if err != nil {
+					return reflect.Value{}, template.NewErrorPath(fb.goName+"["+k+"]", err)
+				}
+				m.SetMapIndex(reflect.ValueOf(k), v)
+			}
+			fv.Set(m)
+
+		case resourceField:
+			if fb.res == nil {
+				continue
+			}
+			v, errp := fb.res.build(attrs)
+			if !errp.IsNil() {
+				return reflect.Value{}, errp.WithPrefix(fb.goName)
+			}
+			fv.Set(v)
+
+		case resourceListField:
+			if fb.resList == nil {
+				continue
+			}
+			l := reflect.MakeSlice(fv.Type(), 0, len(fb.resList))
+			for i, rb := range fb.resList {
+				v, errp := rb.build(attrs)
+				if !errp.IsNil() {
+					return reflect.Value{}, errp.WithPrefix(fmt.Sprintf("%s[%d]", fb.goName, i))
+				}
+				l = reflect.Append(l, v)
+			}
+			fv.Set(l)
+
+		case resourceMapField:
+			if fb.resMap == nil {
+				continue
+			}
+			m := reflect.MakeMap(fv.Type())
+			for k, rb := range fb.resMap {
+				v, errp := rb.build(attrs)
+				if !errp.IsNil() {
+					return reflect.Value{}, errp.WithPrefix(fb.goName + "[" + k + "]")
+				}
+				m.SetMapIndex(reflect.ValueOf(k), v)
+			}
+			fv.Set(m)
+		}
+	}
+
+	return r, template.ErrorPath{}
+}
+
+// inferType type checks the expressions of the params of a message, and returns the types of the expressions in
+// a struct with the same structure as the params.
+func (m *message) inferType(s *types.Struct, tEvalFn template.TypeEvalFn, path string) (*types.Struct, error) {
+	if err := m.checkParams(s); err != nil {
+		return nil, fmt.Errorf("%s: %v", m.name, err)
+	}
+
+	inferred := &types.Struct{Fields: make(map[string]*types.Value)}
+	for _, f := range m.fields {
+		v := param(s, f)
+		if v == nil {
+			continue
+		}
+
+		var iv *types.Value
+		var err error
+		switch f.kind {
+		case exprField:
+			iv, err = f.inferType(v, tEvalFn, path+f.goName)
+
+		case exprMapField:
+			var entries *types.Struct
+			if entries, err = structValue(v); err != nil {
+				return nil, fmt.Errorf("field '%s': %v", path+f.goName, err)
+			}
+			entryTypes := &types.Struct{Fields: make(map[string]*types.Value, len(entries.Fields))}
+			for k, ev := range entries.Fields {
+				if entryTypes.Fields[k], err = f.inferType(ev, tEvalFn, path+f.goName+"["+k+"]"); err != nil {
+					return nil, err
+				}
+			}
+			iv = structVal(entryTypes)
+
+		case resourceField:
+			iv, err = f.inferResourceType(v, tEvalFn, path+f.goName)
+
+		case resourceListField:
+			l, ok := v.Kind.(*types.Value_ListValue)
+			if !ok || l.ListValue == nil {
+				return nil, fmt.Errorf("field '%s': expected a list, got %v", path+f.goName, v)
+			}
+			list := &types.ListValue{}
+			for i, ev := range l.ListValue.Values {
+				var rv *types.Value
+				if rv, err = f.inferResourceType(ev, tEvalFn, fmt.Sprintf("%s%s[%d]", path, f.goName, i)); err != nil {
+					return nil, err
+				}
+				list.Values = append(list.Values, rv)
+			}
+			iv = &types.Value{Kind: &types.Value_ListValue{ListValue: list}}
+
+		case resourceMapField:
+			var entries *types.Struct
+			if entries, err = structValue(v); err != nil {
+				return nil, fmt.Errorf("field '%s': %v", path+f.goName, err)
+			}
+			resources := &types.Struct{Fields: make(map[string]*types.Value, len(entries.Fields))}
+			for k, ev := range entries.Fields {
+				if resources.Fields[k], err = f.inferResourceType(ev, tEvalFn, path+f.goName+"["+k+"]"); err != nil {
+					return nil, err
+				}
+			}
+			iv = structVal(resources)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		inferred.Fields[f.protoName] = iv
+	}
+
+	return inferred, nil
+}
+
+// checkParams returns an error if the params contain fields that the message does not have.
+func (m *message) checkParams(s *types.Struct) error {
+	var unknown []string
+	for name := range s.Fields {
+		if m.lookup(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields %v", unknown)
+	}
+	return nil
+}
+
+// compile compiles the expression of an expression field, or of an entry of an expression map field. Empty
+// expressions are not compiled, and leave the field unset.
+func (f *field) compile(expb *compiled.ExpressionBuilder, v *types.Value) (compiled.Expression, error) {
+	text, err := expression(v)
+	if err != nil || text == "" {
+		return nil, err
+	}
+
+	exp, expType, err := expb.Compile(text)
+	if err != nil {
+		return nil, err
+	}
+	if f.valueType != pb.VALUE_TYPE_UNSPECIFIED && expType != f.valueType {
+		return nil, fmt.Errorf("instance field type mismatch: expected='%v', actual='%v', expression='%s'",
+			f.valueType, expType, text)
+	}
+	return exp, nil
+}
+
+// inferType returns the type of the expression of an expression field, or of an entry of an expression map field.
+func (f *field) inferType(v *types.Value, tEvalFn template.TypeEvalFn, path string) (*types.Value, error) {
+	text, err := expression(v)
+	if err != nil {
+		return nil, fmt.Errorf("field '%s': %v", path, err)
+	}
+
+	t := pb.VALUE_TYPE_UNSPECIFIED
+	if text != "" {
+		if t, err = tEvalFn(text); err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression for field '%s'; %v", path, err)
+		}
+		if f.valueType != pb.VALUE_TYPE_UNSPECIFIED && t != f.valueType {
+			return nil, fmt.Errorf("error type checking for field '%s': Evaluated expression type %v want %v",
+				path, t, f.valueType)
+		}
+	}
+	return &types.Value{Kind: &types.Value_StringValue{StringValue: t.String()}}, nil
+}
+
+func (f *field) inferResourceType(v *types.Value, tEvalFn template.TypeEvalFn, path string) (*types.Value, error) {
+	rs, err := structValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("field '%s': %v", path, err)
+	}
+	inferred, err := f.msg.inferType(rs, tEvalFn, path+".")
+	if err != nil {
+		return nil, err
+	}
+	return structVal(inferred), nil
+}
+
+// evaluate evaluates an expression, and converts the result to the type of the field.
+func evaluate(e compiled.Expression, attrs attribute.Bag, t reflect.Type) (reflect.Value, error) {
+	v, err := e.Evaluate(attrs)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if t == ifaceType {
+		return reflect.ValueOf(&v).Elem(), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !rv.Type().ConvertibleTo(t) {
+		return reflect.Value{}, fmt.Errorf("expression evaluated to %T, want %v", v, t)
+	}
+	return rv.Convert(t), nil
+}
+
+// param returns the param of a field, or nil if it is not set.
+func param(s *types.Struct, f *field) *types.Value {
+	if v, found := s.Fields[f.protoName]; found {
+		return v
+	}
+	return s.Fields[f.jsonName]
+}
+
+func expression(v *types.Value) (string, error) {
+	switch k := v.Kind.(type) {
+	case *types.Value_StringValue:
+		return k.StringValue, nil
+	case *types.Value_NullValue:
+		return "", nil
+	}
+	return "", fmt.Errorf("expected an expression, got %v", v)
+}
+
+func structValue(v *types.Value) (*types.Struct, error) {
+	switch k := v.Kind.(type) {
+	case *types.Value_StructValue:
+		if k.StructValue != nil {
+			return k.StructValue, nil
+		}
+	case *types.Value_NullValue:
+		return &types.Struct{}, nil
+	}
+	return nil, fmt.Errorf("expected an object, got %v", v)
+}
+
+func structVal(s *types.Struct) *types.Value {
+	return &types.Value{Kind: &types.Value_StructValue{StructValue: s}}
+}
@@ -0,0 +1,221 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dynamic creates templates at runtime, from the FileDescriptorSet of the template proto, such as the
+// template_proto.descriptor_set files that are generated for the templates compiled into Mixer.
+//
+// The instances of a dynamic template are pointers to structs that are created from the descriptor, and have the
+// same fields as the Instance structs that mixgenproc generates for the template. Their fields carry protobuf tags
+// with the field numbers of the template, which lets the remote package encode them for out-of-process adapters.
+// In-process adapters are compiled against the generated code, so instances of dynamic templates can only be
+// dispatched to the handlers of out-of-process adapters.
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/types"
+
+	adptTmpl "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/remote"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/mixer/tools/codegen/pkg/modelgen"
+	"istio.io/istio/pkg/log"
+)
+
+// New creates a template from the FileDescriptorSet of the template proto and its dependencies. The instance
+// params of the template are google.protobuf.Struct messages, with the structure of the template's InstanceParam
+// message.
+func New(descriptorSet []byte) (*template.Info, error) {
+	fds := &descriptor.FileDescriptorSet{}
+	if err := proto.Unmarshal(descriptorSet, fds); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %v", err)
+	}
+
+	// The model generator exits the process if a type cannot be resolved, so make sure that all types are known.
+	if err := checkTypes(fds); err != nil {
+		return nil, err
+	}
+
+	parser, err := modelgen.CreateFileDescriptorSetParser(fds, map[string]string{}, "")
+	if err != nil {
+		return nil, err
+	}
+	model, err := modelgen.Create(parser)
+	if err != nil {
+		return nil, err
+	}
+
+	variety := adptTmpl.TemplateVariety(adptTmpl.TemplateVariety_value[model.VarietyName])
+	if variety == adptTmpl.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR {
+		return nil, fmt.Errorf("template %s: attribute generator templates cannot be loaded at runtime", model.TemplateName)
+	}
+
+	msg, err := newMessages(model).root()
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %v", model.TemplateName, err)
+	}
+
+	ti := &template.Info{
+		Name:               model.TemplateName,
+		Impl:               model.PackageName,
+		Variety:            variety,
+		BldrInterfaceName:  "remote.Builder",
+		HndlrInterfaceName: "remote.Handler",
+		CtrCfg:             &types.Struct{},
+		NewInstance: func() interface{} {
+			return msg.new().Interface()
+		},
+		BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
+			_, ok := hndlrBuilder.(remote.Builder)
+			return ok
+		},
+		HandlerSupportsTemplate: func(hndlr adapter.Handler) bool {
+			_, ok := hndlr.(remote.Handler)
+			return ok
+		},
+		InferType: func(cp proto.Message, tEvalFn template.TypeEvalFn) (proto.Message, error) {
+			s, err := params(cp)
+			if err != nil {
+				return nil, err
+			}
+			return msg.inferType(s, tEvalFn, "")
+		},
+		CreateInstanceBuilder: func(instanceName string, param proto.Message, expb *compiled.ExpressionBuilder) (template.InstanceBuilderFn, error) {
+			// If the parameter is nil. Simply return nil. The builder, then, will also return nil.
+			if param == nil {
+				return func(attrs attribute.Bag) (interface{}, error) {
+					return nil, nil
+				}, nil
+			}
+
+			s, err := params(param)
+			if err != nil {
+				return nil, fmt.Errorf("instance '%s': %v", instanceName, err)
+			}
+
+			b, errp := msg.newBuilder(expb, s)
+			if !errp.IsNil() {
+				return nil, errp.AsCompilationError(instanceName)
+			}
+
+			return func(attrs attribute.Bag) (interface{}, error) {
+				v, errp := b.build(attrs)
+				if !errp.IsNil() {
+					err := errp.AsEvaluationError(instanceName)
+					log.Error(err.Error())
+					return nil, err
+				}
+				v.Elem().Field(0).SetString(instanceName)
+				return v.Interface(), nil
+			}, nil
+		},
+	}
+
+	// The handlers of out-of-process adapters support all templates. The dispatcher invokes them directly, these
+	// functions cover the other callers.
+	ti.DispatchCheck = func(ctx context.Context, handler adapter.Handler, instance interface{}) (adapter.CheckResult, error) {
+		h, err := remoteHandler(ti, handler)
+		if err != nil {
+			return adapter.CheckResult{}, err
+		}
+		return h.HandleRemoteCheck(ctx, ti, instance)
+	}
+	ti.DispatchReport = func(ctx context.Context, handler adapter.Handler, instances []interface{}) error {
+		h, err := remoteHandler(ti, handler)
+		if err != nil {
+			return err
+		}
+		return h.HandleRemoteReport(ctx, ti, instances)
+	}
+	ti.DispatchQuota = func(ctx context.Context, handler adapter.Handler, instance interface{},
+		args adapter.QuotaArgs) (adapter.QuotaResult, error) {
+		h, err := remoteHandler(ti, handler)
+		if err != nil {
+			return adapter.QuotaResult{}, err
+		}
+		return h.HandleRemoteQuota(ctx, ti, instance, args)
+	}
+
+	return ti, nil
+}
+
+func remoteHandler(ti *template.Info, handler adapter.Handler) (remote.Handler, error) {
+	h, ok := handler.(remote.Handler)
+	if !ok {
+		return nil, fmt.Errorf("template %s is loaded at runtime, and is only supported by out-of-process adapters", ti.Name)
+	}
+	return h, nil
+}
+
+// params returns the instance params of a dynamic template.
+func params(cp proto.Message) (*types.Struct, error) {
+	s, ok := cp.(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("unexpected instance params type %T", cp)
+	}
+	return s, nil
+}
+
+// checkTypes makes sure that the types of all message and enum fields are defined in the descriptor set.
+func checkTypes(fds *descriptor.FileDescriptorSet) error {
+	known := make(map[string]bool)
+	var addTypes func(prefix string, msgs []*descriptor.DescriptorProto, enums []*descriptor.EnumDescriptorProto)
+	addTypes = func(prefix string, msgs []*descriptor.DescriptorProto, enums []*descriptor.EnumDescriptorProto) {
+		for _, e := range enums {
+			known[prefix+"."+e.GetName()] = true
+		}
+		for _, m := range msgs {
+			name := prefix + "." + m.GetName()
+			known[name] = true
+			addTypes(name, m.NestedType, m.EnumType)
+		}
+	}
+	for _, f := range fds.File {
+		prefix := ""
+		if f.GetPackage() != "" {
+			prefix = "." + f.GetPackage()
+		}
+		addTypes(prefix, f.MessageType, f.EnumType)
+	}
+
+	var check func(msgs []*descriptor.DescriptorProto) error
+	check = func(msgs []*descriptor.DescriptorProto) error {
+		for _, m := range msgs {
+			for _, f := range m.Field {
+				if n := f.GetTypeName(); n != "" && !known[n] {
+					return fmt.Errorf("type %s of field %s.%s is not defined in the descriptor set",
+						strings.TrimPrefix(n, "."), m.GetName(), f.GetName())
+				}
+			}
+			if err := check(m.NestedType); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, f := range fds.File {
+		if err := check(f.MessageType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamic
+
+import (
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/types"
+
+	configpb "istio.io/api/mixer/v1/config"
+	pb "istio.io/api/mixer/v1/config/descriptor"
+	adptTmpl "istio.io/api/mixer/v1/template"
+	"istio.io/istio/mixer/pkg/adapter/remote"
+	"istio.io/istio/mixer/pkg/attribute"
+	"istio.io/istio/mixer/pkg/expr"
+	"istio.io/istio/mixer/pkg/il/compiled"
+	"istio.io/istio/mixer/pkg/template"
+	"istio.io/istio/mixer/template/metric"
+)
+
+const (
+	metricDescriptorSet = "../../../template/metric/template_proto.descriptor_set"
+	reportDescriptorSet = "../../../template/sample/report/ReportTesterTemplate_proto.descriptor_set"
+	apaDescriptorSet    = "../../../test/spyAdapter/template/apa/tmpl_proto.descriptor_set"
+)
+
+var attrs = map[string]*configpb.AttributeManifest_AttributeInfo{
+	"request.size":     {ValueType: pb.INT64},
+	"request.time":     {ValueType: pb.TIMESTAMP},
+	"response.latency": {ValueType: pb.DURATION},
+	"source.name":      {ValueType: pb.STRING},
+	"source.ip":        {ValueType: pb.IP_ADDRESS},
+	"source.labels":    {ValueType: pb.STRING_MAP},
+}
+
+// metricInfo is the information about the metric template that is generated by mixgenproc.
+var metricInfo = &template.Info{
+	Name:        metric.TemplateName,
+	Impl:        "metric",
+	Variety:     adptTmpl.TEMPLATE_VARIETY_REPORT,
+	CtrCfg:      &metric.InstanceParam{},
+	NewInstance: func() interface{} { return &metric.Instance{} },
+}
+
+func load(t *testing.T, path string) *template.Info {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti, err := New(b)
+	if err != nil {
+		t.Fatalf("New() => unexpected error: %v", err)
+	}
+	return ti
+}
+
+func mustParams(t *testing.T, js string) *types.Struct {
+	s := &types.Struct{}
+	if err := jsonpb.UnmarshalString(js, s); err != nil {
+		t.Fatalf("invalid params %s: %v", js, err)
+	}
+	return s
+}
+
+func TestNew(t *testing.T) {
+	ti := load(t, metricDescriptorSet)
+
+	if ti.Name != metric.TemplateName || ti.Impl != "metric" || ti.Variety != adptTmpl.TEMPLATE_VARIETY_REPORT {
+		t.Errorf("New() => got name=%s, impl=%s, variety=%v", ti.Name, ti.Impl, ti.Variety)
+	}
+	if got, want := remote.MethodName(ti), remote.MethodName(metricInfo); got != want {
+		t.Errorf("MethodName() => got %s, want %s", got, want)
+	}
+
+	// The instances have the fields of the generated instances, in the same order.
+	got := reflect.TypeOf(ti.NewInstance()).Elem()
+	want := reflect.TypeOf(metric.Instance{})
+	if got.NumField() != want.NumField() {
+		t.Fatalf("NewInstance() => got %d fields, want %d", got.NumField(), want.NumField())
+	}
+	for i := 0; i < want.NumField(); i++ {
+		if got.Field(i).Name != want.Field(i).Name || got.Field(i).Type != want.Field(i).Type {
+			t.Errorf("NewInstance() => got field %s %v, want %s %v",
+				got.Field(i).Name, got.Field(i).Type, want.Field(i).Name, want.Field(i).Type)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	apa, err := ioutil.ReadFile(apaDescriptorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(metricDescriptorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fds := &descriptor.FileDescriptorSet{}
+	if err = proto.Unmarshal(b, fds); err != nil {
+		t.Fatal(err)
+	}
+	// Drop the dependencies of the template.
+	for _, f := range fds.File {
+		if strings.HasSuffix(f.GetName(), "template.proto") && f.GetPackage() == "metric" {
+			fds.File = []*descriptor.FileDescriptorProto{f}
+			break
+		}
+	}
+	unresolved, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{"invalid", []byte("invalid"), "invalid descriptor set"},
+		{"attribute generator", apa, "attribute generator templates cannot be loaded at runtime"},
+		{"unresolved types", unresolved, "is not defined in the descriptor set"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := New(c.b); err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("New() => got error %v, want %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestCreateInstanceBuilder(t *testing.T) {
+	ti := load(t, metricDescriptorSet)
+	expb := compiled.NewBuilder(expr.NewFinder(attrs))
+
+	p := mustParams(t, `{
+		"value": "request.size",
+		"dimensions": {"source": "source.name", "latency": "response.latency"},
+		"monitoredResourceType": "\"container\""
+	}`)
+	build, err := ti.CreateInstanceBuilder("requestsize.metric.istio-system", p, expb)
+	if err != nil {
+		t.Fatalf("CreateInstanceBuilder() => unexpected error: %v", err)
+	}
+
+	bag := attribute.GetFakeMutableBagForTesting(map[string]interface{}{
+		"request.size":     int64(1024),
+		"source.name":      "productpage",
+		"response.latency": 10 * time.Millisecond,
+	})
+	inst, err := build(bag)
+	if err != nil {
+		t.Fatalf("build() => unexpected error: %v", err)
+	}
+
+	// The instances are decoded as the generated instances by out-of-process adapters.
+	want := &metric.Instance{
+		Name:  "requestsize.metric.istio-system",
+		Value: int64(1024),
+		Dimensions: map[string]interface{}{
+			"source":  "productpage",
+			"latency": 10 * time.Millisecond,
+		},
+		MonitoredResourceType: "container",
+	}
+	b, err := remote.MarshalRequest(ti, &remote.Request{Instances: []interface{}{inst}})
+	if err != nil {
+		t.Fatalf("MarshalRequest() => unexpected error: %v", err)
+	}
+	r, err := remote.UnmarshalRequest(metricInfo, b)
+	if err != nil {
+		t.Fatalf("UnmarshalRequest() => unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(r.Instances[0], want) {
+		t.Errorf("UnmarshalRequest() =>\ngot  %#v\nwant %#v", r.Instances[0], want)
+	}
+
+	// Evaluation errors are reported with the path of the field.
+	if _, err = build(attribute.GetFakeMutableBagForTesting(map[string]interface{}{})); err == nil ||
+		!strings.Contains(err.Error(), "requestsize.metric.istio-system") {
+		t.Errorf("build() => got error %v, want evaluation error", err)
+	}
+
+	if build, err = ti.CreateInstanceBuilder("nil", nil, expb); err != nil {
+		t.Fatalf("CreateInstanceBuilder() => unexpected error: %v", err)
+	}
+	if inst, err = build(bag); inst != nil || err != nil {
+		t.Errorf("build() => got (%v, %v), want (nil, nil)", inst, err)
+	}
+}
+
+func TestResources(t *testing.T) {
+	ti := load(t, reportDescriptorSet)
+	expb := compiled.NewBuilder(expr.NewFinder(attrs))
+
+	p := mustParams(t, `{
+		"value": "request.size",
+		"dimensions": {"source": "source.name"},
+		"int64Primitive": "request.size",
+		"boolPrimitive": "true",
+		"doublePrimitive": "1.5",
+		"stringPrimitive": "source.name",
+		"timeStamp": "request.time",
+		"duration": "response.latency",
+		"res1": {
+			"value": "request.size",
+			"int64Primitive": "request.size",
+			"res2": {"value": "source.name", "ipAddr": "source.ip"},
+			"res2Map": {"a": {"int64Primitive": "request.size"}}
+		}
+	}`)
+	build, err := ti.CreateInstanceBuilder("i1", p, expb)
+	if err != nil {
+		t.Fatalf("CreateInstanceBuilder() => unexpected error: %v", err)
+	}
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	inst, err := build(attribute.GetFakeMutableBagForTesting(map[string]interface{}{
+		"request.size":     int64(10),
+		"request.time":     now,
+		"response.latency": time.Second,
+		"source.name":      "productpage",
+		"source.ip":        []byte(net.ParseIP("10.0.0.1")),
+	}))
+	if err != nil {
+		t.Fatalf("build() => unexpected error: %v", err)
+	}
+
+	v := reflect.ValueOf(inst).Elem()
+	checks := map[string]interface{}{
+		"Name":            "i1",
+		"Int64Primitive":  int64(10),
+		"BoolPrimitive":   true,
+		"DoublePrimitive": 1.5,
+		"StringPrimitive": "productpage",
+		"TimeStamp":       now,
+		"Duration":        time.Second,
+	}
+	for name, want := range checks {
+		if got := v.FieldByName(name).Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("field %s => got %v, want %v", name, got, want)
+		}
+	}
+
+	res1 := v.FieldByName("Res1").Elem()
+	if got := res1.FieldByName("Int64Primitive").Interface(); got != int64(10) {
+		t.Errorf("field Res1.Int64Primitive => got %v, want 10", got)
+	}
+	res2 := res1.FieldByName("Res2").Elem()
+	if got := res2.FieldByName("IpAddr").Interface(); !reflect.DeepEqual(got, net.IP(net.ParseIP("10.0.0.1"))) {
+		t.Errorf("field Res1.Res2.IpAddr => got %v", got)
+	}
+	m := res1.FieldByName("Res2Map")
+	if m.Len() != 1 || m.MapIndex(reflect.ValueOf("a")).Elem().FieldByName("Int64Primitive").Interface() != int64(10) {
+		t.Errorf("field Res1.Res2Map => got %v", m.Interface())
+	}
+
+	// Instances with resources can be sent to out-of-process adapters.
+	b, err := remote.MarshalRequest(ti, &remote.Request{Instances: []interface{}{inst}})
+	if err != nil {
+		t.Fatalf("MarshalRequest() => unexpected error: %v", err)
+	}
+	r, err := remote.UnmarshalRequest(ti, b)
+	if err != nil {
+		t.Fatalf("UnmarshalRequest() => unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(r.Instances[0], inst) {
+		t.Errorf("UnmarshalRequest() =>\ngot  %#v\nwant %#v", r.Instances[0], inst)
+	}
+}
+
+func TestCreateInstanceBuilderErrors(t *testing.T) {
+	ti := load(t, metricDescriptorSet)
+	expb := compiled.NewBuilder(expr.NewFinder(attrs))
+
+	cases := []struct {
+		name   string
+		params proto.Message
+		want   string
+	}{
+		{"unknown field", mustParams(t, `{"value": "request.size", "foo": "bar"}`), "unknown fields [foo]"},
+		{"type mismatch", mustParams(t, `{"monitoredResourceType": "request.size"}`), "instance field type mismatch"},
+		{"invalid expression", mustParams(t, `{"value": "request.size ++"}`), "Value"},
+		{"not an expression", mustParams(t, `{"value": 1}`), "expected an expression"},
+		{"not an object", mustParams(t, `{"dimensions": "source.name"}`), "expected an object"},
+		{"params type", &types.StringValue{}, "unexpected instance params type"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ti.CreateInstanceBuilder("i1", c.params, expb); err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("CreateInstanceBuilder() => got error %v, want %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestInferType(t *testing.T) {
+	ti := load(t, reportDescriptorSet)
+	tEvalFn := func(text string) (pb.ValueType, error) {
+		if a, found := attrs[text]; found {
+			return a.ValueType, nil
+		}
+		return pb.STRING, nil
+	}
+
+	got, err := ti.InferType(mustParams(t, `{
+		"value": "request.size",
+		"dimensions": {"source": "source.name"},
+		"res1": {"res2": {"ipAddr": "source.ip"}}
+	}`), tEvalFn)
+	if err != nil {
+		t.Fatalf("InferType() => unexpected error: %v", err)
+	}
+
+	want := mustParams(t, `{
+		"value": "INT64",
+		"dimensions": {"source": "STRING"},
+		"res1": {"res2": {"ip_addr": "IP_ADDRESS"}}
+	}`)
+	if !proto.Equal(got, want) {
+		t.Errorf("InferType() => got %v, want %v", got, want)
+	}
+
+	if _, err = ti.InferType(mustParams(t, `{"int64Primitive": "source.name"}`), tEvalFn); err == nil ||
+		!strings.Contains(err.Error(), "Int64Primitive") {
+		t.Errorf("InferType() => got error %v, want type error", err)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamic
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "istio.io/api/mixer/v1/config/descriptor"
+	"istio.io/istio/mixer/pkg/adapter"
+	"istio.io/istio/mixer/pkg/adapter/remote"
+	"istio.io/istio/mixer/tools/codegen/pkg/modelgen"
+)
+
+type (
+	// fieldKind determines how the value of a field is built from the instance params.
+	fieldKind int
+
+	// field describes a field of the template message or of a resource message.
+	field struct {
+		protoName string
+		jsonName  string
+		goName    string
+
+		// index of the field in the struct type of the message.
+		index int
+
+		kind fieldKind
+
+		// typ is the type of the values of expression fields, or of the map values of expression map fields.
+		typ reflect.Type
+
+		// valueType is the type the expressions of the field must have. VALUE_TYPE_UNSPECIFIED allows any type.
+		valueType pb.ValueType
+
+		// msg is the resource message of resource fields.
+		msg *message
+	}
+
+	// message describes the template message or a resource message, and the struct type of its values.
+	message struct {
+		name   string
+		typ    reflect.Type
+		fields []*field
+	}
+
+	// messages creates the messages of a template model.
+	messages struct {
+		model    *modelgen.Model
+		created  map[string]*message
+		creating map[string]bool
+	}
+
+	scalarType struct {
+		typ       reflect.Type
+		valueType pb.ValueType
+	}
+)
+
+const (
+	// An expression, e.g. `value: request.size`.
+	exprField fieldKind = iota
+	// A map of expressions, e.g. `dimensions: {source: source.name}`.
+	exprMapField
+	// A resource message, whose fields are given as a nested object.
+	resourceField
+	// A repeated resource message, given as a list of nested objects.
+	resourceListField
+	// A map of resource messages, given as an object of nested objects.
+	resourceMapField
+)
+
+var (
+	ifaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+	// scalarTypes maps the proto types that are allowed in templates to the types of the instance fields, as in
+	// the code generated by mixgenproc.
+	scalarTypes = map[string]scalarType{
+		"string":                               {reflect.TypeOf(""), pb.STRING},
+		"int64":                                {reflect.TypeOf(int64(0)), pb.INT64},
+		"double":                               {reflect.TypeOf(float64(0)), pb.DOUBLE},
+		"bool":                                 {reflect.TypeOf(false), pb.BOOL},
+		"istio.mixer.v1.template.TimeStamp":    {reflect.TypeOf(time.Time{}), pb.TIMESTAMP},
+		"istio.mixer.v1.template.Duration":     {reflect.TypeOf(time.Duration(0)), pb.DURATION},
+		"istio.mixer.v1.template.IPAddress":    {reflect.TypeOf(net.IP{}), pb.IP_ADDRESS},
+		"istio.mixer.v1.template.DNSName":      {reflect.TypeOf(adapter.DNSName("")), pb.DNS_NAME},
+		"istio.mixer.v1.template.EmailAddress": {reflect.TypeOf(adapter.EmailAddress("")), pb.EMAIL_ADDRESS},
+		"istio.mixer.v1.template.Uri":          {reflect.TypeOf(adapter.URI("")), pb.URI},
+	}
+)
+
+func newMessages(model *modelgen.Model) *messages {
+	return &messages{
+		model:    model,
+		created:  make(map[string]*message),
+		creating: make(map[string]bool),
+	}
+}
+
+// root returns the message of the template, whose struct type is the type of the instances.
+func (ms *messages) root() (*message, error) {
+	return ms.message(ms.model.PackageName+".Template", &ms.model.TemplateMessage, true)
+}
+
+func (ms *messages) resource(name string) (*message, error) {
+	short := strings.TrimPrefix(name, ms.model.PackageName+".")
+	for i, r := range ms.model.ResourceMessages {
+		if r.Name == short {
+			return ms.message(name, &ms.model.ResourceMessages[i], false)
+		}
+	}
+	return nil, fmt.Errorf("resource message %s is not defined in the package of the template", name)
+}
+
+func (ms *messages) message(name string, info *modelgen.MessageInfo, root bool) (*message, error) {
+	if m, found := ms.created[name]; found {
+		return m, nil
+	}
+	if ms.creating[name] {
+		return nil, fmt.Errorf("recursive message %s is not supported", name)
+	}
+	ms.creating[name] = true
+	defer delete(ms.creating, name)
+
+	m := &message{name: name}
+	var sfs []reflect.StructField
+	if root {
+		sfs = append(sfs, reflect.StructField{
+			Name: "Name",
+			Type: reflect.TypeOf(""),
+			Tag:  tag("bytes", remote.NameFieldNum, "opt", "name"),
+		})
+	}
+
+	for _, fi := range info.Fields {
+		f, wire, err := ms.field(fi)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %v", info.Name, fi.ProtoName, err)
+		}
+		num, err := strconv.Atoi(fi.Number)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: invalid field number %s", info.Name, fi.ProtoName, fi.Number)
+		}
+
+		label := "opt"
+		if f.kind == exprMapField || f.kind == resourceListField || f.kind == resourceMapField {
+			label = "rep"
+		}
+
+		f.index = len(sfs)
+		sfs = append(sfs, reflect.StructField{
+			Name: fi.GoName,
+			Type: f.goType(),
+			Tag:  tag(wire, num, label, fi.ProtoName),
+		})
+		m.fields = append(m.fields, f)
+	}
+
+	m.typ = reflect.StructOf(sfs)
+	ms.created[name] = m
+	return m, nil
+}
+
+// field returns the field for the field info, and the protobuf wire type of its values.
+func (ms *messages) field(fi modelgen.FieldInfo) (*field, string, error) {
+	f := &field{
+		protoName: fi.ProtoName,
+		jsonName:  strings.ToLower(fi.GoName[:1]) + fi.GoName[1:],
+		goName:    fi.GoName,
+	}
+
+	t := fi.ProtoType
+	switch {
+	case t.IsMap && t.MapValue.IsResourceMessage:
+		f.kind = resourceMapField
+		t = *t.MapValue
+	case t.IsMap:
+		f.kind = exprMapField
+		t = *t.MapValue
+	case t.IsResourceMessage && t.IsRepeated:
+		f.kind = resourceListField
+		t.Name = strings.TrimPrefix(t.Name, "repeated ")
+	case t.IsResourceMessage:
+		f.kind = resourceField
+	case t.IsRepeated:
+		return nil, "", fmt.Errorf("repeated field of type %s is not supported", t.Name)
+	default:
+		f.kind = exprField
+	}
+
+	switch {
+	case t.IsResourceMessage:
+		msg, err := ms.resource(t.Name)
+		if err != nil {
+			return nil, "", err
+		}
+		f.msg = msg
+		return f, "bytes", nil
+	case t.IsValueType:
+		f.typ = ifaceType
+		f.valueType = pb.VALUE_TYPE_UNSPECIFIED
+		return f, "bytes", nil
+	}
+
+	st, found := scalarTypes[t.Name]
+	if !found {
+		return nil, "", fmt.Errorf("type %s is not supported", t.Name)
+	}
+	f.typ = st.typ
+	f.valueType = st.valueType
+
+	wire := "bytes"
+	switch st.valueType {
+	case pb.INT64, pb.BOOL:
+		wire = "varint"
+	case pb.DOUBLE:
+		wire = "fixed64"
+	}
+	return f, wire, nil
+}
+
+// goType returns the type of the field in the struct type of its message.
+func (f *field) goType() reflect.Type {
+	switch f.kind {
+	case exprMapField:
+		return reflect.MapOf(reflect.TypeOf(""), f.typ)
+	case resourceField:
+		return reflect.PtrTo(f.msg.typ)
+	case resourceListField:
+		return reflect.SliceOf(reflect.PtrTo(f.msg.typ))
+	case resourceMapField:
+		return reflect.MapOf(reflect.TypeOf(""), reflect.PtrTo(f.msg.typ))
+	}
+	return f.typ
+}
+
+// new returns a pointer to a new value of the message.
+func (m *message) new() reflect.Value {
+	return reflect.New(m.typ)
+}
+
+// lookup returns the field with the given proto or JSON name.
+func (m *message) lookup(name string) *field {
+	for _, f := range m.fields {
+		if f.protoName == name || f.jsonName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func tag(wire string, num int, label string, name string) reflect.StructTag {
+	return reflect.StructTag(fmt.Sprintf(`protobuf:"%s,%d,%s,name=%s"`, wire, num, label, name))
+}
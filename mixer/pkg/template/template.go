@@ -163,6 +163,9 @@ type (
 		finder expr.AttributeDescriptorFinder,
 		expb *compiled.ExpressionBuilder) (map[string]compiled.Expression, error)
 
+	// NewInstanceFn returns a new, empty instance object of the template (e.g. &metric.Instance{}).
+	NewInstanceFn func() interface{}
+
 	// OutputMapperFn maps the results of an APA output bag, with "$out"s, by processing it through
 	// AttributeBindings.
 	//
@@ -187,6 +190,7 @@ type (
 		BldrInterfaceName       string
 		HndlrInterfaceName      string
 		CtrCfg                  proto.Message
+		NewInstance             NewInstanceFn
 		InferType               InferTypeFn
 		SetType                 SetTypeFn
 		BuilderSupportsTemplate BuilderSupportsTemplateFn
@@ -121,6 +121,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR,
 			BldrInterfaceName:  adapter_template_kubernetes.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: adapter_template_kubernetes.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &adapter_template_kubernetes.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(adapter_template_kubernetes.HandlerBuilder)
 				return ok
@@ -784,6 +787,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_REPORT,
 			BldrInterfaceName:  servicecontrolreport.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: servicecontrolreport.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &servicecontrolreport.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(servicecontrolreport.HandlerBuilder)
 				return ok
@@ -1255,6 +1261,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_CHECK,
 			BldrInterfaceName:  apikey.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: apikey.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &apikey.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(apikey.HandlerBuilder)
 				return ok
@@ -1517,6 +1526,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_CHECK,
 			BldrInterfaceName:  authorization.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: authorization.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &authorization.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(authorization.HandlerBuilder)
 				return ok
@@ -1956,6 +1968,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_CHECK,
 			BldrInterfaceName:  checknothing.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: checknothing.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &checknothing.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(checknothing.HandlerBuilder)
 				return ok
@@ -2093,6 +2108,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_CHECK,
 			BldrInterfaceName:  listentry.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: listentry.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &listentry.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(listentry.HandlerBuilder)
 				return ok
@@ -2255,6 +2273,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_REPORT,
 			BldrInterfaceName:  logentry.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: logentry.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &logentry.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(logentry.HandlerBuilder)
 				return ok
@@ -2516,6 +2537,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_REPORT,
 			BldrInterfaceName:  metric.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: metric.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &metric.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(metric.HandlerBuilder)
 				return ok
@@ -2746,6 +2770,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_QUOTA,
 			BldrInterfaceName:  quota.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: quota.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &quota.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(quota.HandlerBuilder)
 				return ok
@@ -2902,6 +2929,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_REPORT,
 			BldrInterfaceName:  reportnothing.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: reportnothing.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &reportnothing.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(reportnothing.HandlerBuilder)
 				return ok
@@ -3048,6 +3078,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_REPORT,
 			BldrInterfaceName:  tracespan.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: tracespan.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &tracespan.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(tracespan.HandlerBuilder)
 				return ok
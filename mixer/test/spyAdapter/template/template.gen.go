@@ -101,6 +101,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR,
 			BldrInterfaceName:  sampleapa.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: sampleapa.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &sampleapa.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(sampleapa.HandlerBuilder)
 				return ok
@@ -522,6 +525,9 @@ var (
 			Variety:            adptTmpl.TEMPLATE_VARIETY_REPORT,
 			BldrInterfaceName:  samplereport.TemplateName + "." + "HandlerBuilder",
 			HndlrInterfaceName: samplereport.TemplateName + "." + "Handler",
+			NewInstance: func() interface{} {
+				return &samplereport.Instance{}
+			},
 			BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
 				_, ok := hndlrBuilder.(samplereport.HandlerBuilder)
 				return ok
@@ -28,6 +28,7 @@ import (
 func withArgs(args []string, errorf func(format string, a ...interface{})) {
 	var outInterfaceFile string
 	var oAugmentedTmplFile string
+	var oServiceFile string
 	var mappings []string
 
 	rootCmd := cobra.Command{
@@ -56,13 +57,20 @@ func withArgs(args []string, errorf func(format string, a ...interface{})) {
 			if err != nil {
 				errorf("Invalid path %s: %v", oAugmentedTmplFile, err)
 			}
+			if oServiceFile != "" {
+				oServiceFile, err = filepath.Abs(oServiceFile)
+				if err != nil {
+					errorf("Invalid path %s: %v", oServiceFile, err)
+				}
+			}
 			importMapping := make(map[string]string)
 			for _, maps := range mappings {
 				m := strings.Split(maps, ":")
 				importMapping[strings.TrimSpace(m[0])] = strings.TrimSpace(m[1])
 			}
 
-			generator := interfacegen.Generator{OutInterfacePath: outInterfaceFile, OAugmentedTmplPath: oAugmentedTmplFile, ImptMap: importMapping}
+			generator := interfacegen.Generator{OutInterfacePath: outInterfaceFile, OAugmentedTmplPath: oAugmentedTmplFile,
+				OServicePath: oServiceFile, ImptMap: importMapping}
 			if err := generator.Generate(args[0]); err != nil {
 				errorf("%v", err)
 			}
@@ -77,6 +85,9 @@ func withArgs(args []string, errorf func(format string, a ...interface{})) {
 	rootCmd.PersistentFlags().StringVarP(&oAugmentedTmplFile, "output_template", "t", "./generated_template.proto", "Output "+
 		"path for augmented template file.")
 
+	rootCmd.PersistentFlags().StringVarP(&oServiceFile, "output_service", "s", "", "Output "+
+		"path for the gRPC service definition that out-of-process adapters implement. Not generated if empty.")
+
 	rootCmd.PersistentFlags().StringArrayVarP(&mappings, "importmapping",
 		"m", []string{},
 		"colon separated mapping of proto import to Go package names."+
@@ -118,6 +118,9 @@ var (
             Variety:   adptTmpl.{{.VarietyName}},
             BldrInterfaceName:  {{.GoPackageName}}.TemplateName + "." + "HandlerBuilder",
             HndlrInterfaceName: {{.GoPackageName}}.TemplateName + "." + "Handler",
+            NewInstance: func() interface{} {
+                return &{{.GoPackageName}}.Instance{}
+            },
             BuilderSupportsTemplate: func(hndlrBuilder adapter.HandlerBuilder) bool {
                 _, ok := hndlrBuilder.({{.GoPackageName}}.HandlerBuilder)
                 return ok
@@ -39,13 +39,33 @@ const (
 	goFileImportFmt            = `"%s"`
 	protoFileImportFmt         = `import "%s";`
 	protoValueTypeImport       = "mixer/v1/config/descriptor/value_type.proto"
+	serviceMsgSuffix           = "Msg"
+	remoteValueMsg             = "istio.mixer.adapter.remote.Value"
+	fullProtoNameOfTimeStamp   = "istio.mixer.v1.template.TimeStamp"
+	fullProtoNameOfDuration    = "istio.mixer.v1.template.Duration"
+	protoTimestampImport       = "google/protobuf/timestamp.proto"
+	protoDurationImport        = "google/protobuf/duration.proto"
+	attributeGeneratorVariety  = "TEMPLATE_VARIETY_ATTRIBUTE_GENERATOR"
 )
 
+// serviceTypes maps the standard istio types to the types that carry them over the wire to out-of-process adapters.
+var serviceTypes = map[string]string{
+	fullProtoNameOfTimeStamp:               "google.protobuf.Timestamp",
+	fullProtoNameOfDuration:                "google.protobuf.Duration",
+	"istio.mixer.v1.template.IPAddress":    "bytes",
+	"istio.mixer.v1.template.DNSName":      "string",
+	"istio.mixer.v1.template.EmailAddress": "string",
+	"istio.mixer.v1.template.Uri":          "string",
+}
+
 // Generator generates Go interfaces for adapters to implement for a given Template.
 type Generator struct {
 	OutInterfacePath   string
 	OAugmentedTmplPath string
-	ImptMap            map[string]string
+	// OServicePath is the path of the gRPC service definition for out-of-process adapters. The service
+	// is only generated if the path is set, and never for attribute generating templates.
+	OServicePath string
+	ImptMap      map[string]string
 }
 
 func toProtoMap(k string, v string) string {
@@ -88,6 +108,13 @@ func (g *Generator) Generate(fdsFile string) error {
 		return err
 	}
 
+	var serviceData []byte
+	if g.OServicePath != "" && model.VarietyName != attributeGeneratorVariety {
+		if serviceData, err = g.getServiceProtoContent(model); err != nil {
+			return err
+		}
+	}
+
 	// Everything succeeded, now write to the file.
 	f1, err := os.Create(g.OutInterfacePath)
 	if err != nil {
@@ -112,6 +139,21 @@ func (g *Generator) Generate(fdsFile string) error {
 		return err
 	}
 
+	if serviceData == nil {
+		return nil
+	}
+
+	f3, err := os.Create(g.OServicePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f3.Close() }() // nolint: gas
+	if _, err = f3.Write(serviceData); err != nil {
+		_ = f3.Close()           // nolint: gas
+		_ = os.Remove(f3.Name()) // nolint: gas
+		return err
+	}
+
 	return nil
 }
 
@@ -234,6 +276,73 @@ func (g *Generator) getAugmentedProtoContent(model *modelgen.Model) ([]byte, err
 	return bytes.Replace(tmplBuf.Bytes(), []byte("$$additional_imports$$"), []byte(strings.Join(imports, "\n")), 1), nil
 }
 
+func (g *Generator) getServiceProtoContent(model *modelgen.Model) ([]byte, error) {
+	imports := make([]string, 0)
+	re := regexp.MustCompile(`(?i)` + model.PackageName + "\\.")
+
+	addImport := func(imprt string) {
+		imptStm := fmt.Sprintf(protoFileImportFmt, imprt)
+		if !contains(imports, imptStm) {
+			imports = append(imports, imptStm)
+		}
+	}
+
+	var serviceType stringifyFn
+	serviceType = func(protoType modelgen.TypeInfo) string {
+		if protoType.IsMap {
+			return toProtoMap(serviceType(*protoType.MapKey), serviceType(*protoType.MapValue))
+		}
+		if protoType.IsRepeated {
+			elem := protoType
+			elem.IsRepeated = false
+			elem.Name = strings.TrimPrefix(protoType.Name, "repeated ")
+			return "repeated " + serviceType(elem)
+		}
+		if protoType.IsValueType {
+			return remoteValueMsg
+		}
+		if protoType.IsResourceMessage {
+			return re.ReplaceAllString(protoType.Name, "") + serviceMsgSuffix
+		}
+		if t, ok := serviceTypes[protoType.Name]; ok {
+			return t
+		}
+		return protoType.Name
+	}
+
+	serviceTmpl, err := template.New("ServiceTmpl").Funcs(
+		template.FuncMap{
+			"serviceType": serviceType,
+			"reportTypeUsed": func(ti modelgen.TypeInfo) string {
+				if ti.IsMap {
+					ti = *ti.MapValue
+				}
+				switch strings.TrimPrefix(ti.Name, "repeated ") {
+				case fullProtoNameOfTimeStamp:
+					addImport(protoTimestampImport)
+				case fullProtoNameOfDuration:
+					addImport(protoDurationImport)
+				}
+				// do nothing, just record the import so that we can add them later (only for the types that got printed)
+				return ""
+			},
+			"getResourceMessageMsgName": func(s string) string {
+				return s + serviceMsgSuffix
+			},
+		},
+	).Parse(tmpl.ServiceTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load template: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err = serviceTmpl.Execute(buf, model); err != nil {
+		return nil, fmt.Errorf("cannot execute the template with the given data: %v", err)
+	}
+
+	return bytes.Replace(buf.Bytes(), []byte("$$additional_imports$$"), []byte(strings.Join(imports, "\n")), 1), nil
+}
+
 func getFileDescSet(path string) (*descriptor.FileDescriptorSet, error) {
 	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
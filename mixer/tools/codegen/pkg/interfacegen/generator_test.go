@@ -37,20 +37,25 @@ func TestGenerator_Generate(t *testing.T) {
 	}
 
 	tests := []struct {
-		name, descriptor, wantIntFace, wantProto string
+		name, descriptor, wantIntFace, wantProto, wantService string
 	}{
 		{"Report", "testdata/report/template_proto.descriptor_set",
 			"testdata/report/template_handler.gen.go.golden",
-			"testdata/report/template_instance.proto.golden"},
+			"testdata/report/template_instance.proto.golden",
+			"testdata/report/template_handler_service.proto.golden"},
 		{"Quota", "testdata/quota/template_proto.descriptor_set",
 			"testdata/quota/template_handler.gen.go.golden",
-			"testdata/quota/template_instance.proto.golden"},
+			"testdata/quota/template_instance.proto.golden",
+			"testdata/quota/template_handler_service.proto.golden"},
 		{"Check", "testdata/check/template_proto.descriptor_set",
 			"testdata/check/template_handler.gen.go.golden",
-			"testdata/check/template_instance.proto.golden"},
+			"testdata/check/template_instance.proto.golden",
+			"testdata/check/template_handler_service.proto.golden"},
+		// attribute generating templates have no service for out-of-process adapters.
 		{"APA", "testdata/apa/template_proto.descriptor_set",
 			"testdata/apa/template_handler.gen.go.golden",
-			"testdata/apa/template_instance.proto.golden"},
+			"testdata/apa/template_instance.proto.golden",
+			""},
 	}
 	for _, v := range tests {
 		t.Run(v.name, func(t *testing.T) {
@@ -63,6 +68,9 @@ func TestGenerator_Generate(t *testing.T) {
 				t.Fatal(err)
 			}
 
+			oService := path.Join(os.TempDir(), v.name+"_"+"template_handler_service.proto")
+			_ = os.Remove(oService)
+
 			defer func() {
 				if !t.Failed() {
 					if removeErr := os.Remove(oIntface.Name()); removeErr != nil {
@@ -71,10 +79,11 @@ func TestGenerator_Generate(t *testing.T) {
 					if removeErr := os.Remove(oTmpl.Name()); removeErr != nil {
 						t.Logf("Could not remove temporary file %s: %v", oTmpl.Name(), removeErr)
 					}
+					_ = os.Remove(oService)
 				}
 			}()
 
-			g := Generator{OutInterfacePath: oIntface.Name(), OAugmentedTmplPath: oTmpl.Name(), ImptMap: importmap}
+			g := Generator{OutInterfacePath: oIntface.Name(), OAugmentedTmplPath: oTmpl.Name(), OServicePath: oService, ImptMap: importmap}
 
 			if err := g.Generate(v.descriptor); err != nil {
 				t.Fatalf("Generate(%s) produced an error: %v", v.descriptor, err)
@@ -87,6 +96,16 @@ func TestGenerator_Generate(t *testing.T) {
 			if same := fileCompare(oTmpl.Name(), v.wantProto, t.Errorf, true); !same {
 				t.Errorf("File %s does not match baseline %s.", oTmpl.Name(), v.wantProto)
 			}
+
+			if v.wantService == "" {
+				if _, err := os.Stat(oService); !os.IsNotExist(err) {
+					t.Errorf("Generate(%s) produced a service definition %s; want none", v.descriptor, oService)
+				}
+				return
+			}
+			if same := fileCompare(oService, v.wantService, t.Errorf, true); !same {
+				t.Errorf("File %s does not match baseline %s.", oService, v.wantService)
+			}
 		})
 	}
 }
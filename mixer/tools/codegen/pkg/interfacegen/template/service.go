@@ -0,0 +1,90 @@
+package template
+
+// ServiceTemplate defines the gRPC service that out-of-process adapters implement
+// to process instances of a given template.
+var ServiceTemplate = `// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// THIS FILE IS AUTOMATICALLY GENERATED.
+
+syntax = "proto3";
+
+package {{.PackageName}};
+
+import "google/protobuf/any.proto";
+import "mixer/pkg/adapter/remote/remote.proto";
+$$additional_imports$$
+
+// HandlerService must be implemented by out-of-process adapters if they want to
+// process data associated with the '{{.TemplateName}}' template.
+//
+// Mixer calls the service at request time in order to dispatch created instances
+// to the adapter, whenever a rule refers to a 'remote' handler.
+service HandlerService {
+  // Handle is called by Mixer at request time to deliver instances to the adapter.
+  {{if eq .VarietyName "TEMPLATE_VARIETY_CHECK" -}}
+  rpc Handle(HandleRequest) returns (istio.mixer.adapter.remote.CheckResult);
+  {{- else if eq .VarietyName "TEMPLATE_VARIETY_QUOTA" -}}
+  rpc Handle(HandleRequest) returns (istio.mixer.adapter.remote.QuotaResult);
+  {{- else -}}
+  rpc Handle(HandleRequest) returns (istio.mixer.adapter.remote.ReportResult);
+  {{- end}}
+}
+
+// HandleRequest carries the instances of the '{{.TemplateName}}' template to the adapter.
+message HandleRequest {
+  {{if eq .VarietyName "TEMPLATE_VARIETY_REPORT" -}}
+  // The instances to process.
+  repeated InstanceMsg instances = 1;
+  {{- else -}}
+  // The instance to process.
+  InstanceMsg instance = 1;
+  {{- end}}
+
+  // The adapter-specific configuration, as specified in the params of the remote handler.
+  google.protobuf.Any adapter_config = 2;
+  {{if eq .VarietyName "TEMPLATE_VARIETY_QUOTA"}}
+  // Identifies the quota allocation, so that retries of the same allocation can be deduplicated.
+  string dedup_id = 3;
+  {{end}}
+  // The inferred types of the instances that Mixer dispatches to the handler, keyed by instance name.
+  // Each type is a '{{.PackageName}}.Type' message.
+  map<string, google.protobuf.Any> instance_types = 4;
+  {{if eq .VarietyName "TEMPLATE_VARIETY_QUOTA"}}
+  // The arguments of the quota allocation.
+  istio.mixer.adapter.remote.QuotaRequest quota_request = 5;
+  {{end}}
+}
+
+{{.Comment}}
+{{.TemplateMessage.Comment}}
+message InstanceMsg {
+  // Name of the instance as specified in configuration.
+  string name = 72295727;
+  {{range .TemplateMessage.Fields}}
+  {{.Comment}}
+  {{serviceType .ProtoType}} {{.ProtoName}} = {{.Number}};{{reportTypeUsed .ProtoType}}
+  {{end}}
+}
+
+{{range .ResourceMessages}}
+{{.Comment}}
+message {{getResourceMessageMsgName .Name}} {
+  {{range .Fields}}
+  {{.Comment}}
+  {{serviceType .ProtoType}} {{.ProtoName}} = {{.Number}};{{reportTypeUsed .ProtoType}}
+  {{end}}
+}
+{{end}}
+`